@@ -0,0 +1,359 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Package abi encodes and decodes the parameters smart contract functions are
+// invoked with, such that clients lay out parameters exactly as smart contracts
+// expect to read them.
+//
+// Parameters are laid out one after the other with no padding, using the same
+// layout the Rust smart contract SDK reads parameters with:
+//
+//	bool                      1 byte, 0 for false and 1 for true
+//	int8, uint8               1 byte
+//	int16, uint16             2 bytes, little-endian
+//	int32, uint32             4 bytes, little-endian
+//	int64, uint64             8 bytes, little-endian
+//	string, []byte            length as a uint32, followed by its bytes
+//	other slices              length as a uint32, followed by its elements
+//	arrays, such as [32]byte  its elements, with no length
+//	structs                   its exported fields, in the order they are declared
+//
+// Fields of structs tagged with `abi:"-"` are neither encoded nor decoded. Types
+// whose size varies across platforms, such as int and uint, may not be encoded.
+package abi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/pkg/errors"
+	"io"
+	"math"
+	"reflect"
+)
+
+var byteType = reflect.TypeOf(byte(0))
+
+var (
+	ErrUnsupportedType = errors.New("abi: unsupported type")
+	ErrTrailingBytes   = errors.New("abi: trailing bytes left after decoding")
+)
+
+// Marshal encodes values one after the other as parameters for a smart
+// contract function.
+func Marshal(values ...interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+
+	for _, v := range values {
+		if err := encode(&buf, reflect.ValueOf(v)); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes parameters for a smart contract function into the values
+// pointed to by ptrs, in order. It returns ErrTrailingBytes should buf not be
+// entirely consumed.
+func Unmarshal(buf []byte, ptrs ...interface{}) error {
+	r := bytes.NewReader(buf)
+
+	for _, ptr := range ptrs {
+		v := reflect.ValueOf(ptr)
+
+		if v.Kind() != reflect.Ptr || v.IsNil() {
+			return errors.Errorf("abi: may only decode into a non-nil pointer, but got %T", ptr)
+		}
+
+		if err := decode(r, v.Elem()); err != nil {
+			return err
+		}
+	}
+
+	if r.Len() > 0 {
+		return errors.Wrapf(ErrTrailingBytes, "%d bytes left", r.Len())
+	}
+
+	return nil
+}
+
+func encode(w *bytes.Buffer, v reflect.Value) error {
+	var buf [8]byte
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			w.WriteByte(1)
+		} else {
+			w.WriteByte(0)
+		}
+	case reflect.Int8:
+		w.WriteByte(byte(v.Int()))
+	case reflect.Int16:
+		binary.LittleEndian.PutUint16(buf[:2], uint16(v.Int()))
+		w.Write(buf[:2])
+	case reflect.Int32:
+		binary.LittleEndian.PutUint32(buf[:4], uint32(v.Int()))
+		w.Write(buf[:4])
+	case reflect.Int64:
+		binary.LittleEndian.PutUint64(buf[:8], uint64(v.Int()))
+		w.Write(buf[:8])
+	case reflect.Uint8:
+		w.WriteByte(byte(v.Uint()))
+	case reflect.Uint16:
+		binary.LittleEndian.PutUint16(buf[:2], uint16(v.Uint()))
+		w.Write(buf[:2])
+	case reflect.Uint32:
+		binary.LittleEndian.PutUint32(buf[:4], uint32(v.Uint()))
+		w.Write(buf[:4])
+	case reflect.Uint64:
+		binary.LittleEndian.PutUint64(buf[:8], v.Uint())
+		w.Write(buf[:8])
+	case reflect.String:
+		if err := encodeLength(w, v.Len()); err != nil {
+			return err
+		}
+
+		w.WriteString(v.String())
+	case reflect.Slice:
+		if err := encodeLength(w, v.Len()); err != nil {
+			return err
+		}
+
+		if v.Type().Elem() == byteType {
+			w.Write(v.Bytes())
+			return nil
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			if err := encode(w, v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := encode(w, v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		for _, i := range fields(v.Type()) {
+			if err := encode(w, v.Field(i)); err != nil {
+				return errors.Wrapf(err, "failed to encode field %s of %s", v.Type().Field(i).Name, v.Type())
+			}
+		}
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return errors.Wrapf(ErrUnsupportedType, "may not encode nil %s", v.Type())
+		}
+
+		return encode(w, v.Elem())
+	default:
+		if !v.IsValid() {
+			return errors.Wrap(ErrUnsupportedType, "may not encode nil")
+		}
+
+		return errors.Wrapf(ErrUnsupportedType, "may not encode %s", v.Type())
+	}
+
+	return nil
+}
+
+func encodeLength(w *bytes.Buffer, n int) error {
+	if uint64(n) > math.MaxUint32 {
+		return errors.Errorf("abi: length %d does not fit in a uint32", n)
+	}
+
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], uint32(n))
+	w.Write(buf[:])
+
+	return nil
+}
+
+func decode(r *bytes.Reader, v reflect.Value) error {
+	var buf [8]byte
+
+	read := func(n int) error {
+		if _, err := io.ReadFull(r, buf[:n]); err != nil {
+			return errors.Wrapf(io.ErrUnexpectedEOF, "abi: failed to decode %s", v.Type())
+		}
+
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if err := read(1); err != nil {
+			return err
+		}
+
+		if buf[0] > 1 {
+			return errors.Errorf("abi: bool must be encoded as either 0 or 1, but got %d", buf[0])
+		}
+
+		v.SetBool(buf[0] == 1)
+	case reflect.Int8:
+		if err := read(1); err != nil {
+			return err
+		}
+
+		v.SetInt(int64(int8(buf[0])))
+	case reflect.Int16:
+		if err := read(2); err != nil {
+			return err
+		}
+
+		v.SetInt(int64(int16(binary.LittleEndian.Uint16(buf[:2]))))
+	case reflect.Int32:
+		if err := read(4); err != nil {
+			return err
+		}
+
+		v.SetInt(int64(int32(binary.LittleEndian.Uint32(buf[:4]))))
+	case reflect.Int64:
+		if err := read(8); err != nil {
+			return err
+		}
+
+		v.SetInt(int64(binary.LittleEndian.Uint64(buf[:8])))
+	case reflect.Uint8:
+		if err := read(1); err != nil {
+			return err
+		}
+
+		v.SetUint(uint64(buf[0]))
+	case reflect.Uint16:
+		if err := read(2); err != nil {
+			return err
+		}
+
+		v.SetUint(uint64(binary.LittleEndian.Uint16(buf[:2])))
+	case reflect.Uint32:
+		if err := read(4); err != nil {
+			return err
+		}
+
+		v.SetUint(uint64(binary.LittleEndian.Uint32(buf[:4])))
+	case reflect.Uint64:
+		if err := read(8); err != nil {
+			return err
+		}
+
+		v.SetUint(binary.LittleEndian.Uint64(buf[:8]))
+	case reflect.String:
+		b, err := decodeBytes(r, v)
+		if err != nil {
+			return err
+		}
+
+		v.SetString(string(b))
+	case reflect.Slice:
+		if v.Type().Elem() == byteType {
+			b, err := decodeBytes(r, v)
+			if err != nil {
+				return err
+			}
+
+			v.SetBytes(b)
+			return nil
+		}
+
+		if err := read(4); err != nil {
+			return err
+		}
+
+		n := int(binary.LittleEndian.Uint32(buf[:4]))
+
+		// Every element takes up at least a byte, which bounds how much is
+		// allocated for a malformed length.
+		if n > r.Len() {
+			return errors.Wrapf(io.ErrUnexpectedEOF, "abi: %s has %d elements, but only %d bytes are left", v.Type(), n, r.Len())
+		}
+
+		slice := reflect.MakeSlice(v.Type(), n, n)
+
+		for i := 0; i < n; i++ {
+			if err := decode(r, slice.Index(i)); err != nil {
+				return err
+			}
+		}
+
+		v.Set(slice)
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := decode(r, v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		for _, i := range fields(v.Type()) {
+			if err := decode(r, v.Field(i)); err != nil {
+				return errors.Wrapf(err, "failed to decode field %s of %s", v.Type().Field(i).Name, v.Type())
+			}
+		}
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+
+		return decode(r, v.Elem())
+	default:
+		return errors.Wrapf(ErrUnsupportedType, "may not decode %s", v.Type())
+	}
+
+	return nil
+}
+
+func decodeBytes(r *bytes.Reader, v reflect.Value) ([]byte, error) {
+	var buf [4]byte
+
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return nil, errors.Wrapf(io.ErrUnexpectedEOF, "abi: failed to decode length of %s", v.Type())
+	}
+
+	n := binary.LittleEndian.Uint32(buf[:])
+
+	if int64(n) > int64(r.Len()) {
+		return nil, errors.Wrapf(io.ErrUnexpectedEOF, "abi: %s is %d bytes, but only %d bytes are left", v.Type(), n, r.Len())
+	}
+
+	b := make([]byte, n)
+	_, _ = io.ReadFull(r, b)
+
+	return b, nil
+}
+
+// fields returns the indices of the fields of a struct which are encoded,
+// being those which are exported and not tagged with `abi:"-"`.
+func fields(t reflect.Type) []int {
+	indices := make([]int, 0, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if field.PkgPath != "" || field.Tag.Get("abi") == "-" {
+			continue
+		}
+
+		indices = append(indices, i)
+	}
+
+	return indices
+}
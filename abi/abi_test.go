@@ -0,0 +1,94 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package abi
+
+import (
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"testing"
+)
+
+type transfer struct {
+	Recipient [4]byte
+	Amount    uint64
+	Memo      string
+	Internal  bool `abi:"-"`
+	unused    int
+}
+
+func TestMarshal(t *testing.T) {
+	buf, err := Marshal(true, int8(-1), uint16(0x0102), int32(-2), uint64(3), "hi", []byte{0xAA}, [2]byte{1, 2}, []uint16{4})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{
+		1,
+		0xFF,
+		0x02, 0x01,
+		0xFE, 0xFF, 0xFF, 0xFF,
+		3, 0, 0, 0, 0, 0, 0, 0,
+		2, 0, 0, 0, 'h', 'i',
+		1, 0, 0, 0, 0xAA,
+		1, 2,
+		1, 0, 0, 0, 4, 0,
+	}, buf)
+
+	// Fields which are unexported or tagged to be skipped are not encoded.
+
+	buf, err = Marshal(&transfer{Recipient: [4]byte{1, 2, 3, 4}, Amount: 5, Memo: "a", Internal: true, unused: 6})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3, 4, 5, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 'a'}, buf)
+
+	_, err = Marshal(1)
+	assert.Equal(t, ErrUnsupportedType, errors.Cause(err))
+
+	_, err = Marshal(struct{ A map[string]string }{})
+	assert.Equal(t, ErrUnsupportedType, errors.Cause(err))
+}
+
+func TestUnmarshal(t *testing.T) {
+	expected := transfer{Recipient: [4]byte{1, 2, 3, 4}, Amount: 5, Memo: "a"}
+
+	buf, err := Marshal(expected, int16(-7), []uint32{8, 9})
+	assert.NoError(t, err)
+
+	var (
+		decoded transfer
+		a       int16
+		b       []uint32
+	)
+
+	assert.NoError(t, Unmarshal(buf, &decoded, &a, &b))
+	assert.Equal(t, expected, decoded)
+	assert.EqualValues(t, -7, a)
+	assert.Equal(t, []uint32{8, 9}, b)
+
+	assert.Error(t, Unmarshal(buf, decoded))
+	assert.Equal(t, ErrTrailingBytes, errors.Cause(Unmarshal(buf, &decoded)))
+	assert.Equal(t, io.ErrUnexpectedEOF, errors.Cause(Unmarshal(buf[:len(buf)-1], &decoded, &a, &b)))
+
+	// Lengths larger than what is left to decode are rejected before being
+	// allocated.
+
+	var s string
+	assert.Equal(t, io.ErrUnexpectedEOF, errors.Cause(Unmarshal([]byte{0xFF, 0xFF, 0xFF, 0xFF}, &s)))
+
+	var flag bool
+	assert.Error(t, Unmarshal([]byte{2}, &flag))
+}
@@ -0,0 +1,201 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/perlin-network/wavelet/log"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrRoundNotFinalized         = errors.New("round has not been finalized yet")
+	ErrAccountHistoryUnavailable = errors.New("account history is not available for round")
+)
+
+// SizeAccountDelta is the size of a marshaled account delta in bytes.
+const SizeAccountDelta = 8 + 8 + 8 + 8 + 8
+
+// AccountDelta records how the balance and stake of an account changed as a
+// result of a round being finalized.
+type AccountDelta struct {
+	Round uint64
+
+	PrevBalance uint64
+	Balance     uint64
+
+	PrevStake uint64
+	Stake     uint64
+}
+
+func (d AccountDelta) Marshal() []byte {
+	var buf [SizeAccountDelta]byte
+
+	binary.BigEndian.PutUint64(buf[0:8], d.Round)
+	binary.BigEndian.PutUint64(buf[8:16], d.PrevBalance)
+	binary.BigEndian.PutUint64(buf[16:24], d.Balance)
+	binary.BigEndian.PutUint64(buf[24:32], d.PrevStake)
+	binary.BigEndian.PutUint64(buf[32:40], d.Stake)
+
+	return buf[:]
+}
+
+func UnmarshalAccountDelta(buf []byte) (AccountDelta, error) {
+	var d AccountDelta
+
+	if len(buf) != SizeAccountDelta {
+		return d, errors.Errorf("account delta must be %d bytes, but got %d bytes", SizeAccountDelta, len(buf))
+	}
+
+	d.Round = binary.BigEndian.Uint64(buf[0:8])
+	d.PrevBalance = binary.BigEndian.Uint64(buf[8:16])
+	d.Balance = binary.BigEndian.Uint64(buf[16:24])
+	d.PrevStake = binary.BigEndian.Uint64(buf[24:32])
+	d.Stake = binary.BigEndian.Uint64(buf[32:40])
+
+	return d, nil
+}
+
+// AccountHistory returns up to limit changes made to the balance and stake of
+// the account id in rounds after the round since, ordered from oldest to
+// newest. Changes are only recorded from when a node starts finalizing or
+// replicating rounds, and not for the genesis round.
+func (l *Ledger) AccountHistory(id AccountID, since uint64, limit int) ([]AccountDelta, error) {
+	return LoadAccountHistory(l.accounts.kv, id, since, limit)
+}
+
+// AccountState is the balance and stake of an account as of a round.
+type AccountState struct {
+	Round uint64
+
+	Balance uint64
+	Stake   uint64
+}
+
+// AccountStateAt resolves the balance and stake the account id had once the
+// round with index round was finalized, by reverting the changes made to the
+// account in the rounds since from its latest state. It returns
+// ErrRoundNotFinalized should the round not have been finalized yet, and
+// ErrAccountHistoryUnavailable should the node not have stored the changes
+// made in every round since. See AccountHistoryFrom.
+func (l *Ledger) AccountStateAt(id AccountID, round uint64) (AccountState, error) {
+	state := AccountState{Round: round}
+
+	// The latest state is read before the changes made since, such that the
+	// changes made by a round which is finalized in the meantime are accounted
+	// for.
+	snapshot := l.accounts.Snapshot()
+
+	if latest := l.rounds.Latest().Index; round > latest {
+		return state, errors.Wrapf(ErrRoundNotFinalized, "round %d is after the latest round %d", round, latest)
+	}
+
+	if from := l.AccountHistoryFrom(); round < from {
+		return state, errors.Wrapf(ErrAccountHistoryUnavailable, "round %d is before round %d, which account history is available from", round, from)
+	}
+
+	deltas, err := LoadAccountHistory(l.accounts.kv, id, round, 1)
+	if err != nil {
+		return state, err
+	}
+
+	if len(deltas) > 0 {
+		state.Balance = deltas[0].PrevBalance
+		state.Stake = deltas[0].PrevStake
+	} else {
+		state.Balance, _ = ReadAccountBalance(snapshot, id)
+		state.Stake, _ = ReadAccountStake(snapshot, id)
+	}
+
+	return state, nil
+}
+
+// AccountHistoryFrom returns the index of the earliest round the state of
+// accounts may be resolved at. Changes made to accounts are only stored for
+// rounds the node finalizes or replicates, and thus history starts over from
+// the latest round whenever the node syncs to, or is restored from a backup
+// of, a later round.
+func (l *Ledger) AccountHistoryFrom() uint64 {
+	from, err := LoadAccountHistoryFrom(l.accounts.kv)
+	if err != nil {
+		return l.rounds.Latest().Index
+	}
+
+	return from
+}
+
+// restartAccountHistory has account history start over from the round with
+// index round, after the ledger has skipped ahead to it without storing the
+// changes made in the rounds skipped.
+func (l *Ledger) restartAccountHistory(round uint64) {
+	l.historyLock.Lock()
+	defer l.historyLock.Unlock()
+
+	if err := StoreAccountHistoryFrom(l.accounts.kv, round); err != nil {
+		logger := log.Node()
+		logger.Warn().Err(err).Uint64("round", round).Msg("Failed to restart account history.")
+	}
+}
+
+// accountDeltas diffs the balances and stakes of all accounts which were
+// modified in snapshot since the round lastRound against those in prev.
+func accountDeltas(prev, snapshot *avl.Tree, lastRound, round uint64) map[AccountID]AccountDelta {
+	balanceKey := append(keyAccounts[:], keyAccountBalance[:]...)
+	stakeKey := append(keyAccounts[:], keyAccountStake[:]...)
+
+	deltas := make(map[AccountID]AccountDelta)
+
+	var id AccountID
+
+	snapshot.IterateLeafDiff(lastRound, func(key, value []byte) bool {
+		switch {
+		case bytes.HasPrefix(key, balanceKey):
+			copy(id[:], key[len(balanceKey):])
+		case bytes.HasPrefix(key, stakeKey):
+			copy(id[:], key[len(stakeKey):])
+		default:
+			return true
+		}
+
+		if _, seen := deltas[id]; seen {
+			return true
+		}
+
+		delta := AccountDelta{Round: round}
+		delta.PrevBalance, _ = ReadAccountBalance(prev, id)
+		delta.Balance, _ = ReadAccountBalance(snapshot, id)
+		delta.PrevStake, _ = ReadAccountStake(prev, id)
+		delta.Stake, _ = ReadAccountStake(snapshot, id)
+
+		deltas[id] = delta
+
+		return true
+	})
+
+	for id, delta := range deltas {
+		if delta.PrevBalance == delta.Balance && delta.PrevStake == delta.Stake {
+			delete(deltas, id)
+		}
+	}
+
+	return deltas
+}
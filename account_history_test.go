@@ -0,0 +1,77 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAccountStateAt(t *testing.T) {
+	ledger := newTestLedger(t)
+
+	var account AccountID
+	account[0] = 1
+
+	advance := func(index, balance, stake uint64) {
+		latest := ledger.Rounds().Latest()
+
+		snapshot := ledger.Snapshot()
+		snapshot.SetViewID(index)
+		WriteAccountBalance(snapshot, account, balance)
+		WriteAccountStake(snapshot, account, stake)
+
+		round := NewRound(index, snapshot.Checksum(), 0, latest.End, latest.End)
+		assert.NoError(t, ledger.applyReplicatedRound(round, snapshot.DumpDiff(latest.Index), nil))
+	}
+
+	assert.EqualValues(t, 0, ledger.AccountHistoryFrom())
+
+	advance(1, 100, 0)
+	advance(2, 70, 5)
+	advance(3, 70, 5)
+
+	for _, want := range []AccountState{
+		{Round: 0, Balance: 0, Stake: 0},
+		{Round: 1, Balance: 100, Stake: 0},
+		{Round: 2, Balance: 70, Stake: 5},
+		{Round: 3, Balance: 70, Stake: 5},
+	} {
+		state, err := ledger.AccountStateAt(account, want.Round)
+		assert.NoError(t, err)
+		assert.Equal(t, want, state)
+	}
+
+	_, err := ledger.AccountStateAt(account, 4)
+	assert.Equal(t, ErrRoundNotFinalized, errors.Cause(err))
+
+	// Skipping ahead has history start over from the round skipped to.
+	advance(5, 10, 5)
+
+	assert.EqualValues(t, 5, ledger.AccountHistoryFrom())
+
+	_, err = ledger.AccountStateAt(account, 3)
+	assert.Equal(t, ErrAccountHistoryUnavailable, errors.Cause(err))
+
+	state, err := ledger.AccountStateAt(account, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, AccountState{Round: 5, Balance: 10, Stake: 5}, state)
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"encoding/binary"
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/pkg/errors"
+)
+
+// AccountProof proves the nonce, balance, stake and reward of an account as of
+// a round against the Merkle root of the state of the round. Each proof is
+// that of the value stored under the respective key of the account, or of the
+// key being absent. See avl.VerifyProof.
+type AccountProof struct {
+	Round   Round
+	Account AccountID
+
+	Nonce   [][]byte
+	Balance [][]byte
+	Stake   [][]byte
+	Reward  [][]byte
+}
+
+// ProvenAccount is the state of an account proven by an AccountProof.
+type ProvenAccount struct {
+	Nonce   uint64
+	Balance uint64
+	Stake   uint64
+	Reward  uint64
+}
+
+// Prove generates a proof of the state of the account id as of the latest
+// round of the ledger, which may be verified by anyone that trusts the round,
+// such as light clients.
+func (l *Ledger) Prove(id AccountID) (AccountProof, error) {
+	round, snapshot, err := l.consistentSnapshot()
+	if err != nil {
+		return AccountProof{}, err
+	}
+
+	proof := AccountProof{Round: round, Account: id}
+
+	fields := []struct {
+		key   []byte
+		proof *[][]byte
+	}{
+		{key: AccountNonceKey(id), proof: &proof.Nonce},
+		{key: AccountBalanceKey(id), proof: &proof.Balance},
+		{key: AccountStakeKey(id), proof: &proof.Stake},
+		{key: AccountRewardKey(id), proof: &proof.Reward},
+	}
+
+	for _, field := range fields {
+		if *field.proof, err = snapshot.Prove(field.key); err != nil {
+			return AccountProof{}, errors.Wrapf(err, "failed to prove state of account %x", id)
+		}
+	}
+
+	return proof, nil
+}
+
+// Verify checks the proofs against the Merkle root of the round, and returns
+// the state of the account proven. It is up to the caller to decide whether
+// or not the round is to be trusted.
+func (p AccountProof) Verify() (ProvenAccount, error) {
+	var account ProvenAccount
+
+	fields := []struct {
+		name  string
+		key   []byte
+		proof [][]byte
+		value *uint64
+	}{
+		{name: "nonce", key: AccountNonceKey(p.Account), proof: p.Nonce, value: &account.Nonce},
+		{name: "balance", key: AccountBalanceKey(p.Account), proof: p.Balance, value: &account.Balance},
+		{name: "stake", key: AccountStakeKey(p.Account), proof: p.Stake, value: &account.Stake},
+		{name: "reward", key: AccountRewardKey(p.Account), proof: p.Reward, value: &account.Reward},
+	}
+
+	for _, field := range fields {
+		buf, exists, err := avl.VerifyProof(p.Round.Merkle, field.key, field.proof)
+		if err != nil {
+			return ProvenAccount{}, errors.Wrapf(err, "invalid proof of the %s of account %x", field.name, p.Account)
+		}
+
+		if *field.value, err = decodeProvenValue(buf, exists); err != nil {
+			return ProvenAccount{}, errors.Wrapf(err, "invalid %s of account %x", field.name, p.Account)
+		}
+	}
+
+	return account, nil
+}
+
+// decodeProvenValue decodes a proven value of an account, which is zero should
+// it not be stored.
+func decodeProvenValue(buf []byte, exists bool) (uint64, error) {
+	if !exists || len(buf) == 0 {
+		return 0, nil
+	}
+
+	if len(buf) != 8 {
+		return 0, errors.Errorf("proven value must be 8 bytes, but got %d bytes", len(buf))
+	}
+
+	return binary.LittleEndian.Uint64(buf), nil
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestProveAccount(t *testing.T) {
+	l := newTestLedger(t)
+
+	var account, missing AccountID
+	account[0], missing[0] = 1, 2
+
+	snapshot := l.Snapshot()
+	snapshot.SetViewID(1)
+	WriteAccountNonce(snapshot, account, 3)
+	WriteAccountBalance(snapshot, account, 1337)
+	WriteAccountStake(snapshot, account, 42)
+
+	latest := l.Rounds().Latest()
+	round := NewRound(1, snapshot.Checksum(), 0, latest.End, latest.End)
+
+	_, err := l.rounds.Save(&round)
+	assert.NoError(t, err)
+	assert.NoError(t, l.accounts.Commit(snapshot))
+
+	proof, err := l.Prove(account)
+	assert.NoError(t, err)
+	assert.Equal(t, round.ID, proof.Round.ID)
+
+	proven, err := proof.Verify()
+	assert.NoError(t, err)
+	assert.Equal(t, ProvenAccount{Nonce: 3, Balance: 1337, Stake: 42}, proven)
+
+	// Accounts absent from the state are proven to be empty.
+	proof, err = l.Prove(missing)
+	assert.NoError(t, err)
+
+	proven, err = proof.Verify()
+	assert.NoError(t, err)
+	assert.Equal(t, ProvenAccount{}, proven)
+
+	// Proofs may not be verified against the Merkle root of another round.
+	proof, err = l.Prove(account)
+	assert.NoError(t, err)
+
+	proof.Round.Merkle = latest.Merkle
+
+	_, err = proof.Verify()
+	assert.Error(t, err)
+}
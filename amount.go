@@ -0,0 +1,147 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"github.com/pkg/errors"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// AmountDecimals is the number of decimal places a single PERL is divisible
+// into. An Amount of 1 is hence 10^-AmountDecimals PERLs.
+const AmountDecimals = 9
+
+var (
+	ErrAmountOverflow  = errors.New("amount overflows the max amount of PERLs representable")
+	ErrAmountUnderflow = errors.New("amount underflows to below zero PERLs")
+)
+
+var amountScale = uint64(math.Pow10(AmountDecimals))
+
+// Amount denotes a quantity of PERLs, counted in its smallest indivisible
+// unit. All arithmetic on amounts is checked against overflowing and
+// underflowing.
+type Amount uint64
+
+// Add returns a + b, or ErrAmountOverflow should the sum not fit in an Amount.
+func (a Amount) Add(b Amount) (Amount, error) {
+	sum, carry := bits.Add64(uint64(a), uint64(b), 0)
+	if carry != 0 {
+		return 0, ErrAmountOverflow
+	}
+
+	return Amount(sum), nil
+}
+
+// Sub returns a - b, or ErrAmountUnderflow should b be larger than a.
+func (a Amount) Sub(b Amount) (Amount, error) {
+	diff, borrow := bits.Sub64(uint64(a), uint64(b), 0)
+	if borrow != 0 {
+		return 0, ErrAmountUnderflow
+	}
+
+	return Amount(diff), nil
+}
+
+// Mul returns a * n, or ErrAmountOverflow should the product not fit in an
+// Amount.
+func (a Amount) Mul(n uint64) (Amount, error) {
+	hi, lo := bits.Mul64(uint64(a), n)
+	if hi != 0 {
+		return 0, ErrAmountOverflow
+	}
+
+	return Amount(lo), nil
+}
+
+// String formats the amount as a decimal number of PERLs, omitting any
+// trailing zeroes in its fractional part.
+func (a Amount) String() string {
+	whole, frac := uint64(a)/amountScale, uint64(a)%amountScale
+
+	if frac == 0 {
+		return strconv.FormatUint(whole, 10)
+	}
+
+	digits := strconv.FormatUint(frac, 10)
+	digits = strings.Repeat("0", AmountDecimals-len(digits)) + digits
+
+	return strconv.FormatUint(whole, 10) + "." + strings.TrimRight(digits, "0")
+}
+
+// ParseAmount parses a non-negative decimal number of PERLs such as "12" or
+// "0.5" into an Amount. Numbers with more than AmountDecimals digits after
+// the decimal point, or which are too large to be represented, are rejected.
+func ParseAmount(str string) (Amount, error) {
+	wholeStr, fracStr := str, ""
+
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		wholeStr, fracStr = str[:i], str[i+1:]
+
+		if len(fracStr) == 0 || len(fracStr) > AmountDecimals {
+			return 0, errors.Errorf("amount %q must have between 1 and %d digits after its decimal point", str, AmountDecimals)
+		}
+	}
+
+	if len(wholeStr) == 0 {
+		return 0, errors.Errorf("amount %q is missing its whole part", str)
+	}
+
+	if !isDecimalDigits(wholeStr) || !isDecimalDigits(fracStr) {
+		return 0, errors.Errorf("amount %q must only contain decimal digits and a single decimal point", str)
+	}
+
+	whole, err := strconv.ParseUint(wholeStr, 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(ErrAmountOverflow, "failed to parse amount %q", str)
+	}
+
+	var frac uint64
+
+	if len(fracStr) > 0 {
+		if frac, err = strconv.ParseUint(fracStr+strings.Repeat("0", AmountDecimals-len(fracStr)), 10, 64); err != nil {
+			return 0, errors.Wrapf(err, "failed to parse amount %q", str)
+		}
+	}
+
+	amount, err := Amount(whole).Mul(amountScale)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to parse amount %q", str)
+	}
+
+	if amount, err = amount.Add(Amount(frac)); err != nil {
+		return 0, errors.Wrapf(err, "failed to parse amount %q", str)
+	}
+
+	return amount, nil
+}
+
+func isDecimalDigits(str string) bool {
+	for i := 0; i < len(str); i++ {
+		if str[i] < '0' || str[i] > '9' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestAmountArithmetic(t *testing.T) {
+	sum, err := Amount(1).Add(2)
+	assert.NoError(t, err)
+	assert.Equal(t, Amount(3), sum)
+
+	_, err = Amount(math.MaxUint64).Add(1)
+	assert.Equal(t, ErrAmountOverflow, err)
+
+	diff, err := Amount(3).Sub(3)
+	assert.NoError(t, err)
+	assert.Equal(t, Amount(0), diff)
+
+	_, err = Amount(2).Sub(3)
+	assert.Equal(t, ErrAmountUnderflow, err)
+
+	product, err := Amount(math.MaxUint64 / 2).Mul(2)
+	assert.NoError(t, err)
+	assert.Equal(t, Amount(math.MaxUint64-1), product)
+
+	_, err = Amount(math.MaxUint64/2 + 1).Mul(2)
+	assert.Equal(t, ErrAmountOverflow, err)
+}
+
+func TestAmountString(t *testing.T) {
+	cases := []struct {
+		amount Amount
+		str    string
+	}{
+		{0, "0"},
+		{1, "0.000000001"},
+		{500000000, "0.5"},
+		{1000000000, "1"},
+		{1230000000, "1.23"},
+		{math.MaxUint64, "18446744073.709551615"},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.str, c.amount.String())
+
+		amount, err := ParseAmount(c.str)
+		assert.NoError(t, err)
+		assert.Equal(t, c.amount, amount)
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	amount, err := ParseAmount("007.10")
+	assert.NoError(t, err)
+	assert.Equal(t, Amount(7100000000), amount)
+
+	for _, str := range []string{"", ".", ".5", "1.", "1.0000000001", "-1", "+1", "1e9", "1.2.3", " 1", "18446744073.709551616", "18446744074"} {
+		_, err := ParseAmount(str)
+		assert.Error(t, err, str)
+	}
+
+	_, err = ParseAmount("18446744073.709551616")
+	assert.Equal(t, ErrAmountOverflow, errors.Cause(err))
+}
@@ -0,0 +1,211 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"github.com/perlin-network/wavelet/log"
+	"github.com/rs/zerolog"
+	"github.com/valyala/fasthttp"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultAccessLogSampleRate is the default fraction of successful requests
+	// which are written to the access log. Requests which fail are always logged.
+	DefaultAccessLogSampleRate = 0.1
+
+	redactedHeaderValue = "[REDACTED]"
+)
+
+var (
+	// Headers whose values are redacted from the access log by default, as
+	// they may carry credentials.
+	defaultAccessLogRedactedHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-API-Key"}
+
+	// Routes which are not written to the access log by default, as they are
+	// polled continuously by monitoring tools.
+	defaultAccessLogDisabledRoutes = []string{"/healthz", "/readyz"}
+)
+
+// accessLogger writes a structured entry for requests made to the gateway
+// into the API module log.
+type accessLogger struct {
+	sampleRate float64
+
+	redacted map[string]struct{}
+	disabled map[string]struct{}
+}
+
+func newAccessLogger() *accessLogger {
+	l := &accessLogger{
+		sampleRate: DefaultAccessLogSampleRate,
+		redacted:   make(map[string]struct{}),
+		disabled:   make(map[string]struct{}),
+	}
+
+	l.redact(defaultAccessLogRedactedHeaders...)
+	l.disable(defaultAccessLogDisabledRoutes...)
+
+	return l
+}
+
+func (l *accessLogger) redact(headers ...string) {
+	for _, header := range headers {
+		l.redacted[http.CanonicalHeaderKey(header)] = struct{}{}
+	}
+}
+
+func (l *accessLogger) disable(routes ...string) {
+	for _, route := range routes {
+		l.disabled[route] = struct{}{}
+	}
+}
+
+// WithAccessLogSampleRate sets the fraction of successful requests which are
+// written to the access log. Requests responded to with a 4xx or 5xx status
+// code are always logged. A rate of zero only logs failed requests.
+func WithAccessLogSampleRate(rate float64) Option {
+	return func(g *Gateway) {
+		g.accessLog.sampleRate = rate
+	}
+}
+
+// WithAccessLogRedactedHeaders redacts the values of headers from the access
+// log, in addition to the headers which are redacted by default, such as
+// Authorization and Cookie.
+func WithAccessLogRedactedHeaders(headers ...string) Option {
+	return func(g *Gateway) {
+		g.accessLog.redact(headers...)
+	}
+}
+
+// WithAccessLogDisabledRoutes stops requests made to routes from being written
+// to the access log. Routes are specified as they are registered to the router,
+// such as /tx/:id. Versioned routes are specified alongside their prefix, such
+// as /v1/tx/:id.
+func WithAccessLogDisabledRoutes(routes ...string) Option {
+	return func(g *Gateway) {
+		g.accessLog.disable(routes...)
+	}
+}
+
+// WithAccessLogEnabledRoutes has requests made to routes which are disabled by
+// default, such as the /healthz and /readyz probes, written to the access log.
+func WithAccessLogEnabledRoutes(routes ...string) Option {
+	return func(g *Gateway) {
+		for _, route := range routes {
+			delete(g.accessLog.disabled, route)
+		}
+	}
+}
+
+func (l *accessLogger) log(method, path string, next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	if _, disabled := l.disabled[path]; disabled {
+		return next
+	}
+
+	return func(ctx *fasthttp.RequestCtx) {
+		start := time.Now()
+		next(ctx)
+		latency := time.Since(start)
+
+		status := ctx.Response.StatusCode()
+
+		if status < http.StatusBadRequest && (l.sampleRate <= 0 || (l.sampleRate < 1 && rand.Float64() >= l.sampleRate)) {
+			return
+		}
+
+		logger := log.API("access")
+
+		var event *zerolog.Event
+
+		switch {
+		case status >= http.StatusInternalServerError:
+			event = logger.Error()
+		case status >= http.StatusBadRequest:
+			event = logger.Warn()
+		default:
+			event = logger.Info()
+		}
+
+		headers := zerolog.Dict()
+
+		ctx.Request.Header.VisitAll(func(key, value []byte) {
+			if _, redacted := l.redacted[http.CanonicalHeaderKey(string(key))]; redacted {
+				headers.Str(string(key), redactedHeaderValue)
+			} else {
+				headers.Bytes(string(key), value)
+			}
+		})
+
+		// The body of a streamed response is not read, as doing so would block
+		// until the stream ends. Its size is reported as its Content-Length
+		// instead, which is -1 should it not be known.
+		responseSize := ctx.Response.Header.ContentLength()
+		if !ctx.Response.IsBodyStream() {
+			responseSize = len(ctx.Response.Body())
+		}
+
+		event.
+			Str("method", method).
+			Str("route", path).
+			Bytes("path", ctx.Path()).
+			Str("query", l.query(ctx)).
+			Int("status", status).
+			Dur("latency", latency).
+			Str("remote_addr", ctx.RemoteIP().String()).
+			Int("request_size", len(ctx.Request.Body())).
+			Int("response_size", responseSize).
+			Dict("headers", headers).
+			Msg("Served API request.")
+	}
+}
+
+// query returns the query string of a request, with the values of any
+// arguments named after redacted headers redacted.
+func (l *accessLogger) query(ctx *fasthttp.RequestCtx) string {
+	args := ctx.QueryArgs()
+
+	if args.Len() == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+
+	args.VisitAll(func(key, value []byte) {
+		if b.Len() > 0 {
+			b.WriteByte('&')
+		}
+
+		b.Write(key)
+		b.WriteByte('=')
+
+		if _, redacted := l.redacted[http.CanonicalHeaderKey(string(key))]; redacted {
+			b.WriteString(redactedHeaderValue)
+		} else {
+			b.Write(value)
+		}
+	})
+
+	return b.String()
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"github.com/perlin-network/wavelet/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fastjson"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAccessLog(t *testing.T) {
+	buf := new(bytes.Buffer)
+
+	log.SetWriter("test_access_log", buf)
+	defer log.SetWriter("test_access_log", ioutil.Discard)
+
+	request := func(gateway *Gateway, path string) []*fastjson.Value {
+		buf.Reset()
+
+		req, err := http.NewRequest("GET", "http://localhost"+path, nil)
+		assert.NoError(t, err)
+
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set("X-Custom-Secret", "secret")
+
+		_, err = serve(gateway.router, req)
+		assert.NoError(t, err)
+
+		var entries []*fastjson.Value
+
+		for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+			if len(line) == 0 {
+				continue
+			}
+
+			v, err := fastjson.ParseBytes(line)
+			assert.NoError(t, err)
+
+			if string(v.GetStringBytes(log.KeyModule)) == log.ModuleAPI && string(v.GetStringBytes(log.KeyEvent)) == "access" {
+				entries = append(entries, v)
+			}
+		}
+
+		return entries
+	}
+
+	t.Run("failed requests are always logged", func(t *testing.T) {
+		gateway := New(WithAccessLogSampleRate(0))
+		gateway.setup()
+
+		entries := request(gateway, "/tx/1c331c1d")
+		if !assert.Len(t, entries, 1) {
+			return
+		}
+
+		assert.Equal(t, "/tx/:id", string(entries[0].GetStringBytes("route")))
+		assert.Equal(t, "/tx/1c331c1d", string(entries[0].GetStringBytes("path")))
+		assert.Equal(t, http.StatusBadRequest, entries[0].GetInt("status"))
+		assert.Equal(t, redactedHeaderValue, string(entries[0].GetStringBytes("headers", "Authorization")))
+
+		assert.Empty(t, request(gateway, "/healthz"))
+	})
+
+	t.Run("disabled routes are not logged", func(t *testing.T) {
+		gateway := New(WithAccessLogSampleRate(1), WithAccessLogDisabledRoutes("/tx/:id"))
+		gateway.setup()
+
+		assert.Empty(t, request(gateway, "/tx/1c331c1d"))
+		assert.Empty(t, request(gateway, "/healthz"))
+	})
+
+	t.Run("sampled requests are logged", func(t *testing.T) {
+		gateway := New(WithAccessLogSampleRate(1), WithAccessLogEnabledRoutes("/healthz"), WithAccessLogRedactedHeaders("x-custom-secret"))
+		gateway.setup()
+
+		entries := request(gateway, "/healthz")
+		if !assert.Len(t, entries, 1) {
+			return
+		}
+
+		assert.Equal(t, http.StatusOK, entries[0].GetInt("status"))
+		assert.Equal(t, redactedHeaderValue, string(entries[0].GetStringBytes("headers", "X-Custom-Secret")))
+	})
+
+	t.Run("streamed responses are logged without being read", func(t *testing.T) {
+		gateway := New(WithAccessLogSampleRate(1))
+
+		release := make(chan struct{})
+		defer close(release)
+
+		handler := gateway.accessLog.log("GET", "/stream", func(ctx *fasthttp.RequestCtx) {
+			ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+				<-release
+			})
+		})
+
+		buf.Reset()
+
+		done := make(chan struct{})
+
+		go func() {
+			handler(new(fasthttp.RequestCtx))
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(3 * time.Second):
+			t.Fatal("access log blocked reading a streamed response")
+		}
+
+		v, err := fastjson.ParseBytes(bytes.TrimSpace(buf.Bytes()))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, -1, v.GetInt("response_size"))
+	})
+}
@@ -0,0 +1,202 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/valyala/fasthttp"
+	"golang.org/x/crypto/blake2b"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strings"
+)
+
+// Scope is a set of routes of the API which an API key may be granted access to.
+type Scope string
+
+const (
+	// ScopeRead grants access to query the ledger and subscribe to events.
+	ScopeRead Scope = "read"
+
+	// ScopeSend grants access to send transactions and upload smart contracts
+	// paid for by the node.
+	ScopeSend Scope = "send"
+
+	// ScopeAdmin grants access to every route, including those which manage
+	// the node such as connecting to peers and taking backups.
+	ScopeAdmin Scope = "admin"
+)
+
+// HeaderAPIKey is the HTTP header, and gRPC metadata key, API keys are to be
+// presented under.
+const HeaderAPIKey = "X-API-Key"
+
+var (
+	ErrMissingAPIKey     = errors.New("an api key must be presented under the " + HeaderAPIKey + " header")
+	ErrInvalidAPIKey     = errors.New("invalid api key")
+	ErrInsufficientScope = errors.New("api key is not granted access to this route")
+	ErrAdminDisabled     = errors.New("admin routes are disabled unless an api key granted the " + string(ScopeAdmin) + " scope is configured")
+)
+
+// rpcScopes are the scopes required to call methods of the gRPC API. Methods
+// which are not listed require the read scope.
+var rpcScopes = map[string]Scope{
+	"/api.API/SendTransaction": ScopeSend,
+}
+
+// ParseAPIKey parses an API key alongside the scopes it is granted in the
+// format key:scope,scope, such as secret:read,send.
+func ParseAPIKey(s string) (string, []Scope, error) {
+	sep := strings.LastIndexByte(s, ':')
+	if sep <= 0 || sep == len(s)-1 {
+		return "", nil, errors.Errorf("api key %q must be in the format key:scope,scope", s)
+	}
+
+	var scopes []Scope
+
+	for _, name := range strings.Split(s[sep+1:], ",") {
+		switch scope := Scope(strings.TrimSpace(name)); scope {
+		case ScopeRead, ScopeSend, ScopeAdmin:
+			scopes = append(scopes, scope)
+		default:
+			return "", nil, errors.Errorf("unknown api key scope %q; must be one of %q, %q, or %q", name, ScopeRead, ScopeSend, ScopeAdmin)
+		}
+	}
+
+	return s[:sep], scopes, nil
+}
+
+// WithAPIKey grants key access to routes of the API under scopes. Once any
+// API key is configured, all routes other than the /healthz and /readyz probes
+// require an API key with a scope granting access to them be presented. Routes
+// under the admin scope are disabled until an API key granted it is configured.
+func WithAPIKey(key string, scopes ...Scope) Option {
+	return func(g *Gateway) {
+		id := blake2b.Sum256([]byte(key))
+
+		granted, exists := g.apiKeys[id]
+		if !exists {
+			granted = make(map[Scope]struct{})
+			g.apiKeys[id] = granted
+		}
+
+		for _, scope := range scopes {
+			granted[scope] = struct{}{}
+		}
+	}
+}
+
+// authorize checks whether or not key is granted access to routes under scope.
+// Keys are looked up by their hashes, such that looking them up does not leak
+// timing information about the keys which are configured. Should no keys be
+// configured, access is granted to all routes but those under the admin scope,
+// as the API may be reachable by anyone.
+func (g *Gateway) authorize(key string, scope Scope) error {
+	if len(g.apiKeys) == 0 {
+		if scope == ScopeAdmin {
+			return ErrAdminDisabled
+		}
+
+		return nil
+	}
+
+	if len(key) == 0 {
+		return ErrMissingAPIKey
+	}
+
+	granted, exists := g.apiKeys[blake2b.Sum256([]byte(key))]
+	if !exists {
+		return ErrInvalidAPIKey
+	}
+
+	if _, admin := granted[ScopeAdmin]; admin {
+		return nil
+	}
+
+	if _, ok := granted[scope]; !ok {
+		return ErrInsufficientScope
+	}
+
+	return nil
+}
+
+// requireScope only lets requests through which present an API key granted
+// access to routes under scope.
+func (g *Gateway) requireScope(scope Scope) middleware {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			if err := g.authorize(string(ctx.Request.Header.Peek(HeaderAPIKey)), scope); err != nil {
+				if err == ErrInsufficientScope || err == ErrAdminDisabled {
+					g.renderError(ctx, ErrForbidden(err))
+				} else {
+					g.renderError(ctx, ErrUnauthorized(err))
+				}
+
+				return
+			}
+
+			next(ctx)
+		}
+	}
+}
+
+func (g *Gateway) authorizeRPC(ctx context.Context, method string) error {
+	scope, exists := rpcScopes[method]
+	if !exists {
+		scope = ScopeRead
+	}
+
+	var key string
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(HeaderAPIKey); len(values) > 0 {
+			key = values[0]
+		}
+	}
+
+	if err := g.authorize(key, scope); err != nil {
+		if err == ErrInsufficientScope {
+			return status.Error(codes.PermissionDenied, err.Error())
+		}
+
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	return nil
+}
+
+func (g *Gateway) unaryAuthInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := g.authorizeRPC(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+func (g *Gateway) streamAuthInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := g.authorizeRPC(stream.Context(), info.FullMethod); err != nil {
+		return err
+	}
+
+	return handler(srv, stream)
+}
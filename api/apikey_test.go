@@ -0,0 +1,147 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"testing"
+)
+
+func TestParseAPIKey(t *testing.T) {
+	key, scopes, err := ParseAPIKey("se:cret:read, send")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "se:cret", key)
+		assert.Equal(t, []Scope{ScopeRead, ScopeSend}, scopes)
+	}
+
+	for _, invalid := range []string{"secret", ":read", "secret:", "secret:write"} {
+		_, _, err := ParseAPIKey(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	gateway := New(
+		WithAPIKey("reader", ScopeRead),
+		WithAPIKey("sender", ScopeSend),
+		WithAPIKey("admin", ScopeAdmin),
+	)
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	tests := []struct {
+		name     string
+		path     string
+		key      string
+		wantCode int
+	}{
+		{name: "missing key", path: "/tx/1c331c1d", wantCode: http.StatusUnauthorized},
+		{name: "invalid key", path: "/tx/1c331c1d", key: "invalid", wantCode: http.StatusUnauthorized},
+		{name: "insufficient scope", path: "/tx/1c331c1d", key: "sender", wantCode: http.StatusForbidden},
+		{name: "granted scope", path: "/tx/1c331c1d", key: "reader", wantCode: http.StatusBadRequest},
+		{name: "admin scope", path: "/tx/1c331c1d", key: "admin", wantCode: http.StatusBadRequest},
+		{name: "admin route", path: "/node/backup", key: "reader", wantCode: http.StatusForbidden},
+		{name: "probe", path: "/healthz", wantCode: http.StatusOK},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			request, err := http.NewRequest("GET", "http://localhost"+tc.path, nil)
+			assert.NoError(t, err)
+
+			if tc.key != "" {
+				request.Header.Set(HeaderAPIKey, tc.key)
+			}
+
+			w, err := serve(gateway.router, request)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantCode, w.StatusCode)
+		})
+	}
+
+	t.Run("grpc", func(t *testing.T) {
+		client, stop := newTestRPCClient(t, gateway)
+		defer stop()
+
+		_, err := client.GetAccount(context.Background(), &GetAccountRequest{})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		ctx := metadata.AppendToOutgoingContext(context.Background(), HeaderAPIKey, "sender")
+
+		_, err = client.GetAccount(ctx, &GetAccountRequest{})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+		ctx = metadata.AppendToOutgoingContext(context.Background(), HeaderAPIKey, "reader")
+
+		_, err = client.GetAccount(ctx, &GetAccountRequest{})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestAdminRoutesRequireAdminKey(t *testing.T) {
+	request := func(gateway *Gateway, path, key string) int {
+		req, err := http.NewRequest("GET", "http://localhost"+path, nil)
+		assert.NoError(t, err)
+
+		if key != "" {
+			req.Header.Set(HeaderAPIKey, key)
+		}
+
+		w, err := serve(gateway.router, req)
+		assert.NoError(t, err)
+
+		return w.StatusCode
+	}
+
+	// Without any API keys configured, only admin routes are closed off.
+	gateway := New()
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	assert.Equal(t, http.StatusBadRequest, request(gateway, "/tx/1c331c1d", ""))
+
+	for _, path := range []string{"/node/backup", "/v1/node/params", "/node/peers", "/debug/vars"} {
+		assert.Equal(t, http.StatusForbidden, request(gateway, path, ""), path)
+		assert.Equal(t, http.StatusForbidden, request(gateway, path, "anything"), path)
+	}
+
+	// Nor may they be accessed should no configured API key be granted the admin scope.
+	gateway = New(WithAPIKey("reader", ScopeRead, ScopeSend))
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	assert.Equal(t, http.StatusForbidden, request(gateway, "/node/params", "reader"))
+	assert.Equal(t, http.StatusUnauthorized, request(gateway, "/node/params", ""))
+
+	gateway = New(WithAPIKey("admin", ScopeAdmin))
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	assert.Equal(t, http.StatusOK, request(gateway, "/node/params", "admin"))
+}
@@ -70,7 +70,7 @@ var (
 		allowOrigins:     []string{"*"},
 		allowMethods:     []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodOptions},
 		allowHeaders:     []string{"*"},
-		exposeHeaders:    []string{"Link"},
+		exposeHeaders:    []string{"Link", HeaderAPIVersion, HeaderRoundIndex, HeaderRoundID, HeaderMerkleRoot},
 		allowCredentials: true,
 		maxAge:           300,
 	}
@@ -0,0 +1,149 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"github.com/perlin-network/wavelet"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/pkg/errors"
+	"github.com/valyala/fasthttp"
+	"sync"
+	"time"
+)
+
+// DefaultFaucetCooldown is the default duration an address must wait after
+// being sent PERLs by the faucet before it may request PERLs again.
+const DefaultFaucetCooldown = 1 * time.Hour
+
+// faucet hands out PERLs from the account of the node on test networks, such
+// that users may fund their own accounts. Each address may only be sent PERLs
+// once per cooldown.
+type faucet struct {
+	sync.Mutex
+
+	max      uint64
+	cooldown time.Duration
+
+	sent map[wavelet.AccountID]time.Time
+}
+
+// WithFaucet enables POST /faucet, through which anyone may have the node send
+// up to max PERLs from its own account to an address of their choosing once
+// per cooldown. It is meant for test networks only. A max of zero keeps the
+// faucet disabled, and a cooldown of zero or less keeps the default.
+func WithFaucet(max uint64, cooldown time.Duration) Option {
+	return func(g *Gateway) {
+		if max == 0 {
+			return
+		}
+
+		if cooldown <= 0 {
+			cooldown = DefaultFaucetCooldown
+		}
+
+		g.faucet = &faucet{max: max, cooldown: cooldown, sent: make(map[wavelet.AccountID]time.Time)}
+	}
+}
+
+// reserve marks recipient as having been sent PERLs at now. Should recipient
+// still be cooling down, it instead returns how long is left of its cooldown.
+func (f *faucet) reserve(recipient wavelet.AccountID, now time.Time) time.Duration {
+	f.Lock()
+	defer f.Unlock()
+
+	for id, at := range f.sent {
+		if now.Sub(at) >= f.cooldown {
+			delete(f.sent, id)
+		}
+	}
+
+	if at, exists := f.sent[recipient]; exists {
+		return f.cooldown - now.Sub(at)
+	}
+
+	f.sent[recipient] = now
+
+	return 0
+}
+
+// release lifts the cooldown on recipient, should sending it PERLs have failed.
+func (f *faucet) release(recipient wavelet.AccountID) {
+	f.Lock()
+	delete(f.sent, recipient)
+	f.Unlock()
+}
+
+func (g *Gateway) faucetTransfer(ctx *fasthttp.RequestCtx) {
+	req := new(faucetRequest)
+
+	parser := g.parserPool.Get()
+	err := req.bind(parser, ctx.PostBody())
+	g.parserPool.Put(parser)
+
+	if err != nil {
+		g.renderError(ctx, ErrBadRequest(err))
+		return
+	}
+
+	if req.amount == 0 {
+		req.amount = g.faucet.max
+	}
+
+	if req.amount > g.faucet.max {
+		g.renderError(ctx, ErrBadRequest(errors.Errorf("the faucet sends at most %d PERLs, but %d PERLs were requested", g.faucet.max, req.amount)))
+		return
+	}
+
+	if g.ledger == nil || g.keys == nil {
+		g.renderError(ctx, ErrInternal(errors.New("node is not ready to send PERLs")))
+		return
+	}
+
+	if req.recipient == g.keys.PublicKey() {
+		g.renderError(ctx, ErrBadRequest(errors.New("the faucet may not send PERLs to itself")))
+		return
+	}
+
+	if balance, _ := wavelet.ReadAccountBalance(g.ledger.Snapshot(), g.keys.PublicKey()); balance < req.amount {
+		g.renderError(ctx, ErrInternal(errors.New("the faucet has run dry")))
+		return
+	}
+
+	if wait := g.faucet.reserve(req.recipient, time.Now()); wait > 0 {
+		g.renderError(ctx, ErrTooManyRequests(errors.Errorf("recipient may only request PERLs from the faucet again in %s", wait.Round(time.Second))))
+		return
+	}
+
+	transfer := wavelet.Transfer{Recipient: req.recipient, Amount: req.amount}
+
+	tx := wavelet.AttachSenderToTransaction(
+		g.keys,
+		wavelet.NewTransaction(g.keys, sys.TagTransfer, transfer.Marshal()),
+		g.ledger.Graph().FindEligibleParents()...,
+	)
+
+	if err := g.ledger.AddTransaction(tx); err != nil && errors.Cause(err) != wavelet.ErrMissingParents {
+		g.faucet.release(req.recipient)
+		g.renderError(ctx, ErrInternal(errors.Wrap(err, "error adding your transfer to graph")))
+		return
+	}
+
+	g.render(ctx, &sendTransactionResponse{ledger: g.ledger, tx: &tx})
+}
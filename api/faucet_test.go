@@ -0,0 +1,136 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fastjson"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFaucetCooldown(t *testing.T) {
+	f := &faucet{max: 10, cooldown: time.Minute, sent: make(map[wavelet.AccountID]time.Time)}
+
+	var a, b wavelet.AccountID
+	a[0], b[0] = 1, 2
+
+	now := time.Now()
+
+	assert.Zero(t, f.reserve(a, now))
+	assert.Equal(t, 30*time.Second, f.reserve(a, now.Add(30*time.Second)))
+	assert.Zero(t, f.reserve(b, now.Add(30*time.Second)))
+
+	// Cooldowns which have lapsed are pruned.
+	assert.Zero(t, f.reserve(a, now.Add(time.Minute)))
+	assert.Len(t, f.sent, 2)
+
+	f.release(a)
+	assert.Zero(t, f.reserve(a, now.Add(time.Minute)))
+}
+
+func TestFaucet(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	publicKey := keys.PublicKey()
+	genesis := fmt.Sprintf(`{"%x": {"balance": 1000}}`, publicKey)
+
+	var recipient wavelet.AccountID
+	recipient[0] = 1
+
+	post := func(gateway *Gateway, body string) (int, []byte) {
+		w, err := serve(gateway.router, httptest.NewRequest("POST", "http://localhost/faucet", strings.NewReader(body)))
+		assert.NoError(t, err)
+
+		response, err := ioutil.ReadAll(w.Body)
+		assert.NoError(t, err)
+
+		return w.StatusCode, response
+	}
+
+	// The faucet is disabled by default.
+	gateway := New()
+	gateway.setup()
+
+	code, _ := post(gateway, fmt.Sprintf(`{"recipient": "%x"}`, recipient))
+	assert.Equal(t, http.StatusNotFound, code)
+
+	gateway = New(WithFaucet(100, time.Hour))
+	gateway.setup()
+
+	gateway.ledger = wavelet.NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), &genesis)
+	gateway.keys = keys
+
+	invalid := []string{
+		`{}`,
+		`{"recipient": "zz"}`,
+		`{"recipient": "0102"}`,
+		fmt.Sprintf(`{"recipient": "%x", "amount": 101}`, recipient),
+		fmt.Sprintf(`{"recipient": "%x", "amount": "0.000000101"}`, recipient),
+		fmt.Sprintf(`{"recipient": "%x", "amount": "1.x"}`, recipient),
+		fmt.Sprintf(`{"recipient": "%x"}`, publicKey),
+	}
+
+	for _, body := range invalid {
+		code, _ := post(gateway, body)
+		assert.Equal(t, http.StatusBadRequest, code, body)
+	}
+
+	code, response := post(gateway, fmt.Sprintf(`{"recipient": "%x"}`, recipient))
+	assert.Equal(t, http.StatusOK, code)
+
+	txID, err := hex.DecodeString(string(fastjson.GetBytes(response, "tx_id")))
+	assert.NoError(t, err)
+
+	var id wavelet.TransactionID
+	copy(id[:], txID)
+
+	tx := gateway.ledger.Graph().FindTransaction(id)
+	if assert.NotNil(t, tx) {
+		transfer, err := wavelet.ParseTransferTransaction(tx.Payload)
+		assert.NoError(t, err)
+		assert.Equal(t, recipient, transfer.Recipient)
+		assert.EqualValues(t, 100, transfer.Amount)
+	}
+
+	// The recipient must wait out its cooldown before requesting PERLs again.
+	code, _ = post(gateway, fmt.Sprintf(`{"recipient": "%x", "amount": 1}`, recipient))
+	assert.Equal(t, http.StatusTooManyRequests, code)
+
+	// The faucet may only send what the node has.
+	gateway = New(WithFaucet(10000, time.Hour))
+	gateway.setup()
+
+	gateway.ledger = wavelet.NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), &genesis)
+	gateway.keys = keys
+
+	code, _ = post(gateway, fmt.Sprintf(`{"recipient": "%x"}`, recipient))
+	assert.Equal(t, http.StatusInternalServerError, code)
+}
@@ -0,0 +1,427 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/pkg/errors"
+	"github.com/valyala/fastjson"
+	"strconv"
+	"strings"
+)
+
+// Filter expressions may be supplied by websocket subscribers to only be sent
+// events which match them, such as:
+//
+//	tag == transfer && depth > 100
+//	account_id in ["ab12...", "cd34..."] || !(balance < 1000)
+//
+// The left-hand side of a comparison is the name of a field of an event, with
+// fields of nested objects separated by dots. The right-hand side is either a
+// number, a quoted string, true or false, or the name of a transaction tag.
+// Comparisons against fields an event does not have are always false.
+
+// Max length of a filter expression in bytes.
+const maxFilterLength = 1024
+
+// Names of transaction tags which may be compared against in filter expressions.
+var filterTags = map[string]byte{
+	"nop":             sys.TagNop,
+	"transfer":        sys.TagTransfer,
+	"contract":        sys.TagContract,
+	"stake":           sys.TagStake,
+	"batch":           sys.TagBatch,
+	"contract_admin":  sys.TagContractAdmin,
+	"delegate":        sys.TagDelegate,
+	"delegated":       sys.TagDelegated,
+	"update_contract": sys.TagUpdateContract,
+	"create_token":    sys.TagCreateToken,
+	"transfer_token":  sys.TagTransferToken,
+}
+
+type filterExpr interface {
+	eval(v *fastjson.Value) bool
+}
+
+type filterAnd struct{ left, right filterExpr }
+
+func (e filterAnd) eval(v *fastjson.Value) bool { return e.left.eval(v) && e.right.eval(v) }
+
+type filterOr struct{ left, right filterExpr }
+
+func (e filterOr) eval(v *fastjson.Value) bool { return e.left.eval(v) || e.right.eval(v) }
+
+type filterNot struct{ expr filterExpr }
+
+func (e filterNot) eval(v *fastjson.Value) bool { return !e.expr.eval(v) }
+
+// filterLiteral is a value compared against in a filter expression. Numbers
+// keep their text, such that integers may be compared exactly.
+type filterLiteral struct {
+	typ fastjson.Type
+	str string
+	num float64
+}
+
+type filterCompare struct {
+	path []string
+	op   string
+
+	values []filterLiteral // Only one value unless op is "in".
+}
+
+func (e filterCompare) eval(v *fastjson.Value) bool {
+	field := v.Get(e.path...)
+	if field == nil {
+		return false
+	}
+
+	if e.op == "in" {
+		for _, value := range e.values {
+			if cmp, ok := compareFilterLiteral(field, value); ok && cmp == 0 {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	cmp, ok := compareFilterLiteral(field, e.values[0])
+	if !ok {
+		return false
+	}
+
+	switch e.op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+
+	return false
+}
+
+// compareFilterLiteral compares a field of an event against a literal. It
+// returns false if they are of different types. Strings and booleans are
+// only ever equal or not equal to one another.
+func compareFilterLiteral(field *fastjson.Value, value filterLiteral) (int, bool) {
+	switch value.typ {
+	case fastjson.TypeNumber:
+		if field.Type() != fastjson.TypeNumber {
+			return 0, false
+		}
+
+		// Compare integers exactly, as amounts of PERLs may not be exactly
+		// represented as floats.
+		if a, err := field.Uint64(); err == nil {
+			if b, err := strconv.ParseUint(value.str, 10, 64); err == nil {
+				switch {
+				case a < b:
+					return -1, true
+				case a > b:
+					return 1, true
+				}
+
+				return 0, true
+			}
+		}
+
+		a := field.GetFloat64()
+
+		switch {
+		case a < value.num:
+			return -1, true
+		case a > value.num:
+			return 1, true
+		}
+
+		return 0, true
+	case fastjson.TypeString:
+		if field.Type() != fastjson.TypeString {
+			return 0, false
+		}
+
+		if string(field.GetStringBytes()) == value.str {
+			return 0, true
+		}
+
+		return 1, true
+	default:
+		if field.Type() != fastjson.TypeTrue && field.Type() != fastjson.TypeFalse {
+			return 0, false
+		}
+
+		if field.Type() == value.typ {
+			return 0, true
+		}
+
+		return 1, true
+	}
+}
+
+type filterParser struct {
+	src string
+	pos int
+}
+
+// parseFilter parses a filter expression. An empty expression matches all events.
+func parseFilter(src string) (filterExpr, error) {
+	if len(src) > maxFilterLength {
+		return nil, errors.Errorf("filter must be at most %d bytes long", maxFilterLength)
+	}
+
+	p := &filterParser{src: src}
+
+	if p.skip(); p.pos == len(p.src) {
+		return nil, nil
+	}
+
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid filter")
+	}
+
+	if p.skip(); p.pos != len(p.src) {
+		return nil, errors.Errorf("invalid filter: unexpected %q at position %d", p.src[p.pos:], p.pos)
+	}
+
+	return expr, nil
+}
+
+func (p *filterParser) skip() {
+	for p.pos < len(p.src) && strings.IndexByte(" \t\r\n", p.src[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// consume skips over token should it be next, and returns whether it was.
+func (p *filterParser) consume(token string) bool {
+	p.skip()
+
+	if strings.HasPrefix(p.src[p.pos:], token) {
+		p.pos += len(token)
+		return true
+	}
+
+	return false
+}
+
+func (p *filterParser) parseOr() (filterExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.consume("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = filterOr{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filterExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.consume("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		left = filterAnd{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (filterExpr, error) {
+	if p.consume("!") {
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return filterNot{expr: expr}, nil
+	}
+
+	if p.consume("(") {
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if !p.consume(")") {
+			return nil, errors.Errorf("expected ')' at position %d", p.pos)
+		}
+
+		return expr, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *filterParser) parseComparison() (filterExpr, error) {
+	var path []string
+
+	for {
+		name := p.parseName()
+		if name == "" {
+			return nil, errors.Errorf("expected a field name at position %d", p.pos)
+		}
+
+		path = append(path, name)
+
+		if p.pos == len(p.src) || p.src[p.pos] != '.' {
+			break
+		}
+
+		p.pos++
+	}
+
+	e := filterCompare{path: path}
+
+	// Operators which are prefixes of others are checked for last.
+	for _, op := range []string{"==", "!=", ">=", "<=", ">", "<", "in"} {
+		if p.consume(op) {
+			e.op = op
+			break
+		}
+	}
+
+	switch e.op {
+	case "":
+		return nil, errors.Errorf("expected a comparison operator at position %d", p.pos)
+	case "in":
+		if !p.consume("[") {
+			return nil, errors.Errorf("expected '[' at position %d", p.pos)
+		}
+
+		for !p.consume("]") {
+			if len(e.values) > 0 && !p.consume(",") {
+				return nil, errors.Errorf("expected ',' or ']' at position %d", p.pos)
+			}
+
+			value, err := p.parseLiteral()
+			if err != nil {
+				return nil, err
+			}
+
+			e.values = append(e.values, value)
+		}
+	default:
+		value, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+
+		if value.typ != fastjson.TypeNumber && e.op != "==" && e.op != "!=" {
+			return nil, errors.Errorf("operator %q may only be applied to numbers", e.op)
+		}
+
+		e.values = append(e.values, value)
+	}
+
+	return e, nil
+}
+
+func (p *filterParser) parseName() string {
+	p.skip()
+
+	start := p.pos
+
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+
+		if c != '_' && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (p.pos == start || c < '0' || c > '9') {
+			break
+		}
+
+		p.pos++
+	}
+
+	return p.src[start:p.pos]
+}
+
+func (p *filterParser) parseLiteral() (filterLiteral, error) {
+	p.skip()
+
+	if p.pos == len(p.src) {
+		return filterLiteral{}, errors.New("unexpected end of filter")
+	}
+
+	start := p.pos
+
+	switch c := p.src[p.pos]; {
+	case c == '"' || c == '\'':
+		end := strings.IndexByte(p.src[p.pos+1:], c)
+		if end == -1 {
+			return filterLiteral{}, errors.Errorf("unterminated string at position %d", start)
+		}
+
+		p.pos += end + 2
+
+		return filterLiteral{typ: fastjson.TypeString, str: p.src[start+1 : p.pos-1]}, nil
+	case c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		p.pos++
+
+		for p.pos < len(p.src) && strings.IndexByte("0123456789.eE+-", p.src[p.pos]) >= 0 {
+			p.pos++
+		}
+
+		num, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+		if err != nil {
+			return filterLiteral{}, errors.Errorf("invalid number %q at position %d", p.src[start:p.pos], start)
+		}
+
+		return filterLiteral{typ: fastjson.TypeNumber, str: p.src[start:p.pos], num: num}, nil
+	}
+
+	switch name := p.parseName(); name {
+	case "true":
+		return filterLiteral{typ: fastjson.TypeTrue}, nil
+	case "false":
+		return filterLiteral{typ: fastjson.TypeFalse}, nil
+	case "":
+		return filterLiteral{}, errors.Errorf("expected a value at position %d", start)
+	default:
+		tag, exists := filterTags[name]
+		if !exists {
+			return filterLiteral{}, errors.Errorf("unknown value %q at position %d", name, start)
+		}
+
+		return filterLiteral{typ: fastjson.TypeNumber, str: strconv.Itoa(int(tag)), num: float64(tag)}, nil
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fastjson"
+	"strings"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	event := fastjson.MustParse(`{
+		"tag": 1,
+		"sender_id": "ab12",
+		"amount": 18446744073709551615,
+		"ratio": 0.5,
+		"paused": false,
+		"round": {"index": 42}
+	}`)
+
+	tests := []struct {
+		filter string
+		match  bool
+	}{
+		{filter: ``, match: true},
+		{filter: `tag == transfer`, match: true},
+		{filter: `tag == stake`, match: false},
+		{filter: `tag != 3 && sender_id == "ab12"`, match: true},
+		{filter: `amount > 18446744073709551614`, match: true},
+		{filter: `amount >= 18446744073709551615 && amount <= 18446744073709551615`, match: true},
+		{filter: `ratio < 0.75 && ratio > 0.25`, match: true},
+		{filter: `paused == false`, match: true},
+		{filter: `round.index == 42`, match: true},
+		{filter: `sender_id in ['cd34', 'ab12']`, match: true},
+		{filter: `tag in [stake, batch]`, match: false},
+		{filter: `tag == stake || (tag == transfer && !(paused == true))`, match: true},
+		{filter: `missing == 1`, match: false},
+		{filter: `missing != 1`, match: false},
+		{filter: `sender_id == 1`, match: false},
+	}
+
+	for _, tc := range tests {
+		expr, err := parseFilter(tc.filter)
+		if !assert.NoError(t, err, tc.filter) {
+			continue
+		}
+
+		if expr == nil {
+			assert.True(t, tc.match, tc.filter)
+			continue
+		}
+
+		assert.Equal(t, tc.match, expr.eval(event), tc.filter)
+	}
+
+	for _, invalid := range []string{
+		`tag`,
+		`tag ==`,
+		`tag == unknown`,
+		`== 1`,
+		`sender_id > "ab"`,
+		`(tag == 1`,
+		`tag == 1 tag == 2`,
+		`tag in [1 2]`,
+		`sender_id == "ab`,
+		`tag == ` + strings.Repeat("1", maxFilterLength),
+	} {
+		_, err := parseFilter(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,743 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"github.com/pkg/errors"
+	"github.com/valyala/fastjson"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// A subset of GraphQL sufficient for clients to query for the ledger state
+// and the relationships between its accounts, transactions, contracts and
+// rounds in a single request. Only query operations are supported, with
+// fields, aliases, arguments and variables. Fragments, directives and
+// introspection are not supported.
+
+const (
+	// Max number of levels deep selection sets of a query may be nested.
+	graphqlMaxDepth = 8
+
+	// Max number of fields which may be resolved executing a single query.
+	graphqlMaxComplexity = 5000
+)
+
+var (
+	ErrGraphQLMaxComplexity = errors.Errorf("query exceeds the max complexity of %d fields", graphqlMaxComplexity)
+)
+
+type gqlTokenKind byte
+
+const (
+	gqlEOF gqlTokenKind = iota
+	gqlPunct
+	gqlName
+	gqlInt
+	gqlFloat
+	gqlString
+)
+
+type gqlToken struct {
+	kind  gqlTokenKind
+	value string
+	pos   int
+}
+
+func (t gqlToken) String() string {
+	if t.kind == gqlEOF {
+		return "end of query"
+	}
+
+	return strconv.Quote(t.value)
+}
+
+// gqlField is a field selected by a query, with its arguments resolved
+// against the variables of the query.
+type gqlField struct {
+	alias string
+	name  string
+
+	args       map[string]interface{}
+	selections []*gqlField
+}
+
+func isGraphQLNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isGraphQLDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func lexGraphQL(src string) ([]gqlToken, error) {
+	var tokens []gqlToken
+
+	for i := 0; i < len(src); {
+		c := src[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(src) && src[i] != '\n' && src[i] != '\r' {
+				i++
+			}
+		case strings.IndexByte("{}()[]:!$=@", c) >= 0:
+			tokens = append(tokens, gqlToken{kind: gqlPunct, value: src[i : i+1], pos: i})
+			i++
+		case c == '.':
+			if !strings.HasPrefix(src[i:], "...") {
+				return nil, errors.Errorf("unexpected character '.' at position %d", i)
+			}
+
+			tokens = append(tokens, gqlToken{kind: gqlPunct, value: "...", pos: i})
+			i += 3
+		case c == '"':
+			start := i
+			i++
+
+			var b strings.Builder
+
+			for {
+				if i >= len(src) || src[i] == '\n' || src[i] == '\r' {
+					return nil, errors.Errorf("unterminated string at position %d", start)
+				}
+
+				if src[i] == '"' {
+					i++
+					break
+				}
+
+				if src[i] != '\\' {
+					b.WriteByte(src[i])
+					i++
+					continue
+				}
+
+				if i+1 >= len(src) {
+					return nil, errors.Errorf("unterminated string at position %d", start)
+				}
+
+				switch esc := src[i+1]; esc {
+				case '"', '\\', '/':
+					b.WriteByte(esc)
+				case 'b':
+					b.WriteByte('\b')
+				case 'f':
+					b.WriteByte('\f')
+				case 'n':
+					b.WriteByte('\n')
+				case 'r':
+					b.WriteByte('\r')
+				case 't':
+					b.WriteByte('\t')
+				case 'u':
+					if i+6 > len(src) {
+						return nil, errors.Errorf("invalid unicode escape in string at position %d", i)
+					}
+
+					r, err := strconv.ParseUint(src[i+2:i+6], 16, 16)
+					if err != nil {
+						return nil, errors.Errorf("invalid unicode escape in string at position %d", i)
+					}
+
+					var buf [utf8.UTFMax]byte
+					b.Write(buf[:utf8.EncodeRune(buf[:], rune(r))])
+
+					i += 4
+				default:
+					return nil, errors.Errorf("invalid escape sequence in string at position %d", i)
+				}
+
+				i += 2
+			}
+
+			tokens = append(tokens, gqlToken{kind: gqlString, value: b.String(), pos: start})
+		case c == '-' || isGraphQLDigit(c):
+			start := i
+			kind := gqlInt
+
+			if c == '-' {
+				i++
+			}
+
+			for i < len(src) && isGraphQLDigit(src[i]) {
+				i++
+			}
+
+			if i < len(src) && src[i] == '.' {
+				kind = gqlFloat
+				i++
+
+				for i < len(src) && isGraphQLDigit(src[i]) {
+					i++
+				}
+			}
+
+			if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+				kind = gqlFloat
+				i++
+
+				if i < len(src) && (src[i] == '+' || src[i] == '-') {
+					i++
+				}
+
+				for i < len(src) && isGraphQLDigit(src[i]) {
+					i++
+				}
+			}
+
+			tokens = append(tokens, gqlToken{kind: kind, value: src[start:i], pos: start})
+		case isGraphQLNameStart(c):
+			start := i
+
+			for i < len(src) && (isGraphQLNameStart(src[i]) || isGraphQLDigit(src[i])) {
+				i++
+			}
+
+			tokens = append(tokens, gqlToken{kind: gqlName, value: src[start:i], pos: start})
+		default:
+			return nil, errors.Errorf("unexpected character %q at position %d", c, i)
+		}
+	}
+
+	return append(tokens, gqlToken{kind: gqlEOF, pos: len(src)}), nil
+}
+
+type gqlParser struct {
+	tokens []gqlToken
+	pos    int
+
+	variables map[string]interface{}
+	defined   map[string]struct{}
+}
+
+// parseGraphQL parses a query into the fields it selects at its root, with
+// the arguments of all fields resolved against variables.
+func parseGraphQL(query string, variables map[string]interface{}) ([]*gqlField, error) {
+	tokens, err := lexGraphQL(query)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &gqlParser{tokens: tokens, variables: make(map[string]interface{}), defined: make(map[string]struct{})}
+
+	for name, value := range variables {
+		p.variables[name] = value
+	}
+
+	if t := p.peek(); t.kind == gqlName {
+		switch t.value {
+		case "query":
+			p.next()
+
+			if p.peek().kind == gqlName {
+				p.next()
+			}
+
+			if p.peekPunct("(") {
+				if err := p.parseVariableDefinitions(); err != nil {
+					return nil, err
+				}
+			}
+		case "mutation", "subscription":
+			return nil, errors.Errorf("%s operations are not supported", t.value)
+		default:
+			return nil, p.unexpected(t)
+		}
+	}
+
+	fields, err := p.parseSelectionSet(1)
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != gqlEOF {
+		return nil, errors.Errorf("expected a single operation, but got %s at position %d", t, t.pos)
+	}
+
+	return fields, nil
+}
+
+func (p *gqlParser) peek() gqlToken {
+	return p.tokens[p.pos]
+}
+
+func (p *gqlParser) next() gqlToken {
+	t := p.tokens[p.pos]
+
+	if t.kind != gqlEOF {
+		p.pos++
+	}
+
+	return t
+}
+
+func (p *gqlParser) peekPunct(value string) bool {
+	t := p.peek()
+	return t.kind == gqlPunct && t.value == value
+}
+
+func (p *gqlParser) expectPunct(value string) error {
+	if t := p.next(); t.kind != gqlPunct || t.value != value {
+		return errors.Errorf("expected %q, but got %s at position %d", value, t, t.pos)
+	}
+
+	return nil
+}
+
+func (p *gqlParser) expectName() (string, error) {
+	t := p.next()
+	if t.kind != gqlName {
+		return "", p.unexpected(t)
+	}
+
+	return t.value, nil
+}
+
+func (p *gqlParser) unexpected(t gqlToken) error {
+	switch {
+	case t.kind == gqlPunct && t.value == "...":
+		return errors.Errorf("fragments are not supported, but got one at position %d", t.pos)
+	case t.kind == gqlPunct && t.value == "@":
+		return errors.Errorf("directives are not supported, but got one at position %d", t.pos)
+	case t.kind == gqlName && t.value == "fragment":
+		return errors.Errorf("fragments are not supported, but got one at position %d", t.pos)
+	}
+
+	return errors.Errorf("unexpected %s at position %d", t, t.pos)
+}
+
+func (p *gqlParser) parseVariableDefinitions() error {
+	if err := p.expectPunct("("); err != nil {
+		return err
+	}
+
+	for !p.peekPunct(")") {
+		if err := p.expectPunct("$"); err != nil {
+			return err
+		}
+
+		name, err := p.expectName()
+		if err != nil {
+			return err
+		}
+
+		if err := p.expectPunct(":"); err != nil {
+			return err
+		}
+
+		if err := p.parseType(); err != nil {
+			return err
+		}
+
+		if p.peekPunct("=") {
+			p.next()
+
+			def, err := p.parseValue()
+			if err != nil {
+				return err
+			}
+
+			if _, provided := p.variables[name]; !provided {
+				p.variables[name] = def
+			}
+		}
+
+		p.defined[name] = struct{}{}
+	}
+
+	p.next()
+
+	return nil
+}
+
+// parseType parses over the type of a variable. Types are not checked, as
+// the arguments of fields are checked as they are resolved.
+func (p *gqlParser) parseType() error {
+	if p.peekPunct("[") {
+		p.next()
+
+		if err := p.parseType(); err != nil {
+			return err
+		}
+
+		if err := p.expectPunct("]"); err != nil {
+			return err
+		}
+	} else if _, err := p.expectName(); err != nil {
+		return err
+	}
+
+	if p.peekPunct("!") {
+		p.next()
+	}
+
+	return nil
+}
+
+func (p *gqlParser) parseSelectionSet(depth int) ([]*gqlField, error) {
+	if depth > graphqlMaxDepth {
+		return nil, errors.Errorf("query exceeds the max depth of %d", graphqlMaxDepth)
+	}
+
+	if err := p.expectPunct("{"); err != nil {
+		return nil, err
+	}
+
+	var fields []*gqlField
+
+	for !p.peekPunct("}") {
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+
+		field := &gqlField{alias: name, name: name}
+
+		if p.peekPunct(":") {
+			p.next()
+
+			if field.name, err = p.expectName(); err != nil {
+				return nil, err
+			}
+		}
+
+		if p.peekPunct("(") {
+			if field.args, err = p.parseArguments(); err != nil {
+				return nil, err
+			}
+		}
+
+		if p.peekPunct("@") {
+			return nil, p.unexpected(p.peek())
+		}
+
+		if p.peekPunct("{") {
+			if field.selections, err = p.parseSelectionSet(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+
+		fields = append(fields, field)
+	}
+
+	p.next()
+
+	if len(fields) == 0 {
+		return nil, errors.New("selection sets must not be empty")
+	}
+
+	return fields, nil
+}
+
+func (p *gqlParser) parseArguments() (map[string]interface{}, error) {
+	if err := p.expectPunct("("); err != nil {
+		return nil, err
+	}
+
+	args := make(map[string]interface{})
+
+	for !p.peekPunct(")") {
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, exists := args[name]; exists {
+			return nil, errors.Errorf("found duplicate argument %q", name)
+		}
+
+		if err := p.expectPunct(":"); err != nil {
+			return nil, err
+		}
+
+		if args[name], err = p.parseValue(); err != nil {
+			return nil, err
+		}
+	}
+
+	p.next()
+
+	if len(args) == 0 {
+		return nil, errors.New("argument lists must not be empty")
+	}
+
+	return args, nil
+}
+
+func (p *gqlParser) parseValue() (interface{}, error) {
+	t := p.next()
+
+	switch t.kind {
+	case gqlInt:
+		n, err := strconv.ParseInt(t.value, 10, 64)
+		if err != nil {
+			return nil, errors.Errorf("invalid integer %s at position %d", t, t.pos)
+		}
+
+		return n, nil
+	case gqlFloat:
+		f, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, errors.Errorf("invalid float %s at position %d", t, t.pos)
+		}
+
+		return f, nil
+	case gqlString:
+		return t.value, nil
+	case gqlName:
+		switch t.value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+	case gqlPunct:
+		switch t.value {
+		case "$":
+			name, err := p.expectName()
+			if err != nil {
+				return nil, err
+			}
+
+			if _, defined := p.defined[name]; !defined {
+				return nil, errors.Errorf("variable $%s is not defined", name)
+			}
+
+			return p.variables[name], nil
+		case "[":
+			list := make([]interface{}, 0)
+
+			for !p.peekPunct("]") {
+				if p.peek().kind == gqlEOF {
+					return nil, p.unexpected(p.peek())
+				}
+
+				item, err := p.parseValue()
+				if err != nil {
+					return nil, err
+				}
+
+				list = append(list, item)
+			}
+
+			p.next()
+
+			return list, nil
+		}
+	}
+
+	return nil, p.unexpected(t)
+}
+
+// graphqlVariable converts the JSON-encoded value of a variable into the same
+// representation as values parsed out of a query.
+func graphqlVariable(v *fastjson.Value) interface{} {
+	switch v.Type() {
+	case fastjson.TypeString:
+		return string(v.GetStringBytes())
+	case fastjson.TypeNumber:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+
+		return v.GetFloat64()
+	case fastjson.TypeTrue:
+		return true
+	case fastjson.TypeFalse:
+		return false
+	case fastjson.TypeArray:
+		list := make([]interface{}, 0)
+
+		for _, item := range v.GetArray() {
+			list = append(list, graphqlVariable(item))
+		}
+
+		return list
+	default:
+		return nil
+	}
+}
+
+func (f *gqlField) stringArg(name string) (string, bool, error) {
+	v, exists := f.args[name]
+	if !exists || v == nil {
+		return "", false, nil
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		return "", false, errors.Errorf("argument %q must be a string", name)
+	}
+
+	return s, true, nil
+}
+
+func (f *gqlField) intArg(name string) (int64, bool, error) {
+	v, exists := f.args[name]
+	if !exists || v == nil {
+		return 0, false, nil
+	}
+
+	n, ok := v.(int64)
+	if !ok {
+		return 0, false, errors.Errorf("argument %q must be an integer", name)
+	}
+
+	return n, true, nil
+}
+
+// gqlObject is an object whose fields may be selected by a query.
+type gqlObject interface {
+	// resolve returns the value of a field of the object, which is either nil,
+	// a string, a bool, an int, a uint64, a gqlObject, or a []gqlObject.
+	resolve(field *gqlField) (interface{}, error)
+}
+
+type gqlError struct {
+	message string
+	path    []interface{}
+}
+
+type gqlExecutor struct {
+	arena *fastjson.Arena
+
+	complexity int
+	aborted    error
+
+	errors []gqlError
+}
+
+// executeObject resolves the fields selected from an object. Fields which fail
+// to be resolved are set to null, and have their errors recorded.
+func (e *gqlExecutor) executeObject(obj gqlObject, selections []*gqlField, path []interface{}) *fastjson.Value {
+	o := e.arena.NewObject()
+
+	for _, field := range selections {
+		if e.aborted != nil {
+			return nil
+		}
+
+		if e.complexity++; e.complexity > graphqlMaxComplexity {
+			e.aborted = ErrGraphQLMaxComplexity
+			return nil
+		}
+
+		fieldPath := append(path[:len(path):len(path)], field.alias)
+
+		value, err := obj.resolve(field)
+		if err != nil {
+			e.errors = append(e.errors, gqlError{message: err.Error(), path: fieldPath})
+			o.Set(field.alias, e.arena.NewNull())
+
+			continue
+		}
+
+		o.Set(field.alias, e.complete(field, value, fieldPath))
+	}
+
+	return o
+}
+
+func (e *gqlExecutor) complete(field *gqlField, value interface{}, path []interface{}) *fastjson.Value {
+	var scalar *fastjson.Value
+
+	switch v := value.(type) {
+	case nil:
+		return e.arena.NewNull()
+	case gqlObject:
+		if len(field.selections) == 0 {
+			return e.fail(errors.Errorf("field %q of an object type must have a selection of subfields", field.name), path)
+		}
+
+		return e.executeObject(v, field.selections, path)
+	case []gqlObject:
+		if len(field.selections) == 0 {
+			return e.fail(errors.Errorf("field %q of an object type must have a selection of subfields", field.name), path)
+		}
+
+		list := e.arena.NewArray()
+
+		for i, item := range v {
+			list.SetArrayItem(i, e.executeObject(item, field.selections, append(path[:len(path):len(path)], i)))
+		}
+
+		return list
+	case string:
+		scalar = e.arena.NewString(v)
+	case bool:
+		if v {
+			scalar = e.arena.NewTrue()
+		} else {
+			scalar = e.arena.NewFalse()
+		}
+	case int:
+		scalar = e.arena.NewNumberInt(v)
+	case uint64:
+		scalar = e.arena.NewNumberString(strconv.FormatUint(v, 10))
+	default:
+		return e.fail(errors.Errorf("field %q resolved to an unsupported value of type %T", field.name, value), path)
+	}
+
+	if len(field.selections) > 0 {
+		return e.fail(errors.Errorf("field %q of a scalar type must not have a selection of subfields", field.name), path)
+	}
+
+	return scalar
+}
+
+func (e *gqlExecutor) fail(err error, path []interface{}) *fastjson.Value {
+	e.errors = append(e.errors, gqlError{message: err.Error(), path: path})
+	return e.arena.NewNull()
+}
+
+// marshalErrors encodes all errors recorded while executing a query.
+func (e *gqlExecutor) marshalErrors() *fastjson.Value {
+	list := e.arena.NewArray()
+
+	for i, err := range e.errors {
+		o := e.arena.NewObject()
+		o.Set("message", e.arena.NewString(err.message))
+
+		if len(err.path) > 0 {
+			path := e.arena.NewArray()
+
+			for j, item := range err.path {
+				switch item := item.(type) {
+				case string:
+					path.SetArrayItem(j, e.arena.NewString(item))
+				case int:
+					path.SetArrayItem(j, e.arena.NewNumberInt(item))
+				}
+			}
+
+			o.Set("path", path)
+		}
+
+		list.SetArrayItem(i, o)
+	}
+
+	return list
+}
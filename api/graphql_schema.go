@@ -0,0 +1,382 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/perlin-network/wavelet"
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/pkg/errors"
+)
+
+const (
+	// Default and max number of transactions which may be listed by a
+	// single field of a query.
+	graphqlDefaultListLimit = 20
+	graphqlMaxListLimit     = 100
+)
+
+// gqlQuery is the root of all queries, resolved against a single snapshot of
+// the ledger state.
+type gqlQuery struct {
+	ledger   *wavelet.Ledger
+	snapshot *avl.Tree
+}
+
+func (q *gqlQuery) resolve(f *gqlField) (interface{}, error) {
+	switch f.name {
+	case "account":
+		id, err := accountIDArg(f, "id")
+		if err != nil {
+			return nil, err
+		}
+
+		return &gqlAccount{q: q, id: id}, nil
+	case "transaction":
+		id, err := transactionIDArg(f, "id")
+		if err != nil {
+			return nil, err
+		}
+
+		return q.transaction(id), nil
+	case "transactions":
+		offset, _, err := f.intArg("offset")
+		if err != nil {
+			return nil, err
+		}
+
+		if offset < 0 {
+			return nil, errors.New("argument \"offset\" must not be negative")
+		}
+
+		limit, err := limitArg(f)
+		if err != nil {
+			return nil, err
+		}
+
+		var sender, creator wavelet.AccountID
+
+		if _, exists := f.args["sender"]; exists {
+			if sender, err = accountIDArg(f, "sender"); err != nil {
+				return nil, err
+			}
+		}
+
+		if _, exists := f.args["creator"]; exists {
+			if creator, err = accountIDArg(f, "creator"); err != nil {
+				return nil, err
+			}
+		}
+
+		var tags []byte
+
+		tag, filtered, err := f.intArg("tag")
+		if err != nil {
+			return nil, err
+		}
+
+		if filtered {
+			if tag < 0 || tag > 255 {
+				return nil, errors.New("argument \"tag\" must be a byte")
+			}
+
+			tags = append(tags, byte(tag))
+		}
+
+		return q.transactions(q.ledger.Graph().ListTransactions(uint64(offset), uint64(limit), sender, creator, tags...)), nil
+	case "contract":
+		id, err := transactionIDArg(f, "id")
+		if err != nil {
+			return nil, err
+		}
+
+		return q.contract(id), nil
+	case "round":
+		index, specified, err := f.intArg("index")
+		if err != nil {
+			return nil, err
+		}
+
+		if !specified {
+			return &gqlRound{q: q, round: q.ledger.Rounds().Latest()}, nil
+		}
+
+		if index < 0 {
+			return nil, errors.New("argument \"index\" must not be negative")
+		}
+
+		round, err := q.ledger.Rounds().GetByIndex(uint64(index))
+		if err != nil {
+			return nil, nil
+		}
+
+		return &gqlRound{q: q, round: round}, nil
+	}
+
+	return nil, errors.Errorf("unknown field %q on type Query", f.name)
+}
+
+func (q *gqlQuery) transaction(id wavelet.TransactionID) gqlObject {
+	tx := q.ledger.Graph().FindTransaction(id)
+	if tx == nil {
+		return nil
+	}
+
+	return &gqlTransaction{q: q, tx: tx}
+}
+
+func (q *gqlQuery) transactions(txs []*wavelet.Transaction) []gqlObject {
+	objects := make([]gqlObject, 0, len(txs))
+
+	for _, tx := range txs {
+		objects = append(objects, &gqlTransaction{q: q, tx: tx})
+	}
+
+	return objects
+}
+
+func (q *gqlQuery) contract(id wavelet.TransactionID) gqlObject {
+	if _, exists := wavelet.ReadAccountContractCode(q.snapshot, id); !exists {
+		return nil
+	}
+
+	return &gqlContract{q: q, id: id}
+}
+
+type gqlAccount struct {
+	q  *gqlQuery
+	id wavelet.AccountID
+}
+
+func (a *gqlAccount) resolve(f *gqlField) (interface{}, error) {
+	switch f.name {
+	case "id":
+		return hex.EncodeToString(a.id[:]), nil
+	case "balance":
+		balance, _ := wavelet.ReadAccountBalance(a.q.snapshot, a.id)
+		return balance, nil
+	case "stake":
+		stake, _ := wavelet.ReadAccountStake(a.q.snapshot, a.id)
+		return stake, nil
+	case "reward":
+		reward, _ := wavelet.ReadAccountReward(a.q.snapshot, a.id)
+		return reward, nil
+	case "nonce":
+		nonce, _ := wavelet.ReadAccountNonce(a.q.snapshot, a.id)
+		return nonce, nil
+	case "is_contract":
+		_, isContract := wavelet.ReadAccountContractCode(a.q.snapshot, a.id)
+		return isContract, nil
+	case "contract":
+		return a.q.contract(a.id), nil
+	}
+
+	return nil, errors.Errorf("unknown field %q on type Account", f.name)
+}
+
+type gqlTransaction struct {
+	q  *gqlQuery
+	tx *wavelet.Transaction
+}
+
+func (t *gqlTransaction) resolve(f *gqlField) (interface{}, error) {
+	switch f.name {
+	case "id":
+		return hex.EncodeToString(t.tx.ID[:]), nil
+	case "sender":
+		return &gqlAccount{q: t.q, id: t.tx.Sender}, nil
+	case "creator":
+		return &gqlAccount{q: t.q, id: t.tx.Creator}, nil
+	case "nonce":
+		return t.tx.Nonce, nil
+	case "depth":
+		return t.tx.Depth, nil
+	case "tag":
+		return int(t.tx.Tag), nil
+	case "payload":
+		return base64.StdEncoding.EncodeToString(t.tx.Payload), nil
+	case "status":
+		status, _ := t.q.ledger.TransactionStatus(t.tx.ID)
+		return status, nil
+	case "round":
+		// Transactions are never part of the genesis round, which has an index of zero.
+		if _, round := t.q.ledger.TransactionStatus(t.tx.ID); round != 0 {
+			return round, nil
+		}
+
+		return nil, nil
+	case "parents":
+		limit, err := limitArg(f)
+		if err != nil {
+			return nil, err
+		}
+
+		parents := make([]gqlObject, 0, len(t.tx.ParentIDs))
+
+		for _, id := range t.tx.ParentIDs {
+			if len(parents) == limit {
+				break
+			}
+
+			if parent := t.q.ledger.Graph().FindTransaction(id); parent != nil {
+				parents = append(parents, &gqlTransaction{q: t.q, tx: parent})
+			}
+		}
+
+		return parents, nil
+	case "children":
+		limit, err := limitArg(f)
+		if err != nil {
+			return nil, err
+		}
+
+		_, _, children := t.q.ledger.Graph().FindNeighborhood(t.tx.ID, 1, limit)
+
+		return t.q.transactions(children), nil
+	}
+
+	return nil, errors.Errorf("unknown field %q on type Transaction", f.name)
+}
+
+type gqlContract struct {
+	q  *gqlQuery
+	id wavelet.TransactionID
+}
+
+func (c *gqlContract) resolve(f *gqlField) (interface{}, error) {
+	switch f.name {
+	case "id":
+		return hex.EncodeToString(c.id[:]), nil
+	case "account":
+		return &gqlAccount{q: c.q, id: c.id}, nil
+	case "transaction":
+		// The ID of a contract is the ID of the transaction which spawned it.
+		return c.q.transaction(c.id), nil
+	case "owner":
+		owner, exists := wavelet.ReadAccountContractOwner(c.q.snapshot, c.id)
+		if !exists {
+			return nil, nil
+		}
+
+		return &gqlAccount{q: c.q, id: owner}, nil
+	case "paused":
+		return wavelet.ReadAccountContractPaused(c.q.snapshot, c.id), nil
+	case "call_quota":
+		quota, exists := wavelet.ReadAccountContractCallQuota(c.q.snapshot, c.id)
+		if !exists {
+			return nil, nil
+		}
+
+		return quota, nil
+	case "num_pages":
+		numPages, _ := wavelet.ReadAccountContractNumPages(c.q.snapshot, c.id)
+		return numPages, nil
+	}
+
+	return nil, errors.Errorf("unknown field %q on type Contract", f.name)
+}
+
+type gqlRound struct {
+	q     *gqlQuery
+	round *wavelet.Round
+}
+
+func (r *gqlRound) resolve(f *gqlField) (interface{}, error) {
+	switch f.name {
+	case "index":
+		return r.round.Index, nil
+	case "id":
+		return hex.EncodeToString(r.round.ID[:]), nil
+	case "merkle_root":
+		return hex.EncodeToString(r.round.Merkle[:]), nil
+	case "applied":
+		return r.round.Applied, nil
+	case "depth":
+		return r.round.End.Depth, nil
+	case "start":
+		return &gqlTransaction{q: r.q, tx: &r.round.Start}, nil
+	case "end":
+		return &gqlTransaction{q: r.q, tx: &r.round.End}, nil
+	}
+
+	return nil, errors.Errorf("unknown field %q on type Round", f.name)
+}
+
+func limitArg(f *gqlField) (int, error) {
+	limit, specified, err := f.intArg("limit")
+	if err != nil {
+		return 0, err
+	}
+
+	if !specified {
+		return graphqlDefaultListLimit, nil
+	}
+
+	if limit < 1 || limit > graphqlMaxListLimit {
+		return 0, errors.Errorf("argument \"limit\" must be between 1 and %d", graphqlMaxListLimit)
+	}
+
+	return int(limit), nil
+}
+
+func accountIDArg(f *gqlField, name string) (wavelet.AccountID, error) {
+	var id wavelet.AccountID
+
+	param, specified, err := f.stringArg(name)
+	if err != nil {
+		return id, err
+	}
+
+	if !specified {
+		return id, errors.Errorf("argument %q is required", name)
+	}
+
+	slice, err := hex.DecodeString(param)
+	if err != nil || len(slice) != wavelet.SizeAccountID {
+		return id, errors.Errorf("argument %q must be a %d-byte account ID presented as hex", name, wavelet.SizeAccountID)
+	}
+
+	copy(id[:], slice)
+
+	return id, nil
+}
+
+func transactionIDArg(f *gqlField, name string) (wavelet.TransactionID, error) {
+	var id wavelet.TransactionID
+
+	param, specified, err := f.stringArg(name)
+	if err != nil {
+		return id, err
+	}
+
+	if !specified {
+		return id, errors.Errorf("argument %q is required", name)
+	}
+
+	slice, err := hex.DecodeString(param)
+	if err != nil || len(slice) != wavelet.SizeTransactionID {
+		return id, errors.Errorf("argument %q must be a %d-byte ID presented as hex", name, wavelet.SizeTransactionID)
+	}
+
+	copy(id[:], slice)
+
+	return id, nil
+}
@@ -0,0 +1,182 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"github.com/perlin-network/wavelet"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fastjson"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParseGraphQL(t *testing.T) {
+	fields, err := parseGraphQL(`
+		query Lookup($id: String!, $limit: Int = 5) {
+			tx: transaction(id: $id) {
+				id, parents(limit: $limit) { id }
+			}
+			# Comments and commas are ignored.
+			transactions(tag: 1, sender: "ab") { id }
+		}`, map[string]interface{}{"id": "abc"})
+
+	if !assert.NoError(t, err) || !assert.Len(t, fields, 2) {
+		return
+	}
+
+	assert.Equal(t, "tx", fields[0].alias)
+	assert.Equal(t, "transaction", fields[0].name)
+	assert.Equal(t, map[string]interface{}{"id": "abc"}, fields[0].args)
+	assert.Equal(t, map[string]interface{}{"limit": int64(5)}, fields[0].selections[1].args)
+	assert.Equal(t, map[string]interface{}{"tag": int64(1), "sender": "ab"}, fields[1].args)
+
+	tests := []struct {
+		query string
+		err   string
+	}{
+		{query: `mutation { a }`, err: "mutation operations are not supported"},
+		{query: `{ a { ...F } }`, err: "fragments are not supported"},
+		{query: `{ a @skip(if: true) }`, err: "directives are not supported"},
+		{query: `{ a(id: $id) }`, err: "variable $id is not defined"},
+		{query: `{ a { } }`, err: "selection sets must not be empty"},
+		{query: `{ a(x: 1, x: 2) }`, err: `found duplicate argument "x"`},
+		{query: `{ a } { b }`, err: "expected a single operation"},
+		{query: `{ a(x: "abc) }`, err: "unterminated string"},
+		{query: `{ a ` + strings.Repeat("{ a ", graphqlMaxDepth) + strings.Repeat("} ", graphqlMaxDepth) + `}`, err: "max depth"},
+	}
+
+	for _, tc := range tests {
+		_, err := parseGraphQL(tc.query, nil)
+
+		if assert.Error(t, err, tc.query) {
+			assert.Contains(t, err.Error(), tc.err, tc.query)
+		}
+	}
+}
+
+type gqlTestObject struct{}
+
+func (o gqlTestObject) resolve(f *gqlField) (interface{}, error) {
+	switch f.name {
+	case "list":
+		list := make([]gqlObject, graphqlMaxComplexity)
+
+		for i := range list {
+			list[i] = o
+		}
+
+		return list, nil
+	case "value":
+		return 1, nil
+	}
+
+	return nil, fmt.Errorf("unknown field %q", f.name)
+}
+
+func TestGraphQLExecutor(t *testing.T) {
+	execute := func(query string) string {
+		fields, err := parseGraphQL(query, nil)
+		if !assert.NoError(t, err) {
+			return ""
+		}
+
+		res, err := (&graphqlResponse{root: gqlTestObject{}, fields: fields}).marshalJSON(new(fastjson.Arena))
+		assert.NoError(t, err)
+
+		return string(res)
+	}
+
+	assert.Equal(t, `{"data":{"v":1,"missing":null},"errors":[{"message":"unknown field \"missing\"","path":["missing"]}]}`,
+		execute(`{ v: value, missing }`))
+
+	assert.Equal(t, `{"data":null,"errors":[{"message":"`+ErrGraphQLMaxComplexity.Error()+`"}]}`,
+		execute(`{ list { value } }`))
+}
+
+func TestGraphQL(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	transactions := gateway.ledger.Graph().ListTransactions(0, 0, wavelet.AccountID{}, wavelet.AccountID{})
+	if !assert.NotEmpty(t, transactions) {
+		return
+	}
+
+	tx := transactions[0]
+	txID := hex.EncodeToString(tx.ID[:])
+	sender := hex.EncodeToString(tx.Sender[:])
+
+	query := `query($id: String!) { transaction(id: $id) { id status sender { id is_contract } parents { id } } round { index } }`
+
+	expected := fmt.Sprintf(`{"data":{"transaction":{"id":"%s","status":"%s","sender":{"id":"%s","is_contract":false},"parents":[]},"round":{"index":0}}}`,
+		txID, wavelet.TransactionStatusApplied, sender)
+
+	t.Run("post", func(t *testing.T) {
+		body := fmt.Sprintf(`{"query":%q,"variables":{"id":%q}}`, query, txID)
+
+		request, err := http.NewRequest("POST", "http://localhost/graphql", bytes.NewBufferString(body))
+		assert.NoError(t, err)
+
+		w, err := serve(gateway.router, request)
+		assert.NoError(t, err)
+
+		response, err := ioutil.ReadAll(w.Body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, w.StatusCode)
+		assert.NoError(t, compareJson([]byte(expected), response))
+	})
+
+	t.Run("get", func(t *testing.T) {
+		values := url.Values{}
+		values.Set("query", query)
+		values.Set("variables", fmt.Sprintf(`{"id":%q}`, txID))
+
+		request, err := http.NewRequest("GET", "http://localhost/graphql?"+values.Encode(), nil)
+		assert.NoError(t, err)
+
+		w, err := serve(gateway.router, request)
+		assert.NoError(t, err)
+
+		response, err := ioutil.ReadAll(w.Body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, w.StatusCode)
+		assert.NoError(t, compareJson([]byte(expected), response))
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		request, err := http.NewRequest("POST", "http://localhost/graphql", bytes.NewBufferString(`{"query":"{ transaction"}`))
+		assert.NoError(t, err)
+
+		w, err := serve(gateway.router, request)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusBadRequest, w.StatusCode)
+	})
+}
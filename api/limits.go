@@ -0,0 +1,202 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"github.com/pkg/errors"
+	"github.com/valyala/fasthttp"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RouteGroup is a set of routes of the API which share limits on the size of
+// request bodies, and on the time taken to serve requests.
+type RouteGroup string
+
+const (
+	// RouteGroupRead holds routes which read from the node, including calls
+	// to smart contracts and GraphQL queries.
+	RouteGroupRead RouteGroup = "read"
+
+	// RouteGroupSend holds routes which send transactions paid for by the
+	// node, other than those uploading smart contracts.
+	RouteGroupSend RouteGroup = "send"
+
+	// RouteGroupContract holds POST /contract, which uploads smart contracts.
+	RouteGroupContract RouteGroup = "contract"
+
+	// RouteGroupAdmin holds routes which manage the node.
+	RouteGroupAdmin RouteGroup = "admin"
+)
+
+// DefaultRouteTimeout is the default max duration taken to serve a request to
+// any route group.
+const DefaultRouteTimeout = 60 * time.Second
+
+// RouteLimits are the limits imposed on requests to a route group. Websocket,
+// polling, and server-sent event routes, alongside /debug, serve for as long
+// as clients stay connected, and therefore belong to no route group. Neither
+// do the /healthz and /readyz probes.
+type RouteLimits struct {
+	MaxRequestBodySize int // Max size of a request body in bytes.
+	Timeout            time.Duration
+}
+
+func defaultRouteLimits() map[RouteGroup]RouteLimits {
+	return map[RouteGroup]RouteLimits{
+		RouteGroupRead:     {MaxRequestBodySize: fasthttp.DefaultMaxRequestBodySize, Timeout: DefaultRouteTimeout},
+		RouteGroupSend:     {MaxRequestBodySize: fasthttp.DefaultMaxRequestBodySize, Timeout: DefaultRouteTimeout},
+		RouteGroupContract: {MaxRequestBodySize: DefaultMaxContractRequestBodySize, Timeout: DefaultRouteTimeout},
+		RouteGroupAdmin:    {MaxRequestBodySize: fasthttp.DefaultMaxRequestBodySize, Timeout: DefaultRouteTimeout},
+	}
+}
+
+// ParseRouteLimits parses the limits of a route group in the format
+// group:max_request_body_size:timeout, such as send:65536:10s. Either limit
+// may be left empty to keep its default, such as contract::2m.
+func ParseRouteLimits(s string) (RouteGroup, RouteLimits, error) {
+	var limits RouteLimits
+
+	fields := strings.Split(s, ":")
+	if len(fields) != 3 {
+		return "", limits, errors.Errorf("route limits %q must be in the format group:max_request_body_size:timeout", s)
+	}
+
+	group := RouteGroup(strings.TrimSpace(fields[0]))
+
+	switch group {
+	case RouteGroupRead, RouteGroupSend, RouteGroupContract, RouteGroupAdmin:
+	default:
+		return "", limits, errors.Errorf("unknown route group %q; must be one of %q, %q, %q, or %q", fields[0], RouteGroupRead, RouteGroupSend, RouteGroupContract, RouteGroupAdmin)
+	}
+
+	if size := strings.TrimSpace(fields[1]); len(size) > 0 {
+		n, err := strconv.Atoi(size)
+		if err != nil || n <= 0 {
+			return "", limits, errors.Errorf("max request body size of route group %q must be a positive number of bytes, but got %q", group, size)
+		}
+
+		limits.MaxRequestBodySize = n
+	}
+
+	if timeout := strings.TrimSpace(fields[2]); len(timeout) > 0 {
+		d, err := time.ParseDuration(timeout)
+		if err != nil || d <= 0 {
+			return "", limits, errors.Errorf("timeout of route group %q must be a positive duration, but got %q", group, timeout)
+		}
+
+		limits.Timeout = d
+	}
+
+	return group, limits, nil
+}
+
+// WithRouteLimits sets the limits imposed on requests to routes in group.
+// Limits which are zero or less keep their defaults.
+func WithRouteLimits(group RouteGroup, limits RouteLimits) Option {
+	return func(g *Gateway) {
+		current := g.routeLimits[group]
+
+		if limits.MaxRequestBodySize > 0 {
+			current.MaxRequestBodySize = limits.MaxRequestBodySize
+		}
+
+		if limits.Timeout > 0 {
+			current.Timeout = limits.Timeout
+		}
+
+		g.routeLimits[group] = current
+	}
+}
+
+// maxRequestBodySize returns the largest max request body size across all
+// route groups, past which the server rejects requests before routing them.
+func (g *Gateway) maxRequestBodySize() int {
+	max := fasthttp.DefaultMaxRequestBodySize
+
+	for _, limits := range g.routeLimits {
+		if limits.MaxRequestBodySize > max {
+			max = limits.MaxRequestBodySize
+		}
+	}
+
+	return max
+}
+
+// limit imposes the limits of group on requests. Requests with bodies that are
+// too large are responded to with 413, and requests which are not served in
+// time with 408. Both responses state the limit which was exceeded.
+func (g *Gateway) limit(group RouteGroup) middleware {
+	limits := g.routeLimits[group]
+
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		if limits.Timeout > 0 {
+			next = g.timeout(group, limits.Timeout, next)
+		}
+
+		return func(ctx *fasthttp.RequestCtx) {
+			if size := len(ctx.Request.Body()); limits.MaxRequestBodySize > 0 && size > limits.MaxRequestBodySize {
+				g.renderError(ctx, ErrRequestEntityTooLarge(errors.Errorf("request body is %d bytes, but requests to %s routes may only have bodies of %d bytes at most", size, group, limits.MaxRequestBodySize)))
+				return
+			}
+
+			next(ctx)
+		}
+	}
+}
+
+// timeout responds to requests which next does not finish serving within
+// timeout with 408. As next may still hold onto the request after it is
+// responded to, fasthttp is told to discard it rather than reuse it.
+func (g *Gateway) timeout(group RouteGroup, timeout time.Duration, next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	var res fasthttp.Response
+
+	arena := g.arenaPool.Get()
+	body, _ := ErrRequestTimeout(errors.Errorf("request was not served within %s, the timeout of %s routes", timeout, group)).marshalJSON(arena)
+	g.arenaPool.Put(arena)
+
+	res.SetStatusCode(http.StatusRequestTimeout)
+	res.Header.SetContentType("application/json")
+	res.SetBody(body)
+
+	// Panics are recovered from in the goroutine serving the request, as they
+	// would otherwise not reach the recoverer further up the chain.
+	next = recoverer(next)
+
+	return func(ctx *fasthttp.RequestCtx) {
+		done := make(chan struct{})
+
+		go func() {
+			next(ctx)
+			close(done)
+		}()
+
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		select {
+		case <-done:
+		case <-timer.C:
+			ctx.TimeoutErrorWithResponse(&res)
+		}
+	}
+}
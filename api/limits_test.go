@@ -0,0 +1,106 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"github.com/buaazp/fasthttprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fastjson"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRouteLimits(t *testing.T) {
+	group, limits, err := ParseRouteLimits("send:65536:10s")
+	if assert.NoError(t, err) {
+		assert.Equal(t, RouteGroupSend, group)
+		assert.Equal(t, RouteLimits{MaxRequestBodySize: 65536, Timeout: 10 * time.Second}, limits)
+	}
+
+	group, limits, err = ParseRouteLimits("contract::2m")
+	if assert.NoError(t, err) {
+		assert.Equal(t, RouteGroupContract, group)
+		assert.Equal(t, RouteLimits{Timeout: 2 * time.Minute}, limits)
+	}
+
+	for _, invalid := range []string{"send", "send:1", "stream:1:1s", "send:-1:", "send::never", "send::-1s"} {
+		_, _, err := ParseRouteLimits(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestRouteLimits(t *testing.T) {
+	gateway := New(
+		WithRouteLimits(RouteGroupSend, RouteLimits{MaxRequestBodySize: 16, Timeout: 50 * time.Millisecond}),
+		WithRouteLimits(RouteGroupRead, RouteLimits{Timeout: time.Hour}),
+	)
+
+	assert.Equal(t, RouteLimits{MaxRequestBodySize: 16, Timeout: 50 * time.Millisecond}, gateway.routeLimits[RouteGroupSend])
+	assert.Equal(t, fasthttp.DefaultMaxRequestBodySize, gateway.routeLimits[RouteGroupRead].MaxRequestBodySize)
+	assert.Equal(t, time.Hour, gateway.routeLimits[RouteGroupRead].Timeout)
+
+	router := fasthttprouter.New()
+	router.POST("/send", gateway.applyMiddleware(func(ctx *fasthttp.RequestCtx) {
+		if string(ctx.PostBody()) == "slow" {
+			time.Sleep(time.Second)
+		}
+
+		ctx.SetStatusCode(http.StatusOK)
+	}, "", gateway.limit(RouteGroupSend)))
+
+	tests := []struct {
+		name      string
+		body      string
+		wantCode  int
+		wantError string
+	}{
+		{name: "within limits", body: "fast", wantCode: http.StatusOK},
+		{name: "too large", body: strings.Repeat("a", 17), wantCode: http.StatusRequestEntityTooLarge, wantError: "16 bytes at most"},
+		{name: "too slow", body: "slow", wantCode: http.StatusRequestTimeout, wantError: "within 50ms"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			request, err := http.NewRequest("POST", "http://localhost/send", strings.NewReader(tc.body))
+			assert.NoError(t, err)
+
+			res, err := serve(router, request)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			body, err := ioutil.ReadAll(res.Body)
+			assert.NoError(t, err)
+
+			if !assert.Equal(t, tc.wantCode, res.StatusCode, string(body)) || tc.wantError == "" {
+				return
+			}
+
+			v, err := fastjson.ParseBytes(body)
+			if assert.NoError(t, err) {
+				assert.Contains(t, string(v.GetStringBytes("error")), tc.wantError)
+			}
+		})
+	}
+}
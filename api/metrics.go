@@ -0,0 +1,109 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"expvar"
+	"github.com/buaazp/fasthttprouter"
+	"github.com/rcrowley/go-metrics"
+	"github.com/valyala/fasthttp"
+	"strconv"
+	"time"
+)
+
+// routeMetrics records the number of requests made to a single route, the
+// distribution of the status codes of their responses, and the latency of
+// serving them.
+type routeMetrics struct {
+	latency  metrics.Timer
+	statuses [5]metrics.Counter // Indexed by the class of a status code, from 1xx to 5xx.
+}
+
+func newRouteMetrics(registry metrics.Registry, method, path string) *routeMetrics {
+	prefix := "api." + method + " " + path
+
+	m := &routeMetrics{latency: metrics.GetOrRegisterTimer(prefix+".latency", registry)}
+
+	for i := range m.statuses {
+		m.statuses[i] = metrics.GetOrRegisterCounter(prefix+".status."+strconv.Itoa(i+1)+"xx", registry)
+	}
+
+	return m
+}
+
+func (m *routeMetrics) instrument(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		start := time.Now()
+		next(ctx)
+		m.latency.UpdateSince(start)
+
+		if class := ctx.Response.StatusCode()/100 - 1; class >= 0 && class < len(m.statuses) {
+			m.statuses[class].Inc(1)
+		}
+	}
+}
+
+// instrumentedRouter is a router which records metrics for, and writes an
+// access log entry for requests made to, every route registered to it.
+type instrumentedRouter struct {
+	*fasthttprouter.Router
+
+	registry  metrics.Registry
+	accessLog *accessLogger
+}
+
+func (r *instrumentedRouter) Handle(method, path string, handle fasthttp.RequestHandler) {
+	if r.accessLog != nil {
+		handle = r.accessLog.log(method, path, handle)
+	}
+
+	r.Router.Handle(method, path, newRouteMetrics(r.registry, method, path).instrument(handle))
+}
+
+func (r *instrumentedRouter) GET(path string, handle fasthttp.RequestHandler) {
+	r.Handle("GET", path, handle)
+}
+
+func (r *instrumentedRouter) POST(path string, handle fasthttp.RequestHandler) {
+	r.Handle("POST", path, handle)
+}
+
+func (r *instrumentedRouter) PUT(path string, handle fasthttp.RequestHandler) {
+	r.Handle("PUT", path, handle)
+}
+
+func (r *instrumentedRouter) DELETE(path string, handle fasthttp.RequestHandler) {
+	r.Handle("DELETE", path, handle)
+}
+
+// Metrics returns the registry the number of requests made to, the status
+// codes responded with by, and the latency of every route of the gateway are
+// recorded into.
+func (g *Gateway) Metrics() metrics.Registry {
+	return g.metrics
+}
+
+// PublishMetrics exports a snapshot of all metrics of the gateway through
+// expvar under name. It panics if name is already in use.
+func (g *Gateway) PublishMetrics(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return g.metrics.GetAll()
+	}))
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouteMetrics(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	for i := 0; i < 3; i++ {
+		w, err := serve(gateway.router, httptest.NewRequest("GET", "http://localhost/healthz", nil))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, w.StatusCode)
+	}
+
+	w, err := serve(gateway.router, httptest.NewRequest("GET", "http://localhost/tx/1c331c1d", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, w.StatusCode)
+
+	registry := gateway.Metrics()
+
+	assert.EqualValues(t, 3, registry.Get("api.GET /healthz.latency").(metrics.Timer).Count())
+	assert.EqualValues(t, 3, registry.Get("api.GET /healthz.status.2xx").(metrics.Counter).Count())
+	assert.EqualValues(t, 0, registry.Get("api.GET /healthz.status.4xx").(metrics.Counter).Count())
+
+	assert.EqualValues(t, 1, registry.Get("api.GET /tx/:id.latency").(metrics.Timer).Count())
+	assert.EqualValues(t, 1, registry.Get("api.GET /tx/:id.status.4xx").(metrics.Counter).Count())
+
+	assert.EqualValues(t, 0, registry.Get("api.POST /tx/send.latency").(metrics.Timer).Count())
+}
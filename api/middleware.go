@@ -25,7 +25,6 @@ import (
 	"net/http"
 	"os"
 	"runtime/debug"
-	"time"
 )
 
 type middleware func(fasthttp.RequestHandler) fasthttp.RequestHandler
@@ -54,9 +53,3 @@ func recoverer(next fasthttp.RequestHandler) fasthttp.RequestHandler {
 
 	return fasthttp.RequestHandler(fn)
 }
-
-func timeout(timeout time.Duration, msg string) func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
-	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
-		return fasthttp.TimeoutHandler(next, timeout, msg)
-	}
-}
@@ -20,6 +20,8 @@
 package api
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/hex"
 	"fmt"
@@ -28,15 +30,23 @@ import (
 	"github.com/perlin-network/wavelet"
 	"github.com/perlin-network/wavelet/debounce"
 	"github.com/perlin-network/wavelet/log"
+	"github.com/perlin-network/wavelet/sys"
 	"github.com/pkg/errors"
+	"github.com/rcrowley/go-metrics"
 	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/expvarhandler"
 	"github.com/valyala/fasthttp/pprofhandler"
 	"github.com/valyala/fastjson"
+	"golang.org/x/crypto/blake2b"
+	"google.golang.org/grpc"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -47,24 +57,146 @@ type Gateway struct {
 	network *skademlia.Protocol
 	keys    *skademlia.Keypair
 
-	router        *fasthttprouter.Router
-	server        *fasthttp.Server
-	sinks         map[string]*sink
-	enableTimeout bool
+	router     *fasthttprouter.Router
+	server     *fasthttp.Server
+	grpcServer *grpc.Server
+	grpcPort   int
+	sinks      map[string]*sink
 
-	rateLimiter *rateLimiter
+	rateLimiter     *rateLimiter
+	sendRateLimiter *rateLimiter
+	metrics         metrics.Registry
+	accessLog       *accessLogger
+
+	routeLimits map[RouteGroup]RouteLimits
+
+	maxWebsocketConnections      int
+	maxWebsocketConnectionsPerIP int
+	websocketIdleTimeout         time.Duration
+	websocketLimiter             *connLimiter
+
+	idleConns *idleConns
+
+	// Closed upon shutdown, such that event streams, which would otherwise
+	// hold up the server from shutting down, are ended.
+	shutdown     chan struct{}
+	shutdownOnce sync.Once
+
+	tlsCertFile, tlsKeyFile string
+
+	apiKeys map[[32]byte]map[Scope]struct{}
+
+	faucet *faucet
+
+	verifyingState int32
 
 	parserPool *fastjson.ParserPool
 	arenaPool  *fastjson.ArenaPool
 }
 
-func New() *Gateway {
-	return &Gateway{
-		sinks:       make(map[string]*sink),
-		parserPool:  new(fastjson.ParserPool),
-		arenaPool:   new(fastjson.ArenaPool),
-		rateLimiter: newRateLimiter(1000),
+// DefaultMaxContractRequestBodySize is the default max size in bytes of a
+// request body uploading a smart contract, which leaves room for a contract
+// of the largest permitted payload size to be base64-encoded.
+const DefaultMaxContractRequestBodySize = 4 * 1024 * 1024
+
+// DefaultShutdownTimeout is the default duration to wait for the gateway to
+// gracefully shut down.
+const DefaultShutdownTimeout = 10 * time.Second
+
+type Option func(*Gateway)
+
+// WithMaxContractRequestBodySize sets the max size in bytes of a request body
+// uploading a smart contract to POST /contract. It is shorthand for setting
+// the max request body size of RouteGroupContract through WithRouteLimits.
+func WithMaxContractRequestBodySize(size int) Option {
+	return WithRouteLimits(RouteGroupContract, RouteLimits{MaxRequestBodySize: size})
+}
+
+// WithGRPCPort has the gateway additionally serve its API over gRPC on port
+// once it is started through StartHTTP. The gRPC API is described by
+// rpc.proto, from which clients in other languages may be generated.
+func WithGRPCPort(port int) Option {
+	return func(g *Gateway) {
+		g.grpcPort = port
+	}
+}
+
+// WithTLS has the gateway serve its HTTP API over HTTPS, and websockets over
+// WSS, using the PEM-encoded certificate and private key at the given paths.
+func WithTLS(certFile, keyFile string) Option {
+	return func(g *Gateway) {
+		g.tlsCertFile = certFile
+		g.tlsKeyFile = keyFile
+	}
+}
+
+// WithMaxWebsocketConnections caps the number of websocket connections served
+// at once, in total and to any single IP address. New connections past either
+// limit are refused. A limit of zero or less is unlimited.
+func WithMaxWebsocketConnections(max, maxPerIP int) Option {
+	return func(g *Gateway) {
+		g.maxWebsocketConnections = max
+		g.maxWebsocketConnectionsPerIP = maxPerIP
+	}
+}
+
+// WithWebsocketIdleTimeout sets the duration a websocket client may go without
+// responding to a ping before it is disconnected. Clients are pinged at 9/10ths
+// of the timeout. A timeout of zero or less keeps the default.
+func WithWebsocketIdleTimeout(timeout time.Duration) Option {
+	return func(g *Gateway) {
+		if timeout > 0 {
+			g.websocketIdleTimeout = timeout
+		}
+	}
+}
+
+// WithRateLimits sets the max number of requests per second a single IP address
+// may make to each route which reads from the node, and the max number of
+// requests per second a single client may make to each route which sends
+// transactions paid for by the node. Clients sending transactions are told
+// apart by the API key they present, or by their IP address otherwise. A limit
+// of zero or less keeps the default.
+func WithRateLimits(readsPerSec, sendsPerSec float64) Option {
+	return func(g *Gateway) {
+		if readsPerSec > 0 {
+			g.rateLimiter = newRateLimiter(readsPerSec)
+		}
+
+		if sendsPerSec > 0 {
+			g.sendRateLimiter = newRateLimiter(sendsPerSec)
+		}
+	}
+}
+
+func New(opts ...Option) *Gateway {
+	g := &Gateway{
+		sinks:           make(map[string]*sink),
+		parserPool:      new(fastjson.ParserPool),
+		arenaPool:       new(fastjson.ArenaPool),
+		rateLimiter:     newRateLimiter(DefaultReadRateLimit),
+		sendRateLimiter: newRateLimiter(DefaultSendRateLimit),
+		metrics:         metrics.NewRegistry(),
+		accessLog:       newAccessLogger(),
+		routeLimits:     defaultRouteLimits(),
+
+		maxWebsocketConnections:      DefaultMaxWebsocketConnections,
+		maxWebsocketConnectionsPerIP: DefaultMaxWebsocketConnectionsPerIP,
+		websocketIdleTimeout:         DefaultWebsocketIdleTimeout,
+
+		shutdown: make(chan struct{}),
+
+		apiKeys: make(map[[32]byte]map[Scope]struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(g)
 	}
+
+	g.websocketLimiter = newConnLimiter(g.maxWebsocketConnections, g.maxWebsocketConnectionsPerIP)
+	g.idleConns = &idleConns{conns: make(map[net.Conn]struct{})}
+
+	return g
 }
 
 func (g *Gateway) setup() {
@@ -84,6 +216,7 @@ func (g *Gateway) setup() {
 			debounce.WithKeys("contract_id"),
 		),
 	)
+	sinkContractEvents := g.registerWebsocketSink("ws://contract_events/?id=contract_id&tx=tx_id&topic=topic", nil)
 	sinkTransactions := g.registerWebsocketSink("ws://tx/?id=tx_id&sender=sender_id&creator=creator_id&tag=tag",
 		debounce.NewFactory(debounce.TypeLimiter,
 			debounce.WithPeriod(2200*time.Millisecond),
@@ -96,7 +229,10 @@ func (g *Gateway) setup() {
 
 	// Setup HTTP router.
 
-	r := fasthttprouter.New()
+	// Routes are served under /v1, and without a prefix for clients written
+	// before the API was versioned.
+	r := newVersionedRouter(&instrumentedRouter{Router: fasthttprouter.New(), registry: g.metrics, accessLog: g.accessLog})
+	v1 := r.version(1)
 
 	// If the route does not exist for a method type (e.g. OPTIONS), fasthttprouter will consider it to not exist.
 	// So, we need to override notFound handler for OPTIONS method type to handle CORS.
@@ -104,38 +240,100 @@ func (g *Gateway) setup() {
 	r.NotFound = g.notFound()
 
 	// Websocket endpoints.
-	r.GET("/poll/network", g.applyMiddleware(g.poll(sinkNetwork), "/poll/network"))
-	r.GET("/poll/consensus", g.applyMiddleware(g.poll(sinkConsensus), "/poll/consensus"))
-	r.GET("/poll/stake", g.applyMiddleware(g.poll(sinkStake), "/poll/stake"))
-	r.GET("/poll/accounts", g.applyMiddleware(g.poll(sinkAccounts), "/poll/accounts"))
-	r.GET("/poll/contract", g.applyMiddleware(g.poll(sinkContracts), "/poll/contract"))
-	r.GET("/poll/tx", g.applyMiddleware(g.poll(sinkTransactions), "/poll/tx"))
-	r.GET("/poll/metrics", g.applyMiddleware(g.poll(sinkMetrics), "/poll/metrics"))
+	v1.GET("/poll/network", g.applyMiddleware(g.poll(sinkNetwork), "/poll/network", g.requireScope(ScopeRead)))
+	v1.GET("/poll/consensus", g.applyMiddleware(g.poll(sinkConsensus), "/poll/consensus", g.requireScope(ScopeRead)))
+	v1.GET("/poll/stake", g.applyMiddleware(g.poll(sinkStake), "/poll/stake", g.requireScope(ScopeRead)))
+	v1.GET("/poll/accounts", g.applyMiddleware(g.poll(sinkAccounts), "/poll/accounts", g.requireScope(ScopeRead)))
+	v1.GET("/poll/contract", g.applyMiddleware(g.poll(sinkContracts), "/poll/contract", g.requireScope(ScopeRead)))
+	v1.GET("/poll/contract_events", g.applyMiddleware(g.poll(sinkContractEvents), "/poll/contract_events", g.requireScope(ScopeRead)))
+	v1.GET("/poll/tx", g.applyMiddleware(g.poll(sinkTransactions), "/poll/tx", g.requireScope(ScopeRead)))
+	v1.GET("/poll/metrics", g.applyMiddleware(g.poll(sinkMetrics), "/poll/metrics", g.requireScope(ScopeRead)))
+
+	// Server-sent event endpoints, mirroring the websocket endpoints.
+	v1.GET("/sse/network", g.applyMiddleware(g.events(sinkNetwork), "/sse/network", g.requireScope(ScopeRead)))
+	v1.GET("/sse/consensus", g.applyMiddleware(g.events(sinkConsensus), "/sse/consensus", g.requireScope(ScopeRead)))
+	v1.GET("/sse/stake", g.applyMiddleware(g.events(sinkStake), "/sse/stake", g.requireScope(ScopeRead)))
+	v1.GET("/sse/accounts", g.applyMiddleware(g.events(sinkAccounts), "/sse/accounts", g.requireScope(ScopeRead)))
+	v1.GET("/sse/contract", g.applyMiddleware(g.events(sinkContracts), "/sse/contract", g.requireScope(ScopeRead)))
+	v1.GET("/sse/contract_events", g.applyMiddleware(g.events(sinkContractEvents), "/sse/contract_events", g.requireScope(ScopeRead)))
+	v1.GET("/sse/tx", g.applyMiddleware(g.events(sinkTransactions), "/sse/tx", g.requireScope(ScopeRead)))
+	v1.GET("/sse/metrics", g.applyMiddleware(g.events(sinkMetrics), "/sse/metrics", g.requireScope(ScopeRead)))
+
+	// Probe endpoints. They are not rate limited, nor do they require an API
+	// key, such that orchestrators polling them may not starve out, or be
+	// starved out by, other clients. Neither they nor /debug are versioned.
+	r.GET("/healthz", g.applyMiddleware(g.healthz, ""))
+	r.GET("/readyz", g.applyMiddleware(g.readyz, ""))
 
 	// Debug endpoint.
-	r.GET("/debug/*p", g.applyMiddleware(pprofhandler.PprofHandler, "/debug/*p"))
+	r.GET("/debug/*p", g.applyMiddleware(debugHandler, "/debug/*p", g.requireScope(ScopeAdmin)))
 
 	// Ledger endpoint.
-	r.GET("/ledger", g.applyMiddleware(g.ledgerStatus, "/ledger"))
+	v1.GET("/ledger", g.applyMiddleware(g.ledgerStatus, "/ledger", g.requireScope(ScopeRead), g.limit(RouteGroupRead)))
+	v1.GET("/ledger/state", g.applyMiddleware(g.ledgerState, "/ledger/state", g.requireScope(ScopeRead), g.limit(RouteGroupRead)))
+	v1.GET("/ledger/diff", g.applyMiddleware(g.ledgerDiff, "/ledger/diff", g.requireScope(ScopeRead), g.limit(RouteGroupRead)))
+	v1.GET("/ledger/beacon", g.applyMiddleware(g.randomBeacon, "/ledger/beacon", g.requireScope(ScopeRead), g.limit(RouteGroupRead)))
+	v1.GET("/ledger/rounds/:index/diff", g.applyMiddleware(g.roundDiff, "/ledger/rounds/:index/diff", g.requireScope(ScopeRead), g.limit(RouteGroupRead)))
+	v1.GET("/ledger/rounds/:index/changes", g.applyMiddleware(g.roundChanges, "/ledger/rounds/:index/changes", g.requireScope(ScopeRead), g.limit(RouteGroupRead)))
+	v1.GET("/network/stats", g.applyMiddleware(g.networkStats, "/network/stats", g.requireScope(ScopeRead), g.limit(RouteGroupRead)))
+
+	// Node endpoints.
+	v1.POST("/node/connect", g.applyMiddleware(g.connect, "/node/connect", g.requireScope(ScopeAdmin), g.limit(RouteGroupAdmin)))
+	v1.GET("/node/params", g.applyMiddleware(g.getParams, "/node/params", g.requireScope(ScopeAdmin), g.limit(RouteGroupAdmin)))
+	v1.GET("/node/history", g.applyMiddleware(g.getMetricsHistory, "/node/history", g.requireScope(ScopeRead), g.limit(RouteGroupAdmin)))
+	v1.PUT("/node/params", g.applyMiddleware(g.tuneParams, "/node/params", g.requireScope(ScopeAdmin), g.limit(RouteGroupAdmin)))
+	v1.GET("/node/backup", g.applyMiddleware(g.backup, "/node/backup", g.requireScope(ScopeAdmin), g.limit(RouteGroupAdmin)))
+	v1.POST("/node/verify-state", g.applyMiddleware(g.verifyState, "/node/verify-state", g.requireScope(ScopeAdmin), g.limit(RouteGroupAdmin)))
+	v1.POST("/node/promote", g.applyMiddleware(g.promote, "/node/promote", g.requireScope(ScopeAdmin), g.limit(RouteGroupAdmin)))
+	v1.GET("/node/peers", g.applyMiddleware(g.listPeers, "/node/peers", g.requireScope(ScopeAdmin), g.limit(RouteGroupAdmin)))
+	v1.DELETE("/node/peers/:id", g.applyMiddleware(g.disconnectPeer, "/node/peers/:id", g.requireScope(ScopeAdmin), g.peerScope, g.limit(RouteGroupAdmin)))
+	v1.POST("/node/peers/:id/ban", g.applyMiddleware(g.banPeer, "/node/peers/:id/ban", g.requireScope(ScopeAdmin), g.peerScope, g.limit(RouteGroupAdmin)))
+	v1.DELETE("/node/peers/:id/ban", g.applyMiddleware(g.unbanPeer, "/node/peers/:id/ban", g.requireScope(ScopeAdmin), g.peerScope, g.limit(RouteGroupAdmin)))
+	v1.GET("/node/peers/:id/stats", g.applyMiddleware(g.getPeerStats, "/node/peers/:id/stats", g.requireScope(ScopeRead), g.peerScope, g.limit(RouteGroupAdmin)))
 
 	// Account endpoints.
-	r.GET("/accounts/:id", g.applyMiddleware(g.getAccount, ""))
+	v1.GET("/accounts/:id", g.applyMiddleware(g.getAccount, "/accounts/:id", g.requireScope(ScopeRead), g.accountScope, g.limit(RouteGroupRead)))
+	v1.POST("/accounts/batch", g.applyMiddleware(g.getAccounts, "/accounts/batch", g.requireScope(ScopeRead), g.limit(RouteGroupRead)))
+	v1.GET("/accounts/:id/history", g.applyMiddleware(g.getAccountHistory, "/accounts/:id/history", g.requireScope(ScopeRead), g.accountScope, g.limit(RouteGroupRead)))
+	v1.GET("/accounts/:id/proof", g.applyMiddleware(g.getAccountProof, "/accounts/:id/proof", g.requireScope(ScopeRead), g.accountScope, g.limit(RouteGroupRead)))
+	v1.GET("/accounts/:id/tokens", g.applyMiddleware(g.getAccountTokens, "/accounts/:id/tokens", g.requireScope(ScopeRead), g.accountScope, g.limit(RouteGroupRead)))
+
+	// Token endpoints.
+	v1.GET("/tokens/:id", g.applyMiddleware(g.getToken, "/tokens/:id", g.requireScope(ScopeRead), g.tokenScope, g.limit(RouteGroupRead)))
 
 	// Contract endpoints.
-	r.GET("/contract/:id/page/:index", g.applyMiddleware(g.getContractPages, "/contract/:id/page/:index", g.contractScope))
-	r.GET("/contract/:id/page", g.applyMiddleware(g.getContractPages, "/contract/:id/page", g.contractScope))
-	r.GET("/contract/:id", g.applyMiddleware(g.getContractCode, "/contract/:id", g.contractScope))
+	v1.POST("/contract", g.applyMiddleware(g.uploadContract, "/contract", g.requireScope(ScopeSend), g.sendRateLimiter.limit("/contract", byAPIKey), g.limit(RouteGroupContract)))
+	v1.POST("/contract/:id/call", g.applyMiddleware(g.callContract, "/contract/:id/call", g.requireScope(ScopeRead), g.contractScope, g.limit(RouteGroupRead)))
+	v1.GET("/contract/:id/events", g.applyMiddleware(g.getContractEvents, "/contract/:id/events", g.requireScope(ScopeRead), g.contractScope, g.limit(RouteGroupRead)))
+	v1.GET("/contract/:id/page/:index", g.applyMiddleware(g.getContractPages, "/contract/:id/page/:index", g.requireScope(ScopeRead), g.contractScope, g.limit(RouteGroupRead)))
+	v1.GET("/contract/:id/page", g.applyMiddleware(g.getContractPages, "/contract/:id/page", g.requireScope(ScopeRead), g.contractScope, g.limit(RouteGroupRead)))
+	v1.GET("/contract/:id", g.applyMiddleware(g.getContractCode, "/contract/:id", g.requireScope(ScopeRead), g.contractScope, g.limit(RouteGroupRead)))
 
 	// Transaction endpoints.
-	r.POST("/tx/send", g.applyMiddleware(g.sendTransaction, ""))
-	r.GET("/tx/:id", g.applyMiddleware(g.getTransaction, ""))
-	r.GET("/tx", g.applyMiddleware(g.listTransactions, "/tx"))
+	v1.POST("/tx/send", g.applyMiddleware(g.sendTransaction, "/tx/send", g.requireScope(ScopeSend), g.sendRateLimiter.limit("/tx/send", byAPIKey), g.limit(RouteGroupSend)))
+	v1.GET("/tx/:id", g.applyMiddleware(g.getTransaction, "/tx/:id", g.requireScope(ScopeRead), g.limit(RouteGroupRead)))
+	v1.GET("/tx/:id/graph", g.applyMiddleware(g.getTransactionGraph, "/tx/:id/graph", g.requireScope(ScopeRead), g.limit(RouteGroupRead)))
+	v1.GET("/tx/:id/status", g.applyMiddleware(g.getTransactionStatus, "/tx/:id/status", g.requireScope(ScopeRead), g.limit(RouteGroupRead)))
+	v1.GET("/tx/:id/receipt", g.applyMiddleware(g.getTransactionReceipt, "/tx/:id/receipt", g.requireScope(ScopeRead), g.limit(RouteGroupRead)))
+	v1.GET("/tx", g.applyMiddleware(g.listTransactions, "/tx", g.requireScope(ScopeRead), g.limit(RouteGroupRead)))
+	v1.GET("/mempool", g.applyMiddleware(g.getMempool, "/mempool", g.requireScope(ScopeRead), g.limit(RouteGroupRead)))
+
+	// Faucet endpoint, for test networks only.
+	if g.faucet != nil {
+		v1.POST("/faucet", g.applyMiddleware(g.faucetTransfer, "/faucet", g.requireScope(ScopeSend), g.sendRateLimiter.limit("/faucet", byIP), g.limit(RouteGroupSend)))
+	}
+
+	// GraphQL endpoint.
+	v1.GET("/graphql", g.applyMiddleware(g.graphql, "/graphql", g.requireScope(ScopeRead), g.limit(RouteGroupRead)))
+	v1.POST("/graphql", g.applyMiddleware(g.graphql, "/graphql", g.requireScope(ScopeRead), g.limit(RouteGroupRead)))
 
-	g.router = r
+	r.registerShims(g)
+
+	g.router = r.Router
 }
 
 // Apply base middleware to the handler and along with middleware passed.
-// If rateLimiterKey is not empty, enable rate limit.
+// If rateLimiterKey is not empty, enable rate limit by IP address.
 func (g *Gateway) applyMiddleware(f fasthttp.RequestHandler, rateLimiterKey string, m ...middleware) fasthttp.RequestHandler {
 	var list []middleware
 
@@ -149,15 +347,11 @@ func (g *Gateway) applyMiddleware(f fasthttp.RequestHandler, rateLimiterKey stri
 		// Rate limiter middleware should be after recoverer and before anything else
 		list = []middleware{
 			recoverer,
-			g.rateLimiter.limit(rateLimiterKey),
+			g.rateLimiter.limit(rateLimiterKey, byIP),
 			cors(),
 		}
 	}
 
-	if g.enableTimeout {
-		list = append(list, timeout(60*time.Second, "Request timeout!"))
-	}
-
 	if len(m) > 0 {
 		for i := range m {
 			list = append(list, m[i])
@@ -167,35 +361,168 @@ func (g *Gateway) applyMiddleware(f fasthttp.RequestHandler, rateLimiterKey stri
 	return chain(f, list)
 }
 
-func (g *Gateway) StartHTTP(port int, c *skademlia.Client, l *wavelet.Ledger, k *skademlia.Keypair) {
-	stop := g.rateLimiter.cleanup(10 * time.Minute)
-	defer stop()
+// debugHandler serves all variables exported through expvar, such as database
+// metrics, under /debug/vars, and runtime profiling data otherwise.
+func debugHandler(ctx *fasthttp.RequestCtx) {
+	if string(ctx.Path()) == "/debug/vars" {
+		expvarhandler.ExpvarHandler(ctx)
+		return
+	}
+
+	pprofhandler.PprofHandler(ctx)
+}
 
+// StartHTTP starts serving the HTTP API on port, over HTTPS should the gateway
+// have been configured through WithTLS. It returns once the server is
+// listening, and serves requests until the gateway is shut down.
+func (g *Gateway) StartHTTP(port int, c *skademlia.Client, l *wavelet.Ledger, k *skademlia.Keypair) (*fasthttp.Server, error) {
 	g.client = c
 	g.ledger = l
 
 	g.keys = k
 
-	g.enableTimeout = false
 	g.setup()
 
+	// Request bodies are limited per route group; the server only rejects
+	// those which no route group would accept.
+	g.server = &fasthttp.Server{
+		Handler:            g.router.Handler,
+		MaxRequestBodySize: g.maxRequestBodySize(),
+		ConnState:          g.idleConns.track,
+	}
+
+	tls := len(g.tlsCertFile) > 0 || len(g.tlsKeyFile) > 0
+
+	if tls {
+		if err := g.server.AppendCert(g.tlsCertFile, g.tlsKeyFile); err != nil {
+			return nil, errors.Wrap(err, "failed to load tls certificate")
+		}
+	}
+
+	listener, err := net.Listen("tcp", ":"+strconv.Itoa(port))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to listen for http requests")
+	}
+
 	logger := log.Node()
-	logger.Info().Int("port", port).Msg("Started HTTP API server.")
+	logger.Info().Int("port", port).Bool("tls", tls).Msg("Started HTTP API server.")
 
-	g.server = &fasthttp.Server{
-		Handler: g.router.Handler,
+	if g.grpcPort > 0 {
+		go g.startGRPC(g.grpcPort)
+	}
+
+	go func() {
+		stop := g.rateLimiter.cleanup(10 * time.Minute)
+		defer stop()
+
+		stopSend := g.sendRateLimiter.cleanup(10 * time.Minute)
+		defer stopSend()
+
+		var err error
+
+		if tls {
+			err = g.server.ServeTLS(listener, "", "")
+		} else {
+			err = g.server.Serve(listener)
+		}
+
+		if err != nil {
+			logger.Error().Err(err).Msg("HTTP API server stopped serving requests.")
+		}
+	}()
+
+	return g.server, nil
+}
+
+// Shutdown gracefully shuts down the gateway. Server-sent event streams are
+// ended, and websocket clients are sent a close message, after which requests
+// in flight are drained. Should ctx be done before the gateway has shut down,
+// the gRPC API is stopped outright and ctx.Err() is returned.
+func (g *Gateway) Shutdown(ctx context.Context) error {
+	g.shutdownOnce.Do(func() { close(g.shutdown) })
+
+	done := make(chan error, 1)
+
+	go func() {
+		if g.grpcServer != nil {
+			g.grpcServer.GracefulStop()
+		}
+
+		var err error
+
+		if g.server != nil {
+			err = g.shutdownHTTP()
+		}
+
+		// Websocket connections are hijacked from the server, and are
+		// therefore not waited on by it.
+		g.websocketLimiter.wait()
+
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		if g.grpcServer != nil {
+			g.grpcServer.Stop()
+		}
+
+		return ctx.Err()
 	}
+}
 
-	if err := g.server.ListenAndServe(":" + strconv.Itoa(port)); err != nil {
-		logger.Fatal().Err(err).Msg("Failed to start HTTP server.")
+// shutdownHTTP shuts down the HTTP server once all requests in flight have been
+// served. The server otherwise waits on idle keep-alive connections to send
+// another request, which are therefore closed until it has shut down.
+func (g *Gateway) shutdownHTTP() error {
+	done := make(chan error, 1)
+
+	go func() {
+		done <- g.server.Shutdown()
+	}()
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case err := <-done:
+			return err
+		case <-ticker.C:
+			g.idleConns.close()
+		}
 	}
 }
 
-func (g *Gateway) Shutdown() {
-	if g.server == nil {
-		return
+// idleConns tracks connections to the HTTP server which are not serving a
+// request.
+type idleConns struct {
+	sync.Mutex
+	conns map[net.Conn]struct{}
+}
+
+func (c *idleConns) track(conn net.Conn, state fasthttp.ConnState) {
+	c.Lock()
+	defer c.Unlock()
+
+	switch state {
+	case fasthttp.StateNew, fasthttp.StateIdle:
+		c.conns[conn] = struct{}{}
+	default:
+		delete(c.conns, conn)
+	}
+}
+
+func (c *idleConns) close() {
+	c.Lock()
+	defer c.Unlock()
+
+	for conn := range c.conns {
+		_ = conn.Close()
+		delete(c.conns, conn)
 	}
-	_ = g.server.Shutdown()
 }
 
 func (g *Gateway) sendTransaction(ctx *fasthttp.RequestCtx) {
@@ -215,30 +542,175 @@ func (g *Gateway) sendTransaction(ctx *fasthttp.RequestCtx) {
 		return
 	}
 
+	if g.ledger != nil && expired(g.ledger, req.ValidUntilView) {
+		g.renderError(ctx, ErrBadRequest(errors.Wrapf(wavelet.ErrTransactionExpired, "tx is valid until round %d", req.ValidUntilView)))
+		return
+	}
+
+	tx, err := g.addTransaction(req.Tag, req.payload, req.ValidUntilView, req.creator, req.signature)
+	if err != nil {
+		g.renderError(ctx, ErrInternal(err))
+		return
+	}
+
+	g.render(ctx, &sendTransactionResponse{ledger: g.ledger, tx: &tx})
+}
+
+// expired returns whether or not a transaction valid until the round indexed
+// validUntilView may no longer be applied in the next round to be finalized.
+func expired(ledger *wavelet.Ledger, validUntilView uint64) bool {
+	tx := wavelet.Transaction{ValidUntilView: validUntilView}
+	return tx.Expired(ledger.Rounds().Latest().Index + 1)
+}
+
+// addTransaction has the node attach itself as the sender of a transaction
+// signed by its creator, and add it to the ledger.
+func (g *Gateway) addTransaction(tag byte, payload []byte, validUntilView uint64, creator wavelet.AccountID, signature wavelet.Signature) (wavelet.Transaction, error) {
+	tx := wavelet.AttachSenderToTransaction(
+		g.keys,
+		wavelet.Transaction{Tag: tag, Payload: payload, ValidUntilView: validUntilView, Creator: creator, CreatorSignature: signature},
+		g.ledger.Graph().FindEligibleParents()...,
+	)
+
+	err := g.ledger.AddTransaction(tx)
+
+	if err != nil && errors.Cause(err) != wavelet.ErrMissingParents {
+		return tx, errors.Wrap(err, "error adding your transaction to graph")
+	}
+
+	return tx, nil
+}
+
+func (g *Gateway) uploadContract(ctx *fasthttp.RequestCtx) {
+	req := new(uploadContractRequest)
+
+	if g.ledger != nil && g.ledger.TakeSendToken() == false {
+		g.renderError(ctx, ErrInternal(errors.New("rate limit")))
+		return
+	}
+
+	parser := g.parserPool.Get()
+	err := req.bind(parser, ctx)
+	g.parserPool.Put(parser)
+
+	if err != nil {
+		g.renderError(ctx, ErrBadRequest(err))
+		return
+	}
+
+	if err := wavelet.ValidateContractCode(req.code); err != nil {
+		g.renderError(ctx, ErrBadRequest(err))
+		return
+	}
+
+	if g.ledger == nil || g.keys == nil {
+		g.renderError(ctx, ErrInternal(errors.New("node is not ready to spawn smart contracts")))
+		return
+	}
+
 	tx := wavelet.AttachSenderToTransaction(
 		g.keys,
-		wavelet.Transaction{Tag: req.Tag, Payload: req.payload, Creator: req.creator, CreatorSignature: req.signature},
+		wavelet.NewTransaction(g.keys, sys.TagContract, req.payload()),
 		g.ledger.Graph().FindEligibleParents()...,
 	)
 
 	err = g.ledger.AddTransaction(tx)
 
 	if err != nil && errors.Cause(err) != wavelet.ErrMissingParents {
-		g.renderError(ctx, ErrInternal(errors.Wrap(err, "error adding your transaction to graph")))
+		g.renderError(ctx, ErrInternal(errors.Wrap(err, "error adding your contract to graph")))
 		return
 	}
 
-	g.render(ctx, &sendTransactionResponse{ledger: g.ledger, tx: &tx})
+	g.render(ctx, &uploadContractResponse{sendTransactionResponse{ledger: g.ledger, tx: &tx}})
+}
+
+// healthz responds so long as the node is running.
+func (g *Gateway) healthz(ctx *fasthttp.RequestCtx) {
+	g.render(ctx, &healthResponse{})
+}
+
+// readyz responds with whether or not the node is ready to serve requests,
+// which is when it has caught up to the latest round of the network and is
+// connected to at least as many peers as it queries for consensus. Should
+// it not be ready, it responds with a 503 status code.
+func (g *Gateway) readyz(ctx *fasthttp.RequestCtx) {
+	res := &readinessResponse{
+		synced:   g.ledger.Synced(),
+		numPeers: len(g.client.ClosestPeerIDs()),
+		minPeers: sys.Params().SnowballK,
+		round:    g.ledger.Rounds().Latest().Index,
+	}
+
+	g.render(ctx, res)
+
+	if !res.ready() {
+		ctx.Response.SetStatusCode(http.StatusServiceUnavailable)
+	}
 }
 
 func (g *Gateway) ledgerStatus(ctx *fasthttp.RequestCtx) {
 	g.render(ctx, &ledgerStatusResponse{client: g.client, ledger: g.ledger, publicKey: g.keys.PublicKey()})
 }
 
+// ledgerState renders only what has changed in the ledger since the round
+// given by the query parameter since, such that the ledger may be monitored by
+// polling it cheaply.
+func (g *Gateway) ledgerState(ctx *fasthttp.RequestCtx) {
+	var since uint64
+	var err error
+
+	if raw := string(ctx.QueryArgs().Peek("since")); len(raw) > 0 {
+		since, err = strconv.ParseUint(raw, 10, 64)
+
+		if err != nil {
+			g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "could not parse since")))
+			return
+		}
+	}
+
+	res := &ledgerStateResponse{since: since, latest: g.ledger.Rounds().Latest()}
+
+	if res.latest.Index > since {
+		rootDepth := g.ledger.Graph().RootDepth()
+
+		res.rounds = g.ledger.Rounds().Since(since)
+		res.numAccounts = wavelet.ReadAccountsLen(g.ledger.Snapshot())
+		res.numTx = g.ledger.Graph().DepthLen(&rootDepth, nil)
+		res.numMissingTx = g.ledger.Graph().MissingLen()
+	}
+
+	g.render(ctx, res)
+}
+
+// randomBeacon responds with the random beacon of the latest round of the
+// ledger, which smart contracts executed in the next round see through the
+// _random_beacon host function.
+func (g *Gateway) randomBeacon(ctx *fasthttp.RequestCtx) {
+	g.render(ctx, &randomBeaconResponse{
+		round:  g.ledger.Rounds().Latest().Index,
+		beacon: wavelet.ReadRandomBeacon(g.ledger.Snapshot()),
+	})
+}
+
+func (g *Gateway) networkStats(ctx *fasthttp.RequestCtx) {
+	res := &networkStatsResponse{
+		round:  g.ledger.Rounds().Latest(),
+		tps:    g.ledger.TPS(),
+		synced: g.ledger.Synced(),
+	}
+
+	if g.client != nil {
+		res.numPeers = len(g.client.ClosestPeerIDs())
+	}
+
+	g.render(ctx, res)
+}
+
 func (g *Gateway) listTransactions(ctx *fasthttp.RequestCtx) {
 	var sender wavelet.AccountID
 	var creator wavelet.AccountID
 	var offset, limit uint64
+	var tags []byte
 	var err error
 
 	queryArgs := ctx.QueryArgs()
@@ -274,6 +746,22 @@ func (g *Gateway) listTransactions(ctx *fasthttp.RequestCtx) {
 		copy(creator[:], slice)
 	}
 
+	if raw := string(queryArgs.Peek("tag")); len(raw) > 0 {
+		tag, err := strconv.ParseUint(raw, 10, 8)
+
+		if err != nil {
+			g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "could not parse tag")))
+			return
+		}
+
+		if tag > uint64(sys.TagTransferToken) {
+			g.renderError(ctx, ErrBadRequest(errors.Errorf("unknown transaction tag %d", tag)))
+			return
+		}
+
+		tags = append(tags, byte(tag))
+	}
+
 	if raw := string(queryArgs.Peek("offset")); len(raw) > 0 {
 		offset, err = strconv.ParseUint(raw, 10, 64)
 
@@ -300,17 +788,62 @@ func (g *Gateway) listTransactions(ctx *fasthttp.RequestCtx) {
 
 	var transactions transactionList
 
-	for _, tx := range g.ledger.Graph().ListTransactions(offset, limit, sender, creator) {
-		status := "received"
+	for _, tx := range g.ledger.Graph().ListTransactions(offset, limit, sender, creator, tags...) {
+		transactions = append(transactions, &transaction{tx: tx, status: transactionStatus(tx, rootDepth)})
+	}
+
+	g.render(ctx, transactions)
+}
+
+// getMempool responds with the transactions this node has received which are
+// yet to be finalized, ordered from the earliest to the latest received or by
+// their fees should order be "fee", and their counts by tag and age.
+func (g *Gateway) getMempool(ctx *fasthttp.RequestCtx) {
+	var offset, limit uint64
+	var err error
+
+	queryArgs := ctx.QueryArgs()
+
+	if raw := string(queryArgs.Peek("offset")); len(raw) > 0 {
+		offset, err = strconv.ParseUint(raw, 10, 64)
 
-		if tx.Depth <= rootDepth {
-			status = "applied"
+		if err != nil {
+			g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "could not parse offset")))
+			return
 		}
+	}
+
+	if raw := string(queryArgs.Peek("limit")); len(raw) > 0 {
+		limit, err = strconv.ParseUint(raw, 10, 64)
 
-		transactions = append(transactions, &transaction{tx: tx, status: status})
+		if err != nil {
+			g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "could not parse limit")))
+			return
+		}
 	}
 
-	g.render(ctx, transactions)
+	if limit == 0 || limit > maxPaginationLimit {
+		limit = maxPaginationLimit
+	}
+
+	var pending []wavelet.PendingTransaction
+
+	switch order := string(queryArgs.Peek("order")); order {
+	case "", "received":
+		pending = g.ledger.Graph().PendingTransactions()
+	case "fee":
+		pending = g.ledger.Graph().PendingTransactionsByFee()
+	default:
+		g.renderError(ctx, ErrBadRequest(errors.Errorf("unknown order %q, must be either received or fee", order)))
+		return
+	}
+
+	g.render(ctx, &mempoolResponse{
+		pending: pending,
+		offset:  offset,
+		limit:   limit,
+		now:     time.Now(),
+	})
 }
 
 func (g *Gateway) getTransaction(ctx *fasthttp.RequestCtx) {
@@ -345,16 +878,18 @@ func (g *Gateway) getTransaction(ctx *fasthttp.RequestCtx) {
 
 	res := &transaction{tx: tx}
 
-	if tx.Depth <= rootDepth {
-		res.status = "applied"
-	} else {
-		res.status = "received"
+	if timings, sampled := g.ledger.TransactionTimings(id); sampled {
+		res.timings = &timings
 	}
 
+	res.rejections = g.ledger.TransactionRejections(id)
+
+	res.status = transactionStatus(tx, rootDepth)
+
 	g.render(ctx, res)
 }
 
-func (g *Gateway) getAccount(ctx *fasthttp.RequestCtx) {
+func (g *Gateway) getTransactionGraph(ctx *fasthttp.RequestCtx) {
 	param, ok := ctx.UserValue("id").(string)
 	if !ok {
 		g.renderError(ctx, ErrBadRequest(errors.New("id must be a string")))
@@ -363,61 +898,777 @@ func (g *Gateway) getAccount(ctx *fasthttp.RequestCtx) {
 
 	slice, err := hex.DecodeString(param)
 	if err != nil {
-		g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "account ID must be presented as valid hex")))
+		g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "transaction ID must be presented as valid hex")))
 		return
 	}
 
-	if len(slice) != wavelet.SizeAccountID {
-		g.renderError(ctx, ErrBadRequest(errors.Errorf("account ID must be %d bytes long", wavelet.SizeAccountID)))
+	if len(slice) != wavelet.SizeTransactionID {
+		g.renderError(ctx, ErrBadRequest(errors.Errorf("transaction ID must be %d bytes long", wavelet.SizeTransactionID)))
 		return
 	}
 
-	var id wavelet.AccountID
+	var id wavelet.TransactionID
 	copy(id[:], slice)
 
-	g.render(ctx, &account{ledger: g.ledger, id: id})
-}
+	depth := uint64(1)
 
-func (g *Gateway) contractScope(next fasthttp.RequestHandler) fasthttp.RequestHandler {
-	return fasthttp.RequestHandler(func(ctx *fasthttp.RequestCtx) {
-		param, ok := ctx.UserValue("id").(string)
-		if !ok {
-			g.renderError(ctx, ErrBadRequest(errors.New("could not cast id into string")))
-			return
-		}
+	if raw := string(ctx.QueryArgs().Peek("depth")); len(raw) > 0 {
+		depth, err = strconv.ParseUint(raw, 10, 64)
 
-		slice, err := hex.DecodeString(param)
 		if err != nil {
-			g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "contract ID must be presented as valid hex")))
+			g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "could not parse depth")))
 			return
 		}
 
-		if len(slice) != wavelet.SizeTransactionID {
-			g.renderError(ctx, ErrBadRequest(errors.Errorf("contract ID must be %d bytes long", wavelet.SizeTransactionID)))
+		if depth == 0 || depth > maxTransactionGraphDepth {
+			g.renderError(ctx, ErrBadRequest(errors.Errorf("depth must be between 1 and %d", maxTransactionGraphDepth)))
 			return
 		}
+	}
 
-		var contractID wavelet.TransactionID
-		copy(contractID[:], slice)
-
-		ctx.SetUserValue("contract_id", contractID)
-
-		next(ctx)
-	})
-}
+	tx, ancestors, descendants := g.ledger.Graph().FindNeighborhood(id, int(depth), maxPaginationLimit)
 
-func (g *Gateway) getContractCode(ctx *fasthttp.RequestCtx) {
-	id, ok := ctx.UserValue("contract_id").(wavelet.TransactionID)
-	if !ok {
-		g.renderError(ctx, ErrBadRequest(errors.New("id must be a TransactionID")))
+	if tx == nil {
+		g.renderError(ctx, ErrNotFound(errors.Errorf("could not find transaction with ID %x", id)))
 		return
 	}
 
-	code, available := wavelet.ReadAccountContractCode(g.ledger.Snapshot(), id)
+	rootDepth := g.ledger.Graph().RootDepth()
 
-	if len(code) == 0 || !available {
-		g.renderError(ctx, ErrNotFound(errors.Errorf("could not find contract with ID %x", id)))
-		return
+	res := &transactionGraph{transaction: &transaction{tx: tx, status: transactionStatus(tx, rootDepth)}}
+
+	for _, tx := range ancestors {
+		res.ancestors = append(res.ancestors, &transaction{tx: tx, status: transactionStatus(tx, rootDepth)})
+	}
+
+	for _, tx := range descendants {
+		res.descendants = append(res.descendants, &transaction{tx: tx, status: transactionStatus(tx, rootDepth)})
+	}
+
+	g.render(ctx, res)
+}
+
+// graphql executes a query against a snapshot of the ledger, such that clients
+// may look up accounts, transactions, contracts and rounds alongside their
+// relationships with one another in a single request.
+func (g *Gateway) graphql(ctx *fasthttp.RequestCtx) {
+	req := new(graphqlRequest)
+
+	parser := g.parserPool.Get()
+	err := req.bind(parser, ctx)
+	g.parserPool.Put(parser)
+
+	if err != nil {
+		g.renderError(ctx, ErrBadRequest(err))
+		return
+	}
+
+	fields, err := parseGraphQL(req.query, req.variables)
+	if err != nil {
+		g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "invalid query")))
+		return
+	}
+
+	g.render(ctx, &graphqlResponse{root: &gqlQuery{ledger: g.ledger, snapshot: g.ledger.Snapshot()}, fields: fields})
+}
+
+// getTransactionStatus responds with the lifecycle status of a transaction,
+// such that clients may poll for when it is finalized.
+func (g *Gateway) getTransactionStatus(ctx *fasthttp.RequestCtx) {
+	param, ok := ctx.UserValue("id").(string)
+	if !ok {
+		g.renderError(ctx, ErrBadRequest(errors.New("id must be a string")))
+		return
+	}
+
+	slice, err := hex.DecodeString(param)
+	if err != nil {
+		g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "transaction ID must be presented as valid hex")))
+		return
+	}
+
+	if len(slice) != wavelet.SizeTransactionID {
+		g.renderError(ctx, ErrBadRequest(errors.Errorf("transaction ID must be %d bytes long", wavelet.SizeTransactionID)))
+		return
+	}
+
+	var id wavelet.TransactionID
+	copy(id[:], slice)
+
+	status, round := g.ledger.TransactionStatus(id)
+
+	g.render(ctx, &transactionStatusResponse{id: id, status: status, round: round})
+}
+
+// getTransactionReceipt responds with the receipt of a transaction finalized
+// by this node. Transactions adopted by syncing or replicating have none.
+func (g *Gateway) getTransactionReceipt(ctx *fasthttp.RequestCtx) {
+	param, ok := ctx.UserValue("id").(string)
+	if !ok {
+		g.renderError(ctx, ErrBadRequest(errors.New("id must be a string")))
+		return
+	}
+
+	slice, err := hex.DecodeString(param)
+	if err != nil {
+		g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "transaction ID must be presented as valid hex")))
+		return
+	}
+
+	if len(slice) != wavelet.SizeTransactionID {
+		g.renderError(ctx, ErrBadRequest(errors.Errorf("transaction ID must be %d bytes long", wavelet.SizeTransactionID)))
+		return
+	}
+
+	var id wavelet.TransactionID
+	copy(id[:], slice)
+
+	receipt, err := g.ledger.Receipt(id)
+	if err != nil {
+		g.renderError(ctx, ErrNotFound(errors.Errorf("could not find receipt of transaction with ID %x", id)))
+		return
+	}
+
+	g.render(ctx, &receiptResponse{receipt: receipt})
+}
+
+// transactionStatus returns the status of a transaction stored in the graph,
+// given the depth of the root of the graph.
+func transactionStatus(tx *wavelet.Transaction, rootDepth uint64) string {
+	if tx.Depth <= rootDepth {
+		return "applied"
+	}
+
+	return "received"
+}
+
+func (g *Gateway) accountScope(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return fasthttp.RequestHandler(func(ctx *fasthttp.RequestCtx) {
+		param, ok := ctx.UserValue("id").(string)
+		if !ok {
+			g.renderError(ctx, ErrBadRequest(errors.New("id must be a string")))
+			return
+		}
+
+		slice, err := hex.DecodeString(param)
+		if err != nil {
+			g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "account ID must be presented as valid hex")))
+			return
+		}
+
+		if len(slice) != wavelet.SizeAccountID {
+			g.renderError(ctx, ErrBadRequest(errors.Errorf("account ID must be %d bytes long", wavelet.SizeAccountID)))
+			return
+		}
+
+		var id wavelet.AccountID
+		copy(id[:], slice)
+
+		ctx.SetUserValue("account_id", id)
+
+		next(ctx)
+	})
+}
+
+// getAccount responds with the latest state of an account. Should the query
+// parameter round be given, it instead responds with the balance and stake of
+// the account as of when said round was finalized. The query parameter view is
+// accepted as an alias of round.
+func (g *Gateway) getAccount(ctx *fasthttp.RequestCtx) {
+	id, ok := ctx.UserValue("account_id").(wavelet.AccountID)
+	if !ok {
+		g.renderError(ctx, ErrBadRequest(errors.New("id must be an AccountID")))
+		return
+	}
+
+	raw := ctx.QueryArgs().Peek("round")
+	if len(raw) == 0 {
+		raw = ctx.QueryArgs().Peek("view")
+	}
+
+	if len(raw) == 0 {
+		g.render(ctx, &account{ledger: g.ledger, id: id})
+		return
+	}
+
+	round, err := strconv.ParseUint(string(raw), 10, 64)
+	if err != nil {
+		g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "could not parse round")))
+		return
+	}
+
+	state, err := g.ledger.AccountStateAt(id, round)
+
+	switch errors.Cause(err) {
+	case nil:
+	case wavelet.ErrRoundNotFinalized:
+		g.renderError(ctx, ErrBadRequest(err))
+		return
+	case wavelet.ErrAccountHistoryUnavailable:
+		g.renderError(ctx, ErrNotFound(err))
+		return
+	default:
+		g.renderError(ctx, ErrInternal(err))
+		return
+	}
+
+	g.render(ctx, &accountStateResponse{id: id, state: state})
+}
+
+// getAccounts responds with the accounts whose public keys are listed in the
+// request body, sparing clients from looking up each account one at a time.
+func (g *Gateway) getAccounts(ctx *fasthttp.RequestCtx) {
+	req := new(accountBatchRequest)
+
+	parser := g.parserPool.Get()
+	err := req.bind(parser, ctx.PostBody())
+	g.parserPool.Put(parser)
+
+	if err != nil {
+		g.renderError(ctx, ErrBadRequest(err))
+		return
+	}
+
+	g.render(ctx, &accountBatchResponse{ledger: g.ledger, ids: req.ids})
+}
+
+// getAccountHistory responds with the changes made to the balance and stake of
+// an account in each round after the round given by the query parameter since.
+func (g *Gateway) getAccountHistory(ctx *fasthttp.RequestCtx) {
+	id, ok := ctx.UserValue("account_id").(wavelet.AccountID)
+	if !ok {
+		g.renderError(ctx, ErrBadRequest(errors.New("id must be an AccountID")))
+		return
+	}
+
+	var since, limit uint64
+	var err error
+
+	queryArgs := ctx.QueryArgs()
+
+	if raw := string(queryArgs.Peek("since")); len(raw) > 0 {
+		since, err = strconv.ParseUint(raw, 10, 64)
+
+		if err != nil {
+			g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "could not parse since")))
+			return
+		}
+	}
+
+	if raw := string(queryArgs.Peek("limit")); len(raw) > 0 {
+		limit, err = strconv.ParseUint(raw, 10, 64)
+
+		if err != nil {
+			g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "could not parse limit")))
+			return
+		}
+	}
+
+	if limit == 0 || limit > maxPaginationLimit {
+		limit = maxPaginationLimit
+	}
+
+	history, err := g.ledger.AccountHistory(id, since, int(limit))
+	if err != nil {
+		g.renderError(ctx, ErrInternal(err))
+		return
+	}
+
+	g.render(ctx, accountHistoryResponse(history))
+}
+
+// getAccountProof responds with a proof of the nonce, balance, stake and reward
+// of an account against the Merkle root of the latest round, such that clients
+// may verify the state of the account without trusting the node.
+func (g *Gateway) getAccountProof(ctx *fasthttp.RequestCtx) {
+	id, ok := ctx.UserValue("account_id").(wavelet.AccountID)
+	if !ok {
+		g.renderError(ctx, ErrBadRequest(errors.New("id must be an AccountID")))
+		return
+	}
+
+	proof, err := g.ledger.Prove(id)
+	if err != nil {
+		g.renderError(ctx, ErrInternal(err))
+		return
+	}
+
+	g.render(ctx, &accountProofResponse{proof: proof})
+}
+
+// getAccountTokens responds with the balances an account holds of all tokens it
+// has ever held.
+func (g *Gateway) getAccountTokens(ctx *fasthttp.RequestCtx) {
+	id, ok := ctx.UserValue("account_id").(wavelet.AccountID)
+	if !ok {
+		g.renderError(ctx, ErrBadRequest(errors.New("id must be an AccountID")))
+		return
+	}
+
+	g.render(ctx, accountTokensResponse(wavelet.ReadAccountTokenBalances(g.ledger.Snapshot(), id)))
+}
+
+func (g *Gateway) getToken(ctx *fasthttp.RequestCtx) {
+	id, ok := ctx.UserValue("token_id").(wavelet.TransactionID)
+	if !ok {
+		g.renderError(ctx, ErrBadRequest(errors.New("id must be a TransactionID")))
+		return
+	}
+
+	token, exists := wavelet.ReadToken(g.ledger.Snapshot(), id)
+	if !exists {
+		g.renderError(ctx, ErrNotFound(errors.Errorf("could not find token with ID %x", id)))
+		return
+	}
+
+	g.render(ctx, &tokenResponse{id: id, token: token})
+}
+
+func (g *Gateway) connect(ctx *fasthttp.RequestCtx) {
+	req := new(connectRequest)
+
+	parser := g.parserPool.Get()
+	err := req.bind(parser, ctx.PostBody())
+	g.parserPool.Put(parser)
+
+	if err != nil {
+		g.renderError(ctx, ErrBadRequest(err))
+		return
+	}
+
+	if g.client == nil {
+		g.renderError(ctx, ErrInternal(errors.New("node is not connected to any network")))
+		return
+	}
+
+	if _, err := g.client.Dial(req.Address); err != nil {
+		g.renderError(ctx, ErrInternal(errors.Wrapf(err, "failed to connect to peer %s", req.Address)))
+		return
+	}
+
+	g.ledger.PeerDiversity().MarkOutbound(req.Address)
+
+	if err := g.ledger.SavePeer(req.Address); err != nil {
+		g.renderError(ctx, ErrInternal(errors.Wrap(err, "connected to peer, but failed to persist it")))
+		return
+	}
+
+	g.render(ctx, &connectResponse{Address: req.Address})
+}
+
+// getMetricsHistory responds with the snapshots of the key metrics of the node
+// it has periodically persisted, ordered from oldest to newest.
+func (g *Gateway) getMetricsHistory(ctx *fasthttp.RequestCtx) {
+	history, err := g.ledger.MetricsHistory()
+	if err != nil {
+		g.renderError(ctx, ErrInternal(err))
+		return
+	}
+
+	g.render(ctx, metricsHistoryResponse(history))
+}
+
+func (g *Gateway) getParams(ctx *fasthttp.RequestCtx) {
+	g.render(ctx, &paramsResponse{})
+}
+
+// tuneParams adjusts the consensus parameters of the node while it is running.
+// Parameters which are not present in the request body are left untouched.
+func (g *Gateway) tuneParams(ctx *fasthttp.RequestCtx) {
+	req := new(tuneParamsRequest)
+
+	parser := g.parserPool.Get()
+	defer g.parserPool.Put(parser)
+
+	if err := req.bind(parser, ctx.PostBody()); err != nil {
+		g.renderError(ctx, ErrBadRequest(err))
+		return
+	}
+
+	if err := wavelet.TuneParams(req.params); err != nil {
+		g.renderError(ctx, ErrBadRequest(err))
+		return
+	}
+
+	g.render(ctx, &paramsResponse{})
+}
+
+func (g *Gateway) backup(ctx *fasthttp.RequestCtx) {
+	size, write, err := g.ledger.PrepareBackup()
+	if err != nil {
+		g.renderError(ctx, ErrInternal(errors.Wrap(err, "failed to backup ledger")))
+		return
+	}
+
+	ctx.Response.Header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"wavelet-%d.tar\"", time.Now().Unix()))
+	ctx.SetContentType("application/x-tar")
+	ctx.SetStatusCode(http.StatusOK)
+
+	// The backup is streamed to the client as it is exported. Should exporting
+	// fail partway, the client is left with fewer bytes than announced.
+	ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := write(w); err != nil {
+			logger := log.API("backup")
+			logger.Warn().Err(err).Msg("Failed to stream backup of ledger.")
+		}
+	})
+
+	ctx.Response.Header.SetContentLength(int(size))
+}
+
+// Headers describing the round a state diff served by /ledger/diff is of.
+const (
+	HeaderRoundIndex = "X-Round-Index"
+	HeaderRoundID    = "X-Round-ID"
+	HeaderMerkleRoot = "X-Merkle-Root"
+)
+
+// ledgerDiff streams the diff of the ledger state as of the latest round
+// against the state as of the round given by the query parameter since, which
+// is the same diff the node serves to peers syncing with it. The diff may be
+// applied to the state as of said round, upon which the state must yield the
+// merkle root in the X-Merkle-Root header. The ETag of the diff allows for it
+// to be cached by mirrors.
+func (g *Gateway) ledgerDiff(ctx *fasthttp.RequestCtx) {
+	var since uint64
+	var err error
+
+	if raw := string(ctx.QueryArgs().Peek("since")); len(raw) > 0 {
+		since, err = strconv.ParseUint(raw, 10, 64)
+
+		if err != nil {
+			g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "could not parse since")))
+			return
+		}
+	}
+
+	round, diff, err := g.ledger.StateDiff(since)
+	if err != nil {
+		if errors.Cause(err) == wavelet.ErrRoundNotFinalized {
+			g.renderError(ctx, ErrBadRequest(err))
+			return
+		}
+
+		g.renderError(ctx, ErrInternal(errors.Wrap(err, "failed to diff ledger state")))
+		return
+	}
+
+	checksum := blake2b.Sum256(diff)
+	etag := fmt.Sprintf(`"%x"`, checksum[:16])
+
+	ctx.Response.Header.Set("ETag", etag)
+	ctx.Response.Header.Set(HeaderRoundIndex, strconv.FormatUint(round.Index, 10))
+	ctx.Response.Header.Set(HeaderRoundID, hex.EncodeToString(round.ID[:]))
+	ctx.Response.Header.Set(HeaderMerkleRoot, hex.EncodeToString(round.Merkle[:]))
+
+	if string(ctx.Request.Header.Peek("If-None-Match")) == etag {
+		ctx.SetStatusCode(http.StatusNotModified)
+		return
+	}
+
+	ctx.SetContentType("application/octet-stream")
+	ctx.SetStatusCode(http.StatusOK)
+	ctx.SetBodyStream(bytes.NewReader(diff), len(diff))
+}
+
+// roundDiff responds with the diff of the ledger state made by a single round,
+// provided that the node is running as an archive node.
+func (g *Gateway) roundDiff(ctx *fasthttp.RequestCtx) {
+	round, ok := g.archivedRound(ctx)
+	if !ok {
+		return
+	}
+
+	diff, err := g.ledger.RoundDiff(round)
+	if err != nil {
+		g.renderArchiveError(ctx, err)
+		return
+	}
+
+	ctx.Response.Header.Set(HeaderRoundIndex, strconv.FormatUint(round, 10))
+
+	ctx.SetContentType("application/octet-stream")
+	ctx.SetStatusCode(http.StatusOK)
+	ctx.SetBodyStream(bytes.NewReader(diff), len(diff))
+}
+
+// roundChanges responds with the changes made to the balances and stakes of
+// accounts by a single round, provided that the node is running as an archive
+// node.
+func (g *Gateway) roundChanges(ctx *fasthttp.RequestCtx) {
+	round, ok := g.archivedRound(ctx)
+	if !ok {
+		return
+	}
+
+	changes, err := g.ledger.RoundChanges(round)
+	if err != nil {
+		g.renderArchiveError(ctx, err)
+		return
+	}
+
+	g.render(ctx, roundChangesResponse(changes))
+}
+
+func (g *Gateway) archivedRound(ctx *fasthttp.RequestCtx) (uint64, bool) {
+	raw, ok := ctx.UserValue("index").(string)
+	if !ok {
+		g.renderError(ctx, ErrBadRequest(errors.New("could not cast index into string")))
+		return 0, false
+	}
+
+	round, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "could not parse round index")))
+		return 0, false
+	}
+
+	return round, true
+}
+
+func (g *Gateway) renderArchiveError(ctx *fasthttp.RequestCtx, err error) {
+	switch errors.Cause(err) {
+	case wavelet.ErrNotArchive, wavelet.ErrRoundNotArchived:
+		g.renderError(ctx, ErrNotFound(err))
+	case wavelet.ErrRoundNotFinalized:
+		g.renderError(ctx, ErrBadRequest(err))
+	default:
+		g.renderError(ctx, ErrInternal(err))
+	}
+}
+
+// Number of leaves of the state tree to visit in between reporting the progress
+// of verifying the ledger state.
+const verifyStateProgressInterval = 10000
+
+// verifyState checks the integrity of the ledger state. Progress is streamed
+// as newline-delimited JSON objects, followed by an object reporting any
+// inconsistencies found. Only one check may be run at a time.
+func (g *Gateway) verifyState(ctx *fasthttp.RequestCtx) {
+	if !atomic.CompareAndSwapInt32(&g.verifyingState, 0, 1) {
+		g.renderError(ctx, ErrUnavailable(errors.New("ledger state is already being verified")))
+		return
+	}
+
+	ctx.SetContentType("application/x-ndjson")
+	ctx.SetStatusCode(http.StatusOK)
+
+	ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer atomic.StoreInt32(&g.verifyingState, 0)
+
+		write := func(m marshalableJSON) {
+			arena := g.arenaPool.Get()
+			b, err := m.marshalJSON(arena)
+			g.arenaPool.Put(arena)
+
+			if err != nil {
+				return
+			}
+
+			_, _ = w.Write(b)
+			_ = w.WriteByte('\n')
+			_ = w.Flush()
+		}
+
+		report, err := g.ledger.VerifyState(func(leaves uint64) {
+			if leaves%verifyStateProgressInterval == 0 {
+				write(&verifyStateProgress{leaves: leaves})
+			}
+		})
+
+		write(&verifyStateResult{report: report, err: err})
+	})
+}
+
+// promote has the node, should it be running as a cold standby, take over from
+// the validator it replicates from and resume participating in consensus.
+func (g *Gateway) promote(ctx *fasthttp.RequestCtx) {
+	if err := g.ledger.Promote(); err != nil {
+		g.renderError(ctx, ErrBadRequest(err))
+		return
+	}
+
+	g.render(ctx, &promoteResponse{round: g.ledger.Rounds().Latest().Index})
+}
+
+func (g *Gateway) peerScope(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return fasthttp.RequestHandler(func(ctx *fasthttp.RequestCtx) {
+		param, ok := ctx.UserValue("id").(string)
+		if !ok {
+			g.renderError(ctx, ErrBadRequest(errors.New("id must be a string")))
+			return
+		}
+
+		slice, err := hex.DecodeString(param)
+		if err != nil {
+			g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "peer ID must be presented as valid hex")))
+			return
+		}
+
+		if len(slice) != wavelet.SizeAccountID {
+			g.renderError(ctx, ErrBadRequest(errors.Errorf("peer ID must be %d bytes long", wavelet.SizeAccountID)))
+			return
+		}
+
+		var id wavelet.AccountID
+		copy(id[:], slice)
+
+		ctx.SetUserValue("peer_id", id)
+
+		next(ctx)
+	})
+}
+
+// listPeers responds with the peers in the routing table of the node alongside
+// the latency and latest round recorded for each, and the peers which are banned.
+func (g *Gateway) listPeers(ctx *fasthttp.RequestCtx) {
+	if g.client == nil {
+		g.renderError(ctx, ErrInternal(errors.New("node is not connected to any network")))
+		return
+	}
+
+	g.render(ctx, &peersResponse{
+		peers:  g.client.ClosestPeerIDs(),
+		stats:  g.ledger.PeerStats(),
+		banned: g.ledger.PeerBans().List(),
+	})
+}
+
+func (g *Gateway) disconnectPeer(ctx *fasthttp.RequestCtx) {
+	id, ok := ctx.UserValue("peer_id").(wavelet.AccountID)
+	if !ok {
+		g.renderError(ctx, ErrBadRequest(errors.New("id must be an AccountID")))
+		return
+	}
+
+	if !g.ledger.DisconnectPeer(id) {
+		g.renderError(ctx, ErrNotFound(errors.Errorf("not connected to peer with ID %x", id)))
+		return
+	}
+
+	g.render(ctx, &peerActionResponse{id: id, event: "disconnected"})
+}
+
+func (g *Gateway) banPeer(ctx *fasthttp.RequestCtx) {
+	id, ok := ctx.UserValue("peer_id").(wavelet.AccountID)
+	if !ok {
+		g.renderError(ctx, ErrBadRequest(errors.New("id must be an AccountID")))
+		return
+	}
+
+	if err := g.ledger.BanPeer(id); err != nil {
+		g.renderError(ctx, ErrInternal(errors.Wrapf(err, "failed to ban peer with ID %x", id)))
+		return
+	}
+
+	g.render(ctx, &peerActionResponse{id: id, event: "banned"})
+}
+
+func (g *Gateway) unbanPeer(ctx *fasthttp.RequestCtx) {
+	id, ok := ctx.UserValue("peer_id").(wavelet.AccountID)
+	if !ok {
+		g.renderError(ctx, ErrBadRequest(errors.New("id must be an AccountID")))
+		return
+	}
+
+	unbanned, err := g.ledger.UnbanPeer(id)
+	if err != nil {
+		g.renderError(ctx, ErrInternal(errors.Wrapf(err, "failed to unban peer with ID %x", id)))
+		return
+	}
+
+	if !unbanned {
+		g.renderError(ctx, ErrNotFound(errors.Errorf("peer with ID %x is not banned", id)))
+		return
+	}
+
+	g.render(ctx, &peerActionResponse{id: id, event: "unbanned"})
+}
+
+func (g *Gateway) getPeerStats(ctx *fasthttp.RequestCtx) {
+	id, ok := ctx.UserValue("peer_id").(wavelet.AccountID)
+	if !ok {
+		g.renderError(ctx, ErrBadRequest(errors.New("id must be an AccountID")))
+		return
+	}
+
+	stats, exists := g.ledger.PeerStats().Snapshot(id)
+	if !exists {
+		g.renderError(ctx, ErrNotFound(errors.Errorf("could not find statistics for peer with ID %x", id)))
+		return
+	}
+
+	g.render(ctx, &peerStatsResponse{id: id, stats: stats})
+}
+
+func (g *Gateway) contractScope(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return fasthttp.RequestHandler(func(ctx *fasthttp.RequestCtx) {
+		param, ok := ctx.UserValue("id").(string)
+		if !ok {
+			g.renderError(ctx, ErrBadRequest(errors.New("could not cast id into string")))
+			return
+		}
+
+		slice, err := hex.DecodeString(param)
+		if err != nil {
+			g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "contract ID must be presented as valid hex")))
+			return
+		}
+
+		if len(slice) != wavelet.SizeTransactionID {
+			g.renderError(ctx, ErrBadRequest(errors.Errorf("contract ID must be %d bytes long", wavelet.SizeTransactionID)))
+			return
+		}
+
+		var contractID wavelet.TransactionID
+		copy(contractID[:], slice)
+
+		ctx.SetUserValue("contract_id", contractID)
+
+		next(ctx)
+	})
+}
+
+func (g *Gateway) tokenScope(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return fasthttp.RequestHandler(func(ctx *fasthttp.RequestCtx) {
+		param, ok := ctx.UserValue("id").(string)
+		if !ok {
+			g.renderError(ctx, ErrBadRequest(errors.New("could not cast id into string")))
+			return
+		}
+
+		slice, err := hex.DecodeString(param)
+		if err != nil {
+			g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "token ID must be presented as valid hex")))
+			return
+		}
+
+		if len(slice) != wavelet.SizeTransactionID {
+			g.renderError(ctx, ErrBadRequest(errors.Errorf("token ID must be %d bytes long", wavelet.SizeTransactionID)))
+			return
+		}
+
+		var tokenID wavelet.TransactionID
+		copy(tokenID[:], slice)
+
+		ctx.SetUserValue("token_id", tokenID)
+
+		next(ctx)
+	})
+}
+
+func (g *Gateway) getContractCode(ctx *fasthttp.RequestCtx) {
+	id, ok := ctx.UserValue("contract_id").(wavelet.TransactionID)
+	if !ok {
+		g.renderError(ctx, ErrBadRequest(errors.New("id must be a TransactionID")))
+		return
+	}
+
+	code, available := wavelet.ReadAccountContractCode(g.ledger.Snapshot(), id)
+
+	if len(code) == 0 || !available {
+		g.renderError(ctx, ErrNotFound(errors.Errorf("could not find contract with ID %x", id)))
+		return
 	}
 
 	ctx.Response.Header.Set("Content-Disposition", "attachment; filename="+hex.EncodeToString(id[:])+".wasm")
@@ -427,6 +1678,134 @@ func (g *Gateway) getContractCode(ctx *fasthttp.RequestCtx) {
 	_, _ = io.Copy(ctx, strings.NewReader(hex.EncodeToString(code)))
 }
 
+// callContract executes a smart contract function against a copy of the
+// latest ledger state, and reports what executing it did without ever
+// applying any of it to the ledger.
+func (g *Gateway) callContract(ctx *fasthttp.RequestCtx) {
+	id, ok := ctx.UserValue("contract_id").(wavelet.TransactionID)
+	if !ok {
+		g.renderError(ctx, ErrBadRequest(errors.New("id must be a TransactionID")))
+		return
+	}
+
+	req := new(callContractRequest)
+
+	parser := g.parserPool.Get()
+	err := req.bind(parser, ctx.PostBody())
+	g.parserPool.Put(parser)
+
+	if err != nil {
+		g.renderError(ctx, ErrBadRequest(err))
+		return
+	}
+
+	if wavelet.IsContractConstructor(req.Func) {
+		g.renderError(ctx, ErrBadRequest(wavelet.ErrContractConstructor))
+		return
+	}
+
+	snapshot := g.ledger.Snapshot()
+
+	code, available := wavelet.ReadAccountContractCode(snapshot, id)
+
+	if len(code) == 0 || !available {
+		g.renderError(ctx, ErrNotFound(errors.Errorf("could not find contract with ID %x", id)))
+		return
+	}
+
+	round := g.ledger.Rounds().Latest()
+	tx := &wavelet.Transaction{Sender: req.sender, Creator: req.sender}
+
+	executor := &wavelet.ContractExecutor{}
+
+	if err := executor.Execute(snapshot, id, round, tx, req.Amount, req.GasLimit, req.Func, req.params, code); err != nil {
+		g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "failed to call smart contract")))
+		return
+	}
+
+	g.render(ctx, &callContractResponse{executor: executor})
+}
+
+// getContractEvents lists the events emitted by a smart contract in the order
+// they were emitted in, starting from the event indexed by offset. Events may
+// be filtered by the ID of the transaction which originated them, and by their
+// topic, in which case only the page of events given by offset and limit is
+// filtered.
+func (g *Gateway) getContractEvents(ctx *fasthttp.RequestCtx) {
+	id, ok := ctx.UserValue("contract_id").(wavelet.TransactionID)
+	if !ok {
+		g.renderError(ctx, ErrBadRequest(errors.New("id must be a TransactionID")))
+		return
+	}
+
+	var offset, limit uint64
+	var err error
+
+	queryArgs := ctx.QueryArgs()
+
+	if raw := string(queryArgs.Peek("offset")); len(raw) > 0 {
+		offset, err = strconv.ParseUint(raw, 10, 64)
+
+		if err != nil {
+			g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "could not parse offset")))
+			return
+		}
+	}
+
+	if raw := string(queryArgs.Peek("limit")); len(raw) > 0 {
+		limit, err = strconv.ParseUint(raw, 10, 64)
+
+		if err != nil {
+			g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "could not parse limit")))
+			return
+		}
+	}
+
+	if limit == 0 || limit > maxPaginationLimit {
+		limit = maxPaginationLimit
+	}
+
+	var txID *wavelet.TransactionID
+
+	if raw := queryArgs.Peek("tx"); len(raw) > 0 {
+		var tx wavelet.TransactionID
+
+		if n, err := hex.Decode(tx[:], raw); n != wavelet.SizeTransactionID || err != nil {
+			g.renderError(ctx, ErrBadRequest(errors.Errorf("tx ID must be %d bytes long", wavelet.SizeTransactionID)))
+			return
+		}
+
+		txID = &tx
+	}
+
+	topic := string(queryArgs.Peek("topic"))
+
+	snapshot := g.ledger.Snapshot()
+
+	if _, available := wavelet.ReadAccountContractCode(snapshot, id); !available {
+		g.renderError(ctx, ErrNotFound(errors.Errorf("could not find contract with ID %x", id)))
+		return
+	}
+
+	events := wavelet.ReadAccountContractEvents(snapshot, id, offset, limit)
+
+	filtered := events[:0]
+
+	for _, event := range events {
+		if txID != nil && event.TxID != *txID {
+			continue
+		}
+
+		if len(topic) > 0 && event.Topic != topic {
+			continue
+		}
+
+		filtered = append(filtered, event)
+	}
+
+	g.render(ctx, contractEventsResponse(filtered))
+}
+
 func (g *Gateway) getContractPages(ctx *fasthttp.RequestCtx) {
 	id, ok := ctx.UserValue("contract_id").(wavelet.TransactionID)
 	if !ok {
@@ -514,7 +1893,29 @@ func (g *Gateway) notFound() func(ctx *fasthttp.RequestCtx) {
 func (g *Gateway) poll(sink *sink) func(ctx *fasthttp.RequestCtx) {
 	return func(ctx *fasthttp.RequestCtx) {
 		if err := sink.serve(ctx); err != nil {
-			g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "failed to init websocket session")))
+			switch errors.Cause(err) {
+			case ErrMaxWebsocketConnections:
+				g.renderError(ctx, ErrUnavailable(err))
+			case ErrMaxWebsocketConnectionsPerIP:
+				g.renderError(ctx, ErrTooManyRequests(err))
+			default:
+				g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "failed to init websocket session")))
+			}
+		}
+	}
+}
+
+func (g *Gateway) events(sink *sink) func(ctx *fasthttp.RequestCtx) {
+	return func(ctx *fasthttp.RequestCtx) {
+		if err := sink.serveEvents(ctx); err != nil {
+			switch errors.Cause(err) {
+			case ErrMaxWebsocketConnections:
+				g.renderError(ctx, ErrUnavailable(err))
+			case ErrMaxWebsocketConnectionsPerIP:
+				g.renderError(ctx, ErrTooManyRequests(err))
+			default:
+				g.renderError(ctx, ErrBadRequest(errors.Wrap(err, "failed to init event stream")))
+			}
 		}
 	}
 }
@@ -540,6 +1941,10 @@ func (g *Gateway) registerWebsocketSink(rawURL string, factory *debounce.Factory
 		join:      make(chan *client),
 		leave:     make(chan *client),
 		clients:   make(map[*client]struct{}),
+
+		limiter:     g.websocketLimiter,
+		idleTimeout: g.websocketIdleTimeout,
+		shutdown:    g.shutdown,
 	}
 
 	if factory != nil {
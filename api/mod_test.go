@@ -20,27 +20,35 @@
 package api
 
 import (
+	"archive/tar"
 	"bufio"
 	"bytes"
 	"crypto/rand"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"github.com/buaazp/fasthttprouter"
+	"github.com/perlin-network/noise"
 	"github.com/perlin-network/noise/skademlia"
 	"github.com/perlin-network/wavelet"
+	"github.com/perlin-network/wavelet/avl"
 	"github.com/perlin-network/wavelet/store"
 	"github.com/perlin-network/wavelet/sys"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/valyala/fasthttp"
 	"github.com/valyala/fastjson"
+	"golang.org/x/crypto/blake2b"
+	"io"
 	"io/ioutil"
+	"mime/multipart"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/http/httputil"
 	"strconv"
+	"strings"
 	"testing"
 	"testing/quick"
 	"time"
@@ -133,6 +141,20 @@ func TestListTransaction(t *testing.T) {
 			url:      "/tx?limit=-1",
 			wantCode: http.StatusBadRequest,
 		},
+		{
+			name:     "tag invalid",
+			url:      "/tx?tag=a",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "tag unknown",
+			url:      "/tx?tag=100",
+			wantCode: http.StatusBadRequest,
+			wantResponse: testErrResponse{
+				StatusText: "Bad request.",
+				ErrorText:  "unknown transaction tag 100",
+			},
+		},
 		{
 			name:         "success",
 			url:          "/tx?limit=1&offset=0",
@@ -242,174 +264,1256 @@ func TestGetTransaction(t *testing.T) {
 	}
 }
 
-func TestSendTransaction(t *testing.T) {
+func TestTransactionMemo(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	transfer := wavelet.Transfer{Recipient: keys.PublicKey(), Amount: 1}
+
+	tx := wavelet.NewTransaction(keys, sys.TagTransfer, transfer.Marshal())
+
+	r, err := (&transaction{tx: &tx}).marshalJSON(new(fastjson.ArenaPool).Get())
+	assert.NoError(t, err)
+	assert.False(t, fastjson.MustParseBytes(r).Exists("memo"))
+
+	transfer.Memo = []byte{0xde, 0xad, 0xbe, 0xef}
+	tx = wavelet.NewTransaction(keys, sys.TagTransfer, transfer.Marshal())
+
+	r, err = (&transaction{tx: &tx}).marshalJSON(new(fastjson.ArenaPool).Get())
+	assert.NoError(t, err)
+	assert.Equal(t, "deadbeef", string(fastjson.MustParseBytes(r).GetStringBytes("memo")))
+}
+
+func TestGetTransactionGraph(t *testing.T) {
 	gateway := New()
 	gateway.setup()
 
+	gateway.ledger = createLedger(t)
+
+	transactions := gateway.ledger.Graph().ListTransactions(0, 0, wavelet.AccountID{}, wavelet.AccountID{})
+	if !assert.NotEmpty(t, transactions) {
+		return
+	}
+
+	txId := transactions[0].ID
+
 	tests := []struct {
-		name     string
-		wantCode int
-		req      sendTransactionRequest
+		name         string
+		url          string
+		wantCode     int
+		wantResponse marshalableJSON
 	}{
 		{
-			name:     "ok",
+			name:     "invalid id length",
+			url:      "/tx/1c331c1d/graph",
+			wantCode: http.StatusBadRequest,
+			wantResponse: &testErrResponse{
+				StatusText: "Bad request.",
+				ErrorText:  fmt.Sprintf("transaction ID must be %d bytes long", wavelet.SizeTransactionID),
+			},
+		},
+		{
+			name:     "not found",
+			url:      "/tx/" + hex.EncodeToString(make([]byte, wavelet.SizeTransactionID)) + "/graph",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "depth too large",
+			url:      "/tx/" + hex.EncodeToString(txId[:]) + "/graph?depth=17",
 			wantCode: http.StatusBadRequest,
+			wantResponse: &testErrResponse{
+				StatusText: "Bad request.",
+				ErrorText:  "depth must be between 1 and 16",
+			},
+		},
+		{
+			name:     "success",
+			url:      "/tx/" + hex.EncodeToString(txId[:]) + "/graph?depth=2",
+			wantCode: http.StatusOK,
+			wantResponse: &transactionGraph{
+				transaction: &transaction{tx: transactions[0], status: "applied"},
+			},
 		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			reqBody, err := json.Marshal(tc.req)
+			request, err := http.NewRequest("GET", "http://localhost"+tc.url, nil)
 			assert.NoError(t, err)
 
-			request := httptest.NewRequest("POST", "http://localhost/tx/send", bytes.NewReader(reqBody))
+			w, err := serve(gateway.router, request)
+			assert.NoError(t, err)
+			assert.NotNil(t, w)
+
+			response, err := ioutil.ReadAll(w.Body)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tc.wantCode, w.StatusCode, "status code")
+
+			if tc.wantResponse != nil {
+				r, err := tc.wantResponse.marshalJSON(new(fastjson.ArenaPool).Get())
+				assert.Nil(t, err)
+				assert.Equal(t, string(r), string(bytes.TrimSpace(response)))
+			}
+		})
+	}
+}
+
+func TestGetTransactionStatus(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	transactions := gateway.ledger.Graph().ListTransactions(0, 0, wavelet.AccountID{}, wavelet.AccountID{})
+	if !assert.NotEmpty(t, transactions) {
+		return
+	}
+
+	txId := transactions[0].ID
+
+	var unknownId wavelet.TransactionID
+	_, err := rand.Read(unknownId[:])
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		id           string
+		wantCode     int
+		wantResponse marshalableJSON
+	}{
+		{
+			name:     "invalid id length",
+			id:       "1c331c1d",
+			wantCode: http.StatusBadRequest,
+			wantResponse: &testErrResponse{
+				StatusText: "Bad request.",
+				ErrorText:  fmt.Sprintf("transaction ID must be %d bytes long", wavelet.SizeTransactionID),
+			},
+		},
+		{
+			name:         "unknown",
+			id:           hex.EncodeToString(unknownId[:]),
+			wantCode:     http.StatusOK,
+			wantResponse: &transactionStatusResponse{id: unknownId, status: wavelet.TransactionStatusUnknown},
+		},
+		{
+			name:         "applied",
+			id:           hex.EncodeToString(txId[:]),
+			wantCode:     http.StatusOK,
+			wantResponse: &transactionStatusResponse{id: txId, status: wavelet.TransactionStatusApplied},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			request, err := http.NewRequest("GET", "http://localhost/tx/"+tc.id+"/status", nil)
+			assert.NoError(t, err)
 
 			w, err := serve(gateway.router, request)
 			assert.NoError(t, err)
 			assert.NotNil(t, w)
 
-			_, err = ioutil.ReadAll(w.Body)
-			assert.Nil(t, err)
+			response, err := ioutil.ReadAll(w.Body)
+			assert.NoError(t, err)
 
 			assert.Equal(t, tc.wantCode, w.StatusCode, "status code")
+
+			if tc.wantResponse != nil {
+				r, err := tc.wantResponse.marshalJSON(new(fastjson.ArenaPool).Get())
+				assert.Nil(t, err)
+				assert.Equal(t, string(r), string(bytes.TrimSpace(response)))
+			}
 		})
 	}
 }
 
-func TestSendTransactionRandom(t *testing.T) {
+func TestGetTransactionReceipt(t *testing.T) {
 	gateway := New()
 	gateway.setup()
 
-	type request struct {
-		Sender    string `json:"sender"`
-		Tag       byte   `json:"tag"`
-		Payload   string `json:"payload"`
-		Signature string `json:"signature"`
+	gateway.ledger = createLedger(t)
+
+	tests := []struct {
+		name         string
+		id           string
+		wantCode     int
+		wantResponse marshalableJSON
+	}{
+		{
+			name:     "invalid id length",
+			id:       "1c331c1d",
+			wantCode: http.StatusBadRequest,
+			wantResponse: &testErrResponse{
+				StatusText: "Bad request.",
+				ErrorText:  fmt.Sprintf("transaction ID must be %d bytes long", wavelet.SizeTransactionID),
+			},
+		},
+		{
+			name:     "not found",
+			id:       hex.EncodeToString(make([]byte, wavelet.SizeTransactionID)),
+			wantCode: http.StatusNotFound,
+		},
 	}
 
-	f := func(req request) bool {
-		reqBody, err := json.Marshal(req)
-		assert.NoError(t, err)
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			request, err := http.NewRequest("GET", "http://localhost/tx/"+tc.id+"/receipt", nil)
+			assert.NoError(t, err)
 
-		request := httptest.NewRequest("POST", "http://localhost/tx/send", bytes.NewReader(reqBody))
+			w, err := serve(gateway.router, request)
+			assert.NoError(t, err)
+			assert.NotNil(t, w)
 
-		res, err := serve(gateway.router, request)
-		assert.NoError(t, err)
+			response, err := ioutil.ReadAll(w.Body)
+			assert.NoError(t, err)
 
-		assert.NotNil(t, res)
-		assert.NotEqual(t, http.StatusNotFound, res.StatusCode)
+			assert.Equal(t, tc.wantCode, w.StatusCode, "status code")
 
-		return true
+			if tc.wantResponse != nil {
+				r, err := tc.wantResponse.marshalJSON(new(fastjson.ArenaPool).Get())
+				assert.Nil(t, err)
+				assert.Equal(t, string(r), string(bytes.TrimSpace(response)))
+			}
+		})
 	}
+}
 
-	if err := quick.Check(f, &quick.Config{
-		MaxCountScale: 10,
-	}); err != nil {
-		t.Error(err)
+func TestGetAccountProof(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	var id wavelet.AccountID
+	id[0] = 1
+
+	w, err := serve(gateway.router, httptest.NewRequest("GET", "http://localhost/accounts/1c331c1d/proof", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, w.StatusCode)
+
+	w, err = serve(gateway.router, httptest.NewRequest("GET", "http://localhost/accounts/"+hex.EncodeToString(id[:])+"/proof", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.StatusCode)
+
+	response, err := ioutil.ReadAll(w.Body)
+	assert.NoError(t, err)
+
+	v, err := fastjson.ParseBytes(response)
+	assert.NoError(t, err)
+
+	assert.Equal(t, hex.EncodeToString(id[:]), string(v.GetStringBytes("id")))
+
+	merkle := gateway.ledger.Rounds().Latest().Merkle
+	assert.Equal(t, hex.EncodeToString(merkle[:]), string(v.GetStringBytes("merkle_root")))
+
+	for _, field := range []string{"nonce", "balance", "stake", "reward"} {
+		assert.NotEmpty(t, v.GetArray("proofs", field), field)
 	}
 }
 
-// Test POST APIs with completely random payload
-func TestPostPayloadRandom(t *testing.T) {
+func TestGetRoundHistory(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	for _, route := range []string{"diff", "changes"} {
+		w, err := serve(gateway.router, httptest.NewRequest("GET", "http://localhost/ledger/rounds/abc/"+route, nil))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, w.StatusCode, route)
+
+		// Only archive nodes serve the history of past rounds.
+		w, err = serve(gateway.router, httptest.NewRequest("GET", "http://localhost/ledger/rounds/0/"+route, nil))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, w.StatusCode, route)
+	}
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	gateway.ledger = wavelet.NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), nil, wavelet.WithArchive())
+
+	for _, route := range []string{"diff", "changes"} {
+		w, err := serve(gateway.router, httptest.NewRequest("GET", "http://localhost/ledger/rounds/5/"+route, nil))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, w.StatusCode, route)
+
+		// The genesis round is never finalized, and thus never archived.
+		w, err = serve(gateway.router, httptest.NewRequest("GET", "http://localhost/ledger/rounds/0/"+route, nil))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, w.StatusCode, route)
+	}
+}
+
+func TestSendTransaction(t *testing.T) {
 	gateway := New()
 	gateway.setup()
 
 	tests := []struct {
-		url string
+		name     string
+		wantCode int
+		req      sendTransactionRequest
 	}{
 		{
-			url: "/tx/send",
+			name:     "ok",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			reqBody, err := json.Marshal(tc.req)
+			assert.NoError(t, err)
+
+			request := httptest.NewRequest("POST", "http://localhost/tx/send", bytes.NewReader(reqBody))
+
+			w, err := serve(gateway.router, request)
+			assert.NoError(t, err)
+			assert.NotNil(t, w)
+
+			_, err = ioutil.ReadAll(w.Body)
+			assert.Nil(t, err)
+
+			assert.Equal(t, tc.wantCode, w.StatusCode, "status code")
+		})
+	}
+}
+
+func TestUploadContract(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	gateway := New(WithMaxContractRequestBodySize(1024))
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+	gateway.keys = keys
+
+	code := []byte("\x00asm\x01\x00\x00\x00")
+
+	var form bytes.Buffer
+
+	w := multipart.NewWriter(&form)
+
+	part, err := w.CreateFormFile("contract", "contract.wasm")
+	assert.NoError(t, err)
+
+	_, err = part.Write(code)
+	assert.NoError(t, err)
+
+	assert.NoError(t, w.WriteField("gas_limit", "1000"))
+	assert.NoError(t, w.Close())
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		wantCode    int
+	}{
+		{
+			name:     "base64",
+			body:     []byte(fmt.Sprintf(`{"contract": "%s", "gas_limit": 1000, "params": "0102"}`, base64.StdEncoding.EncodeToString(code))),
+			wantCode: http.StatusOK,
+		},
+		{
+			name:        "multipart",
+			contentType: w.FormDataContentType(),
+			body:        form.Bytes(),
+			wantCode:    http.StatusOK,
+		},
+		{
+			name:     "missing contract",
+			body:     []byte(`{"gas_limit": 1000}`),
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid base64",
+			body:     []byte(`{"contract": "!"}`),
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "not wasm",
+			body:     []byte(fmt.Sprintf(`{"contract": "%s"}`, base64.StdEncoding.EncodeToString([]byte("not wasm")))),
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid params",
+			body:     []byte(fmt.Sprintf(`{"contract": "%s", "params": "zz"}`, base64.StdEncoding.EncodeToString(code))),
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "too large",
+			body:     []byte(fmt.Sprintf(`{"contract": "%s"}`, base64.StdEncoding.EncodeToString(append(code, make([]byte, 1024)...)))),
+			wantCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			time.Sleep(10 * time.Millisecond) // Wait for the ledger to refill its send quota.
+
+			request := httptest.NewRequest("POST", "http://localhost/contract", bytes.NewReader(tc.body))
+			if tc.contentType != "" {
+				request.Header.Set("Content-Type", tc.contentType)
+			}
+
+			res, err := serve(gateway.router, request)
+			assert.NoError(t, err)
+			assert.NotNil(t, res)
+
+			body, err := ioutil.ReadAll(res.Body)
+			assert.NoError(t, err)
+
+			if !assert.Equal(t, tc.wantCode, res.StatusCode, string(body)) || tc.wantCode != http.StatusOK {
+				return
+			}
+
+			v, err := fastjson.ParseBytes(body)
+			assert.NoError(t, err)
+
+			var id wavelet.TransactionID
+			_, err = hex.Decode(id[:], v.GetStringBytes("contract_id"))
+			assert.NoError(t, err)
+
+			assert.Equal(t, string(v.GetStringBytes("tx_id")), string(v.GetStringBytes("contract_id")))
+
+			tx := gateway.ledger.Graph().FindTransaction(id)
+			if assert.NotNil(t, tx) {
+				assert.Equal(t, sys.TagContract, tx.Tag)
+
+				params, err := wavelet.ParseContractTransaction(tx.Payload)
+				assert.NoError(t, err)
+				assert.Equal(t, code, params.Code)
+				assert.EqualValues(t, 1000, params.GasLimit)
+			}
+		})
+	}
+}
+
+func TestConnect(t *testing.T) {
+	gateway := New(WithAPIKey(testAdminKey, ScopeAdmin))
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+	}{
+		{
+			name:     "invalid json",
+			body:     "{",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "missing address",
+			body:     "{}",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "address without port",
+			body:     `{"address": "127.0.0.1"}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "no network",
+			body:     `{"address": "127.0.0.1:3000"}`,
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest("POST", "http://localhost/node/connect", strings.NewReader(tc.body))
+
+			w, err := serve(gateway.router, asAdmin(request))
+			assert.NoError(t, err)
+			assert.NotNil(t, w)
+
+			_, err = ioutil.ReadAll(w.Body)
+			assert.Nil(t, err)
+
+			assert.Equal(t, tc.wantCode, w.StatusCode, "status code")
+		})
+	}
+
+	peers, err := gateway.ledger.SavedPeers()
+	assert.NoError(t, err)
+	assert.Empty(t, peers)
+}
+
+func TestSendTransactionRandom(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	type request struct {
+		Sender    string `json:"sender"`
+		Tag       byte   `json:"tag"`
+		Payload   string `json:"payload"`
+		Signature string `json:"signature"`
+	}
+
+	f := func(req request) bool {
+		reqBody, err := json.Marshal(req)
+		assert.NoError(t, err)
+
+		request := httptest.NewRequest("POST", "http://localhost/tx/send", bytes.NewReader(reqBody))
+
+		res, err := serve(gateway.router, request)
+		assert.NoError(t, err)
+
+		assert.NotNil(t, res)
+		assert.NotEqual(t, http.StatusNotFound, res.StatusCode)
+
+		return true
+	}
+
+	if err := quick.Check(f, &quick.Config{
+		MaxCountScale: 10,
+	}); err != nil {
+		t.Error(err)
+	}
+}
+
+// Test POST APIs with completely random payload
+func TestPostPayloadRandom(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	tests := []struct {
+		url string
+	}{
+		{
+			url: "/tx/send",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.url, func(t *testing.T) {
+			f := func(random1 [][]byte, random2 [][]byte) bool {
+
+				var payload []byte
+				for i := range random1 {
+					payload = append(payload, random1[i]...)
+				}
+
+				for i := range random2 {
+					payload = append(payload, random2[i]...)
+				}
+
+				request := httptest.NewRequest("POST", "http://localhost"+tc.url, bytes.NewReader(payload))
+
+				res, err := serve(gateway.router, request)
+
+				assert.NoError(t, err)
+
+				assert.NotNil(t, res)
+				assert.NotEmpty(t, res.StatusCode)
+
+				return true
+			}
+
+			if err := quick.Check(f, &quick.Config{
+				MaxCountScale: 10,
+			}); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestGetAccount(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	idHex := "1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d"
+	idBytes, err := hex.DecodeString(idHex)
+	assert.NoError(t, err)
+
+	var id32 wavelet.AccountID
+	copy(id32[:], idBytes)
+
+	wavelet.WriteAccountBalance(gateway.ledger.Snapshot(), id32, 10)
+	wavelet.WriteAccountStake(gateway.ledger.Snapshot(), id32, 11)
+	wavelet.WriteAccountContractNumPages(gateway.ledger.Snapshot(), id32, 12)
+
+	var id wavelet.AccountID
+	copy(id[:], idBytes)
+
+	state, err := gateway.ledger.AccountStateAt(id, 0)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		url          string
+		wantCode     int
+		wantResponse marshalableJSON
+	}{
+		{
+			name:     "missing id",
+			url:      "/accounts/",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "id not hex",
+			url:      "/accounts/-----",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid id length",
+			url:      "/accounts/1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:         "valid id",
+			url:          "/accounts/" + idHex,
+			wantCode:     http.StatusOK,
+			wantResponse: &account{ledger: gateway.ledger, id: id},
+		},
+		{
+			name:     "invalid round",
+			url:      "/accounts/" + idHex + "?round=abc",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "round not yet finalized",
+			url:      "/accounts/" + idHex + "?round=5",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:         "valid round",
+			url:          "/accounts/" + idHex + "?round=0",
+			wantCode:     http.StatusOK,
+			wantResponse: &accountStateResponse{id: id, state: state},
+		},
+		{
+			name:         "valid view",
+			url:          "/accounts/" + idHex + "?view=0",
+			wantCode:     http.StatusOK,
+			wantResponse: &accountStateResponse{id: id, state: state},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest("GET", "http://localhost"+tc.url, nil)
+
+			w, err := serve(gateway.router, request)
+			assert.NoError(t, err)
+			assert.NotNil(t, w)
+
+			response, err := ioutil.ReadAll(w.Body)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tc.wantCode, w.StatusCode, "status code")
+
+			if tc.wantResponse != nil {
+				r, err := tc.wantResponse.marshalJSON(new(fastjson.ArenaPool).Get())
+				assert.Nil(t, err)
+				assert.Equal(t, string(r), string(bytes.TrimSpace(response)))
+			}
+		})
+	}
+}
+
+func TestTuneParams(t *testing.T) {
+	defer func(params sys.ConsensusParams) {
+		sys.UpdateParams(func(p *sys.ConsensusParams) { *p = params })
+	}(sys.Params())
+
+	gateway := New(WithAPIKey(testAdminKey, ScopeAdmin))
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	tests := []struct {
+		name     string
+		method   string
+		body     string
+		wantCode int
+	}{
+		{
+			name:     "invalid json",
+			method:   "PUT",
+			body:     "{",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "unknown param",
+			method:   "PUT",
+			body:     `{"snowball.beta": 10}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "param out of range",
+			method:   "PUT",
+			body:     `{"query.timeout": 0}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "tune params",
+			method:   "PUT",
+			body:     `{"snowball.k": 5, "query.timeout": 2000}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "get params",
+			method:   "GET",
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest(tc.method, "http://localhost/node/params", strings.NewReader(tc.body))
+
+			w, err := serve(gateway.router, asAdmin(request))
+			assert.NoError(t, err)
+			assert.NotNil(t, w)
+
+			response, err := ioutil.ReadAll(w.Body)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tc.wantCode, w.StatusCode, "status code")
+
+			if tc.wantCode == http.StatusOK {
+				v, err := fastjson.ParseBytes(response)
+				assert.NoError(t, err)
+
+				assert.Equal(t, 5, v.GetInt("snowball.k"))
+				assert.Equal(t, 2000, v.GetInt("query.timeout"))
+			}
+		})
+	}
+}
+
+func TestGetMempool(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	graph := gateway.ledger.Graph()
+
+	tx := wavelet.AttachSenderToTransaction(keys, wavelet.NewTransaction(keys, sys.TagNop, nil), graph.FindEligibleParents()...)
+	assert.NoError(t, graph.AddTransaction(tx))
+
+	tests := []struct {
+		name      string
+		url       string
+		wantCode  int
+		wantCount int
+	}{
+		{
+			name:     "invalid offset",
+			url:      "/mempool?offset=-1",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:      "all pending transactions",
+			url:       "/mempool",
+			wantCode:  http.StatusOK,
+			wantCount: 1,
+		},
+		{
+			name:      "offset past pending transactions",
+			url:       "/mempool?offset=1",
+			wantCode:  http.StatusOK,
+			wantCount: 0,
+		},
+		{
+			name:      "pending transactions by fee",
+			url:       "/mempool?order=fee",
+			wantCode:  http.StatusOK,
+			wantCount: 1,
+		},
+		{
+			name:     "unknown order",
+			url:      "/mempool?order=size",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest("GET", "http://localhost"+tc.url, nil)
+
+			w, err := serve(gateway.router, request)
+			assert.NoError(t, err)
+			assert.NotNil(t, w)
+
+			response, err := ioutil.ReadAll(w.Body)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tc.wantCode, w.StatusCode, "status code")
+
+			if tc.wantCode != http.StatusOK {
+				return
+			}
+
+			v, err := fastjson.ParseBytes(response)
+			assert.NoError(t, err)
+
+			assert.Equal(t, 1, v.GetInt("count"))
+			assert.Equal(t, 0, v.GetInt("incomplete"))
+			assert.Equal(t, 1, v.GetInt("by_tag", "nop"))
+			assert.Equal(t, 0, v.GetInt("by_tag", "transfer"))
+			assert.Equal(t, 1, v.GetInt("by_age", "10s"))
+			assert.Equal(t, 0, v.GetInt("by_age", "older"))
+
+			transactions := v.GetArray("transactions")
+
+			if assert.Len(t, transactions, tc.wantCount) && tc.wantCount > 0 {
+				assert.Equal(t, hex.EncodeToString(tx.ID[:]), string(transactions[0].GetStringBytes("id")))
+				assert.Equal(t, wavelet.TransactionStatusAccepted, string(transactions[0].GetStringBytes("status")))
+				assert.True(t, transactions[0].GetInt64("received_at") > 0)
+			}
+		})
+	}
+}
+
+func TestGetLedgerState(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	get := func(query string) (int, *fastjson.Value) {
+		w, err := serve(gateway.router, httptest.NewRequest("GET", "http://localhost/ledger/state"+query, nil))
+		assert.NoError(t, err)
+
+		response, err := ioutil.ReadAll(w.Body)
+		assert.NoError(t, err)
+
+		v, err := fastjson.ParseBytes(response)
+		assert.NoError(t, err)
+
+		return w.StatusCode, v
+	}
+
+	code, v := get("?since=abc")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// Nothing has changed since genesis.
+	code, v = get("")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, `{"round":0,"since":0,"changed":false}`, v.String())
+
+	for i := uint64(1); i <= 3; i++ {
+		_, err := gateway.ledger.Rounds().Save(&wavelet.Round{Index: i, Applied: i * 10})
+		assert.NoError(t, err)
+	}
+
+	code, v = get("?since=1")
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, v.GetBool("changed"))
+	assert.False(t, v.GetBool("truncated"))
+	assert.Equal(t, uint64(3), v.GetUint64("round"))
+
+	rounds := v.GetArray("rounds")
+	if assert.Len(t, rounds, 2) {
+		assert.Equal(t, uint64(2), rounds[0].GetUint64("index"))
+		assert.Equal(t, uint64(30), rounds[1].GetUint64("applied"))
+	}
+
+	code, v = get("?since=3")
+	assert.Equal(t, http.StatusOK, code)
+	assert.False(t, v.GetBool("changed"))
+	assert.False(t, v.Exists("rounds"))
+}
+
+func TestGetNetworkStats(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	request := httptest.NewRequest("GET", "http://localhost/network/stats", nil)
+
+	w, err := serve(gateway.router, request)
+	assert.NoError(t, err)
+	assert.NotNil(t, w)
+
+	response, err := ioutil.ReadAll(w.Body)
+	assert.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, w.StatusCode, "status code")
+
+	round := gateway.ledger.Rounds().Latest()
+
+	v, err := fastjson.ParseBytes(response)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, v.GetInt("num_peers"))
+	assert.Equal(t, round.Index, v.GetUint64("round"))
+	assert.Equal(t, hex.EncodeToString(round.End.ID[:]), string(v.GetStringBytes("root_id")))
+	assert.Equal(t, 0.0, v.GetFloat64("tps"))
+	assert.False(t, v.GetBool("synced"))
+}
+
+func TestGetLedgerDiff(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	for _, url := range []string{"/ledger/diff?since=abc", "/ledger/diff?since=5"} {
+		w, err := serve(gateway.router, httptest.NewRequest("GET", "http://localhost"+url, nil))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, w.StatusCode, url)
+	}
+
+	w, err := serve(gateway.router, httptest.NewRequest("GET", "http://localhost/ledger/diff", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.StatusCode)
+
+	latest := gateway.ledger.Rounds().Latest()
+	assert.Equal(t, strconv.FormatUint(latest.Index, 10), w.Header.Get(HeaderRoundIndex))
+	assert.Equal(t, hex.EncodeToString(latest.ID[:]), w.Header.Get(HeaderRoundID))
+	assert.Equal(t, hex.EncodeToString(latest.Merkle[:]), w.Header.Get(HeaderMerkleRoot))
+	assert.NotEmpty(t, w.Header.Get("ETag"))
+
+	// Mirrors which already hold the diff are told so.
+	request := httptest.NewRequest("GET", "http://localhost/ledger/diff", nil)
+	request.Header.Set("If-None-Match", w.Header.Get("ETag"))
+
+	w, err = serve(gateway.router, request)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotModified, w.StatusCode)
+}
+
+func TestGetRandomBeacon(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	request := httptest.NewRequest("GET", "http://localhost/ledger/beacon", nil)
+
+	w, err := serve(gateway.router, request)
+	assert.NoError(t, err)
+	assert.NotNil(t, w)
+
+	response, err := ioutil.ReadAll(w.Body)
+	assert.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, w.StatusCode, "status code")
+
+	beacon := wavelet.ReadRandomBeacon(gateway.ledger.Snapshot())
+
+	v, err := fastjson.ParseBytes(response)
+	assert.NoError(t, err)
+	assert.Equal(t, gateway.ledger.Rounds().Latest().Index, v.GetUint64("round"))
+	assert.Equal(t, hex.EncodeToString(beacon[:]), string(v.GetStringBytes("beacon")))
+}
+
+func TestGetAccountHistory(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	id := "1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d"
+
+	tests := []struct {
+		url      string
+		wantCode int
+		wantBody string
+	}{
+		{"/accounts/1c331c1d/history", http.StatusBadRequest, ""},
+		{"/accounts/" + id + "/history?since=abc", http.StatusBadRequest, ""},
+		{"/accounts/" + id + "/history?limit=-1", http.StatusBadRequest, ""},
+		{"/accounts/" + id + "/history?since=10&limit=5", http.StatusOK, "[]"},
+	}
+
+	for _, tc := range tests {
+		w, err := serve(gateway.router, httptest.NewRequest("GET", "http://localhost"+tc.url, nil))
+		assert.NoError(t, err)
+
+		response, err := ioutil.ReadAll(w.Body)
+		assert.NoError(t, err)
+
+		assert.Equal(t, tc.wantCode, w.StatusCode, tc.url)
+
+		if tc.wantBody != "" {
+			assert.Equal(t, tc.wantBody, string(bytes.TrimSpace(response)))
+		}
+	}
+
+	history := accountHistoryResponse{
+		{Round: 3, PrevBalance: 100, Balance: 70, PrevStake: 5, Stake: 5},
+	}
+
+	r, err := history.marshalJSON(new(fastjson.ArenaPool).Get())
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"round":3,"balance":70,"balance_perls":"0.00000007","prev_balance":100,"prev_balance_perls":"0.0000001","stake":5,"stake_perls":"0.000000005","prev_stake":5,"prev_stake_perls":"0.000000005"}]`, string(r))
+}
+
+func TestAccountObjectFormatsAmounts(t *testing.T) {
+	snapshot := avl.New(store.NewInmem())
+
+	var id wavelet.AccountID
+	id[0] = 1
+
+	wavelet.WriteAccountBalance(snapshot, id, 1500000000)
+	wavelet.WriteAccountStake(snapshot, id, 2)
+
+	v := accountObject(new(fastjson.Arena), snapshot, id)
+
+	assert.EqualValues(t, 1500000000, v.GetUint64("balance"))
+	assert.Equal(t, "1.5", string(v.GetStringBytes("balance_perls")))
+	assert.Equal(t, "0.000000002", string(v.GetStringBytes("stake_perls")))
+	assert.Equal(t, "0", string(v.GetStringBytes("reward_perls")))
+}
+
+func TestParseAmountJSON(t *testing.T) {
+	amount, err := parseAmount(fastjson.MustParse(`1500`))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1500, amount)
+
+	amount, err = parseAmount(fastjson.MustParse(`"1.5"`))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1500000000, amount)
+
+	_, err = parseAmount(fastjson.MustParse(`"-1"`))
+	assert.Error(t, err)
+
+	_, err = parseAmount(fastjson.MustParse(`-1`))
+	assert.Error(t, err)
+}
+
+func TestGetAccounts(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	a := "1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d"
+	b := "2c331c1d1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d"
+
+	tooMany := make([]string, maxAccountBatchSize+1)
+	for i := range tooMany {
+		tooMany[i] = `"` + a + `"`
+	}
+
+	tests := []struct {
+		body     string
+		wantCode int
+	}{
+		{`{}`, http.StatusBadRequest},
+		{`{"public_keys":"` + a + `"}`, http.StatusBadRequest},
+		{`{"public_keys":[]}`, http.StatusBadRequest},
+		{`{"public_keys":["1c331c1d"]}`, http.StatusBadRequest},
+		{`{"public_keys":["` + a + `",1]}`, http.StatusBadRequest},
+		{`{"public_keys":[` + strings.Join(tooMany, ",") + `]}`, http.StatusBadRequest},
+		{`{"public_keys":["` + b + `","` + a + `"]}`, http.StatusOK},
+	}
+
+	for _, tc := range tests {
+		w, err := serve(gateway.router, httptest.NewRequest("POST", "http://localhost/accounts/batch", strings.NewReader(tc.body)))
+		assert.NoError(t, err)
+
+		response, err := ioutil.ReadAll(w.Body)
+		assert.NoError(t, err)
+
+		if !assert.Equal(t, tc.wantCode, w.StatusCode, string(response)) || tc.wantCode != http.StatusOK {
+			continue
+		}
+
+		v, err := fastjson.ParseBytes(response)
+		assert.NoError(t, err)
+
+		accounts := v.GetArray()
+		if assert.Len(t, accounts, 2) {
+			assert.Equal(t, b, string(accounts[0].GetStringBytes("public_key")))
+			assert.Equal(t, a, string(accounts[1].GetStringBytes("public_key")))
+			assert.Equal(t, uint64(0), accounts[1].GetUint64("balance"))
+		}
+	}
+}
+
+func TestGetMetricsHistory(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	request := httptest.NewRequest("GET", "http://localhost/node/history", nil)
+
+	w, err := serve(gateway.router, request)
+	assert.NoError(t, err)
+	assert.NotNil(t, w)
+
+	response, err := ioutil.ReadAll(w.Body)
+	assert.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, w.StatusCode, "status code")
+
+	history := metricsHistoryResponse{
+		{Seq: 7, Time: time.Unix(0, 1565000000000000000), RoundIndex: 42, NumPeers: 3, MempoolSize: 128, FinalityLatency: 250 * time.Millisecond},
+	}
+
+	r, err := history.marshalJSON(new(fastjson.ArenaPool).Get())
+	assert.NoError(t, err)
+
+	assert.Equal(t, "[]", string(bytes.TrimSpace(response)))
+	assert.Equal(t, `[{"seq":7,"time":1565000000000000000,"round":42,"num_peers":3,"mempool_size":128,"finality_latency":250000000}]`, string(r))
+}
+
+func TestGetPeerStats(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	idHex := "1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d"
+	idBytes, err := hex.DecodeString(idHex)
+	assert.NoError(t, err)
+
+	var id wavelet.AccountID
+	copy(id[:], idBytes)
+
+	round := wavelet.NewRound(3, wavelet.MerkleNodeID{1}, 0, wavelet.Transaction{}, wavelet.Transaction{})
+	gateway.ledger.PeerStats().ObserveRound(skademlia.NewID("127.0.0.1:3000", id, [blake2b.Size256]byte{}), round)
+
+	stats, exists := gateway.ledger.PeerStats().Snapshot(id)
+	assert.True(t, exists)
+
+	tests := []struct {
+		name         string
+		url          string
+		wantCode     int
+		wantResponse marshalableJSON
+	}{
+		{
+			name:     "id not hex",
+			url:      "/node/peers/-----/stats",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid id length",
+			url:      "/node/peers/1c331c1d/stats",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "unknown peer",
+			url:      "/node/peers/" + strings.Repeat("00", wavelet.SizeAccountID) + "/stats",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:         "valid id",
+			url:          "/node/peers/" + idHex + "/stats",
+			wantCode:     http.StatusOK,
+			wantResponse: &peerStatsResponse{id: id, stats: stats},
 		},
 	}
 
 	for _, tc := range tests {
-		t.Run(tc.url, func(t *testing.T) {
-			f := func(random1 [][]byte, random2 [][]byte) bool {
-
-				var payload []byte
-				for i := range random1 {
-					payload = append(payload, random1[i]...)
-				}
-
-				for i := range random2 {
-					payload = append(payload, random2[i]...)
-				}
-
-				request := httptest.NewRequest("POST", "http://localhost"+tc.url, bytes.NewReader(payload))
-
-				res, err := serve(gateway.router, request)
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest("GET", "http://localhost"+tc.url, nil)
 
-				assert.NoError(t, err)
+			w, err := serve(gateway.router, request)
+			assert.NoError(t, err)
+			assert.NotNil(t, w)
 
-				assert.NotNil(t, res)
-				assert.NotEmpty(t, res.StatusCode)
+			response, err := ioutil.ReadAll(w.Body)
+			assert.NoError(t, err)
 
-				return true
-			}
+			assert.Equal(t, tc.wantCode, w.StatusCode, "status code")
 
-			if err := quick.Check(f, &quick.Config{
-				MaxCountScale: 10,
-			}); err != nil {
-				t.Error(err)
+			if tc.wantResponse != nil {
+				r, err := tc.wantResponse.marshalJSON(new(fastjson.ArenaPool).Get())
+				assert.Nil(t, err)
+				assert.Equal(t, string(r), string(bytes.TrimSpace(response)))
 			}
 		})
 	}
 }
 
-func TestGetAccount(t *testing.T) {
-	gateway := New()
+func TestPeerBans(t *testing.T) {
+	gateway := New(WithAPIKey(testAdminKey, ScopeAdmin))
 	gateway.setup()
 
 	gateway.ledger = createLedger(t)
 
-	idHex := "1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d"
-	idBytes, err := hex.DecodeString(idHex)
+	keys, err := skademlia.NewKeys(1, 1)
 	assert.NoError(t, err)
 
-	var id32 wavelet.AccountID
-	copy(id32[:], idBytes)
+	gateway.client = skademlia.NewClient(":0", keys)
 
-	wavelet.WriteAccountBalance(gateway.ledger.Snapshot(), id32, 10)
-	wavelet.WriteAccountStake(gateway.ledger.Snapshot(), id32, 11)
-	wavelet.WriteAccountContractNumPages(gateway.ledger.Snapshot(), id32, 12)
+	idHex := "1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d"
+	idBytes, err := hex.DecodeString(idHex)
+	assert.NoError(t, err)
 
 	var id wavelet.AccountID
 	copy(id[:], idBytes)
 
 	tests := []struct {
 		name         string
+		method       string
 		url          string
 		wantCode     int
 		wantResponse marshalableJSON
 	}{
 		{
-			name:     "missing id",
-			url:      "/accounts/",
+			name:     "ban invalid id",
+			method:   "POST",
+			url:      "/node/peers/1c331c1d/ban",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "unban peer not banned",
+			method:   "DELETE",
+			url:      "/node/peers/" + idHex + "/ban",
 			wantCode: http.StatusNotFound,
 		},
 		{
-			name:     "id not hex",
-			url:      "/accounts/-----",
-			wantCode: http.StatusBadRequest,
+			name:     "disconnect peer not connected",
+			method:   "DELETE",
+			url:      "/node/peers/" + idHex,
+			wantCode: http.StatusNotFound,
 		},
 		{
-			name:     "invalid id length",
-			url:      "/accounts/1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d",
-			wantCode: http.StatusBadRequest,
+			name:         "ban peer",
+			method:       "POST",
+			url:          "/node/peers/" + idHex + "/ban",
+			wantCode:     http.StatusOK,
+			wantResponse: &peerActionResponse{id: id, event: "banned"},
 		},
 		{
-			name:         "valid id",
-			url:          "/accounts/" + idHex,
+			name:         "list banned peer",
+			method:       "GET",
+			url:          "/node/peers",
 			wantCode:     http.StatusOK,
-			wantResponse: &account{ledger: gateway.ledger, id: id},
+			wantResponse: &peersResponse{stats: gateway.ledger.PeerStats(), banned: []wavelet.AccountID{id}},
+		},
+		{
+			name:         "unban peer",
+			method:       "DELETE",
+			url:          "/node/peers/" + idHex + "/ban",
+			wantCode:     http.StatusOK,
+			wantResponse: &peerActionResponse{id: id, event: "unbanned"},
+		},
+		{
+			name:         "list no banned peers",
+			method:       "GET",
+			url:          "/node/peers",
+			wantCode:     http.StatusOK,
+			wantResponse: &peersResponse{stats: gateway.ledger.PeerStats()},
 		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			request := httptest.NewRequest("GET", "http://localhost"+tc.url, nil)
+			request := httptest.NewRequest(tc.method, "http://localhost"+tc.url, nil)
 
-			w, err := serve(gateway.router, request)
+			w, err := serve(gateway.router, asAdmin(request))
 			assert.NoError(t, err)
 			assert.NotNil(t, w)
 
@@ -425,6 +1529,8 @@ func TestGetAccount(t *testing.T) {
 			}
 		})
 	}
+
+	assert.False(t, gateway.ledger.PeerBans().IsBanned(id))
 }
 
 func TestGetContractCode(t *testing.T) {
@@ -497,6 +1603,115 @@ func TestGetContractCode(t *testing.T) {
 	}
 }
 
+func TestCallContract(t *testing.T) {
+	code, err := ioutil.ReadFile("../cmd/wavelet/contracts/transfer_back.wasm")
+	assert.NoError(t, err)
+
+	contract := "1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d1c331c1d"
+	sender := "400056ee68a7cc2695222df05ea76875bc27ec6e61e8e62317c336157019c405"
+
+	// Initialize the contract to have its memory pages included in genesis.
+	var id wavelet.AccountID
+	_, err = hex.Decode(id[:], []byte(contract))
+	assert.NoError(t, err)
+
+	executor := &wavelet.ContractExecutor{}
+	tree := avl.New(store.NewInmem())
+	assert.NoError(t, executor.Execute(tree, id, &wavelet.Round{}, &wavelet.Transaction{}, 0, 1000000000, "init", nil, code))
+
+	mem := wavelet.LoadContractMemorySnapshot(tree, id)
+
+	pages := make([]string, 0, len(mem)/wavelet.PageSize)
+	for i := 0; i < len(mem); i += wavelet.PageSize {
+		pages = append(pages, fmt.Sprintf(`"%d": "%s"`, i/wavelet.PageSize, hex.EncodeToString(mem[i:i+wavelet.PageSize])))
+	}
+
+	genesis := fmt.Sprintf(`{"version": 2, "contracts": {"%s": {"code": "%s", "num_pages": %d, "pages": {%s}}}}`,
+		contract, hex.EncodeToString(code), len(pages), strings.Join(pages, ", "))
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	gateway := New()
+	gateway.setup()
+
+	gateway.ledger = wavelet.NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), &genesis)
+
+	before := gateway.ledger.Snapshot().Checksum()
+
+	tests := []struct {
+		name     string
+		url      string
+		body     string
+		wantCode int
+	}{
+		{
+			name:     "missing func",
+			url:      "/contract/" + contract + "/call",
+			body:     `{}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "contract not exist",
+			url:      "/contract/3132333435363738393031323334353637383930313233343536373839303132/call",
+			body:     `{"func": "on_money_received"}`,
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "func not exist",
+			url:      "/contract/" + contract + "/call",
+			body:     `{"func": "missing"}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "ok",
+			url:      "/contract/" + contract + "/call",
+			body:     fmt.Sprintf(`{"func": "on_money_received", "sender": "%s", "amount": 100}`, sender),
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest("POST", "http://localhost"+tc.url, strings.NewReader(tc.body))
+
+			res, err := serve(gateway.router, request)
+			assert.NoError(t, err)
+			assert.NotNil(t, res)
+
+			body, err := ioutil.ReadAll(res.Body)
+			assert.NoError(t, err)
+
+			if !assert.Equal(t, tc.wantCode, res.StatusCode, string(body)) || tc.wantCode != http.StatusOK {
+				return
+			}
+
+			v, err := fastjson.ParseBytes(body)
+			assert.NoError(t, err)
+
+			assert.False(t, v.GetBool("gas_limit_exceeded"))
+			assert.NotZero(t, v.GetUint64("gas"))
+
+			// The contract sends half of the PERLs it receives back to the sender.
+			transactions := v.GetArray("transactions")
+			if assert.Len(t, transactions, 1) {
+				assert.Equal(t, int(sys.TagTransfer), transactions[0].GetInt("tag"))
+
+				payload, err := hex.DecodeString(string(transactions[0].GetStringBytes("payload")))
+				assert.NoError(t, err)
+
+				params, err := wavelet.ParseTransferTransaction(payload)
+				assert.NoError(t, err)
+				assert.Equal(t, sender, hex.EncodeToString(params.Recipient[:]))
+				assert.EqualValues(t, 50, params.Amount)
+			}
+		})
+	}
+
+	// Calling a contract must never modify the ledger.
+	assert.Equal(t, before, gateway.ledger.Snapshot().Checksum())
+}
+
 func TestGetContractPages(t *testing.T) {
 	gateway := New()
 	gateway.setup()
@@ -554,6 +1769,32 @@ func TestGetContractPages(t *testing.T) {
 	}
 }
 
+func TestGetContractEvents(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	var id = "3132333435363738393031323334353637383930313233343536373839303132"
+
+	tests := []struct {
+		url      string
+		wantCode int
+	}{
+		{"/contract/" + id + "/events?offset=-1", http.StatusBadRequest},
+		{"/contract/" + id + "/events?limit=abc", http.StatusBadRequest},
+		{"/contract/" + id + "/events?tx=1c331c1d", http.StatusBadRequest},
+		{"/contract/" + id + "/events?tx=" + id + "&topic=transfer", http.StatusNotFound},
+		{"/contract/" + id + "/events", http.StatusNotFound},
+	}
+
+	for _, tc := range tests {
+		w, err := serve(gateway.router, httptest.NewRequest("GET", "http://localhost"+tc.url, nil))
+		assert.NoError(t, err)
+		assert.Equal(t, tc.wantCode, w.StatusCode, tc.url)
+	}
+}
+
 func TestGetLedger(t *testing.T) {
 	gateway := New()
 	gateway.setup()
@@ -601,10 +1842,158 @@ func TestGetLedger(t *testing.T) {
 	assert.NoError(t, compareJson([]byte(expectedJSON), response))
 }
 
+func TestProbes(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	gateway.client = skademlia.NewClient(":0", keys)
+
+	w, err := serve(gateway.router, httptest.NewRequest("GET", "http://localhost/healthz", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.StatusCode)
+
+	response, err := ioutil.ReadAll(w.Body)
+	assert.NoError(t, err)
+	assert.NoError(t, compareJson([]byte(`{"status":"ok"}`), response))
+
+	// A node which has neither synced with the network, nor has any peers is
+	// not ready.
+
+	w, err = serve(gateway.router, httptest.NewRequest("GET", "http://localhost/readyz", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, w.StatusCode)
+
+	response, err = ioutil.ReadAll(w.Body)
+	assert.NoError(t, err)
+
+	expectedJSON := fmt.Sprintf(`{"ready":false,"synced":false,"num_peers":0,"min_peers":%d,"round":0}`, sys.Params().SnowballK)
+	assert.NoError(t, compareJson([]byte(expectedJSON), response))
+
+	res := &readinessResponse{synced: true, numPeers: sys.Params().SnowballK, minPeers: sys.Params().SnowballK}
+	assert.True(t, res.ready())
+
+	res.numPeers--
+	assert.False(t, res.ready())
+}
+
+func TestBackup(t *testing.T) {
+	gateway := New(WithAPIKey(testAdminKey, ScopeAdmin))
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	w, err := serve(gateway.router, asAdmin(httptest.NewRequest("GET", "http://localhost/node/backup", nil)))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.StatusCode)
+	assert.Equal(t, "application/x-tar", w.Header.Get("Content-Type"))
+
+	backup, err := ioutil.ReadAll(w.Body)
+	assert.NoError(t, err)
+
+	// The backup is streamed with its size announced up front.
+	assert.EqualValues(t, len(backup), w.ContentLength)
+
+	tr := tar.NewReader(bytes.NewReader(backup))
+
+	var names []string
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		names = append(names, header.Name)
+	}
+
+	assert.Equal(t, []string{"round", "state"}, names)
+}
+
+func TestVerifyState(t *testing.T) {
+	gateway := New(WithAPIKey(testAdminKey, ScopeAdmin))
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	w, err := serve(gateway.router, asAdmin(httptest.NewRequest("POST", "http://localhost/node/verify-state", nil)))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.StatusCode)
+
+	response, err := ioutil.ReadAll(w.Body)
+	assert.NoError(t, err)
+
+	lines := bytes.Split(bytes.TrimSpace(response), []byte("\n"))
+
+	result, err := fastjson.ParseBytes(lines[len(lines)-1])
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	snapshot := gateway.ledger.Snapshot()
+	checksum := snapshot.Checksum()
+
+	assert.Equal(t, "result", string(result.GetStringBytes("event")))
+	assert.True(t, result.GetBool("healthy"))
+	assert.Equal(t, hex.EncodeToString(checksum[:]), string(result.GetStringBytes("merkle_root")))
+	assert.Equal(t, wavelet.ReadAccountsLen(snapshot), result.GetUint64("num_accounts"))
+	assert.Empty(t, result.GetArray("problems"))
+}
+
+func TestPromote(t *testing.T) {
+	gateway := New(WithAPIKey(testAdminKey, ScopeAdmin))
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	promote := func() (int, *fastjson.Value) {
+		w, err := serve(gateway.router, asAdmin(httptest.NewRequest("POST", "http://localhost/node/promote", nil)))
+		assert.NoError(t, err)
+
+		response, err := ioutil.ReadAll(w.Body)
+		assert.NoError(t, err)
+
+		return w.StatusCode, fastjson.MustParseBytes(response)
+	}
+
+	code, v := promote()
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, wavelet.ErrNotStandby.Error(), string(v.GetStringBytes("error")))
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	replicator := skademlia.NewClient(":0", keys)
+	replicator.SetCredentials(noise.NewCredentials(":0", replicator.Protocol()))
+
+	gateway.ledger = wavelet.NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), nil,
+		wavelet.WithStandby("127.0.0.1:1", replicator, nil),
+	)
+
+	code, v = promote()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, gateway.ledger.Rounds().Latest().Index, v.GetUint64("round"))
+	assert.True(t, v.GetBool("promoted"))
+	assert.False(t, gateway.ledger.IsStandby())
+
+	code, v = promote()
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, wavelet.ErrAlreadyPromoted.Error(), string(v.GetStringBytes("error")))
+}
+
 // Test the rate limit on all endpoints
 func TestEndpointsRateLimit(t *testing.T) {
 	gateway := New()
 	gateway.rateLimiter = newRateLimiter(10)
+	gateway.sendRateLimiter = newRateLimiter(10)
 	gateway.setup()
 
 	gateway.ledger = createLedger(t)
@@ -670,7 +2059,7 @@ func TestEndpointsRateLimit(t *testing.T) {
 		{
 			url:           "/accounts/1",
 			method:        "GET",
-			isRateLimited: false,
+			isRateLimited: true,
 		},
 		{
 			url:           "/contract/1/page/1",
@@ -690,12 +2079,12 @@ func TestEndpointsRateLimit(t *testing.T) {
 		{
 			url:           "/tx/send",
 			method:        "POST",
-			isRateLimited: false,
+			isRateLimited: true,
 		},
 		{
 			url:           "/tx/1",
 			method:        "GET",
-			isRateLimited: false,
+			isRateLimited: true,
 		},
 		{
 			url:           "/tx",
@@ -723,6 +2112,7 @@ func TestEndpointsRateLimit(t *testing.T) {
 						assert.NotEqual(t, http.StatusTooManyRequests, w.StatusCode)
 					} else {
 						assert.Equal(t, http.StatusTooManyRequests, w.StatusCode)
+						assert.NotEmpty(t, w.Header.Get("Retry-After"))
 					}
 				} else {
 					assert.NotEqual(t, http.StatusTooManyRequests, w.StatusCode)
@@ -732,6 +2122,31 @@ func TestEndpointsRateLimit(t *testing.T) {
 	}
 }
 
+func TestSendRateLimitPerIP(t *testing.T) {
+	gateway := New()
+	gateway.rateLimiter = newRateLimiter(5)
+	gateway.sendRateLimiter = newRateLimiter(100)
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	// Presenting a different API key with every request does not get around
+	// the limit placed on the IP address sending transactions.
+	for i := 0; i < 10; i++ {
+		request := httptest.NewRequest("POST", "http://localhost/tx/send", nil)
+		request.Header.Set(HeaderAPIKey, strconv.Itoa(i))
+
+		w, err := serve(gateway.router, request)
+		assert.NoError(t, err)
+
+		if i < 5 {
+			assert.NotEqual(t, http.StatusTooManyRequests, w.StatusCode)
+		} else {
+			assert.Equal(t, http.StatusTooManyRequests, w.StatusCode)
+		}
+	}
+}
+
 func compareJson(expected []byte, response []byte) error {
 	if bytes.Equal(bytes.TrimSpace(response), expected) {
 		return nil
@@ -757,6 +2172,16 @@ func (t testErrResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
 	return json.Marshal(t)
 }
 
+// testAdminKey is the API key tests configure to be granted the admin scope,
+// as admin routes are disabled should no such key be configured.
+const testAdminKey = "admin"
+
+// asAdmin has req present testAdminKey.
+func asAdmin(req *http.Request) *http.Request {
+	req.Header.Set(HeaderAPIKey, testAdminKey)
+	return req
+}
+
 func serve(router *fasthttprouter.Router, req *http.Request) (*http.Response, error) {
 	server := &fasthttp.Server{
 		Handler: router.Handler,
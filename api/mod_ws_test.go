@@ -20,6 +20,7 @@
 package api
 
 import (
+	"context"
 	"github.com/fasthttp/websocket"
 	"github.com/perlin-network/noise/skademlia"
 	"github.com/perlin-network/wavelet"
@@ -28,6 +29,7 @@ import (
 	"github.com/perlin-network/wavelet/sys"
 	"github.com/stretchr/testify/assert"
 	"github.com/valyala/fastjson"
+	"net/http"
 	"net/url"
 	"strconv"
 	"testing"
@@ -45,10 +47,11 @@ func TestPollLog(t *testing.T) {
 
 	ledger := wavelet.NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), nil)
 
-	go gateway.StartHTTP(8080, nil, ledger, keys)
-	defer gateway.Shutdown()
-
-	time.Sleep(100 * time.Millisecond)
+	_, err = gateway.StartHTTP(8080, nil, ledger, keys)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer gateway.Shutdown(context.Background())
 
 	t.Run("tx-tag-filter", func(t *testing.T) {
 		u := url.URL{Scheme: "ws", Host: ":8080", Path: `/poll/tx`, RawQuery: "tag=1"}
@@ -67,8 +70,9 @@ func TestPollLog(t *testing.T) {
 				default:
 				}
 
+				// Connections are closed once the gateway is shut down.
 				_, msg, err := c.ReadMessage()
-				if !assert.NoError(t, err) {
+				if err != nil {
 					return
 				}
 				response <- msg
@@ -106,8 +110,9 @@ func TestPollLog(t *testing.T) {
 				default:
 				}
 
+				// Connections are closed once the gateway is shut down.
 				_, msg, err := c.ReadMessage()
-				if !assert.NoError(t, err) {
+				if err != nil {
 					return
 				}
 				response <- msg
@@ -144,3 +149,43 @@ func TestPollLog(t *testing.T) {
 		assert.Equal(t, 2, len(vals))
 	})
 }
+
+func TestGracefulShutdown(t *testing.T) {
+	gateway := New()
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	ledger := wavelet.NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), nil)
+
+	_, err = gateway.StartHTTP(8081, nil, ledger, keys)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	u := url.URL{Scheme: "ws", Host: ":8081", Path: "/poll/network"}
+	c, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer c.Close()
+
+	// Idle keep-alive connections do not hold up the server from shutting down.
+	res, err := http.Get("http://localhost:8081/healthz")
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		_ = res.Body.Close()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	assert.NoError(t, gateway.Shutdown(ctx))
+
+	_, _, err = c.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), err)
+
+	// The server no longer accepts connections once it is shut down.
+	_, _, err = websocket.DefaultDialer.Dial(u.String(), nil)
+	assert.Error(t, err)
+}
@@ -20,16 +20,24 @@
 package api
 
 import (
+	"bytes"
 	"encoding/base64"
+	"encoding/binary"
 	"encoding/hex"
 	"github.com/perlin-network/noise/edwards25519"
 	"github.com/perlin-network/noise/skademlia"
 	"github.com/perlin-network/wavelet"
+	"github.com/perlin-network/wavelet/avl"
 	"github.com/perlin-network/wavelet/sys"
 	"github.com/pkg/errors"
+	"github.com/valyala/fasthttp"
 	"github.com/valyala/fastjson"
+	"io/ioutil"
+	"net"
 	"net/http"
+	"sort"
 	"strconv"
+	"time"
 )
 
 type marshalableJSON interface {
@@ -39,6 +47,10 @@ type marshalableJSON interface {
 var (
 	_ marshalableJSON = (*sendTransactionResponse)(nil)
 
+	_ marshalableJSON = (*uploadContractResponse)(nil)
+
+	_ marshalableJSON = (*callContractResponse)(nil)
+
 	_ marshalableJSON = (*ledgerStatusResponse)(nil)
 
 	_ marshalableJSON = (*transaction)(nil)
@@ -52,6 +64,10 @@ type sendTransactionRequest struct {
 	Payload   string `json:"payload"`
 	Signature string `json:"signature"`
 
+	// Index of the last round the transaction may be applied in. Optional,
+	// such that the transaction never expires should it be left unspecified.
+	ValidUntilView uint64 `json:"valid_until_view"`
+
 	// Internal fields.
 	creator   wavelet.AccountID
 	signature wavelet.Signature
@@ -116,6 +132,16 @@ func (s *sendTransactionRequest) bind(parser *fastjson.Parser, body []byte) erro
 		return errors.Wrap(err, "invalid tag")
 	}
 
+	if validUntilVal := v.Get("valid_until_view"); validUntilVal != nil {
+		if validUntilVal.Type() != fastjson.TypeNumber {
+			return errors.New("valid_until_view is not a number")
+		}
+
+		if s.ValidUntilView, err = validUntilVal.Uint64(); err != nil {
+			return errors.Wrap(err, "invalid valid_until_view")
+		}
+	}
+
 	s.Sender = string(senderStr)
 	s.Payload = string(payloadStr)
 	s.Signature = string(signatureStr)
@@ -130,7 +156,7 @@ func (s *sendTransactionRequest) bind(parser *fastjson.Parser, body []byte) erro
 		return errors.Errorf("sender public key must be size %d", wavelet.SizeAccountID)
 	}
 
-	if s.Tag > sys.TagBatch {
+	if s.Tag > sys.TagTransferToken {
 		return errors.New("unknown transaction tag specified")
 	}
 
@@ -139,6 +165,10 @@ func (s *sendTransactionRequest) bind(parser *fastjson.Parser, body []byte) erro
 		return errors.Wrap(err, "payload provided is not hex-formatted")
 	}
 
+	if len(s.payload) > sys.MaxTransactionPayloadSize {
+		return errors.Errorf("payload is %d bytes, but may only be %d bytes at most", len(s.payload), sys.MaxTransactionPayloadSize)
+	}
+
 	signatureBuf, err := hex.DecodeString(s.Signature)
 	if err != nil {
 		return errors.Wrap(err, "sender signature provided is not hex-formatted")
@@ -154,6 +184,71 @@ func (s *sendTransactionRequest) bind(parser *fastjson.Parser, body []byte) erro
 	return nil
 }
 
+// parseAmount reads an amount of PERLs from v, given either as a number
+// counted in the smallest indivisible unit of PERLs, or as a string holding a
+// decimal number of PERLs such as "0.5".
+func parseAmount(v *fastjson.Value) (uint64, error) {
+	if v.Type() != fastjson.TypeString {
+		return v.Uint64()
+	}
+
+	amount, err := wavelet.ParseAmount(string(v.GetStringBytes()))
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(amount), nil
+}
+
+type faucetRequest struct {
+	Recipient string `json:"recipient"`
+	Amount    uint64 `json:"amount"`
+
+	// Internal fields.
+	recipient wavelet.AccountID
+	amount    uint64
+}
+
+// bind reads the address to send PERLs to, and optionally the amount of PERLs
+// to send, which defaults to the most the faucet sends.
+func (s *faucetRequest) bind(parser *fastjson.Parser, body []byte) error {
+	if err := fastjson.ValidateBytes(body); err != nil {
+		return errors.Wrap(err, "invalid json")
+	}
+
+	v, err := parser.ParseBytes(body)
+	if err != nil {
+		return err
+	}
+
+	recipientVal := v.Get("recipient")
+	if recipientVal == nil {
+		return errors.New("missing recipient")
+	}
+	if recipientVal.Type() != fastjson.TypeString {
+		return errors.New("recipient is not a string")
+	}
+	s.Recipient = string(recipientVal.GetStringBytes())
+
+	recipient, err := hex.DecodeString(s.Recipient)
+	if err != nil {
+		return errors.Wrap(err, "recipient provided is not hex-formatted")
+	}
+	if len(recipient) != wavelet.SizeAccountID {
+		return errors.Errorf("recipient must be %d bytes long", wavelet.SizeAccountID)
+	}
+	copy(s.recipient[:], recipient)
+
+	if amountVal := v.Get("amount"); amountVal != nil {
+		if s.Amount, err = parseAmount(amountVal); err != nil {
+			return errors.Wrap(err, "could not parse amount")
+		}
+		s.amount = s.Amount
+	}
+
+	return nil
+}
+
 type sendTransactionResponse struct {
 	// Internal fields.
 	ledger *wavelet.Ledger
@@ -161,6 +256,15 @@ type sendTransactionResponse struct {
 }
 
 func (s *sendTransactionResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	o, err := s.getObject(arena)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.MarshalTo(nil), nil
+}
+
+func (s *sendTransactionResponse) getObject(arena *fastjson.Arena) (*fastjson.Value, error) {
 	if s.ledger == nil || s.tx == nil {
 		return nil, errors.New("insufficient parameters were provided")
 	}
@@ -180,199 +284,1616 @@ func (s *sendTransactionResponse) marshalJSON(arena *fastjson.Arena) ([]byte, er
 	}
 
 	round := s.ledger.Rounds().Latest()
+	params := sys.Params()
 
-	if s.tx.IsCritical(round.ExpectedDifficulty(sys.MinDifficulty, sys.DifficultyScaleFactor)) {
+	if s.tx.IsCritical(round.ExpectedDifficulty(params.MinDifficulty, params.DifficultyScaleFactor)) {
 		o.Set("is_critical", arena.NewTrue())
 	} else {
 		o.Set("is_critical", arena.NewFalse())
 	}
 
-	return o.MarshalTo(nil), nil
+	return o, nil
 }
 
-type ledgerStatusResponse struct {
+// defaultContractGasLimit is the gas limit used to spawn contracts uploaded
+// without one being specified.
+const defaultContractGasLimit = 100000000
+
+// wasmMagic is the magic number every WebAssembly module starts with.
+var wasmMagic = []byte{0x00, 0x61, 0x73, 0x6d}
+
+type uploadContractRequest struct {
+	Contract string `json:"contract"`
+	GasLimit uint64 `json:"gas_limit"`
+	Params   string `json:"params"`
+
 	// Internal fields.
+	code   []byte
+	params []byte
+}
 
-	client    *skademlia.Client
-	ledger    *wavelet.Ledger
-	publicKey edwards25519.PublicKey
+// bind reads the contract to upload either out of a multipart form, with the
+// WebAssembly binary as the file field "contract", or out of a JSON object
+// with the binary being base64-encoded.
+func (s *uploadContractRequest) bind(parser *fastjson.Parser, ctx *fasthttp.RequestCtx) error {
+	var err error
+
+	if bytes.HasPrefix(ctx.Request.Header.ContentType(), []byte("multipart/form-data")) {
+		err = s.bindMultipart(ctx)
+	} else {
+		err = s.bindJSON(parser, ctx.PostBody())
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if len(s.code) == 0 {
+		return errors.New("missing contract")
+	}
+
+	if !bytes.HasPrefix(s.code, wasmMagic) {
+		return errors.New("contract is not a WebAssembly module")
+	}
+
+	if s.params, err = hex.DecodeString(s.Params); err != nil {
+		return errors.Wrap(err, "params provided are not hex-formatted")
+	}
+
+	if s.GasLimit == 0 {
+		s.GasLimit = defaultContractGasLimit
+	}
+
+	if size := len(s.payload()); size > sys.MaxTransactionPayloadSize {
+		return errors.Errorf("contract is %d bytes, but may only be %d bytes at most", size, sys.MaxTransactionPayloadSize)
+	}
+
+	return nil
 }
 
-func (s *ledgerStatusResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
-	if s.client == nil || s.ledger == nil {
-		return nil, errors.New("insufficient parameters were provided")
+func (s *uploadContractRequest) bindMultipart(ctx *fasthttp.RequestCtx) error {
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		return errors.Wrap(err, "invalid multipart form")
 	}
 
-	snapshot := s.ledger.Snapshot()
-	round := s.ledger.Rounds().Latest()
+	files := form.File["contract"]
+	if len(files) != 1 {
+		return errors.New("exactly one contract file must be provided")
+	}
 
-	accountsLen := wavelet.ReadAccountsLen(snapshot)
+	file, err := files[0].Open()
+	if err != nil {
+		return errors.Wrap(err, "failed to open contract file")
+	}
 
-	o := arena.NewObject()
+	defer file.Close()
 
-	o.Set("public_key", arena.NewString(hex.EncodeToString(s.publicKey[:])))
-	o.Set("address", arena.NewString(s.client.ID().Address()))
-	o.Set("num_accounts", arena.NewNumberString(strconv.FormatUint(accountsLen, 10)))
+	if s.code, err = ioutil.ReadAll(file); err != nil {
+		return errors.Wrap(err, "failed to read contract file")
+	}
 
-	r := arena.NewObject()
-	r.Set("merkle_root", arena.NewString(hex.EncodeToString(round.Merkle[:])))
-	r.Set("start_id", arena.NewString(hex.EncodeToString(round.Start.ID[:])))
-	r.Set("end_id", arena.NewString(hex.EncodeToString(round.End.ID[:])))
-	r.Set("applied", arena.NewNumberString(strconv.FormatUint(round.Applied, 10)))
-	r.Set("depth", arena.NewNumberString(strconv.FormatUint(round.End.Depth-round.Start.Depth, 10)))
-	r.Set("difficulty", arena.NewNumberString(strconv.FormatUint(uint64(round.ExpectedDifficulty(sys.MinDifficulty, sys.DifficultyScaleFactor)), 10)))
+	if values := form.Value["gas_limit"]; len(values) > 0 {
+		if s.GasLimit, err = strconv.ParseUint(values[0], 10, 64); err != nil {
+			return errors.Wrap(err, "invalid gas limit")
+		}
+	}
 
-	o.Set("round", r)
+	if values := form.Value["params"]; len(values) > 0 {
+		s.Params = values[0]
+	}
 
-	peers := s.client.ClosestPeerIDs()
-	if len(peers) > 0 {
-		peersArray := arena.NewArray()
+	return nil
+}
 
-		for i := range peers {
-			publicKey := peers[i].PublicKey()
+func (s *uploadContractRequest) bindJSON(parser *fastjson.Parser, body []byte) error {
+	if err := fastjson.ValidateBytes(body); err != nil {
+		return errors.Wrap(err, "invalid json")
+	}
 
-			peer := arena.NewObject()
-			peer.Set("address", arena.NewString(peers[i].Address()))
-			peer.Set("public_key", arena.NewString(hex.EncodeToString(publicKey[:])))
+	v, err := parser.ParseBytes(body)
+	if err != nil {
+		return err
+	}
 
-			peersArray.SetArrayItem(i, peer)
+	contractVal := v.Get("contract")
+	if contractVal == nil {
+		return errors.New("missing contract")
+	}
+	if contractVal.Type() != fastjson.TypeString {
+		return errors.New("contract is not a string")
+	}
+
+	s.Contract = string(contractVal.GetStringBytes())
+
+	if s.code, err = base64.StdEncoding.DecodeString(s.Contract); err != nil {
+		return errors.Wrap(err, "contract provided is not base64-encoded")
+	}
+
+	if gasLimitVal := v.Get("gas_limit"); gasLimitVal != nil {
+		if gasLimitVal.Type() != fastjson.TypeNumber {
+			return errors.New("gas limit is not a number")
+		}
+
+		if s.GasLimit, err = gasLimitVal.Uint64(); err != nil {
+			return errors.Wrap(err, "invalid gas limit")
 		}
-		o.Set("peers", peersArray)
-	} else {
-		o.Set("peers", nil)
 	}
 
-	return o.MarshalTo(nil), nil
+	if paramsVal := v.Get("params"); paramsVal != nil {
+		if paramsVal.Type() != fastjson.TypeString {
+			return errors.New("params is not a string")
+		}
+
+		s.Params = string(paramsVal.GetStringBytes())
+	}
+
+	return nil
 }
 
-type transaction struct {
-	// Internal fields.
-	tx     *wavelet.Transaction
-	status string
+// payload returns the payload of the contract transaction spawning the
+// uploaded contract.
+func (s *uploadContractRequest) payload() []byte {
+	var buf [8]byte
+
+	payload := make([]byte, 0, 8+4+len(s.params)+len(s.code))
+
+	binary.LittleEndian.PutUint64(buf[:], s.GasLimit)
+	payload = append(payload, buf[:8]...)
+
+	binary.LittleEndian.PutUint32(buf[:4], uint32(len(s.params)))
+	payload = append(payload, buf[:4]...)
+
+	payload = append(payload, s.params...)
+	payload = append(payload, s.code...)
+
+	return payload
 }
 
-func (s *transaction) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+type uploadContractResponse struct {
+	sendTransactionResponse
+}
+
+func (s *uploadContractResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
 	o, err := s.getObject(arena)
 	if err != nil {
 		return nil, err
 	}
 
+	o.Set("contract_id", arena.NewString(hex.EncodeToString(s.tx.ID[:])))
+
 	return o.MarshalTo(nil), nil
 }
 
-func (s *transaction) getObject(arena *fastjson.Arena) (*fastjson.Value, error) {
-	if s.tx == nil {
-		return nil, errors.New("insufficient fields specified")
+type callContractRequest struct {
+	Func     string `json:"func"`
+	Params   string `json:"params"`
+	Sender   string `json:"sender"`
+	Amount   uint64 `json:"amount"`
+	GasLimit uint64 `json:"gas_limit"`
+
+	// Internal fields.
+	params []byte
+	sender wavelet.AccountID
+}
+
+func (s *callContractRequest) bind(parser *fastjson.Parser, body []byte) error {
+	if err := fastjson.ValidateBytes(body); err != nil {
+		return errors.Wrap(err, "invalid json")
 	}
 
-	o := arena.NewObject()
+	v, err := parser.ParseBytes(body)
+	if err != nil {
+		return err
+	}
 
-	o.Set("id", arena.NewString(hex.EncodeToString(s.tx.ID[:])))
-	o.Set("sender", arena.NewString(hex.EncodeToString(s.tx.Sender[:])))
-	o.Set("creator", arena.NewString(hex.EncodeToString(s.tx.Creator[:])))
-	o.Set("status", arena.NewString(s.status))
-	o.Set("nonce", arena.NewNumberString(strconv.FormatUint(s.tx.Nonce, 10)))
-	o.Set("depth", arena.NewNumberString(strconv.FormatUint(s.tx.Depth, 10)))
-	o.Set("tag", arena.NewNumberInt(int(s.tx.Tag)))
-	o.Set("payload", arena.NewString(base64.StdEncoding.EncodeToString(s.tx.Payload)))
-	o.Set("sender_signature", arena.NewString(hex.EncodeToString(s.tx.SenderSignature[:])))
-	o.Set("creator_signature", arena.NewString(hex.EncodeToString(s.tx.CreatorSignature[:])))
+	funcVal := v.Get("func")
+	if funcVal == nil {
+		return errors.New("missing func")
+	}
+	if funcVal.Type() != fastjson.TypeString {
+		return errors.New("func is not a string")
+	}
 
-	if s.tx.ParentIDs != nil {
-		parents := arena.NewArray()
-		for i := range s.tx.ParentIDs {
-			parents.SetArrayItem(i, arena.NewString(hex.EncodeToString(s.tx.ParentIDs[i][:])))
+	s.Func = string(funcVal.GetStringBytes())
+
+	if len(s.Func) == 0 {
+		return errors.New("func must not be empty")
+	}
+
+	if paramsVal := v.Get("params"); paramsVal != nil {
+		if paramsVal.Type() != fastjson.TypeString {
+			return errors.New("params is not a string")
 		}
-		o.Set("parents", parents)
-	} else {
-		o.Set("parents", nil)
+
+		s.Params = string(paramsVal.GetStringBytes())
 	}
 
-	return o, nil
-}
+	if s.params, err = hex.DecodeString(s.Params); err != nil {
+		return errors.Wrap(err, "params provided are not hex-formatted")
+	}
 
-type transactionList []*transaction
+	if senderVal := v.Get("sender"); senderVal != nil {
+		if senderVal.Type() != fastjson.TypeString {
+			return errors.New("sender is not a string")
+		}
 
-func (s transactionList) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
-	list := arena.NewArray()
+		s.Sender = string(senderVal.GetStringBytes())
 
-	for i, v := range s {
-		o, err := v.getObject(arena)
+		senderBuf, err := hex.DecodeString(s.Sender)
 		if err != nil {
-			return nil, err
+			return errors.Wrap(err, "sender public key provided is not hex-formatted")
 		}
 
-		list.SetArrayItem(i, o)
+		if len(senderBuf) != wavelet.SizeAccountID {
+			return errors.Errorf("sender public key must be size %d", wavelet.SizeAccountID)
+		}
+
+		copy(s.sender[:], senderBuf)
 	}
 
-	return list.MarshalTo(nil), nil
+	if amountVal := v.Get("amount"); amountVal != nil {
+		if amountVal.Type() != fastjson.TypeNumber && amountVal.Type() != fastjson.TypeString {
+			return errors.New("amount is neither a number nor a string")
+		}
+
+		if s.Amount, err = parseAmount(amountVal); err != nil {
+			return errors.Wrap(err, "invalid amount")
+		}
+	}
+
+	if gasLimitVal := v.Get("gas_limit"); gasLimitVal != nil {
+		if gasLimitVal.Type() != fastjson.TypeNumber {
+			return errors.New("gas limit is not a number")
+		}
+
+		if s.GasLimit, err = gasLimitVal.Uint64(); err != nil {
+			return errors.Wrap(err, "invalid gas limit")
+		}
+	}
+
+	if s.GasLimit == 0 {
+		s.GasLimit = defaultContractGasLimit
+	}
+
+	return nil
 }
 
-type account struct {
+type callContractResponse struct {
 	// Internal fields.
-	id     wavelet.AccountID
-	ledger *wavelet.Ledger
+	executor *wavelet.ContractExecutor
 }
 
-func (s *account) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
-	if s.ledger == nil || s.id == wavelet.ZeroAccountID {
-		return nil, errors.New("insufficient fields specified")
+func (s *callContractResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	if s.executor == nil {
+		return nil, errors.New("insufficient parameters were provided")
 	}
 
-	snapshot := s.ledger.Snapshot()
-
 	o := arena.NewObject()
 
-	o.Set("public_key", arena.NewString(hex.EncodeToString(s.id[:])))
-
-	balance, _ := wavelet.ReadAccountBalance(snapshot, s.id)
-	o.Set("balance", arena.NewNumberString(strconv.FormatUint(balance, 10)))
+	o.Set("result", arena.NewString(hex.EncodeToString(s.executor.Error)))
+	o.Set("gas", arena.NewNumberString(strconv.FormatUint(s.executor.Gas, 10)))
 
-	stake, _ := wavelet.ReadAccountStake(snapshot, s.id)
-	o.Set("stake", arena.NewNumberString(strconv.FormatUint(stake, 10)))
+	if s.executor.GasLimitExceeded {
+		o.Set("gas_limit_exceeded", arena.NewTrue())
+	} else {
+		o.Set("gas_limit_exceeded", arena.NewFalse())
+	}
 
-	reward, _ := wavelet.ReadAccountReward(snapshot, s.id)
-	o.Set("reward", arena.NewNumberString(strconv.FormatUint(reward, 10)))
+	logs := arena.NewArray()
+	for i, msg := range s.executor.Logs {
+		logs.SetArrayItem(i, arena.NewString(msg))
+	}
+	o.Set("logs", logs)
 
-	nonce, _ := wavelet.ReadAccountNonce(snapshot, s.id)
-	o.Set("nonce", arena.NewNumberString(strconv.FormatUint(nonce, 10)))
+	transactions := arena.NewArray()
+	for i, tx := range s.executor.Queue {
+		v := arena.NewObject()
+		v.Set("tag", arena.NewNumberInt(int(tx.Tag)))
+		v.Set("payload", arena.NewString(hex.EncodeToString(tx.Payload)))
 
-	_, isContract := wavelet.ReadAccountContractCode(snapshot, s.id)
-	if isContract {
-		o.Set("is_contract", arena.NewTrue())
-	} else {
-		o.Set("is_contract", arena.NewFalse())
+		transactions.SetArrayItem(i, v)
 	}
+	o.Set("transactions", transactions)
 
-	numPages, _ := wavelet.ReadAccountContractNumPages(snapshot, s.id)
-	if numPages != 0 {
-		o.Set("num_mem_pages", arena.NewNumberString(strconv.FormatUint(numPages, 10)))
+	events := arena.NewArray()
+	for i, event := range s.executor.Events {
+		events.SetArrayItem(i, contractEventObject(arena, event))
 	}
+	o.Set("events", events)
 
 	return o.MarshalTo(nil), nil
 }
 
-type errResponse struct {
-	Err            error `json:"-"` // low-level runtime error
-	HTTPStatusCode int   `json:"-"` // http response status code
+func contractEventObject(arena *fastjson.Arena, event wavelet.ContractEvent) *fastjson.Value {
+	o := arena.NewObject()
+
+	o.Set("contract_id", arena.NewString(hex.EncodeToString(event.Contract[:])))
+	o.Set("index", arena.NewNumberString(strconv.FormatUint(event.Index, 10)))
+	o.Set("tx_id", arena.NewString(hex.EncodeToString(event.TxID[:])))
+	o.Set("topic", arena.NewString(event.Topic))
+	o.Set("payload", arena.NewString(hex.EncodeToString(event.Payload)))
+
+	return o
 }
 
-func (e *errResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
-	o := arena.NewObject()
+type contractEventsResponse []wavelet.ContractEvent
 
-	o.Set("status", arena.NewString("Bad request."))
+func (s contractEventsResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	list := arena.NewArray()
 
-	if e.Err != nil {
-		o.Set("error", arena.NewString(e.Err.Error()))
+	for i, event := range s {
+		list.SetArrayItem(i, contractEventObject(arena, event))
 	}
 
-	return o.MarshalTo(nil), nil
+	return list.MarshalTo(nil), nil
 }
 
-func ErrBadRequest(err error) *errResponse {
-	return &errResponse{
-		Err:            err,
-		HTTPStatusCode: http.StatusBadRequest,
+type healthResponse struct{}
+
+func (s *healthResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	o := arena.NewObject()
+
+	o.Set("status", arena.NewString("ok"))
+
+	return o.MarshalTo(nil), nil
+}
+
+type readinessResponse struct {
+	// Internal fields.
+	synced   bool
+	numPeers int
+	minPeers int
+	round    uint64
+}
+
+func (s *readinessResponse) ready() bool {
+	return s.synced && s.numPeers >= s.minPeers
+}
+
+func (s *readinessResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	o := arena.NewObject()
+
+	if s.ready() {
+		o.Set("ready", arena.NewTrue())
+	} else {
+		o.Set("ready", arena.NewFalse())
+	}
+
+	if s.synced {
+		o.Set("synced", arena.NewTrue())
+	} else {
+		o.Set("synced", arena.NewFalse())
+	}
+
+	o.Set("num_peers", arena.NewNumberInt(s.numPeers))
+	o.Set("min_peers", arena.NewNumberInt(s.minPeers))
+	o.Set("round", arena.NewNumberString(strconv.FormatUint(s.round, 10)))
+
+	return o.MarshalTo(nil), nil
+}
+
+type ledgerStateResponse struct {
+	// Internal fields.
+	since  uint64
+	latest *wavelet.Round
+	rounds []*wavelet.Round
+
+	numAccounts  uint64
+	numTx        int
+	numMissingTx int
+}
+
+func (s *ledgerStateResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	if s.latest == nil {
+		return nil, errors.New("insufficient fields specified")
+	}
+
+	o := arena.NewObject()
+
+	o.Set("round", arena.NewNumberString(strconv.FormatUint(s.latest.Index, 10)))
+	o.Set("since", arena.NewNumberString(strconv.FormatUint(s.since, 10)))
+
+	if s.latest.Index <= s.since {
+		o.Set("changed", arena.NewFalse())
+		return o.MarshalTo(nil), nil
+	}
+
+	o.Set("changed", arena.NewTrue())
+	o.Set("root_id", arena.NewString(hex.EncodeToString(s.latest.End.ID[:])))
+	o.Set("merkle_root", arena.NewString(hex.EncodeToString(s.latest.Merkle[:])))
+
+	// Rounds which are no longer retained by the node are left out.
+	if len(s.rounds) == 0 || s.rounds[0].Index > s.since+1 {
+		o.Set("truncated", arena.NewTrue())
+	} else {
+		o.Set("truncated", arena.NewFalse())
+	}
+
+	rounds := arena.NewArray()
+
+	for i, round := range s.rounds {
+		r := arena.NewObject()
+		r.Set("index", arena.NewNumberString(strconv.FormatUint(round.Index, 10)))
+		r.Set("id", arena.NewString(hex.EncodeToString(round.ID[:])))
+		r.Set("root_id", arena.NewString(hex.EncodeToString(round.End.ID[:])))
+		r.Set("applied", arena.NewNumberString(strconv.FormatUint(round.Applied, 10)))
+
+		rounds.SetArrayItem(i, r)
+	}
+
+	o.Set("rounds", rounds)
+	o.Set("num_accounts", arena.NewNumberString(strconv.FormatUint(s.numAccounts, 10)))
+	o.Set("num_tx", arena.NewNumberInt(s.numTx))
+	o.Set("num_missing_tx", arena.NewNumberInt(s.numMissingTx))
+
+	return o.MarshalTo(nil), nil
+}
+
+type randomBeaconResponse struct {
+	// Internal fields.
+	round  uint64
+	beacon wavelet.RandomBeacon
+}
+
+func (s *randomBeaconResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	o := arena.NewObject()
+
+	o.Set("round", arena.NewNumberString(strconv.FormatUint(s.round, 10)))
+	o.Set("beacon", arena.NewString(hex.EncodeToString(s.beacon[:])))
+
+	return o.MarshalTo(nil), nil
+}
+
+type networkStatsResponse struct {
+	// Internal fields.
+	numPeers int
+	round    *wavelet.Round
+	tps      float64
+	synced   bool
+}
+
+func (s *networkStatsResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	if s.round == nil {
+		return nil, errors.New("insufficient fields specified")
+	}
+
+	o := arena.NewObject()
+
+	o.Set("num_peers", arena.NewNumberInt(s.numPeers))
+	o.Set("round", arena.NewNumberString(strconv.FormatUint(s.round.Index, 10)))
+	o.Set("root_id", arena.NewString(hex.EncodeToString(s.round.End.ID[:])))
+	o.Set("tps", arena.NewNumberFloat64(s.tps))
+
+	if s.synced {
+		o.Set("synced", arena.NewTrue())
+	} else {
+		o.Set("synced", arena.NewFalse())
+	}
+
+	return o.MarshalTo(nil), nil
+}
+
+type ledgerStatusResponse struct {
+	// Internal fields.
+
+	client    *skademlia.Client
+	ledger    *wavelet.Ledger
+	publicKey edwards25519.PublicKey
+}
+
+func (s *ledgerStatusResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	if s.client == nil || s.ledger == nil {
+		return nil, errors.New("insufficient parameters were provided")
+	}
+
+	snapshot := s.ledger.Snapshot()
+	round := s.ledger.Rounds().Latest()
+	params := sys.Params()
+
+	accountsLen := wavelet.ReadAccountsLen(snapshot)
+
+	o := arena.NewObject()
+
+	o.Set("public_key", arena.NewString(hex.EncodeToString(s.publicKey[:])))
+	o.Set("address", arena.NewString(s.client.ID().Address()))
+	o.Set("num_accounts", arena.NewNumberString(strconv.FormatUint(accountsLen, 10)))
+
+	r := arena.NewObject()
+	r.Set("merkle_root", arena.NewString(hex.EncodeToString(round.Merkle[:])))
+	r.Set("start_id", arena.NewString(hex.EncodeToString(round.Start.ID[:])))
+	r.Set("end_id", arena.NewString(hex.EncodeToString(round.End.ID[:])))
+	r.Set("applied", arena.NewNumberString(strconv.FormatUint(round.Applied, 10)))
+	r.Set("depth", arena.NewNumberString(strconv.FormatUint(round.End.Depth-round.Start.Depth, 10)))
+	r.Set("difficulty", arena.NewNumberString(strconv.FormatUint(uint64(round.ExpectedDifficulty(params.MinDifficulty, params.DifficultyScaleFactor)), 10)))
+
+	o.Set("round", r)
+
+	peers := s.client.ClosestPeerIDs()
+	if len(peers) > 0 {
+		peersArray := arena.NewArray()
+
+		for i := range peers {
+			publicKey := peers[i].PublicKey()
+
+			peer := arena.NewObject()
+			peer.Set("address", arena.NewString(peers[i].Address()))
+			peer.Set("public_key", arena.NewString(hex.EncodeToString(publicKey[:])))
+
+			peersArray.SetArrayItem(i, peer)
+		}
+		o.Set("peers", peersArray)
+	} else {
+		o.Set("peers", nil)
+	}
+
+	return o.MarshalTo(nil), nil
+}
+
+type transaction struct {
+	// Internal fields.
+	tx      *wavelet.Transaction
+	status  string
+	timings *wavelet.TransactionTimings
+
+	rejections []wavelet.TransactionRejection
+}
+
+func (s *transaction) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	o, err := s.getObject(arena)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.MarshalTo(nil), nil
+}
+
+func (s *transaction) getObject(arena *fastjson.Arena) (*fastjson.Value, error) {
+	if s.tx == nil {
+		return nil, errors.New("insufficient fields specified")
+	}
+
+	o := arena.NewObject()
+
+	o.Set("id", arena.NewString(hex.EncodeToString(s.tx.ID[:])))
+	o.Set("sender", arena.NewString(hex.EncodeToString(s.tx.Sender[:])))
+	o.Set("creator", arena.NewString(hex.EncodeToString(s.tx.Creator[:])))
+	o.Set("status", arena.NewString(s.status))
+	o.Set("nonce", arena.NewNumberString(strconv.FormatUint(s.tx.Nonce, 10)))
+	o.Set("valid_until_view", arena.NewNumberString(strconv.FormatUint(s.tx.ValidUntilView, 10)))
+	o.Set("depth", arena.NewNumberString(strconv.FormatUint(s.tx.Depth, 10)))
+	o.Set("tag", arena.NewNumberInt(int(s.tx.Tag)))
+	o.Set("payload", arena.NewString(base64.StdEncoding.EncodeToString(s.tx.Payload)))
+	o.Set("sender_signature", arena.NewString(hex.EncodeToString(s.tx.SenderSignature[:])))
+	o.Set("creator_signature", arena.NewString(hex.EncodeToString(s.tx.CreatorSignature[:])))
+
+	// Memos are encrypted to the recipient, and are merely passed through.
+	if s.tx.Tag == sys.TagTransfer {
+		if transfer, err := wavelet.ParseTransferTransaction(s.tx.Payload); err == nil && len(transfer.Memo) > 0 {
+			o.Set("memo", arena.NewString(hex.EncodeToString(transfer.Memo)))
+		}
+	}
+
+	if s.tx.ParentIDs != nil {
+		parents := arena.NewArray()
+		for i := range s.tx.ParentIDs {
+			parents.SetArrayItem(i, arena.NewString(hex.EncodeToString(s.tx.ParentIDs[i][:])))
+		}
+		o.Set("parents", parents)
+	} else {
+		o.Set("parents", nil)
+	}
+
+	if s.timings != nil {
+		timings := arena.NewObject()
+
+		stages := []struct {
+			key string
+			at  time.Time
+		}{
+			{"received", s.timings.Received},
+			{"accepted", s.timings.Accepted},
+			{"finalized", s.timings.Finalized},
+			{"applied", s.timings.Applied},
+		}
+
+		for _, stage := range stages {
+			if stage.at.IsZero() {
+				continue
+			}
+
+			timings.Set(stage.key, arena.NewNumberString(strconv.FormatInt(stage.at.UnixNano(), 10)))
+		}
+
+		o.Set("timings", timings)
+	}
+
+	if len(s.rejections) > 0 {
+		rejections := arena.NewArray()
+
+		for i, rejection := range s.rejections {
+			r := arena.NewObject()
+			r.Set("peer", arena.NewString(rejection.Peer))
+			r.Set("reason", arena.NewString(rejection.Reason))
+			r.Set("message", arena.NewString(rejection.Message))
+
+			rejections.SetArrayItem(i, r)
+		}
+
+		o.Set("rejections", rejections)
+	}
+
+	return o, nil
+}
+
+type transactionList []*transaction
+
+func (s transactionList) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	list, err := s.getArray(arena)
+	if err != nil {
+		return nil, err
+	}
+
+	return list.MarshalTo(nil), nil
+}
+
+func (s transactionList) getArray(arena *fastjson.Arena) (*fastjson.Value, error) {
+	list := arena.NewArray()
+
+	for i, v := range s {
+		o, err := v.getObject(arena)
+		if err != nil {
+			return nil, err
+		}
+
+		list.SetArrayItem(i, o)
+	}
+
+	return list, nil
+}
+
+// Upper bounds of the age brackets pending transactions are counted under.
+// Transactions older than the last bracket are counted as "older".
+var mempoolAgeBrackets = []struct {
+	name string
+	age  time.Duration
+}{
+	{name: "10s", age: 10 * time.Second},
+	{name: "1m", age: 1 * time.Minute},
+	{name: "10m", age: 10 * time.Minute},
+}
+
+type mempoolResponse struct {
+	// Internal fields.
+	pending []wavelet.PendingTransaction
+	offset  uint64
+	limit   uint64
+	now     time.Time
+}
+
+func (s *mempoolResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	o := arena.NewObject()
+
+	var (
+		incomplete int
+		tags       [256]int
+		ages       = make([]int, len(mempoolAgeBrackets)+1)
+	)
+
+	for _, tx := range s.pending {
+		if !tx.Complete {
+			incomplete++
+		}
+
+		tags[tx.Tag]++
+
+		age := s.now.Sub(tx.ReceivedAt)
+
+		bracket := sort.Search(len(mempoolAgeBrackets), func(i int) bool {
+			return age < mempoolAgeBrackets[i].age
+		})
+
+		ages[bracket]++
+	}
+
+	o.Set("count", arena.NewNumberInt(len(s.pending)))
+	o.Set("incomplete", arena.NewNumberInt(incomplete))
+
+	names := make([]string, 0, len(filterTags))
+	for name := range filterTags {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		return filterTags[names[i]] < filterTags[names[j]]
+	})
+
+	byTag := arena.NewObject()
+	for _, name := range names {
+		byTag.Set(name, arena.NewNumberInt(tags[filterTags[name]]))
+	}
+
+	o.Set("by_tag", byTag)
+
+	byAge := arena.NewObject()
+	for i, bracket := range mempoolAgeBrackets {
+		byAge.Set(bracket.name, arena.NewNumberInt(ages[i]))
+	}
+	byAge.Set("older", arena.NewNumberInt(ages[len(mempoolAgeBrackets)]))
+
+	o.Set("by_age", byAge)
+
+	list := arena.NewArray()
+
+	if s.offset < uint64(len(s.pending)) {
+		page := s.pending[s.offset:]
+
+		if uint64(len(page)) > s.limit {
+			page = page[:s.limit]
+		}
+
+		for i, tx := range page {
+			status := wavelet.TransactionStatusAccepted
+			if !tx.Complete {
+				status = wavelet.TransactionStatusReceived
+			}
+
+			v, err := (&transaction{tx: tx.Transaction, status: status}).getObject(arena)
+			if err != nil {
+				return nil, err
+			}
+
+			v.Set("received_at", arena.NewNumberString(strconv.FormatInt(tx.ReceivedAt.UnixNano(), 10)))
+			setAmount(arena, v, "fee", tx.Fee())
+
+			list.SetArrayItem(i, v)
+		}
+	}
+
+	o.Set("transactions", list)
+
+	return o.MarshalTo(nil), nil
+}
+
+type transactionGraph struct {
+	transaction *transaction
+	ancestors   transactionList
+	descendants transactionList
+}
+
+func (s *transactionGraph) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	if s.transaction == nil {
+		return nil, errors.New("insufficient fields specified")
+	}
+
+	o := arena.NewObject()
+
+	tx, err := s.transaction.getObject(arena)
+	if err != nil {
+		return nil, err
+	}
+
+	o.Set("transaction", tx)
+
+	ancestors, err := s.ancestors.getArray(arena)
+	if err != nil {
+		return nil, err
+	}
+
+	o.Set("ancestors", ancestors)
+
+	descendants, err := s.descendants.getArray(arena)
+	if err != nil {
+		return nil, err
+	}
+
+	o.Set("descendants", descendants)
+
+	return o.MarshalTo(nil), nil
+}
+
+type graphqlRequest struct {
+	// Internal fields.
+	query     string
+	variables map[string]interface{}
+}
+
+// bind reads a query and its variables either out of a JSON-encoded body, or
+// out of the query string of a GET request.
+func (s *graphqlRequest) bind(parser *fastjson.Parser, ctx *fasthttp.RequestCtx) error {
+	var (
+		query     []byte
+		variables []byte
+	)
+
+	if ctx.IsGet() {
+		query = ctx.QueryArgs().Peek("query")
+		variables = ctx.QueryArgs().Peek("variables")
+	} else {
+		body := ctx.PostBody()
+
+		if err := fastjson.ValidateBytes(body); err != nil {
+			return errors.Wrap(err, "invalid json")
+		}
+
+		v, err := parser.ParseBytes(body)
+		if err != nil {
+			return err
+		}
+
+		queryVal := v.Get("query")
+		if queryVal == nil {
+			return errors.New("missing query")
+		}
+
+		if query, err = queryVal.StringBytes(); err != nil {
+			return errors.Wrap(err, "query must be a string")
+		}
+
+		if variablesVal := v.Get("variables"); variablesVal != nil && variablesVal.Type() != fastjson.TypeNull {
+			variables = variablesVal.MarshalTo(nil)
+		}
+	}
+
+	if len(query) == 0 {
+		return errors.New("missing query")
+	}
+
+	s.query = string(query)
+	s.variables = make(map[string]interface{})
+
+	if len(variables) > 0 {
+		v, err := parser.ParseBytes(variables)
+		if err != nil {
+			return errors.Wrap(err, "variables must be valid json")
+		}
+
+		o, err := v.Object()
+		if err != nil {
+			return errors.Wrap(err, "variables must be an object")
+		}
+
+		o.Visit(func(key []byte, v *fastjson.Value) {
+			s.variables[string(key)] = graphqlVariable(v)
+		})
+	}
+
+	return nil
+}
+
+type graphqlResponse struct {
+	// Internal fields.
+	root   gqlObject
+	fields []*gqlField
+}
+
+func (s *graphqlResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	e := &gqlExecutor{arena: arena}
+
+	data := e.executeObject(s.root, s.fields, nil)
+
+	if e.aborted != nil {
+		e.errors = []gqlError{{message: e.aborted.Error()}}
+		data = arena.NewNull()
+	}
+
+	o := arena.NewObject()
+	o.Set("data", data)
+
+	if len(e.errors) > 0 {
+		o.Set("errors", e.marshalErrors())
+	}
+
+	return o.MarshalTo(nil), nil
+}
+
+type transactionStatusResponse struct {
+	// Internal fields.
+	id     wavelet.TransactionID
+	status string
+	round  uint64
+}
+
+func (s *transactionStatusResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	o := arena.NewObject()
+
+	o.Set("id", arena.NewString(hex.EncodeToString(s.id[:])))
+	o.Set("status", arena.NewString(s.status))
+
+	// Transactions are never part of the genesis round, which has an index of zero.
+	if s.round != 0 {
+		o.Set("round", arena.NewNumberString(strconv.FormatUint(s.round, 10)))
+	} else {
+		o.Set("round", arena.NewNull())
+	}
+
+	return o.MarshalTo(nil), nil
+}
+
+type receiptResponse struct {
+	// Internal fields.
+	receipt *wavelet.Receipt
+}
+
+func (s *receiptResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	if s.receipt == nil {
+		return nil, errors.New("insufficient fields specified")
+	}
+
+	o := arena.NewObject()
+
+	o.Set("id", arena.NewString(hex.EncodeToString(s.receipt.TxID[:])))
+	o.Set("round", arena.NewNumberString(strconv.FormatUint(s.receipt.Round, 10)))
+
+	if s.receipt.Applied() {
+		o.Set("applied", arena.NewTrue())
+		o.Set("error", arena.NewNull())
+	} else {
+		o.Set("applied", arena.NewFalse())
+		o.Set("error", arena.NewString(s.receipt.Error))
+	}
+
+	o.Set("gas_used", arena.NewNumberString(strconv.FormatUint(s.receipt.GasUsed, 10)))
+
+	if s.receipt.GasLimitExceeded {
+		o.Set("gas_limit_exceeded", arena.NewTrue())
+	} else {
+		o.Set("gas_limit_exceeded", arena.NewFalse())
+	}
+
+	if s.receipt.Reverted {
+		o.Set("reverted", arena.NewTrue())
+	} else {
+		o.Set("reverted", arena.NewFalse())
+	}
+
+	o.Set("result", arena.NewString(hex.EncodeToString(s.receipt.Result)))
+
+	events := arena.NewArray()
+	for i, event := range s.receipt.Events {
+		events.SetArrayItem(i, contractEventObject(arena, event))
+	}
+	o.Set("events", events)
+
+	transactions := arena.NewArray()
+	for i, tx := range s.receipt.Children {
+		v := arena.NewObject()
+		v.Set("creator", arena.NewString(hex.EncodeToString(tx.Creator[:])))
+		v.Set("tag", arena.NewNumberInt(int(tx.Tag)))
+		v.Set("payload", arena.NewString(hex.EncodeToString(tx.Payload)))
+
+		transactions.SetArrayItem(i, v)
+	}
+	o.Set("transactions", transactions)
+
+	return o.MarshalTo(nil), nil
+}
+
+type account struct {
+	// Internal fields.
+	id     wavelet.AccountID
+	ledger *wavelet.Ledger
+}
+
+func (s *account) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	if s.ledger == nil || s.id == wavelet.ZeroAccountID {
+		return nil, errors.New("insufficient fields specified")
+	}
+
+	return accountObject(arena, s.ledger.Snapshot(), s.id).MarshalTo(nil), nil
+}
+
+// setAmount sets key in o to an amount of PERLs, counted in its smallest
+// indivisible unit, and additionally sets key suffixed with "_perls" to the
+// amount formatted as a decimal number of PERLs.
+func setAmount(arena *fastjson.Arena, o *fastjson.Value, key string, amount uint64) {
+	o.Set(key, arena.NewNumberString(strconv.FormatUint(amount, 10)))
+	o.Set(key+"_perls", arena.NewString(wavelet.Amount(amount).String()))
+}
+
+// accountObject renders the balance, stake, nonce and contract details of the
+// account id as of snapshot.
+func accountObject(arena *fastjson.Arena, snapshot *avl.Tree, id wavelet.AccountID) *fastjson.Value {
+	o := arena.NewObject()
+
+	o.Set("public_key", arena.NewString(hex.EncodeToString(id[:])))
+
+	balance, _ := wavelet.ReadAccountBalance(snapshot, id)
+	setAmount(arena, o, "balance", balance)
+
+	stake, _ := wavelet.ReadAccountStake(snapshot, id)
+	setAmount(arena, o, "stake", stake)
+
+	reward, _ := wavelet.ReadAccountReward(snapshot, id)
+	setAmount(arena, o, "reward", reward)
+
+	nonce, _ := wavelet.ReadAccountNonce(snapshot, id)
+	o.Set("nonce", arena.NewNumberString(strconv.FormatUint(nonce, 10)))
+
+	_, isContract := wavelet.ReadAccountContractCode(snapshot, id)
+	if isContract {
+		o.Set("is_contract", arena.NewTrue())
+	} else {
+		o.Set("is_contract", arena.NewFalse())
+	}
+
+	numPages, _ := wavelet.ReadAccountContractNumPages(snapshot, id)
+	if numPages != 0 {
+		o.Set("num_mem_pages", arena.NewNumberString(strconv.FormatUint(numPages, 10)))
+	}
+
+	if owner, exists := wavelet.ReadAccountContractOwner(snapshot, id); exists {
+		o.Set("owner", arena.NewString(hex.EncodeToString(owner[:])))
+	}
+
+	if wavelet.ReadAccountContractPaused(snapshot, id) {
+		o.Set("paused", arena.NewTrue())
+	}
+
+	if quota, exists := wavelet.ReadAccountContractCallQuota(snapshot, id); exists {
+		o.Set("call_quota", arena.NewNumberString(strconv.FormatUint(quota, 10)))
+	}
+
+	return o
+}
+
+// accountStateResponse renders the balance and stake of an account as of a
+// past round.
+type accountStateResponse struct {
+	// Internal fields.
+	id    wavelet.AccountID
+	state wavelet.AccountState
+}
+
+func (s *accountStateResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	o := arena.NewObject()
+
+	o.Set("public_key", arena.NewString(hex.EncodeToString(s.id[:])))
+	o.Set("round", arena.NewNumberString(strconv.FormatUint(s.state.Round, 10)))
+	setAmount(arena, o, "balance", s.state.Balance)
+	setAmount(arena, o, "stake", s.state.Stake)
+
+	return o.MarshalTo(nil), nil
+}
+
+// maxAccountBatchSize is the largest number of accounts which may be looked up
+// in a single request to /accounts/batch.
+const maxAccountBatchSize = 500
+
+type accountBatchRequest struct {
+	PublicKeys []string `json:"public_keys"`
+
+	// Internal fields.
+	ids []wavelet.AccountID
+}
+
+func (s *accountBatchRequest) bind(parser *fastjson.Parser, body []byte) error {
+	if err := fastjson.ValidateBytes(body); err != nil {
+		return errors.Wrap(err, "invalid json")
+	}
+
+	v, err := parser.ParseBytes(body)
+	if err != nil {
+		return err
+	}
+
+	keysVal := v.Get("public_keys")
+	if keysVal == nil {
+		return errors.New("missing public_keys")
+	}
+
+	keys, err := keysVal.Array()
+	if err != nil {
+		return errors.Wrap(err, "public_keys must be an array")
+	}
+
+	if len(keys) == 0 {
+		return errors.New("public_keys must not be empty")
+	}
+
+	if len(keys) > maxAccountBatchSize {
+		return errors.Errorf("at most %d accounts may be looked up at once, but got %d", maxAccountBatchSize, len(keys))
+	}
+
+	s.PublicKeys = make([]string, 0, len(keys))
+	s.ids = make([]wavelet.AccountID, 0, len(keys))
+
+	for i, keyVal := range keys {
+		keyBuf, err := keyVal.StringBytes()
+		if err != nil {
+			return errors.Wrapf(err, "public key at index %d must be a string", i)
+		}
+
+		slice, err := hex.DecodeString(string(keyBuf))
+		if err != nil {
+			return errors.Wrapf(err, "public key at index %d must be presented as valid hex", i)
+		}
+
+		if len(slice) != wavelet.SizeAccountID {
+			return errors.Errorf("public key at index %d must be %d bytes long", i, wavelet.SizeAccountID)
+		}
+
+		var id wavelet.AccountID
+		copy(id[:], slice)
+
+		s.PublicKeys = append(s.PublicKeys, string(keyBuf))
+		s.ids = append(s.ids, id)
+	}
+
+	return nil
+}
+
+// accountBatchResponse renders a list of accounts in the order they were
+// requested. All accounts are read from the same snapshot of the ledger, such
+// that they are consistent with one another.
+type accountBatchResponse struct {
+	// Internal fields.
+	ids    []wavelet.AccountID
+	ledger *wavelet.Ledger
+}
+
+func (s *accountBatchResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	if s.ledger == nil {
+		return nil, errors.New("insufficient fields specified")
+	}
+
+	snapshot := s.ledger.Snapshot()
+
+	list := arena.NewArray()
+
+	for i, id := range s.ids {
+		list.SetArrayItem(i, accountObject(arena, snapshot, id))
+	}
+
+	return list.MarshalTo(nil), nil
+}
+
+type connectRequest struct {
+	Address string `json:"address"`
+}
+
+func (s *connectRequest) bind(parser *fastjson.Parser, body []byte) error {
+	if err := fastjson.ValidateBytes(body); err != nil {
+		return errors.Wrap(err, "invalid json")
+	}
+
+	v, err := parser.ParseBytes(body)
+	if err != nil {
+		return err
+	}
+
+	addressVal := v.Get("address")
+	if addressVal == nil {
+		return errors.New("missing address")
+	}
+
+	addressBuf, err := addressVal.StringBytes()
+	if err != nil {
+		return errors.Wrap(err, "address must be a string")
+	}
+
+	if _, _, err := net.SplitHostPort(string(addressBuf)); err != nil {
+		return errors.Wrap(err, "address must be in the format host:port")
+	}
+
+	s.Address = string(addressBuf)
+
+	return nil
+}
+
+type connectResponse struct {
+	Address string `json:"address"`
+}
+
+func (s *connectResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	o := arena.NewObject()
+
+	o.Set("address", arena.NewString(s.Address))
+	o.Set("connected", arena.NewTrue())
+
+	return o.MarshalTo(nil), nil
+}
+
+type accountProofResponse struct {
+	// Internal fields.
+	proof wavelet.AccountProof
+}
+
+func (s *accountProofResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	o := arena.NewObject()
+
+	o.Set("id", arena.NewString(hex.EncodeToString(s.proof.Account[:])))
+	o.Set("round", arena.NewNumberString(strconv.FormatUint(s.proof.Round.Index, 10)))
+	o.Set("round_id", arena.NewString(hex.EncodeToString(s.proof.Round.ID[:])))
+	o.Set("merkle_root", arena.NewString(hex.EncodeToString(s.proof.Round.Merkle[:])))
+
+	proofs := arena.NewObject()
+
+	for _, field := range []struct {
+		name  string
+		proof [][]byte
+	}{
+		{name: "nonce", proof: s.proof.Nonce},
+		{name: "balance", proof: s.proof.Balance},
+		{name: "stake", proof: s.proof.Stake},
+		{name: "reward", proof: s.proof.Reward},
+	} {
+		nodes := arena.NewArray()
+		for i, node := range field.proof {
+			nodes.SetArrayItem(i, arena.NewString(hex.EncodeToString(node)))
+		}
+
+		proofs.Set(field.name, nodes)
+	}
+
+	o.Set("proofs", proofs)
+
+	return o.MarshalTo(nil), nil
+}
+
+type accountHistoryResponse []wavelet.AccountDelta
+
+func (s accountHistoryResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	list := arena.NewArray()
+
+	for i, delta := range s {
+		o := arena.NewObject()
+
+		o.Set("round", arena.NewNumberString(strconv.FormatUint(delta.Round, 10)))
+		setAmount(arena, o, "balance", delta.Balance)
+		setAmount(arena, o, "prev_balance", delta.PrevBalance)
+		setAmount(arena, o, "stake", delta.Stake)
+		setAmount(arena, o, "prev_stake", delta.PrevStake)
+
+		list.SetArrayItem(i, o)
+	}
+
+	return list.MarshalTo(nil), nil
+}
+
+type tokenResponse struct {
+	// Internal fields.
+	id    wavelet.TransactionID
+	token wavelet.Token
+}
+
+func (s *tokenResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	o := arena.NewObject()
+
+	o.Set("id", arena.NewString(hex.EncodeToString(s.id[:])))
+	o.Set("creator", arena.NewString(hex.EncodeToString(s.token.Creator[:])))
+	o.Set("supply", arena.NewNumberString(strconv.FormatUint(s.token.Supply, 10)))
+	o.Set("decimals", arena.NewNumberInt(int(s.token.Decimals)))
+	o.Set("symbol", arena.NewString(s.token.Symbol))
+
+	return o.MarshalTo(nil), nil
+}
+
+type accountTokensResponse []wavelet.TokenBalance
+
+func (s accountTokensResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	list := arena.NewArray()
+
+	for i, balance := range s {
+		o := arena.NewObject()
+
+		o.Set("token_id", arena.NewString(hex.EncodeToString(balance.TokenID[:])))
+		o.Set("balance", arena.NewNumberString(strconv.FormatUint(balance.Balance, 10)))
+
+		list.SetArrayItem(i, o)
+	}
+
+	return list.MarshalTo(nil), nil
+}
+
+type roundChangesResponse []wavelet.AccountChange
+
+func (s roundChangesResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	list := arena.NewArray()
+
+	for i, change := range s {
+		o := arena.NewObject()
+
+		o.Set("public_key", arena.NewString(hex.EncodeToString(change.Account[:])))
+		o.Set("round", arena.NewNumberString(strconv.FormatUint(change.Round, 10)))
+		setAmount(arena, o, "balance", change.Balance)
+		setAmount(arena, o, "prev_balance", change.PrevBalance)
+		setAmount(arena, o, "stake", change.Stake)
+		setAmount(arena, o, "prev_stake", change.PrevStake)
+
+		list.SetArrayItem(i, o)
+	}
+
+	return list.MarshalTo(nil), nil
+}
+
+type metricsHistoryResponse []wavelet.MetricsSnapshot
+
+func (s metricsHistoryResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	list := arena.NewArray()
+
+	for i, snapshot := range s {
+		o := arena.NewObject()
+
+		o.Set("seq", arena.NewNumberString(strconv.FormatUint(snapshot.Seq, 10)))
+		o.Set("time", arena.NewNumberString(strconv.FormatInt(snapshot.Time.UnixNano(), 10)))
+		o.Set("round", arena.NewNumberString(strconv.FormatUint(snapshot.RoundIndex, 10)))
+		o.Set("num_peers", arena.NewNumberInt(int(snapshot.NumPeers)))
+		o.Set("mempool_size", arena.NewNumberInt(int(snapshot.MempoolSize)))
+		o.Set("finality_latency", arena.NewNumberString(strconv.FormatInt(snapshot.FinalityLatency.Nanoseconds(), 10)))
+
+		list.SetArrayItem(i, o)
+	}
+
+	return list.MarshalTo(nil), nil
+}
+
+type tuneParamsRequest struct {
+	// Internal fields. Only valid for as long as the parser it was bound with is.
+	params *fastjson.Value
+}
+
+func (s *tuneParamsRequest) bind(parser *fastjson.Parser, body []byte) error {
+	if err := fastjson.ValidateBytes(body); err != nil {
+		return errors.Wrap(err, "invalid json")
+	}
+
+	v, err := parser.ParseBytes(body)
+	if err != nil {
+		return err
+	}
+
+	if v.Type() != fastjson.TypeObject {
+		return errors.New("params must be given as an object")
+	}
+
+	s.params = v
+
+	return nil
+}
+
+type paramsResponse struct{}
+
+func (s *paramsResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	return wavelet.TunableParams(arena).MarshalTo(nil), nil
+}
+
+type peerStatsResponse struct {
+	// Internal fields.
+	id    wavelet.AccountID
+	stats wavelet.PeerStatsSnapshot
+}
+
+func (s *peerStatsResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	o := arena.NewObject()
+
+	o.Set("public_key", arena.NewString(hex.EncodeToString(s.id[:])))
+	o.Set("address", arena.NewString(s.stats.Address))
+
+	if !s.stats.LastSeen.IsZero() {
+		o.Set("last_seen", arena.NewNumberString(strconv.FormatInt(s.stats.LastSeen.UnixNano(), 10)))
+	}
+
+	if s.stats.Latency > 0 {
+		o.Set("latency", arena.NewNumberString(strconv.FormatInt(s.stats.Latency.Nanoseconds(), 10)))
+	}
+
+	if !s.stats.LastPong.IsZero() {
+		o.Set("ping_rtt", arena.NewNumberString(strconv.FormatInt(s.stats.PingRTT.Nanoseconds(), 10)))
+		o.Set("last_pong", arena.NewNumberString(strconv.FormatInt(s.stats.LastPong.UnixNano(), 10)))
+	}
+
+	if offset := s.stats.ClockOffset; offset != nil {
+		o.Set("clock_offset", arena.NewNumberString(strconv.FormatInt(offset.Nanoseconds(), 10)))
+	}
+
+	if root := s.stats.Root; root != nil {
+		o.Set("last_round", marshalPeerRoot(arena, root))
+	}
+
+	opcodes := make([]string, 0, len(s.stats.Opcodes))
+	for opcode := range s.stats.Opcodes {
+		opcodes = append(opcodes, opcode)
+	}
+
+	sort.Strings(opcodes)
+
+	list := arena.NewObject()
+
+	for _, opcode := range opcodes {
+		counters := s.stats.Opcodes[opcode]
+
+		c := arena.NewObject()
+
+		c.Set("calls", arena.NewNumberString(strconv.FormatUint(counters.Calls, 10)))
+		c.Set("errors", arena.NewNumberString(strconv.FormatUint(counters.Errors, 10)))
+		c.Set("timeouts", arena.NewNumberString(strconv.FormatUint(counters.Timeouts, 10)))
+
+		if counters.Calls > 0 {
+			c.Set("error_rate", arena.NewNumberFloat64(float64(counters.Errors)/float64(counters.Calls)))
+		} else {
+			c.Set("error_rate", arena.NewNumberInt(0))
+		}
+
+		c.Set("messages_sent", arena.NewNumberString(strconv.FormatUint(counters.MessagesSent, 10)))
+		c.Set("messages_received", arena.NewNumberString(strconv.FormatUint(counters.MessagesReceived, 10)))
+		c.Set("bytes_sent", arena.NewNumberString(strconv.FormatUint(counters.BytesSent, 10)))
+		c.Set("bytes_received", arena.NewNumberString(strconv.FormatUint(counters.BytesReceived, 10)))
+
+		list.Set(opcode, c)
+	}
+
+	o.Set("opcodes", list)
+
+	return o.MarshalTo(nil), nil
+}
+
+func marshalPeerRoot(arena *fastjson.Arena, root *wavelet.PeerRoot) *fastjson.Value {
+	r := arena.NewObject()
+
+	r.Set("index", arena.NewNumberString(strconv.FormatUint(root.Index, 10)))
+	r.Set("id", arena.NewString(hex.EncodeToString(root.ID[:])))
+	r.Set("merkle_root", arena.NewString(hex.EncodeToString(root.Merkle[:])))
+	r.Set("seen_at", arena.NewNumberString(strconv.FormatInt(root.SeenAt.UnixNano(), 10)))
+
+	return r
+}
+
+type peersResponse struct {
+	// Internal fields.
+	peers  []*skademlia.ID
+	stats  *wavelet.PeerStats
+	banned []wavelet.AccountID
+}
+
+func (s *peersResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	o := arena.NewObject()
+
+	peers := arena.NewArray()
+
+	for i, id := range s.peers {
+		publicKey := id.PublicKey()
+
+		peer := arena.NewObject()
+		peer.Set("public_key", arena.NewString(hex.EncodeToString(publicKey[:])))
+		peer.Set("address", arena.NewString(id.Address()))
+
+		if stats, exists := s.stats.Snapshot(publicKey); exists {
+			if stats.Latency > 0 {
+				peer.Set("latency", arena.NewNumberString(strconv.FormatInt(stats.Latency.Nanoseconds(), 10)))
+			}
+
+			if !stats.LastPong.IsZero() {
+				peer.Set("ping_rtt", arena.NewNumberString(strconv.FormatInt(stats.PingRTT.Nanoseconds(), 10)))
+			}
+
+			if root := stats.Root; root != nil {
+				peer.Set("last_round", marshalPeerRoot(arena, root))
+			}
+		}
+
+		peers.SetArrayItem(i, peer)
+	}
+
+	o.Set("peers", peers)
+
+	banned := arena.NewArray()
+
+	for i, id := range s.banned {
+		banned.SetArrayItem(i, arena.NewString(hex.EncodeToString(id[:])))
+	}
+
+	o.Set("banned", banned)
+
+	return o.MarshalTo(nil), nil
+}
+
+type peerActionResponse struct {
+	// Internal fields.
+	id    wavelet.AccountID
+	event string
+}
+
+func (s *peerActionResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	o := arena.NewObject()
+
+	o.Set("public_key", arena.NewString(hex.EncodeToString(s.id[:])))
+	o.Set(s.event, arena.NewTrue())
+
+	return o.MarshalTo(nil), nil
+}
+
+type promoteResponse struct {
+	// Internal fields.
+	round uint64
+}
+
+func (s *promoteResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	o := arena.NewObject()
+
+	o.Set("round", arena.NewNumberString(strconv.FormatUint(s.round, 10)))
+	o.Set("promoted", arena.NewTrue())
+
+	return o.MarshalTo(nil), nil
+}
+
+type verifyStateProgress struct {
+	// Internal fields.
+	leaves uint64
+}
+
+func (s *verifyStateProgress) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	o := arena.NewObject()
+
+	o.Set("event", arena.NewString("progress"))
+	o.Set("leaves_verified", arena.NewNumberString(strconv.FormatUint(s.leaves, 10)))
+
+	return o.MarshalTo(nil), nil
+}
+
+type verifyStateResult struct {
+	// Internal fields.
+	report *wavelet.StateReport
+	err    error
+}
+
+func (s *verifyStateResult) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	if s.report == nil {
+		return nil, errors.New("insufficient fields specified")
+	}
+
+	o := arena.NewObject()
+
+	o.Set("event", arena.NewString("result"))
+	o.Set("round", arena.NewNumberString(strconv.FormatUint(s.report.Round, 10)))
+	o.Set("merkle_root", arena.NewString(hex.EncodeToString(s.report.MerkleRoot[:])))
+	o.Set("leaves_verified", arena.NewNumberString(strconv.FormatUint(s.report.NumLeaves, 10)))
+	o.Set("num_accounts", arena.NewNumberString(strconv.FormatUint(s.report.NumAccounts, 10)))
+
+	healthy := s.err == nil && s.report.Healthy()
+
+	if healthy {
+		o.Set("healthy", arena.NewTrue())
+	} else {
+		o.Set("healthy", arena.NewFalse())
+	}
+
+	if s.err != nil {
+		o.Set("error", arena.NewString(s.err.Error()))
+	}
+
+	problems := arena.NewArray()
+
+	for i, problem := range s.report.Problems {
+		problems.SetArrayItem(i, arena.NewString(problem))
+	}
+
+	o.Set("problems", problems)
+
+	return o.MarshalTo(nil), nil
+}
+
+type errResponse struct {
+	Err            error `json:"-"` // low-level runtime error
+	HTTPStatusCode int   `json:"-"` // http response status code
+}
+
+func (e *errResponse) marshalJSON(arena *fastjson.Arena) ([]byte, error) {
+	o := arena.NewObject()
+
+	o.Set("status", arena.NewString("Bad request."))
+
+	if e.Err != nil {
+		o.Set("error", arena.NewString(e.Err.Error()))
+	}
+
+	return o.MarshalTo(nil), nil
+}
+
+func ErrBadRequest(err error) *errResponse {
+	return &errResponse{
+		Err:            err,
+		HTTPStatusCode: http.StatusBadRequest,
 	}
 }
 
@@ -383,6 +1904,48 @@ func ErrNotFound(err error) *errResponse {
 	}
 }
 
+func ErrUnauthorized(err error) *errResponse {
+	return &errResponse{
+		Err:            err,
+		HTTPStatusCode: http.StatusUnauthorized,
+	}
+}
+
+func ErrForbidden(err error) *errResponse {
+	return &errResponse{
+		Err:            err,
+		HTTPStatusCode: http.StatusForbidden,
+	}
+}
+
+func ErrTooManyRequests(err error) *errResponse {
+	return &errResponse{
+		Err:            err,
+		HTTPStatusCode: http.StatusTooManyRequests,
+	}
+}
+
+func ErrRequestTimeout(err error) *errResponse {
+	return &errResponse{
+		Err:            err,
+		HTTPStatusCode: http.StatusRequestTimeout,
+	}
+}
+
+func ErrRequestEntityTooLarge(err error) *errResponse {
+	return &errResponse{
+		Err:            err,
+		HTTPStatusCode: http.StatusRequestEntityTooLarge,
+	}
+}
+
+func ErrUnavailable(err error) *errResponse {
+	return &errResponse{
+		Err:            err,
+		HTTPStatusCode: http.StatusServiceUnavailable,
+	}
+}
+
 func ErrInternal(err error) *errResponse {
 	return &errResponse{
 		Err:            err,
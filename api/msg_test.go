@@ -63,7 +63,7 @@ func TestSendTransactionRequestTag(t *testing.T) {
 	// test send invalid tag
 	typeInvalid := `
 		{
-			"tag": 10,
+			"tag": 11,
 			"sender": "3132333435363738393031323334353637383930313233343536373839303132",
 			"payload": "7061796C6F6164",
 			"signature": "31323334353637383930313233343536373839303132333435363738393031323132333435363738393031323334353637383930313233343536373839303132"
@@ -72,6 +72,34 @@ func TestSendTransactionRequestTag(t *testing.T) {
 	assert.Error(t, req.bind(&fastjson.Parser{}, []byte(typeInvalid)))
 }
 
+func TestSendTransactionRequestValidUntilView(t *testing.T) {
+	req := new(sendTransactionRequest)
+
+	valid := `
+		{
+			"tag": 1,
+			"sender": "3132333435363738393031323334353637383930313233343536373839303132",
+			"payload": "7061796C6F6164",
+			"signature": "31323334353637383930313233343536373839303132333435363738393031323132333435363738393031323334353637383930313233343536373839303132",
+			"valid_until_view": 42
+		}
+	`
+	assert.NoError(t, req.bind(&fastjson.Parser{}, []byte(valid)))
+	assert.EqualValues(t, 42, req.ValidUntilView)
+
+	// test send valid until view as string
+	typeMismatch := `
+		{
+			"tag": 1,
+			"sender": "3132333435363738393031323334353637383930313233343536373839303132",
+			"payload": "7061796C6F6164",
+			"signature": "31323334353637383930313233343536373839303132333435363738393031323132333435363738393031323334353637383930313233343536373839303132",
+			"valid_until_view": "42"
+		}
+	`
+	assert.Error(t, new(sendTransactionRequest).bind(&fastjson.Parser{}, []byte(typeMismatch)))
+}
+
 func TestSendTransactionRequestMissingFields(t *testing.T) {
 	req := new(sendTransactionRequest)
 
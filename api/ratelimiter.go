@@ -21,13 +21,26 @@ package api
 
 import (
 	"github.com/valyala/fasthttp"
+	"golang.org/x/crypto/blake2b"
 	"golang.org/x/time/rate"
 	"math"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 )
 
+const (
+	// DefaultReadRateLimit is the default max number of requests per second
+	// a single IP address may make to each route which reads from the node.
+	DefaultReadRateLimit = 100
+
+	// DefaultSendRateLimit is the default max number of requests per second
+	// a single client may make to each route which sends transactions paid
+	// for by the node.
+	DefaultSendRateLimit = 10
+)
+
 type limiter struct {
 	key      string
 	limiter  *rate.Limiter
@@ -76,11 +89,15 @@ func (r *rateLimiter) getLimiter(key string) *limiter {
 }
 
 // At every interval, check the map for limiters that haven't been seen for
-// more than the expiry duration and delete the entries.
+// more than the expiry duration and delete the entries. The returned stop
+// function blocks until the cleanup goroutine has exited.
 func (r *rateLimiter) cleanup(interval time.Duration) (stop func()) {
 	done := make(chan struct{})
+	exited := make(chan struct{})
+
 	stop = func() {
 		close(done)
+		<-exited
 	}
 
 	ticker := time.NewTicker(interval)
@@ -88,6 +105,7 @@ func (r *rateLimiter) cleanup(interval time.Duration) (stop func()) {
 	ttl := r.expirationTTL.Nanoseconds()
 
 	go func() {
+		defer close(exited)
 		defer ticker.Stop()
 
 		for {
@@ -111,16 +129,44 @@ func (r *rateLimiter) cleanup(interval time.Duration) (stop func()) {
 	return
 }
 
-// Apply rate limiting by key and IP
-func (r *rateLimiter) limit(key string) func(fasthttp.RequestHandler) fasthttp.RequestHandler {
+// byIP identifies the client making a request by its IP address.
+func byIP(ctx *fasthttp.RequestCtx) string {
+	return ctx.RemoteIP().String()
+}
+
+// byAPIKey identifies the client making a request by the API key it presents,
+// or by its IP address should it not present one. Keys are identified by their
+// hashes, such that they are not kept around in memory by the rate limiter.
+func byAPIKey(ctx *fasthttp.RequestCtx) string {
+	key := ctx.Request.Header.Peek(HeaderAPIKey)
+	if len(key) == 0 {
+		return byIP(ctx)
+	}
+
+	id := blake2b.Sum256(key)
+
+	return string(id[:])
+}
+
+// Apply rate limiting by key and the client making the request, which is
+// identified by identify. Requests which are limited are responded to with
+// a Retry-After header specifying the number of seconds until the client
+// may make a request again.
+func (r *rateLimiter) limit(key string, identify func(ctx *fasthttp.RequestCtx) string) func(fasthttp.RequestHandler) fasthttp.RequestHandler {
 	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
 		fn := func(ctx *fasthttp.RequestCtx) {
-			addr := ctx.RemoteAddr().String()
+			l := r.getLimiter(key + identify(ctx))
+
+			if reservation := l.limiter.Reserve(); !reservation.OK() || reservation.Delay() > 0 {
+				retryAfter := int64(1)
 
-			l := r.getLimiter(key + addr)
+				if reservation.OK() {
+					retryAfter = int64(math.Ceil(reservation.Delay().Seconds()))
+					reservation.Cancel()
+				}
 
-			if !l.limiter.Allow() {
 				ctx.Error(http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				ctx.Response.Header.Set("Retry-After", strconv.FormatInt(retryAfter, 10))
 				return
 			}
 
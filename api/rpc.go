@@ -0,0 +1,264 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"context"
+	"github.com/perlin-network/wavelet"
+	"github.com/perlin-network/wavelet/log"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net"
+	"strconv"
+)
+
+var _ APIServer = (*rpcServer)(nil)
+
+// rpcServer serves the API of a gateway over gRPC.
+type rpcServer struct {
+	g *Gateway
+}
+
+func (g *Gateway) startGRPC(port int) {
+	logger := log.Node()
+
+	listener, err := net.Listen("tcp", ":"+strconv.Itoa(port))
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed to start gRPC API server.")
+	}
+
+	g.grpcServer = g.newGRPCServer()
+
+	logger.Info().Int("port", port).Msg("Started gRPC API server.")
+
+	if err := g.grpcServer.Serve(listener); err != nil {
+		logger.Fatal().Err(err).Msg("Failed to start gRPC API server.")
+	}
+}
+
+// newGRPCServer creates a gRPC server which serves the API of the gateway, and
+// checks the API keys presented to it.
+func (g *Gateway) newGRPCServer() *grpc.Server {
+	server := grpc.NewServer(
+		grpc.MaxRecvMsgSize(sys.MaxMessageSize),
+		grpc.MaxSendMsgSize(sys.MaxMessageSize),
+		grpc.UnaryInterceptor(g.unaryAuthInterceptor),
+		grpc.StreamInterceptor(g.streamAuthInterceptor),
+	)
+
+	RegisterAPIServer(server, &rpcServer{g: g})
+
+	return server
+}
+
+func (s *rpcServer) SendTransaction(ctx context.Context, req *SendTransactionRequest) (*SendTransactionResponse, error) {
+	if len(req.Sender) != wavelet.SizeAccountID {
+		return nil, status.Errorf(codes.InvalidArgument, "sender public key must be size %d", wavelet.SizeAccountID)
+	}
+
+	if req.Tag > uint32(sys.TagTransferToken) {
+		return nil, status.Error(codes.InvalidArgument, "unknown transaction tag specified")
+	}
+
+	if len(req.Payload) > sys.MaxTransactionPayloadSize {
+		return nil, status.Errorf(codes.InvalidArgument, "payload is %d bytes, but may only be %d bytes at most", len(req.Payload), sys.MaxTransactionPayloadSize)
+	}
+
+	if len(req.Signature) != wavelet.SizeSignature {
+		return nil, status.Errorf(codes.InvalidArgument, "sender signature must be size %d", wavelet.SizeSignature)
+	}
+
+	if expired(s.g.ledger, req.ValidUntilView) {
+		return nil, status.Errorf(codes.InvalidArgument, "tx has expired: tx is valid until round %d", req.ValidUntilView)
+	}
+
+	if !s.g.ledger.TakeSendToken() {
+		return nil, status.Error(codes.ResourceExhausted, "rate limit")
+	}
+
+	var creator wavelet.AccountID
+	copy(creator[:], req.Sender)
+
+	var signature wavelet.Signature
+	copy(signature[:], req.Signature)
+
+	tx, err := s.g.addTransaction(byte(req.Tag), req.Payload, req.ValidUntilView, creator, signature)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	round := s.g.ledger.Rounds().Latest()
+	params := sys.Params()
+
+	res := &SendTransactionResponse{
+		Id:         tx.ID[:],
+		IsCritical: tx.IsCritical(round.ExpectedDifficulty(params.MinDifficulty, params.DifficultyScaleFactor)),
+	}
+
+	for _, parentID := range tx.ParentIDs {
+		parentID := parentID
+		res.ParentIds = append(res.ParentIds, parentID[:])
+	}
+
+	return res, nil
+}
+
+func (s *rpcServer) GetAccount(ctx context.Context, req *GetAccountRequest) (*Account, error) {
+	if len(req.Id) != wavelet.SizeAccountID {
+		return nil, status.Errorf(codes.InvalidArgument, "account ID must be %d bytes long", wavelet.SizeAccountID)
+	}
+
+	var id wavelet.AccountID
+	copy(id[:], req.Id)
+
+	snapshot := s.g.ledger.Snapshot()
+
+	res := &Account{PublicKey: id[:]}
+
+	res.Balance, _ = wavelet.ReadAccountBalance(snapshot, id)
+	res.Stake, _ = wavelet.ReadAccountStake(snapshot, id)
+	res.Reward, _ = wavelet.ReadAccountReward(snapshot, id)
+	res.Nonce, _ = wavelet.ReadAccountNonce(snapshot, id)
+
+	_, res.IsContract = wavelet.ReadAccountContractCode(snapshot, id)
+	res.NumMemPages, _ = wavelet.ReadAccountContractNumPages(snapshot, id)
+
+	if owner, exists := wavelet.ReadAccountContractOwner(snapshot, id); exists {
+		res.Owner = owner[:]
+	}
+
+	res.Paused = wavelet.ReadAccountContractPaused(snapshot, id)
+
+	return res, nil
+}
+
+func (s *rpcServer) GetTransaction(ctx context.Context, req *GetTransactionRequest) (*Transaction, error) {
+	if len(req.Id) != wavelet.SizeTransactionID {
+		return nil, status.Errorf(codes.InvalidArgument, "transaction ID must be %d bytes long", wavelet.SizeTransactionID)
+	}
+
+	var id wavelet.TransactionID
+	copy(id[:], req.Id)
+
+	tx := s.g.ledger.Graph().FindTransaction(id)
+
+	if tx == nil {
+		return nil, status.Errorf(codes.NotFound, "could not find transaction with ID %x", id)
+	}
+
+	res := &Transaction{
+		Id:               tx.ID[:],
+		Sender:           tx.Sender[:],
+		Creator:          tx.Creator[:],
+		Nonce:            tx.Nonce,
+		Depth:            tx.Depth,
+		Tag:              uint32(tx.Tag),
+		Payload:          tx.Payload,
+		SenderSignature:  tx.SenderSignature[:],
+		CreatorSignature: tx.CreatorSignature[:],
+	}
+
+	for _, parentID := range tx.ParentIDs {
+		parentID := parentID
+		res.ParentIds = append(res.ParentIds, parentID[:])
+	}
+
+	res.Status, res.Round = s.g.ledger.TransactionStatus(id)
+
+	return res, nil
+}
+
+func (s *rpcServer) LedgerStatus(ctx context.Context, req *LedgerStatusRequest) (*LedgerStatusResponse, error) {
+	publicKey := s.g.keys.PublicKey()
+	round := s.g.ledger.Rounds().Latest()
+	params := sys.Params()
+
+	res := &LedgerStatusResponse{
+		PublicKey:   publicKey[:],
+		Address:     s.g.client.ID().Address(),
+		NumAccounts: wavelet.ReadAccountsLen(s.g.ledger.Snapshot()),
+		Round: &Round{
+			Index:      round.Index,
+			MerkleRoot: round.Merkle[:],
+			StartId:    round.Start.ID[:],
+			EndId:      round.End.ID[:],
+			Applied:    round.Applied,
+			Depth:      round.End.Depth - round.Start.Depth,
+			Difficulty: uint32(round.ExpectedDifficulty(params.MinDifficulty, params.DifficultyScaleFactor)),
+		},
+	}
+
+	for _, id := range s.g.client.ClosestPeerIDs() {
+		publicKey := id.PublicKey()
+		res.Peers = append(res.Peers, &Peer{PublicKey: publicKey[:], Address: id.Address()})
+	}
+
+	return res, nil
+}
+
+// Subscribe streams events logged under a topic, which is any of the modules
+// that may otherwise be polled through a websocket under /poll. Events may
+// be filtered by the same query parameters their websocket accepts. Each
+// event holds the JSON-encoded log entry, or a JSON array of log entries
+// for topics whose events are batched.
+func (s *rpcServer) Subscribe(req *SubscribeRequest, stream API_SubscribeServer) error {
+	sink, exists := s.g.sinks[req.Topic]
+	if !exists {
+		return status.Errorf(codes.InvalidArgument, "unknown topic %q", req.Topic)
+	}
+
+	filters := make(map[string]string)
+
+	for queryKey, value := range req.Filters {
+		key, exists := sink.filters[queryKey]
+		if !exists {
+			return status.Errorf(codes.InvalidArgument, "events under topic %q may not be filtered by %q", req.Topic, queryKey)
+		}
+
+		filters[key] = value
+	}
+
+	c := &client{sink: sink, filters: filters, queue: make(chan sinkEvent, clientQueueSize)}
+
+	sink.join <- c
+	defer func() { sink.leave <- c }()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event, ok := <-c.queue:
+			if !ok {
+				return nil
+			}
+
+			if len(event.buf) == 0 {
+				continue
+			}
+
+			if err := stream.Send(&Event{Topic: req.Topic, Data: event.buf}); err != nil {
+				return errors.Wrap(err, "failed to send event")
+			}
+		}
+	}
+}
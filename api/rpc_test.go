@@ -0,0 +1,196 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"context"
+	"encoding/hex"
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net"
+	"testing"
+	"time"
+)
+
+func newTestRPCClient(t *testing.T, gateway *Gateway) (APIClient, func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	server := gateway.newGRPCServer()
+
+	go server.Serve(listener)
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithInsecure())
+	assert.NoError(t, err)
+
+	return NewAPIClient(conn), func() {
+		_ = conn.Close()
+		server.Stop()
+	}
+}
+
+func TestRPCQueries(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	gateway.keys = keys
+	gateway.client = skademlia.NewClient("127.0.0.1:3000", keys)
+
+	client, stop := newTestRPCClient(t, gateway)
+	defer stop()
+
+	ctx := context.Background()
+
+	// Accounts.
+
+	faucet, err := hex.DecodeString(sys.FaucetAddress)
+	assert.NoError(t, err)
+
+	var faucetID wavelet.AccountID
+	copy(faucetID[:], faucet)
+
+	balance, _ := wavelet.ReadAccountBalance(gateway.ledger.Snapshot(), faucetID)
+
+	account, err := client.GetAccount(ctx, &GetAccountRequest{Id: faucet})
+	if assert.NoError(t, err) {
+		assert.Equal(t, faucet, account.PublicKey)
+		assert.Equal(t, balance, account.Balance)
+		assert.False(t, account.IsContract)
+	}
+
+	_, err = client.GetAccount(ctx, &GetAccountRequest{Id: faucet[:4]})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Transactions.
+
+	transactions := gateway.ledger.Graph().ListTransactions(0, 0, wavelet.AccountID{}, wavelet.AccountID{})
+	if !assert.NotEmpty(t, transactions) {
+		return
+	}
+
+	tx, err := client.GetTransaction(ctx, &GetTransactionRequest{Id: transactions[0].ID[:]})
+	if assert.NoError(t, err) {
+		assert.Equal(t, transactions[0].ID[:], tx.Id)
+		assert.Equal(t, transactions[0].Creator[:], tx.Creator)
+		assert.Equal(t, wavelet.TransactionStatusApplied, tx.Status)
+	}
+
+	_, err = client.GetTransaction(ctx, &GetTransactionRequest{Id: make([]byte, wavelet.SizeTransactionID)})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// Ledger status.
+
+	ledger, err := client.LedgerStatus(ctx, &LedgerStatusRequest{})
+	if assert.NoError(t, err) {
+		publicKey := keys.PublicKey()
+
+		assert.Equal(t, publicKey[:], ledger.PublicKey)
+		assert.Equal(t, "127.0.0.1:3000", ledger.Address)
+		assert.EqualValues(t, 0, ledger.Round.Index)
+		assert.Empty(t, ledger.Peers)
+	}
+
+	// Sending transactions.
+
+	_, err = client.SendTransaction(ctx, &SendTransactionRequest{Sender: faucet, Tag: uint32(sys.TagContractAdmin) + 1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.SendTransaction(ctx, &SendTransactionRequest{Sender: faucet, Signature: make([]byte, 4)})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestRPCSubscribe(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	gateway.ledger = createLedger(t)
+
+	client, stop := newTestRPCClient(t, gateway)
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.Subscribe(ctx, &SubscribeRequest{Topic: "foo"})
+	if assert.NoError(t, err) {
+		_, err = stream.Recv()
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+
+	stream, err = client.Subscribe(ctx, &SubscribeRequest{Topic: "stake", Filters: map[string]string{"foo": "bar"}})
+	if assert.NoError(t, err) {
+		_, err = stream.Recv()
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+
+	stream, err = client.Subscribe(ctx, &SubscribeRequest{Topic: "stake", Filters: map[string]string{"id": "a"}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	events := make(chan *Event)
+
+	go func() {
+		for {
+			event, err := stream.Recv()
+			if err != nil {
+				close(events)
+				return
+			}
+
+			events <- event
+		}
+	}()
+
+	// Keep logging events until the subscriber has joined the sink, as it
+	// joins asynchronously. Events of other accounts are filtered out.
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !assert.True(t, ok) {
+				return
+			}
+
+			assert.Equal(t, "stake", event.Topic)
+			assert.JSONEq(t, `{"mod":"stake","account_id":"a"}`, string(event.Data))
+			return
+		case <-ticker.C:
+			_, err := gateway.Write([]byte(`{"mod":"stake","account_id":"b"}`))
+			assert.NoError(t, err)
+
+			_, err = gateway.Write([]byte(`{"mod":"stake","account_id":"a"}`))
+			assert.NoError(t, err)
+		}
+	}
+}
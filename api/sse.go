@@ -0,0 +1,142 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"github.com/pkg/errors"
+	"github.com/valyala/fasthttp"
+	"strconv"
+	"time"
+)
+
+// serveEvents streams the events broadcasted to the sink to a client as
+// server-sent events, for clients which sit behind proxies that do not support
+// websockets. Every event carries the ID of the latest event broadcasted to the
+// sink, such that a client which lost its connection may resume from where it
+// left off by presenting it under the Last-Event-ID header, or the
+// last_event_id query parameter. Server-sent event streams count towards the
+// same connection limits as websocket connections.
+func (s *sink) serveEvents(ctx *fasthttp.RequestCtx) error {
+	filters, expr, err := s.parseFilters(ctx)
+	if err != nil {
+		return err
+	}
+
+	client := &client{
+		filters: filters,
+		expr:    expr,
+		sink:    s,
+		queue:   make(chan sinkEvent, clientQueueSize),
+	}
+
+	lastEventID := ctx.Request.Header.Peek("Last-Event-ID")
+	if len(lastEventID) == 0 {
+		lastEventID = ctx.QueryArgs().Peek("last_event_id")
+	}
+
+	if len(lastEventID) > 0 {
+		if client.lastEventID, err = strconv.ParseUint(string(lastEventID), 10, 64); err != nil {
+			return errors.Wrap(err, "could not parse last event id")
+		}
+
+		client.resume = true
+	}
+
+	ip := ctx.RemoteIP().String()
+
+	if err := s.limiter.acquire(ip); err != nil {
+		return err
+	}
+
+	ctx.SetContentType("text/event-stream")
+	ctx.Response.Header.Set("Cache-Control", "no-cache")
+	ctx.Response.Header.Set("X-Accel-Buffering", "no") // Have reverse proxies not buffer up events.
+
+	ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer s.limiter.release(ip)
+
+		// Have the response headers sent right away, rather than have the
+		// client wait for them until the first event or ping is sent. Headers
+		// are only sent alongside the body, so a comment is sent with them.
+		_, _ = w.WriteString(": connected\n\n")
+
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		s.join <- client
+		client.eventWorker(w)
+		s.leave <- client
+	})
+
+	return nil
+}
+
+// eventWorker writes the messages queued up for a client as server-sent events
+// until either its queue is closed, it disconnects, or the gateway is shut down.
+func (c *client) eventWorker(w *bufio.Writer) {
+	// Send comments often enough that proxies do not consider the stream to be
+	// idle, and such that a client which disconnected is noticed.
+	ticker := time.NewTicker(c.sink.idleTimeout * 9 / 10)
+	defer ticker.Stop()
+
+	queue := c.queue
+
+	for {
+		select {
+		case msg, ok := <-queue:
+			if !ok {
+				return
+			}
+
+			if len(msg.buf) == 0 {
+				continue
+			}
+
+			writeEvent(w, msg)
+		case <-ticker.C:
+			_, _ = w.WriteString(": ping\n\n")
+		case <-c.sink.shutdown:
+			return
+		}
+
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeEvent writes event in the server-sent events format, splitting its data
+// across as many data fields as it has lines.
+func writeEvent(w *bufio.Writer, event sinkEvent) {
+	_, _ = w.WriteString("id: ")
+	_, _ = w.WriteString(strconv.FormatUint(event.id, 10))
+	_ = w.WriteByte('\n')
+
+	for _, line := range bytes.Split(bytes.TrimSpace(event.buf), []byte{'\n'}) {
+		_, _ = w.WriteString("data: ")
+		_, _ = w.Write(line)
+		_ = w.WriteByte('\n')
+	}
+
+	_ = w.WriteByte('\n')
+}
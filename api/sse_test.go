@@ -0,0 +1,179 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"bufio"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSinkResume(t *testing.T) {
+	s := &sink{
+		broadcast: make(chan broadcastItem),
+		join:      make(chan *client),
+		leave:     make(chan *client),
+		clients:   make(map[*client]struct{}),
+	}
+
+	go s.run()
+
+	for i := 0; i < sinkHistorySize+2; i++ {
+		s.broadcast <- broadcastItem{buf: []byte(`{"a":1}`)}
+	}
+
+	s.broadcast <- broadcastItem{buf: []byte(`{"a":2}`)}
+	s.broadcast <- broadcastItem{buf: []byte(`{"a":3}`)}
+
+	// Clients resuming are sent only the events retained which came after the
+	// last event they received, and which match their filters.
+	c := &client{sink: s, filters: map[string]string{"a": "3"}, queue: make(chan sinkEvent, clientQueueSize), resume: true, lastEventID: 3}
+	s.join <- c
+
+	s.broadcast <- broadcastItem{buf: []byte(`{"a":3}`)}
+
+	assert.Equal(t, sinkEvent{id: sinkHistorySize + 4, buf: []byte(`{"a":3}`)}, <-c.queue)
+	assert.Equal(t, sinkEvent{id: sinkHistorySize + 5, buf: []byte(`{"a":3}`)}, <-c.queue)
+
+	// Only the latest events are retained.
+	s.historyLock.Lock()
+	assert.Len(t, s.history, sinkHistorySize)
+	assert.EqualValues(t, 6, s.history[0].id)
+	s.historyLock.Unlock()
+}
+
+func TestServeEvents(t *testing.T) {
+	gateway := New()
+	gateway.setup()
+
+	listener := fasthttputil.NewInmemoryListener()
+	defer listener.Close()
+
+	server := &fasthttp.Server{Handler: gateway.router.Handler}
+	go func() { _ = server.Serve(listener) }()
+
+	// The timeout bounds the entire exchange, including reading the event
+	// stream, such that a stalled stream fails the test rather than hangs it.
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			Dial: func(network, addr string) (net.Conn, error) {
+				return listener.Dial()
+			},
+		},
+	}
+
+	subscribe := func(lastEventID string) *http.Response {
+		req, err := http.NewRequest("GET", "http://localhost/sse/consensus", nil)
+		assert.NoError(t, err)
+
+		req.Header.Set("Last-Event-ID", lastEventID)
+
+		res, err := client.Do(req)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		return res
+	}
+
+	res := subscribe("abc")
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.NoError(t, res.Body.Close())
+
+	// Response headers are sent right away, even with no events to be sent.
+	res = subscribe("")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.NoError(t, res.Body.Close())
+
+	sink := gateway.sinks["consensus"]
+
+	sink.broadcast <- broadcastItem{buf: []byte(`{"a":1}`)}
+	sink.broadcast <- broadcastItem{buf: []byte("{\"a\":2}\n")}
+
+	res = subscribe("1")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	events := make(chan string, 2)
+
+	go func() {
+		reader := bufio.NewReader(res.Body)
+
+		var event strings.Builder
+
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				close(events)
+				return
+			}
+
+			if strings.HasPrefix(line, ":") { // Skip comments.
+				continue
+			}
+
+			if line == "\n" {
+				if event.Len() > 0 {
+					events <- event.String()
+					event.Reset()
+				}
+
+				continue
+			}
+
+			event.WriteString(line)
+		}
+	}()
+
+	next := func() string {
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(3 * time.Second):
+			t.Fatal("timed out waiting for an event")
+			return ""
+		}
+	}
+
+	assert.Equal(t, "id: 2\ndata: {\"a\":2}\n", next())
+
+	sink.broadcast <- broadcastItem{buf: []byte(`{"a":3}`)}
+	assert.Equal(t, "id: 3\ndata: {\"a\":3}\n", next())
+
+	// Event streams are ended upon shutdown.
+	assert.NoError(t, gateway.Shutdown(context.Background()))
+
+	select {
+	case _, ok := <-events:
+		assert.False(t, ok)
+	case <-time.After(3 * time.Second):
+		t.Fatal("event stream was not ended upon shutdown")
+	}
+
+	assert.NoError(t, res.Body.Close())
+}
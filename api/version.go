@@ -0,0 +1,170 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"github.com/pkg/errors"
+	"github.com/valyala/fasthttp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// HeaderAPIVersion is the HTTP header a client may request a version of the
+// API under when making requests to unversioned routes. Responses from routes
+// of any version state the version they were served under in it.
+const HeaderAPIVersion = "X-API-Version"
+
+// DefaultAPIVersion is the version of the API served by unversioned routes to
+// clients which do not request a version, such that clients written before
+// the API was versioned keep working as further versions are introduced.
+const DefaultAPIVersion = 1
+
+// route is a single route of the API, regardless of its version.
+type route struct {
+	method, path string
+}
+
+// versionedRouter registers routes of the API under the prefix of the version
+// they belong to, such as /v1/ledger. Every route is additionally served
+// without a prefix, by a shim which negotiates the version of the route to
+// serve. See HeaderAPIVersion and DefaultAPIVersion.
+//
+// Versions are to be introduced for breaking changes to the shape of requests
+// or responses only. Routes which are left unchanged in a new version are to
+// be registered under it as well, such that clients may pin a single version.
+type versionedRouter struct {
+	*instrumentedRouter
+
+	routes   []route
+	versions map[route]map[int]fasthttp.RequestHandler
+}
+
+func newVersionedRouter(r *instrumentedRouter) *versionedRouter {
+	return &versionedRouter{instrumentedRouter: r, versions: make(map[route]map[int]fasthttp.RequestHandler)}
+}
+
+// apiVersion registers routes of a single version of the API.
+type apiVersion struct {
+	router  *versionedRouter
+	version int
+}
+
+func (r *versionedRouter) version(version int) apiVersion {
+	return apiVersion{router: r, version: version}
+}
+
+func (v apiVersion) Handle(method, path string, handle fasthttp.RequestHandler) {
+	r := route{method: method, path: path}
+
+	versions, exists := v.router.versions[r]
+	if !exists {
+		versions = make(map[int]fasthttp.RequestHandler)
+		v.router.versions[r] = versions
+		v.router.routes = append(v.router.routes, r)
+	}
+
+	versions[v.version] = handle
+
+	v.router.instrumentedRouter.Handle(method, "/v"+strconv.Itoa(v.version)+path, servedUnder(v.version, handle))
+}
+
+func (v apiVersion) GET(path string, handle fasthttp.RequestHandler) {
+	v.Handle("GET", path, handle)
+}
+
+func (v apiVersion) POST(path string, handle fasthttp.RequestHandler) {
+	v.Handle("POST", path, handle)
+}
+
+func (v apiVersion) PUT(path string, handle fasthttp.RequestHandler) {
+	v.Handle("PUT", path, handle)
+}
+
+func (v apiVersion) DELETE(path string, handle fasthttp.RequestHandler) {
+	v.Handle("DELETE", path, handle)
+}
+
+// registerShims serves every route registered under any version without a
+// prefix. It is to be called once all versions of all routes are registered.
+func (r *versionedRouter) registerShims(g *Gateway) {
+	for _, route := range r.routes {
+		r.instrumentedRouter.Handle(route.method, route.path, g.negotiateVersion(r.versions[route]))
+	}
+}
+
+// negotiateVersion serves requests with the version of a route requested under
+// HeaderAPIVersion, or DefaultAPIVersion should none be requested.
+func (g *Gateway) negotiateVersion(versions map[int]fasthttp.RequestHandler) fasthttp.RequestHandler {
+	supported := make([]int, 0, len(versions))
+	for version := range versions {
+		supported = append(supported, version)
+	}
+
+	sort.Ints(supported)
+
+	handlers := make(map[int]fasthttp.RequestHandler, len(versions))
+	for version, handle := range versions {
+		handlers[version] = servedUnder(version, handle)
+	}
+
+	return func(ctx *fasthttp.RequestCtx) {
+		version := DefaultAPIVersion
+
+		if requested := ctx.Request.Header.Peek(HeaderAPIVersion); len(requested) > 0 {
+			v, err := parseAPIVersion(string(requested))
+			if err != nil {
+				g.renderError(ctx, ErrBadRequest(err))
+				return
+			}
+
+			version = v
+		}
+
+		handle, exists := handlers[version]
+		if !exists {
+			g.renderError(ctx, ErrBadRequest(errors.Errorf("route is not served under api version %d; supported versions are %v", version, supported)))
+			return
+		}
+
+		handle(ctx)
+	}
+}
+
+// parseAPIVersion parses a version of the API, such as 1 or v1.
+func parseAPIVersion(s string) (int, error) {
+	version, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(s), "v"))
+	if err != nil || version <= 0 {
+		return 0, errors.Errorf("invalid api version %q", s)
+	}
+
+	return version, nil
+}
+
+// servedUnder states the version of the API a request was served under in the
+// response to it.
+func servedUnder(version int, next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	v := strconv.Itoa(version)
+
+	return func(ctx *fasthttp.RequestCtx) {
+		ctx.Response.Header.Set(HeaderAPIVersion, v)
+		next(ctx)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package api
+
+import (
+	"github.com/buaazp/fasthttprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestParseAPIVersion(t *testing.T) {
+	for s, want := range map[string]int{"1": 1, "v2": 2, " 3 ": 3} {
+		version, err := parseAPIVersion(s)
+		if assert.NoError(t, err, s) {
+			assert.Equal(t, want, version, s)
+		}
+	}
+
+	for _, invalid := range []string{"", "v", "0", "-1", "one", "1.0"} {
+		_, err := parseAPIVersion(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestVersionedRoutes(t *testing.T) {
+	gateway := New()
+
+	r := newVersionedRouter(&instrumentedRouter{Router: fasthttprouter.New(), registry: gateway.metrics})
+
+	respond := func(body string) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			ctx.SetBodyString(body)
+		}
+	}
+
+	r.version(1).GET("/tx/:id", respond("v1 tx"))
+	r.version(2).GET("/tx/:id", respond("v2 tx"))
+	r.version(1).GET("/ledger", respond("v1 ledger"))
+	r.registerShims(gateway)
+
+	tests := []struct {
+		name        string
+		path        string
+		version     string
+		wantCode    int
+		wantBody    string
+		wantVersion string
+	}{
+		{name: "v1 prefix", path: "/v1/tx/1", wantCode: http.StatusOK, wantBody: "v1 tx", wantVersion: "1"},
+		{name: "v2 prefix", path: "/v2/tx/1", wantCode: http.StatusOK, wantBody: "v2 tx", wantVersion: "2"},
+		{name: "prefix ignores header", path: "/v1/tx/1", version: "2", wantCode: http.StatusOK, wantBody: "v1 tx", wantVersion: "1"},
+		{name: "unversioned defaults to v1", path: "/tx/1", wantCode: http.StatusOK, wantBody: "v1 tx", wantVersion: "1"},
+		{name: "unversioned negotiates v2", path: "/tx/1", version: "v2", wantCode: http.StatusOK, wantBody: "v2 tx", wantVersion: "2"},
+		{name: "route not in version", path: "/ledger", version: "2", wantCode: http.StatusBadRequest},
+		{name: "invalid version", path: "/ledger", version: "latest", wantCode: http.StatusBadRequest},
+		{name: "prefix without route", path: "/v2/ledger", wantCode: http.StatusNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			request, err := http.NewRequest("GET", "http://localhost"+tc.path, nil)
+			assert.NoError(t, err)
+
+			if tc.version != "" {
+				request.Header.Set(HeaderAPIVersion, tc.version)
+			}
+
+			res, err := serve(r.Router, request)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			body, err := ioutil.ReadAll(res.Body)
+			assert.NoError(t, err)
+
+			if !assert.Equal(t, tc.wantCode, res.StatusCode, string(body)) || tc.wantCode != http.StatusOK {
+				return
+			}
+
+			assert.Equal(t, tc.wantBody, string(body))
+			assert.Equal(t, tc.wantVersion, res.Header.Get(HeaderAPIVersion))
+		})
+	}
+}
@@ -22,18 +22,49 @@ package api
 import (
 	"github.com/fasthttp/websocket"
 	"github.com/perlin-network/wavelet/debounce"
+	"github.com/pkg/errors"
 	"github.com/valyala/fasthttp"
 	"github.com/valyala/fastjson"
 	"strconv"
+	"sync"
 	"time"
 )
 
 const (
 	writeWait          = 10 * time.Second
-	pongWait           = 60 * time.Second
-	pingPeriod         = (pongWait * 9) / 10
 	maxMessageSize     = 512
 	maxPaginationLimit = 5000
+
+	// Number of messages which may be queued up to be written to a websocket
+	// client before it is considered to be a slow consumer, and disconnected.
+	clientQueueSize = 256
+
+	// Number of the latest events a sink retains, such that clients which lost
+	// their connection may resume from the last event they received. It is at
+	// most clientQueueSize, such that replaying them never has a client be
+	// considered to be a slow consumer.
+	sinkHistorySize = clientQueueSize
+
+	maxTransactionGraphDepth = 16
+)
+
+const (
+	// DefaultMaxWebsocketConnections is the default max number of websocket
+	// connections a node serves at once across all of its sinks.
+	DefaultMaxWebsocketConnections = 1024
+
+	// DefaultMaxWebsocketConnectionsPerIP is the default max number of
+	// websocket connections a node serves at once to a single IP address.
+	DefaultMaxWebsocketConnectionsPerIP = 16
+
+	// DefaultWebsocketIdleTimeout is the default duration a websocket client
+	// may go without responding to a ping before it is disconnected.
+	DefaultWebsocketIdleTimeout = 60 * time.Second
+)
+
+var (
+	ErrMaxWebsocketConnections      = errors.New("node is serving the max number of websocket connections")
+	ErrMaxWebsocketConnectionsPerIP = errors.New("ip address has opened the max number of websocket connections")
 )
 
 var upgrader = websocket.FastHTTPUpgrader{
@@ -44,20 +75,106 @@ var upgrader = websocket.FastHTTPUpgrader{
 	},
 }
 
+// connLimiter caps the number of websocket connections served by a node, both
+// in total and to any single IP address.
+type connLimiter struct {
+	sync.Mutex
+
+	max, maxPerIP int
+
+	total int
+	perIP map[string]int
+
+	conns sync.WaitGroup // Connections which have yet to be released.
+}
+
+func newConnLimiter(max, maxPerIP int) *connLimiter {
+	return &connLimiter{max: max, maxPerIP: maxPerIP, perIP: make(map[string]int)}
+}
+
+// acquire reserves a connection for ip, or returns an error should any limit
+// be reached. A limit of zero or less is unlimited.
+func (l *connLimiter) acquire(ip string) error {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.max > 0 && l.total >= l.max {
+		return ErrMaxWebsocketConnections
+	}
+
+	if l.maxPerIP > 0 && l.perIP[ip] >= l.maxPerIP {
+		return ErrMaxWebsocketConnectionsPerIP
+	}
+
+	l.total++
+	l.conns.Add(1)
+	l.perIP[ip]++
+
+	return nil
+}
+
+func (l *connLimiter) release(ip string) {
+	l.Lock()
+	defer l.Unlock()
+
+	l.total--
+	l.conns.Done()
+
+	if l.perIP[ip]--; l.perIP[ip] <= 0 {
+		delete(l.perIP, ip)
+	}
+}
+
+// wait blocks until all connections which were reserved have been released.
+func (l *connLimiter) wait() {
+	l.conns.Wait()
+}
+
 type client struct {
 	sink *sink
 	conn *websocket.Conn
 
 	filters map[string]string
-	queue   chan []byte
+	expr    filterExpr
+	queue   chan sinkEvent
+
+	// Should resume be set, the client is first sent the events retained by
+	// the sink which came after the event with ID lastEventID.
+	resume      bool
+	lastEventID uint64
+}
+
+// sinkEvent is a message queued up to be written to a client. Its ID is that of
+// the latest event broadcasted to the sink it carries.
+type sinkEvent struct {
+	id  uint64
+	buf []byte
+}
+
+// matches returns whether or not an event is to be sent to the client, which
+// is when it matches all filters and the filter expression of the client.
+func (c *client) matches(o *fastjson.Value) bool {
+	for key, condition := range c.filters {
+		val := o.Get(key)
+
+		if val == nil {
+			return false
+		}
+
+		if !fastjsonEquals(val, condition) {
+			return false
+		}
+	}
+
+	return c.expr == nil || c.expr.eval(o)
 }
 
 func (c *client) readWorker() {
 	c.conn.SetReadLimit(maxMessageSize)
-	_ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	_ = c.conn.SetReadDeadline(time.Now().Add(c.sink.idleTimeout))
 
 	c.conn.SetPongHandler(func(string) error {
-		_ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
+		_ = c.conn.SetReadDeadline(time.Now().Add(c.sink.idleTimeout))
 		return nil
 	})
 
@@ -73,7 +190,9 @@ func (c *client) readWorker() {
 }
 
 func (c *client) writeWorker() {
-	ticker := time.NewTicker(pingPeriod)
+	// Ping clients often enough that they have a chance to respond before
+	// they are considered to be idle.
+	ticker := time.NewTicker(c.sink.idleTimeout * 9 / 10)
 	defer func() {
 		ticker.Stop()
 		_ = c.conn.Close()
@@ -87,13 +206,13 @@ func (c *client) writeWorker() {
 				return
 			}
 
-			if len(msg) == 0 {
+			if len(msg.buf) == 0 {
 				continue
 			}
 
 			_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 
-			err := c.conn.WriteMessage(websocket.TextMessage, msg)
+			err := c.conn.WriteMessage(websocket.TextMessage, msg.buf)
 			if err != nil {
 				return
 			}
@@ -103,11 +222,17 @@ func (c *client) writeWorker() {
 			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				return
 			}
+		case <-c.sink.shutdown:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			_ = c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "server is shutting down"))
+			return
 		}
 	}
 }
 
-func (s *sink) serve(ctx *fasthttp.RequestCtx) error {
+// parseFilters returns the filters and the filter expression specified in the
+// query of a request to subscribe to the sink.
+func (s *sink) parseFilters(ctx *fasthttp.RequestCtx) (map[string]string, filterExpr, error) {
 	values := ctx.QueryArgs()
 
 	filters := make(map[string]string)
@@ -117,12 +242,35 @@ func (s *sink) serve(ctx *fasthttp.RequestCtx) error {
 		}
 	}
 
-	return upgrader.Upgrade(ctx, func(conn *websocket.Conn) {
+	expr, err := parseFilter(string(values.Peek("filter")))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return filters, expr, nil
+}
+
+func (s *sink) serve(ctx *fasthttp.RequestCtx) error {
+	filters, expr, err := s.parseFilters(ctx)
+	if err != nil {
+		return err
+	}
+
+	ip := ctx.RemoteIP().String()
+
+	if err := s.limiter.acquire(ip); err != nil {
+		return err
+	}
+
+	err = upgrader.Upgrade(ctx, func(conn *websocket.Conn) {
+		defer s.limiter.release(ip)
+
 		client := &client{
 			filters: filters,
+			expr:    expr,
 			sink:    s,
 			conn:    conn,
-			queue:   make(chan []byte, 256),
+			queue:   make(chan sinkEvent, clientQueueSize),
 		}
 
 		s.join <- client
@@ -130,6 +278,13 @@ func (s *sink) serve(ctx *fasthttp.RequestCtx) error {
 		go client.readWorker()
 		client.writeWorker()
 	})
+
+	// The connection is only served, and released, should the upgrade succeed.
+	if err != nil {
+		s.limiter.release(ip)
+	}
+
+	return err
 }
 
 type broadcastItem struct {
@@ -145,19 +300,32 @@ type sink struct {
 	join, leave chan *client
 
 	debouncer debounce.Debouncer
+
+	limiter     *connLimiter
+	idleTimeout time.Duration
+	shutdown    <-chan struct{}
+
+	// The latest events broadcasted to the sink, ordered by their IDs, which
+	// count up from one. The lock is held while debounced events are sent
+	// out, such that a client joining may not miss, nor be sent twice, any
+	// events broadcasted while the events it is to resume from are replayed.
+	history     []sinkEvent
+	historyLock sync.Mutex
+	seq         uint64
 }
 
 func (s *sink) run() {
 	for {
 		select {
 		case client := <-s.join:
+			s.historyLock.Lock()
+			if client.resume {
+				s.replay(client)
+			}
 			s.clients[client] = struct{}{}
+			s.historyLock.Unlock()
 		case client := <-s.leave:
-			if _, ok := s.clients[client]; ok {
-				delete(s.clients, client)
-				close(client.queue)
-				client.queue = nil
-			}
+			s.drop(client)
 		case msg := <-s.broadcast:
 			if s.debouncer != nil {
 				s.debouncer.Add(debounce.Bytes(msg.buf))
@@ -168,34 +336,115 @@ func (s *sink) run() {
 	}
 }
 
+// drop stops sending messages to a client, and has its connection closed.
+func (s *sink) drop(client *client) {
+	if _, ok := s.clients[client]; ok {
+		delete(s.clients, client)
+		close(client.queue)
+		client.queue = nil
+	}
+}
+
+// enqueue queues up buf to be written to a client. Clients which do not keep up
+// with the messages queued up for them are dropped, such that they may not have
+// the node buffer up an unbounded number of messages. Clients are dropped by
+// the goroutine running the sink, as messages may be debounced on another.
+func (s *sink) enqueue(c *client, event sinkEvent) {
+	select {
+	case c.queue <- event:
+	default:
+		go func() {
+			s.leave <- c
+		}()
+	}
+}
+
+// record retains buf as the latest event broadcasted to the sink, and returns
+// the ID assigned to it. The history lock must be held.
+func (s *sink) record(buf []byte) uint64 {
+	s.seq++
+
+	if len(s.history) == sinkHistorySize {
+		s.history = append(s.history[:0], s.history[1:]...)
+	}
+
+	s.history = append(s.history, sinkEvent{id: s.seq, buf: buf})
+
+	return s.seq
+}
+
+// replay queues up the events retained by the sink which came after the last
+// event received by a client resuming its subscription. Events of sinks which
+// debounce them are replayed in a single batch. The history lock must be held.
+func (s *sink) replay(c *client) {
+	var (
+		events []sinkEvent
+		last   uint64
+	)
+
+	for _, event := range s.history {
+		if event.id <= c.lastEventID {
+			continue
+		}
+
+		last = event.id
+
+		o, err := fastjson.ParseBytes(event.buf)
+		if err != nil || !c.matches(o) {
+			continue
+		}
+
+		events = append(events, event)
+	}
+
+	if len(events) == 0 {
+		return
+	}
+
+	if s.debouncer == nil {
+		for _, event := range events {
+			s.enqueue(c, event)
+		}
+
+		return
+	}
+
+	obj := fastjson.MustParse("[]")
+
+	for i, event := range events {
+		obj.SetArrayItem(i, fastjson.MustParseBytes(event.buf))
+	}
+
+	s.enqueue(c, sinkEvent{id: last, buf: obj.MarshalTo(nil)})
+}
+
 func (s *sink) send(buf []byte) {
+	s.historyLock.Lock()
+	id := s.record(buf)
+	s.historyLock.Unlock()
+
 	o, err := fastjson.ParseBytes(buf)
 	if err != nil {
 		return
 	}
 
-SENDING:
 	for c := range s.clients {
-		for key, condition := range c.filters {
-			val := o.Get(key)
-
-			if val == nil {
-				continue SENDING
-			}
-
-			if !fastjsonEquals(val, condition) {
-				continue SENDING
-			}
-		}
-
-		select {
-		case c.queue <- buf:
-		default:
+		if c.matches(o) {
+			s.enqueue(c, sinkEvent{id: id, buf: buf})
 		}
 	}
 }
 
 func (s *sink) debounce(batch [][]byte) {
+	s.historyLock.Lock()
+	defer s.historyLock.Unlock()
+
+	var id uint64
+
+	for _, buf := range batch {
+		id = s.record(buf)
+	}
+
 SENDING:
 	for c := range s.clients {
 		idx, obj := 0, fastjson.MustParse("[]")
@@ -207,16 +456,8 @@ SENDING:
 				continue BATCHING
 			}
 
-			for key, condition := range c.filters {
-				val := o.Get(key)
-
-				if val == nil {
-					continue BATCHING
-				}
-
-				if !fastjsonEquals(val, condition) {
-					continue BATCHING
-				}
+			if !c.matches(o) {
+				continue BATCHING
 			}
 
 			obj.SetArrayItem(idx, o)
@@ -229,10 +470,7 @@ SENDING:
 
 		buf := obj.MarshalTo(nil)
 
-		select {
-		case c.queue <- buf:
-		default:
-		}
+		s.enqueue(c, sinkEvent{id: id, buf: buf})
 	}
 }
 
@@ -23,6 +23,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/valyala/fastjson"
 	"testing"
+	"time"
 )
 
 func TestSinkEqual(t *testing.T) {
@@ -44,3 +45,54 @@ func TestSinkEqual(t *testing.T) {
 	assert.True(t, fastjsonEquals(v.Get("obj"), `{"key":"value"}`))
 	assert.True(t, fastjsonEquals(v.Get("arr"), `[1,"str"]`))
 }
+
+func TestConnLimiter(t *testing.T) {
+	l := newConnLimiter(3, 2)
+
+	assert.NoError(t, l.acquire("1.1.1.1"))
+	assert.NoError(t, l.acquire("1.1.1.1"))
+	assert.Equal(t, ErrMaxWebsocketConnectionsPerIP, l.acquire("1.1.1.1"))
+
+	assert.NoError(t, l.acquire("2.2.2.2"))
+	assert.Equal(t, ErrMaxWebsocketConnections, l.acquire("3.3.3.3"))
+
+	l.release("1.1.1.1")
+	assert.NoError(t, l.acquire("3.3.3.3"))
+
+	l.release("2.2.2.2")
+	l.release("3.3.3.3")
+	assert.NotContains(t, l.perIP, "2.2.2.2")
+	assert.NotContains(t, l.perIP, "3.3.3.3")
+}
+
+func TestSinkDropsSlowConsumers(t *testing.T) {
+	s := &sink{
+		broadcast: make(chan broadcastItem),
+		join:      make(chan *client),
+		leave:     make(chan *client),
+		clients:   make(map[*client]struct{}),
+	}
+
+	go s.run()
+
+	c := &client{sink: s, queue: make(chan sinkEvent, 1)}
+	s.join <- c
+
+	queue := c.queue
+
+	s.broadcast <- broadcastItem{buf: []byte(`{"a":1}`)}
+	s.broadcast <- broadcastItem{buf: []byte(`{"a":2}`)}
+
+	// The client does not read the first message before the second is sent,
+	// and so has its queue closed after the first message is read.
+	timeout := time.After(1 * time.Second)
+
+	assert.Equal(t, sinkEvent{id: 1, buf: []byte(`{"a":1}`)}, <-queue)
+
+	select {
+	case _, ok := <-queue:
+		assert.False(t, ok)
+	case <-timeout:
+		t.Fatal("slow consumer was not dropped")
+	}
+}
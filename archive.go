@@ -0,0 +1,125 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrNotArchive       = errors.New("node is not running as an archive node")
+	ErrRoundNotArchived = errors.New("round has not been archived")
+)
+
+// AccountChange is how the balance and stake of an account changed as a result
+// of a round being finalized.
+type AccountChange struct {
+	Account AccountID
+	AccountDelta
+}
+
+// WithArchive has the ledger run as an archive node. On top of the changes
+// made to accounts, which are indexed by account, archive nodes store the diff
+// of the ledger state made by every round they finalize or replicate, and
+// index the changes made to accounts by round. Archive nodes never prune away
+// their history, regardless of sys.PruneHistoryAfter.
+func WithArchive() LedgerOption {
+	return func(ledger *Ledger) {
+		ledger.archive = true
+	}
+}
+
+// IsArchive returns whether or not the ledger is running as an archive node.
+func (l *Ledger) IsArchive() bool {
+	return l.archive
+}
+
+// RoundDiff returns the diff of the ledger state made by the round with index
+// round. Rounds skipped over by syncing are folded into the round synced to,
+// and are thus not archived on their own. It returns ErrNotArchive should the
+// ledger not be running as an archive node, ErrRoundNotFinalized should the
+// round not have been finalized yet, and ErrRoundNotArchived should the node
+// not have archived the round.
+func (l *Ledger) RoundDiff(round uint64) ([]byte, error) {
+	if err := l.checkArchived(round); err != nil {
+		return nil, err
+	}
+
+	diff, err := LoadRoundDiff(l.accounts.kv, round)
+	if err != nil {
+		return nil, errors.Wrapf(ErrRoundNotArchived, "round %d", round)
+	}
+
+	return diff, nil
+}
+
+// RoundChanges returns the changes made to the balances and stakes of accounts
+// by the round with index round, ordered by account. It returns the same
+// errors as RoundDiff.
+func (l *Ledger) RoundChanges(round uint64) ([]AccountChange, error) {
+	if err := l.checkArchived(round); err != nil {
+		return nil, err
+	}
+
+	// Rounds which changed no accounts are told apart from rounds which
+	// were never archived by their diff.
+	if _, err := LoadRoundDiff(l.accounts.kv, round); err != nil {
+		return nil, errors.Wrapf(ErrRoundNotArchived, "round %d", round)
+	}
+
+	return LoadRoundChanges(l.accounts.kv, round)
+}
+
+func (l *Ledger) checkArchived(round uint64) error {
+	if !l.archive {
+		return ErrNotArchive
+	}
+
+	if latest := l.rounds.Latest().Index; round > latest {
+		return errors.Wrapf(ErrRoundNotFinalized, "round %d is after the latest round %d", round, latest)
+	}
+
+	return nil
+}
+
+// storeRoundHistory stores the changes made to accounts by the round with
+// index round, whose state is snapshot, against the state prev of the round
+// with index lastRound the ledger advanced from. Archive nodes additionally
+// store the diff of the ledger state, and index the changes by round.
+func (l *Ledger) storeRoundHistory(prev, snapshot *avl.Tree, lastRound, round uint64) error {
+	deltas := accountDeltas(prev, snapshot, lastRound, round)
+
+	if err := StoreAccountDeltas(l.accounts.kv, deltas); err != nil {
+		return err
+	}
+
+	if !l.archive {
+		return nil
+	}
+
+	if err := StoreRoundChanges(l.accounts.kv, round, deltas); err != nil {
+		return err
+	}
+
+	// The diff is stored last, such that a round is only ever reported as
+	// archived once its changes have been stored.
+	return StoreRoundDiff(l.accounts.kv, round, snapshot.DumpDiff(lastRound))
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestArchive(t *testing.T) {
+	archive := newTestLedger(t, WithArchive())
+	ledger := newTestLedger(t)
+
+	assert.True(t, archive.IsArchive())
+	assert.False(t, ledger.IsArchive())
+
+	var alice, bob AccountID
+	alice[0], bob[0] = 1, 2
+
+	var diffs [][]byte
+
+	for index := uint64(1); index <= 3; index++ {
+		latest := archive.Rounds().Latest()
+
+		snapshot := archive.Snapshot()
+		snapshot.SetViewID(index)
+		WriteAccountBalance(snapshot, alice, index*10)
+
+		if index == 2 {
+			WriteAccountStake(snapshot, bob, 5)
+		}
+
+		diff := snapshot.DumpDiff(latest.Index)
+		diffs = append(diffs, diff)
+
+		round := NewRound(index, snapshot.Checksum(), 0, latest.End, latest.End)
+		assert.NoError(t, archive.applyReplicatedRound(round, diff, nil))
+		assert.NoError(t, ledger.applyReplicatedRound(round, diff, nil))
+	}
+
+	changes, err := archive.RoundChanges(2)
+	assert.NoError(t, err)
+	assert.Equal(t, []AccountChange{
+		{Account: alice, AccountDelta: AccountDelta{Round: 2, PrevBalance: 10, Balance: 20}},
+		{Account: bob, AccountDelta: AccountDelta{Round: 2, Stake: 5}},
+	}, changes)
+
+	// Applying the diffs of every round in order to the genesis state rebuilds
+	// the latest state.
+	tree := newTestLedger(t).Snapshot()
+
+	for index := uint64(1); index <= 3; index++ {
+		diff, err := archive.RoundDiff(index)
+		assert.NoError(t, err)
+		assert.Equal(t, diffs[index-1], diff)
+
+		assert.NoError(t, tree.ApplyDiff(diff))
+		assert.NoError(t, tree.Commit())
+	}
+
+	assert.Equal(t, archive.Rounds().Latest().Merkle, tree.Checksum())
+
+	// The genesis round was never finalized, and is thus not archived.
+	_, err = archive.RoundDiff(0)
+	assert.Equal(t, ErrRoundNotArchived, errors.Cause(err))
+
+	_, err = archive.RoundChanges(4)
+	assert.Equal(t, ErrRoundNotFinalized, errors.Cause(err))
+
+	_, err = ledger.RoundChanges(2)
+	assert.Equal(t, ErrNotArchive, errors.Cause(err))
+
+	_, err = ledger.RoundDiff(2)
+	assert.Equal(t, ErrNotArchive, errors.Cause(err))
+}
@@ -576,6 +576,10 @@ func deserialize(r *bytes.Reader) (*node, error) {
 		return nil, err
 	}
 
+	if size := binary.LittleEndian.Uint32(buf64[:4]); uint64(size) > uint64(r.Len()) {
+		return nil, errors.Errorf("avl: key of size %d exceeds the %d bytes remaining", size, r.Len())
+	}
+
 	n.key = make([]byte, binary.LittleEndian.Uint32(buf64[:4]))
 	_, err = r.Read(n.key)
 	if err != nil {
@@ -588,6 +592,10 @@ func deserialize(r *bytes.Reader) (*node, error) {
 			return nil, err
 		}
 
+		if size := binary.LittleEndian.Uint32(buf64[:4]); uint64(size) > uint64(r.Len()) {
+			return nil, errors.Errorf("avl: value of size %d exceeds the %d bytes remaining", size, r.Len())
+		}
+
 		n.value = make([]byte, binary.LittleEndian.Uint32(buf64[:4]))
 		_, err = r.Read(n.value)
 		if err != nil {
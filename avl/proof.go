@@ -0,0 +1,139 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package avl
+
+import (
+	"bytes"
+	"github.com/pkg/errors"
+)
+
+// Prove returns a proof of the value stored under key in the tree, or of key
+// not being stored in the tree. The proof may be checked against the checksum
+// of the tree using VerifyProof.
+//
+// The proof comprises of the serialized nodes on the path from the root of the
+// tree to the leaf a lookup of key ends at. Should the path descend into the
+// right child of a node, the left child of the node is included in the proof
+// right before it, such that the direction of the lookup may be verified.
+func (t *Tree) Prove(key []byte) ([][]byte, error) {
+	if t.root == nil {
+		return nil, nil
+	}
+
+	n := t.root
+	proof := [][]byte{serializeNode(n)}
+
+	for n.kind == NodeNonLeaf {
+		left, err := t.loadLeft(n)
+		if err != nil {
+			return nil, err
+		}
+
+		proof = append(proof, serializeNode(left))
+
+		if bytes.Compare(key, left.key) <= 0 {
+			n = left
+			continue
+		}
+
+		right, err := t.loadRight(n)
+		if err != nil {
+			return nil, err
+		}
+
+		proof = append(proof, serializeNode(right))
+		n = right
+	}
+
+	return proof, nil
+}
+
+// VerifyProof checks a proof produced by Prove against the checksum of a tree,
+// and returns the value stored under key in the tree, and whether or not the
+// key is stored in the tree.
+func VerifyProof(checksum [MerkleHashSize]byte, key []byte, proof [][]byte) ([]byte, bool, error) {
+	if checksum == [MerkleHashSize]byte{} {
+		if len(proof) != 0 {
+			return nil, false, errors.New("avl: proof against an empty tree must be empty")
+		}
+
+		return nil, false, nil
+	}
+
+	next := func(expected [MerkleHashSize]byte) (*node, error) {
+		if len(proof) == 0 {
+			return nil, errors.New("avl: proof is incomplete")
+		}
+
+		n, err := deserialize(bytes.NewReader(proof[0]))
+		if err != nil {
+			return nil, errors.Wrap(err, "avl: failed to decode node in proof")
+		}
+
+		if n.id != expected {
+			return nil, errors.Errorf("avl: expected node %x in proof, but got node %x", expected, n.id)
+		}
+
+		proof = proof[1:]
+
+		return n, nil
+	}
+
+	n, err := next(checksum)
+	if err != nil {
+		return nil, false, err
+	}
+
+	for n.kind == NodeNonLeaf {
+		left, err := next(n.left)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if bytes.Compare(key, left.key) <= 0 {
+			n = left
+			continue
+		}
+
+		if n, err = next(n.right); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if n.kind != NodeLeafValue {
+		return nil, false, errors.Errorf("avl: proof contains an unsupported node kind %d", n.kind)
+	}
+
+	if len(proof) != 0 {
+		return nil, false, errors.Errorf("avl: proof has %d unexpected trailing nodes", len(proof))
+	}
+
+	if !bytes.Equal(n.key, key) {
+		return nil, false, nil
+	}
+
+	return n.value, true, nil
+}
+
+func serializeNode(n *node) []byte {
+	var buf bytes.Buffer
+	n.serialize(&buf)
+	return buf.Bytes()
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package avl
+
+// Changes records the keys read from a fork of a tree made through Track,
+// alongside the changes made to the fork in the order they were made.
+//
+// The shape of a tree, and thus its checksum, depends on the order in which
+// keys are inserted and deleted. Replaying the changes onto another tree in
+// the order they were made therefore yields the exact same tree as making the
+// changes on it directly would have, provided that none of the keys read from
+// the fork differ in the other tree.
+type Changes struct {
+	reads  map[string]struct{}
+	writes []change
+
+	// scanned is whether or not the fork was iterated over, such that the
+	// changes may depend on any key.
+	scanned bool
+}
+
+type change struct {
+	key    []byte
+	value  []byte
+	delete bool
+	update func(value []byte, exists bool) ([]byte, bool)
+}
+
+// Track returns a fork of the tree which records all keys read from it, and
+// all changes made to it, into the returned changes.
+func (t *Tree) Track() (*Tree, *Changes) {
+	changes := &Changes{reads: make(map[string]struct{})}
+
+	fork := t.Snapshot()
+	fork.viewID = t.viewID
+	fork.changes = changes
+	fork.numChanges = 0
+
+	return fork, changes
+}
+
+// Peek looks up k without recording it as read should the tree be tracked.
+// The caller must make sure that what it does with the value found would not
+// differ should the value of k differ, such as by writing to k through Update.
+func (t *Tree) Peek(k []byte) ([]byte, bool) {
+	return t.lookup(k)
+}
+
+// Update sets the value of key to what fn returns given its current value,
+// provided that fn returns true. Should the tree be tracked, key is not
+// recorded as read. Instead, fn is evaluated again against the value of key
+// at the time the changes are replayed, such that changes which only update
+// the same keys may be replayed on top of each other. Update returns whether
+// or not fn returned true.
+func (t *Tree) Update(key []byte, fn func(value []byte, exists bool) ([]byte, bool)) bool {
+	value, ok := fn(t.lookup(key))
+	if !ok {
+		return false
+	}
+
+	if t.changes != nil {
+		t.changes.writes = append(t.changes.writes, change{key: key, update: fn})
+	}
+
+	t.insert(key, value)
+
+	return true
+}
+
+// ReadsAny returns whether or not any of the keys in written were read, or
+// whether the fork was iterated over while any keys were written.
+func (c *Changes) ReadsAny(written map[string]struct{}) bool {
+	if len(written) == 0 {
+		return false
+	}
+
+	if c.scanned {
+		return true
+	}
+
+	for key := range c.reads {
+		if _, exists := written[key]; exists {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AddWritten adds the keys the changes write to into written.
+func (c *Changes) AddWritten(written map[string]struct{}) {
+	for _, w := range c.writes {
+		written[string(w.key)] = struct{}{}
+	}
+}
+
+// Replay makes the changes onto t in the order they were originally made. It
+// returns false, leaving t untouched, should any update not apply to the value
+// of the key it updates in t.
+func (c *Changes) Replay(t *Tree) bool {
+	original := t.Snapshot()
+
+	for _, w := range c.writes {
+		switch {
+		case w.delete:
+			t.Delete(w.key)
+		case w.update != nil:
+			if !t.Update(w.key, w.update) {
+				t.Revert(original)
+				return false
+			}
+		default:
+			t.Insert(w.key, w.value)
+		}
+	}
+
+	return true
+}
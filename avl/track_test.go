@@ -0,0 +1,131 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package avl
+
+import (
+	"encoding/binary"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTree_Track(t *testing.T) {
+	tree := New(store.NewInmem())
+	tree.Insert([]byte("a"), []byte("1"))
+	tree.Insert([]byte("b"), []byte("1"))
+
+	fork, changes := tree.Track()
+
+	fork.Lookup([]byte("a"))
+	fork.Peek([]byte("b"))
+	fork.Insert([]byte("c"), []byte("1"))
+
+	// Changes reverted on the fork are discarded, though keys read are kept.
+	ss := fork.Snapshot()
+	fork.Lookup([]byte("d"))
+	fork.Insert([]byte("d"), []byte("1"))
+	fork.Revert(ss)
+
+	assert.True(t, changes.ReadsAny(map[string]struct{}{"a": {}}))
+	assert.True(t, changes.ReadsAny(map[string]struct{}{"d": {}}))
+	assert.False(t, changes.ReadsAny(map[string]struct{}{"b": {}, "c": {}}))
+
+	written := make(map[string]struct{})
+	changes.AddWritten(written)
+	assert.Equal(t, map[string]struct{}{"c": {}}, written)
+
+	// Changes made to a fork are not made to the tree it was forked from.
+	_, exists := tree.Lookup([]byte("c"))
+	assert.False(t, exists)
+
+	// Iterating over a fork reads every key.
+	fork.Iterate(func(key, value []byte) {})
+	assert.True(t, changes.ReadsAny(map[string]struct{}{"e": {}}))
+	assert.False(t, changes.ReadsAny(nil))
+}
+
+func TestTree_Replay(t *testing.T) {
+	add := func(amount uint64) func(value []byte, exists bool) ([]byte, bool) {
+		return func(value []byte, exists bool) ([]byte, bool) {
+			var sum uint64
+			if exists {
+				sum = binary.LittleEndian.Uint64(value)
+			}
+
+			if sum+amount < sum {
+				return nil, false
+			}
+
+			var buf [8]byte
+			binary.LittleEndian.PutUint64(buf[:], sum+amount)
+
+			return buf[:], true
+		}
+	}
+
+	tree := New(store.NewInmem())
+	tree.Insert([]byte("a"), []byte("1"))
+	tree.Insert([]byte("b"), []byte("1"))
+
+	// Make the same changes to a tree directly, and to forks of it which are
+	// then replayed onto it in the order the changes were made.
+	direct := tree.Snapshot()
+
+	first, firstChanges := tree.Track()
+	second, secondChanges := tree.Track()
+
+	for _, fork := range []*Tree{direct, first} {
+		fork.Insert([]byte("c"), []byte("1"))
+		fork.Delete([]byte("a"))
+		assert.True(t, fork.Update([]byte("sum"), add(2)))
+	}
+
+	for _, fork := range []*Tree{direct, second} {
+		fork.Insert([]byte("d"), []byte("1"))
+		assert.True(t, fork.Update([]byte("sum"), add(3)))
+	}
+
+	written := make(map[string]struct{})
+
+	assert.False(t, firstChanges.ReadsAny(written))
+	assert.True(t, firstChanges.Replay(tree))
+	firstChanges.AddWritten(written)
+
+	// Updates are evaluated again against the value of the key they update
+	// once replayed, and so do not conflict with one another.
+	assert.False(t, secondChanges.ReadsAny(written))
+	assert.True(t, secondChanges.Replay(tree))
+
+	assert.Equal(t, direct.Checksum(), tree.Checksum())
+
+	sum, _ := tree.Lookup([]byte("sum"))
+	assert.EqualValues(t, 5, binary.LittleEndian.Uint64(sum))
+
+	// Changes are not replayed at all should any update not apply.
+	third, thirdChanges := tree.Track()
+	third.Insert([]byte("e"), []byte("1"))
+	assert.True(t, third.Update([]byte("sum"), add(1)))
+
+	tree.Insert([]byte("sum"), []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	checksum := tree.Checksum()
+
+	assert.False(t, thirdChanges.Replay(tree))
+	assert.Equal(t, checksum, tree.Checksum())
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/perlin-network/wavelet/store"
 	"github.com/phf/go-queue/queue"
 	"github.com/pkg/errors"
+	"io"
 )
 
 var NodeKeyPrefix = []byte("@1:")
@@ -49,6 +50,12 @@ type Tree struct {
 	cache *lru
 
 	viewID uint64
+
+	// changes records the keys read from, and the changes made to the tree,
+	// should the tree be a fork made through Track. numChanges is the number
+	// of changes made at the time the tree was snapshotted.
+	changes    *Changes
+	numChanges int
 }
 
 func New(kv store.KV) *Tree {
@@ -81,6 +88,14 @@ func (t *Tree) WithMaxWriteBatchSize(maxWriteBatchSize int) *Tree {
 }
 
 func (t *Tree) Insert(key, value []byte) {
+	if t.changes != nil {
+		t.changes.writes = append(t.changes.writes, change{key: key, value: value})
+	}
+
+	t.insert(key, value)
+}
+
+func (t *Tree) insert(key, value []byte) {
 	if t.root == nil {
 		t.root = newLeafNode(t, key, value)
 	} else {
@@ -89,6 +104,14 @@ func (t *Tree) Insert(key, value []byte) {
 }
 
 func (t *Tree) Lookup(k []byte) ([]byte, bool) {
+	if t.changes != nil {
+		t.changes.reads[string(k)] = struct{}{}
+	}
+
+	return t.lookup(k)
+}
+
+func (t *Tree) lookup(k []byte) ([]byte, bool) {
 	if t.root == nil {
 		return nil, false
 	}
@@ -97,6 +120,17 @@ func (t *Tree) Lookup(k []byte) ([]byte, bool) {
 }
 
 func (t *Tree) Delete(k []byte) bool {
+	// Whether or not a key is deleted depends on whether it exists, and so
+	// deleting a key reads it.
+	if t.changes != nil {
+		t.changes.reads[string(k)] = struct{}{}
+		t.changes.writes = append(t.changes.writes, change{key: k, delete: true})
+	}
+
+	return t.delete(k)
+}
+
+func (t *Tree) delete(k []byte) bool {
 	if t.root == nil {
 		return false
 	}
@@ -108,18 +142,38 @@ func (t *Tree) Delete(k []byte) bool {
 }
 
 func (t *Tree) Snapshot() *Tree {
-	return &Tree{kv: t.kv, cache: t.cache, maxWriteBatchSize: t.maxWriteBatchSize, root: t.root}
+	snapshot := &Tree{kv: t.kv, cache: t.cache, maxWriteBatchSize: t.maxWriteBatchSize, root: t.root, changes: t.changes}
+
+	if t.changes != nil {
+		snapshot.numChanges = len(t.changes.writes)
+	}
+
+	return snapshot
 }
 
 func (t *Tree) Revert(snapshot *Tree) {
 	t.root = snapshot.root
+
+	// Changes made since the snapshot are discarded, though the keys read
+	// since are kept, as they may have decided to revert the tree.
+	if t.changes != nil && t.changes == snapshot.changes {
+		t.changes.writes = t.changes.writes[:snapshot.numChanges]
+	}
 }
 
 func (t *Tree) Iterate(callback func(key, value []byte)) {
+	if t.changes != nil {
+		t.changes.scanned = true
+	}
+
 	t.doIterate(callback, t.root)
 }
 
 func (t *Tree) IterateFrom(key []byte, callback func(key, value []byte) bool) {
+	if t.changes != nil {
+		t.changes.scanned = true
+	}
+
 	if t.root == nil {
 		return
 	}
@@ -127,6 +181,10 @@ func (t *Tree) IterateFrom(key []byte, callback func(key, value []byte) bool) {
 }
 
 func (t *Tree) IteratePrefix(prefix []byte, callback func(key, value []byte)) {
+	if t.changes != nil {
+		t.changes.scanned = true
+	}
+
 	if t.root == nil {
 		return
 	}
@@ -393,6 +451,111 @@ func (t *Tree) IterateLeafDiff(prevViewID uint64, callback func(key, value []byt
 	})
 }
 
+// Export writes every node of the tree to w, starting from its root. Unlike
+// DumpDiff, the export is self-contained and may be imported into a tree
+// backed by an empty store.
+func (t *Tree) Export(w io.Writer) error {
+	if t.root == nil {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	var lenBuf [4]byte
+
+	return t.root.dfs(t, false, func(n *node) (bool, error) {
+		buf.Reset()
+		n.serialize(&buf)
+
+		binary.LittleEndian.PutUint32(lenBuf[:], uint32(buf.Len()))
+
+		if _, err := w.Write(lenBuf[:]); err != nil {
+			return false, err
+		}
+
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return false, err
+		}
+
+		return true, nil
+	})
+}
+
+// Import reads nodes written by Export from r, writes them to the store, and
+// sets the root of the tree to the first node read. The new root is not
+// persisted until Commit is called.
+func (t *Tree) Import(r io.Reader) error {
+	var root *node
+
+	seen := make(map[[MerkleHashSize]byte]struct{})
+	referenced := make(map[[MerkleHashSize]byte]struct{})
+
+	batch := t.kv.NewWriteBatch()
+
+	var lenBuf [4]byte
+
+	for {
+		if _, err := io.ReadFull(r, lenBuf[:]); err != nil {
+			if err == io.EOF {
+				break
+			}
+
+			return errors.Wrap(err, "avl: failed to read node length")
+		}
+
+		buf := make([]byte, binary.LittleEndian.Uint32(lenBuf[:]))
+
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return errors.Wrap(err, "avl: failed to read node")
+		}
+
+		n, err := deserialize(bytes.NewReader(buf))
+		if err != nil {
+			return errors.Wrap(err, "avl: failed to deserialize node")
+		}
+
+		n.wroteBack = true
+
+		if root == nil {
+			root = n
+		}
+
+		seen[n.id] = struct{}{}
+
+		if n.kind == NodeNonLeaf {
+			referenced[n.left] = struct{}{}
+			referenced[n.right] = struct{}{}
+		}
+
+		batch.Put(append(NodeKeyPrefix, n.id[:]...), buf)
+
+		if batch.Count() >= t.maxWriteBatchSize {
+			if err := t.kv.CommitWriteBatch(batch); err != nil {
+				return errors.Wrap(err, "failed to commit write batch to db")
+			}
+
+			batch = t.kv.NewWriteBatch()
+		}
+	}
+
+	if err := t.kv.CommitWriteBatch(batch); err != nil {
+		return errors.Wrap(err, "failed to commit write batch to db")
+	}
+
+	for id := range referenced {
+		if _, exists := seen[id]; !exists {
+			return errors.Errorf("avl: import is missing node %x", id)
+		}
+	}
+
+	if root != nil {
+		t.viewID = root.viewID
+	}
+
+	t.root = root
+
+	return nil
+}
+
 func (t *Tree) ApplyDiffWithUpdateNotifier(diff []byte, updateNotifier func(key, value []byte)) error {
 	reader := bytes.NewReader(diff)
 
@@ -514,3 +677,98 @@ func (profile *GCProfile) PerformFullGC() (int, error) {
 
 	return deleteCount, nil
 }
+
+// Verify walks every node of the tree, loading them from the underlying store
+// should they not be cached. It checks that the hash of every node matches its
+// contents and the hash its parent refers to it by, that the depth, size and
+// key of every non-leaf node match those of its children, and that leaves are
+// ordered by their keys. progress is called
+// with the number of leaves visited after each leaf is visited.
+func (t *Tree) Verify(progress func(leaves uint64)) error {
+	if t.root == nil {
+		return nil
+	}
+
+	var (
+		leaves uint64
+		last   []byte
+	)
+
+	var verify func(n *node) error
+
+	verify = func(n *node) error {
+		if id := n.rehashNoWrite(); id != n.id {
+			return errors.Errorf("avl: node %x has a mismatching hash %x", n.id, id)
+		}
+
+		if n.kind == NodeLeafValue {
+			if leaves > 0 && bytes.Compare(last, n.key) >= 0 {
+				return errors.Errorf("avl: leaf %x is out of order with leaf %x", n.key, last)
+			}
+
+			if n.depth != 0 || n.size != 1 {
+				return errors.Errorf("avl: leaf %x has a depth of %d and size of %d", n.key, n.depth, n.size)
+			}
+
+			last = n.key
+			leaves++
+
+			if progress != nil {
+				progress(leaves)
+			}
+
+			return nil
+		}
+
+		if n.kind != NodeNonLeaf {
+			return errors.Errorf("avl: node %x is of an unsupported kind %d", n.id, n.kind)
+		}
+
+		left, err := t.loadLeft(n)
+		if err != nil {
+			return err
+		}
+
+		if left.id != n.left {
+			return errors.Errorf("avl: node %x refers to left child %x, but it hashes to %x", n.id, n.left, left.id)
+		}
+
+		if err := verify(left); err != nil {
+			return err
+		}
+
+		right, err := t.loadRight(n)
+		if err != nil {
+			return err
+		}
+
+		if right.id != n.right {
+			return errors.Errorf("avl: node %x refers to right child %x, but it hashes to %x", n.id, n.right, right.id)
+		}
+
+		if err := verify(right); err != nil {
+			return err
+		}
+
+		depth := left.depth
+		if right.depth > depth {
+			depth = right.depth
+		}
+
+		if n.depth != depth+1 {
+			return errors.Errorf("avl: node %x has a depth of %d, but its children have a max depth of %d", n.id, n.depth, depth)
+		}
+
+		if n.size != left.size+right.size {
+			return errors.Errorf("avl: node %x has a size of %d, but its children have a total size of %d", n.id, n.size, left.size+right.size)
+		}
+
+		if !bytes.Equal(n.key, right.key) {
+			return errors.Errorf("avl: node %x has key %x, but the max key of its children is %x", n.id, n.key, right.key)
+		}
+
+		return nil
+	}
+
+	return verify(t.root)
+}
@@ -381,6 +381,115 @@ func BenchmarkAVL(b *testing.B) {
 	}
 }
 
+func TestTree_ExportImport(t *testing.T) {
+	kv, cleanup := GetKV("inmem", "")
+	defer cleanup()
+
+	tree := New(kv)
+
+	for i := 0; i < 100; i++ {
+		tree.SetViewID(uint64(i))
+
+		var key [8]byte
+		binary.BigEndian.PutUint64(key[:], uint64(i))
+
+		tree.Insert(key[:], key[:])
+	}
+
+	assert.NoError(t, tree.Commit())
+
+	var buf bytes.Buffer
+	assert.NoError(t, tree.Export(&buf))
+
+	imported := New(store.NewInmem())
+	assert.NoError(t, imported.Import(bytes.NewReader(buf.Bytes())))
+	assert.NoError(t, imported.Commit())
+
+	assert.Equal(t, tree.Checksum(), imported.Checksum())
+
+	imported = New(imported.kv)
+	assert.Equal(t, tree.Checksum(), imported.Checksum())
+
+	for i := 0; i < 100; i++ {
+		var key [8]byte
+		binary.BigEndian.PutUint64(key[:], uint64(i))
+
+		val, ok := imported.Lookup(key[:])
+		assert.True(t, ok)
+		assert.EqualValues(t, key[:], val)
+	}
+
+	// Imports with missing nodes should be rejected.
+	truncated := buf.Bytes()[:buf.Len()/2]
+	assert.Error(t, New(store.NewInmem()).Import(bytes.NewReader(truncated)))
+}
+
+func TestTree_Proof(t *testing.T) {
+	tree := New(store.NewInmem())
+
+	// Proofs against an empty tree.
+	proof, err := tree.Prove([]byte("missing"))
+	assert.NoError(t, err)
+
+	_, exists, err := VerifyProof(tree.Checksum(), []byte("missing"), proof)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	for i := 0; i < 100; i += 2 {
+		var key [8]byte
+		binary.BigEndian.PutUint64(key[:], uint64(i))
+
+		tree.Insert(key[:], append([]byte("value"), key[:]...))
+	}
+
+	checksum := tree.Checksum()
+
+	for i := 0; i <= 100; i++ {
+		var key [8]byte
+		binary.BigEndian.PutUint64(key[:], uint64(i))
+
+		proof, err := tree.Prove(key[:])
+		assert.NoError(t, err)
+
+		value, exists, err := VerifyProof(checksum, key[:], proof)
+		assert.NoError(t, err)
+
+		if i%2 == 0 && i < 100 {
+			assert.True(t, exists)
+			assert.Equal(t, append([]byte("value"), key[:]...), value)
+		} else {
+			assert.False(t, exists)
+		}
+	}
+
+	var key [8]byte
+	binary.BigEndian.PutUint64(key[:], 42)
+
+	proof, err = tree.Prove(key[:])
+	assert.NoError(t, err)
+
+	// Proofs may not be checked against another key, or another tree.
+	_, _, err = VerifyProof(checksum, []byte{0xFF}, proof)
+	assert.Error(t, err)
+
+	_, _, err = VerifyProof([MerkleHashSize]byte{1}, key[:], proof)
+	assert.Error(t, err)
+
+	// Proofs which are tampered with, truncated or padded are rejected.
+	tampered := append([][]byte{}, proof...)
+	tampered[len(tampered)-1] = append([]byte{}, tampered[len(tampered)-1]...)
+	tampered[len(tampered)-1][len(tampered[len(tampered)-1])-10] ^= 0xFF
+
+	_, _, err = VerifyProof(checksum, key[:], tampered)
+	assert.Error(t, err)
+
+	_, _, err = VerifyProof(checksum, key[:], proof[:len(proof)-1])
+	assert.Error(t, err)
+
+	_, _, err = VerifyProof(checksum, key[:], append(proof, proof[0]))
+	assert.Error(t, err)
+}
+
 func GetKV(kv string, path string) (store.KV, func()) {
 	if kv == "inmem" {
 		inmemdb := store.NewInmem()
@@ -405,3 +514,47 @@ func GetKV(kv string, path string) (store.KV, func()) {
 
 	panic("unknown kv " + kv)
 }
+
+func TestTree_Verify(t *testing.T) {
+	kv, cleanup := GetKV("level", "db")
+	defer cleanup()
+
+	tree := New(kv)
+
+	for i := 0; i < 1000; i++ {
+		var key [8]byte
+		binary.BigEndian.PutUint64(key[:], rand.Uint64())
+
+		tree.Insert(key[:], key[:])
+	}
+
+	assert.NoError(t, tree.Commit())
+
+	var leaves uint64
+
+	assert.NoError(t, New(kv).Verify(func(n uint64) {
+		leaves = n
+	}))
+	assert.Equal(t, tree.root.size, leaves)
+
+	// Corrupt the value of a leaf stored in the database.
+	var leaf *node
+
+	assert.NoError(t, tree.root.dfs(tree, false, func(n *node) (bool, error) {
+		if n.kind == NodeLeafValue && leaf == nil {
+			leaf = n
+		}
+
+		return leaf == nil, nil
+	}))
+
+	corrupted := leaf.clone()
+	corrupted.value = []byte("corrupted")
+
+	var buf bytes.Buffer
+	corrupted.serialize(&buf)
+
+	assert.NoError(t, kv.Put(append(NodeKeyPrefix, leaf.id[:]...), buf.Bytes()))
+
+	assert.Error(t, New(kv).Verify(nil))
+}
@@ -0,0 +1,295 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"archive/tar"
+	"bytes"
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"time"
+)
+
+const (
+	backupRoundEntry = "round"
+	backupStateEntry = "state"
+
+	backupMaxAttempts = 10
+)
+
+type restoreRequest struct {
+	round    Round
+	snapshot *avl.Tree
+	result   chan error
+}
+
+// Backup writes a tarball to w containing the latest finalized round of the
+// ledger, alongside a consistent snapshot of the ledger state as of that
+// round. It may be called while the ledger is running. The ledger state is
+// streamed to w as it is exported, rather than being held in memory.
+func (l *Ledger) Backup(w io.Writer) error {
+	_, write, err := l.PrepareBackup()
+	if err != nil {
+		return err
+	}
+
+	return write(w)
+}
+
+// PrepareBackup takes a consistent snapshot of the ledger for a backup. It
+// returns the size of the tarball the backup amounts to, alongside a function
+// which streams the tarball to w, such that the size of the backup may be
+// announced before any of it is written.
+func (l *Ledger) PrepareBackup() (int64, func(w io.Writer) error, error) {
+	round, snapshot, err := l.consistentSnapshot()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Entries of a tarball are preceded by their size, which for the ledger
+	// state is measured by exporting it once without keeping the export.
+
+	var stateSize byteCounter
+
+	if err := snapshot.Export(&stateSize); err != nil {
+		return 0, nil, errors.Wrap(err, "failed to export ledger state")
+	}
+
+	roundBuf := round.Marshal()
+	modTime := time.Now()
+
+	// The size of the tarball is measured by writing it with the ledger state
+	// replaced by as many zeroes, which yields the same headers and padding.
+
+	var size byteCounter
+
+	zeroes := func(w io.Writer) error {
+		_, err := io.CopyN(w, zeroReader{}, int64(stateSize))
+		return err
+	}
+
+	if err := writeBackup(&size, modTime, roundBuf, int64(stateSize), zeroes); err != nil {
+		return 0, nil, err
+	}
+
+	write := func(w io.Writer) error {
+		return writeBackup(w, modTime, roundBuf, int64(stateSize), snapshot.Export)
+	}
+
+	return int64(size), write, nil
+}
+
+// writeBackup writes a tarball to w containing roundBuf, alongside a ledger
+// state of stateSize bytes written by writeState.
+func writeBackup(w io.Writer, modTime time.Time, roundBuf []byte, stateSize int64, writeState func(w io.Writer) error) error {
+	tw := tar.NewWriter(w)
+
+	entries := []struct {
+		name  string
+		size  int64
+		write func(w io.Writer) error
+	}{
+		{
+			name: backupRoundEntry,
+			size: int64(len(roundBuf)),
+			write: func(w io.Writer) error {
+				_, err := w.Write(roundBuf)
+				return err
+			},
+		},
+		{
+			name:  backupStateEntry,
+			size:  stateSize,
+			write: writeState,
+		},
+	}
+
+	for _, entry := range entries {
+		header := &tar.Header{
+			Name:    entry.name,
+			Mode:    0600,
+			Size:    entry.size,
+			ModTime: modTime,
+		}
+
+		if err := tw.WriteHeader(header); err != nil {
+			return errors.Wrapf(err, "failed to write backup entry header %q", entry.name)
+		}
+
+		if err := entry.write(tw); err != nil {
+			return errors.Wrapf(err, "failed to write backup entry %q", entry.name)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return errors.Wrap(err, "failed to finish writing backup")
+	}
+
+	return nil
+}
+
+// byteCounter is an io.Writer which discards all bytes written to it, and
+// counts them.
+type byteCounter int64
+
+func (c *byteCounter) Write(buf []byte) (int, error) {
+	*c += byteCounter(len(buf))
+	return len(buf), nil
+}
+
+// zeroReader is an io.Reader which reads an endless stream of zeroes.
+type zeroReader struct{}
+
+func (zeroReader) Read(buf []byte) (int, error) {
+	for i := range buf {
+		buf[i] = 0
+	}
+
+	return len(buf), nil
+}
+
+// consistentSnapshot returns the latest round alongside a snapshot of the
+// ledger state as of said round.
+func (l *Ledger) consistentSnapshot() (Round, *avl.Tree, error) {
+	var err error
+
+	for i := 0; i < backupMaxAttempts; i++ {
+		round := l.rounds.Latest()
+		snapshot := l.accounts.Snapshot()
+
+		if checksum := snapshot.Checksum(); checksum != round.Merkle {
+			err = errors.Errorf("state merkle root %x does not match round merkle root %x", checksum, round.Merkle)
+			time.Sleep(10 * time.Millisecond)
+
+			continue
+		}
+
+		return *round, snapshot, nil
+	}
+
+	return Round{}, nil, errors.Wrap(err, "failed to take a consistent snapshot of the ledger")
+}
+
+// StateDiff returns the latest finalized round of the ledger, alongside the
+// diff of the ledger state as of said round against the state as of the round
+// with index since. It is the same diff served to peers syncing to the round.
+func (l *Ledger) StateDiff(since uint64) (Round, []byte, error) {
+	round, snapshot, err := l.consistentSnapshot()
+	if err != nil {
+		return round, nil, err
+	}
+
+	if since > round.Index {
+		return round, nil, errors.Wrapf(ErrRoundNotFinalized, "round %d is after the latest round %d", since, round.Index)
+	}
+
+	return round, snapshot.DumpDiff(since), nil
+}
+
+// Restore reads a tarball produced by Backup from r, and has the ledger adopt
+// the round and state within it. Only backups of rounds newer than the latest
+// round of the ledger may be restored.
+func (l *Ledger) Restore(r io.Reader) error {
+	if len(l.upstream) > 0 {
+		return errors.New("read replicas adopt rounds from their upstream node, and may not be restored from a backup")
+	}
+
+	tr := tar.NewReader(r)
+
+	var round *Round
+	var snapshot *avl.Tree
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return errors.Wrap(err, "failed to read backup")
+		}
+
+		switch header.Name {
+		case backupRoundEntry:
+			buf, err := ioutil.ReadAll(tr)
+			if err != nil {
+				return errors.Wrap(err, "failed to read round from backup")
+			}
+
+			restored, err := UnmarshalRound(bytes.NewReader(buf))
+			if err != nil {
+				return errors.Wrap(err, "failed to decode round from backup")
+			}
+
+			round = &restored
+		case backupStateEntry:
+			snapshot = l.accounts.Snapshot()
+
+			if err := snapshot.Import(tr); err != nil {
+				return errors.Wrap(err, "failed to import state from backup")
+			}
+		default:
+			return errors.Errorf("unexpected entry %q in backup", header.Name)
+		}
+	}
+
+	if round == nil || snapshot == nil {
+		return errors.New("backup is missing either its round or its state")
+	}
+
+	if checksum := snapshot.Checksum(); checksum != round.Merkle {
+		return errors.Errorf("restored state merkle root %x does not match round merkle root %x", checksum, round.Merkle)
+	}
+
+	req := restoreRequest{round: *round, snapshot: snapshot, result: make(chan error, 1)}
+
+	l.restores <- req
+
+	return <-req.result
+}
+
+// applyRestore has the ledger adopt a restored round and state. It must only
+// be called while all consensus workers are stopped.
+func (l *Ledger) applyRestore(req restoreRequest) error {
+	current := l.rounds.Latest()
+
+	if req.round.Index <= current.Index {
+		return errors.Errorf("backup is of round %d, but the ledger is already at round %d", req.round.Index, current.Index)
+	}
+
+	pruned, err := l.commitRound(&req.round, req.snapshot)
+	if err != nil {
+		return errors.Wrap(err, "failed to commit restored round")
+	}
+
+	if pruned != nil {
+		l.graph.PruneBelowDepth(pruned.End.Depth)
+	}
+
+	l.graph.UpdateRoot(req.round.End)
+
+	l.restartAccountHistory(req.round.Index)
+
+	l.roundFinalized(req.round)
+
+	return nil
+}
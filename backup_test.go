@@ -0,0 +1,138 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"bytes"
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newTestLedger(t *testing.T, opts ...LedgerOption) *Ledger {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	ledger := NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), nil, opts...)
+	t.Cleanup(ledger.Stop)
+
+	return ledger
+}
+
+func TestBackupRestore(t *testing.T) {
+	source := newTestLedger(t)
+
+	var account AccountID
+	account[0] = 1
+
+	// Advance the source ledger by a round.
+	snapshot := source.Snapshot()
+	snapshot.SetViewID(1)
+	WriteAccountBalance(snapshot, account, 1337)
+
+	latest := source.Rounds().Latest()
+	round := NewRound(1, snapshot.Checksum(), 0, latest.End, latest.End)
+
+	_, err := source.rounds.Save(&round)
+	assert.NoError(t, err)
+	assert.NoError(t, source.accounts.Commit(snapshot))
+
+	var backup bytes.Buffer
+	assert.NoError(t, source.Backup(&backup))
+
+	// The size of a prepared backup is known before it is written.
+	size, write, err := source.PrepareBackup()
+	assert.NoError(t, err)
+
+	var prepared bytes.Buffer
+	assert.NoError(t, write(&prepared))
+	assert.EqualValues(t, prepared.Len(), size)
+	assert.Equal(t, backup.Len(), prepared.Len())
+
+	target := newTestLedger(t)
+
+	var finalized []Round
+	target.OnRoundFinalized(func(round Round) {
+		finalized = append(finalized, round)
+	})
+
+	assert.NoError(t, target.Restore(bytes.NewReader(backup.Bytes())))
+
+	if assert.Len(t, finalized, 1) {
+		assert.Equal(t, round.ID, finalized[0].ID)
+		assert.Equal(t, round.Merkle, finalized[0].Merkle)
+	}
+
+	assert.Equal(t, round.ID, target.Rounds().Latest().ID)
+	assert.Equal(t, round.Merkle, target.Snapshot().Checksum())
+
+	balance, exists := ReadAccountBalance(target.Snapshot(), account)
+	assert.True(t, exists)
+	assert.EqualValues(t, 1337, balance)
+
+	// Account history starts over from the round restored.
+	assert.Equal(t, round.Index, target.AccountHistoryFrom())
+
+	// Backups of rounds that are not newer than the latest round of the ledger may not be restored.
+	assert.Error(t, target.Restore(bytes.NewReader(backup.Bytes())))
+
+	// Corrupted backups may not be restored.
+	assert.Error(t, newTestLedger(t).Restore(bytes.NewReader(backup.Bytes()[:backup.Len()/2])))
+}
+
+func TestStateDiff(t *testing.T) {
+	source := newTestLedger(t)
+	target := newTestLedger(t)
+
+	var account AccountID
+	account[0] = 1
+
+	// Advance the source ledger by a round.
+	snapshot := source.Snapshot()
+	snapshot.SetViewID(1)
+	WriteAccountBalance(snapshot, account, 1337)
+
+	latest := source.Rounds().Latest()
+	round := NewRound(1, snapshot.Checksum(), 0, latest.End, latest.End)
+
+	_, err := source.rounds.Save(&round)
+	assert.NoError(t, err)
+	assert.NoError(t, source.accounts.Commit(snapshot))
+
+	diffRound, diff, err := source.StateDiff(0)
+	assert.NoError(t, err)
+	assert.Equal(t, round.ID, diffRound.ID)
+
+	// Applying the diff to the state as of the round it is against yields the
+	// state as of the latest round.
+	state := target.Snapshot()
+	assert.NoError(t, state.ApplyDiff(diff))
+	assert.Equal(t, round.Merkle, state.Checksum())
+
+	// Diffs against the latest round are empty.
+	_, diff, err = source.StateDiff(1)
+	assert.NoError(t, err)
+	assert.Empty(t, diff)
+
+	_, _, err = source.StateDiff(2)
+	assert.Equal(t, ErrRoundNotFinalized, errors.Cause(err))
+}
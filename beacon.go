@@ -0,0 +1,86 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"encoding/binary"
+	"github.com/perlin-network/wavelet/avl"
+	"golang.org/x/crypto/blake2b"
+)
+
+// SizeRandomBeacon is the size of a random beacon value in bytes.
+const SizeRandomBeacon = blake2b.Size256
+
+// RandomBeacon is a pseudo-random value which every node derives identically
+// from the rounds finalized by the ledger, such that smart contracts have a
+// source of entropy agreed upon by consensus.
+//
+// The beacon of a round is the hash of the beacon of the round before it and
+// the ID of the transaction the round ended at, forming a hash chain rooted at
+// a beacon of all zeroes. Transactions applied in a round only ever see the
+// beacon of the round before it, which was fixed before they were created. To
+// bias the beacon, one would have to withhold or grind out critical
+// transactions, each of which must meet the difficulty of its round.
+type RandomBeacon [SizeRandomBeacon]byte
+
+// NextRandomBeacon derives the beacon of the round ending at the transaction
+// end from the beacon of the round before it.
+func NextRandomBeacon(prev RandomBeacon, end TransactionID) RandomBeacon {
+	return blake2b.Sum256(append(prev[:], end[:]...))
+}
+
+// SizeRandomSeed is the size of a seed drawn by smart contracts in bytes.
+const SizeRandomSeed = blake2b.Size256
+
+// RandomSeed derives the seed a smart contract draws through the _random host
+// function from the beacon of the round before the one being applied, the
+// transaction which originated the invocation of the contract, and the number
+// of seeds the invocation drew prior. Every node derives the same seeds, and
+// seeds differ across transactions, contracts, and draws.
+func RandomSeed(beacon RandomBeacon, txID TransactionID, contract AccountID, draw uint64) [SizeRandomSeed]byte {
+	var counter [8]byte
+	binary.LittleEndian.PutUint64(counter[:], draw)
+
+	buf := make([]byte, 0, SizeRandomBeacon+SizeTransactionID+SizeAccountID+len(counter))
+
+	buf = append(buf, beacon[:]...)
+	buf = append(buf, txID[:]...)
+	buf = append(buf, contract[:]...)
+	buf = append(buf, counter[:]...)
+
+	return blake2b.Sum256(buf)
+}
+
+// ReadRandomBeacon reads the beacon of the latest round applied to tree. The
+// beacon is all zeroes should no rounds have been applied to tree yet.
+func ReadRandomBeacon(tree *avl.Tree) RandomBeacon {
+	var beacon RandomBeacon
+
+	buf, exists := tree.Lookup(keyRandomBeacon[:])
+	if exists && len(buf) == SizeRandomBeacon {
+		copy(beacon[:], buf)
+	}
+
+	return beacon
+}
+
+func WriteRandomBeacon(tree *avl.Tree, beacon RandomBeacon) {
+	tree.Insert(keyRandomBeacon[:], beacon[:])
+}
@@ -125,7 +125,9 @@ func main() {
 	}()
 
 	if *apiPortFlag > 0 {
-		go api.New().StartHTTP(*apiPortFlag, client, ledger, keys)
+		if _, err := api.New().StartHTTP(*apiPortFlag, client, ledger, keys); err != nil {
+			panic(err)
+		}
 	}
 
 	if len(flag.Args()) > 1 {
@@ -0,0 +1,236 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"github.com/chzyer/readline"
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet"
+	"github.com/perlin-network/wavelet/log"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// startLight runs the node as a light node, which neither stores the state of
+// the ledger nor participates in consensus. It only tracks the latest round
+// agreed upon by its peers, and has them prove the state of accounts against
+// the round on demand.
+func startLight(cfg *Config, client *skademlia.Client, keys *skademlia.Keypair, listener net.Listener) {
+	logger := log.Node()
+
+	go func() {
+		server := client.Listen(
+			grpc.MaxRecvMsgSize(sys.MaxMessageSize),
+			grpc.MaxSendMsgSize(sys.MaxMessageSize),
+		)
+
+		if err := server.Serve(listener); err != nil {
+			panic(err)
+		}
+	}()
+
+	for _, addr := range cfg.Peers {
+		if _, err := client.Dial(addr); err != nil {
+			fmt.Printf("Error dialing %s: %v\n", addr, err)
+		}
+	}
+
+	if peers := client.Bootstrap(); len(peers) > 0 {
+		var ids []string
+
+		for _, id := range peers {
+			ids = append(ids, id.String())
+		}
+
+		logger.Info().Msgf("Bootstrapped with peers: %+v", ids)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	light := wavelet.NewLightClient(client)
+	go light.Track(ctx)
+
+	shell, err := NewLightCLI(client, light, keys)
+	if err != nil {
+		panic(err)
+	}
+
+	shell.Start()
+}
+
+// LightCLI is the shell of a light node. It only supports commands which may
+// be answered with the state proven by peers.
+type LightCLI struct {
+	rl     *readline.Instance
+	client *skademlia.Client
+	light  *wavelet.LightClient
+	logger zerolog.Logger
+	keys   *skademlia.Keypair
+	tree   string
+}
+
+func NewLightCLI(client *skademlia.Client, light *wavelet.LightClient, keys *skademlia.Keypair) (*LightCLI, error) {
+	completer := readline.NewPrefixCompleter(
+		readline.PcItem("l"), readline.PcItem("status"),
+		readline.PcItem("f"), readline.PcItem("find"),
+		readline.PcItem("help"),
+	)
+
+	rl, err := readline.NewEx(
+		&readline.Config{
+			Prompt:            "\033[31m»»»\033[0m ",
+			AutoComplete:      completer,
+			HistoryFile:       "/tmp/readline.tmp",
+			InterruptPrompt:   "^C",
+			EOFPrompt:         "exit",
+			HistorySearchFold: true,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	log.SetWriter(log.LoggerWavelet, log.NewConsoleWriter(rl.Stderr(), log.FilterFor(log.ModuleNode, log.ModuleNetwork, log.ModuleSync)))
+
+	return &LightCLI{
+		rl:     rl,
+		client: client,
+		light:  light,
+		logger: log.Node(),
+		tree:   completer.Tree("    "),
+		keys:   keys,
+	}, nil
+}
+
+func (cli *LightCLI) Start() {
+	defer func() {
+		_ = cli.rl.Close()
+	}()
+
+	for {
+		line, err := cli.rl.Readline()
+		switch err {
+		case readline.ErrInterrupt:
+			if len(line) == 0 {
+				return
+			} else {
+				continue
+			}
+		case io.EOF:
+			return
+		}
+
+		switch {
+		case line == "l" || line == "status":
+			cli.status()
+		case strings.HasPrefix(line, "f "):
+			cli.find(toCMD(line, 2))
+		case strings.HasPrefix(line, "find "):
+			cli.find(toCMD(line, 5))
+		case line == "":
+			fallthrough
+		case line == "help":
+			cli.usage()
+		default:
+			fmt.Printf("unrecognised command :'%s'\n", line)
+		}
+	}
+}
+
+func (cli *LightCLI) usage() {
+	_, _ = io.WriteString(cli.rl.Stderr(), "commands:\n")
+	_, _ = io.WriteString(cli.rl.Stderr(), cli.tree)
+}
+
+func (cli *LightCLI) status() {
+	publicKey := cli.keys.PublicKey()
+
+	peers := cli.client.ClosestPeerIDs()
+	peerIDs := make([]string, 0, len(peers))
+
+	for _, id := range peers {
+		peerIDs = append(peerIDs, id.String())
+	}
+
+	round, tracked := cli.light.Round()
+	if !tracked {
+		cli.logger.Info().
+			Str("id", hex.EncodeToString(publicKey[:])).
+			Strs("peers", peerIDs).
+			Msg("Light node has yet to track a round from its peers.")
+
+		return
+	}
+
+	cli.logger.Info().
+		Uint64("round", round.Index).
+		Hex("round_id", round.ID[:]).
+		Hex("merkle_root", round.Merkle[:]).
+		Hex("root_id", round.End.ID[:]).
+		Str("id", hex.EncodeToString(publicKey[:])).
+		Strs("peers", peerIDs).
+		Msg("Here is the current status of your light node.")
+}
+
+func (cli *LightCLI) find(cmd []string) {
+	if len(cmd) != 1 {
+		fmt.Println("find <wallet-address>")
+		return
+	}
+
+	buf, err := hex.DecodeString(cmd[0])
+	if err != nil {
+		cli.logger.Error().Err(err).Msg("Cannot decode address")
+		return
+	}
+
+	if len(buf) != wavelet.SizeAccountID {
+		cli.logger.Error().Int("length", len(buf)).Msg("You have specified an invalid account ID to find.")
+		return
+	}
+
+	var accountID wavelet.AccountID
+	copy(accountID[:], buf)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	account, err := cli.light.Account(ctx, accountID)
+	if err != nil {
+		cli.logger.Error().Err(err).Msg("Failed to have peers prove the state of the account.")
+		return
+	}
+
+	cli.logger.Info().
+		Uint64("balance", account.Balance).
+		Uint64("stake", account.Stake).
+		Uint64("nonce", account.Nonce).
+		Uint64("reward", account.Reward).
+		Msgf("Account: %s", cmd[0])
+}
@@ -20,6 +20,7 @@
 package main
 
 import (
+	"context"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -35,6 +36,7 @@ import (
 	"github.com/perlin-network/wavelet/log"
 	"github.com/perlin-network/wavelet/store"
 	"github.com/perlin-network/wavelet/sys"
+	"github.com/rcrowley/go-metrics"
 	"google.golang.org/grpc"
 	"gopkg.in/urfave/cli.v1"
 	"gopkg.in/urfave/cli.v1/altsrc"
@@ -50,18 +52,50 @@ import (
 import _ "net/http/pprof"
 
 type Config struct {
-	NAT      bool
-	Host     string
-	Port     uint
-	Wallet   string
-	Genesis  *string
-	APIPort  uint
-	Peers    []string
-	Database string
+	NAT             bool
+	Host            string
+	Port            uint
+	Wallet          string
+	Genesis         *string
+	GenesisPath     string
+	APIPort         uint
+	APIMaxContract  int
+	APIGRPCPort     uint
+	Peers           []string
+	Upstream        string
+	Standby         string
+	Light           bool
+	Archive         bool
+	Database        string
+	DatabaseBackend string
+
+	CacheSize           int
+	CacheFlushThreshold int
+
+	APIAccessLogSampleRate     float64
+	APIAccessLogDisabledRoutes []string
+
+	APIReadRateLimit float64
+	APISendRateLimit float64
+
+	APIMaxWebsocketConnections      int
+	APIMaxWebsocketConnectionsPerIP int
+	APIWebsocketIdleTimeout         time.Duration
+
+	APIKeys []string
+
+	APIRouteLimits []string
+
+	APITLSCert         string
+	APITLSKey          string
+	APIShutdownTimeout time.Duration
+
+	FaucetAmount   uint64
+	FaucetCooldown time.Duration
 }
 
 func main() {
-	log.SetWriter(log.LoggerWavelet, log.NewConsoleWriter(nil, log.FilterFor(log.ModuleNode, log.ModuleNetwork, log.ModuleSync, log.ModuleConsensus, log.ModuleContract)))
+	log.SetWriter(log.LoggerWavelet, log.NewConsoleWriter(nil, log.FilterFor(log.ModuleNode, log.ModuleNetwork, log.ModuleSync, log.ModuleConsensus, log.ModuleContract, log.ModuleAPI)))
 	logger := log.Node()
 
 	app := cli.NewApp()
@@ -90,12 +124,122 @@ func main() {
 			Usage:  "Listen for peers on port.",
 			EnvVar: "WAVELET_NODE_PORT",
 		}),
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:   "upstream",
+			Usage:  "Run as a read replica of the full node at this address, adopting the rounds it finalizes rather than participating in consensus.",
+			EnvVar: "WAVELET_UPSTREAM",
+		}),
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:   "standby",
+			Usage:  "Run as a cold standby of the validator at this address, given the wallet of the validator. Rounds are replicated from the validator until the standby is promoted through POST /node/promote, which requires an API key granted the admin scope, upon which it joins the network and participates in consensus in place of the validator.",
+			EnvVar: "WAVELET_STANDBY",
+		}),
+		altsrc.NewBoolFlag(cli.BoolFlag{
+			Name:   "light",
+			Usage:  "Run as a light node, which only tracks the latest round agreed upon by its peers and has them prove the state of accounts on demand, rather than syncing and storing the state of the ledger.",
+			EnvVar: "WAVELET_LIGHT",
+		}),
+		altsrc.NewBoolFlag(cli.BoolFlag{
+			Name:   "archive",
+			Usage:  "Run as an archive node, which keeps the diff of the ledger state and the changes made to accounts in every round it finalizes, indexed by round, and never prunes away its history.",
+			EnvVar: "WAVELET_ARCHIVE",
+		}),
 		altsrc.NewIntFlag(cli.IntFlag{
 			Name:   "api.port",
 			Value:  0,
 			Usage:  "Host a local HTTP API at port.",
 			EnvVar: "WAVELET_API_PORT",
 		}),
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:   "api.tls.cert",
+			Usage:  "Path to a PEM-encoded TLS certificate to serve the HTTP API over HTTPS with. Requires --api.tls.key to be set.",
+			EnvVar: "WAVELET_API_TLS_CERT",
+		}),
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:   "api.tls.key",
+			Usage:  "Path to the PEM-encoded private key of the TLS certificate specified by --api.tls.cert.",
+			EnvVar: "WAVELET_API_TLS_KEY",
+		}),
+		altsrc.NewDurationFlag(cli.DurationFlag{
+			Name:   "api.shutdown_timeout",
+			Value:  api.DefaultShutdownTimeout,
+			Usage:  "Duration to wait for requests in flight to the HTTP API to be served upon exiting.",
+			EnvVar: "WAVELET_API_SHUTDOWN_TIMEOUT",
+		}),
+		altsrc.NewIntFlag(cli.IntFlag{
+			Name:   "api.max_contract_size",
+			Value:  api.DefaultMaxContractRequestBodySize,
+			Usage:  "Max size in bytes of a request body uploading a smart contract to the HTTP API.",
+			EnvVar: "WAVELET_API_MAX_CONTRACT_SIZE",
+		}),
+		altsrc.NewIntFlag(cli.IntFlag{
+			Name:   "api.grpc.port",
+			Value:  0,
+			Usage:  "Additionally host the local API over gRPC at port. Requires --api.port to be set.",
+			EnvVar: "WAVELET_API_GRPC_PORT",
+		}),
+		altsrc.NewFloat64Flag(cli.Float64Flag{
+			Name:   "api.access_log.sample_rate",
+			Value:  api.DefaultAccessLogSampleRate,
+			Usage:  "Fraction of successful requests to the HTTP API to write to the access log. Failed requests are always logged.",
+			EnvVar: "WAVELET_API_ACCESS_LOG_SAMPLE_RATE",
+		}),
+		altsrc.NewStringSliceFlag(cli.StringSliceFlag{
+			Name:   "api.access_log.disabled_routes",
+			Usage:  "Routes of the HTTP API, such as /tx/:id, which are not to be written to the access log.",
+			EnvVar: "WAVELET_API_ACCESS_LOG_DISABLED_ROUTES",
+		}),
+		altsrc.NewFloat64Flag(cli.Float64Flag{
+			Name:   "api.rate_limit.reads",
+			Value:  api.DefaultReadRateLimit,
+			Usage:  "Max number of requests per second a single IP address may make to each route of the HTTP API which reads from the node.",
+			EnvVar: "WAVELET_API_RATE_LIMIT_READS",
+		}),
+		altsrc.NewFloat64Flag(cli.Float64Flag{
+			Name:   "api.rate_limit.sends",
+			Value:  api.DefaultSendRateLimit,
+			Usage:  "Max number of requests per second a single API key, or IP address if no API key is presented, may make to each route of the HTTP API which sends transactions.",
+			EnvVar: "WAVELET_API_RATE_LIMIT_SENDS",
+		}),
+		altsrc.NewIntFlag(cli.IntFlag{
+			Name:   "api.ws.max_connections",
+			Value:  api.DefaultMaxWebsocketConnections,
+			Usage:  "Max number of websocket connections the HTTP API serves at once. Zero is unlimited.",
+			EnvVar: "WAVELET_API_WS_MAX_CONNECTIONS",
+		}),
+		altsrc.NewIntFlag(cli.IntFlag{
+			Name:   "api.ws.max_connections_per_ip",
+			Value:  api.DefaultMaxWebsocketConnectionsPerIP,
+			Usage:  "Max number of websocket connections the HTTP API serves at once to a single IP address. Zero is unlimited.",
+			EnvVar: "WAVELET_API_WS_MAX_CONNECTIONS_PER_IP",
+		}),
+		altsrc.NewDurationFlag(cli.DurationFlag{
+			Name:   "api.ws.idle_timeout",
+			Value:  api.DefaultWebsocketIdleTimeout,
+			Usage:  "Duration a websocket client of the HTTP API may go without responding to a ping before it is disconnected.",
+			EnvVar: "WAVELET_API_WS_IDLE_TIMEOUT",
+		}),
+		altsrc.NewStringSliceFlag(cli.StringSliceFlag{
+			Name:   "api.route_limits",
+			Usage:  "Limits on requests to a group of routes of the HTTP API in the format group:max_request_body_size:timeout, such as send:65536:10s. Groups are read, send, contract, and admin. Either limit may be left empty to keep its default.",
+			EnvVar: "WAVELET_API_ROUTE_LIMITS",
+		}),
+		altsrc.NewStringSliceFlag(cli.StringSliceFlag{
+			Name:   "api.keys",
+			Usage:  "API keys alongside the scopes they are granted, in the format key:scope,scope. Scopes are read, send, and admin. If any are specified, the HTTP and gRPC API require an API key be presented under the X-API-Key header. Admin routes are disabled unless a key granted the admin scope is specified.",
+			EnvVar: "WAVELET_API_KEYS",
+		}),
+		altsrc.NewUint64Flag(cli.Uint64Flag{
+			Name:   "api.faucet.amount",
+			Usage:  "Max number of PERLs the faucet at POST /faucet of the HTTP API sends from the account of the node per request. The faucet is meant for test networks only, and is disabled if zero.",
+			EnvVar: "WAVELET_API_FAUCET_AMOUNT",
+		}),
+		altsrc.NewDurationFlag(cli.DurationFlag{
+			Name:   "api.faucet.cooldown",
+			Value:  api.DefaultFaucetCooldown,
+			Usage:  "Duration an address must wait after being sent PERLs by the faucet of the HTTP API before it may request PERLs again.",
+			EnvVar: "WAVELET_API_FAUCET_COOLDOWN",
+		}),
 		altsrc.NewStringFlag(cli.StringFlag{
 			Name:   "wallet",
 			Value:  "config/wallet.txt",
@@ -104,59 +248,126 @@ func main() {
 		}),
 		altsrc.NewStringFlag(cli.StringFlag{
 			Name:   "genesis",
-			Usage:  "Genesis JSON file contents representing initial fields of some set of accounts at round 0.",
+			Usage:  "Genesis JSON file contents representing initial accounts, contracts and consensus parameters at round 0.",
 			EnvVar: "WAVELET_GENESIS",
 		}),
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:   "genesis.path",
+			Usage:  "Path to a genesis JSON file. Mutually exclusive with --genesis.",
+			EnvVar: "WAVELET_GENESIS_PATH",
+		}),
 		altsrc.NewStringFlag(cli.StringFlag{
 			Name:   "db",
-			Usage:  "Directory path to the database. If empty, a temporary in-memory database will be used instead.",
+			Usage:  "Directory path to the database, or file path for single-file backends. If empty, a temporary in-memory database will be used instead.",
 			EnvVar: "WAVELET_DB_PATH",
 		}),
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:   "db.backend",
+			Value:  store.BackendLevelDB,
+			Usage:  "Database backend to use: leveldb, or bolt for a single-file database.",
+			EnvVar: "WAVELET_DB_BACKEND",
+		}),
+		altsrc.NewIntFlag(cli.IntFlag{
+			Name:   "db.cache",
+			Value:  0,
+			Usage:  "Number of database entries to keep in an in-memory LRU cache. If zero, no cache will be used.",
+			EnvVar: "WAVELET_DB_CACHE",
+		}),
+		altsrc.NewIntFlag(cli.IntFlag{
+			Name:   "db.cache.flush",
+			Value:  store.DefaultCacheFlushThreshold,
+			Usage:  "Number of pending writes buffered in the database cache before they are flushed to the database. If zero, writes will not be buffered.",
+			EnvVar: "WAVELET_DB_CACHE_FLUSH",
+		}),
 		altsrc.NewIntFlag(cli.IntFlag{
 			Name:  "sys.query_timeout",
-			Value: int(sys.QueryTimeout.Seconds()),
+			Value: int(sys.Params().QueryTimeout.Seconds()),
 			Usage: "Timeout in seconds for querying a transaction to K peers.",
 		}),
 		altsrc.NewUint64Flag(cli.Uint64Flag{
 			Name:  "sys.max_depth_diff",
-			Value: sys.MaxDepthDiff,
+			Value: sys.Params().MaxDepthDiff,
 			Usage: "Max graph depth difference to search for eligible transaction parents from for our node.",
 		}),
+		altsrc.NewFloat64Flag(cli.Float64Flag{
+			Name:  "sys.sync_quorum",
+			Value: sys.SyncQuorum,
+			Usage: "Fraction of peers queried while syncing which must agree on the latest round before it is synced to. Must be greater than 0.5.",
+		}),
+		altsrc.NewIntFlag(cli.IntFlag{
+			Name:  "sys.max_peers_per_subnet",
+			Value: sys.MaxPeersPerSubnet,
+			Usage: "Max number of peers from a single /24 IPv4 or /48 IPv6 subnet to query for consensus or syncing. If zero, there is no limit.",
+		}),
+		altsrc.NewFloat64Flag(cli.Float64Flag{
+			Name:  "sys.min_outbound_peer_fraction",
+			Value: sys.MinOutboundPeerFraction,
+			Usage: "Minimum fraction of peers queried for consensus or syncing which must be peers we were explicitly told to dial.",
+		}),
+		altsrc.NewBoolFlag(cli.BoolFlag{
+			Name:  "sys.gossip_rejections",
+			Usage: "Ask peers for, and answer peers with, machine-readable reasons for rejecting gossiped transactions.",
+		}),
+		altsrc.NewStringFlag(cli.StringFlag{
+			Name:   "sys.contract_runtime",
+			Value:  sys.ContractRuntime,
+			Usage:  "Runtime to execute smart contracts with. The interpreter is the only runtime shipped, and is fallen back to should the runtime not be registered or available on this platform.",
+			EnvVar: "WAVELET_CONTRACT_RUNTIME",
+		}),
+		altsrc.NewIntFlag(cli.IntFlag{
+			Name:  "sys.apply_workers",
+			Value: sys.ApplyWorkers,
+			Usage: "Number of workers to apply transactions within a round on in parallel. Transactions are applied one after another should it be at most 1.",
+		}),
+		altsrc.NewUint64Flag(cli.Uint64Flag{
+			Name:  "sys.prune_history_after",
+			Value: sys.PruneHistoryAfter,
+			Usage: "Number of latest finalized rounds account history and transaction receipts are kept for, unless running as an archive node. Kept forever should it be 0.",
+		}),
 		altsrc.NewUint64Flag(cli.Uint64Flag{
 			Name:  "sys.transaction_fee_amount",
-			Value: sys.TransactionFeeAmount,
+			Value: sys.Params().TransactionFeeAmount,
+		}),
+		altsrc.NewUint64Flag(cli.Uint64Flag{
+			Name:  "sys.transaction_fee_per_byte",
+			Value: sys.Params().TransactionFeePerByte,
+			Usage: "fee paid per byte of a transaction on top of the flat transaction fee amount",
+		}),
+		altsrc.NewBoolFlag(cli.BoolFlag{
+			Name:  "sys.burn_transaction_fees",
+			Usage: "burn transaction fees rather than rewarding them to validators",
 		}),
 		altsrc.NewUint64Flag(cli.Uint64Flag{
 			Name:  "sys.min_stake",
-			Value: sys.MinimumStake,
+			Value: sys.Params().MinimumStake,
 			Usage: "minimum stake to garner validator rewards and have importance in consensus",
 		}),
 		altsrc.NewIntFlag(cli.IntFlag{
 			Name:   "sys.snowball.k",
-			Value:  sys.SnowballK,
+			Value:  sys.Params().SnowballK,
 			Usage:  "Snowball consensus protocol parameter k",
 			EnvVar: "WAVELET_SNOWBALL_K",
 		}),
 		altsrc.NewFloat64Flag(cli.Float64Flag{
 			Name:   "sys.snowball.alpha",
-			Value:  sys.SnowballAlpha,
+			Value:  sys.Params().SnowballAlpha,
 			Usage:  "Snowball consensus protocol parameter alpha",
 			EnvVar: "WAVELET_SNOWBALL_ALPHA",
 		}),
 		altsrc.NewIntFlag(cli.IntFlag{
 			Name:   "sys.snowball.beta",
-			Value:  sys.SnowballBeta,
+			Value:  sys.Params().SnowballBeta,
 			Usage:  "Snowball consensus protocol parameter beta",
 			EnvVar: "WAVELET_SNOWBALL_BETA",
 		}),
 		altsrc.NewIntFlag(cli.IntFlag{
 			Name:  "sys.difficulty.min",
-			Value: int(sys.MinDifficulty),
+			Value: int(sys.Params().MinDifficulty),
 			Usage: "Minimum difficulty to define a critical transaction",
 		}),
 		altsrc.NewFloat64Flag(cli.Float64Flag{
 			Name:  "sys.difficulty.scale",
-			Value: sys.DifficultyScaleFactor,
+			Value: sys.Params().DifficultyScaleFactor,
 			Usage: "Factor to scale a transactions confidence down by to compute the difficulty needed to define a critical transaction",
 		}),
 		cli.StringFlag{
@@ -185,12 +396,44 @@ func main() {
 	app.Action = func(c *cli.Context) error {
 		c.String("config")
 		config := &Config{
-			Host:     c.String("host"),
-			Port:     c.Uint("port"),
-			Wallet:   c.String("wallet"),
-			APIPort:  c.Uint("api.port"),
-			Peers:    c.Args(),
-			Database: c.String("db"),
+			Host:            c.String("host"),
+			Port:            c.Uint("port"),
+			Wallet:          c.String("wallet"),
+			APIPort:         c.Uint("api.port"),
+			APIMaxContract:  c.Int("api.max_contract_size"),
+			APIGRPCPort:     c.Uint("api.grpc.port"),
+			Peers:           c.Args(),
+			Upstream:        c.String("upstream"),
+			Standby:         c.String("standby"),
+			Light:           c.Bool("light"),
+			Archive:         c.Bool("archive"),
+			GenesisPath:     c.String("genesis.path"),
+			Database:        c.String("db"),
+			DatabaseBackend: c.String("db.backend"),
+
+			CacheSize:           c.Int("db.cache"),
+			CacheFlushThreshold: c.Int("db.cache.flush"),
+
+			APIAccessLogSampleRate:     c.Float64("api.access_log.sample_rate"),
+			APIAccessLogDisabledRoutes: c.StringSlice("api.access_log.disabled_routes"),
+
+			APIReadRateLimit: c.Float64("api.rate_limit.reads"),
+			APISendRateLimit: c.Float64("api.rate_limit.sends"),
+
+			APIMaxWebsocketConnections:      c.Int("api.ws.max_connections"),
+			APIMaxWebsocketConnectionsPerIP: c.Int("api.ws.max_connections_per_ip"),
+			APIWebsocketIdleTimeout:         c.Duration("api.ws.idle_timeout"),
+
+			APIKeys: c.StringSlice("api.keys"),
+
+			APIRouteLimits: c.StringSlice("api.route_limits"),
+
+			APITLSCert:         c.String("api.tls.cert"),
+			APITLSKey:          c.String("api.tls.key"),
+			APIShutdownTimeout: c.Duration("api.shutdown_timeout"),
+
+			FaucetAmount:   c.Uint64("api.faucet.amount"),
+			FaucetCooldown: c.Duration("api.faucet.cooldown"),
 		}
 
 		if genesis := c.String("genesis"); len(genesis) > 0 {
@@ -198,21 +441,79 @@ func main() {
 		}
 
 		// set the the sys variables
-		sys.SnowballK = c.Int("sys.snowball.k")
-		sys.SnowballAlpha = c.Float64("sys.snowball.alpha")
-		sys.SnowballBeta = c.Int("sys.snowball.beta")
-		sys.QueryTimeout = time.Duration(c.Int("sys.query_timeout")) * time.Second
-		sys.MaxDepthDiff = c.Uint64("sys.max_depth_diff")
-		sys.MinDifficulty = byte(c.Int("sys.difficulty.min"))
-		sys.DifficultyScaleFactor = c.Float64("sys.difficulty.scale")
-		sys.TransactionFeeAmount = c.Uint64("sys.transaction_fee_amount")
-		sys.MinimumStake = c.Uint64("sys.min_stake")
+		sys.UpdateParams(func(p *sys.ConsensusParams) {
+			p.SnowballK = c.Int("sys.snowball.k")
+			p.SnowballAlpha = c.Float64("sys.snowball.alpha")
+			p.QueryTimeout = time.Duration(c.Int("sys.query_timeout")) * time.Second
+			p.SnowballBeta = c.Int("sys.snowball.beta")
+			p.MaxDepthDiff = c.Uint64("sys.max_depth_diff")
+			p.MinDifficulty = byte(c.Int("sys.difficulty.min"))
+			p.DifficultyScaleFactor = c.Float64("sys.difficulty.scale")
+			p.TransactionFeeAmount = c.Uint64("sys.transaction_fee_amount")
+			p.TransactionFeePerByte = c.Uint64("sys.transaction_fee_per_byte")
+			p.BurnTransactionFees = c.Bool("sys.burn_transaction_fees")
+			p.MinimumStake = c.Uint64("sys.min_stake")
+		})
+		sys.SyncQuorum = c.Float64("sys.sync_quorum")
+		sys.MaxPeersPerSubnet = c.Int("sys.max_peers_per_subnet")
+		sys.MinOutboundPeerFraction = c.Float64("sys.min_outbound_peer_fraction")
+		sys.GossipRejections = c.Bool("sys.gossip_rejections")
+		sys.ContractRuntime = c.String("sys.contract_runtime")
+		sys.ApplyWorkers = c.Int("sys.apply_workers")
+		sys.PruneHistoryAfter = c.Uint64("sys.prune_history_after")
+
+		if config.Light && (len(config.Upstream) > 0 || len(config.Standby) > 0 || config.Archive) {
+			return errors.New("a light node may not run as a read replica, a standby, or an archive node")
+		}
+
+		if sys.SyncQuorum <= 0.5 || sys.SyncQuorum > 1 {
+			return errors.New("sys.sync_quorum must be greater than 0.5 and at most 1")
+		}
+
+		if sys.MinOutboundPeerFraction < 0 || sys.MinOutboundPeerFraction > 1 {
+			return errors.New("sys.min_outbound_peer_fraction must be between 0 and 1")
+		}
+
+		if _, available := wavelet.LookupContractRuntime(sys.ContractRuntime); !available {
+			logger.Warn().
+				Str("runtime", sys.ContractRuntime).
+				Strs("available", wavelet.ContractRuntimes()).
+				Msg("Contract runtime is not available on this platform. Falling back to the interpreter.")
+
+			sys.ContractRuntime = wavelet.ContractRuntimeInterpreter
+		}
 
 		start(config)
 
 		return nil
 	}
 
+	app.Commands = []cli.Command{
+		{
+			Name:      "validate-genesis",
+			Usage:     "validate a genesis file and print the state root it produces, without starting a node",
+			ArgsUsage: "<path>",
+			Action: func(c *cli.Context) error {
+				if c.NArg() != 1 {
+					return errors.New("expected the path to a genesis file")
+				}
+
+				report, err := wavelet.ValidateGenesis(c.Args().First())
+				if err != nil {
+					return err
+				}
+
+				fmt.Printf("Version:   %d\n", report.Version)
+				fmt.Printf("Accounts:  %d\n", report.Accounts)
+				fmt.Printf("Contracts: %d\n", report.Contracts)
+				fmt.Printf("Params:    %d\n", report.Params)
+				fmt.Printf("Root:      %x\n", report.Root)
+
+				return nil
+			},
+		},
+	}
+
 	sort.Sort(cli.FlagsByName(app.Flags))
 	sort.Sort(cli.CommandsByName(app.Commands))
 
@@ -225,6 +526,15 @@ func main() {
 func start(cfg *Config) {
 	logger := log.Node()
 
+	lock, err := selfCheck(cfg)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Refusing to start the node.")
+	}
+
+	if lock != nil {
+		defer lock.Release()
+	}
+
 	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Port))
 	if err != nil {
 		panic(err)
@@ -269,16 +579,29 @@ func start(cfg *Config) {
 		panic(err)
 	}
 
+	peers := wavelet.NewPeerStats()
+	bans := wavelet.NewPeerBans()
+
 	client := skademlia.NewClient(
 		addr, keys,
 		skademlia.WithC1(sys.SKademliaC1),
 		skademlia.WithC2(sys.SKademliaC2),
-		skademlia.WithDialOptions(grpc.WithDefaultCallOptions(grpc.UseCompressor(snappy.Name))),
+		skademlia.WithDialOptions(
+			grpc.WithDefaultCallOptions(
+				grpc.UseCompressor(snappy.Name),
+				grpc.MaxCallRecvMsgSize(sys.MaxMessageSize),
+				grpc.MaxCallSendMsgSize(sys.MaxMessageSize),
+			),
+			grpc.WithUnaryInterceptor(peers.UnaryClientInterceptor),
+			grpc.WithStreamInterceptor(peers.StreamClientInterceptor),
+		),
 	)
 
-	client.SetCredentials(noise.NewCredentials(addr, handshake.NewECDH(), cipher.NewAEAD(), client.Protocol()))
+	client.SetCredentials(noise.NewCredentials(addr, handshake.NewECDH(), cipher.NewAEAD(), client.Protocol(), bans))
 
 	client.OnPeerJoin(func(conn *grpc.ClientConn, id *skademlia.ID) {
+		peers.Register(id)
+
 		publicKey := id.PublicKey()
 
 		logger := log.Network("joined")
@@ -290,6 +613,8 @@ func start(cfg *Config) {
 	})
 
 	client.OnPeerLeave(func(conn *grpc.ClientConn, id *skademlia.ID) {
+		peers.Unregister(id)
+
 		publicKey := id.PublicKey()
 
 		logger := log.Network("left")
@@ -299,19 +624,110 @@ func start(cfg *Config) {
 			Msg("Peer has left.")
 	})
 
+	if cfg.Light {
+		startLight(cfg, client, keys, listener)
+		return
+	}
+
 	var kv store.KV = store.NewInmem()
 
 	if len(cfg.Database) > 0 {
-		kv, err = store.NewLevelDB(cfg.Database)
+		kv, err = store.New(cfg.DatabaseBackend, cfg.Database)
 		if err != nil {
 			logger.Fatal().Err(err).Msgf("Failed to create/open database located at %q.", cfg.Database)
 		}
+
+		if err := wavelet.CheckDataVersion(kv); err != nil {
+			logger.Fatal().Err(err).Msgf("Refusing to open database located at %q.", cfg.Database)
+		}
 	}
 
-	ledger := wavelet.NewLedger(kv, client, cfg.Genesis)
+	instrumented := store.NewInstrumented(kv, metrics.NewRegistry())
+	instrumented.Publish("store")
+
+	kv = instrumented
+
+	if cfg.CacheSize > 0 {
+		kv = store.NewCache(kv,
+			store.WithCacheSize(cfg.CacheSize),
+			store.WithCacheFlushThreshold(cfg.CacheFlushThreshold),
+		)
+	}
+
+	opts := []wavelet.LedgerOption{wavelet.WithPeerStats(peers), wavelet.WithPeerBans(bans)}
+
+	if len(cfg.GenesisPath) > 0 {
+		genesis, err := wavelet.LoadGenesis(cfg.GenesisPath)
+		if err != nil {
+			logger.Fatal().Err(err).Msgf("Failed to load genesis file located at %q.", cfg.GenesisPath)
+		}
+
+		opts = append(opts, wavelet.WithGenesis(genesis))
+	}
+
+	if len(cfg.Upstream) > 0 {
+		opts = append(opts, wavelet.WithUpstream(cfg.Upstream))
+	}
+
+	if cfg.Archive {
+		opts = append(opts, wavelet.WithArchive())
+	}
+
+	var (
+		ledger  *wavelet.Ledger
+		gateway *api.Gateway
+	)
+
+	join := func() {
+		for _, addr := range cfg.Peers {
+			ledger.PeerDiversity().MarkOutbound(addr)
+
+			if _, err := client.Dial(addr); err != nil {
+				fmt.Printf("Error dialing %s: %v\n", addr, err)
+			}
+		}
+
+		saved, err := ledger.SavedPeers()
+		if err != nil {
+			logger.Warn().Err(err).Msg("Failed to load persisted peers.")
+		}
+
+		for _, addr := range saved {
+			ledger.PeerDiversity().MarkOutbound(addr)
+
+			if _, err := client.Dial(addr); err != nil {
+				fmt.Printf("Error dialing persisted peer %s: %v\n", addr, err)
+			}
+		}
+
+		if peers := client.Bootstrap(); len(peers) > 0 {
+			var ids []string
+
+			for _, id := range peers {
+				ids = append(ids, id.String())
+			}
+
+			logger.Info().Msgf("Bootstrapped with peers: %+v", ids)
+		}
+	}
+
+	if len(cfg.Standby) > 0 {
+		replicator, err := standbyReplicator(cfg.Host)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Failed to set up a client to replicate from the validator with.")
+		}
+
+		opts = append(opts, wavelet.WithStandby(cfg.Standby, replicator, join))
+	}
+
+	ledger = wavelet.NewLedger(kv, client, cfg.Genesis, opts...)
 
 	go func() {
-		server := client.Listen()
+		server := client.Listen(
+			grpc.StatsHandler(peers.ServerStatsHandler()),
+			grpc.MaxRecvMsgSize(sys.MaxMessageSize),
+			grpc.MaxSendMsgSize(sys.MaxMessageSize),
+		)
 
 		wavelet.RegisterWaveletServer(server, ledger.Protocol())
 
@@ -320,24 +736,53 @@ func start(cfg *Config) {
 		}
 	}()
 
-	for _, addr := range cfg.Peers {
-		if _, err := client.Dial(addr); err != nil {
-			fmt.Printf("Error dialing %s: %v\n", addr, err)
-		}
+	if len(cfg.Standby) == 0 {
+		join()
 	}
 
-	if peers := client.Bootstrap(); len(peers) > 0 {
-		var ids []string
+	go checkClock(peers)
 
-		for _, id := range peers {
-			ids = append(ids, id.String())
+	if cfg.APIPort > 0 {
+		opts := []api.Option{
+			api.WithMaxContractRequestBodySize(cfg.APIMaxContract),
+			api.WithGRPCPort(int(cfg.APIGRPCPort)),
+			api.WithAccessLogSampleRate(cfg.APIAccessLogSampleRate),
+			api.WithAccessLogDisabledRoutes(cfg.APIAccessLogDisabledRoutes...),
+			api.WithRateLimits(cfg.APIReadRateLimit, cfg.APISendRateLimit),
+			api.WithMaxWebsocketConnections(cfg.APIMaxWebsocketConnections, cfg.APIMaxWebsocketConnectionsPerIP),
+			api.WithWebsocketIdleTimeout(cfg.APIWebsocketIdleTimeout),
+			api.WithFaucet(cfg.FaucetAmount, cfg.FaucetCooldown),
 		}
 
-		logger.Info().Msgf("Bootstrapped with peers: %+v", ids)
-	}
+		for _, raw := range cfg.APIRouteLimits {
+			group, limits, err := api.ParseRouteLimits(raw)
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Failed to parse API route limits.")
+			}
 
-	if cfg.APIPort > 0 {
-		go api.New().StartHTTP(int(cfg.APIPort), client, ledger, keys)
+			opts = append(opts, api.WithRouteLimits(group, limits))
+		}
+
+		for _, raw := range cfg.APIKeys {
+			key, scopes, err := api.ParseAPIKey(raw)
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Failed to parse API key.")
+			}
+
+			opts = append(opts, api.WithAPIKey(key, scopes...))
+		}
+
+		if len(cfg.APITLSCert) > 0 {
+			opts = append(opts, api.WithTLS(cfg.APITLSCert, cfg.APITLSKey))
+		}
+
+		gateway = api.New(opts...)
+
+		gateway.PublishMetrics("api")
+
+		if _, err := gateway.StartHTTP(int(cfg.APIPort), client, ledger, keys); err != nil {
+			logger.Fatal().Err(err).Msg("Failed to start HTTP API server.")
+		}
 	}
 
 	shell, err := NewCLI(client, ledger, keys)
@@ -346,6 +791,68 @@ func start(cfg *Config) {
 	}
 
 	shell.Start()
+
+	if gateway != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.APIShutdownTimeout)
+		defer cancel()
+
+		if err := gateway.Shutdown(ctx); err != nil {
+			logger.Warn().Err(err).Msg("Failed to gracefully shut down the HTTP API server.")
+		}
+	}
+
+	ledger.Stop()
+
+	// Closing the store flushes all writes still pending in its cache.
+	if err := kv.Close(); err != nil {
+		logger.Warn().Err(err).Msg("Failed to close the database.")
+	}
+}
+
+// standbyReplicator returns a client holding a throwaway identity, listening
+// for peers on an ephemeral port on host, which a cold standby replicates
+// rounds from its validator with. The client of the standby itself may not be
+// used, as it holds the identity of the validator.
+func standbyReplicator(host string) (*skademlia.Client, error) {
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, "0"))
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := skademlia.NewKeys(sys.SKademliaC1, sys.SKademliaC2)
+	if err != nil {
+		return nil, err
+	}
+
+	addr := net.JoinHostPort(host, strconv.Itoa(listener.Addr().(*net.TCPAddr).Port))
+
+	client := skademlia.NewClient(
+		addr, keys,
+		skademlia.WithC1(sys.SKademliaC1),
+		skademlia.WithC2(sys.SKademliaC2),
+		skademlia.WithDialOptions(
+			grpc.WithDefaultCallOptions(
+				grpc.UseCompressor(snappy.Name),
+				grpc.MaxCallRecvMsgSize(sys.MaxMessageSize),
+				grpc.MaxCallSendMsgSize(sys.MaxMessageSize),
+			),
+		),
+	)
+
+	client.SetCredentials(noise.NewCredentials(addr, handshake.NewECDH(), cipher.NewAEAD(), client.Protocol()))
+
+	go func() {
+		server := client.Listen(
+			grpc.MaxRecvMsgSize(sys.MaxMessageSize),
+			grpc.MaxSendMsgSize(sys.MaxMessageSize),
+		)
+
+		if err := server.Serve(listener); err != nil {
+			panic(err)
+		}
+	}()
+
+	return client, nil
 }
 
 func keys(wallet string) (*skademlia.Keypair, error) {
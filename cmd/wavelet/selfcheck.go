@@ -0,0 +1,163 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"fmt"
+	"github.com/perlin-network/wavelet"
+	"github.com/perlin-network/wavelet/log"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/pkg/errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// validate checks that the configuration is coherent. All problems found are
+// reported at once, such that they may all be fixed in one go.
+func (cfg *Config) validate() error {
+	var problems []string
+
+	if cfg.Genesis != nil && len(cfg.GenesisPath) > 0 {
+		problems = append(problems, "only one of --genesis and --genesis.path may be specified")
+	}
+
+	if len(cfg.Upstream) > 0 && len(cfg.Standby) > 0 {
+		problems = append(problems, "only one of --upstream and --standby may be specified")
+	}
+
+	if (len(cfg.APITLSCert) > 0) != (len(cfg.APITLSKey) > 0) {
+		problems = append(problems, "both --api.tls.cert and --api.tls.key must be specified to serve the HTTP API over HTTPS")
+	}
+
+	if cfg.APIGRPCPort > 0 && cfg.APIPort == 0 {
+		problems = append(problems, "--api.grpc.port requires --api.port to be set")
+	}
+
+	ports := map[uint]string{}
+
+	for _, p := range []struct {
+		flag string
+		port uint
+	}{{"--port", cfg.Port}, {"--api.port", cfg.APIPort}, {"--api.grpc.port", cfg.APIGRPCPort}} {
+		if p.port == 0 {
+			continue
+		}
+
+		if flag, taken := ports[p.port]; taken {
+			problems = append(problems, fmt.Sprintf("%s must differ from %s, as both are set to port %d", p.flag, flag, p.port))
+			continue
+		}
+
+		ports[p.port] = p.flag
+	}
+
+	switch cfg.DatabaseBackend {
+	case store.BackendInmem, store.BackendLevelDB, store.BackendBolt:
+	default:
+		problems = append(problems, fmt.Sprintf("--db.backend must be one of inmem, leveldb, or bolt, but got %q", cfg.DatabaseBackend))
+	}
+
+	if cfg.CacheSize < 0 {
+		problems = append(problems, "--db.cache must not be negative")
+	}
+
+	if cfg.CacheFlushThreshold < 0 {
+		problems = append(problems, "--db.cache.flush must not be negative")
+	}
+
+	if len(problems) > 0 {
+		return errors.Errorf("invalid configuration: %s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// selfCheck validates the configuration and the environment the node is to
+// run in before the node starts, such that it refuses to start with an
+// actionable error rather than corrupt its database. Should the node be
+// configured to persist its database, the database is locked for as long as
+// the process runs.
+func selfCheck(cfg *Config) (*store.FileLock, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	if len(cfg.Database) == 0 || cfg.DatabaseBackend == store.BackendInmem {
+		return nil, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filepath.Clean(cfg.Database)), 0755); err != nil {
+		return nil, errors.Wrapf(err, "failed to create the directory the database %q is to be placed in", cfg.Database)
+	}
+
+	lock, err := store.Lock(cfg.Database)
+	if err != nil {
+		if errors.Cause(err) == store.ErrLocked {
+			return nil, errors.Wrapf(err, "database %q is already in use by another node: stop the other node, or point --db at another database", cfg.Database)
+		}
+
+		return nil, err
+	}
+
+	logger := log.Node()
+
+	available, err := store.FreeSpace(cfg.Database)
+
+	switch {
+	case err != nil:
+		logger.Warn().Err(err).Msgf("Failed to determine the disk space available for the database %q.", cfg.Database)
+	case available < sys.MinFreeDiskSpace:
+		_ = lock.Release()
+
+		return nil, errors.Errorf("only %d bytes of disk space are available for the database %q, but at least %d bytes are required: free up disk space, or point --db at another disk", available, cfg.Database, sys.MinFreeDiskSpace)
+	case available < sys.LowFreeDiskSpace:
+		logger.Warn().
+			Uint64("available", available).
+			Msgf("Running low on disk space for the database %q.", cfg.Database)
+	}
+
+	return lock, nil
+}
+
+// checkClock warns should our clock differ by more than sys.MaxClockOffset
+// from the median of the clocks of our peers, once they have had the chance
+// to respond to a round of pings. Consensus is unaffected by clock skew, but
+// the timestamps reported by the node are not.
+func checkClock(peers *wavelet.PeerStats) {
+	time.Sleep(sys.PingInterval + sys.PingTimeout)
+
+	offset, n := peers.ClockOffset()
+	if n == 0 {
+		return
+	}
+
+	if offset <= sys.MaxClockOffset && offset >= -sys.MaxClockOffset {
+		return
+	}
+
+	logger := log.Node()
+	logger.Warn().
+		Dur("offset", offset).
+		Int("num_peers", n).
+		Msg("Our clock differs from the median of the clocks of our peers. Synchronize it, for example through NTP.")
+}
@@ -28,11 +28,13 @@ import (
 	"github.com/perlin-network/noise/skademlia"
 	"github.com/perlin-network/wavelet"
 	"github.com/perlin-network/wavelet/log"
+	"github.com/perlin-network/wavelet/memo"
 	"github.com/perlin-network/wavelet/sys"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	"io"
 	"io/ioutil"
+	"os"
 	"strconv"
 	"strings"
 )
@@ -50,12 +52,24 @@ func NewCLI(client *skademlia.Client, ledger *wavelet.Ledger, keys *skademlia.Ke
 	completer := readline.NewPrefixCompleter(
 		readline.PcItem("l"), readline.PcItem("status"),
 		readline.PcItem("p"), readline.PcItem("pay"),
+		readline.PcItem("batch-pay"),
 		readline.PcItem("c"), readline.PcItem("call"),
 		readline.PcItem("f"), readline.PcItem("find"),
 		readline.PcItem("s"), readline.PcItem("spawn"),
 		readline.PcItem("ps"), readline.PcItem("place-stake"),
 		readline.PcItem("ws"), readline.PcItem("withdraw-stake"),
 		readline.PcItem("wr"), readline.PcItem("withdraw-reward"),
+		readline.PcItem("contract-admin",
+			readline.PcItem("pause"), readline.PcItem("resume"),
+			readline.PcItem("transfer"), readline.PcItem("quota"),
+		),
+		readline.PcItem("update-contract"),
+		readline.PcItem("create-token"), readline.PcItem("transfer-token"),
+		readline.PcItem("delegate",
+			readline.PcItem("grant"), readline.PcItem("revoke"),
+		),
+		readline.PcItem("backup"), readline.PcItem("restore"),
+		readline.PcItem("dump-genesis"),
 		readline.PcItem("help"),
 	)
 
@@ -119,6 +133,8 @@ func (cli *CLI) Start() {
 			cli.pay(toCMD(line, 2))
 		case strings.HasPrefix(line, "pay "):
 			cli.pay(toCMD(line, 4))
+		case strings.HasPrefix(line, "batch-pay "):
+			cli.batchPay(toCMD(line, 10))
 		case strings.HasPrefix(line, "c "):
 			cli.call(toCMD(line, 2))
 		case strings.HasPrefix(line, "call "):
@@ -143,6 +159,22 @@ func (cli *CLI) Start() {
 			cli.withdrawReward(toCMD(line, 3))
 		case strings.HasPrefix(line, "withdraw-reward "):
 			cli.withdrawReward(toCMD(line, 16))
+		case strings.HasPrefix(line, "contract-admin "):
+			cli.contractAdmin(toCMD(line, 15))
+		case strings.HasPrefix(line, "update-contract "):
+			cli.updateContract(toCMD(line, 16))
+		case strings.HasPrefix(line, "create-token "):
+			cli.createToken(toCMD(line, 13))
+		case strings.HasPrefix(line, "transfer-token "):
+			cli.transferToken(toCMD(line, 15))
+		case strings.HasPrefix(line, "delegate "):
+			cli.delegate(toCMD(line, 9))
+		case strings.HasPrefix(line, "backup "):
+			cli.backup(toCMD(line, 7))
+		case strings.HasPrefix(line, "restore "):
+			cli.restore(toCMD(line, 8))
+		case strings.HasPrefix(line, "dump-genesis "):
+			cli.dumpGenesis(toCMD(line, 13))
 		case line == "":
 			fallthrough
 		case line == "help":
@@ -178,6 +210,7 @@ func (cli *CLI) status() {
 	nonce, _ := wavelet.ReadAccountNonce(snapshot, publicKey)
 
 	round := cli.ledger.Rounds().Latest()
+	params := sys.Params()
 	rootDepth := cli.ledger.Graph().RootDepth()
 
 	peers := cli.client.ClosestPeerIDs()
@@ -188,14 +221,14 @@ func (cli *CLI) status() {
 	}
 
 	cli.logger.Info().
-		Uint8("difficulty", round.ExpectedDifficulty(sys.MinDifficulty, sys.DifficultyScaleFactor)).
+		Uint8("difficulty", round.ExpectedDifficulty(params.MinDifficulty, params.DifficultyScaleFactor)).
 		Uint64("round", round.Index).
 		Hex("root_id", round.End.ID[:]).
 		Uint64("height", cli.ledger.Graph().Height()).
 		Str("id", hex.EncodeToString(publicKey[:])).
-		Uint64("balance", balance).
-		Uint64("stake", stake).
-		Uint64("reward", reward).
+		Str("balance", wavelet.Amount(balance).String()).
+		Str("stake", wavelet.Amount(stake).String()).
+		Str("reward", wavelet.Amount(reward).String()).
 		Uint64("nonce", nonce).
 		Strs("peers", peerIDs).
 		Int("num_tx", cli.ledger.Graph().DepthLen(&rootDepth, nil)).
@@ -208,8 +241,8 @@ func (cli *CLI) status() {
 }
 
 func (cli *CLI) pay(cmd []string) {
-	if len(cmd) != 2 {
-		fmt.Println("pay <recipient> <amount>")
+	if len(cmd) < 2 {
+		fmt.Println("pay <recipient> <amount> [memo]")
 		return
 	}
 
@@ -224,49 +257,95 @@ func (cli *CLI) pay(cmd []string) {
 		return
 	}
 
-	amount, err := strconv.ParseUint(cmd[1], 10, 64)
+	amount, err := wavelet.ParseAmount(cmd[1])
 	if err != nil {
-		cli.logger.Error().Err(err).Msg("Failed to convert payment amount to a uint64.")
+		cli.logger.Error().Err(err).Msg("Failed to parse payment amount.")
 		return
 	}
 
 	snapshot := cli.ledger.Snapshot()
 
-	var recipientID wavelet.AccountID
-	copy(recipientID[:], recipient)
+	transfer := wavelet.Transfer{Amount: uint64(amount)}
+	copy(transfer.Recipient[:], recipient)
 
 	balance, _ := wavelet.ReadAccountBalance(snapshot, cli.keys.PublicKey())
-	_, codeAvailable := wavelet.ReadAccountContractCode(snapshot, recipientID)
+	_, codeAvailable := wavelet.ReadAccountContractCode(snapshot, transfer.Recipient)
 
-	if balance < amount {
-		cli.logger.Error().Uint64("your_balance", balance).Uint64("amount_to_send", amount).Msg("You do not have enough PERLs to send.")
+	if balance < uint64(amount) {
+		cli.logger.Error().Str("your_balance", wavelet.Amount(balance).String()).Str("amount_to_send", amount.String()).Msg("You do not have enough PERLs to send.")
 		return
 	}
 
-	payload := bytes.NewBuffer(nil)
-	payload.Write(recipient[:])
+	if codeAvailable {
+		transfer.GasLimit = balance // Set gas limit by default to the balance the user has.
+		transfer.FuncName = []byte("on_money_received")
+	}
 
-	var intBuf [8]byte
-	binary.LittleEndian.PutUint64(intBuf[:], amount)
-	payload.Write(intBuf[:])
+	if len(cmd) > 2 {
+		transfer.Memo, err = memo.Encrypt(transfer.Recipient, []byte(strings.Join(cmd[2:], " ")))
+		if err != nil {
+			cli.logger.Error().Err(err).Msg("Failed to encrypt memo.")
+			return
+		}
+	}
 
-	if codeAvailable {
-		binary.LittleEndian.PutUint64(intBuf[:], balance) // Set gas limit by default to the balance the user has.
-		payload.Write(intBuf[:])
+	tx, err := cli.sendTransaction(wavelet.NewTransaction(cli.keys, sys.TagTransfer, transfer.Marshal()))
+	if err != nil {
+		return
+	}
 
-		defaultFuncName := "on_money_received"
+	cli.logger.Info().Msgf("Success! Your payment transaction ID: %x", tx.ID)
+}
 
-		binary.LittleEndian.PutUint32(intBuf[:4], uint32(len(defaultFuncName)))
-		payload.Write(intBuf[:4])
-		payload.WriteString(defaultFuncName)
+func (cli *CLI) batchPay(cmd []string) {
+	if len(cmd) == 0 || len(cmd)%2 != 0 {
+		fmt.Println("batch-pay <recipient> <amount> [<recipient> <amount>...]")
+		return
 	}
 
-	tx, err := cli.sendTransaction(wavelet.NewTransaction(cli.keys, sys.TagTransfer, payload.Bytes()))
+	var batch wavelet.Batch
+	var total wavelet.Amount
+
+	for i := 0; i < len(cmd); i += 2 {
+		recipient, err := hex.DecodeString(cmd[i])
+		if err != nil || len(recipient) != wavelet.SizeAccountID {
+			cli.logger.Error().Err(err).Str("recipient", cmd[i]).Msg("A recipient you specified is invalid.")
+			return
+		}
+
+		amount, err := wavelet.ParseAmount(cmd[i+1])
+		if err != nil {
+			cli.logger.Error().Err(err).Msg("Failed to parse payment amount.")
+			return
+		}
+
+		if total, err = total.Add(amount); err != nil {
+			cli.logger.Error().Err(err).Msg("The total amount of PERLs to send is too large.")
+			return
+		}
+
+		transfer := wavelet.Transfer{Amount: uint64(amount)}
+		copy(transfer.Recipient[:], recipient)
+
+		if err := batch.Add(sys.TagTransfer, transfer.Marshal()); err != nil {
+			cli.logger.Error().Err(err).Msg("Failed to add payment to batch.")
+			return
+		}
+	}
+
+	balance, _ := wavelet.ReadAccountBalance(cli.ledger.Snapshot(), cli.keys.PublicKey())
+
+	if balance < uint64(total) {
+		cli.logger.Error().Str("your_balance", wavelet.Amount(balance).String()).Str("amount_to_send", total.String()).Msg("You do not have enough PERLs to send.")
+		return
+	}
+
+	tx, err := cli.sendTransaction(wavelet.NewTransaction(cli.keys, sys.TagBatch, batch.Marshal()))
 	if err != nil {
 		return
 	}
 
-	cli.logger.Info().Msgf("Success! Your payment transaction ID: %x", tx.ID)
+	cli.logger.Info().Msgf("Success! Your batch of %d payments has transaction ID: %x", batch.Size, tx.ID)
 }
 
 func (cli *CLI) call(cmd []string) {
@@ -299,9 +378,9 @@ func (cli *CLI) call(cmd []string) {
 		return
 	}
 
-	amount, err := strconv.ParseUint(cmd[1], 10, 64)
+	amount, err := wavelet.ParseAmount(cmd[1])
 	if err != nil {
-		cli.logger.Error().Err(err).Msg("Failed to convert payment amount to a uint64.")
+		cli.logger.Error().Err(err).Msg("Failed to parse payment amount.")
 		return
 	}
 
@@ -311,8 +390,14 @@ func (cli *CLI) call(cmd []string) {
 		return
 	}
 
-	if balance < amount+gasLimit {
-		cli.logger.Error().Uint64("your_balance", balance).Uint64("cost", amount+gasLimit).Msg("You do not have enough PERLs to pay for the costs to invoke the smart contract function you wanted.")
+	cost, err := amount.Add(wavelet.Amount(gasLimit))
+	if err != nil {
+		cli.logger.Error().Err(err).Msg("The costs to invoke the smart contract function you wanted are too large.")
+		return
+	}
+
+	if balance < uint64(cost) {
+		cli.logger.Error().Str("your_balance", wavelet.Amount(balance).String()).Str("cost", cost.String()).Msg("You do not have enough PERLs to pay for the costs to invoke the smart contract function you wanted.")
 		return
 	}
 
@@ -326,7 +411,7 @@ func (cli *CLI) call(cmd []string) {
 	payload.Write(recipient[:])
 
 	// Amount to send.
-	binary.LittleEndian.PutUint64(intBuf[:8], amount)
+	binary.LittleEndian.PutUint64(intBuf[:8], uint64(amount))
 	payload.Write(intBuf[:8])
 
 	// Gas limit.
@@ -436,10 +521,10 @@ func (cli *CLI) find(cmd []string) {
 
 	if balance > 0 || stake > 0 || nonce > 0 || isContract || numPages > 0 {
 		cli.logger.Info().
-			Uint64("balance", balance).
-			Uint64("stake", stake).
+			Str("balance", wavelet.Amount(balance).String()).
+			Str("stake", wavelet.Amount(stake).String()).
 			Uint64("nonce", nonce).
-			Uint64("reward", reward).
+			Str("reward", wavelet.Amount(reward).String()).
 			Bool("is_contract", isContract).
 			Uint64("num_pages", numPages).
 			Msgf("Account: %s", cmd[0])
@@ -458,7 +543,18 @@ func (cli *CLI) find(cmd []string) {
 			parents = append(parents, hex.EncodeToString(parentID[:]))
 		}
 
-		cli.logger.Info().
+		event := cli.logger.Info()
+
+		// Memos may only be read by the recipient of a transfer.
+		if tx.Tag == sys.TagTransfer {
+			if transfer, err := wavelet.ParseTransferTransaction(tx.Payload); err == nil && transfer.Recipient == cli.keys.PublicKey() && len(transfer.Memo) > 0 {
+				if note, err := memo.Decrypt(cli.keys.PrivateKey(), transfer.Memo); err == nil {
+					event = event.Str("memo", string(note))
+				}
+			}
+		}
+
+		event.
 			Strs("parents", parents).
 			Hex("sender", tx.Sender[:]).
 			Hex("creator", tx.Creator[:]).
@@ -510,15 +606,84 @@ func (cli *CLI) spawn(cmd []string) {
 	cli.logger.Info().Msgf("Success! Your smart contracts ID: %x", tx.ID)
 }
 
+func (cli *CLI) createToken(cmd []string) {
+	if len(cmd) != 3 {
+		fmt.Println("create-token <symbol> <supply> <decimals>")
+		return
+	}
+
+	supply, err := strconv.ParseUint(cmd[1], 10, 64)
+	if err != nil {
+		cli.logger.Error().Err(err).Msg("Failed to convert token supply to a uint64.")
+		return
+	}
+
+	decimals, err := strconv.ParseUint(cmd[2], 10, 8)
+	if err != nil {
+		cli.logger.Error().Err(err).Msg("Failed to convert token decimals to a uint8.")
+		return
+	}
+
+	params := wavelet.CreateToken{Supply: supply, Decimals: uint8(decimals), Symbol: cmd[0]}
+
+	if _, err := wavelet.ParseCreateTokenTransaction(params.Marshal()); err != nil {
+		cli.logger.Error().Err(err).Msg("You have specified an invalid token.")
+		return
+	}
+
+	tx, err := cli.sendTransaction(wavelet.NewTransaction(cli.keys, sys.TagCreateToken, params.Marshal()))
+	if err != nil {
+		return
+	}
+
+	cli.logger.Info().Msgf("Success! Your token ID: %x", tx.ID)
+}
+
+func (cli *CLI) transferToken(cmd []string) {
+	if len(cmd) != 3 {
+		fmt.Println("transfer-token <token-id> <recipient> <amount>")
+		return
+	}
+
+	tokenID, err := hex.DecodeString(cmd[0])
+	if err != nil || len(tokenID) != wavelet.SizeTransactionID {
+		cli.logger.Error().Err(err).Msg("The token ID you specified is invalid.")
+		return
+	}
+
+	recipient, err := hex.DecodeString(cmd[1])
+	if err != nil || len(recipient) != wavelet.SizeAccountID {
+		cli.logger.Error().Err(err).Msg("The recipient you specified is invalid.")
+		return
+	}
+
+	amount, err := strconv.ParseUint(cmd[2], 10, 64)
+	if err != nil {
+		cli.logger.Error().Err(err).Msg("Failed to convert token amount to a uint64.")
+		return
+	}
+
+	params := wavelet.TransferToken{Amount: amount}
+	copy(params.TokenID[:], tokenID)
+	copy(params.Recipient[:], recipient)
+
+	tx, err := cli.sendTransaction(wavelet.NewTransaction(cli.keys, sys.TagTransferToken, params.Marshal()))
+	if err != nil {
+		return
+	}
+
+	cli.logger.Info().Msgf("Success! Your token transfer transaction ID: %x", tx.ID)
+}
+
 func (cli *CLI) placeStake(cmd []string) {
 	if len(cmd) != 1 {
 		fmt.Println("place-stake <amount>")
 		return
 	}
 
-	amount, err := strconv.Atoi(cmd[0])
+	amount, err := wavelet.ParseAmount(cmd[0])
 	if err != nil {
-		cli.logger.Error().Err(err).Msg("Failed to convert staking amount to a uint64.")
+		cli.logger.Error().Err(err).Msg("Failed to parse staking amount.")
 		return
 	}
 
@@ -543,9 +708,9 @@ func (cli *CLI) withdrawStake(cmd []string) {
 		return
 	}
 
-	amount, err := strconv.ParseUint(cmd[0], 10, 64)
+	amount, err := wavelet.ParseAmount(cmd[0])
 	if err != nil {
-		cli.logger.Error().Err(err).Msg("Failed to convert withdraw amount to an uint64.")
+		cli.logger.Error().Err(err).Msg("Failed to parse withdraw amount.")
 		return
 	}
 
@@ -570,9 +735,9 @@ func (cli *CLI) withdrawReward(cmd []string) {
 		return
 	}
 
-	amount, err := strconv.ParseUint(cmd[0], 10, 64)
+	amount, err := wavelet.ParseAmount(cmd[0])
 	if err != nil {
-		cli.logger.Error().Err(err).Msg("Failed to convert withdraw amount to an uint64.")
+		cli.logger.Error().Err(err).Msg("Failed to parse withdraw amount.")
 		return
 	}
 
@@ -591,6 +756,278 @@ func (cli *CLI) withdrawReward(cmd []string) {
 		Msgf("Success! Your reward withdrawal transaction ID: %x", tx.ID)
 }
 
+func (cli *CLI) contractAdmin(cmd []string) {
+	usage := "contract-admin pause <smart-contract-address>\n" +
+		"contract-admin resume <smart-contract-address>\n" +
+		"contract-admin transfer <new-owner> <smart-contract-address>\n" +
+		"contract-admin quota <calls-per-round> <smart-contract-address>"
+
+	if len(cmd) < 2 {
+		fmt.Println(usage)
+		return
+	}
+
+	payload := bytes.NewBuffer(nil)
+
+	var op byte
+	var arg []byte
+
+	switch cmd[0] {
+	case "pause", "resume":
+		if len(cmd) != 2 {
+			fmt.Println(usage)
+			return
+		}
+
+		op = sys.PauseContract
+		if cmd[0] == "resume" {
+			op = sys.ResumeContract
+		}
+	case "transfer":
+		if len(cmd) != 3 {
+			fmt.Println(usage)
+			return
+		}
+
+		owner, err := hex.DecodeString(cmd[1])
+		if err != nil || len(owner) != wavelet.SizeAccountID {
+			cli.logger.Error().Err(err).Msg("The new owner you specified is not a valid account ID.")
+			return
+		}
+
+		op, arg = sys.TransferContractOwnership, owner
+	case "quota":
+		if len(cmd) != 3 {
+			fmt.Println(usage)
+			return
+		}
+
+		quota, err := strconv.ParseUint(cmd[1], 10, 64)
+		if err != nil {
+			cli.logger.Error().Err(err).Msg("Failed to convert call quota to a uint64.")
+			return
+		}
+
+		var intBuf [8]byte
+		binary.LittleEndian.PutUint64(intBuf[:8], quota)
+
+		op, arg = sys.SetContractCallQuota, intBuf[:8]
+	default:
+		fmt.Println(usage)
+		return
+	}
+
+	contract, err := hex.DecodeString(cmd[len(cmd)-1])
+	if err != nil || len(contract) != wavelet.SizeTransactionID {
+		cli.logger.Error().Err(err).Msg("The smart contract address you specified is invalid.")
+		return
+	}
+
+	payload.Write(contract)
+	payload.WriteByte(op)
+	payload.Write(arg)
+
+	tx, err := cli.sendTransaction(wavelet.NewTransaction(cli.keys, sys.TagContractAdmin, payload.Bytes()))
+	if err != nil {
+		return
+	}
+
+	cli.logger.Info().
+		Msgf("Success! Your contract administration transaction ID: %x", tx.ID)
+}
+
+func (cli *CLI) updateContract(cmd []string) {
+	if len(cmd) != 2 {
+		fmt.Println("update-contract <smart-contract-address> <path-to-smart-contract>")
+		return
+	}
+
+	contract, err := hex.DecodeString(cmd[0])
+	if err != nil || len(contract) != wavelet.SizeTransactionID {
+		cli.logger.Error().Err(err).Msg("The smart contract address you specified is invalid.")
+		return
+	}
+
+	code, err := ioutil.ReadFile(cmd[1])
+	if err != nil {
+		cli.logger.Error().
+			Err(err).
+			Str("path", cmd[1]).
+			Msg("Failed to find/load the smart contract code from the given path.")
+		return
+	}
+
+	tx, err := cli.sendTransaction(wavelet.NewTransaction(cli.keys, sys.TagUpdateContract, append(contract, code...)))
+	if err != nil {
+		return
+	}
+
+	cli.logger.Info().
+		Msgf("Success! Your contract update transaction ID: %x", tx.ID)
+}
+
+// delegableTags maps the names of tags of transactions a session key may be
+// granted a delegation to make to their tags.
+var delegableTags = map[string]byte{
+	"nop":             sys.TagNop,
+	"transfer":        sys.TagTransfer,
+	"contract":        sys.TagContract,
+	"stake":           sys.TagStake,
+	"contract_admin":  sys.TagContractAdmin,
+	"update_contract": sys.TagUpdateContract,
+}
+
+func (cli *CLI) delegate(cmd []string) {
+	usage := "delegate grant <session-key> <max-spend-per-round> <tag,tag,...> <expiry-round>\n" +
+		"delegate revoke <session-key>"
+
+	if len(cmd) < 2 {
+		fmt.Println(usage)
+		return
+	}
+
+	key, err := hex.DecodeString(cmd[1])
+	if err != nil || len(key) != wavelet.SizeAccountID {
+		cli.logger.Error().Err(err).Msg("The session key you specified is not a valid account ID.")
+		return
+	}
+
+	payload := bytes.NewBuffer(nil)
+
+	switch cmd[0] {
+	case "grant":
+		if len(cmd) != 5 {
+			fmt.Println(usage)
+			return
+		}
+
+		var delegation wavelet.Delegation
+
+		maxSpend, err := wavelet.ParseAmount(cmd[2])
+		if err != nil {
+			cli.logger.Error().Err(err).Msg("Failed to parse max spend per round.")
+			return
+		}
+
+		delegation.MaxSpend = uint64(maxSpend)
+
+		for _, name := range strings.Split(cmd[3], ",") {
+			tag, exists := delegableTags[name]
+			if !exists {
+				cli.logger.Error().Msgf("Transactions tagged %q may not be delegated.", name)
+				return
+			}
+
+			delegation.AllowedTags |= 1 << tag
+		}
+
+		delegation.Expiry, err = strconv.ParseUint(cmd[4], 10, 64)
+		if err != nil {
+			cli.logger.Error().Err(err).Msg("Failed to convert expiry round to a uint64.")
+			return
+		}
+
+		payload.WriteByte(sys.GrantDelegation)
+		payload.Write(key)
+		payload.Write(delegation.Marshal())
+	case "revoke":
+		if len(cmd) != 2 {
+			fmt.Println(usage)
+			return
+		}
+
+		payload.WriteByte(sys.RevokeDelegation)
+		payload.Write(key)
+	default:
+		fmt.Println(usage)
+		return
+	}
+
+	tx, err := cli.sendTransaction(wavelet.NewTransaction(cli.keys, sys.TagDelegate, payload.Bytes()))
+	if err != nil {
+		return
+	}
+
+	cli.logger.Info().
+		Msgf("Success! Your delegation transaction ID: %x", tx.ID)
+}
+
+func (cli *CLI) backup(cmd []string) {
+	if len(cmd) != 1 {
+		fmt.Println("backup <path-to-backup>")
+		return
+	}
+
+	f, err := os.Create(cmd[0])
+	if err != nil {
+		cli.logger.Error().Err(err).Str("path", cmd[0]).Msg("Failed to create the backup file.")
+		return
+	}
+
+	defer f.Close()
+
+	if err := cli.ledger.Backup(f); err != nil {
+		cli.logger.Error().Err(err).Str("path", cmd[0]).Msg("Failed to backup the ledger.")
+		return
+	}
+
+	cli.logger.Info().Str("path", cmd[0]).Msg("Success! The ledger has been backed up.")
+}
+
+func (cli *CLI) restore(cmd []string) {
+	if len(cmd) != 1 {
+		fmt.Println("restore <path-to-backup>")
+		return
+	}
+
+	f, err := os.Open(cmd[0])
+	if err != nil {
+		cli.logger.Error().Err(err).Str("path", cmd[0]).Msg("Failed to open the backup file.")
+		return
+	}
+
+	defer f.Close()
+
+	if err := cli.ledger.Restore(f); err != nil {
+		cli.logger.Error().Err(err).Str("path", cmd[0]).Msg("Failed to restore the ledger from backup.")
+		return
+	}
+
+	round := cli.ledger.Rounds().Latest()
+
+	cli.logger.Info().
+		Uint64("round", round.Index).
+		Hex("merkle_root", round.Merkle[:]).
+		Msg("Success! The ledger has been restored from backup.")
+}
+
+func (cli *CLI) dumpGenesis(cmd []string) {
+	if len(cmd) != 1 {
+		fmt.Println("dump-genesis <path-to-genesis>")
+		return
+	}
+
+	f, err := os.Create(cmd[0])
+	if err != nil {
+		cli.logger.Error().Err(err).Str("path", cmd[0]).Msg("Failed to create the genesis file.")
+		return
+	}
+
+	defer f.Close()
+
+	round := cli.ledger.Rounds().Latest()
+
+	if err := cli.ledger.DumpGenesis(round.Index, f); err != nil {
+		cli.logger.Error().Err(err).Str("path", cmd[0]).Msg("Failed to dump the ledger state as a genesis file.")
+		return
+	}
+
+	cli.logger.Info().
+		Uint64("round", round.Index).
+		Str("path", cmd[0]).
+		Msg("Success! The ledger state has been dumped as a genesis file.")
+}
+
 func (cli *CLI) sendTransaction(tx wavelet.Transaction) (wavelet.Transaction, error) {
 	tx = wavelet.AttachSenderToTransaction(cli.keys, tx, cli.ledger.Graph().FindEligibleParents()...)
 
@@ -24,6 +24,8 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"github.com/perlin-network/noise/edwards25519"
+	"github.com/perlin-network/wavelet"
 	"github.com/perlin-network/wavelet/sys"
 	"github.com/perlin-network/wavelet/wctl"
 	"github.com/pkg/errors"
@@ -36,11 +38,17 @@ import (
 )
 
 var tagConversion = map[string]byte{
-	`nop`:      sys.TagNop,
-	`transfer`: sys.TagTransfer,
-	`contract`: sys.TagContract,
-	`batch`:    sys.TagBatch,
-	`stake`:    sys.TagStake,
+	`nop`:             sys.TagNop,
+	`transfer`:        sys.TagTransfer,
+	`contract`:        sys.TagContract,
+	`batch`:           sys.TagBatch,
+	`stake`:           sys.TagStake,
+	`contract_admin`:  sys.TagContractAdmin,
+	`delegate`:        sys.TagDelegate,
+	`delegated`:       sys.TagDelegated,
+	`update_contract`: sys.TagUpdateContract,
+	`create_token`:    sys.TagCreateToken,
+	`transfer_token`:  sys.TagTransferToken,
 }
 
 func main() {
@@ -70,6 +78,10 @@ func main() {
 			Name:  "api.port",
 			Usage: "Port a local HTTP API.",
 		},
+		cli.StringFlag{
+			Name:  "api.key",
+			Usage: "API key to present to the local HTTP API, should it require one.",
+		},
 		cli.StringFlag{
 			Name:  "key",
 			Usage: "Private key hex-encoded",
@@ -372,6 +384,32 @@ func main() {
 				return nil
 			},
 		},
+		{
+			Name:      "get_accounts",
+			Usage:     "get several accounts at once",
+			ArgsUsage: "<account IDs...>",
+			Flags:     commonFlags,
+			Action: func(c *cli.Context) error {
+				client, err := setup(c)
+				if err != nil {
+					return err
+				}
+
+				res, err := client.GetAccounts(c.Args())
+				if err != nil {
+					return err
+				}
+
+				buf, err := json.Marshal(res)
+				if err != nil {
+					fmt.Println(err)
+				} else {
+					output(buf)
+				}
+
+				return nil
+			},
+		},
 		{
 			Name:      "get_contract_code",
 			Usage:     "get the payload of a contract",
@@ -430,6 +468,63 @@ func main() {
 				return nil
 			},
 		},
+		{
+			Name:      "get_contract_events",
+			Usage:     "get the events emitted by a contract",
+			ArgsUsage: "<contract ID>",
+			Flags: append(commonFlags,
+				[]cli.Flag{
+					cli.Uint64Flag{
+						Name:  "offset",
+						Usage: "index of the first event to list",
+					},
+					cli.Uint64Flag{
+						Name:  "limit",
+						Usage: "max number of events to list",
+					},
+					cli.StringFlag{
+						Name:  "tx_id",
+						Usage: "only list events emitted on behalf of this transaction",
+					},
+					cli.StringFlag{
+						Name:  "topic",
+						Usage: "only list events under this topic",
+					},
+				}...,
+			),
+			Action: func(c *cli.Context) error {
+				client, err := setup(c)
+				if err != nil {
+					return err
+				}
+				contractID := c.Args().Get(0)
+
+				// get these optional variables
+				var txID, topic *string
+				if len(c.String("tx_id")) > 0 {
+					tmp := c.String("tx_id")
+					txID = &tmp
+				}
+				if len(c.String("topic")) > 0 {
+					tmp := c.String("topic")
+					topic = &tmp
+				}
+
+				res, err := client.GetContractEvents(contractID, c.Uint64("offset"), c.Uint64("limit"), txID, topic)
+				if err != nil {
+					return err
+				}
+
+				buf, err := json.Marshal(res)
+				if err != nil {
+					fmt.Println(err)
+				} else {
+					output(buf)
+				}
+
+				return nil
+			},
+		},
 		{
 			Name:      "send_transaction",
 			Usage:     "send a transaction",
@@ -440,6 +535,10 @@ func main() {
 						Name:  "payload",
 						Usage: "the path to the payload file",
 					},
+					cli.Uint64Flag{
+						Name:  "valid_until_view",
+						Usage: "index of the last round the transaction may be applied in; never expires if 0",
+					},
 				}...,
 			),
 			Action: func(c *cli.Context) error {
@@ -462,7 +561,7 @@ func main() {
 					}
 				}
 
-				res, err := client.SendTransaction(byte(tag), []byte(payload))
+				res, err := client.SendExpiringTransaction(byte(tag), []byte(payload), c.Uint64("valid_until_view"))
 				if err != nil {
 					return err
 				}
@@ -477,6 +576,76 @@ func main() {
 				return nil
 			},
 		},
+		{
+			Name:      "send_transfer",
+			Usage:     "send PERLs, optionally with a memo only the recipient may read",
+			ArgsUsage: "<recipient> <amount of PERLs, such as 0.5> [memo]",
+			Flags:     commonFlags,
+			Action: func(c *cli.Context) error {
+				client, err := setup(c)
+				if err != nil {
+					return err
+				}
+
+				buf, err := hex.DecodeString(c.Args().Get(0))
+				if err != nil {
+					return err
+				}
+
+				var recipient edwards25519.PublicKey
+
+				if len(buf) != len(recipient) {
+					return errors.Errorf("recipient must be %d bytes long", len(recipient))
+				}
+
+				copy(recipient[:], buf)
+
+				amount, err := wavelet.ParseAmount(c.Args().Get(1))
+				if err != nil {
+					return err
+				}
+
+				res, err := client.SendTransfer(recipient, uint64(amount), []byte(c.Args().Get(2)))
+				if err != nil {
+					return err
+				}
+
+				buf, err = json.Marshal(res)
+				if err != nil {
+					fmt.Println(err)
+				} else {
+					output(buf)
+				}
+
+				return nil
+			},
+		},
+		{
+			Name:      "decrypt_memo",
+			Usage:     "decrypt the memo attached to a transfer sent to you",
+			ArgsUsage: "<transaction ID>",
+			Flags:     commonFlags,
+			Action: func(c *cli.Context) error {
+				client, err := setup(c)
+				if err != nil {
+					return err
+				}
+
+				tx, err := client.GetTransaction(c.Args().Get(0))
+				if err != nil {
+					return err
+				}
+
+				note, err := client.DecryptMemo(tx)
+				if err != nil {
+					return err
+				}
+
+				fmt.Println(string(note))
+
+				return nil
+			},
+		},
 		{
 			Name:      "get_transaction",
 			Usage:     "get a transaction",
@@ -517,6 +686,11 @@ func main() {
 						Name:  "creator_id",
 						Usage: "creator id of transactions to list (default: all)",
 					},
+					cli.IntFlag{
+						Name:  "tag",
+						Value: -1,
+						Usage: "tag of transactions to list (default: all)",
+					},
 					cli.IntFlag{
 						Name:  "offset",
 						Usage: "an offset of the number of transactions to list",
@@ -536,6 +710,7 @@ func main() {
 				// get these optional variables
 				var senderID *string
 				var creatorID *string
+				var tag *byte
 				var offset *uint64
 				var limit *uint64
 				if len(c.String("sender_id")) > 0 {
@@ -546,6 +721,10 @@ func main() {
 					tmp := c.String("creator_id")
 					creatorID = &tmp
 				}
+				if c.Int("tag") >= 0 {
+					tmp := byte(c.Int("tag"))
+					tag = &tmp
+				}
 				if c.Uint("offset") > 0 {
 					tmp := uint64(c.Uint("offset"))
 					offset = &tmp
@@ -555,7 +734,7 @@ func main() {
 					limit = &tmp
 				}
 
-				res, err := client.ListTransactions(senderID, creatorID, offset, limit)
+				res, err := client.ListTransactions(senderID, creatorID, tag, offset, limit)
 				if err != nil {
 					return err
 				}
@@ -636,6 +815,7 @@ func setup(c *cli.Context) (*wctl.Client, error) {
 		APIHost:  host,
 		APIPort:  uint16(port),
 		UseHTTPS: false,
+		APIKey:   c.String("api.key"),
 	}
 	copy(config.PrivateKey[:], rawPrivateKey)
 
@@ -0,0 +1,386 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Package contract is an SDK for writing Wavelet smart contracts in Go. Smart
+// contracts are built into WebAssembly modules with TinyGo, using the script
+// scripts/build-contract.sh, and may only import the host functions wavelet
+// provides to smart contracts, which this package binds to.
+//
+// A smart contract exports each of its functions under the name of the
+// function prefixed by _contract_. The constructor is exported as
+// _contract_init, and is invoked exactly once as the contract is spawned:
+//
+//	//export _contract_init
+//	func initialize() {
+//		params := contract.LoadParameters()
+//		owner = params.Sender
+//	}
+//
+// The memory of a smart contract, including its package-level variables, is
+// persisted after every invocation which did not report an error through
+// Error. Outside of WebAssembly, functions which call into the host panic with
+// ErrNotWebAssembly, though payloads may still be parsed and encoded.
+package contract
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Sizes of the identifiers passed to smart contracts in bytes.
+const (
+	SizeAccountID     = 32
+	SizeTransactionID = 32
+	SizeRoundID       = 32
+)
+
+// Tags of transactions a smart contract may queue up, matching those in
+// package sys.
+const (
+	TagTransfer byte = 1
+	TagContract byte = 2
+	TagStake    byte = 3
+)
+
+var (
+	ErrNotWebAssembly = errors.New("contract: host functions are only available to smart contracts compiled to WebAssembly")
+	ErrUnexpectedEOF  = errors.New("contract: parameters ended unexpectedly")
+)
+
+type (
+	AccountID     [SizeAccountID]byte
+	TransactionID [SizeTransactionID]byte
+)
+
+type hashFunc byte
+
+const (
+	hashBlake2b256 hashFunc = iota
+	hashBlake2b512
+	hashSHA256
+	hashSHA512
+)
+
+// Parameters is the payload a smart contract function is invoked with. It
+// describes the transaction which invoked the function, and reads the
+// parameters the function was invoked with in order.
+type Parameters struct {
+	RoundIndex    uint64
+	RoundID       [SizeRoundID]byte
+	TransactionID TransactionID
+	Sender        AccountID // The creator of the transaction which invoked the function.
+	Amount        uint64    // PERLs sent to the smart contract alongside the invocation.
+
+	buf []byte
+	err error
+}
+
+// ParseParameters parses the payload a smart contract function is invoked
+// with.
+func ParseParameters(payload []byte) (*Parameters, error) {
+	if len(payload) < 8+SizeRoundID+SizeTransactionID+SizeAccountID+8 {
+		return nil, ErrUnexpectedEOF
+	}
+
+	p := new(Parameters)
+
+	p.RoundIndex = binary.LittleEndian.Uint64(payload[:8])
+	payload = payload[8:]
+
+	payload = payload[copy(p.RoundID[:], payload):]
+	payload = payload[copy(p.TransactionID[:], payload):]
+	payload = payload[copy(p.Sender[:], payload):]
+
+	p.Amount = binary.LittleEndian.Uint64(payload[:8])
+	p.buf = payload[8:]
+
+	return p, nil
+}
+
+// LoadParameters loads the payload the smart contract function currently
+// being invoked was invoked with.
+func LoadParameters() *Parameters {
+	p, err := ParseParameters(payload())
+	if err != nil {
+		panic(err)
+	}
+
+	return p
+}
+
+// Err returns the first error encountered reading parameters. Once an error
+// is encountered, all further reads return zero values.
+func (p *Parameters) Err() error {
+	return p.err
+}
+
+// Remaining returns the parameters which have yet to be read.
+func (p *Parameters) Remaining() []byte {
+	return p.buf
+}
+
+func (p *Parameters) next(n int) []byte {
+	if p.err != nil {
+		return nil
+	}
+
+	if n < 0 || n > len(p.buf) {
+		p.err = ErrUnexpectedEOF
+		p.buf = nil
+
+		return nil
+	}
+
+	b := p.buf[:n]
+	p.buf = p.buf[n:]
+
+	return b
+}
+
+func (p *Parameters) ReadBool() bool {
+	b := p.next(1)
+	return b != nil && b[0] != 0
+}
+
+func (p *Parameters) ReadUint8() uint8 {
+	if b := p.next(1); b != nil {
+		return b[0]
+	}
+
+	return 0
+}
+
+func (p *Parameters) ReadUint16() uint16 {
+	if b := p.next(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+
+	return 0
+}
+
+func (p *Parameters) ReadUint32() uint32 {
+	if b := p.next(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+
+	return 0
+}
+
+func (p *Parameters) ReadUint64() uint64 {
+	if b := p.next(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+
+	return 0
+}
+
+func (p *Parameters) ReadInt64() int64 {
+	return int64(p.ReadUint64())
+}
+
+// ReadBytes reads a byte slice prefixed by its length as a uint32.
+func (p *Parameters) ReadBytes() []byte {
+	n := p.ReadUint32()
+
+	if b := p.next(int(n)); b != nil {
+		return append([]byte(nil), b...)
+	}
+
+	return nil
+}
+
+// ReadString reads a string prefixed by its length as a uint32.
+func (p *Parameters) ReadString() string {
+	return string(p.ReadBytes())
+}
+
+func (p *Parameters) ReadAccountID() AccountID {
+	var id AccountID
+	copy(id[:], p.next(SizeAccountID))
+
+	return id
+}
+
+// Result reports msg as the result of the smart contract function currently
+// being invoked, without reverting the changes it made.
+func Result(msg []byte) {
+	result(msg)
+}
+
+// Error reports err as the result of the smart contract function currently
+// being invoked. All changes made by the invocation are reverted, and no
+// transactions it queued up are applied.
+func Error(err error) {
+	result([]byte(err.Error()))
+}
+
+// Log logs msg on nodes which run with debug logging enabled.
+func Log(msg string) {
+	log(msg)
+}
+
+// SendTransaction queues up a transaction made by the smart contract, which
+// is applied once the smart contract function finishes executing.
+func SendTransaction(tag byte, payload []byte) {
+	sendTransaction(tag, payload)
+}
+
+// Transfer queues up a transfer of amount PERLs from the smart contract to
+// recipient.
+func Transfer(recipient AccountID, amount uint64) {
+	SendTransaction(TagTransfer, TransferPayload(recipient, amount, 0, "", nil))
+}
+
+// Invoke queues up a transfer of amount PERLs to the smart contract recipient,
+// invoking its function name with params. The gas limit of the invocation is
+// carried over from what is left of the gas limit of the current invocation.
+func Invoke(recipient AccountID, amount uint64, name string, params []byte) {
+	SendTransaction(TagTransfer, TransferPayload(recipient, amount, 0, name, params))
+}
+
+// TransferPayload encodes the payload of a transfer transaction. Should name
+// be empty, no function is invoked and params are ignored.
+func TransferPayload(recipient AccountID, amount, gasLimit uint64, name string, params []byte) []byte {
+	buf := make([]byte, 0, SizeAccountID+8+8+4+len(name)+4+len(params))
+
+	buf = append(buf, recipient[:]...)
+	buf = appendUint64(buf, amount)
+
+	if len(name) == 0 {
+		return buf
+	}
+
+	buf = appendUint64(buf, gasLimit)
+	buf = appendUint32(buf, uint32(len(name)))
+	buf = append(buf, name...)
+	buf = appendUint32(buf, uint32(len(params)))
+	buf = append(buf, params...)
+
+	return buf
+}
+
+// TransferEntry is a single recipient of PERLs sent through SendTransfers.
+type TransferEntry struct {
+	Recipient AccountID
+	Amount    uint64
+}
+
+// SendTransfers queues up a transfer to each entry, provided that they add up
+// to no more than limit PERLs. It returns false, and queues up no transfers,
+// should they not.
+func SendTransfers(entries []TransferEntry, limit uint64) bool {
+	return sendTransfers(EncodeTransferEntries(entries), limit)
+}
+
+// EncodeTransferEntries packs entries as expected by the _send_transfers host
+// function.
+func EncodeTransferEntries(entries []TransferEntry) []byte {
+	buf := make([]byte, 0, len(entries)*(SizeAccountID+8))
+
+	for _, entry := range entries {
+		buf = append(buf, entry.Recipient[:]...)
+		buf = appendUint64(buf, entry.Amount)
+	}
+
+	return buf
+}
+
+// EmitEvent emits an event under topic for dApps to observe. It returns false
+// should the event not be emitted, as its topic is empty, its topic or payload
+// is too large, or too many events were emitted by the invocation.
+func EmitEvent(topic string, payload []byte) bool {
+	return emitEvent(topic, payload)
+}
+
+// Balance returns the balance of the account id in PERLs.
+func Balance(id AccountID) uint64 {
+	return balance(id)
+}
+
+// Stake returns the stake of the account id in PERLs.
+func Stake(id AccountID) uint64 {
+	return stake(id)
+}
+
+// RandomBeacon returns the random beacon of the round prior.
+func RandomBeacon() [32]byte {
+	return randomBeacon()
+}
+
+// Random draws a seed which every node derives identically. Every draw made
+// by an invocation yields a different seed.
+func Random() [32]byte {
+	return random()
+}
+
+// VerifyEd25519 returns whether or not sig is a valid signature of data by
+// the public key key, verified natively by the node at a fixed cost of gas.
+func VerifyEd25519(key [32]byte, data []byte, sig [64]byte) bool {
+	return verifyEd25519(key, data, sig)
+}
+
+// Blake2b256 returns the BLAKE2b-256 digest of data, computed natively by the
+// node at a fixed cost of gas.
+func Blake2b256(data []byte) [32]byte {
+	var out [32]byte
+	hash(hashBlake2b256, data, out[:])
+
+	return out
+}
+
+// Blake2b512 returns the BLAKE2b-512 digest of data, computed natively by the
+// node at a fixed cost of gas.
+func Blake2b512(data []byte) [64]byte {
+	var out [64]byte
+	hash(hashBlake2b512, data, out[:])
+
+	return out
+}
+
+// SHA256 returns the SHA-256 digest of data, computed natively by the node at
+// a fixed cost of gas.
+func SHA256(data []byte) [32]byte {
+	var out [32]byte
+	hash(hashSHA256, data, out[:])
+
+	return out
+}
+
+// SHA512 returns the SHA-512 digest of data, computed natively by the node at
+// a fixed cost of gas.
+func SHA512(data []byte) [64]byte {
+	var out [64]byte
+	hash(hashSHA512, data, out[:])
+
+	return out
+}
+
+func appendUint32(buf []byte, v uint32) []byte {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+
+	return append(buf, b[:]...)
+}
+
+func appendUint64(buf []byte, v uint64) []byte {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+
+	return append(buf, b[:]...)
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package contract
+
+import (
+	"encoding/binary"
+	"github.com/perlin-network/wavelet"
+	"github.com/perlin-network/wavelet/abi"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTags(t *testing.T) {
+	assert.Equal(t, sys.TagTransfer, TagTransfer)
+	assert.Equal(t, sys.TagContract, TagContract)
+	assert.Equal(t, sys.TagStake, TagStake)
+}
+
+func TestParseParameters(t *testing.T) {
+	params, err := abi.Marshal(true, uint16(7), "hello", []byte{1, 2}, [32]byte{9})
+	assert.NoError(t, err)
+
+	payload := make([]byte, 8, 8+SizeRoundID+SizeTransactionID+SizeAccountID+8+len(params))
+	binary.LittleEndian.PutUint64(payload, 3)
+
+	payload = append(payload, make([]byte, SizeRoundID)...)
+	payload = append(payload, make([]byte, SizeTransactionID)...)
+	payload = append(payload, 0xAB)
+	payload = append(payload, make([]byte, SizeAccountID-1)...)
+	payload = appendUint64(payload, 42)
+	payload = append(payload, params...)
+
+	p, err := ParseParameters(payload)
+	assert.NoError(t, err)
+
+	assert.EqualValues(t, 3, p.RoundIndex)
+	assert.EqualValues(t, 0xAB, p.Sender[0])
+	assert.EqualValues(t, 42, p.Amount)
+	assert.Equal(t, params, p.Remaining())
+
+	assert.True(t, p.ReadBool())
+	assert.EqualValues(t, 7, p.ReadUint16())
+	assert.Equal(t, "hello", p.ReadString())
+	assert.Equal(t, []byte{1, 2}, p.ReadBytes())
+	assert.Equal(t, AccountID{9}, p.ReadAccountID())
+	assert.NoError(t, p.Err())
+	assert.Empty(t, p.Remaining())
+
+	// Reading beyond the parameters yields zero values and an error.
+
+	assert.Zero(t, p.ReadUint64())
+	assert.Equal(t, ErrUnexpectedEOF, p.Err())
+
+	_, err = ParseParameters(payload[:8])
+	assert.Equal(t, ErrUnexpectedEOF, err)
+}
+
+func TestTransferPayload(t *testing.T) {
+	recipient := AccountID{1}
+
+	transfer, err := wavelet.ParseTransferTransaction(TransferPayload(recipient, 10, 0, "", []byte{1}))
+	assert.NoError(t, err)
+	assert.Equal(t, wavelet.AccountID(recipient), transfer.Recipient)
+	assert.EqualValues(t, 10, transfer.Amount)
+	assert.Empty(t, transfer.FuncName)
+	assert.Empty(t, transfer.FuncParams)
+
+	transfer, err = wavelet.ParseTransferTransaction(TransferPayload(recipient, 10, 20, "deposit", []byte{1}))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 20, transfer.GasLimit)
+	assert.Equal(t, "deposit", string(transfer.FuncName))
+	assert.Equal(t, []byte{1}, transfer.FuncParams)
+
+	entries := EncodeTransferEntries([]TransferEntry{{Recipient: recipient, Amount: 5}, {Recipient: AccountID{2}, Amount: 6}})
+	assert.Len(t, entries, 2*(SizeAccountID+8))
+	assert.EqualValues(t, 6, binary.LittleEndian.Uint64(entries[len(entries)-8:]))
+}
+
+func TestHostOutsideWebAssembly(t *testing.T) {
+	assert.PanicsWithValue(t, ErrNotWebAssembly, func() { LoadParameters() })
+	assert.PanicsWithValue(t, ErrNotWebAssembly, func() { Transfer(AccountID{}, 1) })
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+//go:build !tinygo || !wasm
+// +build !tinygo !wasm
+
+package contract
+
+// Host functions are only provided to smart contracts compiled to WebAssembly
+// with TinyGo, and panic otherwise.
+
+func payload() []byte {
+	panic(ErrNotWebAssembly)
+}
+
+func result([]byte) {
+	panic(ErrNotWebAssembly)
+}
+
+func log(string) {
+	panic(ErrNotWebAssembly)
+}
+
+func sendTransaction(byte, []byte) {
+	panic(ErrNotWebAssembly)
+}
+
+func sendTransfers([]byte, uint64) bool {
+	panic(ErrNotWebAssembly)
+}
+
+func emitEvent(string, []byte) bool {
+	panic(ErrNotWebAssembly)
+}
+
+func balance(AccountID) uint64 {
+	panic(ErrNotWebAssembly)
+}
+
+func stake(AccountID) uint64 {
+	panic(ErrNotWebAssembly)
+}
+
+func randomBeacon() [32]byte {
+	panic(ErrNotWebAssembly)
+}
+
+func random() [32]byte {
+	panic(ErrNotWebAssembly)
+}
+
+func verifyEd25519([32]byte, []byte, [64]byte) bool {
+	panic(ErrNotWebAssembly)
+}
+
+func hash(hashFunc, []byte, []byte) {
+	panic(ErrNotWebAssembly)
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+//go:build tinygo && wasm
+// +build tinygo,wasm
+
+package contract
+
+import "unsafe"
+
+//go:wasmimport env _payload_len
+func _payload_len() uint32
+
+//go:wasmimport env _payload
+func _payload(outPtr uint32)
+
+//go:wasmimport env _result
+func _result(dataPtr, dataLen uint32)
+
+//go:wasmimport env _log
+func _log(dataPtr, dataLen uint32)
+
+//go:wasmimport env _send_transaction
+func _send_transaction(tag, payloadPtr, payloadLen uint32)
+
+//go:wasmimport env _send_transfers
+func _send_transfers(entriesPtr, entriesLen uint32, limit uint64) uint32
+
+//go:wasmimport env _emit_event
+func _emit_event(topicPtr, topicLen, payloadPtr, payloadLen uint32) uint32
+
+//go:wasmimport env _balance
+func _balance(accountPtr uint32) uint64
+
+//go:wasmimport env _stake
+func _stake(accountPtr uint32) uint64
+
+//go:wasmimport env _random_beacon
+func _random_beacon(outPtr, outLen uint32) uint32
+
+//go:wasmimport env _random
+func _random(seedPtr uint32) uint32
+
+//go:wasmimport env _verify_ed25519
+func _verify_ed25519(keyPtr, keyLen, dataPtr, dataLen, sigPtr, sigLen uint32) uint32
+
+//go:wasmimport env _hash_blake2b_256
+func _hash_blake2b_256(dataPtr, dataLen, outPtr, outLen uint32) uint32
+
+//go:wasmimport env _hash_blake2b_512
+func _hash_blake2b_512(dataPtr, dataLen, outPtr, outLen uint32) uint32
+
+//go:wasmimport env _hash_sha256
+func _hash_sha256(dataPtr, dataLen, outPtr, outLen uint32) uint32
+
+//go:wasmimport env _hash_sha512
+func _hash_sha512(dataPtr, dataLen, outPtr, outLen uint32) uint32
+
+// ptr returns the address of b in linear memory, or zero should b be empty.
+func ptr(b []byte) uint32 {
+	if len(b) == 0 {
+		return 0
+	}
+
+	return uint32(uintptr(unsafe.Pointer(&b[0])))
+}
+
+func payload() []byte {
+	buf := make([]byte, _payload_len())
+	if len(buf) > 0 {
+		_payload(ptr(buf))
+	}
+
+	return buf
+}
+
+func result(msg []byte) {
+	_result(ptr(msg), uint32(len(msg)))
+}
+
+func log(msg string) {
+	buf := []byte(msg)
+	_log(ptr(buf), uint32(len(buf)))
+}
+
+func sendTransaction(tag byte, payload []byte) {
+	_send_transaction(uint32(tag), ptr(payload), uint32(len(payload)))
+}
+
+func sendTransfers(entries []byte, limit uint64) bool {
+	return _send_transfers(ptr(entries), uint32(len(entries)), limit) == 0
+}
+
+func emitEvent(topic string, payload []byte) bool {
+	buf := []byte(topic)
+	return _emit_event(ptr(buf), uint32(len(buf)), ptr(payload), uint32(len(payload))) == 0
+}
+
+func balance(id AccountID) uint64 {
+	return _balance(ptr(id[:]))
+}
+
+func stake(id AccountID) uint64 {
+	return _stake(ptr(id[:]))
+}
+
+func randomBeacon() [32]byte {
+	var out [32]byte
+	_random_beacon(ptr(out[:]), uint32(len(out)))
+
+	return out
+}
+
+func random() [32]byte {
+	var out [32]byte
+	_random(ptr(out[:]))
+
+	return out
+}
+
+func verifyEd25519(key [32]byte, data []byte, sig [64]byte) bool {
+	return _verify_ed25519(ptr(key[:]), uint32(len(key)), ptr(data), uint32(len(data)), ptr(sig[:]), uint32(len(sig))) == 0
+}
+
+func hash(f hashFunc, data, out []byte) {
+	switch f {
+	case hashBlake2b256:
+		_hash_blake2b_256(ptr(data), uint32(len(data)), ptr(out), uint32(len(out)))
+	case hashBlake2b512:
+		_hash_blake2b_512(ptr(data), uint32(len(data)), ptr(out), uint32(len(out)))
+	case hashSHA256:
+		_hash_sha256(ptr(data), uint32(len(data)), ptr(out), uint32(len(out)))
+	case hashSHA512:
+		_hash_sha512(ptr(data), uint32(len(data)), ptr(out), uint32(len(out)))
+	}
+}
@@ -25,7 +25,6 @@ import (
 	"encoding/binary"
 	"github.com/perlin-network/life/compiler"
 	"github.com/perlin-network/life/exec"
-	"github.com/perlin-network/life/utils"
 	"github.com/perlin-network/noise/edwards25519"
 	"github.com/perlin-network/wavelet/avl"
 	"github.com/perlin-network/wavelet/log"
@@ -37,6 +36,8 @@ import (
 var (
 	ErrNotSmartContract         = errors.New("contract: specified account ID is not a smart contract")
 	ErrContractFunctionNotFound = errors.New("contract: smart contract func not found")
+	ErrContractConstructor      = errors.New("contract: constructor may only be invoked when spawning the smart contract")
+	ErrContractMemoryBounds     = errors.New("contract: pointer passed to host function is out of bounds of memory")
 
 	_ exec.ImportResolver = (*ContractExecutor)(nil)
 	_ compiler.GasPolicy  = (*ContractExecutor)(nil)
@@ -46,6 +47,24 @@ const (
 	PageSize = 65536
 )
 
+// contractConstructors are the names of the functions a smart contract may
+// export as its constructor, in order of precedence. The constructor is
+// invoked exactly once, with the init params of the transaction spawning the
+// contract, and may not be invoked thereafter.
+var contractConstructors = []string{"init", "constructor"}
+
+// IsContractConstructor returns whether or not name is that of a function a
+// smart contract may export as its constructor.
+func IsContractConstructor(name string) bool {
+	for _, constructor := range contractConstructors {
+		if name == constructor {
+			return true
+		}
+	}
+
+	return false
+}
+
 type ContractExecutor struct {
 	ID       AccountID
 	Snapshot *avl.Tree
@@ -53,10 +72,24 @@ type ContractExecutor struct {
 	Gas              uint64
 	GasLimitExceeded bool
 
+	// Reverted is whether or not changes to the memory of the contract were
+	// discarded, as the invocation trapped, ran out of gas, or reported an
+	// error.
+	Reverted bool
+
 	Payload []byte
 	Error   []byte
 
+	Logs  []string
 	Queue []*Transaction
+
+	// TxID is the ID of the transaction which originated the invocation,
+	// which events emitted by the contract are attributed to.
+	TxID   TransactionID
+	Events []ContractEvent
+
+	// draws is the number of random seeds drawn through _random so far.
+	draws uint64
 }
 
 func (e *ContractExecutor) GetCost(key string) int64 {
@@ -93,6 +126,27 @@ func (e *ContractExecutor) ResolveFunc(module, field string) exec.FunctionImport
 					Payload: payload,
 				})
 
+				return 0
+			}
+		case "_send_transfers":
+			return func(vm *exec.VirtualMachine) int64 {
+				frame := vm.GetCurrentFrame()
+
+				entriesPtr := uint64(uint32(frame.Locals[0]))
+				entriesLen := uint64(uint32(frame.Locals[1]))
+				limit := uint64(frame.Locals[2])
+
+				if entriesPtr+entriesLen > uint64(len(vm.Memory)) {
+					return 1
+				}
+
+				count, ok := e.sendTransfers(vm.Memory[entriesPtr:entriesPtr+entriesLen], limit)
+				if !ok {
+					return 1
+				}
+
+				vm.Gas += uint64(count) * uint64(e.GetCost("wavelet.transfer.recipient"))
+
 				return 0
 			}
 		case "_payload_len":
@@ -123,11 +177,90 @@ func (e *ContractExecutor) ResolveFunc(module, field string) exec.FunctionImport
 				dataPtr := int(uint32(frame.Locals[0]))
 				dataLen := int(uint32(frame.Locals[1]))
 
+				msg := string(vm.Memory[dataPtr : dataPtr+dataLen])
+				e.Logs = append(e.Logs, msg)
+
 				logger := log.Contracts("log")
 				logger.Debug().
 					Hex("contract_id", e.ID[:]).
-					Msg(string(vm.Memory[dataPtr : dataPtr+dataLen]))
+					Msg(msg)
+
+				return 0
+			}
+		case "_emit_event":
+			return func(vm *exec.VirtualMachine) int64 {
+				frame := vm.GetCurrentFrame()
+				topicPtr, topicLen := uint64(uint32(frame.Locals[0])), uint64(uint32(frame.Locals[1]))
+				payloadPtr, payloadLen := uint64(uint32(frame.Locals[2])), uint64(uint32(frame.Locals[3]))
+
+				if topicLen == 0 || topicLen > uint64(sys.MaxContractEventTopicSize) || payloadLen > uint64(sys.MaxContractEventPayloadSize) {
+					return 1
+				}
+
+				if topicPtr+topicLen > uint64(len(vm.Memory)) || payloadPtr+payloadLen > uint64(len(vm.Memory)) {
+					return 1
+				}
+
+				if len(e.Events) >= sys.MaxContractEventsPerCall {
+					return 1
+				}
+
+				vm.Gas += uint64(e.GetCost("wavelet.event")) + (topicLen+payloadLen)*uint64(e.GetCost("wavelet.event.byte"))
+
+				payload := make([]byte, payloadLen)
+				copy(payload, vm.Memory[payloadPtr:payloadPtr+payloadLen])
+
+				e.Events = append(e.Events, ContractEvent{
+					Contract: e.ID,
+					TxID:     e.TxID,
+					Topic:    string(vm.Memory[topicPtr : topicPtr+topicLen]),
+					Payload:  payload,
+				})
 
+				return 0
+			}
+		case "_balance":
+			return buildAccountReadImpl(uint64(e.GetCost("wavelet.balance")), func(id AccountID) uint64 {
+				balance, _ := ReadAccountBalance(e.Snapshot, id)
+				return balance
+			})
+		case "_stake":
+			return buildAccountReadImpl(uint64(e.GetCost("wavelet.stake")), func(id AccountID) uint64 {
+				stake, _ := ReadAccountStake(e.Snapshot, id)
+				return stake
+			})
+		case "_random_beacon":
+			return func(vm *exec.VirtualMachine) int64 {
+				vm.Gas += uint64(e.GetCost("wavelet.random_beacon"))
+
+				frame := vm.GetCurrentFrame()
+				outPtr, outLen := uint64(uint32(frame.Locals[0])), uint64(uint32(frame.Locals[1]))
+				if outLen != SizeRandomBeacon {
+					return 1
+				}
+
+				if outPtr+outLen > uint64(len(vm.Memory)) {
+					return 1
+				}
+
+				beacon := ReadRandomBeacon(e.Snapshot)
+				copy(vm.Memory[outPtr:outPtr+outLen], beacon[:])
+				return 0
+			}
+		case "_random":
+			return func(vm *exec.VirtualMachine) int64 {
+				vm.Gas += uint64(e.GetCost("wavelet.random"))
+
+				frame := vm.GetCurrentFrame()
+				seedPtr := uint64(uint32(frame.Locals[0]))
+				if seedPtr+SizeRandomSeed > uint64(len(vm.Memory)) {
+					return 1
+				}
+
+				seed := RandomSeed(ReadRandomBeacon(e.Snapshot), e.TxID, e.ID, e.draws)
+				e.draws++
+
+				copy(vm.Memory[seedPtr:seedPtr+SizeRandomSeed], seed[:])
 				return 0
 			}
 		case "_verify_ed25519":
@@ -135,14 +268,18 @@ func (e *ContractExecutor) ResolveFunc(module, field string) exec.FunctionImport
 				vm.Gas += uint64(e.GetCost("wavelet.verify.ed25519"))
 
 				frame := vm.GetCurrentFrame()
-				keyPtr, keyLen := int(uint32(frame.Locals[0])), int(uint32(frame.Locals[1]))
-				dataPtr, dataLen := int(uint32(frame.Locals[2])), int(uint32(frame.Locals[3]))
-				sigPtr, sigLen := int(uint32(frame.Locals[4])), int(uint32(frame.Locals[5]))
+				keyPtr, keyLen := uint64(uint32(frame.Locals[0])), uint64(uint32(frame.Locals[1]))
+				dataPtr, dataLen := uint64(uint32(frame.Locals[2])), uint64(uint32(frame.Locals[3]))
+				sigPtr, sigLen := uint64(uint32(frame.Locals[4])), uint64(uint32(frame.Locals[5]))
 
 				if keyLen != edwards25519.SizePublicKey || sigLen != edwards25519.SizeSignature {
 					return 1
 				}
 
+				if keyPtr+keyLen > uint64(len(vm.Memory)) || dataPtr+dataLen > uint64(len(vm.Memory)) || sigPtr+sigLen > uint64(len(vm.Memory)) {
+					return 1
+				}
+
 				key := vm.Memory[keyPtr : keyPtr+keyLen]
 				data := vm.Memory[dataPtr : dataPtr+dataLen]
 				sig := vm.Memory[sigPtr : sigPtr+sigLen]
@@ -204,30 +341,77 @@ func (e *ContractExecutor) ResolveFunc(module, field string) exec.FunctionImport
 	}
 }
 
+// sendTransfers queues up transfers of PERLs from the smart contract to each
+// recipient listed in entries, which is a packed list of 32-byte recipient IDs
+// each followed by a little-endian uint64 amount. Should the entries be
+// malformed, list too many recipients, or add up to more than limit PERLs,
+// no transfers are queued up and false is returned. Queued up transfers are
+// applied once the smart contract finishes executing, and are rejected all
+// together should the smart contract be unable to afford any one of them.
+func (e *ContractExecutor) sendTransfers(entries []byte, limit uint64) (int, bool) {
+	const entrySize = SizeAccountID + 8
+
+	count := len(entries) / entrySize
+
+	if len(entries)%entrySize != 0 || count == 0 || count > sys.MaxContractTransferRecipients {
+		return 0, false
+	}
+
+	var total Amount
+
+	for i := 0; i < len(entries); i += entrySize {
+		var err error
+
+		if total, err = total.Add(Amount(binary.LittleEndian.Uint64(entries[i+SizeAccountID : i+entrySize]))); err != nil {
+			return 0, false
+		}
+	}
+
+	if total > Amount(limit) {
+		return 0, false
+	}
+
+	for i := 0; i < len(entries); i += entrySize {
+		payload := make([]byte, entrySize)
+		copy(payload, entries[i:i+entrySize])
+
+		e.Queue = append(e.Queue, &Transaction{
+			Sender:  e.ID,
+			Creator: e.ID,
+			Tag:     sys.TagTransfer,
+			Payload: payload,
+		})
+	}
+
+	return count, true
+}
+
 func (e *ContractExecutor) ResolveGlobal(module, field string) int64 {
 	panic("global variables are disallowed in smart contracts")
 }
 
 func (e *ContractExecutor) Execute(snapshot *avl.Tree, id AccountID, round *Round, tx *Transaction, amount, gasLimit uint64, name string, params, code []byte) error {
+	limits := sys.Params()
+
 	config := exec.VMConfig{
-		DefaultMemoryPages: 4,
-		MaxMemoryPages:     32,
+		DefaultMemoryPages: limits.ContractDefaultMemoryPages,
+		MaxMemoryPages:     limits.ContractMaxMemoryPages,
 
-		DefaultTableSize: PageSize,
-		MaxTableSize:     PageSize,
+		DefaultTableSize: limits.ContractDefaultTableSize,
+		MaxTableSize:     limits.ContractMaxTableSize,
 
-		MaxValueSlots:     4096,
-		MaxCallStackDepth: 256,
+		MaxValueSlots:     limits.ContractMaxValueSlots,
+		MaxCallStackDepth: limits.ContractMaxCallStackDepth,
 		GasLimit:          gasLimit,
 	}
 
-	vm, err := exec.NewVirtualMachine(code, config, e, e)
+	vm, err := contractRuntime().Instantiate(code, config, e)
 	if err != nil {
 		return errors.Wrap(err, "could not init vm")
 	}
 
 	if mem := LoadContractMemorySnapshot(snapshot, id); mem != nil {
-		vm.Memory = mem
+		vm.SetMemory(mem)
 	}
 
 	e.ID = id
@@ -235,31 +419,23 @@ func (e *ContractExecutor) Execute(snapshot *avl.Tree, id AccountID, round *Roun
 
 	e.Payload = buildContractPayload(round, tx, amount, params)
 
-	entry, exists := vm.GetFunctionExport("_contract_" + name)
-	if !exists {
-		return errors.Wrapf(ErrContractFunctionNotFound, `fn "_contract_%s" does not exist`, name)
+	err = vm.Invoke("_contract_" + name)
+	if errors.Cause(err) == ErrContractFunctionNotFound {
+		return err
 	}
 
-	vm.Ignite(entry)
+	e.Reverted = err != nil || len(e.Error) > 0
 
-	for !vm.Exited {
-		vm.Execute()
-
-		if vm.Delegate != nil {
-			vm.Delegate()
-			vm.Delegate = nil
-		}
-	}
-
-	if vm.ExitError == nil && len(e.Error) == 0 {
-		SaveContractMemorySnapshot(snapshot, id, vm.Memory)
+	if !e.Reverted {
+		SaveContractMemorySnapshot(snapshot, id, vm.Memory())
+		saveContractEvents(snapshot, id, e.Events)
 	}
 
-	if vm.ExitError != nil && utils.UnifyError(vm.ExitError).Error() == "gas limit exceeded" {
+	if err == ErrContractGasLimitExceeded {
 		e.Gas = gasLimit
 		e.GasLimitExceeded = true
 	} else {
-		e.Gas = vm.Gas
+		e.Gas = vm.GasUsed()
 		e.GasLimitExceeded = false
 	}
 
@@ -364,14 +540,21 @@ func buildContractPayload(round *Round, tx *Transaction, amount uint64, params [
 	return p
 }
 
+// buildHashImpl builds a host function which writes the digest f computes of
+// the data passed to it into the buffer of exactly size bytes passed to it, at
+// a fixed cost of gas regardless of the length of the data.
 func buildHashImpl(gas uint64, size int, f func(data, out []byte)) func(vm *exec.VirtualMachine) int64 {
 	return func(vm *exec.VirtualMachine) int64 {
 		vm.Gas += gas
 
 		frame := vm.GetCurrentFrame()
-		dataPtr, dataLen := int(uint32(frame.Locals[0])), int(uint32(frame.Locals[1]))
-		outPtr, outLen := int(uint32(frame.Locals[2])), int(uint32(frame.Locals[3]))
-		if outLen != size {
+		dataPtr, dataLen := uint64(uint32(frame.Locals[0])), uint64(uint32(frame.Locals[1]))
+		outPtr, outLen := uint64(uint32(frame.Locals[2])), uint64(uint32(frame.Locals[3]))
+		if outLen != uint64(size) {
+			return 1
+		}
+
+		if dataPtr+dataLen > uint64(len(vm.Memory)) || outPtr+outLen > uint64(len(vm.Memory)) {
 			return 1
 		}
 
@@ -381,3 +564,25 @@ func buildHashImpl(gas uint64, size int, f func(data, out []byte)) func(vm *exec
 		return 0
 	}
 }
+
+// buildAccountReadImpl builds a host function which reads a value f from the
+// ledger state for the account whose ID is stored in memory at the pointer
+// passed to it. As every value f may return is valid, the host function traps
+// with ErrContractMemoryBounds should the ID not lie within memory.
+func buildAccountReadImpl(gas uint64, f func(id AccountID) uint64) func(vm *exec.VirtualMachine) int64 {
+	return func(vm *exec.VirtualMachine) int64 {
+		vm.Gas += gas
+
+		frame := vm.GetCurrentFrame()
+		idPtr := uint64(uint32(frame.Locals[0]))
+
+		if idPtr+SizeAccountID > uint64(len(vm.Memory)) {
+			panic(ErrContractMemoryBounds)
+		}
+
+		var id AccountID
+		copy(id[:], vm.Memory[idPtr:idPtr+SizeAccountID])
+
+		return int64(f(id))
+	}
+}
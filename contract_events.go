@@ -0,0 +1,123 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/pkg/errors"
+)
+
+// ContractEvent is a structured event emitted by a smart contract while being
+// invoked by a transaction. Events are stored in the ledger state under the
+// contract which emitted them, numbered in the order they were emitted in, and
+// are only stored should the invocation which emitted them have succeeded.
+type ContractEvent struct {
+	Contract TransactionID
+	Index    uint64 // Number of events emitted by the contract prior.
+
+	// TxID is the ID of the transaction which originated the invocation of the
+	// contract, which may be a transaction queued up by another contract.
+	TxID TransactionID
+
+	Topic   string
+	Payload []byte
+}
+
+func (e ContractEvent) Marshal() []byte {
+	buf := make([]byte, 0, SizeTransactionID+1+len(e.Topic)+len(e.Payload))
+
+	buf = append(buf, e.TxID[:]...)
+	buf = append(buf, byte(len(e.Topic)))
+	buf = append(buf, e.Topic...)
+	buf = append(buf, e.Payload...)
+
+	return buf
+}
+
+// UnmarshalContractEvent decodes an event as stored in the ledger state. The
+// contract which emitted the event and its index are not stored alongside it,
+// and are therefore left unset.
+func UnmarshalContractEvent(buf []byte) (ContractEvent, error) {
+	var e ContractEvent
+
+	if len(buf) < SizeTransactionID+1 {
+		return e, errors.Errorf("contract event must be at least %d bytes, but got %d bytes", SizeTransactionID+1, len(buf))
+	}
+
+	copy(e.TxID[:], buf[:SizeTransactionID])
+	buf = buf[SizeTransactionID:]
+
+	topicLen := int(buf[0])
+	buf = buf[1:]
+
+	if len(buf) < topicLen {
+		return e, errors.Errorf("contract event topic is %d bytes, but only %d bytes are left", topicLen, len(buf))
+	}
+
+	e.Topic = string(buf[:topicLen])
+	e.Payload = append([]byte(nil), buf[topicLen:]...)
+
+	return e, nil
+}
+
+// ReadAccountContractEvents reads up to limit events emitted by a contract,
+// starting from the event indexed by offset, in the order they were emitted.
+func ReadAccountContractEvents(tree *avl.Tree, id TransactionID, offset uint64, limit uint64) []ContractEvent {
+	numEvents := ReadAccountContractNumEvents(tree, id)
+
+	if offset >= numEvents {
+		return nil
+	}
+
+	if limit > numEvents-offset {
+		limit = numEvents - offset
+	}
+
+	events := make([]ContractEvent, 0, limit)
+
+	for idx := offset; idx < offset+limit; idx++ {
+		if event, exists := ReadAccountContractEvent(tree, id, idx); exists {
+			events = append(events, event)
+		}
+	}
+
+	return events
+}
+
+// saveContractEvents numbers and stores the events emitted by the contract id
+// after all events it has emitted prior.
+func saveContractEvents(tree *avl.Tree, id TransactionID, events []ContractEvent) {
+	if len(events) == 0 {
+		return
+	}
+
+	numEvents := ReadAccountContractNumEvents(tree, id)
+
+	for i := range events {
+		events[i].Contract = id
+		events[i].Index = numEvents
+
+		WriteAccountContractEvent(tree, events[i])
+
+		numEvents++
+	}
+
+	WriteAccountContractNumEvents(tree, id, numEvents)
+}
@@ -0,0 +1,186 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"github.com/perlin-network/life/exec"
+	"github.com/perlin-network/life/utils"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/pkg/errors"
+	"sort"
+	"sync"
+)
+
+// ContractRuntimeInterpreter is the name of the runtime which interprets
+// smart contracts. It is available on all platforms, and is fallen back to
+// should the configured runtime not be available.
+const ContractRuntimeInterpreter = "interpreter"
+
+var ErrContractGasLimitExceeded = errors.New("contract: gas limit exceeded")
+
+// ContractRuntime loads smart contracts into virtual machines they may be
+// invoked in. The interpreter is the only runtime shipped; others may be
+// registered through RegisterContractRuntime. Gas used is part of the ledger state, so a runtime must meter
+// gas exactly as the interpreter does using the costs of the executor, and
+// must expose to contracts the host functions resolved by the executor.
+type ContractRuntime interface {
+	Name() string
+
+	// Available returns whether or not the runtime may be used on the
+	// platform the node is running on.
+	Available() bool
+
+	Instantiate(code []byte, config exec.VMConfig, executor *ContractExecutor) (ContractVM, error)
+}
+
+// ContractVM is a smart contract loaded into a virtual machine.
+type ContractVM interface {
+	Memory() []byte
+	SetMemory(mem []byte)
+
+	// Invoke runs the exported function name to completion. It returns
+	// ErrContractFunctionNotFound should the function not be exported, and
+	// ErrContractGasLimitExceeded should the contract run out of gas.
+	Invoke(name string) error
+
+	GasUsed() uint64
+}
+
+var (
+	contractRuntimesLock sync.RWMutex
+	contractRuntimes     = map[string]ContractRuntime{
+		ContractRuntimeInterpreter: interpreterRuntime{},
+	}
+)
+
+// RegisterContractRuntime makes rt selectable through sys.ContractRuntime
+// under its name, replacing any runtime previously registered under it.
+func RegisterContractRuntime(rt ContractRuntime) {
+	contractRuntimesLock.Lock()
+	contractRuntimes[rt.Name()] = rt
+	contractRuntimesLock.Unlock()
+}
+
+// ContractRuntimes returns the names of all registered runtimes which are
+// available on the platform the node is running on in ascending order.
+func ContractRuntimes() []string {
+	contractRuntimesLock.RLock()
+	defer contractRuntimesLock.RUnlock()
+
+	names := make([]string, 0, len(contractRuntimes))
+
+	for name, rt := range contractRuntimes {
+		if rt.Available() {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// LookupContractRuntime returns the runtime registered under name, provided
+// that it is available on the platform the node is running on.
+func LookupContractRuntime(name string) (ContractRuntime, bool) {
+	contractRuntimesLock.RLock()
+	rt, exists := contractRuntimes[name]
+	contractRuntimesLock.RUnlock()
+
+	if !exists || !rt.Available() {
+		return nil, false
+	}
+
+	return rt, true
+}
+
+// contractRuntime returns the runtime selected by sys.ContractRuntime, or the
+// interpreter should it not be available.
+func contractRuntime() ContractRuntime {
+	if rt, ok := LookupContractRuntime(sys.ContractRuntime); ok {
+		return rt
+	}
+
+	return interpreterRuntime{}
+}
+
+type interpreterRuntime struct{}
+
+func (interpreterRuntime) Name() string {
+	return ContractRuntimeInterpreter
+}
+
+func (interpreterRuntime) Available() bool {
+	return true
+}
+
+func (interpreterRuntime) Instantiate(code []byte, config exec.VMConfig, executor *ContractExecutor) (ContractVM, error) {
+	vm, err := exec.NewVirtualMachine(code, config, executor, executor)
+	if err != nil {
+		return nil, err
+	}
+
+	return interpreterVM{vm: vm}, nil
+}
+
+type interpreterVM struct {
+	vm *exec.VirtualMachine
+}
+
+func (i interpreterVM) Memory() []byte {
+	return i.vm.Memory
+}
+
+func (i interpreterVM) SetMemory(mem []byte) {
+	i.vm.Memory = mem
+}
+
+func (i interpreterVM) GasUsed() uint64 {
+	return i.vm.Gas
+}
+
+func (i interpreterVM) Invoke(name string) error {
+	entry, exists := i.vm.GetFunctionExport(name)
+	if !exists {
+		return errors.Wrapf(ErrContractFunctionNotFound, `fn "%s" does not exist`, name)
+	}
+
+	i.vm.Ignite(entry)
+
+	for !i.vm.Exited {
+		i.vm.Execute()
+
+		if i.vm.Delegate != nil {
+			i.vm.Delegate()
+			i.vm.Delegate = nil
+		}
+	}
+
+	if i.vm.ExitError == nil {
+		return nil
+	}
+
+	err := utils.UnifyError(i.vm.ExitError)
+	if err.Error() == "gas limit exceeded" {
+		return ErrContractGasLimitExceeded
+	}
+
+	return err
+}
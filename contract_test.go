@@ -0,0 +1,536 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"github.com/perlin-network/life/exec"
+	"github.com/perlin-network/noise/edwards25519"
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/blake2b"
+	"io/ioutil"
+	"math"
+	"testing"
+)
+
+func transferEntries(amounts ...uint64) []byte {
+	var entries []byte
+
+	for i, amount := range amounts {
+		var recipient AccountID
+		recipient[0] = byte(i + 1)
+
+		entries = append(entries, transferPayload(recipient, amount)...)
+	}
+
+	return entries
+}
+
+func TestContractSendTransfers(t *testing.T) {
+	var contract AccountID
+	contract[0] = 0xFF
+
+	sendTransfers := func(entries []byte, limit uint64) (*ContractExecutor, int64) {
+		executor := &ContractExecutor{ID: contract}
+
+		vm := &exec.VirtualMachine{
+			Memory:    append(make([]byte, 8), entries...),
+			CallStack: []exec.Frame{{Locals: []int64{8, int64(len(entries)), int64(limit)}}},
+		}
+
+		return executor, executor.ResolveFunc("env", "_send_transfers")(vm)
+	}
+
+	// Transfers adding up to more than the limit, or overflowing, must not be queued.
+	executor, ret := sendTransfers(transferEntries(10, 20), 29)
+	assert.EqualValues(t, 1, ret)
+	assert.Empty(t, executor.Queue)
+
+	executor, ret = sendTransfers(transferEntries(math.MaxUint64, 1), math.MaxUint64)
+	assert.EqualValues(t, 1, ret)
+	assert.Empty(t, executor.Queue)
+
+	// Malformed entries, or too many entries, must not be queued.
+	executor, ret = sendTransfers(transferEntries(10)[:39], 10)
+	assert.EqualValues(t, 1, ret)
+	assert.Empty(t, executor.Queue)
+
+	executor, ret = sendTransfers(nil, 10)
+	assert.EqualValues(t, 1, ret)
+	assert.Empty(t, executor.Queue)
+
+	executor, ret = sendTransfers(transferEntries(make([]uint64, sys.MaxContractTransferRecipients+1)...), 0)
+	assert.EqualValues(t, 1, ret)
+	assert.Empty(t, executor.Queue)
+
+	// Transfers within the limit are queued, and are applied once the contract finishes executing.
+	executor, ret = sendTransfers(transferEntries(10, 20), 30)
+	assert.EqualValues(t, 0, ret)
+	assert.Len(t, executor.Queue, 2)
+
+	snapshot := avl.New(store.NewInmem())
+	WriteAccountBalance(snapshot, contract, 30)
+
+	for i, entry := range executor.Queue {
+		assert.Equal(t, contract, entry.Creator)
+		assert.Equal(t, sys.TagTransfer, entry.Tag)
+
+		_, err := ApplyTransferTransaction(snapshot, &Round{}, entry, nil)
+		assert.NoError(t, err)
+
+		var recipient AccountID
+		recipient[0] = byte(i + 1)
+
+		balance, _ := ReadAccountBalance(snapshot, recipient)
+		assert.Equal(t, binary.LittleEndian.Uint64(entry.Payload[SizeAccountID:]), balance)
+	}
+
+	balance, _ := ReadAccountBalance(snapshot, contract)
+	assert.EqualValues(t, 0, balance)
+}
+
+func TestContractRandomBeacon(t *testing.T) {
+	snapshot := avl.New(store.NewInmem())
+
+	// The beacon is all zeroes before any rounds have been applied.
+	assert.Equal(t, RandomBeacon{}, ReadRandomBeacon(snapshot))
+
+	var end TransactionID
+	end[0] = 1
+
+	beacon := NextRandomBeacon(ReadRandomBeacon(snapshot), end)
+	assert.NotEqual(t, RandomBeacon{}, beacon)
+	assert.NotEqual(t, beacon, NextRandomBeacon(beacon, end))
+
+	WriteRandomBeacon(snapshot, beacon)
+	assert.Equal(t, beacon, ReadRandomBeacon(snapshot))
+
+	randomBeacon := func(outPtr, outLen int) (*exec.VirtualMachine, int64) {
+		executor := &ContractExecutor{Snapshot: snapshot}
+
+		vm := &exec.VirtualMachine{
+			Memory:    make([]byte, 8+SizeRandomBeacon),
+			CallStack: []exec.Frame{{Locals: []int64{int64(outPtr), int64(outLen)}}},
+		}
+
+		return vm, executor.ResolveFunc("env", "_random_beacon")(vm)
+	}
+
+	vm, ret := randomBeacon(8, SizeRandomBeacon-1)
+	assert.EqualValues(t, 1, ret)
+	assert.Equal(t, make([]byte, 8+SizeRandomBeacon), vm.Memory)
+
+	// The beacon must be written within the bounds of memory.
+	for _, outPtr := range []int{9, 0xFFFFFFFF} {
+		vm, ret = randomBeacon(outPtr, SizeRandomBeacon)
+		assert.EqualValues(t, 1, ret)
+		assert.Equal(t, make([]byte, 8+SizeRandomBeacon), vm.Memory)
+	}
+
+	vm, ret = randomBeacon(8, SizeRandomBeacon)
+	assert.EqualValues(t, 0, ret)
+	assert.Equal(t, beacon[:], vm.Memory[8:])
+	assert.EqualValues(t, sys.GasTable["wavelet.random_beacon"], vm.Gas)
+}
+
+func TestContractEmitEvent(t *testing.T) {
+	var contract, txID TransactionID
+	contract[0], txID[0] = 0xFF, 0xEE
+
+	executor := &ContractExecutor{ID: contract, TxID: txID}
+
+	emitEvent := func(topic string, payload []byte) (*exec.VirtualMachine, int64) {
+		vm := &exec.VirtualMachine{
+			Memory:    append(append(make([]byte, 8), topic...), payload...),
+			CallStack: []exec.Frame{{Locals: []int64{8, int64(len(topic)), int64(8 + len(topic)), int64(len(payload))}}},
+		}
+
+		return vm, executor.ResolveFunc("env", "_emit_event")(vm)
+	}
+
+	// Events without a topic, or with too large a topic or payload, must not be emitted.
+	_, ret := emitEvent("", []byte("payload"))
+	assert.EqualValues(t, 1, ret)
+
+	_, ret = emitEvent(string(make([]byte, sys.MaxContractEventTopicSize+1)), nil)
+	assert.EqualValues(t, 1, ret)
+
+	_, ret = emitEvent("topic", make([]byte, sys.MaxContractEventPayloadSize+1))
+	assert.EqualValues(t, 1, ret)
+
+	assert.Empty(t, executor.Events)
+
+	vm, ret := emitEvent("transfer", []byte("payload"))
+	assert.EqualValues(t, 0, ret)
+	assert.EqualValues(t, sys.GasTable["wavelet.event"]+uint64(len("transfer")+len("payload"))*sys.GasTable["wavelet.event.byte"], vm.Gas)
+
+	_, ret = emitEvent("approval", nil)
+	assert.EqualValues(t, 0, ret)
+
+	assert.Len(t, executor.Events, 2)
+	assert.Equal(t, txID, executor.Events[0].TxID)
+
+	// Events are numbered after all events emitted by the contract prior.
+	snapshot := avl.New(store.NewInmem())
+
+	saveContractEvents(snapshot, contract, executor.Events[:1])
+	saveContractEvents(snapshot, contract, executor.Events[1:])
+
+	assert.EqualValues(t, 2, ReadAccountContractNumEvents(snapshot, contract))
+
+	events := ReadAccountContractEvents(snapshot, contract, 0, 10)
+	if assert.Len(t, events, 2) {
+		assert.EqualValues(t, 0, events[0].Index)
+		assert.Equal(t, "transfer", events[0].Topic)
+		assert.Equal(t, []byte("payload"), events[0].Payload)
+
+		assert.EqualValues(t, 1, events[1].Index)
+		assert.Equal(t, "approval", events[1].Topic)
+		assert.Empty(t, events[1].Payload)
+		assert.Equal(t, txID, events[1].TxID)
+		assert.Equal(t, contract, events[1].Contract)
+	}
+
+	assert.Len(t, ReadAccountContractEvents(snapshot, contract, 1, 10), 1)
+	assert.Empty(t, ReadAccountContractEvents(snapshot, contract, 2, 10))
+
+	// A contract may only emit so many events per invocation.
+	for len(executor.Events) < sys.MaxContractEventsPerCall {
+		_, ret = emitEvent("topic", nil)
+		assert.EqualValues(t, 0, ret)
+	}
+
+	_, ret = emitEvent("topic", nil)
+	assert.EqualValues(t, 1, ret)
+}
+
+func TestContractRandom(t *testing.T) {
+	snapshot := avl.New(store.NewInmem())
+
+	var end TransactionID
+	end[0] = 1
+
+	beacon := NextRandomBeacon(ReadRandomBeacon(snapshot), end)
+	WriteRandomBeacon(snapshot, beacon)
+
+	var txID TransactionID
+	txID[0] = 2
+
+	var contract AccountID
+	contract[0] = 3
+
+	executor := &ContractExecutor{ID: contract, TxID: txID, Snapshot: snapshot}
+
+	random := func(seedPtr int) (*exec.VirtualMachine, int64) {
+		vm := &exec.VirtualMachine{
+			Memory:    make([]byte, 8+SizeRandomSeed),
+			CallStack: []exec.Frame{{Locals: []int64{int64(seedPtr)}}},
+		}
+
+		return vm, executor.ResolveFunc("env", "_random")(vm)
+	}
+
+	// Seeds which do not fit in memory are not drawn.
+
+	vm, ret := random(9)
+	assert.EqualValues(t, 1, ret)
+	assert.Equal(t, make([]byte, 8+SizeRandomSeed), vm.Memory)
+
+	first := RandomSeed(beacon, txID, contract, 0)
+
+	vm, ret = random(8)
+	assert.EqualValues(t, 0, ret)
+	assert.Equal(t, first[:], vm.Memory[8:])
+	assert.EqualValues(t, sys.GasTable["wavelet.random"], vm.Gas)
+
+	// Every draw yields a different seed.
+
+	vm, ret = random(8)
+	assert.EqualValues(t, 0, ret)
+	assert.NotEqual(t, first[:], vm.Memory[8:])
+
+	second := RandomSeed(beacon, txID, contract, 1)
+	assert.Equal(t, second[:], vm.Memory[8:])
+
+	assert.NotEqual(t, first, RandomSeed(beacon, txID, AccountID{}, 0))
+	assert.NotEqual(t, first, RandomSeed(beacon, TransactionID{}, contract, 0))
+	assert.NotEqual(t, first, RandomSeed(RandomBeacon{}, txID, contract, 0))
+}
+
+func TestContractReadAccount(t *testing.T) {
+	snapshot := avl.New(store.NewInmem())
+
+	var account AccountID
+	account[0] = 0xAA
+
+	WriteAccountBalance(snapshot, account, math.MaxUint64)
+	WriteAccountStake(snapshot, account, 42)
+
+	readAccount := func(field string, id AccountID) (*exec.VirtualMachine, int64) {
+		executor := &ContractExecutor{Snapshot: snapshot}
+
+		vm := &exec.VirtualMachine{
+			Memory:    append(make([]byte, 8), id[:]...),
+			CallStack: []exec.Frame{{Locals: []int64{8}}},
+		}
+
+		return vm, executor.ResolveFunc("env", field)(vm)
+	}
+
+	vm, ret := readAccount("_balance", account)
+	assert.EqualValues(t, uint64(math.MaxUint64), uint64(ret))
+	assert.EqualValues(t, sys.GasTable["wavelet.balance"], vm.Gas)
+
+	vm, ret = readAccount("_stake", account)
+	assert.EqualValues(t, 42, ret)
+	assert.EqualValues(t, sys.GasTable["wavelet.stake"], vm.Gas)
+
+	// Accounts which do not exist have no balance nor stake.
+	_, ret = readAccount("_balance", AccountID{})
+	assert.EqualValues(t, 0, ret)
+
+	_, ret = readAccount("_stake", AccountID{})
+	assert.EqualValues(t, 0, ret)
+
+	// Reading an account whose ID does not lie within memory traps.
+	for _, idPtr := range []int64{9, 0xFFFFFFFF} {
+		for _, field := range []string{"_balance", "_stake"} {
+			vm := &exec.VirtualMachine{
+				Memory:    make([]byte, 8+SizeAccountID),
+				CallStack: []exec.Frame{{Locals: []int64{idPtr}}},
+			}
+
+			assert.PanicsWithValue(t, ErrContractMemoryBounds, func() {
+				(&ContractExecutor{Snapshot: snapshot}).ResolveFunc("env", field)(vm)
+			})
+		}
+	}
+}
+
+func TestContractPrecompiles(t *testing.T) {
+	data := []byte("wavelet")
+
+	call := func(field string, mem []byte, locals ...int64) (*exec.VirtualMachine, int64) {
+		vm := &exec.VirtualMachine{Memory: mem, CallStack: []exec.Frame{{Locals: locals}}}
+		return vm, (&ContractExecutor{}).ResolveFunc("env", field)(vm)
+	}
+
+	// Digests are written after the data, and cost a fixed amount of gas
+	// regardless of the length of the data.
+
+	mem := append(append([]byte{}, data...), make([]byte, sha256.Size)...)
+
+	vm, ret := call("_hash_sha256", mem, 0, int64(len(data)), int64(len(data)), sha256.Size)
+	assert.EqualValues(t, 0, ret)
+	digest := sha256.Sum256(data)
+	assert.Equal(t, digest[:], vm.Memory[len(data):])
+	assert.EqualValues(t, sys.GasTable["wavelet.hash.sha256"], vm.Gas)
+
+	vm, ret = call("_hash_blake2b_256", mem, 0, 1, int64(len(data)), blake2b.Size256)
+	assert.EqualValues(t, 0, ret)
+	assert.EqualValues(t, sys.GasTable["wavelet.hash.blake2b256"], vm.Gas)
+
+	// Buffers of the wrong size, or which do not fit in memory, are rejected.
+
+	_, ret = call("_hash_sha256", mem, 0, int64(len(data)), int64(len(data)), sha256.Size-1)
+	assert.EqualValues(t, 1, ret)
+
+	_, ret = call("_hash_sha256", mem, 0, int64(len(data)), int64(len(data))+1, sha256.Size)
+	assert.EqualValues(t, 1, ret)
+
+	_, ret = call("_hash_sha256", mem, 0, int64(len(mem))+1, int64(len(data)), sha256.Size)
+	assert.EqualValues(t, 1, ret)
+
+	pub, priv, err := edwards25519.GenerateKey(nil)
+	assert.NoError(t, err)
+
+	sig := edwards25519.Sign(priv, data)
+
+	mem = append(append(append([]byte{}, pub[:]...), sig[:]...), data...)
+
+	keyLen, sigLen := int64(edwards25519.SizePublicKey), int64(edwards25519.SizeSignature)
+
+	vm, ret = call("_verify_ed25519", mem, 0, keyLen, keyLen+sigLen, int64(len(data)), keyLen, sigLen)
+	assert.EqualValues(t, 0, ret)
+	assert.EqualValues(t, sys.GasTable["wavelet.verify.ed25519"], vm.Gas)
+
+	_, ret = call("_verify_ed25519", mem, 0, keyLen, keyLen+sigLen, int64(len(data))-1, keyLen, sigLen)
+	assert.EqualValues(t, 1, ret)
+
+	_, ret = call("_verify_ed25519", mem, 0, keyLen, keyLen+sigLen, int64(len(data))+1, keyLen, sigLen)
+	assert.EqualValues(t, 1, ret)
+}
+
+func TestValidateContractCode(t *testing.T) {
+	for _, path := range []string{"cmd/wavelet/contracts/token.wasm", "cmd/wavelet/contracts/transfer_back.wasm"} {
+		code, err := ioutil.ReadFile(path)
+		assert.NoError(t, err)
+		assert.NoError(t, ValidateContractCode(code), path)
+	}
+
+	module := func(sections ...[]byte) []byte {
+		code := []byte{0x00, 0x61, 0x73, 0x6D, 0x01, 0x00, 0x00, 0x00}
+
+		for _, section := range sections {
+			code = append(code, section...)
+		}
+
+		return code
+	}
+
+	typeSection := []byte{0x01, 0x04, 0x01, 0x60, 0x00, 0x00} // func () -> ()
+	funcSection := []byte{0x03, 0x02, 0x01, 0x00}
+
+	codeSection := func(body ...byte) []byte {
+		body = append(append([]byte{0x00}, body...), 0x0B) // No locals, and ends with end.
+		return append([]byte{0x0A, byte(len(body) + 2), 0x01, byte(len(body))}, body...)
+	}
+
+	importSection := func(field string) []byte {
+		entry := append(append([]byte{0x03}, "env"...), byte(len(field)))
+		entry = append(append(entry, field...), 0x00, 0x00)
+
+		return append([]byte{0x02, byte(len(entry) + 1), 0x01}, entry...)
+	}
+
+	f64Const := []byte{0x44, 0, 0, 0, 0, 0, 0, 0, 0}
+
+	assert.Error(t, ValidateContractCode([]byte("not a module")))
+
+	// Only host functions provided to smart contracts may be imported.
+	assert.NoError(t, ValidateContractCode(module(typeSection, importSection("_payload_len"))))
+	assert.Error(t, ValidateContractCode(module(typeSection, importSection("_unknown"))))
+
+	// Memory may not be declared beyond the limits of the virtual machine.
+	assert.NoError(t, ValidateContractCode(module([]byte{0x05, 0x03, 0x01, 0x00, byte(sys.Params().ContractMaxMemoryPages)})))
+	assert.Error(t, ValidateContractCode(module([]byte{0x05, 0x03, 0x01, 0x00, byte(sys.Params().ContractMaxMemoryPages + 1)})))
+	assert.Error(t, ValidateContractCode(module([]byte{0x05, 0x04, 0x01, 0x01, 0x01, byte(sys.Params().ContractMaxMemoryPages + 1)})))
+
+	// Floating-point operators are allowed, unless they are nondeterministic.
+	assert.NoError(t, ValidateContractCode(module(typeSection, funcSection, codeSection(append(f64Const, 0x1A)...))))
+
+	err := ValidateContractCode(module(typeSection, funcSection, codeSection(append(f64Const, 0xB6, 0x1A)...)))
+	assert.Equal(t, ErrNondeterministicContract, errors.Cause(err))
+}
+
+type testContractRuntime struct {
+	interpreterRuntime
+
+	name      string
+	available bool
+}
+
+func (rt testContractRuntime) Name() string {
+	return rt.name
+}
+
+func (rt testContractRuntime) Available() bool {
+	return rt.available
+}
+
+func TestContractRuntimeFallback(t *testing.T) {
+	defer func(runtime string) { sys.ContractRuntime = runtime }(sys.ContractRuntime)
+
+	RegisterContractRuntime(testContractRuntime{name: "test.available", available: true})
+	RegisterContractRuntime(testContractRuntime{name: "test.unavailable", available: false})
+
+	assert.Contains(t, ContractRuntimes(), ContractRuntimeInterpreter)
+	assert.Contains(t, ContractRuntimes(), "test.available")
+	assert.NotContains(t, ContractRuntimes(), "test.unavailable")
+
+	sys.ContractRuntime = "test.available"
+	assert.Equal(t, "test.available", contractRuntime().Name())
+
+	// Runtimes which are not available, or not registered, fall back to the interpreter.
+	sys.ContractRuntime = "test.unavailable"
+	assert.Equal(t, ContractRuntimeInterpreter, contractRuntime().Name())
+
+	sys.ContractRuntime = "test.unknown"
+	assert.Equal(t, ContractRuntimeInterpreter, contractRuntime().Name())
+}
+
+func TestContractConstructor(t *testing.T) {
+	code, err := ioutil.ReadFile("cmd/wavelet/contracts/token.wasm")
+	assert.NoError(t, err)
+
+	constructor, err := contractConstructor(code)
+	assert.NoError(t, err)
+	assert.Equal(t, "init", constructor)
+
+	// module exports a single function with no locals, taking and returning nothing, under the name export.
+	module := func(export string, body ...byte) []byte {
+		code := []byte{0x00, 0x61, 0x73, 0x6D, 0x01, 0x00, 0x00, 0x00}
+		code = append(code, 0x01, 0x04, 0x01, 0x60, 0x00, 0x00, 0x03, 0x02, 0x01, 0x00)
+
+		entry := append(append([]byte{0x01, byte(len(export))}, export...), 0x00, 0x00)
+		code = append(append(code, 0x07, byte(len(entry))), entry...)
+
+		body = append(append([]byte{0x00}, body...), 0x0B)
+		return append(append(code, 0x0A, byte(len(body)+2), 0x01, byte(len(body))), body...)
+	}
+
+	constructor, err = contractConstructor(module("_contract_constructor"))
+	assert.NoError(t, err)
+	assert.Equal(t, "constructor", constructor)
+
+	_, err = contractConstructor(module("_contract_transfer"))
+	assert.Error(t, err)
+
+	assert.True(t, IsContractConstructor("init"))
+	assert.True(t, IsContractConstructor("constructor"))
+	assert.False(t, IsContractConstructor("transfer"))
+
+	var sender AccountID
+	sender[0] = 0xFF
+
+	spawn := func(code []byte) (*avl.Tree, TransactionID) {
+		snapshot := avl.New(store.NewInmem())
+		WriteAccountBalance(snapshot, sender, 1000000)
+
+		payload := make([]byte, 12)
+		binary.LittleEndian.PutUint64(payload[:8], 100000)
+
+		tx := &Transaction{Sender: sender, Creator: sender, Tag: sys.TagContract, Payload: append(payload, code...)}
+		tx.ID[0] = 0xEE
+
+		_, err := ApplyContractTransaction(snapshot, &Round{}, tx, nil)
+		assert.NoError(t, err)
+
+		return snapshot, tx.ID
+	}
+
+	// The contract is spawned should its constructor succeed.
+	snapshot, id := spawn(module("_contract_init"))
+	_, available := ReadAccountContractCode(snapshot, id)
+	assert.True(t, available)
+
+	// The contract is not spawned should its constructor trap, though gas is still paid for.
+	snapshot, id = spawn(module("_contract_init", 0x00))
+	_, available = ReadAccountContractCode(snapshot, id)
+	assert.False(t, available)
+
+	balance, _ := ReadAccountBalance(snapshot, sender)
+	assert.True(t, balance < 1000000)
+}
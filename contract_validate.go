@@ -0,0 +1,159 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"github.com/go-interpreter/wagon/disasm"
+	"github.com/go-interpreter/wagon/wasm"
+	"github.com/go-interpreter/wagon/wasm/operators"
+	"github.com/perlin-network/life/compiler"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/pkg/errors"
+)
+
+var ErrNondeterministicContract = errors.New("contract: code may not execute deterministically")
+
+// nondeterministicOps are operators whose results may differ between the
+// platforms nodes run on. Floating-point operators otherwise have the NaNs
+// they produce canonicalized by the virtual machine, though converting a NaN
+// between precisions carries over a payload which depends on the platform.
+var nondeterministicOps = map[byte]struct{}{
+	operators.F32DemoteF64:  {},
+	operators.F64PromoteF32: {},
+}
+
+// ValidateContractCode checks that code is a WebAssembly module which every
+// node executes identically before it is registered as a smart contract. The
+// module may only import host functions provided to smart contracts, may not
+// declare memory or tables beyond the limits of the virtual machine, and may
+// not make use of nondeterministic operators.
+func ValidateContractCode(code []byte) error {
+	return validateContractCode(code, sys.Params())
+}
+
+// validateContractCode is ValidateContractCode, with the limits of the
+// virtual machine taken from params.
+func validateContractCode(code []byte, params sys.ConsensusParams) (err error) {
+	// The module loader and disassembler panic on some malformed modules.
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("contract: failed to load module: %v", r)
+		}
+	}()
+
+	module, err := compiler.LoadModule(code)
+	if err != nil {
+		return errors.Wrap(err, "contract: failed to load module")
+	}
+
+	m := module.Base
+
+	if m.Import != nil {
+		for _, entry := range m.Import.Entries {
+			if entry.Type.Kind() != wasm.ExternalFunction {
+				return errors.Errorf("contract: may only import functions, but imports %s %q", entry.Type.Kind(), entry.FieldName)
+			}
+
+			if entry.ModuleName != "env" || !isContractHostFunc(entry.FieldName) {
+				return errors.Errorf("contract: imports unknown function %q from module %q", entry.FieldName, entry.ModuleName)
+			}
+		}
+	}
+
+	if m.Memory != nil {
+		for _, mem := range m.Memory.Entries {
+			if err := checkContractLimits("memory pages", mem.Limits, params.ContractMaxMemoryPages); err != nil {
+				return err
+			}
+		}
+	}
+
+	if m.Table != nil {
+		for _, table := range m.Table.Entries {
+			if err := checkContractLimits("table entries", table.Limits, params.ContractMaxTableSize); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i, fn := range m.FunctionIndexSpace {
+		d, err := disasm.Disassemble(fn, m)
+		if err != nil {
+			return errors.Wrapf(err, "contract: failed to disassemble function %d", i)
+		}
+
+		for _, instr := range d.Code {
+			if _, nondeterministic := nondeterministicOps[instr.Op.Code]; nondeterministic {
+				return errors.Wrapf(ErrNondeterministicContract, "function %d uses operator %s", i, instr.Op.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func checkContractLimits(name string, limits wasm.ResizableLimits, max int) error {
+	if int64(limits.Initial) > int64(max) {
+		return errors.Errorf("contract: declares %d %s initially, but may have at most %d", limits.Initial, name, max)
+	}
+
+	if limits.Flags&1 != 0 && int64(limits.Maximum) > int64(max) {
+		return errors.Errorf("contract: declares up to %d %s, but may have at most %d", limits.Maximum, name, max)
+	}
+
+	return nil
+}
+
+// isContractHostFunc returns whether or not field names a host function
+// provided to smart contracts. Resolving an unknown host function panics.
+func isContractHostFunc(field string) (exists bool) {
+	defer func() {
+		if recover() != nil {
+			exists = false
+		}
+	}()
+
+	return (&ContractExecutor{}).ResolveFunc("env", field) != nil
+}
+
+// contractConstructor returns the name of the function code exports as its
+// constructor. See contractConstructors.
+func contractConstructor(code []byte) (name string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("contract: failed to load module: %v", r)
+		}
+	}()
+
+	module, err := compiler.LoadModule(code)
+	if err != nil {
+		return "", errors.Wrap(err, "contract: failed to load module")
+	}
+
+	if exports := module.Base.Export; exports != nil {
+		for _, name := range contractConstructors {
+			if entry, exists := exports.Entries["_contract_"+name]; exists && entry.Kind == wasm.ExternalFunction {
+				return name, nil
+			}
+		}
+	}
+
+	return "", errors.New(`contract: must export a constructor named "_contract_init" or "_contract_constructor"`)
+}
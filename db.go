@@ -28,6 +28,7 @@ import (
 	"github.com/perlin-network/wavelet/store"
 	"github.com/pkg/errors"
 	"io"
+	"sort"
 	"strconv"
 )
 
@@ -42,6 +43,13 @@ var (
 	keyAccountContractCode     = [...]byte{0x7}
 	keyAccountContractNumPages = [...]byte{0x8}
 	keyAccountContractPages    = [...]byte{0x9}
+	keyAccountContractOwner    = [...]byte{0xA}
+	keyAccountContractPaused   = [...]byte{0xB}
+	keyAccountContractQuota    = [...]byte{0xC}
+	keyAccountContractCalls    = [...]byte{0xD}
+
+	keyAccountDelegation      = [...]byte{0xE}
+	keyAccountDelegationSpent = [...]byte{0xF}
 
 	keyRounds           = [...]byte{0x10}
 	keyRoundLatestIx    = [...]byte{0x11}
@@ -49,8 +57,39 @@ var (
 	keyRoundStoredCount = [...]byte{0x13}
 
 	keyRewardWithdrawals = [...]byte{0x14}
+
+	keyPeers    = [...]byte{0x15}
+	keyPeerBans = [...]byte{0x16}
+
+	keyMetricsHistory = [...]byte{0x17}
+	keyAccountHistory = [...]byte{0x18}
+
+	keyRandomBeacon = [...]byte{0x19}
+
+	keyDataVersion = [...]byte{0x1A}
+
+	keyAccountHistoryFrom = [...]byte{0x1B}
+
+	keyAccountContractNumEvents = [...]byte{0x1C}
+	keyAccountContractEvents    = [...]byte{0x1D}
+
+	keyReceipts = [...]byte{0x1E}
+
+	keyWriteAheadLog = [...]byte{0x1F}
+
+	keyRoundDiffs   = [...]byte{0x20}
+	keyRoundChanges = [...]byte{0x21}
+
+	keyTokens              = [...]byte{0x22}
+	keyAccountTokenBalance = [...]byte{0x23}
 )
 
+// DataVersion is the version of the layout the ledger is persisted under. It
+// is to be bumped whenever the layout changes in a way which older nodes may
+// not read, such that they refuse to open the database instead of corrupting
+// it.
+const DataVersion = 1
+
 type RewardWithdrawalRequest struct {
 	account AccountID
 	amount  uint64
@@ -174,6 +213,40 @@ func WriteAccountReward(tree *avl.Tree, id AccountID, reward uint64) {
 	writeUnderAccounts(tree, id, keyAccountReward[:], buf[:])
 }
 
+// PeekAccountReward reads the reward of an account without it being recorded
+// as read should tree be tracked. See avl.Tree.Peek.
+func PeekAccountReward(tree *avl.Tree, id AccountID) (uint64, bool) {
+	buf, exists := tree.Peek(accountKey(id, keyAccountReward[:]))
+	if !exists || len(buf) == 0 {
+		return 0, false
+	}
+
+	return binary.LittleEndian.Uint64(buf), true
+}
+
+// CreditAccountReward adds amount to the reward of an account through an
+// update, such that it is credited on top of whatever the reward is should
+// the changes made to tree be replayed. It returns false should the reward
+// overflow.
+func CreditAccountReward(tree *avl.Tree, id AccountID, amount uint64) bool {
+	return tree.Update(accountKey(id, keyAccountReward[:]), func(value []byte, exists bool) ([]byte, bool) {
+		var reward uint64
+		if exists && len(value) > 0 {
+			reward = binary.LittleEndian.Uint64(value)
+		}
+
+		credited, err := Amount(reward).Add(Amount(amount))
+		if err != nil {
+			return nil, false
+		}
+
+		var buf [8]byte
+		binary.LittleEndian.PutUint64(buf[:], uint64(credited))
+
+		return buf[:], true
+	})
+}
+
 func ReadAccountContractCode(tree *avl.Tree, id TransactionID) ([]byte, bool) {
 	buf, exists := readUnderAccounts(tree, id, keyAccountContractCode[:])
 	if !exists || len(buf) == 0 {
@@ -229,8 +302,157 @@ func WriteAccountContractPage(tree *avl.Tree, id TransactionID, idx uint64, page
 	writeUnderAccounts(tree, id, append(keyAccountContractPages[:], buf[:]...), encoded)
 }
 
+func ReadAccountContractOwner(tree *avl.Tree, id TransactionID) (AccountID, bool) {
+	var owner AccountID
+
+	buf, exists := readUnderAccounts(tree, id, keyAccountContractOwner[:])
+	if !exists || len(buf) != SizeAccountID {
+		return owner, false
+	}
+
+	copy(owner[:], buf)
+
+	return owner, true
+}
+
+func WriteAccountContractOwner(tree *avl.Tree, id TransactionID, owner AccountID) {
+	writeUnderAccounts(tree, id, keyAccountContractOwner[:], owner[:])
+}
+
+func ReadAccountContractPaused(tree *avl.Tree, id TransactionID) bool {
+	buf, exists := readUnderAccounts(tree, id, keyAccountContractPaused[:])
+	return exists && len(buf) == 1 && buf[0] == 1
+}
+
+func WriteAccountContractPaused(tree *avl.Tree, id TransactionID, paused bool) {
+	if !paused {
+		deleteUnderAccounts(tree, id, keyAccountContractPaused[:])
+		return
+	}
+
+	writeUnderAccounts(tree, id, keyAccountContractPaused[:], []byte{1})
+}
+
+// ReadAccountContractCallQuota reads the max number of times a contract may be invoked per round. A quota of zero
+// means the contract may be invoked any number of times.
+func ReadAccountContractCallQuota(tree *avl.Tree, id TransactionID) (uint64, bool) {
+	buf, exists := readUnderAccounts(tree, id, keyAccountContractQuota[:])
+	if !exists || len(buf) == 0 {
+		return 0, false
+	}
+
+	return binary.LittleEndian.Uint64(buf), true
+}
+
+func WriteAccountContractCallQuota(tree *avl.Tree, id TransactionID, quota uint64) {
+	if quota == 0 {
+		deleteUnderAccounts(tree, id, keyAccountContractQuota[:])
+		return
+	}
+
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], quota)
+
+	writeUnderAccounts(tree, id, keyAccountContractQuota[:], buf[:])
+}
+
+// ReadAccountContractCalls reads the number of times a contract has been invoked in the round it was last invoked in.
+func ReadAccountContractCalls(tree *avl.Tree, id TransactionID) (round uint64, calls uint64) {
+	buf, exists := readUnderAccounts(tree, id, keyAccountContractCalls[:])
+	if !exists || len(buf) != 16 {
+		return 0, 0
+	}
+
+	return binary.LittleEndian.Uint64(buf[:8]), binary.LittleEndian.Uint64(buf[8:16])
+}
+
+func WriteAccountContractCalls(tree *avl.Tree, id TransactionID, round uint64, calls uint64) {
+	var buf [16]byte
+	binary.LittleEndian.PutUint64(buf[:8], round)
+	binary.LittleEndian.PutUint64(buf[8:16], calls)
+
+	writeUnderAccounts(tree, id, keyAccountContractCalls[:], buf[:])
+}
+
+// ReadAccountContractNumEvents reads the number of events a contract has emitted.
+func ReadAccountContractNumEvents(tree *avl.Tree, id TransactionID) uint64 {
+	buf, exists := readUnderAccounts(tree, id, keyAccountContractNumEvents[:])
+	if !exists || len(buf) != 8 {
+		return 0
+	}
+
+	return binary.LittleEndian.Uint64(buf)
+}
+
+func WriteAccountContractNumEvents(tree *avl.Tree, id TransactionID, numEvents uint64) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], numEvents)
+
+	writeUnderAccounts(tree, id, keyAccountContractNumEvents[:], buf[:])
+}
+
+func ReadAccountContractEvent(tree *avl.Tree, id TransactionID, idx uint64) (ContractEvent, bool) {
+	var idxBuf [8]byte
+	binary.LittleEndian.PutUint64(idxBuf[:], idx)
+
+	buf, exists := readUnderAccounts(tree, id, append(keyAccountContractEvents[:], idxBuf[:]...))
+	if !exists {
+		return ContractEvent{}, false
+	}
+
+	event, err := UnmarshalContractEvent(buf)
+	if err != nil {
+		return ContractEvent{}, false
+	}
+
+	event.Contract, event.Index = id, idx
+
+	return event, true
+}
+
+func WriteAccountContractEvent(tree *avl.Tree, event ContractEvent) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], event.Index)
+
+	writeUnderAccounts(tree, event.Contract, append(keyAccountContractEvents[:], buf[:]...), event.Marshal())
+}
+
+// AccountNonceKey returns the key the nonce of an account is stored under in
+// the state of the ledger.
+func AccountNonceKey(id AccountID) []byte {
+	return accountKey(id, keyAccountNonce[:])
+}
+
+// AccountBalanceKey returns the key the balance of an account is stored under
+// in the state of the ledger.
+func AccountBalanceKey(id AccountID) []byte {
+	return accountKey(id, keyAccountBalance[:])
+}
+
+// AccountStakeKey returns the key the stake of an account is stored under in
+// the state of the ledger.
+func AccountStakeKey(id AccountID) []byte {
+	return accountKey(id, keyAccountStake[:])
+}
+
+// AccountRewardKey returns the key the reward of an account is stored under
+// in the state of the ledger.
+func AccountRewardKey(id AccountID) []byte {
+	return accountKey(id, keyAccountReward[:])
+}
+
+// AccountContractCodeKey returns the key the code of a smart contract is
+// stored under in the state of the ledger.
+func AccountContractCodeKey(id AccountID) []byte {
+	return accountKey(id, keyAccountContractCode[:])
+}
+
+func accountKey(id AccountID, key []byte) []byte {
+	return append(keyAccounts[:], append(key, id[:]...)...)
+}
+
 func readUnderAccounts(tree *avl.Tree, id AccountID, key []byte) ([]byte, bool) {
-	buf, exists := tree.Lookup(append(keyAccounts[:], append(key, id[:]...)...))
+	buf, exists := tree.Lookup(accountKey(id, key))
 
 	if !exists {
 		return nil, false
@@ -240,7 +462,11 @@ func readUnderAccounts(tree *avl.Tree, id AccountID, key []byte) ([]byte, bool)
 }
 
 func writeUnderAccounts(tree *avl.Tree, id AccountID, key, value []byte) {
-	tree.Insert(append(keyAccounts[:], append(key, id[:]...)...), value[:])
+	tree.Insert(accountKey(id, key), value[:])
+}
+
+func deleteUnderAccounts(tree *avl.Tree, id AccountID, key []byte) {
+	tree.Delete(accountKey(id, key))
 }
 
 func ReadAccountsLen(tree *avl.Tree) uint64 {
@@ -259,24 +485,26 @@ func WriteAccountsLen(tree *avl.Tree, size uint64) {
 	tree.Insert(keyAccountsLen[:], buf[:])
 }
 
+// StoreRound stores round at index currentIx of the ring buffer of rounds, alongside
+// the indices of the latest and oldest rounds in the buffer and the number of
+// rounds stored. All are stored in a single write batch, such that the ring
+// buffer is never left half updated.
 func StoreRound(kv store.KV, round Round, currentIx, oldestIx uint32, storedCount uint8) error {
-	if err := kv.Put(keyRoundStoredCount[:], []byte{byte(storedCount)}); err != nil {
-		return errors.Wrap(err, "error storing stored rounds count")
-	}
+	batch := kv.NewWriteBatch()
+
+	batch.Put(keyRoundStoredCount[:], []byte{byte(storedCount)})
 
 	var oldestIxBuf [4]byte
 	binary.BigEndian.PutUint32(oldestIxBuf[:], oldestIx)
-	if err := kv.Put(keyRoundOldestIx[:], oldestIxBuf[:]); err != nil {
-		return errors.Wrap(err, "error storing oldest round index")
-	}
+	batch.Put(keyRoundOldestIx[:], oldestIxBuf[:])
 
 	var currentIxBuf [4]byte
 	binary.BigEndian.PutUint32(currentIxBuf[:], currentIx)
-	if err := kv.Put(keyRoundLatestIx[:], currentIxBuf[:]); err != nil {
-		return errors.Wrap(err, "error storing latest round index")
-	}
+	batch.Put(keyRoundLatestIx[:], currentIxBuf[:])
+
+	batch.Put(append(keyRounds[:], strconv.Itoa(int(currentIx))...), round.Marshal())
 
-	if err := kv.Put(append(keyRounds[:], strconv.Itoa(int(currentIx))...), round.Marshal()); err != nil {
+	if err := kv.CommitWriteBatch(batch); err != nil {
 		return errors.Wrap(err, "error storing round")
 	}
 
@@ -323,6 +551,143 @@ func LoadRounds(kv store.KV) ([]*Round, uint32, uint32, error) {
 	return rounds, latestIx, oldestIx, nil
 }
 
+// CheckDataVersion checks that the database kv was written under a layout
+// this node is able to read. A database without a version marker is either
+// empty, or was written before markers were introduced, and is marked as
+// being of the current version.
+func CheckDataVersion(kv store.KV) error {
+	buf, err := kv.Get(keyDataVersion[:])
+	if err != nil || len(buf) == 0 {
+		var marker [4]byte
+		binary.BigEndian.PutUint32(marker[:], DataVersion)
+
+		if err := kv.Put(keyDataVersion[:], marker[:]); err != nil {
+			return errors.Wrap(err, "error storing data version")
+		}
+
+		return nil
+	}
+
+	if len(buf) != 4 {
+		return errors.Errorf("data version marker must be 4 bytes, but got %d bytes", len(buf))
+	}
+
+	version := binary.BigEndian.Uint32(buf)
+
+	if version > DataVersion {
+		return errors.Errorf("database was written by a newer node under data version %d, but this node only supports up to data version %d: upgrade the node", version, DataVersion)
+	}
+
+	if version < DataVersion {
+		return errors.Errorf("database was written under data version %d, but this node requires data version %d: restore it from a backup taken by a newer node, or resync from scratch", version, DataVersion)
+	}
+
+	return nil
+}
+
+func StorePeerAddress(kv store.KV, address string) error {
+	if err := kv.Put(append(keyPeers[:], address...), []byte{}); err != nil {
+		return errors.Wrap(err, "error storing peer address")
+	}
+
+	return nil
+}
+
+func LoadPeerAddresses(kv store.KV) ([]string, error) {
+	var addresses []string
+
+	err := kv.Scan(keyPeers[:], func(key, value []byte) bool {
+		addresses = append(addresses, string(key[len(keyPeers):]))
+		return true
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error loading peer addresses")
+	}
+
+	return addresses, nil
+}
+
+func StorePeerBan(kv store.KV, id AccountID) error {
+	if err := kv.Put(append(keyPeerBans[:], id[:]...), []byte{}); err != nil {
+		return errors.Wrap(err, "error storing peer ban")
+	}
+
+	return nil
+}
+
+func DeletePeerBan(kv store.KV, id AccountID) error {
+	if err := kv.Delete(append(keyPeerBans[:], id[:]...)); err != nil {
+		return errors.Wrap(err, "error deleting peer ban")
+	}
+
+	return nil
+}
+
+func LoadPeerBans(kv store.KV) ([]AccountID, error) {
+	var ids []AccountID
+
+	err := kv.Scan(keyPeerBans[:], func(key, value []byte) bool {
+		var id AccountID
+		copy(id[:], key[len(keyPeerBans):])
+
+		ids = append(ids, id)
+		return true
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error loading peer bans")
+	}
+
+	return ids, nil
+}
+
+// StoreMetricsSnapshot stores snapshot into a ring buffer of size slots, overwriting
+// whichever snapshot was stored size snapshots ago.
+func StoreMetricsSnapshot(kv store.KV, snapshot MetricsSnapshot, size int) error {
+	var slot [4]byte
+	binary.BigEndian.PutUint32(slot[:], uint32(snapshot.Seq%uint64(size)))
+
+	if err := kv.Put(append(keyMetricsHistory[:], slot[:]...), snapshot.Marshal()); err != nil {
+		return errors.Wrap(err, "error storing metrics snapshot")
+	}
+
+	return nil
+}
+
+// LoadMetricsHistory loads all stored metrics snapshots, ordered from oldest to newest.
+func LoadMetricsHistory(kv store.KV) ([]MetricsSnapshot, error) {
+	var (
+		snapshots []MetricsSnapshot
+		err       error
+	)
+
+	scanErr := kv.Scan(keyMetricsHistory[:], func(key, value []byte) bool {
+		var snapshot MetricsSnapshot
+
+		if snapshot, err = UnmarshalMetricsSnapshot(value); err != nil {
+			return false
+		}
+
+		snapshots = append(snapshots, snapshot)
+		return true
+	})
+
+	if scanErr != nil {
+		err = scanErr
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error loading metrics history")
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Seq < snapshots[j].Seq
+	})
+
+	return snapshots, nil
+}
+
 func GetRewardWithdrawalRequests(tree *avl.Tree, roundLimit uint64) []RewardWithdrawalRequest {
 	var rws []RewardWithdrawalRequest
 
@@ -345,3 +710,296 @@ func GetRewardWithdrawalRequests(tree *avl.Tree, roundLimit uint64) []RewardWith
 func StoreRewardWithdrawalRequest(tree *avl.Tree, rw RewardWithdrawalRequest) {
 	tree.Insert(rw.Key(), rw.Marshal())
 }
+
+func accountHistoryKey(id AccountID, round uint64) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], round)
+
+	return append(append(keyAccountHistory[:], id[:]...), buf[:]...)
+}
+
+// StoreAccountDeltas stores how the balances and stakes of accounts changed in
+// a single round. Deltas are keyed by account, and then by round.
+func StoreAccountDeltas(kv store.KV, deltas map[AccountID]AccountDelta) error {
+	if len(deltas) == 0 {
+		return nil
+	}
+
+	batch := kv.NewWriteBatch()
+
+	for id, delta := range deltas {
+		batch.Put(accountHistoryKey(id, delta.Round), delta.Marshal())
+	}
+
+	if err := kv.CommitWriteBatch(batch); err != nil {
+		return errors.Wrap(err, "error storing account deltas")
+	}
+
+	return nil
+}
+
+// StoreAccountHistoryFrom stores the index of the round from which onwards the
+// deltas of accounts have been stored for every round.
+func StoreAccountHistoryFrom(kv store.KV, round uint64) error {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], round)
+
+	if err := kv.Put(keyAccountHistoryFrom[:], buf[:]); err != nil {
+		return errors.Wrap(err, "error storing the round account history is stored from")
+	}
+
+	return nil
+}
+
+// LoadAccountHistoryFrom loads the index of the round from which onwards the
+// deltas of accounts have been stored for every round.
+func LoadAccountHistoryFrom(kv store.KV) (uint64, error) {
+	buf, err := kv.Get(keyAccountHistoryFrom[:])
+	if err != nil {
+		return 0, errors.Wrap(err, "error loading the round account history is stored from")
+	}
+
+	if len(buf) != 8 {
+		return 0, errors.Errorf("round account history is stored from must be 8 bytes, but got %d bytes", len(buf))
+	}
+
+	return binary.BigEndian.Uint64(buf), nil
+}
+
+// LoadAccountHistory loads up to limit deltas of the account id recorded in
+// rounds after the round since, ordered from oldest to newest. A limit of
+// zero or less is unlimited.
+func LoadAccountHistory(kv store.KV, id AccountID, since uint64, limit int) ([]AccountDelta, error) {
+	var (
+		deltas []AccountDelta
+		err    error
+	)
+
+	prefix := append(keyAccountHistory[:], id[:]...)
+
+	scanErr := kv.Scan(prefix, func(key, value []byte) bool {
+		if binary.BigEndian.Uint64(key[len(prefix):]) <= since {
+			return true
+		}
+
+		var delta AccountDelta
+
+		if delta, err = UnmarshalAccountDelta(value); err != nil {
+			return false
+		}
+
+		deltas = append(deltas, delta)
+
+		return limit <= 0 || len(deltas) < limit
+	})
+
+	if scanErr != nil {
+		err = scanErr
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "error loading account history")
+	}
+
+	return deltas, nil
+}
+
+func roundKey(prefix []byte, round uint64) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], round)
+
+	return append(prefix, buf[:]...)
+}
+
+// StoreRoundDiff stores the diff of the ledger state made by a single round.
+func StoreRoundDiff(kv store.KV, round uint64, diff []byte) error {
+	if err := kv.Put(roundKey(keyRoundDiffs[:], round), diff); err != nil {
+		return errors.Wrapf(err, "error storing diff of round %d", round)
+	}
+
+	return nil
+}
+
+// LoadRoundDiff loads the diff of the ledger state made by a single round.
+func LoadRoundDiff(kv store.KV, round uint64) ([]byte, error) {
+	buf, err := kv.Get(roundKey(keyRoundDiffs[:], round))
+	if err != nil {
+		return nil, errors.Wrapf(err, "error loading diff of round %d", round)
+	}
+
+	return buf, nil
+}
+
+// StoreRoundChanges stores how the balances and stakes of accounts changed in
+// a single round. Unlike StoreAccountDeltas, deltas are keyed by round, and
+// then by account.
+func StoreRoundChanges(kv store.KV, round uint64, deltas map[AccountID]AccountDelta) error {
+	if len(deltas) == 0 {
+		return nil
+	}
+
+	batch := kv.NewWriteBatch()
+
+	for id, delta := range deltas {
+		batch.Put(append(roundKey(keyRoundChanges[:], round), id[:]...), delta.Marshal())
+	}
+
+	if err := kv.CommitWriteBatch(batch); err != nil {
+		return errors.Wrapf(err, "error storing account changes of round %d", round)
+	}
+
+	return nil
+}
+
+// LoadRoundChanges loads how the balances and stakes of accounts changed in a
+// single round, ordered by account.
+func LoadRoundChanges(kv store.KV, round uint64) ([]AccountChange, error) {
+	var (
+		changes []AccountChange
+		err     error
+	)
+
+	prefix := roundKey(keyRoundChanges[:], round)
+
+	scanErr := kv.Scan(prefix, func(key, value []byte) bool {
+		var change AccountChange
+		copy(change.Account[:], key[len(prefix):])
+
+		if change.AccountDelta, err = UnmarshalAccountDelta(value); err != nil {
+			return false
+		}
+
+		changes = append(changes, change)
+
+		return true
+	})
+
+	if scanErr != nil {
+		err = scanErr
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "error loading account changes of round %d", round)
+	}
+
+	return changes, nil
+}
+
+// PruneAccountHistory deletes the deltas of all accounts recorded in the round
+// with index round and in rounds before it. It returns the number of deltas
+// deleted.
+func PruneAccountHistory(kv store.KV, round uint64) (int, error) {
+	var keys [][]byte
+
+	err := kv.Scan(keyAccountHistory[:], func(key, value []byte) bool {
+		if binary.BigEndian.Uint64(key[len(key)-8:]) <= round {
+			keys = append(keys, append([]byte(nil), key...))
+		}
+
+		return true
+	})
+
+	if err != nil {
+		return 0, errors.Wrap(err, "error scanning account history")
+	}
+
+	return deleteKeys(kv, keys, "account delta")
+}
+
+// StoreReceipts stores the receipts of transactions finalized in a single
+// round. Receipts are keyed by the ID of their transaction.
+func StoreReceipts(kv store.KV, receipts []*Receipt) error {
+	if len(receipts) == 0 {
+		return nil
+	}
+
+	batch := kv.NewWriteBatch()
+
+	for _, receipt := range receipts {
+		batch.Put(append(keyReceipts[:], receipt.TxID[:]...), receipt.Marshal())
+	}
+
+	if err := kv.CommitWriteBatch(batch); err != nil {
+		return errors.Wrap(err, "error storing transaction receipts")
+	}
+
+	return nil
+}
+
+// StoreWriteAheadLog records that the ledger is about to advance to round, such
+// that should the ledger stop while advancing, it may be rolled forward to, or
+// back from round once restarted. See recoverWriteAheadLog.
+func StoreWriteAheadLog(kv store.KV, round Round) error {
+	if err := kv.Put(keyWriteAheadLog[:], round.Marshal()); err != nil {
+		return errors.Wrap(err, "error storing write-ahead log")
+	}
+
+	return nil
+}
+
+// LoadWriteAheadLog loads the round the ledger was last recorded to be about
+// to advance to. It returns false should the ledger not have been advancing.
+func LoadWriteAheadLog(kv store.KV) (Round, bool, error) {
+	buf, err := kv.Get(keyWriteAheadLog[:])
+	if err != nil || len(buf) == 0 {
+		return Round{}, false, nil
+	}
+
+	round, err := UnmarshalRound(bytes.NewReader(buf))
+	if err != nil {
+		return Round{}, false, errors.Wrap(err, "error unmarshaling write-ahead log")
+	}
+
+	return round, true, nil
+}
+
+// ClearWriteAheadLog records that the ledger has finished advancing.
+func ClearWriteAheadLog(kv store.KV) error {
+	if err := kv.Delete(keyWriteAheadLog[:]); err != nil {
+		return errors.Wrap(err, "error clearing write-ahead log")
+	}
+
+	return nil
+}
+
+// PruneReceipts deletes the receipts of all transactions finalized in the round
+// with index round and in rounds before it. It returns the number of receipts
+// deleted.
+func PruneReceipts(kv store.KV, round uint64) (int, error) {
+	var keys [][]byte
+
+	err := kv.Scan(keyReceipts[:], func(key, value []byte) bool {
+		// Receipts are prefixed with the ID of their transaction, followed
+		// by the index of the round it was finalized in.
+		if len(value) >= SizeTransactionID+8 && binary.BigEndian.Uint64(value[SizeTransactionID:SizeTransactionID+8]) <= round {
+			keys = append(keys, append([]byte(nil), key...))
+		}
+
+		return true
+	})
+
+	if err != nil {
+		return 0, errors.Wrap(err, "error scanning transaction receipts")
+	}
+
+	return deleteKeys(kv, keys, "transaction receipt")
+}
+
+func deleteKeys(kv store.KV, keys [][]byte, kind string) (int, error) {
+	for i, key := range keys {
+		if err := kv.Delete(key); err != nil {
+			return i, errors.Wrapf(err, "error deleting %s", kind)
+		}
+	}
+
+	return len(keys), nil
+}
+
+func LoadReceipt(kv store.KV, id TransactionID) (*Receipt, error) {
+	buf, err := kv.Get(append(keyReceipts[:], id[:]...))
+	if err != nil {
+		return nil, errors.Wrapf(err, "error loading receipt of transaction %x", id)
+	}
+
+	return UnmarshalReceipt(buf)
+}
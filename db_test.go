@@ -26,6 +26,7 @@ import (
 	"math/rand"
 	"sort"
 	"testing"
+	"time"
 )
 
 func TestRewardWithdrawals(t *testing.T) {
@@ -56,3 +57,225 @@ func TestRewardWithdrawals(t *testing.T) {
 	assert.Equal(t, 7, len(rws))
 	assert.True(t, sort.SliceIsSorted(rws, func(i, j int) bool { return rws[i].round < rws[j].round }))
 }
+
+func TestPeerAddresses(t *testing.T) {
+	kv := store.NewInmem()
+
+	addresses, err := LoadPeerAddresses(kv)
+	assert.NoError(t, err)
+	assert.Empty(t, addresses)
+
+	assert.NoError(t, StorePeerAddress(kv, "127.0.0.1:3001"))
+	assert.NoError(t, StorePeerAddress(kv, "127.0.0.1:3000"))
+	assert.NoError(t, StorePeerAddress(kv, "127.0.0.1:3001"))
+
+	addresses, err = LoadPeerAddresses(kv)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"127.0.0.1:3000", "127.0.0.1:3001"}, addresses)
+}
+
+func TestDataVersion(t *testing.T) {
+	kv := store.NewInmem()
+
+	assert.NoError(t, CheckDataVersion(kv))
+	assert.NoError(t, CheckDataVersion(kv))
+
+	buf, err := kv.Get(keyDataVersion[:])
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0, 0, 0, DataVersion}, buf)
+
+	assert.NoError(t, kv.Put(keyDataVersion[:], []byte{0, 0, 0, DataVersion + 1}))
+	assert.Error(t, CheckDataVersion(kv))
+
+	assert.NoError(t, kv.Put(keyDataVersion[:], []byte{0, 0, 0, DataVersion - 1}))
+	assert.Error(t, CheckDataVersion(kv))
+
+	assert.NoError(t, kv.Put(keyDataVersion[:], []byte{DataVersion}))
+	assert.Error(t, CheckDataVersion(kv))
+}
+
+func TestPeerBans(t *testing.T) {
+	kv := store.NewInmem()
+
+	ids, err := LoadPeerBans(kv)
+	assert.NoError(t, err)
+	assert.Empty(t, ids)
+
+	var a, b AccountID
+	a[0], b[0] = 1, 2
+
+	assert.NoError(t, StorePeerBan(kv, b))
+	assert.NoError(t, StorePeerBan(kv, a))
+
+	ids, err = LoadPeerBans(kv)
+	assert.NoError(t, err)
+	assert.Equal(t, []AccountID{a, b}, ids)
+
+	assert.NoError(t, DeletePeerBan(kv, a))
+
+	ids, err = LoadPeerBans(kv)
+	assert.NoError(t, err)
+	assert.Equal(t, []AccountID{b}, ids)
+}
+
+func TestMetricsHistory(t *testing.T) {
+	kv := store.NewInmem()
+
+	history, err := LoadMetricsHistory(kv)
+	assert.NoError(t, err)
+	assert.Empty(t, history)
+
+	start := time.Unix(0, 1565000000000000000)
+
+	for seq := uint64(0); seq < 5; seq++ {
+		snapshot := MetricsSnapshot{
+			Seq:             seq,
+			Time:            start.Add(time.Duration(seq) * time.Minute),
+			RoundIndex:      seq * 10,
+			NumPeers:        uint32(seq),
+			MempoolSize:     uint32(seq * 100),
+			FinalityLatency: time.Duration(seq) * time.Millisecond,
+		}
+
+		assert.NoError(t, StoreMetricsSnapshot(kv, snapshot, 3))
+	}
+
+	// Only the latest three snapshots remain, ordered from oldest to newest.
+
+	history, err = LoadMetricsHistory(kv)
+	assert.NoError(t, err)
+
+	if assert.Len(t, history, 3) {
+		for i, snapshot := range history {
+			seq := uint64(i + 2)
+
+			assert.Equal(t, seq, snapshot.Seq)
+			assert.True(t, start.Add(time.Duration(seq)*time.Minute).Equal(snapshot.Time))
+			assert.Equal(t, seq*10, snapshot.RoundIndex)
+			assert.EqualValues(t, seq, snapshot.NumPeers)
+			assert.EqualValues(t, seq*100, snapshot.MempoolSize)
+			assert.Equal(t, time.Duration(seq)*time.Millisecond, snapshot.FinalityLatency)
+		}
+	}
+
+	_, err = UnmarshalMetricsSnapshot(make([]byte, SizeMetricsSnapshot-1))
+	assert.Error(t, err)
+}
+
+func TestAccountHistory(t *testing.T) {
+	kv := store.NewInmem()
+	tree := avl.New(kv)
+
+	var a, b, c, d AccountID
+	a[0], b[0], c[0], d[0] = 1, 2, 3, 4
+
+	WriteAccountBalance(tree, a, 100)
+	WriteAccountBalance(tree, c, 100)
+	WriteAccountStake(tree, b, 50)
+
+	prev := tree.Snapshot()
+
+	tree.SetViewID(1)
+
+	WriteAccountBalance(tree, a, 70)
+	WriteAccountBalance(tree, b, 30)
+	WriteAccountStake(tree, b, 60)
+	WriteAccountBalance(tree, c, 100)
+	WriteAccountReward(tree, d, 10)
+
+	// Accounts whose balance and stake are left unchanged have no delta.
+	deltas := accountDeltas(prev, tree, 0, 1)
+	assert.Len(t, deltas, 2)
+	assert.Equal(t, AccountDelta{Round: 1, PrevBalance: 100, Balance: 70}, deltas[a])
+	assert.Equal(t, AccountDelta{Round: 1, PrevBalance: 0, Balance: 30, PrevStake: 50, Stake: 60}, deltas[b])
+
+	assert.NoError(t, StoreAccountDeltas(kv, deltas))
+
+	for round := uint64(2); round <= 5; round++ {
+		assert.NoError(t, StoreAccountDeltas(kv, map[AccountID]AccountDelta{
+			a: {Round: round, PrevBalance: 70 + round - 2, Balance: 70 + round - 1},
+		}))
+	}
+
+	history, err := LoadAccountHistory(kv, a, 0, 0)
+	assert.NoError(t, err)
+
+	if assert.Len(t, history, 5) {
+		for i, delta := range history {
+			assert.Equal(t, uint64(i+1), delta.Round)
+		}
+	}
+
+	history, err = LoadAccountHistory(kv, a, 2, 2)
+	assert.NoError(t, err)
+
+	if assert.Len(t, history, 2) {
+		assert.Equal(t, uint64(3), history[0].Round)
+		assert.Equal(t, uint64(4), history[1].Round)
+	}
+
+	history, err = LoadAccountHistory(kv, b, 1, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, history)
+
+	history, err = LoadAccountHistory(kv, c, 0, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, history)
+
+	_, err = UnmarshalAccountDelta(make([]byte, SizeAccountDelta-1))
+	assert.Error(t, err)
+}
+
+func TestReceipts(t *testing.T) {
+	kv := store.NewInmem()
+
+	var txID, contract TransactionID
+	rand.Read(txID[:])
+	rand.Read(contract[:])
+
+	receipt := &Receipt{TxID: txID, Round: 3}
+
+	// Only the invocation made by the transaction itself has its result
+	// recorded, and events emitted by reverted invocations are discarded.
+
+	receipt.recordExecution(&ContractExecutor{
+		Gas:    10,
+		Error:  []byte("ok"),
+		Events: []ContractEvent{{Contract: contract, Index: 4, TxID: txID, Topic: "a", Payload: []byte{1}}},
+	}, false)
+
+	receipt.recordChildren([]*Transaction{{Sender: AccountID(contract), Creator: AccountID(contract), Tag: 1, Payload: []byte{2, 3}}})
+
+	receipt.recordExecution(&ContractExecutor{
+		Gas:      5,
+		Error:    []byte("failed"),
+		Reverted: true,
+		Events:   []ContractEvent{{Contract: contract, Index: 5, TxID: txID, Topic: "b"}},
+	}, true)
+
+	assert.True(t, receipt.Applied())
+	assert.EqualValues(t, 15, receipt.GasUsed)
+	assert.False(t, receipt.Reverted)
+	assert.Equal(t, []byte("ok"), receipt.Result)
+	assert.Len(t, receipt.Events, 1)
+	assert.Len(t, receipt.Children, 1)
+
+	rejected := &Receipt{TxID: contract, Round: 3, Error: "transfer: not enough PERLs"}
+
+	assert.NoError(t, StoreReceipts(kv, []*Receipt{receipt, rejected}))
+
+	loaded, err := LoadReceipt(kv, txID)
+	assert.NoError(t, err)
+	assert.Equal(t, receipt, loaded)
+
+	loaded, err = LoadReceipt(kv, contract)
+	assert.NoError(t, err)
+	assert.Equal(t, rejected, loaded)
+	assert.False(t, loaded.Applied())
+
+	_, err = LoadReceipt(kv, ZeroTransactionID)
+	assert.Error(t, err)
+
+	_, err = UnmarshalReceipt(receipt.Marshal()[:40])
+	assert.Error(t, err)
+}
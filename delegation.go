@@ -0,0 +1,123 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"encoding/binary"
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/perlin-network/wavelet/sys"
+)
+
+// SizeDelegation is the size of a marshaled delegation in bytes.
+const SizeDelegation = 8 + 4 + 8
+
+// delegableTags is the set of tags of transactions which may be made on behalf
+// of an account by a session key. Batches and delegations themselves may not
+// be delegated, such that the tags allowed by a delegation may not be evaded.
+const delegableTags = 1<<sys.TagNop | 1<<sys.TagTransfer | 1<<sys.TagContract | 1<<sys.TagStake | 1<<sys.TagContractAdmin | 1<<sys.TagUpdateContract
+
+// Delegation authorizes a session key to make transactions on behalf of an
+// account, such that applications may act for a user without holding their
+// main key. Transactions made by a session key pay their fees from the
+// balance of the session key, though any PERLs they spend are deducted from
+// the account they are made on behalf of.
+type Delegation struct {
+	// MaxSpend is the number of PERLs the session key may spend from the
+	// balance of the account within a single round.
+	MaxSpend uint64
+
+	// AllowedTags is the set of tags of transactions the session key may make,
+	// with bit i set should tag i be allowed.
+	AllowedTags uint32
+
+	// Expiry is the index of the round from which onwards the delegation is
+	// no longer valid.
+	Expiry uint64
+}
+
+// Allows returns whether or not the session key may make transactions with
+// the specified tag.
+func (d Delegation) Allows(tag byte) bool {
+	return tag < 32 && d.AllowedTags&(1<<tag) != 0
+}
+
+func (d Delegation) Marshal() []byte {
+	var buf [SizeDelegation]byte
+
+	binary.LittleEndian.PutUint64(buf[0:8], d.MaxSpend)
+	binary.LittleEndian.PutUint32(buf[8:12], d.AllowedTags)
+	binary.LittleEndian.PutUint64(buf[12:20], d.Expiry)
+
+	return buf[:]
+}
+
+// UnmarshalDelegation decodes a delegation from buf, which must be at least
+// SizeDelegation bytes.
+func UnmarshalDelegation(buf []byte) Delegation {
+	return Delegation{
+		MaxSpend:    binary.LittleEndian.Uint64(buf[0:8]),
+		AllowedTags: binary.LittleEndian.Uint32(buf[8:12]),
+		Expiry:      binary.LittleEndian.Uint64(buf[12:20]),
+	}
+}
+
+// ReadAccountDelegation reads the delegation an account has granted to a session key.
+func ReadAccountDelegation(tree *avl.Tree, id AccountID, key AccountID) (Delegation, bool) {
+	buf, exists := tree.Lookup(delegationKey(id, keyAccountDelegation[:], key))
+	if !exists || len(buf) != SizeDelegation {
+		return Delegation{}, false
+	}
+
+	return UnmarshalDelegation(buf), true
+}
+
+func WriteAccountDelegation(tree *avl.Tree, id AccountID, key AccountID, delegation Delegation) {
+	tree.Insert(delegationKey(id, keyAccountDelegation[:], key), delegation.Marshal())
+}
+
+// DeleteAccountDelegation revokes the delegation an account has granted to a
+// session key, alongside the record of what the session key has spent.
+func DeleteAccountDelegation(tree *avl.Tree, id AccountID, key AccountID) {
+	tree.Delete(delegationKey(id, keyAccountDelegation[:], key))
+	tree.Delete(delegationKey(id, keyAccountDelegationSpent[:], key))
+}
+
+// ReadAccountDelegationSpent reads the number of PERLs a session key has spent on behalf of an account in the round
+// it last spent PERLs in.
+func ReadAccountDelegationSpent(tree *avl.Tree, id AccountID, key AccountID) (round uint64, spent uint64) {
+	buf, exists := tree.Lookup(delegationKey(id, keyAccountDelegationSpent[:], key))
+	if !exists || len(buf) != 16 {
+		return 0, 0
+	}
+
+	return binary.LittleEndian.Uint64(buf[:8]), binary.LittleEndian.Uint64(buf[8:16])
+}
+
+func WriteAccountDelegationSpent(tree *avl.Tree, id AccountID, key AccountID, round uint64, spent uint64) {
+	var buf [16]byte
+	binary.LittleEndian.PutUint64(buf[:8], round)
+	binary.LittleEndian.PutUint64(buf[8:16], spent)
+
+	tree.Insert(delegationKey(id, keyAccountDelegationSpent[:], key), buf[:])
+}
+
+func delegationKey(id AccountID, prefix []byte, key AccountID) []byte {
+	return append(accountKey(id, prefix), key[:]...)
+}
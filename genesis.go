@@ -20,10 +20,18 @@
 package wavelet
 
 import (
+	"bytes"
 	"encoding/hex"
 	"github.com/perlin-network/wavelet/avl"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/perlin-network/wavelet/sys"
 	"github.com/pkg/errors"
 	"github.com/valyala/fastjson"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
 )
 
 const defaultGenesis = `
@@ -41,9 +49,324 @@ const defaultGenesis = `
 }
 `
 
-// performInception loads data expected to exist at the birth of any node in this ledgers network.
-// The data is fed in as .json.
-func performInception(tree *avl.Tree, genesis *string) Round {
+// Versions of the genesis file format. Version 1 files are a single JSON object
+// mapping account IDs to their initial fields, and carry no version field.
+// Version 2 files additionally support pre-deployed contracts and overrides
+// of consensus parameters.
+const (
+	GenesisVersion1 = 1
+	GenesisVersion2 = 2
+)
+
+// maxGenesisContractPages is the max number of pages a WebAssembly module's
+// linear memory may span.
+const maxGenesisContractPages = 65536
+
+// wasmMagic is the magic number every WebAssembly module starts with.
+var wasmMagic = []byte{0x00, 0x61, 0x73, 0x6d}
+
+// GenesisAccount holds the initial fields of an account. Fields which are nil
+// are not written into the ledger state.
+type GenesisAccount struct {
+	ID AccountID
+
+	Balance *uint64
+	Stake   *uint64
+	Reward  *uint64
+
+	// Nonce, if set, overrides the nonce of 1 accounts otherwise start out
+	// with. It may only be set in version 2 genesis files.
+	Nonce *uint64
+
+	// fields lists the keys of all fields set, in the order they were
+	// declared, such that the ledger state is built up deterministically.
+	fields []string
+}
+
+// GenesisContract holds the code and initial memory of a contract deployed at
+// round 0. Pages not present in Pages are zero-filled.
+type GenesisContract struct {
+	ID TransactionID
+
+	Code     []byte
+	NumPages uint64
+	Pages    map[uint64][]byte
+
+	Owner     *AccountID
+	Paused    bool
+	CallQuota uint64
+}
+
+// Genesis is a parsed genesis file, describing the ledger state at round 0 and
+// the consensus parameters all nodes of a network must agree upon.
+type Genesis struct {
+	Version int
+
+	Accounts  []GenesisAccount
+	Contracts []GenesisContract
+
+	// TotalSupply, if declared, is the sum of the balances, stakes and
+	// rewards of all accounts. It may exceed what a uint64 may hold.
+	TotalSupply *big.Int
+
+	// Params holds overrides of consensus parameters keyed by name. Use
+	// ApplyParams to apply them.
+	Params map[string]*fastjson.Value
+}
+
+// Total returns the sum of the balance, stake and reward of the account. It
+// returns false if the sum does not fit into a uint64.
+func (a GenesisAccount) Total() (uint64, bool) {
+	var total uint64
+
+	for _, field := range []*uint64{a.Balance, a.Stake, a.Reward} {
+		if field == nil {
+			continue
+		}
+
+		if total+*field < total {
+			return 0, false
+		}
+
+		total += *field
+	}
+
+	return total, true
+}
+
+// Total returns the sum of the balances, stakes and rewards of all accounts.
+func (g *Genesis) Total() *big.Int {
+	total := new(big.Int)
+
+	for _, account := range g.Accounts {
+		for _, field := range []*uint64{account.Balance, account.Stake, account.Reward} {
+			if field != nil {
+				total.Add(total, new(big.Int).SetUint64(*field))
+			}
+		}
+	}
+
+	return total
+}
+
+// genesisParams maps the names of consensus parameters which may be
+// overridden by a genesis file to functions which validate a value for the
+// parameter, and return a function which applies it.
+var genesisParams = map[string]func(v *fastjson.Value) (func(p *sys.ConsensusParams), error){
+	"snowball.k": func(v *fastjson.Value) (func(p *sys.ConsensusParams), error) {
+		k, err := positiveInt(v)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(p *sys.ConsensusParams) { p.SnowballK = k }, nil
+	},
+	"snowball.alpha": func(v *fastjson.Value) (func(p *sys.ConsensusParams), error) {
+		alpha, err := snowballAlpha(v)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(p *sys.ConsensusParams) { p.SnowballAlpha = alpha }, nil
+	},
+	"snowball.beta": func(v *fastjson.Value) (func(p *sys.ConsensusParams), error) {
+		beta, err := positiveInt(v)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(p *sys.ConsensusParams) { p.SnowballBeta = beta }, nil
+	},
+	"difficulty.min": func(v *fastjson.Value) (func(p *sys.ConsensusParams), error) {
+		min, err := v.Uint()
+		if err != nil {
+			return nil, err
+		}
+
+		if min > math.MaxUint8 {
+			return nil, errors.Errorf("must be at most %d, but got %d", math.MaxUint8, min)
+		}
+
+		return func(p *sys.ConsensusParams) { p.MinDifficulty = byte(min) }, nil
+	},
+	"difficulty.scale": func(v *fastjson.Value) (func(p *sys.ConsensusParams), error) {
+		scale, err := v.Float64()
+		if err != nil {
+			return nil, err
+		}
+
+		if scale <= 0 {
+			return nil, errors.Errorf("must be positive, but got %f", scale)
+		}
+
+		return func(p *sys.ConsensusParams) { p.DifficultyScaleFactor = scale }, nil
+	},
+	"max_depth_diff": func(v *fastjson.Value) (func(p *sys.ConsensusParams), error) {
+		diff, err := v.Uint64()
+		if err != nil {
+			return nil, err
+		}
+
+		return func(p *sys.ConsensusParams) { p.MaxDepthDiff = diff }, nil
+	},
+	"transaction_fee_amount": func(v *fastjson.Value) (func(p *sys.ConsensusParams), error) {
+		fee, err := v.Uint64()
+		if err != nil {
+			return nil, err
+		}
+
+		return func(p *sys.ConsensusParams) { p.TransactionFeeAmount = fee }, nil
+	},
+	"transaction_fee_per_byte": func(v *fastjson.Value) (func(p *sys.ConsensusParams), error) {
+		fee, err := v.Uint64()
+		if err != nil {
+			return nil, err
+		}
+
+		return func(p *sys.ConsensusParams) { p.TransactionFeePerByte = fee }, nil
+	},
+	"burn_transaction_fees": func(v *fastjson.Value) (func(p *sys.ConsensusParams), error) {
+		burn, err := v.Bool()
+		if err != nil {
+			return nil, err
+		}
+
+		return func(p *sys.ConsensusParams) { p.BurnTransactionFees = burn }, nil
+	},
+	"min_stake": func(v *fastjson.Value) (func(p *sys.ConsensusParams), error) {
+		stake, err := v.Uint64()
+		if err != nil {
+			return nil, err
+		}
+
+		return func(p *sys.ConsensusParams) { p.MinimumStake = stake }, nil
+	},
+	"contract.max_queue_depth": func(v *fastjson.Value) (func(p *sys.ConsensusParams), error) {
+		depth, err := positiveInt(v)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(p *sys.ConsensusParams) { p.MaxContractQueueDepth = depth }, nil
+	},
+	"contract.max_queued_transactions": func(v *fastjson.Value) (func(p *sys.ConsensusParams), error) {
+		count, err := positiveInt(v)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(p *sys.ConsensusParams) { p.MaxContractQueuedTransactions = count }, nil
+	},
+	"contract.vm.default_memory_pages": func(v *fastjson.Value) (func(p *sys.ConsensusParams), error) {
+		pages, err := positiveInt(v)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(p *sys.ConsensusParams) { p.ContractDefaultMemoryPages = pages }, nil
+	},
+	"contract.vm.max_memory_pages": func(v *fastjson.Value) (func(p *sys.ConsensusParams), error) {
+		pages, err := positiveInt(v)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(p *sys.ConsensusParams) { p.ContractMaxMemoryPages = pages }, nil
+	},
+	"contract.vm.default_table_size": func(v *fastjson.Value) (func(p *sys.ConsensusParams), error) {
+		size, err := positiveInt(v)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(p *sys.ConsensusParams) { p.ContractDefaultTableSize = size }, nil
+	},
+	"contract.vm.max_table_size": func(v *fastjson.Value) (func(p *sys.ConsensusParams), error) {
+		size, err := positiveInt(v)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(p *sys.ConsensusParams) { p.ContractMaxTableSize = size }, nil
+	},
+	"contract.vm.max_value_slots": func(v *fastjson.Value) (func(p *sys.ConsensusParams), error) {
+		slots, err := positiveInt(v)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(p *sys.ConsensusParams) { p.ContractMaxValueSlots = slots }, nil
+	},
+	"contract.vm.max_call_stack_depth": func(v *fastjson.Value) (func(p *sys.ConsensusParams), error) {
+		depth, err := positiveInt(v)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(p *sys.ConsensusParams) { p.ContractMaxCallStackDepth = depth }, nil
+	},
+}
+
+// validateGenesisVMParams checks that the limits of the virtual machine smart
+// contracts are executed in are consistent with one another, taking the
+// defaults for limits which are not overridden by params.
+func validateGenesisVMParams(params map[string]*fastjson.Value) error {
+	current := sys.Params()
+
+	param := func(name string, def int) int {
+		if v, exists := params[name]; exists {
+			return v.GetInt()
+		}
+
+		return def
+	}
+
+	defaultPages := param("contract.vm.default_memory_pages", current.ContractDefaultMemoryPages)
+	maxPages := param("contract.vm.max_memory_pages", current.ContractMaxMemoryPages)
+
+	if defaultPages > maxPages {
+		return errors.Errorf("default number of contract memory pages %d exceeds the max of %d", defaultPages, maxPages)
+	}
+
+	defaultTableSize := param("contract.vm.default_table_size", current.ContractDefaultTableSize)
+	maxTableSize := param("contract.vm.max_table_size", current.ContractMaxTableSize)
+
+	if defaultTableSize > maxTableSize {
+		return errors.Errorf("default contract table size %d exceeds the max of %d", defaultTableSize, maxTableSize)
+	}
+
+	return nil
+}
+
+func positiveInt(v *fastjson.Value) (int, error) {
+	n, err := v.Int()
+	if err != nil {
+		return 0, err
+	}
+
+	if n <= 0 {
+		return 0, errors.Errorf("must be positive, but got %d", n)
+	}
+
+	return n, nil
+}
+
+func snowballAlpha(v *fastjson.Value) (float64, error) {
+	alpha, err := v.Float64()
+	if err != nil {
+		return 0, err
+	}
+
+	if alpha <= 0 || alpha > 1 {
+		return 0, errors.Errorf("must be in the range (0, 1], but got %f", alpha)
+	}
+
+	return alpha, nil
+}
+
+// ParseGenesis parses and validates a genesis file. If genesis is nil, the
+// default genesis is parsed instead.
+func ParseGenesis(genesis *string) (*Genesis, error) {
 	var buf []byte
 
 	if genesis != nil {
@@ -55,52 +378,129 @@ func performInception(tree *avl.Tree, genesis *string) Round {
 	var p fastjson.Parser
 
 	parsed, err := p.ParseBytes(buf)
-
 	if err != nil {
-		panic(err)
+		return nil, errors.Wrap(err, "genesis file is not valid JSON")
 	}
 
-	accounts, err := parsed.Object()
+	if !parsed.Exists("version") {
+		accounts, _, err := parseGenesisAccounts(parsed, false)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Genesis{Version: GenesisVersion1, Accounts: accounts}, nil
+	}
 
+	root, err := parsed.Object()
 	if err != nil {
-		panic(err)
+		return nil, errors.Wrap(err, "genesis file must be a JSON object")
 	}
 
-	var balance, stake, reward uint64
+	g := &Genesis{Params: make(map[string]*fastjson.Value)}
 
-	set := make(map[AccountID]struct{}) // Ensure that there are no duplicate account entries in the JSON.
+	var contracts []GenesisContract
 
-	accounts.Visit(func(key []byte, val *fastjson.Value) {
+	root.Visit(func(key []byte, v *fastjson.Value) {
 		if err != nil {
 			return
 		}
 
-		var fields *fastjson.Object
-		var id AccountID
-		var n int
+		switch string(key) {
+		case "version":
+			g.Version, err = v.Int()
+		case "total_supply":
+			g.TotalSupply, err = parseGenesisAmount(v)
+		case "accounts":
+			g.Accounts, contracts, err = parseGenesisAccounts(v, true)
+		case "contracts":
+			g.Contracts, err = parseGenesisContracts(v)
+		case "params":
+			err = parseGenesisParams(v, g.Params)
+		default:
+			err = errors.Errorf("unknown key %q", key)
+		}
 
-		n, err = hex.Decode(id[:], key)
+		if err != nil {
+			err = errors.Wrapf(err, "invalid genesis field %q", key)
+		}
+	})
 
-		if n != cap(id) && err == nil {
-			err = errors.Errorf("got an invalid account ID: %x", key)
-			return
+	if err != nil {
+		return nil, err
+	}
+
+	if g.Version != GenesisVersion2 {
+		return nil, errors.Errorf("unsupported genesis file version %d", g.Version)
+	}
+
+	if err := validateGenesisVMParams(g.Params); err != nil {
+		return nil, errors.Wrap(err, "invalid genesis params")
+	}
+
+	// Contracts may either be declared under the account they are deployed
+	// at, or under the contracts section, but not both.
+
+	set := make(map[TransactionID]struct{}, len(g.Contracts))
+
+	for _, contract := range g.Contracts {
+		set[contract.ID] = struct{}{}
+	}
+
+	for _, contract := range contracts {
+		if _, exists := set[contract.ID]; exists {
+			return nil, errors.Errorf("found duplicate entries for contract ID %x in genesis file", contract.ID)
 		}
 
+		g.Contracts = append(g.Contracts, contract)
+	}
+
+	if g.TotalSupply != nil {
+		if total := g.Total(); total.Cmp(g.TotalSupply) != 0 {
+			return nil, errors.Errorf("the balances, stakes and rewards of all accounts add up to %s, but the total supply is declared to be %s", total, g.TotalSupply)
+		}
+	}
+
+	return g, nil
+}
+
+// parseGenesisAccounts parses the accounts of a genesis file. If strict is
+// set, unknown fields are rejected, accounts may declare a contract deployed
+// under their ID, and the fields of accounts must not add up to more than a
+// uint64 may hold.
+func parseGenesisAccounts(v *fastjson.Value, strict bool) ([]GenesisAccount, []GenesisContract, error) {
+	obj, err := v.Object()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var accounts []GenesisAccount
+	var contracts []GenesisContract
+
+	set := make(map[AccountID]struct{}) // Ensure that there are no duplicate account entries in the JSON.
+
+	obj.Visit(func(key []byte, val *fastjson.Value) {
 		if err != nil {
-			err = errors.Wrapf(err, "got an invalid account ID: %x", key)
 			return
 		}
 
-		if _, exists := set[id]; exists {
-			err = errors.Errorf("found duplicate entries for account ID %x in genesis file", id)
+		var account GenesisAccount
+
+		if account.ID, err = decodeGenesisID(key); err != nil {
+			err = errors.Wrapf(err, "got an invalid account ID: %q", key)
+			return
+		}
+
+		if _, exists := set[account.ID]; exists {
+			err = errors.Errorf("found duplicate entries for account ID %x in genesis file", account.ID)
 			return
 		}
 
-		set[id] = struct{}{}
+		set[account.ID] = struct{}{}
 
-		fields, err = val.Object()
+		var fields *fastjson.Object
 
-		if err != nil {
+		if fields, err = val.Object(); err != nil {
+			err = errors.Wrapf(err, "account %x", account.ID)
 			return
 		}
 
@@ -109,44 +509,488 @@ func performInception(tree *avl.Tree, genesis *string) Round {
 				return
 			}
 
+			var dst **uint64
+
 			switch string(key) {
 			case "balance":
-				balance, err = v.Uint64()
-				if err != nil {
-					err = errors.Wrapf(err, "failed to cast type for key %q", key)
+				dst = &account.Balance
+			case "stake":
+				dst = &account.Stake
+			case "reward":
+				dst = &account.Reward
+			case "nonce":
+				if !strict {
 					return
 				}
 
-				WriteAccountBalance(tree, id, balance)
-			case "stake":
-				stake, err = v.Uint64()
-
-				if err != nil {
-					err = errors.Wrapf(err, "failed to cast type for key %q", key)
-					return
+				if account.Nonce, err = parseGenesisNonce(v); err != nil {
+					err = errors.Wrapf(err, "failed to cast type for key %q of account %x", key, account.ID)
 				}
 
-				WriteAccountStake(tree, id, uint64(stake))
-			case "reward":
-				reward, err = v.Uint64()
+				return
+			case "contract":
+				if strict {
+					var contract GenesisContract
+
+					if contract, err = parseGenesisContract(account.ID, v); err != nil {
+						err = errors.Wrapf(err, "invalid contract for account %x", account.ID)
+						return
+					}
 
-				if err != nil {
-					err = errors.Wrapf(err, "failed to cast type for key %q", key)
+					contracts = append(contracts, contract)
 					return
 				}
 
-				WriteAccountReward(tree, id, uint64(reward))
+				fallthrough
+			default:
+				if strict {
+					err = errors.Errorf("unknown field %q for account %x", key, account.ID)
+				}
+				return
+			}
+
+			var value uint64
+
+			if value, err = v.Uint64(); err != nil {
+				err = errors.Wrapf(err, "failed to cast type for key %q of account %x", key, account.ID)
+				return
 			}
+
+			*dst = &value
+			account.fields = append(account.fields, string(key))
 		})
 
-		if err == nil {
-			WriteAccountsLen(tree, ReadAccountsLen(tree)+1)
-			WriteAccountNonce(tree, id, 1)
+		if _, ok := account.Total(); err == nil && strict && !ok {
+			err = errors.Errorf("the balance, stake and reward of account %x add up to more than %d", account.ID, uint64(math.MaxUint64))
 		}
+
+		accounts = append(accounts, account)
 	})
 
 	if err != nil {
-		panic(err)
+		return nil, nil, err
+	}
+
+	return accounts, contracts, nil
+}
+
+func parseGenesisContracts(v *fastjson.Value) ([]GenesisContract, error) {
+	obj, err := v.Object()
+	if err != nil {
+		return nil, err
+	}
+
+	var contracts []GenesisContract
+
+	set := make(map[TransactionID]struct{})
+
+	obj.Visit(func(key []byte, val *fastjson.Value) {
+		if err != nil {
+			return
+		}
+
+		var contract GenesisContract
+
+		if contract.ID, err = decodeGenesisID(key); err != nil {
+			err = errors.Wrapf(err, "got an invalid contract ID: %q", key)
+			return
+		}
+
+		if _, exists := set[contract.ID]; exists {
+			err = errors.Errorf("found duplicate entries for contract ID %x in genesis file", contract.ID)
+			return
+		}
+
+		set[contract.ID] = struct{}{}
+
+		if contract, err = parseGenesisContract(contract.ID, val); err != nil {
+			err = errors.Wrapf(err, "contract %x", contract.ID)
+			return
+		}
+
+		contracts = append(contracts, contract)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return contracts, nil
+}
+
+func parseGenesisContract(id TransactionID, v *fastjson.Value) (GenesisContract, error) {
+	contract := GenesisContract{ID: id, Pages: make(map[uint64][]byte)}
+
+	obj, err := v.Object()
+	if err != nil {
+		return contract, err
+	}
+
+	numPagesSet := false
+
+	obj.Visit(func(key []byte, v *fastjson.Value) {
+		if err != nil {
+			return
+		}
+
+		switch string(key) {
+		case "code":
+			contract.Code, err = decodeGenesisHex(v)
+		case "num_pages":
+			contract.NumPages, err = v.Uint64()
+			numPagesSet = true
+		case "pages":
+			err = parseGenesisPages(v, contract.Pages)
+		case "owner":
+			var buf []byte
+
+			if buf, err = v.StringBytes(); err == nil {
+				var owner AccountID
+
+				if owner, err = decodeGenesisID(buf); err == nil {
+					contract.Owner = &owner
+				}
+			}
+		case "paused":
+			contract.Paused, err = v.Bool()
+		case "call_quota":
+			contract.CallQuota, err = v.Uint64()
+		default:
+			err = errors.Errorf("unknown field %q", key)
+			return
+		}
+
+		if err != nil {
+			err = errors.Wrapf(err, "invalid field %q", key)
+		}
+	})
+
+	if err != nil {
+		return contract, err
+	}
+
+	if len(contract.Code) == 0 {
+		return contract, errors.New("contract code must be specified")
+	}
+
+	if !bytes.HasPrefix(contract.Code, wasmMagic) {
+		return contract, errors.New("contract code is not a WebAssembly module")
+	}
+
+	for idx := range contract.Pages {
+		if idx >= contract.NumPages {
+			if numPagesSet {
+				return contract, errors.Errorf("page %d is out of bounds of the %d pages of the contract", idx, contract.NumPages)
+			}
+
+			contract.NumPages = idx + 1
+		}
+	}
+
+	if contract.NumPages > maxGenesisContractPages {
+		return contract, errors.Errorf("contract may have at most %d pages, but has %d", maxGenesisContractPages, contract.NumPages)
+	}
+
+	return contract, nil
+}
+
+func parseGenesisPages(v *fastjson.Value, pages map[uint64][]byte) error {
+	obj, err := v.Object()
+	if err != nil {
+		return err
+	}
+
+	obj.Visit(func(key []byte, v *fastjson.Value) {
+		if err != nil {
+			return
+		}
+
+		var idx uint64
+
+		if idx, err = strconv.ParseUint(string(key), 10, 64); err != nil {
+			err = errors.Wrapf(err, "got an invalid page index %q", key)
+			return
+		}
+
+		if _, exists := pages[idx]; exists {
+			err = errors.Errorf("found duplicate entries for page %d", idx)
+			return
+		}
+
+		var page []byte
+
+		if page, err = decodeGenesisHex(v); err != nil {
+			err = errors.Wrapf(err, "page %d", idx)
+			return
+		}
+
+		if len(page) > PageSize {
+			err = errors.Errorf("page %d is %d bytes, but pages may be at most %d bytes", idx, len(page), PageSize)
+			return
+		}
+
+		// Pages are stored in full, so zero-fill the remainder of the page.
+
+		if len(page) > 0 && len(page) < PageSize {
+			page = append(page, make([]byte, PageSize-len(page))...)
+		}
+
+		pages[idx] = page
+	})
+
+	return err
+}
+
+func parseGenesisParams(v *fastjson.Value, params map[string]*fastjson.Value) error {
+	obj, err := v.Object()
+	if err != nil {
+		return err
+	}
+
+	obj.Visit(func(key []byte, v *fastjson.Value) {
+		if err != nil {
+			return
+		}
+
+		parse, exists := genesisParams[string(key)]
+		if !exists {
+			err = errors.Errorf("unknown parameter %q", key)
+			return
+		}
+
+		if _, exists := params[string(key)]; exists {
+			err = errors.Errorf("found duplicate entries for parameter %q", key)
+			return
+		}
+
+		if _, err = parse(v); err != nil {
+			err = errors.Wrapf(err, "invalid value for parameter %q", key)
+			return
+		}
+
+		params[string(key)] = v
+	})
+
+	return err
+}
+
+func parseGenesisNonce(v *fastjson.Value) (*uint64, error) {
+	nonce, err := v.Uint64()
+	if err != nil {
+		return nil, err
+	}
+
+	if nonce == 0 {
+		return nil, errors.New("nonce must be positive")
+	}
+
+	return &nonce, nil
+}
+
+// parseGenesisAmount parses a non-negative integer which may exceed what a
+// uint64 may hold, given either as a JSON number or a decimal string.
+func parseGenesisAmount(v *fastjson.Value) (*big.Int, error) {
+	var str string
+
+	switch v.Type() {
+	case fastjson.TypeNumber:
+		str = string(v.MarshalTo(nil))
+	case fastjson.TypeString:
+		str = string(v.GetStringBytes())
+	default:
+		return nil, errors.Errorf("expected a number or string, but got %s", v.Type())
+	}
+
+	amount, ok := new(big.Int).SetString(str, 10)
+	if !ok || amount.Sign() < 0 {
+		return nil, errors.Errorf("expected a non-negative integer, but got %q", str)
+	}
+
+	return amount, nil
+}
+
+func decodeGenesisID(key []byte) (id [32]byte, err error) {
+	n, err := hex.Decode(id[:], key)
+
+	if err != nil {
+		return id, err
+	}
+
+	if n != len(id) || len(key) != hex.EncodedLen(len(id)) {
+		return id, errors.Errorf("expected %d bytes, but got %d", len(id), len(key)/2)
+	}
+
+	return id, nil
+}
+
+func decodeGenesisHex(v *fastjson.Value) ([]byte, error) {
+	str, err := v.StringBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, hex.DecodedLen(len(str)))
+
+	if _, err := hex.Decode(buf, str); err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
+
+// LoadGenesis reads, parses and validates the genesis file located at path.
+func LoadGenesis(path string) (*Genesis, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read genesis file %q", path)
+	}
+
+	genesis := string(buf)
+
+	g, err := ParseGenesis(&genesis)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid genesis file %q", path)
+	}
+
+	return g, nil
+}
+
+// GenesisReport summarizes the ledger state produced by a genesis file.
+type GenesisReport struct {
+	Version int
+
+	Accounts  int
+	Contracts int
+	Params    int
+
+	// Root is the Merkle root of the ledger state at round 0.
+	Root MerkleNodeID
+}
+
+// ValidateGenesis parses and validates the genesis file located at path, and
+// performs inception into a temporary in-memory tree to report the resulting
+// state root, without constructing a Ledger. On top of the checks made by
+// ParseGenesis, the code of all contracts must pass ValidateContractCode under
+// the consensus parameters overridden by the genesis file.
+func ValidateGenesis(path string) (*GenesisReport, error) {
+	g, err := LoadGenesis(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(g.Accounts) == 0 {
+		return nil, errors.New("genesis file must specify at least one account")
+	}
+
+	params := sys.Params()
+	g.overrideParams(&params)
+
+	for _, contract := range g.Contracts {
+		if err := validateContractCode(contract.Code, params); err != nil {
+			return nil, errors.Wrapf(err, "contract %x has invalid code", contract.ID)
+		}
+	}
+
+	kv := store.NewInmem()
+	defer kv.Close()
+
+	round := performInception(avl.New(kv), g)
+
+	return &GenesisReport{
+		Version: g.Version,
+
+		Accounts:  len(g.Accounts),
+		Contracts: len(g.Contracts),
+		Params:    len(g.Params),
+
+		Root: round.Merkle,
+	}, nil
+}
+
+// ApplyParams overrides all consensus parameters specified by the genesis
+// file at once, such that no reader observes only some of them overridden.
+// Parameters are applied in order of name.
+func (g *Genesis) ApplyParams() {
+	if len(g.Params) == 0 {
+		return
+	}
+
+	sys.UpdateParams(g.overrideParams)
+}
+
+// overrideParams overrides all consensus parameters in p which are specified
+// by the genesis file, in order of name.
+func (g *Genesis) overrideParams(p *sys.ConsensusParams) {
+	names := make([]string, 0, len(g.Params))
+
+	for name := range g.Params {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		apply, err := genesisParams[name](g.Params[name])
+		if err != nil {
+			panic(err) // Params are validated by ParseGenesis.
+		}
+
+		apply(p)
+	}
+}
+
+// performInception loads data expected to exist at the birth of any node in this ledgers network
+// into tree, and returns the genesis round.
+func performInception(tree *avl.Tree, genesis *Genesis) Round {
+	for _, account := range genesis.Accounts {
+		for _, field := range account.fields {
+			switch field {
+			case "balance":
+				WriteAccountBalance(tree, account.ID, *account.Balance)
+			case "stake":
+				WriteAccountStake(tree, account.ID, *account.Stake)
+			case "reward":
+				WriteAccountReward(tree, account.ID, *account.Reward)
+			}
+		}
+
+		nonce := uint64(1)
+
+		if account.Nonce != nil {
+			nonce = *account.Nonce
+		}
+
+		WriteAccountsLen(tree, ReadAccountsLen(tree)+1)
+		WriteAccountNonce(tree, account.ID, nonce)
+	}
+
+	for _, contract := range genesis.Contracts {
+		WriteAccountContractCode(tree, contract.ID, contract.Code)
+		WriteAccountContractNumPages(tree, contract.ID, contract.NumPages)
+
+		indices := make([]uint64, 0, len(contract.Pages))
+
+		for idx := range contract.Pages {
+			indices = append(indices, idx)
+		}
+
+		sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+
+		for _, idx := range indices {
+			WriteAccountContractPage(tree, contract.ID, idx, contract.Pages[idx])
+		}
+
+		if contract.Owner != nil {
+			WriteAccountContractOwner(tree, contract.ID, *contract.Owner)
+		}
+
+		if contract.Paused {
+			WriteAccountContractPaused(tree, contract.ID, true)
+		}
+
+		if contract.CallQuota > 0 {
+			WriteAccountContractCallQuota(tree, contract.ID, contract.CallQuota)
+		}
 	}
 
 	tx := Transaction{}
@@ -0,0 +1,270 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/pkg/errors"
+	"github.com/valyala/fastjson"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// DumpGenesis writes the full ledger state as of the given round to w as a
+// version 2 genesis file, such that a new network may be bootstrapped from
+// it. The consensus parameters this node is running with are included as
+// parameter overrides. Only the state of the latest round is available.
+func (l *Ledger) DumpGenesis(round uint64, w io.Writer) error {
+	latest, snapshot, err := l.consistentSnapshot()
+	if err != nil {
+		return err
+	}
+
+	if latest.Index != round {
+		return errors.Errorf("state of round %d is not available, only that of the latest round %d is", round, latest.Index)
+	}
+
+	var arena fastjson.Arena
+
+	g := genesisFromSnapshot(snapshot)
+	g.Params = currentGenesisParams(&arena)
+
+	if _, err := w.Write(g.Marshal()); err != nil {
+		return errors.Wrap(err, "failed to write genesis")
+	}
+
+	return nil
+}
+
+// genesisFromSnapshot collects all accounts and contracts within a snapshot of
+// the ledger state into a genesis.
+func genesisFromSnapshot(tree *avl.Tree) *Genesis {
+	g := &Genesis{Version: GenesisVersion2}
+
+	set := make(map[AccountID]struct{})
+
+	for _, prefix := range [][]byte{keyAccountNonce[:], keyAccountBalance[:], keyAccountStake[:], keyAccountReward[:]} {
+		tree.IteratePrefix(append(keyAccounts[:], prefix...), func(key, value []byte) {
+			var id AccountID
+			copy(id[:], key[len(key)-SizeAccountID:])
+
+			set[id] = struct{}{}
+		})
+	}
+
+	for id := range set {
+		account := GenesisAccount{ID: id}
+
+		// Fields are listed in the order in which they are written upon inception.
+
+		if balance, exists := ReadAccountBalance(tree, id); exists {
+			account.Balance = &balance
+			account.fields = append(account.fields, "balance")
+		}
+
+		if stake, exists := ReadAccountStake(tree, id); exists {
+			account.Stake = &stake
+			account.fields = append(account.fields, "stake")
+		}
+
+		if reward, exists := ReadAccountReward(tree, id); exists {
+			account.Reward = &reward
+			account.fields = append(account.fields, "reward")
+		}
+
+		if nonce, exists := ReadAccountNonce(tree, id); exists && nonce != 1 {
+			account.Nonce = &nonce
+		}
+
+		g.Accounts = append(g.Accounts, account)
+	}
+
+	sort.Slice(g.Accounts, func(i, j int) bool {
+		return bytes.Compare(g.Accounts[i].ID[:], g.Accounts[j].ID[:]) < 0
+	})
+
+	tree.IteratePrefix(append(keyAccounts[:], keyAccountContractCode[:]...), func(key, value []byte) {
+		contract := GenesisContract{Code: append([]byte{}, value...), Pages: make(map[uint64][]byte)}
+		copy(contract.ID[:], key[len(key)-SizeTransactionID:])
+
+		contract.NumPages, _ = ReadAccountContractNumPages(tree, contract.ID)
+
+		for idx := uint64(0); idx < contract.NumPages; idx++ {
+			if page, exists := ReadAccountContractPage(tree, contract.ID, idx); exists && len(page) > 0 {
+				contract.Pages[idx] = page
+			}
+		}
+
+		if owner, exists := ReadAccountContractOwner(tree, contract.ID); exists {
+			contract.Owner = &owner
+		}
+
+		// The number of times the contract was invoked in the round it was last invoked in is
+		// not carried over, as it only matters within that round.
+
+		contract.Paused = ReadAccountContractPaused(tree, contract.ID)
+		contract.CallQuota, _ = ReadAccountContractCallQuota(tree, contract.ID)
+
+		g.Contracts = append(g.Contracts, contract)
+	})
+
+	return g
+}
+
+// currentGenesisParams returns the values of all consensus parameters which
+// may be overridden by a genesis file this node is currently running with.
+func currentGenesisParams(arena *fastjson.Arena) map[string]*fastjson.Value {
+	current := sys.Params()
+
+	params := map[string]*fastjson.Value{
+		"snowball.k":                       arena.NewNumberInt(current.SnowballK),
+		"snowball.alpha":                   arena.NewNumberFloat64(current.SnowballAlpha),
+		"snowball.beta":                    arena.NewNumberInt(current.SnowballBeta),
+		"difficulty.min":                   arena.NewNumberInt(int(current.MinDifficulty)),
+		"difficulty.scale":                 arena.NewNumberFloat64(current.DifficultyScaleFactor),
+		"max_depth_diff":                   arena.NewNumberString(strconv.FormatUint(current.MaxDepthDiff, 10)),
+		"transaction_fee_amount":           arena.NewNumberString(strconv.FormatUint(current.TransactionFeeAmount, 10)),
+		"transaction_fee_per_byte":         arena.NewNumberString(strconv.FormatUint(current.TransactionFeePerByte, 10)),
+		"min_stake":                        arena.NewNumberString(strconv.FormatUint(current.MinimumStake, 10)),
+		"contract.max_queue_depth":         arena.NewNumberInt(current.MaxContractQueueDepth),
+		"contract.max_queued_transactions": arena.NewNumberInt(current.MaxContractQueuedTransactions),
+		"contract.vm.default_memory_pages": arena.NewNumberInt(current.ContractDefaultMemoryPages),
+		"contract.vm.max_memory_pages":     arena.NewNumberInt(current.ContractMaxMemoryPages),
+		"contract.vm.default_table_size":   arena.NewNumberInt(current.ContractDefaultTableSize),
+		"contract.vm.max_table_size":       arena.NewNumberInt(current.ContractMaxTableSize),
+		"contract.vm.max_value_slots":      arena.NewNumberInt(current.ContractMaxValueSlots),
+		"contract.vm.max_call_stack_depth": arena.NewNumberInt(current.ContractMaxCallStackDepth),
+		"burn_transaction_fees":            arena.NewFalse(),
+	}
+
+	if current.BurnTransactionFees {
+		params["burn_transaction_fees"] = arena.NewTrue()
+	}
+
+	return params
+}
+
+// Marshal encodes the genesis as a version 2 genesis file. Pages of contract
+// memory have their trailing zeroes trimmed.
+func (g *Genesis) Marshal() []byte {
+	var arena fastjson.Arena
+
+	o := arena.NewObject()
+	o.Set("version", arena.NewNumberInt(GenesisVersion2))
+
+	if g.TotalSupply != nil {
+		o.Set("total_supply", arena.NewString(g.TotalSupply.String()))
+	}
+
+	if len(g.Params) > 0 {
+		params := arena.NewObject()
+
+		names := make([]string, 0, len(g.Params))
+
+		for name := range g.Params {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			params.Set(name, g.Params[name])
+		}
+
+		o.Set("params", params)
+	}
+
+	accounts := arena.NewObject()
+
+	for _, account := range g.Accounts {
+		fields := arena.NewObject()
+
+		for _, field := range account.fields {
+			var value *uint64
+
+			switch field {
+			case "balance":
+				value = account.Balance
+			case "stake":
+				value = account.Stake
+			case "reward":
+				value = account.Reward
+			}
+
+			fields.Set(field, arena.NewNumberString(strconv.FormatUint(*value, 10)))
+		}
+
+		if account.Nonce != nil {
+			fields.Set("nonce", arena.NewNumberString(strconv.FormatUint(*account.Nonce, 10)))
+		}
+
+		accounts.Set(hex.EncodeToString(account.ID[:]), fields)
+	}
+
+	o.Set("accounts", accounts)
+
+	if len(g.Contracts) > 0 {
+		contracts := arena.NewObject()
+
+		for _, contract := range g.Contracts {
+			fields := arena.NewObject()
+			fields.Set("code", arena.NewString(hex.EncodeToString(contract.Code)))
+			fields.Set("num_pages", arena.NewNumberString(strconv.FormatUint(contract.NumPages, 10)))
+
+			indices := make([]uint64, 0, len(contract.Pages))
+
+			for idx := range contract.Pages {
+				indices = append(indices, idx)
+			}
+
+			sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+
+			pages := arena.NewObject()
+
+			for _, idx := range indices {
+				pages.Set(strconv.FormatUint(idx, 10), arena.NewString(hex.EncodeToString(bytes.TrimRight(contract.Pages[idx], "\x00"))))
+			}
+
+			fields.Set("pages", pages)
+
+			if contract.Owner != nil {
+				fields.Set("owner", arena.NewString(hex.EncodeToString(contract.Owner[:])))
+			}
+
+			if contract.Paused {
+				fields.Set("paused", arena.NewTrue())
+			}
+
+			if contract.CallQuota > 0 {
+				fields.Set("call_quota", arena.NewNumberString(strconv.FormatUint(contract.CallQuota, 10)))
+			}
+
+			contracts.Set(hex.EncodeToString(contract.ID[:]), fields)
+		}
+
+		o.Set("contracts", contracts)
+	}
+
+	return o.MarshalTo(nil)
+}
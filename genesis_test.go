@@ -0,0 +1,301 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDefaultGenesisIsStable(t *testing.T) {
+	g, err := ParseGenesis(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, GenesisVersion1, g.Version)
+	assert.Len(t, g.Accounts, 3)
+
+	round := performInception(avl.New(store.NewInmem()), g)
+
+	assert.Equal(t, "1a822467f036f127afe8c3c4df987fa7", hex.EncodeToString(round.Merkle[:]))
+	assert.Equal(t, "e67a2fc7b9aa2e08d82c61481739eb33d6edd89b60cbf5da4903420710be63ef", hex.EncodeToString(round.ID[:]))
+}
+
+func TestGenesisV2(t *testing.T) {
+	genesis := `{
+  "version": 2,
+  "params": {"snowball.k": 4, "min_stake": 500},
+  "accounts": {
+    "400056ee68a7cc2695222df05ea76875bc27ec6e61e8e62317c336157019c405": {"balance": 100, "stake": 500}
+  },
+  "contracts": {
+    "696937c2c8df35dba0169de72990b80761e51dd9e2411fa1fce147f68ade830a": {
+      "code": "0061736d01000000",
+      "num_pages": 2,
+      "pages": {"1": "beef"}
+    }
+  }
+}`
+
+	g, err := ParseGenesis(&genesis)
+	assert.NoError(t, err)
+	assert.Equal(t, GenesisVersion2, g.Version)
+
+	tree := avl.New(store.NewInmem())
+	performInception(tree, g)
+
+	account := g.Accounts[0].ID
+
+	balance, _ := ReadAccountBalance(tree, account)
+	assert.EqualValues(t, 100, balance)
+
+	stake, _ := ReadAccountStake(tree, account)
+	assert.EqualValues(t, 500, stake)
+
+	contract := g.Contracts[0].ID
+
+	code, exists := ReadAccountContractCode(tree, contract)
+	assert.True(t, exists)
+	assert.Equal(t, []byte("\x00asm\x01\x00\x00\x00"), code)
+
+	numPages, _ := ReadAccountContractNumPages(tree, contract)
+	assert.EqualValues(t, 2, numPages)
+
+	_, exists = ReadAccountContractPage(tree, contract, 0)
+	assert.False(t, exists)
+
+	page, exists := ReadAccountContractPage(tree, contract, 1)
+	assert.True(t, exists)
+	assert.Len(t, page, PageSize)
+	assert.Equal(t, []byte{0xbe, 0xef, 0x00}, page[:3])
+
+	defer func(params sys.ConsensusParams) {
+		sys.UpdateParams(func(p *sys.ConsensusParams) { *p = params })
+	}(sys.Params())
+
+	g.ApplyParams()
+
+	assert.Equal(t, 4, sys.Params().SnowballK)
+	assert.EqualValues(t, 500, sys.Params().MinimumStake)
+}
+
+func TestGenesisV2Validation(t *testing.T) {
+	const id = "400056ee68a7cc2695222df05ea76875bc27ec6e61e8e62317c336157019c405"
+
+	invalid := map[string]string{
+		"unsupported version": `{"version": 3}`,
+		"unknown key":         `{"version": 2, "foo": {}}`,
+		"unknown field":       `{"version": 2, "accounts": {"` + id + `": {"foo": 1}}}`,
+		"short account ID":    `{"version": 2, "accounts": {"4000": {"balance": 1}}}`,
+		"duplicate account":   `{"version": 2, "accounts": {"` + id + `": {}, "` + id + `": {}}}`,
+		"negative balance":    `{"version": 2, "accounts": {"` + id + `": {"balance": -1}}}`,
+		"unknown param":       `{"version": 2, "params": {"foo": 1}}`,
+		"invalid param":       `{"version": 2, "params": {"snowball.alpha": 2}}`,
+		"vm memory pages":     `{"version": 2, "params": {"contract.vm.default_memory_pages": 64}}`,
+		"vm table size":       `{"version": 2, "params": {"contract.vm.default_table_size": 1, "contract.vm.max_table_size": 0}}`,
+		"vm inconsistent":     `{"version": 2, "params": {"contract.vm.default_memory_pages": 8, "contract.vm.max_memory_pages": 4}}`,
+		"missing code":        `{"version": 2, "contracts": {"` + id + `": {"num_pages": 1}}}`,
+		"not wasm":            `{"version": 2, "contracts": {"` + id + `": {"code": "deadbeef"}}}`,
+		"page out of bounds":  `{"version": 2, "contracts": {"` + id + `": {"code": "0061736d01000000", "num_pages": 1, "pages": {"1": "00"}}}}`,
+		"invalid page index":  `{"version": 2, "contracts": {"` + id + `": {"code": "0061736d01000000", "pages": {"a": "00"}}}}`,
+		"account overflow":    `{"version": 2, "accounts": {"` + id + `": {"balance": 18446744073709551615, "stake": 1}}}`,
+		"total mismatch":      `{"version": 2, "total_supply": 2, "accounts": {"` + id + `": {"balance": 1}}}`,
+		"invalid total":       `{"version": 2, "total_supply": -1}`,
+		"duplicate contract":  `{"version": 2, "accounts": {"` + id + `": {"contract": {"code": "0061736d01000000"}}}, "contracts": {"` + id + `": {"code": "0061736d01000000"}}}`,
+		"invalid contract":    `{"version": 2, "accounts": {"` + id + `": {"contract": {"code": "00"}}}}`,
+	}
+
+	for name, genesis := range invalid {
+		genesis := genesis
+
+		_, err := ParseGenesis(&genesis)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestValidateGenesis(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genesis")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	write := func(name, genesis string) string {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, ioutil.WriteFile(path, []byte(genesis), 0644))
+		return path
+	}
+
+	const contract = `"contracts": {"696937c2c8df35dba0169de72990b80761e51dd9e2411fa1fce147f68ade830a": {"code": "%s"}}`
+
+	_, err = ValidateGenesis(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+
+	report, err := ValidateGenesis(write("default.json", defaultGenesis))
+	assert.NoError(t, err)
+	assert.Equal(t, GenesisVersion1, report.Version)
+	assert.Equal(t, 3, report.Accounts)
+	assert.Equal(t, "1a822467f036f127afe8c3c4df987fa7", hex.EncodeToString(report.Root[:]))
+
+	valid := `{"version": 2, "accounts": {"400056ee68a7cc2695222df05ea76875bc27ec6e61e8e62317c336157019c405": {"balance": 1}}, ` +
+		fmt.Sprintf(contract, "0061736d01000000") + `}`
+
+	report, err = ValidateGenesis(write("valid.json", valid))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Contracts)
+
+	_, err = ValidateGenesis(write("empty.json", `{"version": 2}`))
+	assert.Error(t, err)
+
+	malformed := `{"version": 2, "accounts": {"400056ee68a7cc2695222df05ea76875bc27ec6e61e8e62317c336157019c405": {"balance": 1}}, ` +
+		fmt.Sprintf(contract, "0061736d010000000105") + `}`
+
+	_, err = ValidateGenesis(write("malformed.json", malformed))
+	assert.Error(t, err)
+
+	// Contracts must only import host functions provided to smart contracts.
+	unknownImport := `{"version": 2, "accounts": {"400056ee68a7cc2695222df05ea76875bc27ec6e61e8e62317c336157019c405": {"balance": 1}}, ` +
+		fmt.Sprintf(contract, "0061736d0100000001040160000002100103656e76085f756e6b6e6f776e0000") + `}`
+
+	_, err = ValidateGenesis(write("unknown_import.json", unknownImport))
+	assert.Error(t, err)
+
+	// Contracts are validated against the limits of the virtual machine as
+	// overridden by the genesis file.
+	memory := `{"version": 2, "accounts": {"400056ee68a7cc2695222df05ea76875bc27ec6e61e8e62317c336157019c405": {"balance": 1}}, ` +
+		fmt.Sprintf(contract, "0061736d010000000503010010") + `%s}`
+
+	_, err = ValidateGenesis(write("memory.json", fmt.Sprintf(memory, "")))
+	assert.NoError(t, err)
+
+	_, err = ValidateGenesis(write("memory_limited.json", fmt.Sprintf(memory, `, "params": {"contract.vm.max_memory_pages": 8}`)))
+	assert.Error(t, err)
+}
+
+func TestLedgerGenesisOptions(t *testing.T) {
+	const id = "400056ee68a7cc2695222df05ea76875bc27ec6e61e8e62317c336157019c405"
+
+	genesis := `{"version": 2, "accounts": {"` + id + `": {"balance": 42}}}`
+
+	g, err := ParseGenesis(&genesis)
+	assert.NoError(t, err)
+
+	ledger := newTestLedger(t, WithGenesis(g))
+
+	balance, _ := ReadAccountBalance(ledger.Snapshot(), g.Accounts[0].ID)
+	assert.EqualValues(t, 42, balance)
+
+	dir, err := ioutil.TempDir("", "genesis")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "genesis.json")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(genesis), 0644))
+
+	ledger = newTestLedger(t, WithGenesisPath(path))
+
+	balance, _ = ReadAccountBalance(ledger.Snapshot(), g.Accounts[0].ID)
+	assert.EqualValues(t, 42, balance)
+
+	// Invalid or conflicting genesis must not silently fall back to the
+	// default genesis.
+
+	assert.Panics(t, func() { newTestLedger(t, WithGenesisPath(filepath.Join(dir, "missing.json"))) })
+	assert.Panics(t, func() { newTestLedger(t, WithGenesis(g), WithGenesisPath(path)) })
+}
+
+func TestGenesisV2Allocations(t *testing.T) {
+	const id = "400056ee68a7cc2695222df05ea76875bc27ec6e61e8e62317c336157019c405"
+
+	genesis := `{
+  "version": 2,
+  "total_supply": "18446744073709551616",
+  "accounts": {
+    "` + id + `": {
+      "balance": 18446744073709551615,
+      "contract": {"code": "0061736d01000000"}
+    },
+    "696937c2c8df35dba0169de72990b80761e51dd9e2411fa1fce147f68ade830a": {"stake": 1}
+  }
+}`
+
+	g, err := ParseGenesis(&genesis)
+	assert.NoError(t, err)
+	assert.Equal(t, "18446744073709551616", g.Total().String())
+
+	if assert.Len(t, g.Contracts, 1) {
+		assert.Equal(t, g.Accounts[0].ID, g.Contracts[0].ID)
+	}
+
+	tree := avl.New(store.NewInmem())
+	performInception(tree, g)
+
+	_, exists := ReadAccountContractCode(tree, g.Accounts[0].ID)
+	assert.True(t, exists)
+}
+
+func TestDumpGenesis(t *testing.T) {
+	genesis := `{
+  "version": 2,
+  "accounts": {
+    "400056ee68a7cc2695222df05ea76875bc27ec6e61e8e62317c336157019c405": {"balance": 100, "reward": 5, "nonce": 7},
+    "696937c2c8df35dba0169de72990b80761e51dd9e2411fa1fce147f68ade830a": {"stake": 500}
+  },
+  "contracts": {
+    "f03bb6f98c4dfd31f3d448c7ec79fa3eaa92250112ada43471812f4b1ace6467": {
+      "code": "0061736d01000000",
+      "num_pages": 2,
+      "pages": {"1": "beef"},
+      "owner": "400056ee68a7cc2695222df05ea76875bc27ec6e61e8e62317c336157019c405",
+      "paused": true,
+      "call_quota": 3
+    }
+  }
+}`
+
+	g, err := ParseGenesis(&genesis)
+	assert.NoError(t, err)
+
+	ledger := newTestLedger(t, WithGenesis(g))
+	round := ledger.Rounds().Latest()
+
+	var buf bytes.Buffer
+
+	assert.Error(t, ledger.DumpGenesis(round.Index+1, &buf))
+	assert.NoError(t, ledger.DumpGenesis(round.Index, &buf))
+
+	dumped := buf.String()
+
+	d, err := ParseGenesis(&dumped)
+	assert.NoError(t, err)
+	assert.Equal(t, g.Accounts, d.Accounts)
+	assert.Equal(t, g.Contracts, d.Contracts)
+	assert.Len(t, d.Params, len(genesisParams))
+
+	// Dumping a ledger bootstrapped from a genesis file whose entries are
+	// sorted reproduces the same state.
+
+	assert.Equal(t, round.Merkle, performInception(avl.New(store.NewInmem()), d).Merkle)
+}
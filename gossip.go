@@ -24,6 +24,7 @@ import (
 	"github.com/perlin-network/noise/skademlia"
 	"github.com/perlin-network/wavelet/debounce"
 	"github.com/perlin-network/wavelet/log"
+	"github.com/perlin-network/wavelet/sys"
 	"sync"
 	"time"
 )
@@ -36,6 +37,8 @@ type Gossiper struct {
 	streamsLock sync.Mutex
 
 	debouncer *debounce.Limiter
+
+	onRejection func(peer string, rejection *Rejection)
 }
 
 func NewGossiper(ctx context.Context, client *skademlia.Client, metrics *Metrics) *Gossiper {
@@ -56,6 +59,14 @@ func NewGossiper(ctx context.Context, client *skademlia.Client, metrics *Metrics
 	return g
 }
 
+// OnRejection registers a callback to be invoked with each reason a peer
+// reports for rejecting a transaction gossiped to it. Peers are only asked
+// to report reasons if sys.GossipRejections is enabled. It must be called
+// before any transactions are gossiped.
+func (g *Gossiper) OnRejection(fn func(peer string, rejection *Rejection)) {
+	g.onRejection = fn
+}
+
 func (g *Gossiper) Push(tx Transaction) {
 	g.debouncer.Add(debounce.Bytes(tx.Marshal()))
 
@@ -67,7 +78,7 @@ func (g *Gossiper) Push(tx Transaction) {
 func (g *Gossiper) Gossip(transactions [][]byte) {
 	var err error
 
-	batch := &Transactions{Transactions: transactions}
+	batch := &Transactions{Transactions: transactions, ReportRejections: sys.GossipRejections}
 
 	conns := g.client.ClosestPeers()
 
@@ -88,6 +99,8 @@ func (g *Gossiper) Gossip(transactions [][]byte) {
 			}
 
 			g.streams[target] = stream
+
+			go g.receiveRejections(target, stream)
 		}
 		g.streamsLock.Unlock()
 
@@ -109,3 +122,22 @@ func (g *Gossiper) Gossip(transactions [][]byte) {
 
 	wg.Wait()
 }
+
+// receiveRejections reads the reasons a peer reports for rejecting the
+// transactions gossiped to it until the stream to the peer is closed.
+func (g *Gossiper) receiveRejections(target string, stream Wavelet_GossipClient) {
+	for {
+		res, err := stream.Recv()
+		if err != nil {
+			return
+		}
+
+		if g.onRejection == nil {
+			continue
+		}
+
+		for _, rejection := range res.Rejections {
+			g.onRejection(target, rejection)
+		}
+	}
+}
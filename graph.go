@@ -22,11 +22,11 @@ package wavelet
 import (
 	"bytes"
 	"github.com/google/btree"
-	"github.com/perlin-network/noise/edwards25519"
 	"github.com/perlin-network/wavelet/sys"
 	"github.com/pkg/errors"
 	"sort"
 	"sync"
+	"time"
 )
 
 type GraphOption func(*Graph)
@@ -43,6 +43,12 @@ func WithMetrics(metrics *Metrics) GraphOption {
 	}
 }
 
+func WithLatencyTracker(latency *LatencyTracker) GraphOption {
+	return func(graph *Graph) {
+		graph.latency = latency
+	}
+}
+
 func VerifySignatures() GraphOption {
 	return func(graph *Graph) {
 		graph.verifySignatures = true
@@ -69,12 +75,18 @@ var (
 	ErrMissingParents     = errors.New("parents for transaction are not in graph")
 	ErrAlreadyExists      = errors.New("transaction already exists in the graph")
 	ErrDepthLimitExceeded = errors.New("transactions parents exceed depth limit")
+
+	ErrInvalidCreatorSignature = errors.New("tx has invalid creator signature")
+	ErrInvalidSenderSignature  = errors.New("tx has invalid sender signature")
+
+	ErrTransactionExpired = errors.New("tx has expired")
 )
 
 type Graph struct {
 	sync.RWMutex
 
 	metrics *Metrics
+	latency *LatencyTracker
 
 	transactions map[TransactionID]*Transaction    // All transactions. Includes incomplete transactions.
 	children     map[TransactionID][]TransactionID // Children of transactions. Includes incomplete/missing transactions.
@@ -82,6 +94,8 @@ type Graph struct {
 	missing    map[TransactionID]uint64   // Transactions that we are missing. Maps to depth of child of missing transaction.
 	incomplete map[TransactionID]struct{} // Transactions that don't have all parents available.
 
+	received map[TransactionID]time.Time // Times at which transactions were added to the graph.
+
 	eligibleIndex *btree.BTree              // Transactions that are eligible to be parent transactions.
 	seedIndex     *btree.BTree              // Indexes transactions by the number of zero bits prefixed of BLAKE2b(Sender || ParentIDs).
 	depthIndex    map[uint64][]*Transaction // Indexes transactions by their depth.
@@ -90,6 +104,9 @@ type Graph struct {
 	rootDepth uint64 // Depth of the graphs root.
 
 	verifySignatures bool
+
+	customChecks []namedTransactionCheck
+	pipeline     []transactionStage // Checks run against transactions before they are added to the graph.
 }
 
 func NewGraph(opts ...GraphOption) *Graph {
@@ -100,6 +117,8 @@ func NewGraph(opts ...GraphOption) *Graph {
 		missing:    make(map[TransactionID]uint64),
 		incomplete: make(map[TransactionID]struct{}),
 
+		received: make(map[TransactionID]time.Time),
+
 		eligibleIndex: btree.New(32),
 		seedIndex:     btree.New(32),
 		depthIndex:    make(map[uint64][]*Transaction),
@@ -109,6 +128,8 @@ func NewGraph(opts ...GraphOption) *Graph {
 		opt(g)
 	}
 
+	g.buildTransactionPipeline()
+
 	return g
 }
 
@@ -123,10 +144,6 @@ func (g *Graph) AddTransaction(tx Transaction) error {
 		return ErrAlreadyExists
 	}
 
-	if g.rootDepth > sys.MaxDepthDiff+tx.Depth {
-		return errors.Errorf("transactions depth is too low compared to root: root depth is %d, but tx depth is %d", g.rootDepth, tx.Depth)
-	}
-
 	if err := g.validateTransaction(tx); err != nil {
 		return errors.Wrap(err, "failed to validate transaction")
 	}
@@ -134,8 +151,11 @@ func (g *Graph) AddTransaction(tx Transaction) error {
 	ptr := &tx
 
 	g.transactions[tx.ID] = ptr
+	g.received[tx.ID] = time.Now()
 	delete(g.missing, tx.ID)
 
+	logEventTX("received", ptr)
+
 	parentsMissing := false
 
 	// Do not consider transactions below root.depth by exactly DEPTH_DIFF to be incomplete
@@ -172,7 +192,7 @@ func (g *Graph) AddTransaction(tx Transaction) error {
 // missing.
 func (g *Graph) MarkTransactionAsMissing(id TransactionID, depth uint64) {
 	g.Lock()
-	if g.rootDepth <= sys.MaxDepthDiff+depth {
+	if g.rootDepth <= sys.Params().MaxDepthDiff+depth {
 		g.missing[id] = depth
 	}
 	g.Unlock()
@@ -210,7 +230,7 @@ func (g *Graph) UpdateRootDepth(rootDepth uint64) {
 	g.rootDepth = rootDepth
 
 	for id, depth := range g.missing {
-		if rootDepth <= sys.MaxDepthDiff+depth {
+		if rootDepth <= sys.Params().MaxDepthDiff+depth {
 			continue
 		}
 
@@ -266,6 +286,7 @@ func (g *Graph) PruneBelowDepth(targetDepth uint64) int {
 
 			delete(g.transactions, tx.ID)
 			delete(g.children, tx.ID)
+			delete(g.received, tx.ID)
 
 			delete(g.missing, tx.ID)
 			delete(g.incomplete, tx.ID)
@@ -304,7 +325,7 @@ func (g *Graph) FindEligibleParents() []*Transaction {
 	g.eligibleIndex.Descend(func(i btree.Item) bool {
 		eligibleParent := i.(*sortByDepthTX)
 
-		if g.height-1 >= sys.MaxDepthDiff+eligibleParent.Depth {
+		if g.height-1 >= sys.Params().MaxDepthDiff+eligibleParent.Depth {
 			pending = append(pending, eligibleParent)
 			return true
 		}
@@ -411,18 +432,34 @@ func (g *Graph) Missing() []TransactionID {
 	return missing
 }
 
-func (g *Graph) ListTransactions(offset, limit uint64, sender, creator AccountID) (transactions []*Transaction) {
+// ListTransactions returns transactions stored in the graph ordered from the
+// deepest to the shallowest, paginated by offset and limit. If either sender
+// or creator is specified, only transactions sent or created by them are
+// listed. If any tags are specified, only transactions with one of the tags
+// are listed.
+func (g *Graph) ListTransactions(offset, limit uint64, sender, creator AccountID, tags ...byte) (transactions []*Transaction) {
 	g.RLock()
 	defer g.RUnlock()
 
 	for _, tx := range g.transactions {
-		if (sender == ZeroAccountID && creator == ZeroAccountID) || (sender != ZeroAccountID && tx.Sender == sender) || (creator != ZeroAccountID && tx.Creator == creator) {
-			transactions = append(transactions, tx)
+		if !((sender == ZeroAccountID && creator == ZeroAccountID) || (sender != ZeroAccountID && tx.Sender == sender) || (creator != ZeroAccountID && tx.Creator == creator)) {
+			continue
 		}
+
+		if len(tags) > 0 && bytes.IndexByte(tags, tx.Tag) == -1 {
+			continue
+		}
+
+		transactions = append(transactions, tx)
 	}
 
+	// Order transactions of equal depth by their IDs, such that pages are consistent across calls.
 	sort.Slice(transactions, func(i, j int) bool {
-		return transactions[i].Depth > transactions[j].Depth
+		if transactions[i].Depth != transactions[j].Depth {
+			return transactions[i].Depth > transactions[j].Depth
+		}
+
+		return bytes.Compare(transactions[i].ID[:], transactions[j].ID[:]) < 0
 	})
 
 	if offset != 0 || limit != 0 {
@@ -430,7 +467,7 @@ func (g *Graph) ListTransactions(offset, limit uint64, sender, creator AccountID
 			return nil
 		}
 
-		if offset+limit > uint64(len(transactions)) {
+		if limit == 0 || offset+limit > uint64(len(transactions)) {
 			limit = uint64(len(transactions)) - offset
 		}
 
@@ -440,6 +477,82 @@ func (g *Graph) ListTransactions(offset, limit uint64, sender, creator AccountID
 	return
 }
 
+// PendingTransaction is a transaction stored in the graph which is yet to be
+// finalized in a round.
+type PendingTransaction struct {
+	*Transaction
+
+	ReceivedAt time.Time // Time the transaction was added to the graph at.
+	Complete   bool      // Whether or not all of its ancestry is in the graph.
+}
+
+// PendingTransactions returns all transactions in the graph deeper than the
+// root of the graph, which are thus yet to be finalized, ordered from the
+// earliest to the latest received.
+func (g *Graph) PendingTransactions() []PendingTransaction {
+	g.RLock()
+
+	var pending []PendingTransaction
+
+	for id, tx := range g.transactions {
+		if tx.Depth <= g.rootDepth {
+			continue
+		}
+
+		_, incomplete := g.incomplete[id]
+
+		pending = append(pending, PendingTransaction{Transaction: tx, ReceivedAt: g.received[id], Complete: !incomplete})
+	}
+
+	g.RUnlock()
+
+	sort.Slice(pending, func(i, j int) bool {
+		if !pending[i].ReceivedAt.Equal(pending[j].ReceivedAt) {
+			return pending[i].ReceivedAt.Before(pending[j].ReceivedAt)
+		}
+
+		return bytes.Compare(pending[i].ID[:], pending[j].ID[:]) < 0
+	})
+
+	return pending
+}
+
+// PendingTransactionsByFee returns all transactions in the graph which are
+// yet to be finalized, ordered from the highest to the lowest fee, and then
+// from the earliest to the latest received.
+func (g *Graph) PendingTransactionsByFee() []PendingTransaction {
+	pending := g.PendingTransactions()
+
+	fees := make(map[TransactionID]uint64, len(pending))
+
+	for _, tx := range pending {
+		fees[tx.ID] = tx.Fee()
+	}
+
+	sort.SliceStable(pending, func(i, j int) bool {
+		return fees[pending[i].ID] > fees[pending[j].ID]
+	})
+
+	return pending
+}
+
+// PendingLen returns the number of transactions in the graph deeper than the
+// root of the graph. See PendingTransactions.
+func (g *Graph) PendingLen() int {
+	g.RLock()
+	defer g.RUnlock()
+
+	count := 0
+
+	for _, tx := range g.transactions {
+		if tx.Depth > g.rootDepth {
+			count++
+		}
+	}
+
+	return count
+}
+
 // FindTransaction returns transaction with id from graph, and nil otherwise.
 func (g *Graph) FindTransaction(id TransactionID) *Transaction {
 	g.RLock()
@@ -449,6 +562,85 @@ func (g *Graph) FindTransaction(id TransactionID) *Transaction {
 	return tx
 }
 
+// IsTransactionComplete returns whether or not the transaction with id is stored
+// in the graph alongside all of its parents.
+func (g *Graph) IsTransactionComplete(id TransactionID) bool {
+	g.RLock()
+	defer g.RUnlock()
+
+	if _, exists := g.transactions[id]; !exists {
+		return false
+	}
+
+	_, incomplete := g.incomplete[id]
+	return !incomplete
+}
+
+// FindNeighborhood returns the transaction with id, alongside its ancestors and
+// descendants stored in the graph which are at most depth parent or child
+// links away from it. Ancestors and descendants are ordered by the number of
+// links they are away from the transaction, and are each capped to limit
+// transactions. It returns nil if the transaction is not stored in the graph.
+func (g *Graph) FindNeighborhood(id TransactionID, depth, limit int) (tx *Transaction, ancestors, descendants []*Transaction) {
+	g.RLock()
+	defer g.RUnlock()
+
+	tx, exists := g.transactions[id]
+	if !exists {
+		return nil, nil, nil
+	}
+
+	ancestors = g.walk(tx, depth, limit, func(tx *Transaction) []TransactionID {
+		return tx.ParentIDs
+	})
+
+	descendants = g.walk(tx, depth, limit, func(tx *Transaction) []TransactionID {
+		return g.children[tx.ID]
+	})
+
+	return tx, ancestors, descendants
+}
+
+// walk performs a breadth-first search of transactions stored in the graph
+// starting from tx, following the links returned by next up to depth levels
+// deep, and up to limit transactions.
+func (g *Graph) walk(tx *Transaction, depth, limit int, next func(tx *Transaction) []TransactionID) []*Transaction {
+	var found []*Transaction
+
+	visited := map[TransactionID]struct{}{tx.ID: {}}
+	level := []*Transaction{tx}
+
+	for i := 0; i < depth && len(level) > 0; i++ {
+		var nextLevel []*Transaction
+
+		for _, current := range level {
+			for _, id := range next(current) {
+				if _, seen := visited[id]; seen {
+					continue
+				}
+
+				visited[id] = struct{}{}
+
+				linked, exists := g.transactions[id]
+				if !exists {
+					continue
+				}
+
+				if len(found) == limit {
+					return found
+				}
+
+				found = append(found, linked)
+				nextLevel = append(nextLevel, linked)
+			}
+		}
+
+		level = nextLevel
+	}
+
+	return found
+}
+
 // Height returns the height of the graph.
 func (g *Graph) Height() uint64 {
 	g.RLock()
@@ -522,6 +714,12 @@ func (g *Graph) updateGraph(tx *Transaction) error {
 		g.metrics.receivedTX.Mark(int64(tx.LogicalUnits()))
 	}
 
+	if g.latency != nil {
+		g.latency.MarkAccepted(tx.ID)
+	}
+
+	logEventTX("accepted", tx)
+
 	for _, childID := range g.children[tx.ID] {
 		if _, incomplete := g.incomplete[childID]; !incomplete {
 			continue
@@ -583,6 +781,7 @@ func (g *Graph) deleteProgeny(id TransactionID) {
 
 	delete(g.transactions, id)
 	delete(g.children, id)
+	delete(g.received, id)
 
 	delete(g.missing, id)
 	delete(g.incomplete, id)
@@ -592,83 +791,11 @@ func (g *Graph) deleteProgeny(id TransactionID) {
 	}
 }
 
-func (g *Graph) validateTransaction(tx Transaction) error {
-	if tx.ID == ZeroTransactionID {
-		return errors.New("tx must have an ID")
-	}
-
-	if tx.Sender == ZeroAccountID {
-		return errors.New("tx must have sender associated to it")
-	}
-
-	if tx.Creator == ZeroAccountID {
-		return errors.New("tx must have a creator associated to it")
-	}
-
-	if len(tx.ParentIDs) == 0 {
-		return errors.New("transaction has no parents")
-	}
-
-	if len(tx.ParentIDs) > sys.MaxParentsPerTransaction {
-		return errors.Errorf("tx has %d parents, but tx may only have %d parents at most", len(tx.ParentIDs), sys.MaxParentsPerTransaction)
-	}
-
-	// Check that parents are lexicographically sorted, are not itself, and are unique.
-	set := make(map[TransactionID]struct{}, len(tx.ParentIDs))
-
-	for i := len(tx.ParentIDs) - 1; i > 0; i-- {
-		if tx.ID == tx.ParentIDs[i] {
-			return errors.New("tx must not include itself in its parents")
-		}
-
-		if bytes.Compare(tx.ParentIDs[i-1][:], tx.ParentIDs[i][:]) > 0 {
-			return errors.New("tx must have lexicographically sorted parent ids")
-		}
-
-		if _, duplicate := set[tx.ParentIDs[i]]; duplicate {
-			return errors.New("tx must not have duplicate parent ids")
-		}
-
-		set[tx.ParentIDs[i]] = struct{}{}
-	}
-
-	if tx.Tag > sys.TagBatch {
-		return errors.New("tx has an unknown tag")
-	}
-
-	if tx.Tag != sys.TagNop && len(tx.Payload) == 0 {
-		return errors.New("tx must have payload if not a nop transaction")
-	}
-
-	if tx.Tag == sys.TagNop && len(tx.Payload) != 0 {
-		return errors.New("tx must have no payload if is a nop transaction")
-	}
-
-	if g.verifySignatures {
-		var nonce [8]byte // TODO(kenta): nonce
-
-		if tx.Sender != tx.Creator {
-			if !edwards25519.Verify(tx.Creator, append(nonce[:], append([]byte{tx.Tag}, tx.Payload...)...), tx.CreatorSignature) {
-				return errors.New("tx has invalid creator signature")
-			}
-		}
-
-		cpy := tx
-		cpy.SenderSignature = ZeroSignature
-
-		if !edwards25519.Verify(tx.Sender, cpy.Marshal(), tx.SenderSignature) {
-			return errors.New("tx has invalid sender signature")
-		}
-	}
-
-	return nil
-}
-
 func (g *Graph) validateTransactionParents(tx *Transaction) error {
 	// Do not consider transactions below root.depth by exactly DEPTH_DIFF to be incomplete
 	// at all. Permit them to have incomplete parent histories.
 
-	if g.rootDepth == uint64(sys.MaxDepthDiff)+tx.Depth {
+	if g.rootDepth == uint64(sys.Params().MaxDepthDiff)+tx.Depth {
 		return nil
 	}
 
@@ -681,7 +808,7 @@ func (g *Graph) validateTransactionParents(tx *Transaction) error {
 			return errors.New("parent not stored in graph")
 		}
 
-		if tx.Depth > sys.MaxDepthDiff+parent.Depth { // Check if the depth of each parents is acceptable.
+		if tx.Depth > sys.Params().MaxDepthDiff+parent.Depth { // Check if the depth of each parents is acceptable.
 			return errors.Wrapf(ErrDepthLimitExceeded, "tx parent has ineligible depth: parents depth is %d, but tx depth is %d", parent.Depth, tx.Depth)
 		}
 
@@ -20,9 +20,12 @@
 package wavelet
 
 import (
+	"bytes"
+	"context"
 	"github.com/perlin-network/noise/skademlia"
 	"github.com/perlin-network/wavelet/sys"
 	"github.com/pkg/errors"
+	"github.com/rcrowley/go-metrics"
 	"github.com/stretchr/testify/assert"
 	"math/rand"
 	"testing"
@@ -131,6 +134,108 @@ func TestGraphFuzz(t *testing.T) {
 	assert.Len(t, graph.Missing(), 0)
 }
 
+func TestGraphListTransactions(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	root := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil))
+	graph := NewGraph(WithRoot(root))
+
+	for i := 0; i < 10; i++ {
+		var payload [50]byte
+
+		_, err = rand.Read(payload[:])
+		assert.NoError(t, err)
+
+		tx := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagTransfer, payload[:]), graph.FindEligibleParents()...)
+		assert.NoError(t, graph.AddTransaction(tx))
+	}
+
+	all := graph.ListTransactions(0, 0, ZeroAccountID, ZeroAccountID)
+	assert.Len(t, all, 11)
+
+	for i := 1; i < len(all); i++ {
+		assert.True(t, all[i-1].Depth > all[i].Depth || (all[i-1].Depth == all[i].Depth && bytes.Compare(all[i-1].ID[:], all[i].ID[:]) < 0))
+	}
+
+	assert.Equal(t, all[3:5], graph.ListTransactions(3, 2, ZeroAccountID, ZeroAccountID))
+	assert.Equal(t, all[8:], graph.ListTransactions(8, 0, ZeroAccountID, ZeroAccountID))
+	assert.Empty(t, graph.ListTransactions(11, 1, ZeroAccountID, ZeroAccountID))
+
+	assert.Len(t, graph.ListTransactions(0, 0, ZeroAccountID, ZeroAccountID, sys.TagTransfer), 10)
+	assert.Len(t, graph.ListTransactions(0, 0, ZeroAccountID, ZeroAccountID, sys.TagNop), 1)
+	assert.Len(t, graph.ListTransactions(0, 0, ZeroAccountID, ZeroAccountID, sys.TagNop, sys.TagTransfer), 11)
+	assert.Empty(t, graph.ListTransactions(0, 0, ZeroAccountID, ZeroAccountID, sys.TagStake))
+}
+
+func TestGraphFindNeighborhood(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	root := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil))
+	graph := NewGraph(WithRoot(root))
+
+	chain := []Transaction{root}
+
+	for i := 0; i < 4; i++ {
+		tx := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil), graph.FindEligibleParents()...)
+		assert.NoError(t, graph.AddTransaction(tx))
+		assert.Equal(t, []TransactionID{chain[len(chain)-1].ID}, tx.ParentIDs)
+
+		chain = append(chain, tx)
+	}
+
+	ids := func(transactions []*Transaction) (ids []TransactionID) {
+		for _, tx := range transactions {
+			ids = append(ids, tx.ID)
+		}
+		return
+	}
+
+	tx, ancestors, descendants := graph.FindNeighborhood(chain[2].ID, 1, 100)
+	if assert.NotNil(t, tx) {
+		assert.Equal(t, chain[2].ID, tx.ID)
+	}
+	assert.Equal(t, []TransactionID{chain[1].ID}, ids(ancestors))
+	assert.Equal(t, []TransactionID{chain[3].ID}, ids(descendants))
+
+	_, ancestors, descendants = graph.FindNeighborhood(chain[2].ID, 2, 100)
+	assert.Equal(t, []TransactionID{chain[1].ID, chain[0].ID}, ids(ancestors))
+	assert.Equal(t, []TransactionID{chain[3].ID, chain[4].ID}, ids(descendants))
+
+	_, ancestors, descendants = graph.FindNeighborhood(chain[2].ID, 10, 1)
+	assert.Equal(t, []TransactionID{chain[1].ID}, ids(ancestors))
+	assert.Equal(t, []TransactionID{chain[3].ID}, ids(descendants))
+
+	_, ancestors, descendants = graph.FindNeighborhood(chain[0].ID, 10, 100)
+	assert.Empty(t, ancestors)
+	assert.Len(t, descendants, 4)
+
+	tx, _, _ = graph.FindNeighborhood(ZeroTransactionID, 1, 100)
+	assert.Nil(t, tx)
+}
+
+func TestGraphIsTransactionComplete(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	root := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil))
+	graph := NewGraph(WithRoot(root))
+
+	parent := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil), graph.FindEligibleParents()...)
+	child := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil), &parent)
+
+	assert.False(t, graph.IsTransactionComplete(child.ID))
+
+	assert.Equal(t, ErrMissingParents, errors.Cause(graph.AddTransaction(child)))
+	assert.False(t, graph.IsTransactionComplete(child.ID))
+
+	assert.NoError(t, graph.AddTransaction(parent))
+	assert.True(t, graph.IsTransactionComplete(parent.ID))
+	assert.True(t, graph.IsTransactionComplete(child.ID))
+	assert.True(t, graph.IsTransactionComplete(root.ID))
+}
+
 func TestGraphPruneBelowDepth(t *testing.T) {
 	t.Parallel()
 
@@ -224,7 +329,7 @@ func TestGraphUpdateRoot(t *testing.T) {
 	// Assert that updating the root removes missing transactions and
 	// their children below a certain depth.
 
-	depthLimit := graph.height - 1 - sys.MaxDepthDiff
+	depthLimit := graph.height - 1 - sys.Params().MaxDepthDiff
 
 	for depth := depthLimit - 30; depth < depthLimit+30; depth++ {
 		var id TransactionID
@@ -244,7 +349,7 @@ func TestGraphUpdateRoot(t *testing.T) {
 	assert.Len(t, graph.children, numChildren)
 
 	// Create a transaction that is at an ineligible depth exceeding DEPTH_DIFF.
-	tx := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil), graph.depthIndex[(graph.height-1)-(sys.MaxDepthDiff+2)][0])
+	tx := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil), graph.depthIndex[(graph.height-1)-(sys.Params().MaxDepthDiff+2)][0])
 
 	// An error should occur.
 	assert.Error(t, graph.AddTransaction(tx))
@@ -282,15 +387,123 @@ func TestGraphValidateTransactionParents(t *testing.T) {
 		}
 	}
 
-	tx := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil), graph.depthIndex[(graph.height-1)-(sys.MaxDepthDiff+2)][0])
+	tx := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil), graph.depthIndex[(graph.height-1)-(sys.Params().MaxDepthDiff+2)][0])
 
-	tx.Depth += sys.MaxDepthDiff
+	tx.Depth += sys.Params().MaxDepthDiff
 	assert.True(t, errors.Cause(graph.validateTransactionParents(&tx)) == ErrDepthLimitExceeded)
 
 	tx.Depth--
 	assert.True(t, errors.Cause(graph.validateTransactionParents(&tx)) != ErrDepthLimitExceeded)
 }
 
+func TestGraphValidateTransactionPayloadSize(t *testing.T) {
+	t.Parallel()
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	root := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil))
+	graph := NewGraph(WithRoot(root))
+
+	tx := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagTransfer, make([]byte, sys.MaxTransactionPayloadSize)), graph.FindEligibleParents()...)
+	assert.NoError(t, graph.validateTransaction(tx))
+
+	_, err = UnmarshalTransaction(bytes.NewReader(tx.Marshal()))
+	assert.NoError(t, err)
+
+	tx = AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagTransfer, make([]byte, sys.MaxTransactionPayloadSize+1)), graph.FindEligibleParents()...)
+	assert.Error(t, graph.validateTransaction(tx))
+
+	_, err = UnmarshalTransaction(bytes.NewReader(tx.Marshal()))
+	assert.Error(t, err)
+}
+
+func TestGraphCheckTransactions(t *testing.T) {
+	t.Parallel()
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	errNoTransfers := errors.New("transfers are not allowed")
+
+	m := NewMetrics(context.Background())
+	defer m.Stop()
+
+	root := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil))
+	graph := NewGraph(WithMetrics(m), WithRoot(root), VerifySignatures(), CheckTransactions("policy", func(tx *Transaction) error {
+		if tx.Tag == sys.TagTransfer {
+			return errNoTransfers
+		}
+
+		return nil
+	}))
+
+	tx := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagTransfer, []byte{1}), graph.FindEligibleParents()...)
+	assert.Equal(t, errNoTransfers, errors.Cause(graph.AddTransaction(tx)))
+
+	// Transactions which fail a check built into the graph never reach custom checks.
+	tx = AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagTransfer, []byte{1}), graph.FindEligibleParents()...)
+	tx.SenderSignature[0] ^= 1
+	assert.Equal(t, ErrInvalidSenderSignature, errors.Cause(graph.AddTransaction(tx)))
+
+	tx = AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil), graph.FindEligibleParents()...)
+	assert.NoError(t, graph.AddTransaction(tx))
+
+	assert.EqualValues(t, 1, m.registry.Get("tx.check.signature.rejected").(metrics.Counter).Count())
+	assert.EqualValues(t, 1, m.registry.Get("tx.check.policy.rejected").(metrics.Counter).Count())
+	assert.EqualValues(t, 2, m.registry.Get("tx.check.policy.latency").(metrics.Timer).Count())
+	assert.EqualValues(t, 3, m.registry.Get("tx.check.format.latency").(metrics.Timer).Count())
+}
+
+func TestGraphPendingTransactions(t *testing.T) {
+	t.Parallel()
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	root := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil))
+	graph := NewGraph(WithRoot(root))
+
+	assert.Empty(t, graph.PendingTransactions())
+
+	parent := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil), &root)
+	child := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagTransfer, []byte{1}), &parent)
+
+	// Have the child be received before its parent, such that it is incomplete until its parent is received.
+
+	assert.Equal(t, ErrMissingParents, graph.AddTransaction(child))
+
+	pending := graph.PendingTransactions()
+
+	if assert.Len(t, pending, 1) {
+		assert.Equal(t, child.ID, pending[0].ID)
+		assert.False(t, pending[0].Complete)
+		assert.False(t, pending[0].ReceivedAt.IsZero())
+	}
+
+	assert.NoError(t, graph.AddTransaction(parent))
+
+	pending = graph.PendingTransactions()
+
+	if assert.Len(t, pending, 2) {
+		assert.Equal(t, child.ID, pending[0].ID)
+		assert.True(t, pending[0].Complete)
+
+		assert.Equal(t, parent.ID, pending[1].ID)
+		assert.True(t, pending[1].Complete)
+	}
+
+	// Transactions no deeper than the root of the graph are no longer pending.
+
+	graph.UpdateRootDepth(parent.Depth)
+
+	pending = graph.PendingTransactions()
+
+	if assert.Len(t, pending, 1) {
+		assert.Equal(t, child.ID, pending[0].ID)
+	}
+}
+
 func TestGraphFindEligibleCritical(t *testing.T) {
 	t.Parallel()
 
@@ -391,3 +604,31 @@ func TestGraphFindEligibleCriticalInBigGraph(t *testing.T) {
 
 	assert.Equal(t, *graph.FindEligibleCritical(difficulty), eligible)
 }
+
+func TestGraphPendingTransactionsByFee(t *testing.T) {
+	defer func(params sys.ConsensusParams) {
+		sys.UpdateParams(func(p *sys.ConsensusParams) { *p = params })
+	}(sys.Params())
+	sys.UpdateParams(func(p *sys.ConsensusParams) { p.TransactionFeePerByte = 1 })
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	root := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil))
+	graph := NewGraph(WithRoot(root))
+
+	for _, size := range []int{10, 30, 20} {
+		tx := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagTransfer, make([]byte, size)), graph.FindEligibleParents()...)
+		assert.NoError(t, graph.AddTransaction(tx))
+	}
+
+	pending := graph.PendingTransactionsByFee()
+	assert.Len(t, pending, 3)
+
+	for i := 1; i < len(pending); i++ {
+		assert.True(t, pending[i-1].Fee() >= pending[i].Fee())
+	}
+
+	assert.Len(t, pending[0].Payload, 30)
+	assert.Len(t, pending[2].Payload, 10)
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"context"
+	"github.com/perlin-network/wavelet/log"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"math/rand"
+	"time"
+)
+
+// KeepPeersAlive pings every peer the node is connected to every
+// sys.PingInterval, and records the round-trip time of every ping the peers
+// respond to, alongside how far their clocks are ahead of ours, into their
+// stats. Peers which fail to respond to
+// sys.MaxMissedPings pings in a row are disconnected, such that a peer which
+// went away without its connection being torn down is no longer sampled to be
+// queried for consensus or syncing. Stats of peers which have gone idle for
+// sys.PeerStatsIdleTimeout are pruned away.
+func (l *Ledger) KeepPeersAlive() {
+	missed := make(map[string]int)
+
+	for {
+		select {
+		case <-l.ctx.Done():
+			return
+		case <-time.After(sys.PingInterval):
+		}
+
+		l.pingPeers(missed)
+		l.peers.Prune(sys.PeerStatsIdleTimeout)
+	}
+}
+
+type pong struct {
+	conn *grpc.ClientConn
+	rtt  time.Duration
+	err  error
+
+	// Estimate of how far the clock of the peer is ahead of ours, assuming
+	// that the ping took as long to reach the peer as the pong took to reach
+	// us. Only set should the peer have reported its time.
+	offset *time.Duration
+}
+
+// pingPeers pings every peer the node is connected to at once, and disconnects
+// those which have missed too many pings in a row. missed holds the number of
+// pings in a row each peer has missed, keyed by the address it was dialed at.
+func (l *Ledger) pingPeers(missed map[string]int) {
+	conns := l.client.AllPeers()
+	pongs := make(chan pong, len(conns))
+
+	nonce := rand.Uint64()
+
+	for _, conn := range conns {
+		go func(conn *grpc.ClientConn) {
+			ctx, cancel := context.WithTimeout(context.Background(), sys.PingTimeout)
+			defer cancel()
+
+			start := time.Now()
+
+			res, err := NewWaveletClient(conn).Ping(ctx, &PingRequest{Nonce: nonce})
+
+			p := pong{conn: conn, rtt: time.Since(start), err: err}
+
+			// Peers running a version of the node which predates pings are
+			// nonetheless alive should they respond at all.
+			if status.Code(err) == codes.Unimplemented {
+				p.err = nil
+			} else if err == nil && res.Nonce != nonce {
+				p.err = errors.Errorf("peer responded to a ping with nonce %d, but expected %d", res.Nonce, nonce)
+			} else if err == nil && res.Time != 0 {
+				offset := time.Unix(0, res.Time).Sub(start.Add(p.rtt / 2))
+				p.offset = &offset
+			}
+
+			pongs <- p
+		}(conn)
+	}
+
+	connected := make(map[string]struct{}, len(conns))
+
+	for range conns {
+		p := <-pongs
+		address := p.conn.Target()
+
+		connected[address] = struct{}{}
+
+		if p.err == nil {
+			delete(missed, address)
+			l.peers.observePing(address, p.rtt, p.offset)
+
+			continue
+		}
+
+		if missed[address]++; missed[address] < sys.MaxMissedPings {
+			continue
+		}
+
+		delete(missed, address)
+
+		_ = p.conn.Close()
+
+		logger := log.Network("unresponsive")
+		logger.Warn().
+			Err(p.err).
+			Str("address", address).
+			Int("missed_pings", sys.MaxMissedPings).
+			Msg("Disconnected from a peer which stopped responding to pings.")
+	}
+
+	// Forget about peers which have since disconnected.
+	for address := range missed {
+		if _, exists := connected[address]; !exists {
+			delete(missed, address)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"context"
+	"github.com/perlin-network/noise"
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"net"
+	"testing"
+	"time"
+)
+
+// unresponsivePeer is a peer whose connection lingers on, but which never
+// responds to pings.
+type unresponsivePeer struct {
+	WaveletServer
+}
+
+func (unresponsivePeer) Ping(ctx context.Context, _ *PingRequest) (*PingResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func newTestPeer(t *testing.T, server WaveletServer) (*skademlia.Client, string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	addr := listener.Addr().String()
+
+	client := skademlia.NewClient(addr, keys, skademlia.WithC1(1), skademlia.WithC2(1))
+	client.SetCredentials(noise.NewCredentials(addr, client.Protocol()))
+
+	if server != nil {
+		s := client.Listen()
+		RegisterWaveletServer(s, server)
+
+		go func() { _ = s.Serve(listener) }()
+		t.Cleanup(s.Stop)
+	}
+
+	return client, addr
+}
+
+func TestKeepPeersAlive(t *testing.T) {
+	defer func(timeout time.Duration) { sys.PingTimeout = timeout }(sys.PingTimeout)
+	sys.PingTimeout = 50 * time.Millisecond
+
+	client, _ := newTestPeer(t, nil)
+	_, aliveAddr := newTestPeer(t, new(Protocol))
+	_, deadAddr := newTestPeer(t, unresponsivePeer{})
+
+	ledger := &Ledger{client: client, peers: NewPeerStats()}
+
+	client.OnPeerJoin(func(conn *grpc.ClientConn, id *skademlia.ID) {
+		ledger.peers.Register(id)
+	})
+
+	_, err := client.Dial(aliveAddr)
+	assert.NoError(t, err)
+
+	_, err = client.Dial(deadAddr)
+	assert.NoError(t, err)
+
+	missed := make(map[string]int)
+
+	for i := 0; i < sys.MaxMissedPings-1; i++ {
+		ledger.pingPeers(missed)
+	}
+
+	// The unresponsive peer is given the benefit of the doubt until it misses
+	// too many pings in a row.
+	assert.Len(t, client.AllPeers(), 2)
+	assert.Equal(t, sys.MaxMissedPings-1, missed[deadAddr])
+	assert.NotContains(t, missed, aliveAddr)
+
+	ledger.pingPeers(missed)
+
+	deadline := time.Now().Add(3 * time.Second)
+	for len(client.AllPeers()) > 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if peers := client.AllPeers(); assert.Len(t, peers, 1) {
+		assert.Equal(t, aliveAddr, peers[0].Target())
+	}
+
+	assert.Empty(t, missed)
+
+	var alive *skademlia.ID
+	for _, id := range client.ClosestPeerIDs() {
+		if id.Address() == aliveAddr {
+			alive = id
+		}
+	}
+
+	if assert.NotNil(t, alive) {
+		stats, exists := ledger.peers.Snapshot(alive.PublicKey())
+		if assert.True(t, exists) {
+			assert.True(t, stats.PingRTT > 0)
+			assert.False(t, stats.LastPong.IsZero())
+
+			if assert.NotNil(t, stats.ClockOffset) {
+				assert.True(t, *stats.ClockOffset < time.Second && *stats.ClockOffset > -time.Second)
+			}
+		}
+
+		_, n := ledger.peers.ClockOffset()
+		assert.Equal(t, 1, n)
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"encoding/binary"
+	"github.com/perlin-network/wavelet/sys"
+	"sync"
+	"time"
+)
+
+// TransactionTimings records the moments at which a transaction passed through
+// each stage of its lifecycle on this node. Stages which have not yet been
+// reached are left as the zero time.
+type TransactionTimings struct {
+	Received  time.Time // Transaction was first added to the ledger.
+	Accepted  time.Time // Transaction had its full ancestry available and was indexed in the graph.
+	Finalized time.Time // Round containing the transaction was decided by the finalizer.
+	Applied   time.Time // Transaction was applied and committed to the ledger state.
+}
+
+// LatencyTracker keeps track of the lifecycle timings of a sample of
+// transactions, and feeds the time elapsed between each stage into the
+// latency timers of a metrics registry.
+type LatencyTracker struct {
+	sync.Mutex
+
+	metrics *Metrics
+	timings *LRU
+}
+
+func NewLatencyTracker(metrics *Metrics, size int) *LatencyTracker {
+	return &LatencyTracker{metrics: metrics, timings: NewLRU(size)}
+}
+
+// sampled deterministically decides whether or not the lifecycle of a
+// transaction should be tracked based on its ID.
+func (t *LatencyTracker) sampled(id TransactionID) bool {
+	if sys.TransactionLatencySampleRate <= 0 {
+		return false
+	}
+
+	return float64(binary.BigEndian.Uint16(id[:2])) < sys.TransactionLatencySampleRate*float64(1<<16)
+}
+
+func (t *LatencyTracker) MarkReceived(id TransactionID) {
+	if !t.sampled(id) {
+		return
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	if _, exists := t.timings.load(id); exists {
+		return
+	}
+
+	t.timings.put(id, &TransactionTimings{Received: time.Now()})
+}
+
+func (t *LatencyTracker) MarkAccepted(id TransactionID) {
+	t.Lock()
+	defer t.Unlock()
+
+	timings := t.lookup(id)
+	if timings == nil || !timings.Accepted.IsZero() {
+		return
+	}
+
+	timings.Accepted = time.Now()
+
+	if t.metrics != nil {
+		t.metrics.acceptLatency.Update(timings.Accepted.Sub(timings.Received))
+	}
+}
+
+func (t *LatencyTracker) MarkFinalized(id TransactionID, at time.Time) {
+	t.Lock()
+	defer t.Unlock()
+
+	timings := t.lookup(id)
+	if timings == nil || !timings.Finalized.IsZero() {
+		return
+	}
+
+	timings.Finalized = at
+
+	if t.metrics != nil {
+		t.metrics.finalizeLatency.Update(timings.Finalized.Sub(timings.Received))
+	}
+}
+
+func (t *LatencyTracker) MarkApplied(id TransactionID, at time.Time) {
+	t.Lock()
+	defer t.Unlock()
+
+	timings := t.lookup(id)
+	if timings == nil || !timings.Applied.IsZero() {
+		return
+	}
+
+	timings.Applied = at
+
+	if t.metrics != nil {
+		t.metrics.applyLatency.Update(timings.Applied.Sub(timings.Received))
+	}
+}
+
+// Timings returns a copy of the recorded lifecycle timings of a transaction. It
+// returns false should the transaction not have been sampled, or should its
+// timings have been evicted.
+func (t *LatencyTracker) Timings(id TransactionID) (TransactionTimings, bool) {
+	t.Lock()
+	defer t.Unlock()
+
+	timings := t.lookup(id)
+	if timings == nil {
+		return TransactionTimings{}, false
+	}
+
+	return *timings, true
+}
+
+func (t *LatencyTracker) lookup(id TransactionID) *TransactionTimings {
+	timings, exists := t.timings.load(id)
+	if !exists {
+		return nil
+	}
+
+	return timings.(*TransactionTimings)
+}
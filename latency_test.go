@@ -0,0 +1,73 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"context"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLatencyTracker(t *testing.T) {
+	metrics := NewMetrics(context.Background())
+	defer metrics.Stop()
+
+	tracker := NewLatencyTracker(metrics, 16)
+
+	var sampled, skipped TransactionID
+	skipped[0] = 0xff
+
+	tracker.MarkReceived(sampled)
+	tracker.MarkReceived(skipped)
+
+	_, exists := tracker.Timings(skipped)
+	assert.False(t, exists)
+
+	tracker.MarkAccepted(sampled)
+
+	now := time.Now()
+	tracker.MarkFinalized(sampled, now)
+	tracker.MarkApplied(sampled, now.Add(1*time.Millisecond))
+
+	timings, exists := tracker.Timings(sampled)
+	assert.True(t, exists)
+
+	assert.False(t, timings.Received.After(timings.Accepted))
+	assert.Equal(t, now, timings.Finalized)
+	assert.True(t, timings.Applied.After(timings.Finalized))
+
+	assert.EqualValues(t, 1, metrics.acceptLatency.Count())
+	assert.EqualValues(t, 1, metrics.finalizeLatency.Count())
+	assert.EqualValues(t, 1, metrics.applyLatency.Count())
+
+	rate := sys.TransactionLatencySampleRate
+	sys.TransactionLatencySampleRate = 0
+	defer func() { sys.TransactionLatencySampleRate = rate }()
+
+	var other TransactionID
+	other[1] = 1
+
+	tracker.MarkReceived(other)
+
+	_, exists = tracker.Timings(other)
+	assert.False(t, exists)
+}
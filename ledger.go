@@ -36,15 +36,22 @@ import (
 	"golang.org/x/crypto/blake2b"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/peer"
+	"math"
 	"math/rand"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type Ledger struct {
 	client  *skademlia.Client
 	metrics *Metrics
+	latency *LatencyTracker
+	peers   *PeerStats
+
+	diversity *PeerDiversity
+	bans      *PeerBans
 
 	accounts *Accounts
 	rounds   *Rounds
@@ -56,6 +63,12 @@ type Ledger struct {
 
 	consensus sync.WaitGroup
 
+	// ctx is cancelled once the ledger is stopped, upon which all workers of
+	// the ledger tracked by workers exit. See Stop.
+	ctx     context.Context
+	stop    context.CancelFunc
+	workers sync.WaitGroup
+
 	broadcastNops      bool
 	broadcastNopsDelay time.Time
 	broadcastNopsLock  sync.Mutex
@@ -64,24 +77,149 @@ type Ledger struct {
 	syncTimer *time.Timer
 	syncVotes chan vote
 
+	synced     bool
+	syncedLock sync.RWMutex
+
+	restores chan restoreRequest
+
+	genesis     *Genesis
+	genesisPath string
+
+	finalizedHooks     []func(round Round)
+	finalizedHooksLock sync.RWMutex
+
+	advanced     chan struct{}
+	advancedLock sync.Mutex
+
+	upstream string
+	standby  *standby
+	archive  bool
+
+	checks []GraphOption
+
 	cacheCollapse *LRU
 	cacheChunks   *LRU
 
+	rejections     *LRU
+	rejectionsLock sync.Mutex
+
+	statuses *LRU
+
+	// historyLock guards the round account history is available from, which
+	// is moved forward both by pruning and by skipping ahead rounds, and the
+	// round history was last pruned up to.
+	historyLock   sync.Mutex
+	historyPruned uint64
+
 	sendQuotaTokenBucket chan struct{}
 }
 
-func NewLedger(kv store.KV, client *skademlia.Client, genesis *string) *Ledger {
-	metrics := NewMetrics(context.TODO())
+type LedgerOption func(*Ledger)
+
+// WithPeerStats has the ledger record the latest rounds reported to it by its
+// peers into stats.
+func WithPeerStats(stats *PeerStats) LedgerOption {
+	return func(ledger *Ledger) {
+		ledger.peers = stats
+	}
+}
+
+// WithPeerDiversity has the ledger select the peers it queries for consensus
+// and syncing through diversity.
+func WithPeerDiversity(diversity *PeerDiversity) LedgerOption {
+	return func(ledger *Ledger) {
+		ledger.diversity = diversity
+	}
+}
+
+// WithPeerBans has the ledger record peers it is asked to ban into bans, which
+// is expected to be among the noise protocols of the node's transport
+// credentials. Bans persisted by the ledger are loaded back into bans.
+func WithPeerBans(bans *PeerBans) LedgerOption {
+	return func(ledger *Ledger) {
+		ledger.bans = bans
+	}
+}
+
+// WithGenesis has the ledger be bootstrapped from an already-parsed genesis,
+// such as one built up in tests or by applications embedding a node.
+func WithGenesis(genesis *Genesis) LedgerOption {
+	return func(ledger *Ledger) {
+		ledger.genesis = genesis
+	}
+}
+
+// WithGenesisPath has the ledger be bootstrapped from the genesis file located
+// at path.
+func WithGenesisPath(path string) LedgerOption {
+	return func(ledger *Ledger) {
+		ledger.genesisPath = path
+	}
+}
+
+// WithUpstream has the ledger run as a read replica of the full node located at
+// address. Rather than participate in consensus, the ledger adopts every round
+// the upstream node finalizes alongside the state changes and transactions
+// applied in it. See Replicate.
+func WithUpstream(address string) LedgerOption {
+	return func(ledger *Ledger) {
+		ledger.upstream = address
+	}
+}
+
+// WithTransactionCheck has the ledger reject transactions which fail check
+// before adding them to its graph and gossiping them to its peers. Checks are
+// run under name after the checks built into the graph, in the order they are
+// given. See TransactionCheck.
+func WithTransactionCheck(name string, check TransactionCheck) LedgerOption {
+	return func(ledger *Ledger) {
+		ledger.checks = append(ledger.checks, CheckTransactions(name, check))
+	}
+}
+
+// NewLedger creates a ledger whose state is persisted in kv. The genesis of the
+// ledger is either given as the JSON contents of a genesis file, or through
+// WithGenesis or WithGenesisPath. If none are given, the default genesis is
+// used. An invalid genesis panics rather than falling back to the default.
+func NewLedger(kv store.KV, client *skademlia.Client, genesis *string, opts ...LedgerOption) *Ledger {
+	ledger := &Ledger{peers: NewPeerStats(), diversity: NewPeerDiversity(), bans: NewPeerBans()}
+	ledger.ctx, ledger.stop = context.WithCancel(context.Background())
+
+	for _, opt := range opts {
+		opt(ledger)
+	}
+
+	g, err := ledger.loadGenesis(genesis)
+	if err != nil {
+		panic(err)
+	}
+
+	// Consensus parameters specified in the genesis must be applied on every
+	// start, not only upon inception.
+
+	g.ApplyParams()
+
+	metrics := NewMetrics(ledger.ctx)
+	latency := NewLatencyTracker(metrics, 4096)
 
 	accounts := NewAccounts(kv)
-	go accounts.GC(context.Background())
+	ledger.spawn(func() { accounts.GC(ledger.ctx) })
+
+	banned, err := LoadPeerBans(kv)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, id := range banned {
+		ledger.bans.Ban(id)
+	}
 
 	rounds, err := NewRounds(kv, sys.PruningLimit)
 
 	var round *Round
 
 	if rounds != nil && err != nil {
-		genesis := performInception(accounts.tree, genesis)
+		genesis := performInception(accounts.tree, g)
 		if err := accounts.Commit(nil); err != nil {
 			panic(err)
 		}
@@ -94,6 +232,10 @@ func NewLedger(kv store.KV, client *skademlia.Client, genesis *string) *Ledger {
 
 		round = ptr
 	} else if rounds != nil {
+		if err := recoverWriteAheadLog(kv, accounts, rounds); err != nil {
+			panic(err)
+		}
+
 		round = rounds.Latest()
 	}
 
@@ -101,43 +243,170 @@ func NewLedger(kv store.KV, client *skademlia.Client, genesis *string) *Ledger {
 		panic("???: COULD NOT FIND GENESIS, OR STORAGE IS CORRUPTED.")
 	}
 
-	graph := NewGraph(WithMetrics(metrics), WithRoot(round.End), VerifySignatures())
+	// Databases created before account history was tracked only have history
+	// available from whichever round they are presently at.
+	if _, err := LoadAccountHistoryFrom(kv); err != nil {
+		if err := StoreAccountHistoryFrom(kv, round.Index); err != nil {
+			panic(err)
+		}
+	}
+
+	graph := NewGraph(append([]GraphOption{WithMetrics(metrics), WithLatencyTracker(latency), WithRoot(round.End), VerifySignatures()}, ledger.checks...)...)
+
+	gossiper := NewGossiper(ledger.ctx, client, metrics)
+	finalizer := NewSnowball(WithBeta(sys.Params().SnowballBeta))
+	syncer := NewSnowball(WithBeta(sys.Params().SnowballBeta))
+
+	ledger.client = client
+	ledger.metrics = metrics
+	ledger.latency = latency
+
+	ledger.accounts = accounts
+	ledger.rounds = rounds
+	ledger.graph = graph
 
-	gossiper := NewGossiper(context.TODO(), client, metrics)
-	finalizer := NewSnowball(WithBeta(sys.SnowballBeta))
-	syncer := NewSnowball(WithBeta(sys.SnowballBeta))
+	ledger.gossiper = gossiper
+	ledger.finalizer = finalizer
+	ledger.syncer = syncer
 
-	ledger := &Ledger{
-		client:  client,
-		metrics: metrics,
+	ledger.sync = make(chan struct{})
+	ledger.syncTimer = time.NewTimer(0)
+	ledger.syncVotes = make(chan vote, sys.Params().SnowballK)
 
-		accounts: accounts,
-		rounds:   rounds,
-		graph:    graph,
+	ledger.restores = make(chan restoreRequest)
 
-		gossiper:  gossiper,
-		finalizer: finalizer,
-		syncer:    syncer,
+	ledger.advanced = make(chan struct{})
 
-		sync:      make(chan struct{}),
-		syncTimer: time.NewTimer(0),
-		syncVotes: make(chan vote, sys.SnowballK),
+	ledger.cacheCollapse = NewLRU(16)
+	ledger.cacheChunks = NewLRU(1024) // In total, it will take up 1024 * 4MB.
 
-		cacheCollapse: NewLRU(16),
-		cacheChunks:   NewLRU(1024), // In total, it will take up 1024 * 4MB.
+	ledger.rejections = NewLRU(4096)
+	gossiper.OnRejection(ledger.recordRejection)
 
-		sendQuotaTokenBucket: make(chan struct{}, 2000),
+	ledger.statuses = NewLRU(65536)
+
+	ledger.sendQuotaTokenBucket = make(chan struct{}, 2000)
+
+	if ledger.standby != nil {
+		ledger.spawn(func() {
+			ledger.Replicate(ledger.upstream)
+			close(ledger.standby.stopped)
+		})
+	} else if len(ledger.upstream) > 0 {
+		ledger.spawn(func() { ledger.Replicate(ledger.upstream) })
+	} else {
+		ledger.PerformConsensus()
+		ledger.spawn(ledger.SyncToLatestRound)
 	}
 
-	go ledger.SyncToLatestRound()
-	go ledger.PerformConsensus()
-	go ledger.FeedSendTokenIntoBucket()
+	ledger.spawn(ledger.FeedSendTokenIntoBucket)
+	ledger.spawn(ledger.RecordMetricsHistory)
+	ledger.spawn(ledger.PruneHistory)
+	ledger.spawn(ledger.KeepPeersAlive)
 
 	return ledger
 }
 
+// spawn runs fn in a new goroutine as a worker of the ledger, which Stop waits
+// to exit.
+func (l *Ledger) spawn(fn func()) {
+	l.workers.Add(1)
+
+	go func() {
+		defer l.workers.Done()
+		fn()
+	}()
+}
+
+// Stop stops all workers of the ledger, such as those performing consensus,
+// syncing or replicating, and waits for them to exit. The store the ledger is
+// persisted in is left open. The ledger may not be used after it is stopped.
+func (l *Ledger) Stop() {
+	l.stop()
+	l.workers.Wait()
+}
+
+// OnRoundFinalized registers fn to be called every time the ledger advances to
+// a new round, be it through finalizing a round by consensus, or adopting a
+// round by syncing with peers or restoring from a backup. The round holds the
+// root transaction it ended at, the number of transactions applied, and the
+// resulting state Merkle root. Rounds adopted by syncing may skip rounds.
+//
+// Hooks are called in order of registration on the goroutine which advanced
+// the ledger, and thus should hand off any long-running work.
+func (l *Ledger) OnRoundFinalized(fn func(round Round)) {
+	l.finalizedHooksLock.Lock()
+	l.finalizedHooks = append(l.finalizedHooks, fn)
+	l.finalizedHooksLock.Unlock()
+}
+
+func (l *Ledger) roundFinalized(round Round) {
+	l.finalizedHooksLock.RLock()
+	hooks := l.finalizedHooks
+	l.finalizedHooksLock.RUnlock()
+
+	for _, fn := range hooks {
+		fn(round)
+	}
+
+	l.advancedLock.Lock()
+	close(l.advanced)
+	l.advanced = make(chan struct{})
+	l.advancedLock.Unlock()
+}
+
+// roundAdvanced returns a channel which is closed the next time the ledger
+// advances to a new round.
+func (l *Ledger) roundAdvanced() <-chan struct{} {
+	l.advancedLock.Lock()
+	defer l.advancedLock.Unlock()
+
+	return l.advanced
+}
+
+// loadGenesis returns the genesis the ledger was configured with. At most one
+// source of genesis may be given.
+func (l *Ledger) loadGenesis(genesis *string) (*Genesis, error) {
+	sources := 0
+
+	if genesis != nil {
+		sources++
+	}
+
+	if l.genesis != nil {
+		sources++
+	}
+
+	if l.genesisPath != "" {
+		sources++
+	}
+
+	if sources > 1 {
+		return nil, errors.New("only one of genesis contents, a parsed genesis, or a genesis path may be specified")
+	}
+
+	if l.genesis != nil {
+		return l.genesis, nil
+	}
+
+	if l.genesisPath != "" {
+		return LoadGenesis(l.genesisPath)
+	}
+
+	return ParseGenesis(genesis)
+}
+
 func (l *Ledger) FeedSendTokenIntoBucket() {
-	for range time.Tick(1 * time.Millisecond) {
+	ticker := time.NewTicker(1 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
 		select {
 		case l.sendQuotaTokenBucket <- struct{}{}:
 		default:
@@ -161,6 +430,8 @@ func (l *Ledger) TakeSendToken() bool {
 // is returned if the transaction has already existed int he ledgers graph
 // beforehand.
 func (l *Ledger) AddTransaction(tx Transaction) error {
+	l.latency.MarkReceived(tx.ID)
+
 	err := l.graph.AddTransaction(tx)
 
 	if err != nil && errors.Cause(err) != ErrAlreadyExists {
@@ -206,15 +477,175 @@ func (l *Ledger) Finalizer() *Snowball {
 	return l.finalizer
 }
 
+// TransactionTimings returns the lifecycle timings recorded for a transaction,
+// should the transaction have been sampled for latency measurements.
+func (l *Ledger) TransactionTimings(id TransactionID) (TransactionTimings, bool) {
+	return l.latency.Timings(id)
+}
+
+// TransactionRejections returns the reasons peers have reported for rejecting
+// a transaction this node gossiped to them.
+func (l *Ledger) TransactionRejections(id TransactionID) []TransactionRejection {
+	rejections, exists := l.rejections.load(id)
+	if !exists {
+		return nil
+	}
+
+	return rejections.([]TransactionRejection)
+}
+
+func (l *Ledger) recordRejection(peer string, rejection *Rejection) {
+	var id TransactionID
+
+	if len(rejection.Id) != len(id) {
+		return
+	}
+
+	copy(id[:], rejection.Id)
+
+	l.rejectionsLock.Lock()
+	defer l.rejectionsLock.Unlock()
+
+	var rejections []TransactionRejection
+
+	if existing, exists := l.rejections.load(id); exists {
+		rejections = existing.([]TransactionRejection)
+	}
+
+	for _, existing := range rejections {
+		if existing.Peer == peer && existing.Reason == rejection.Reason {
+			return
+		}
+	}
+
+	// Copy on append, as the rejections previously stored may be read concurrently.
+	rejections = append(rejections[:len(rejections):len(rejections)], TransactionRejection{
+		Peer:    peer,
+		Reason:  rejection.Reason,
+		Message: rejection.Message,
+	})
+
+	l.rejections.put(id, rejections)
+}
+
+// PeerStats returns the protocol statistics recorded for the peers of the ledger.
+func (l *Ledger) PeerStats() *PeerStats {
+	return l.peers
+}
+
+// PeerDiversity returns the peer diversity requirements the ledger selects
+// peers to query with.
+func (l *Ledger) PeerDiversity() *PeerDiversity {
+	return l.diversity
+}
+
+// SavePeer persists the address of a peer, such that it may be dialed again
+// should the node be restarted.
+func (l *Ledger) SavePeer(address string) error {
+	return StorePeerAddress(l.accounts.kv, address)
+}
+
+// SavedPeers returns the addresses of all peers persisted through SavePeer.
+func (l *Ledger) SavedPeers() ([]string, error) {
+	return LoadPeerAddresses(l.accounts.kv)
+}
+
+// PeerBans returns the peers the ledger has been asked to ban.
+func (l *Ledger) PeerBans() *PeerBans {
+	return l.bans
+}
+
+// BanPeer persists a ban on the peer with the public key id, such that the node
+// refuses to connect to, or accept connections from, the peer from then on. The
+// node is disconnected from the peer should it be connected to it.
+func (l *Ledger) BanPeer(id AccountID) error {
+	if err := StorePeerBan(l.accounts.kv, id); err != nil {
+		return err
+	}
+
+	l.bans.Ban(id)
+	l.DisconnectPeer(id)
+
+	return nil
+}
+
+// UnbanPeer lifts a ban on the peer with the public key id. It returns false if
+// the peer was not banned.
+func (l *Ledger) UnbanPeer(id AccountID) (bool, error) {
+	if err := DeletePeerBan(l.accounts.kv, id); err != nil {
+		return false, err
+	}
+
+	return l.bans.Unban(id), nil
+}
+
+// DisconnectPeer closes the connection of the node to the peer with the public
+// key id. It returns false if the node is not connected to the peer. Peers that
+// are not banned may be connected to again as the node discovers new peers.
+func (l *Ledger) DisconnectPeer(id AccountID) bool {
+	var address string
+
+	for _, peer := range l.client.ClosestPeerIDs() {
+		if peer.PublicKey() == id {
+			address = peer.Address()
+			break
+		}
+	}
+
+	if len(address) == 0 {
+		if stats, exists := l.peers.Snapshot(id); exists {
+			address = stats.Address
+		}
+	}
+
+	if len(address) == 0 {
+		return false
+	}
+
+	for _, conn := range l.client.AllPeers() {
+		if conn.Target() == address {
+			_ = conn.Close()
+			return true
+		}
+	}
+
+	return false
+}
+
 // Rounds returns the round manager for the ledger.
 func (l *Ledger) Rounds() *Rounds {
 	return l.rounds
 }
 
+// Synced returns whether or not the ledger was caught up to the latest round
+// of the network the last time it checked with its peers. The ledger is not
+// synced until it has checked with its peers at least once, nor while it is
+// downloading the latest state from its peers.
+func (l *Ledger) Synced() bool {
+	l.syncedLock.RLock()
+	defer l.syncedLock.RUnlock()
+
+	return l.synced
+}
+
+// TPS returns the exponentially-weighted rate at which transactions have been
+// applied to the ledger over the last minute, in transactions per second.
+func (l *Ledger) TPS() float64 {
+	return l.metrics.acceptedTX.Rate1()
+}
+
+func (l *Ledger) setSynced(synced bool) {
+	l.syncedLock.Lock()
+	l.synced = synced
+	l.syncedLock.Unlock()
+}
+
 // PerformConsensus spawns workers related to performing consensus, such as pulling
 // missing transactions and incrementally finalizing intervals of transactions in
 // the ledgers graph.
 func (l *Ledger) PerformConsensus() {
+	l.consensus.Add(2) // Register workers before spawning them, such that a shutdown may not miss them.
+
 	go l.PullMissingTransactions()
 	go l.FinalizeRounds()
 }
@@ -243,13 +674,13 @@ func (l *Ledger) BroadcastNop() *Transaction {
 
 	balance, _ := ReadAccountBalance(l.accounts.Snapshot(), publicKey)
 
+	nop := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil), l.graph.FindEligibleParents()...)
+
 	// FIXME(kenta): FOR TESTNET ONLY. FAUCET DOES NOT GET ANY PERLs DEDUCTED.
-	if balance < sys.TransactionFeeAmount && hex.EncodeToString(publicKey[:]) != sys.FaucetAddress {
+	if balance < nop.Fee() && hex.EncodeToString(publicKey[:]) != sys.FaucetAddress {
 		return nil
 	}
 
-	nop := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil), l.graph.FindEligibleParents()...)
-
 	if err := l.AddTransaction(nop); err != nil {
 		return nil
 	}
@@ -264,7 +695,6 @@ func (l *Ledger) BroadcastNop() *Transaction {
 // synchronizing/teleporting ahead to a new round, the infinite loop will be cleaned
 // up. It is intended to call PullMissingTransactions() in a new goroutine.
 func (l *Ledger) PullMissingTransactions() {
-	l.consensus.Add(1)
 	defer l.consensus.Done()
 
 	for {
@@ -351,7 +781,6 @@ func (l *Ledger) PullMissingTransactions() {
 // applied to the current ledger state, and the graph is updated to cleanup artifacts from
 // the old round.
 func (l *Ledger) FinalizeRounds() {
-	l.consensus.Add(1)
 	defer l.consensus.Done()
 
 FINALIZE_ROUNDS:
@@ -362,7 +791,7 @@ FINALIZE_ROUNDS:
 		default:
 		}
 
-		if len(l.client.ClosestPeers()) < sys.SnowballK {
+		if len(l.client.ClosestPeers()) < sys.Params().SnowballK {
 			select {
 			case <-l.sync:
 				return
@@ -372,8 +801,10 @@ FINALIZE_ROUNDS:
 			continue FINALIZE_ROUNDS
 		}
 
+		params := sys.Params()
+
 		current := l.rounds.Latest()
-		currentDifficulty := current.ExpectedDifficulty(sys.MinDifficulty, sys.DifficultyScaleFactor)
+		currentDifficulty := current.ExpectedDifficulty(params.MinDifficulty, params.DifficultyScaleFactor)
 
 		if preferred := l.finalizer.Preferred(); preferred == nil {
 			eligible := l.graph.FindEligibleCritical(currentDifficulty)
@@ -411,6 +842,9 @@ FINALIZE_ROUNDS:
 			candidate := NewRound(current.Index+1, results.snapshot.Checksum(), uint64(results.appliedCount), current.End, *eligible)
 			l.finalizer.Prefer(&candidate)
 
+			l.markTransactionsQueried(candidate.Index, results.applied...)
+			l.markTransactionsQueried(candidate.Index, results.rejected...)
+
 			continue FINALIZE_ROUNDS
 		}
 
@@ -423,7 +857,7 @@ FINALIZE_ROUNDS:
 		var workerWG sync.WaitGroup
 		workerWG.Add(cap(workerChan))
 
-		voteChan := make(chan vote, sys.SnowballK)
+		voteChan := make(chan vote, sys.Params().SnowballK)
 		go CollectVotes(l.accounts, l.finalizer, voteChan, &workerWG)
 
 		req := &QueryRequest{RoundIndex: current.Index + 1}
@@ -434,7 +868,10 @@ FINALIZE_ROUNDS:
 					f := func() {
 						client := NewWaveletClient(conn)
 
-						ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+						// Peers which fail to respond in time are left out of the sample
+						// their vote would have been a part of. See voteQuorum.
+
+						ctx, cancel := context.WithTimeout(context.Background(), sys.Params().QueryTimeout)
 
 						p := &peer.Peer{}
 
@@ -469,6 +906,8 @@ FINALIZE_ROUNDS:
 							return
 						}
 
+						l.peers.ObserveRound(voter, round)
+
 						if round.End.Depth <= round.Start.Depth {
 							return
 						}
@@ -543,7 +982,7 @@ FINALIZE_ROUNDS:
 
 			// Randomly sample a peer to query. If no peers are available, stop querying.
 
-			peers, err := SelectPeers(l.client.ClosestPeers(), sys.SnowballK)
+			peers, err := l.diversity.Select(l.client.ClosestPeers(), sys.Params().SnowballK)
 			if err != nil {
 				close(workerChan)
 				workerWG.Wait()
@@ -566,6 +1005,7 @@ FINALIZE_ROUNDS:
 		workerWG.Wait() // Wait for vote processor worker to close.
 
 		finalized := l.finalizer.Preferred()
+		finalizedAt := time.Now()
 		l.finalizer.Reset()
 
 		results, err := l.CollapseTransactions(finalized.Index, finalized.Start, finalized.End, true)
@@ -586,9 +1026,11 @@ FINALIZE_ROUNDS:
 			continue
 		}
 
-		pruned, err := l.rounds.Save(finalized)
+		prev := l.accounts.Snapshot()
+
+		pruned, err := l.commitRound(finalized, results.snapshot)
 		if err != nil {
-			fmt.Printf("Failed to save finalized round to our database: %v\n", err)
+			fmt.Printf("Failed to commit finalized round to our database: %v\n", err)
 		}
 
 		if pruned != nil {
@@ -604,14 +1046,29 @@ FINALIZE_ROUNDS:
 
 		l.graph.UpdateRootDepth(finalized.End.Depth)
 
-		if err = l.accounts.Commit(results.snapshot); err != nil {
-			fmt.Printf("Failed to commit collaped state to our database: %v\n", err)
+		if err = l.storeRoundHistory(prev, results.snapshot, current.Index, finalized.Index); err != nil {
+			fmt.Printf("Failed to store account history to our database: %v\n", err)
+		}
+
+		l.markTransactionsFinalized(finalized.Index, results)
+
+		if err = StoreReceipts(l.accounts.kv, results.receipts); err != nil {
+			fmt.Printf("Failed to store transaction receipts to our database: %v\n", err)
+		}
+
+		appliedAt := time.Now()
+
+		for _, tx := range results.applied {
+			l.latency.MarkFinalized(tx.ID, finalizedAt)
+			l.latency.MarkApplied(tx.ID, appliedAt)
 		}
 
 		l.metrics.acceptedTX.Mark(int64(results.appliedCount))
 
 		l.LogChanges(results.snapshot, current.Index)
 
+		l.roundFinalized(*finalized)
+
 		logger := log.Consensus("round_end")
 		logger.Info().
 			Int("num_applied_tx", results.appliedCount).
@@ -619,8 +1076,8 @@ FINALIZE_ROUNDS:
 			Int("num_ignored_tx", results.ignoredCount).
 			Uint64("old_round", current.Index).
 			Uint64("new_round", finalized.Index).
-			Uint8("old_difficulty", current.ExpectedDifficulty(sys.MinDifficulty, sys.DifficultyScaleFactor)).
-			Uint8("new_difficulty", finalized.ExpectedDifficulty(sys.MinDifficulty, sys.DifficultyScaleFactor)).
+			Uint8("old_difficulty", currentDifficulty).
+			Uint8("new_difficulty", finalized.ExpectedDifficulty(params.MinDifficulty, params.DifficultyScaleFactor)).
 			Hex("new_root", finalized.End.ID[:]).
 			Hex("old_root", current.End.ID[:]).
 			Hex("new_merkle_root", finalized.Merkle[:]).
@@ -637,12 +1094,45 @@ func (l *Ledger) SyncToLatestRound() {
 
 	go CollectVotes(l.accounts, l.syncer, l.syncVotes, voteWG)
 
+	shutdown := func() {
+		close(l.sync)
+		l.consensus.Wait() // Wait for all consensus-related workers to shutdown.
+
+		voteWG.Add(1)
+		close(l.syncVotes)
+		voteWG.Wait() // Wait for the vote processor worker to shutdown.
+
+		l.finalizer.Reset() // Reset consensus Snowball sampler.
+		l.syncer.Reset()    // Reset syncing Snowball sampler.
+	}
+
+	restart := func() { // Respawn all previously stopped workers.
+		l.syncVotes = make(chan vote, sys.Params().SnowballK)
+		go CollectVotes(l.accounts, l.syncer, l.syncVotes, voteWG)
+
+		l.sync = make(chan struct{})
+		l.PerformConsensus()
+	}
+
+	restore := func(req restoreRequest) {
+		shutdown()
+		err := l.applyRestore(req)
+		restart()
+
+		req.result <- err
+	}
+
 	for {
 		for {
-			conns, err := SelectPeers(l.client.ClosestPeers(), sys.SnowballK)
+			conns, err := l.diversity.Select(l.client.ClosestPeers(), sys.Params().SnowballK)
 			if err != nil {
 				select {
+				case <-l.ctx.Done():
+					shutdown()
+					return
 				case <-time.After(1 * time.Second):
+				case req := <-l.restores:
+					restore(req)
 				}
 
 				continue
@@ -650,6 +1140,8 @@ func (l *Ledger) SyncToLatestRound() {
 
 			current := l.rounds.Latest()
 
+			var responded, behind uint32
+
 			var wg sync.WaitGroup
 			wg.Add(len(conns))
 
@@ -693,16 +1185,22 @@ func (l *Ledger) SyncToLatestRound() {
 						return
 					}
 
+					l.peers.ObserveRound(voter, round)
+
 					if round.End.Depth <= round.Start.Depth {
 						wg.Done()
 						return
 					}
 
+					atomic.AddUint32(&responded, 1)
+
 					if round.Index < sys.SyncIfRoundsDifferBy+current.Index {
 						wg.Done()
 						return
 					}
 
+					atomic.AddUint32(&behind, 1)
+
 					l.syncVotes <- vote{voter: voter, preferred: &round}
 
 					wg.Done()
@@ -711,14 +1209,23 @@ func (l *Ledger) SyncToLatestRound() {
 
 			wg.Wait()
 
+			if responded > 0 {
+				l.setSynced(behind == 0)
+			}
+
 			if l.syncer.Decided() {
 				break
 			}
 
-			l.syncTimer.Reset((1500 / (1 + 2*time.Duration(l.syncer.Progress()))) * time.Millisecond)
+			l.syncTimer.Reset(sys.Params().SyncPeriod / (1 + 2*time.Duration(l.syncer.Progress())))
 
 			select {
+			case <-l.ctx.Done():
+				shutdown()
+				return
 			case <-l.syncTimer.C:
+			case req := <-l.restores:
+				restore(req)
 			}
 		}
 
@@ -732,41 +1239,27 @@ func (l *Ledger) SyncToLatestRound() {
 			continue
 		}
 
-		shutdown := func() {
-			close(l.sync)
-			l.consensus.Wait() // Wait for all consensus-related workers to shutdown.
-
-			voteWG.Add(1)
-			close(l.syncVotes)
-			voteWG.Wait() // Wait for the vote processor worker to shutdown.
-
-			l.finalizer.Reset() // Reset consensus Snowball sampler.
-			l.syncer.Reset()    // Reset syncing Snowball sampler.
-		}
-
-		restart := func() { // Respawn all previously stopped workers.
-			l.syncVotes = make(chan vote, sys.SnowballK)
-			go CollectVotes(l.accounts, l.syncer, l.syncVotes, voteWG)
-
-			l.sync = make(chan struct{})
-			go l.PerformConsensus()
-		}
-
 		shutdown() // Shutdown all consensus-related workers.
 
+		l.setSynced(false)
+
 		logger := log.Sync("syncing")
 		logger.Info().
 			Uint64("current_round", current.Index).
 			Uint64("proposed_round", proposed.Index).
 			Msg("Noticed that we are out of sync; downloading latest state Snapshot from our peer(s).")
 
+		logEventSync("sync_start", current, proposed)
+
 	SYNC:
 
-		conns, err := SelectPeers(l.client.ClosestPeers(), sys.SnowballK)
+		conns, err := l.diversity.Select(l.client.ClosestPeers(), sys.Params().SnowballK)
 		if err != nil {
 			logger.Warn().Msg("It looks like there are no peers for us to sync with. Retrying...")
 
 			select {
+			case <-l.ctx.Done():
+				return
 			case <-time.After(1 * time.Second):
 			}
 
@@ -828,34 +1321,36 @@ func (l *Ledger) SyncToLatestRound() {
 			}
 		}
 
-		set := make(map[uint64][]response)
+		rounds := make([]Round, 0, len(responses))
 
-		for _, v := range responses {
-			set[v.latest.Index] = append(set[v.latest.Index], v)
+		for _, res := range responses {
+			rounds = append(rounds, res.latest)
 		}
 
-		// Select a round to sync to which the majority of peers are on.
+		// Select a round to sync to which a quorum of the peers we queried
+		// are on, such that a minority of peers may not mislead us.
 
-		var latest *Round
-		var majority []response
+		selected, ok := selectSyncTarget(rounds, len(conns), sys.SyncQuorum)
 
-		for _, votes := range set {
-			if len(votes) >= len(set)*2/3 {
-				latest = &votes[0].latest
-				majority = votes
-				break
-			}
-		}
+		// If there is no quorum, dispose all streams and try again.
 
-		// If there is no majority or a tie, dispose all streams and try again.
-
-		if majority == nil {
-			logger.Warn().Msg("It looks like our peers could not decide on what the latest round currently is. Retrying...")
+		if !ok {
+			logger.Warn().
+				Int("num_queried", len(conns)).
+				Int("num_responses", len(responses)).
+				Msg("It looks like a quorum of our peers could not agree on what the latest round currently is. Retrying...")
 
 			dispose()
 			goto SYNC
 		}
 
+		latest := &responses[selected[0]].latest
+		majority := make([]response, 0, len(selected))
+
+		for _, i := range selected {
+			majority = append(majority, responses[i])
+		}
+
 		logger.Debug().
 			Uint64("latest_round", latest.Index).
 			Hex("latest_round_root", latest.End.ID[:]).
@@ -1054,10 +1549,9 @@ func (l *Ledger) SyncToLatestRound() {
 			goto SYNC
 		}
 
-		pruned, err := l.rounds.Save(latest)
+		pruned, err := l.commitRound(latest, snapshot)
 		if err != nil {
-			fmt.Printf("Failed to save finalized round to our database: %v\n", err)
-			goto SYNC
+			panic(errors.Wrap(err, "failed to commit synced round to our database"))
 		}
 
 		if pruned != nil {
@@ -1073,57 +1567,69 @@ func (l *Ledger) SyncToLatestRound() {
 
 		l.graph.UpdateRoot(latest.End)
 
-		if err := l.accounts.Commit(snapshot); err != nil {
-			panic(errors.Wrap(err, "failed to commit collapsed state to our database"))
-		}
+		l.restartAccountHistory(latest.Index)
+
+		params := sys.Params()
 
 		logger = log.Sync("apply")
 		logger.Info().
 			Int("num_chunks", len(chunks)).
 			Uint64("old_round", current.Index).
 			Uint64("new_round", latest.Index).
-			Uint8("old_difficulty", current.ExpectedDifficulty(sys.MinDifficulty, sys.DifficultyScaleFactor)).
-			Uint8("new_difficulty", latest.ExpectedDifficulty(sys.MinDifficulty, sys.DifficultyScaleFactor)).
+			Uint8("old_difficulty", current.ExpectedDifficulty(params.MinDifficulty, params.DifficultyScaleFactor)).
+			Uint8("new_difficulty", latest.ExpectedDifficulty(params.MinDifficulty, params.DifficultyScaleFactor)).
 			Hex("new_root", latest.End.ID[:]).
 			Hex("old_root", current.End.ID[:]).
 			Hex("new_merkle_root", latest.Merkle[:]).
 			Hex("old_merkle_root", current.Merkle[:]).
 			Msg("Successfully built a new state Snapshot out of chunk(s) we have received from peers.")
 
+		logEventSync("sync_finish", current, latest)
+
+		l.roundFinalized(*latest)
+
 		restart()
 	}
 }
 
+// selectSyncTarget returns the indices of all rounds which are the same round
+// that at least a quorum fraction of the numQueried peers we queried reported
+// to be their latest round. It returns false if no such round exists.
+func selectSyncTarget(rounds []Round, numQueried int, quorum float64) ([]int, bool) {
+	set := make(map[RoundID][]int)
+
+	for i, round := range rounds {
+		set[round.ID] = append(set[round.ID], i)
+	}
+
+	required := int(math.Ceil(quorum * float64(numQueried)))
+
+	if required < 1 {
+		required = 1
+	}
+
+	for _, votes := range set {
+		if len(votes) >= required {
+			return votes, true
+		}
+	}
+
+	return nil, false
+}
+
 // ApplyTransactionToSnapshot applies a transactions intended changes to a snapshot
 // of the ledgers current state.
 func (l *Ledger) ApplyTransactionToSnapshot(snapshot *avl.Tree, tx *Transaction) error {
+	return l.applyTransactionToSnapshot(snapshot, tx, nil)
+}
+
+func (l *Ledger) applyTransactionToSnapshot(snapshot *avl.Tree, tx *Transaction, receipt *Receipt) error {
 	round := l.Rounds().Latest()
 	original := snapshot.Snapshot()
 
-	switch tx.Tag {
-	case sys.TagNop:
-	case sys.TagTransfer:
-		if _, err := ApplyTransferTransaction(snapshot, round, tx, nil); err != nil {
-			snapshot.Revert(original)
-
-			fmt.Println(err)
-			return errors.Wrap(err, "could not apply transfer transaction")
-		}
-	case sys.TagStake:
-		if _, err := ApplyStakeTransaction(snapshot, round, tx); err != nil {
-			snapshot.Revert(original)
-			return errors.Wrap(err, "could not apply stake transaction")
-		}
-	case sys.TagContract:
-		if _, err := ApplyContractTransaction(snapshot, round, tx, nil); err != nil {
-			snapshot.Revert(original)
-			return errors.Wrap(err, "could not apply contract transaction")
-		}
-	case sys.TagBatch:
-		if _, err := ApplyBatchTransaction(snapshot, round, tx); err != nil {
-			snapshot.Revert(original)
-			return errors.Wrap(err, "could not apply batch transaction")
-		}
+	if _, err := applyTransaction(snapshot, round, tx, nil, receipt); err != nil {
+		snapshot.Revert(original)
+		return errors.Wrapf(err, "could not apply %s transaction", transactionProcessors[tx.Tag].name)
 	}
 
 	return nil
@@ -1137,6 +1643,8 @@ type CollapseResults struct {
 	rejected       []*Transaction
 	rejectedErrors []error
 
+	receipts []*Receipt
+
 	appliedCount  int
 	rejectedCount int
 	ignoredCount  int
@@ -1218,46 +1726,28 @@ func (l *Ledger) CollapseTransactions(round uint64, root Transaction, end Transa
 	res.applied = make([]*Transaction, 0, order.Len())
 	res.rejected = make([]*Transaction, 0, order.Len())
 	res.rejectedErrors = make([]error, 0, order.Len())
+	res.receipts = make([]*Receipt, 0, order.Len())
 
 	// Apply transactions in reverse order from the end of the round
 	// all the way down to the beginning of the round.
 
-	for order.Len() > 0 {
-		popped := order.PopBack().(*Transaction)
-
-		// Update nonce.
-
-		nonce, exists := ReadAccountNonce(res.snapshot, popped.Creator)
-		if !exists {
-			WriteAccountsLen(res.snapshot, ReadAccountsLen(res.snapshot)+1)
-		}
-		WriteAccountNonce(res.snapshot, popped.Creator, nonce+1)
-
-		// FIXME(kenta): FOR TESTNET ONLY. FAUCET DOES NOT GET ANY PERLs DEDUCTED.
-		if hex.EncodeToString(popped.Creator[:]) != sys.FaucetAddress {
-			if err := l.RewardValidators(res.snapshot, root, popped, logging); err != nil {
-				res.rejected = append(res.rejected, popped)
-				res.rejectedErrors = append(res.rejectedErrors, err)
-				res.rejectedCount += popped.LogicalUnits()
-
-				continue
-			}
-		}
+	txs := make([]*Transaction, 0, order.Len())
 
-		if err := l.ApplyTransactionToSnapshot(res.snapshot, popped); err != nil {
-			res.rejected = append(res.rejected, popped)
-			res.rejectedErrors = append(res.rejectedErrors, err)
-			res.rejectedCount += popped.LogicalUnits()
+	for order.Len() > 0 {
+		txs = append(txs, order.PopBack().(*Transaction))
+	}
 
-			fmt.Println(err)
+	// Speculating on transactions would have validators be logged as rewarded
+	// for transactions which are later re-applied, and so transactions are
+	// only applied in parallel should nothing be logged.
 
-			continue
+	if !logging && sys.ApplyWorkers > 1 && len(txs) > 1 {
+		l.collapseTransactionsInParallel(res, round, root, txs, sys.ApplyWorkers)
+	} else {
+		for _, tx := range txs {
+			receipt, err := l.collapseTransaction(res.snapshot, round, root, tx, logging)
+			res.record(tx, receipt, err)
 		}
-
-		// Update statistics.
-
-		res.applied = append(res.applied, popped)
-		res.appliedCount += popped.LogicalUnits()
 	}
 
 	startDepth, endDepth := root.Depth+1, end.Depth
@@ -1272,11 +1762,77 @@ func (l *Ledger) CollapseTransactions(round uint64, root Transaction, end Transa
 		l.processRewardWithdrawals(round, res.snapshot, logging)
 	}
 
+	// Advance the random beacon only after all transactions in the round have
+	// been applied, such that they only ever see the beacon of the round prior.
+	WriteRandomBeacon(res.snapshot, NextRandomBeacon(ReadRandomBeacon(res.snapshot), end.ID))
+
 	l.cacheCollapse.put(end.ID, res)
 
 	return res, nil
 }
 
+// collapseTransaction updates the nonce of the creator of tx, has the creator
+// pay the fees of tx to a validator, and applies tx to snapshot. It returns the
+// receipt of tx, alongside the error tx was rejected with should it have been.
+func (l *Ledger) collapseTransaction(snapshot *avl.Tree, round uint64, root Transaction, tx *Transaction, logging bool) (*Receipt, error) {
+	// Expired transactions are rejected without their creator paying fees,
+	// or their nonce being updated.
+
+	if tx.Expired(round) {
+		err := errors.Wrapf(ErrTransactionExpired, "tx was valid until round %d, but was finalized in round %d", tx.ValidUntilView, round)
+		return &Receipt{TxID: tx.ID, Round: round, Error: err.Error()}, err
+	}
+
+	// Update nonce.
+
+	nonce, exists := ReadAccountNonce(snapshot, tx.Creator)
+	if !exists {
+		WriteAccountsLen(snapshot, ReadAccountsLen(snapshot)+1)
+	}
+	WriteAccountNonce(snapshot, tx.Creator, nonce+1)
+
+	// FIXME(kenta): FOR TESTNET ONLY. FAUCET DOES NOT GET ANY PERLs DEDUCTED.
+	if hex.EncodeToString(tx.Creator[:]) != sys.FaucetAddress {
+		var err error
+
+		if sys.Params().BurnTransactionFees {
+			err = BurnTransactionFee(snapshot, tx, logging)
+		} else {
+			err = l.RewardValidators(snapshot, root, tx, logging)
+		}
+
+		if err != nil {
+			return &Receipt{TxID: tx.ID, Round: round, Error: err.Error()}, err
+		}
+	}
+
+	receipt := &Receipt{TxID: tx.ID, Round: round}
+
+	if err := l.applyTransactionToSnapshot(snapshot, tx, receipt); err != nil {
+		return &Receipt{TxID: tx.ID, Round: round, Error: err.Error()}, err
+	}
+
+	return receipt, nil
+}
+
+// record records tx as applied, or as rejected with err should err not be nil.
+func (r *CollapseResults) record(tx *Transaction, receipt *Receipt, err error) {
+	r.receipts = append(r.receipts, receipt)
+
+	if err != nil {
+		fmt.Println(err)
+
+		r.rejected = append(r.rejected, tx)
+		r.rejectedErrors = append(r.rejectedErrors, err)
+		r.rejectedCount += tx.LogicalUnits()
+
+		return
+	}
+
+	r.applied = append(r.applied, tx)
+	r.appliedCount += tx.LogicalUnits()
+}
+
 // LogChanges logs all changes made to an AVL tree state snapshot for the purposes
 // of logging out changes to account state to Wavelet's HTTP API.
 func (l *Ledger) LogChanges(snapshot *avl.Tree, lastRound uint64) {
@@ -1284,11 +1840,13 @@ func (l *Ledger) LogChanges(snapshot *avl.Tree, lastRound uint64) {
 	stakeLogger := log.Accounts("stake_updated")
 	rewardLogger := log.Accounts("reward_updated")
 	numPagesLogger := log.Accounts("num_pages_updated")
+	eventLogger := log.ContractEvents("emitted")
 
 	balanceKey := append(keyAccounts[:], keyAccountBalance[:]...)
 	stakeKey := append(keyAccounts[:], keyAccountStake[:]...)
 	rewardKey := append(keyAccounts[:], keyAccountReward[:]...)
 	numPagesKey := append(keyAccounts[:], keyAccountContractNumPages[:]...)
+	eventKey := append(keyAccounts[:], keyAccountContractEvents[:]...)
 
 	var id AccountID
 
@@ -1322,6 +1880,21 @@ func (l *Ledger) LogChanges(snapshot *avl.Tree, lastRound uint64) {
 				Hex("account_id", id[:]).
 				Uint64("num_pages", binary.LittleEndian.Uint64(value)).
 				Msg("")
+		case bytes.HasPrefix(key, eventKey) && len(key) == len(eventKey)+8+SizeTransactionID:
+			event, err := UnmarshalContractEvent(value)
+			if err != nil {
+				break
+			}
+
+			copy(id[:], key[len(eventKey)+8:])
+
+			eventLogger.Log().
+				Hex("contract_id", id[:]).
+				Uint64("index", binary.LittleEndian.Uint64(key[len(eventKey):len(eventKey)+8])).
+				Hex("tx_id", event.TxID[:]).
+				Str("topic", event.Topic).
+				Hex("payload", event.Payload).
+				Msg("")
 		}
 
 		return true
@@ -1335,12 +1908,30 @@ func (l *Ledger) processRewardWithdrawals(round uint64, snapshot *avl.Tree, logg
 
 	for _, rw := range rws {
 		balance, _ := ReadAccountBalance(snapshot, rw.account)
-		WriteAccountBalance(snapshot, rw.account, balance+rw.amount)
+
+		newBalance, err := Amount(balance).Add(Amount(rw.amount))
+		if err != nil {
+			// Withdrawn rewards are already deducted, so rather than crediting
+			// the withdrawal only in part, leave it pending to be credited in a
+			// later round.
+			if logging {
+				logger := log.Stake("withdraw_reward")
+				logger.Warn().
+					Err(err).
+					Hex("account_id", rw.account[:]).
+					Uint64("amount", rw.amount).
+					Msg("Skipped a reward withdrawal which would overflow the balance of its account.")
+			}
+
+			continue
+		}
+
+		WriteAccountBalance(snapshot, rw.account, uint64(newBalance))
 
 		if logging {
 			balanceLogger.Log().
 				Hex("account_id", rw.account[:]).
-				Uint64("balance", balance+rw.amount).
+				Uint64("balance", uint64(newBalance)).
 				Msg("")
 		}
 
@@ -1348,11 +1939,44 @@ func (l *Ledger) processRewardWithdrawals(round uint64, snapshot *avl.Tree, logg
 	}
 }
 
+// BurnTransactionFee deducts the fee of tx from its creator without crediting
+// it to anyone, removing it from circulation.
+func BurnTransactionFee(snapshot *avl.Tree, tx *Transaction, logging bool) error {
+	creatorBalance, _ := ReadAccountBalance(snapshot, tx.Creator)
+
+	fee := Amount(tx.Fee())
+
+	newCreatorBalance, err := Amount(creatorBalance).Sub(fee)
+	if err != nil {
+		return errors.Wrapf(err, "fee: creator %x does not have enough PERLs to pay transaction fees (requested %d PERLs)", tx.Creator, fee)
+	}
+
+	WriteAccountBalance(snapshot, tx.Creator, uint64(newCreatorBalance))
+
+	if logging {
+		logger := log.Accounts("balance_updated")
+		logger.Log().
+			Hex("account_id", tx.Creator[:]).
+			Uint64("balance", uint64(newCreatorBalance)).
+			Msg("")
+
+		logger = log.Stake("burn_fee")
+		logger.Info().
+			Hex("creator", tx.Creator[:]).
+			Hex("creator_tx_id", tx.ID[:]).
+			Uint64("fee", uint64(fee)).
+			Msg("Burned transaction fee.")
+	}
+
+	return nil
+}
+
 func (l *Ledger) RewardValidators(snapshot *avl.Tree, root Transaction, tx *Transaction, logging bool) error {
 	var candidates []*Transaction
 	var stakes []uint64
 	var totalStake uint64
 
+	params := sys.Params()
 	visited := make(map[TransactionID]struct{})
 
 	queue := AcquireQueue()
@@ -1382,7 +2006,7 @@ func (l *Ledger) RewardValidators(snapshot *avl.Tree, root Transaction, tx *Tran
 		// If we exceed the max eligible depth we search for candidate
 		// validators to reward from, stop traversing.
 
-		if depthCounter >= sys.MaxDepthDiff {
+		if depthCounter >= params.MaxDepthDiff {
 			break
 		}
 
@@ -1392,7 +2016,7 @@ func (l *Ledger) RewardValidators(snapshot *avl.Tree, root Transaction, tx *Tran
 		if popped.Sender != tx.Sender {
 			stake, _ := ReadAccountStake(snapshot, popped.Sender)
 
-			if stake > sys.MinimumStake {
+			if stake > params.MinimumStake {
 				candidates = append(candidates, popped)
 				stakes = append(stakes, stake)
 
@@ -1447,29 +2071,42 @@ func (l *Ledger) RewardValidators(snapshot *avl.Tree, root Transaction, tx *Tran
 	}
 
 	creatorBalance, _ := ReadAccountBalance(snapshot, tx.Creator)
-	rewardBalance, _ := ReadAccountReward(snapshot, rewardee.Sender)
 
-	fee := sys.TransactionFeeAmount
+	fee := Amount(tx.Fee())
+
+	newCreatorBalance, err := Amount(creatorBalance).Sub(fee)
+	if err != nil {
+		return errors.Wrapf(err, "stake: creator %x does not have enough PERLs to pay transaction fees (requested %d PERLs) to %x", tx.Creator, fee, rewardee.Sender)
+	}
+
+	// The reward of the validator is credited without being read, such that
+	// transactions rewarding the same validator may be applied in parallel.
+	// It is only read should it not be able to be credited, as the transaction
+	// is then rejected based on it.
+
+	rewardBalance, _ := PeekAccountReward(snapshot, rewardee.Sender)
 
-	if creatorBalance < fee {
-		return errors.Errorf("stake: creator %x does not have enough PERLs to pay transaction fees (requested %d PERLs) to %x", tx.Creator, fee, rewardee.Sender)
+	newRewardBalance, err := Amount(rewardBalance).Add(fee)
+	if err != nil {
+		ReadAccountReward(snapshot, rewardee.Sender)
+		return errors.Wrapf(err, "stake: validator %x cannot be rewarded any further transaction fees", rewardee.Sender)
 	}
 
-	WriteAccountBalance(snapshot, tx.Creator, creatorBalance-fee)
+	WriteAccountBalance(snapshot, tx.Creator, uint64(newCreatorBalance))
 	if logging {
 		logger := log.Accounts("balance_updated")
 		logger.Log().
 			Hex("account_id", tx.Creator[:]).
-			Uint64("balance", creatorBalance-fee).
+			Uint64("balance", uint64(newCreatorBalance)).
 			Msg("")
 	}
 
-	WriteAccountReward(snapshot, rewardee.Sender, rewardBalance+fee)
+	CreditAccountReward(snapshot, rewardee.Sender, uint64(fee))
 	if logging {
 		logger := log.Accounts("reward_updated")
 		logger.Log().
 			Hex("account_id", rewardee.Sender[:]).
-			Uint64("reward", rewardBalance+fee).
+			Uint64("reward", uint64(newRewardBalance)).
 			Msg("")
 	}
 
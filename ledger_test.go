@@ -0,0 +1,179 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/perlin-network/wavelet/log"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fastjson"
+	"io/ioutil"
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSelectSyncTarget(t *testing.T) {
+	a := NewRound(10, MerkleNodeID{1}, 0, Transaction{}, Transaction{})
+	b := NewRound(10, MerkleNodeID{2}, 0, Transaction{}, Transaction{})
+
+	// Rounds of the same index but differing contents are not counted together.
+
+	_, ok := selectSyncTarget([]Round{a, b, a, b}, 4, 2.0/3.0)
+	assert.False(t, ok)
+
+	selected, ok := selectSyncTarget([]Round{a, b, a, a}, 4, 2.0/3.0)
+	assert.True(t, ok)
+	assert.Equal(t, []int{0, 2, 3}, selected)
+
+	// Peers which were queried but did not respond count against the quorum,
+	// such that a single responding peer may not dictate our sync target.
+
+	_, ok = selectSyncTarget([]Round{a}, 3, 2.0/3.0)
+	assert.False(t, ok)
+
+	selected, ok = selectSyncTarget([]Round{a}, 1, 2.0/3.0)
+	assert.True(t, ok)
+	assert.Equal(t, []int{0}, selected)
+
+	_, ok = selectSyncTarget(nil, 0, 2.0/3.0)
+	assert.False(t, ok)
+}
+
+type lockedBuffer struct {
+	sync.Mutex
+	bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.Buffer.Write(p)
+}
+
+func TestLedgerStop(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	ledger := NewLedger(store.NewInmem(), skademlia.NewClient(":0", keys), nil)
+
+	stopped := make(chan struct{})
+
+	go func() {
+		ledger.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the workers of the ledger to exit")
+	}
+
+	select {
+	case <-ledger.sync:
+	default:
+		t.Fatal("consensus workers should have been shut down")
+	}
+}
+
+func TestAddTransactionLogsEvents(t *testing.T) {
+	var buf lockedBuffer
+
+	log.SetWriter("test_tx_events", &buf)
+	defer log.SetWriter("test_tx_events", ioutil.Discard)
+
+	ledger := newTestLedger(t)
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	tx := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil), ledger.Graph().FindEligibleParents()...)
+	assert.NoError(t, ledger.AddTransaction(tx))
+
+	buf.Lock()
+	defer buf.Unlock()
+
+	var events []string
+
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		v, err := fastjson.ParseBytes(line)
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		if string(v.GetStringBytes(log.KeyModule)) == log.ModuleTX && string(v.GetStringBytes("tx_id")) == hex.EncodeToString(tx.ID[:]) {
+			events = append(events, string(v.GetStringBytes(log.KeyEvent)))
+		}
+	}
+
+	assert.Equal(t, []string{"received", "accepted"}, events)
+}
+
+func TestBurnTransactionFee(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	snapshot := avl.New(store.NewInmem())
+	tx := NewTransaction(keys, sys.TagNop, nil)
+
+	WriteAccountBalance(snapshot, keys.PublicKey(), tx.Fee()-1)
+	assert.Error(t, BurnTransactionFee(snapshot, &tx, false))
+
+	WriteAccountBalance(snapshot, keys.PublicKey(), tx.Fee()+1)
+	assert.NoError(t, BurnTransactionFee(snapshot, &tx, false))
+
+	balance, _ := ReadAccountBalance(snapshot, keys.PublicKey())
+	assert.EqualValues(t, 1, balance)
+}
+
+func TestProcessRewardWithdrawalsSkipsOverflow(t *testing.T) {
+	snapshot := avl.New(store.NewInmem())
+
+	var a, b AccountID
+	a[0], b[0] = 1, 2
+
+	WriteAccountBalance(snapshot, a, math.MaxUint64-10)
+	WriteAccountBalance(snapshot, b, 10)
+
+	overflowing := RewardWithdrawalRequest{account: a, amount: 11, round: 1}
+	StoreRewardWithdrawalRequest(snapshot, overflowing)
+	StoreRewardWithdrawalRequest(snapshot, RewardWithdrawalRequest{account: b, amount: 11, round: 1})
+
+	ledger := &Ledger{}
+	ledger.processRewardWithdrawals(1+uint64(sys.RewardWithdrawalsRoundLimit), snapshot, false)
+
+	// The overflowing withdrawal is left pending, without the balance of its
+	// account being touched.
+	balance, _ := ReadAccountBalance(snapshot, a)
+	assert.EqualValues(t, uint64(math.MaxUint64-10), balance)
+
+	balance, _ = ReadAccountBalance(snapshot, b)
+	assert.EqualValues(t, 21, balance)
+
+	assert.Equal(t, []RewardWithdrawalRequest{overflowing}, GetRewardWithdrawalRequests(snapshot, 1))
+}
@@ -0,0 +1,232 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"bytes"
+	"context"
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet/log"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/pkg/errors"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNoTrackedRound = errors.New("light client has yet to track a round")
+	ErrNoProvingPeer  = errors.New("no peer proved the state against the tracked round")
+)
+
+// LightClient follows the latest round a quorum of its peers agree upon
+// without storing the state of the ledger, and has peers prove the state of
+// accounts against the Merkle root of the round on demand. Proofs are
+// requested through the same SyncState RPC peers serve proofs of state with
+// to nodes syncing.
+//
+// A light client trusts a quorum of its peers to report the round the network
+// has finalized, as nodes syncing do. It does not trust any single peer for
+// the state of an account.
+type LightClient struct {
+	client *skademlia.Client
+
+	roundLock sync.RWMutex
+	round     *Round
+}
+
+func NewLightClient(client *skademlia.Client) *LightClient {
+	return &LightClient{client: client}
+}
+
+// Round returns the latest round tracked by the light client, and false should
+// it have yet to track any.
+func (c *LightClient) Round() (Round, bool) {
+	c.roundLock.RLock()
+	defer c.roundLock.RUnlock()
+
+	if c.round == nil {
+		return Round{}, false
+	}
+
+	return *c.round, true
+}
+
+// Track updates the round tracked by the light client every sync period until
+// ctx is done.
+func (c *LightClient) Track(ctx context.Context) {
+	logger := log.Sync("light")
+
+	for {
+		previous, _ := c.Round()
+
+		round, err := c.Update(ctx)
+		if err != nil {
+			logger.Debug().Err(err).Msg("Failed to update the round tracked.")
+		} else if round.ID != previous.ID {
+			logger.Info().
+				Uint64("round", round.Index).
+				Hex("round_id", round.ID[:]).
+				Hex("merkle_root", round.Merkle[:]).
+				Msg("Tracking a new round.")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(sys.Params().SyncPeriod):
+		}
+	}
+}
+
+// Update queries up to K of the closest peers of the light client for their
+// latest round, and tracks the round at least sys.SyncQuorum of the
+// peers queried agree upon should it be newer than the round tracked. It
+// returns the round tracked thereafter.
+func (c *LightClient) Update(ctx context.Context) (Round, error) {
+	return c.update(ctx, c.peers(sys.Params().SnowballK))
+}
+
+func (c *LightClient) update(ctx context.Context, peers []WaveletClient) (Round, error) {
+	if len(peers) == 0 {
+		return Round{}, errors.New("no peers to query for their latest round")
+	}
+
+	rounds := make([]*Round, len(peers))
+
+	var wg sync.WaitGroup
+	wg.Add(len(peers))
+
+	for i, peer := range peers {
+		go func(i int, peer WaveletClient) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, sys.Params().QueryTimeout)
+			defer cancel()
+
+			res, err := peer.CheckOutOfSync(ctx, &OutOfSyncRequest{})
+			if err != nil {
+				return
+			}
+
+			round, err := UnmarshalRound(bytes.NewReader(res.Round))
+			if err != nil {
+				return
+			}
+
+			rounds[i] = &round
+		}(i, peer)
+	}
+
+	wg.Wait()
+
+	responded := make([]Round, 0, len(rounds))
+
+	for _, round := range rounds {
+		if round != nil {
+			responded = append(responded, *round)
+		}
+	}
+
+	votes, ok := selectSyncTarget(responded, len(peers), sys.SyncQuorum)
+	if !ok {
+		return Round{}, errors.Errorf("no quorum of the %d peers queried agree upon their latest round", len(peers))
+	}
+
+	target := responded[votes[0]]
+
+	c.roundLock.Lock()
+	defer c.roundLock.Unlock()
+
+	if c.round == nil || target.Index > c.round.Index {
+		c.round = &target
+	}
+
+	return *c.round, nil
+}
+
+// Account has the closest peers of the light client prove the nonce, balance,
+// stake and reward of the account id against the round tracked. Peers are
+// asked one after another until one proves the account against the round.
+func (c *LightClient) Account(ctx context.Context, id AccountID) (ProvenAccount, error) {
+	return c.account(ctx, c.peers(len(c.client.ClosestPeers())), id)
+}
+
+func (c *LightClient) account(ctx context.Context, peers []WaveletClient, id AccountID) (ProvenAccount, error) {
+	tracked, ok := c.Round()
+	if !ok {
+		return ProvenAccount{}, ErrNoTrackedRound
+	}
+
+	keys := [][]byte{AccountNonceKey(id), AccountBalanceKey(id), AccountStakeKey(id), AccountRewardKey(id)}
+
+	for _, peer := range peers {
+		account, err := proveAccount(ctx, peer, tracked, keys)
+		if err != nil {
+			continue
+		}
+
+		return account, nil
+	}
+
+	return ProvenAccount{}, errors.Wrapf(ErrNoProvingPeer, "failed to prove account %x against round %d", id, tracked.Index)
+}
+
+func proveAccount(ctx context.Context, peer WaveletClient, tracked Round, keys [][]byte) (ProvenAccount, error) {
+	ctx, cancel := context.WithTimeout(ctx, sys.Params().QueryTimeout)
+	defer cancel()
+
+	round, values, err := QueryState(ctx, peer, keys...)
+	if err != nil {
+		return ProvenAccount{}, err
+	}
+
+	if round.ID != tracked.ID {
+		return ProvenAccount{}, errors.Errorf("peer proved state against round %d, but round %d is tracked", round.Index, tracked.Index)
+	}
+
+	if len(values) != len(keys) {
+		return ProvenAccount{}, errors.Errorf("peer proved %d of %d values", len(values), len(keys))
+	}
+
+	var account ProvenAccount
+
+	for i, field := range []*uint64{&account.Nonce, &account.Balance, &account.Stake, &account.Reward} {
+		if *field, err = decodeProvenValue(values[i].Value, values[i].Exists); err != nil {
+			return ProvenAccount{}, err
+		}
+	}
+
+	return account, nil
+}
+
+func (c *LightClient) peers(n int) []WaveletClient {
+	conns := c.client.ClosestPeers()
+	if len(conns) > n {
+		conns = conns[:n]
+	}
+
+	peers := make([]WaveletClient, 0, len(conns))
+
+	for _, conn := range conns {
+		peers = append(peers, NewWaveletClient(conn))
+	}
+
+	return peers
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"testing"
+)
+
+// lightTestPeer serves the RPCs a light client makes straight from the
+// protocol of a ledger.
+type lightTestPeer struct {
+	WaveletClient
+	protocol *Protocol
+}
+
+func (p lightTestPeer) CheckOutOfSync(ctx context.Context, in *OutOfSyncRequest, _ ...grpc.CallOption) (*OutOfSyncResponse, error) {
+	return p.protocol.CheckOutOfSync(ctx, in)
+}
+
+func (p lightTestPeer) SyncState(ctx context.Context, in *SyncStateRequest, _ ...grpc.CallOption) (*SyncStateResponse, error) {
+	return p.protocol.SyncState(ctx, in)
+}
+
+func TestLightClient(t *testing.T) {
+	var account AccountID
+	account[0] = 1
+
+	advance := func(l *Ledger) {
+		snapshot := l.Snapshot()
+		snapshot.SetViewID(1)
+		WriteAccountBalance(snapshot, account, 1337)
+		WriteAccountStake(snapshot, account, 42)
+
+		latest := l.Rounds().Latest()
+		round := NewRound(1, snapshot.Checksum(), 0, latest.End, latest.End)
+
+		_, err := l.rounds.Save(&round)
+		assert.NoError(t, err)
+		assert.NoError(t, l.accounts.Commit(snapshot))
+	}
+
+	ahead, behind := newTestLedger(t), newTestLedger(t)
+	advance(ahead)
+
+	a := lightTestPeer{protocol: ahead.Protocol()}
+	b := lightTestPeer{protocol: ahead.Protocol()}
+	c := lightTestPeer{protocol: behind.Protocol()}
+
+	client := new(LightClient)
+
+	_, err := client.account(context.Background(), []WaveletClient{a}, account)
+	assert.Equal(t, ErrNoTrackedRound, err)
+
+	// Rounds which are not agreed upon by a quorum of peers are not tracked.
+	_, err = client.update(context.Background(), []WaveletClient{a, c})
+	assert.Error(t, err)
+
+	_, tracked := client.Round()
+	assert.False(t, tracked)
+
+	round, err := client.update(context.Background(), []WaveletClient{a, b, c})
+	assert.NoError(t, err)
+	assert.Equal(t, ahead.Rounds().Latest().ID, round.ID)
+
+	// Accounts are only proven against the round tracked.
+	proven, err := client.account(context.Background(), []WaveletClient{c, a}, account)
+	assert.NoError(t, err)
+	assert.Equal(t, ProvenAccount{Balance: 1337, Stake: 42}, proven)
+
+	_, err = client.account(context.Background(), []WaveletClient{c}, account)
+	assert.Equal(t, ErrNoProvingPeer, errors.Cause(err))
+
+	// Older rounds are never tracked in place of the round tracked.
+	round, err = client.update(context.Background(), []WaveletClient{c})
+	assert.NoError(t, err)
+	assert.Equal(t, ahead.Rounds().Latest().ID, round.ID)
+}
@@ -48,3 +48,16 @@ func logEventTX(event string, tx *Transaction, other ...interface{}) {
 
 	log.Msg("")
 }
+
+func logEventSync(event string, current *Round, target *Round) {
+	logger := log.Consensus(event)
+	logger.Log().
+		Uint64("old_round", current.Index).
+		Uint64("new_round", target.Index).
+		Hex("old_round_id", current.ID[:]).
+		Hex("new_round_id", target.ID[:]).
+		Hex("old_root", current.End.ID[:]).
+		Hex("new_root", target.End.ID[:]).
+		Hex("new_merkle_root", target.Merkle[:]).
+		Msg("")
+}
@@ -30,15 +30,17 @@ var (
 	}
 	logger = zerolog.New(output).With().Timestamp().Logger()
 
-	node      zerolog.Logger
-	network   zerolog.Logger
-	accounts  zerolog.Logger
-	consensus zerolog.Logger
-	contract  zerolog.Logger
-	syncer    zerolog.Logger
-	stake     zerolog.Logger
-	tx        zerolog.Logger
-	metrics   zerolog.Logger
+	node           zerolog.Logger
+	network        zerolog.Logger
+	accounts       zerolog.Logger
+	consensus      zerolog.Logger
+	contract       zerolog.Logger
+	contractEvents zerolog.Logger
+	syncer         zerolog.Logger
+	stake          zerolog.Logger
+	tx             zerolog.Logger
+	metrics        zerolog.Logger
+	api            zerolog.Logger
 )
 
 const (
@@ -48,15 +50,17 @@ const (
 	KeyModule = "mod"
 	KeyEvent  = "event"
 
-	ModuleNode      = "node"
-	ModuleNetwork   = "network"
-	ModuleAccounts  = "accounts"
-	ModuleConsensus = "consensus"
-	ModuleContract  = "contract"
-	ModuleSync      = "sync"
-	ModuleStake     = "stake"
-	ModuleTX        = "tx"
-	ModuleMetrics   = "metrics"
+	ModuleNode           = "node"
+	ModuleNetwork        = "network"
+	ModuleAccounts       = "accounts"
+	ModuleConsensus      = "consensus"
+	ModuleContract       = "contract"
+	ModuleContractEvents = "contract_events"
+	ModuleSync           = "sync"
+	ModuleStake          = "stake"
+	ModuleTX             = "tx"
+	ModuleMetrics        = "metrics"
+	ModuleAPI            = "api"
 )
 
 func init() {
@@ -69,10 +73,12 @@ func setupChildLoggers() {
 	accounts = logger.With().Str(KeyModule, ModuleAccounts).Logger()
 	consensus = logger.With().Str(KeyModule, ModuleConsensus).Logger()
 	contract = logger.With().Str(KeyModule, ModuleContract).Logger()
+	contractEvents = logger.With().Str(KeyModule, ModuleContractEvents).Logger()
 	syncer = logger.With().Str(KeyModule, ModuleSync).Logger()
 	stake = logger.With().Str(KeyModule, ModuleStake).Logger()
 	tx = logger.With().Str(KeyModule, ModuleTX).Logger()
 	metrics = logger.With().Str(KeyModule, ModuleMetrics).Logger()
+	api = logger.With().Str(KeyModule, ModuleAPI).Logger()
 }
 
 func SetWriter(key string, writer io.Writer) {
@@ -95,6 +101,10 @@ func Contracts(event string) zerolog.Logger {
 	return contract.With().Str(KeyEvent, event).Logger()
 }
 
+func ContractEvents(event string) zerolog.Logger {
+	return contractEvents.With().Str(KeyEvent, event).Logger()
+}
+
 func TX(event string) zerolog.Logger {
 	return tx.With().Str(KeyEvent, event).Logger()
 }
@@ -114,3 +124,7 @@ func Sync(event string) zerolog.Logger {
 func Metrics() zerolog.Logger {
 	return metrics
 }
+
+func API(event string) zerolog.Logger {
+	return api.With().Str(KeyEvent, event).Logger()
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Package memo encrypts and decrypts private notes attached to transfers.
+//
+// A memo is encrypted client-side to the public key of the recipient of a
+// transfer, and is opaque to consensus: nodes store and gossip it as part of
+// the payload of the transfer, but are unable to read it unless they hold the
+// private key of the recipient.
+//
+// To encrypt a memo, an ephemeral Ed25519 keypair is generated, and an ECDH
+// shared secret is computed between the ephemeral private key and the public
+// key of the recipient. The shared secret is expanded via HKDF-SHA256 into a
+// key for ChaCha20-Poly1305. The encrypted memo comprises the ephemeral public
+// key followed by the ciphertext.
+package memo
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"github.com/perlin-network/noise/edwards25519"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/poly1305"
+	"io"
+)
+
+// Overhead is the number of bytes an encrypted memo takes up on top of the
+// memo itself.
+const Overhead = edwards25519.SizePublicKey + poly1305.TagSize
+
+var info = []byte("wavelet transfer memo")
+
+// Encrypt encrypts memo such that it may only be decrypted by the holder of
+// the private key of recipient.
+func Encrypt(recipient edwards25519.PublicKey, memo []byte) ([]byte, error) {
+	ephemeralPublicKey, ephemeralPrivateKey, err := edwards25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "memo: failed to generate ephemeral keypair")
+	}
+
+	aead, err := deriveCipher(ephemeralPrivateKey, recipient, ephemeralPublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// A zero nonce is safe to use, as every key is derived from a freshly
+	// generated ephemeral keypair and is only ever used to seal one memo.
+	var nonce [chacha20poly1305.NonceSize]byte
+
+	return aead.Seal(ephemeralPublicKey[:], nonce[:], memo, nil), nil
+}
+
+// Decrypt decrypts a memo which was encrypted to the public key belonging to
+// privateKey.
+func Decrypt(privateKey edwards25519.PrivateKey, encrypted []byte) ([]byte, error) {
+	if len(encrypted) < Overhead {
+		return nil, errors.Errorf("memo: encrypted memo must be at least %d bytes, but got %d bytes", Overhead, len(encrypted))
+	}
+
+	var ephemeralPublicKey edwards25519.PublicKey
+	copy(ephemeralPublicKey[:], encrypted[:edwards25519.SizePublicKey])
+
+	aead, err := deriveCipher(privateKey, ephemeralPublicKey, ephemeralPublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var nonce [chacha20poly1305.NonceSize]byte
+
+	memo, err := aead.Open(nil, nonce[:], encrypted[edwards25519.SizePublicKey:], nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "memo: failed to decrypt memo")
+	}
+
+	return memo, nil
+}
+
+// deriveCipher computes the ECDH shared secret between privateKey and
+// publicKey, and expands it into a ChaCha20-Poly1305 cipher. The ephemeral
+// public key of the memo is mixed in as salt.
+func deriveCipher(privateKey edwards25519.PrivateKey, publicKey edwards25519.PublicKey, ephemeralPublicKey edwards25519.PublicKey) (cipher.AEAD, error) {
+	var point edwards25519.ExtendedGroupElement
+
+	if !point.FromBytes((*[edwards25519.SizePublicKey]byte)(&publicKey)) {
+		return nil, errors.New("memo: public key is not a valid point")
+	}
+
+	digest := sha512.Sum512(privateKey[:32])
+	digest[0] &= 248
+	digest[31] &= 127
+	digest[31] |= 64
+
+	var secretKey, shared [32]byte
+	copy(secretKey[:], digest[:32])
+
+	var sharedPoint edwards25519.ExtendedGroupElement
+	edwards25519.GeScalarMult(&sharedPoint, &secretKey, &point)
+	sharedPoint.ToBytes(&shared)
+
+	key := make([]byte, chacha20poly1305.KeySize)
+
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared[:], ephemeralPublicKey[:], info), key); err != nil {
+		return nil, errors.Wrap(err, "memo: failed to derive key")
+	}
+
+	return chacha20poly1305.New(key)
+}
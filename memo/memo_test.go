@@ -0,0 +1,69 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package memo
+
+import (
+	"crypto/rand"
+	"github.com/perlin-network/noise/edwards25519"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	publicKey, privateKey, err := edwards25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	_, otherPrivateKey, err := edwards25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	encrypted, err := Encrypt(publicKey, []byte("for the pizza"))
+	assert.NoError(t, err)
+	assert.Len(t, encrypted, len("for the pizza")+Overhead)
+	assert.NotContains(t, string(encrypted), "pizza")
+
+	decrypted, err := Decrypt(privateKey, encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("for the pizza"), decrypted)
+
+	// Memos encrypted twice to the same recipient must not be alike.
+	again, err := Encrypt(publicKey, []byte("for the pizza"))
+	assert.NoError(t, err)
+	assert.NotEqual(t, encrypted, again)
+
+	// Only the recipient may decrypt a memo.
+	_, err = Decrypt(otherPrivateKey, encrypted)
+	assert.Error(t, err)
+
+	// Tampered memos must be rejected.
+	encrypted[len(encrypted)-1] ^= 1
+	_, err = Decrypt(privateKey, encrypted)
+	assert.Error(t, err)
+
+	_, err = Decrypt(privateKey, encrypted[:Overhead-1])
+	assert.Error(t, err)
+
+	// Empty memos are allowed.
+	encrypted, err = Encrypt(publicKey, nil)
+	assert.NoError(t, err)
+
+	decrypted, err = Decrypt(privateKey, encrypted)
+	assert.NoError(t, err)
+	assert.Empty(t, decrypted)
+}
@@ -37,6 +37,10 @@ type Metrics struct {
 	downloadedTX metrics.Meter
 
 	queryLatency metrics.Timer
+
+	acceptLatency   metrics.Timer
+	finalizeLatency metrics.Timer
+	applyLatency    metrics.Timer
 }
 
 func NewMetrics(ctx context.Context) *Metrics {
@@ -51,6 +55,10 @@ func NewMetrics(ctx context.Context) *Metrics {
 
 	queryLatency := metrics.NewRegisteredTimer("query.latency", registry)
 
+	acceptLatency := metrics.NewRegisteredTimer("tx.latency.accepted", registry)
+	finalizeLatency := metrics.NewRegisteredTimer("tx.latency.finalized", registry)
+	applyLatency := metrics.NewRegisteredTimer("tx.latency.applied", registry)
+
 	go func() {
 		logger := log.Metrics()
 
@@ -71,6 +79,12 @@ func NewMetrics(ctx context.Context) *Metrics {
 					Int64("query.latency.max.ms", queryLatency.Max()/(1.0e+7)).
 					Int64("query.latency.min.ms", queryLatency.Min()/(1.0e+7)).
 					Float64("query.latency.mean.ms", queryLatency.Mean()/(1.0e+7)).
+					Float64("tx.latency.accepted.p50.ms", acceptLatency.Percentile(0.5)/(1.0e+6)).
+					Float64("tx.latency.accepted.p99.ms", acceptLatency.Percentile(0.99)/(1.0e+6)).
+					Float64("tx.latency.finalized.p50.ms", finalizeLatency.Percentile(0.5)/(1.0e+6)).
+					Float64("tx.latency.finalized.p99.ms", finalizeLatency.Percentile(0.99)/(1.0e+6)).
+					Float64("tx.latency.applied.p50.ms", applyLatency.Percentile(0.5)/(1.0e+6)).
+					Float64("tx.latency.applied.p99.ms", applyLatency.Percentile(0.99)/(1.0e+6)).
 					Msg("Updated metrics.")
 			case <-ctx.Done():
 				return
@@ -89,6 +103,10 @@ func NewMetrics(ctx context.Context) *Metrics {
 		downloadedTX: downloadedTX,
 
 		queryLatency: queryLatency,
+
+		acceptLatency:   acceptLatency,
+		finalizeLatency: finalizeLatency,
+		applyLatency:    applyLatency,
 	}
 }
 
@@ -101,4 +119,8 @@ func (m *Metrics) Stop() {
 	m.downloadedTX.Stop()
 
 	m.queryLatency.Stop()
+
+	m.acceptLatency.Stop()
+	m.finalizeLatency.Stop()
+	m.applyLatency.Stop()
 }
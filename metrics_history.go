@@ -0,0 +1,124 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"encoding/binary"
+	"github.com/perlin-network/wavelet/log"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// SizeMetricsSnapshot is the size of a marshaled metrics snapshot in bytes.
+const SizeMetricsSnapshot = 8 + 8 + 8 + 4 + 4 + 8
+
+// MetricsSnapshot is a compact record of the key metrics of a node at a point
+// in time. Snapshots are persisted periodically, such that what a node went
+// through may be reconstructed after the fact, even across crashes.
+type MetricsSnapshot struct {
+	Seq  uint64 // Sequence number of the snapshot, counting up from zero.
+	Time time.Time
+
+	RoundIndex  uint64 // Index of the latest round of the ledger.
+	NumPeers    uint32
+	MempoolSize uint32 // Number of transactions pending finalization.
+
+	// Median time taken for sampled transactions to be finalized since being
+	// received. See LatencyTracker.
+	FinalityLatency time.Duration
+}
+
+func (s MetricsSnapshot) Marshal() []byte {
+	var buf [SizeMetricsSnapshot]byte
+
+	binary.BigEndian.PutUint64(buf[0:8], s.Seq)
+	binary.BigEndian.PutUint64(buf[8:16], uint64(s.Time.UnixNano()))
+	binary.BigEndian.PutUint64(buf[16:24], s.RoundIndex)
+	binary.BigEndian.PutUint32(buf[24:28], s.NumPeers)
+	binary.BigEndian.PutUint32(buf[28:32], s.MempoolSize)
+	binary.BigEndian.PutUint64(buf[32:40], uint64(s.FinalityLatency))
+
+	return buf[:]
+}
+
+func UnmarshalMetricsSnapshot(buf []byte) (MetricsSnapshot, error) {
+	var s MetricsSnapshot
+
+	if len(buf) != SizeMetricsSnapshot {
+		return s, errors.Errorf("metrics snapshot must be %d bytes, but got %d bytes", SizeMetricsSnapshot, len(buf))
+	}
+
+	s.Seq = binary.BigEndian.Uint64(buf[0:8])
+	s.Time = time.Unix(0, int64(binary.BigEndian.Uint64(buf[8:16])))
+	s.RoundIndex = binary.BigEndian.Uint64(buf[16:24])
+	s.NumPeers = binary.BigEndian.Uint32(buf[24:28])
+	s.MempoolSize = binary.BigEndian.Uint32(buf[28:32])
+	s.FinalityLatency = time.Duration(binary.BigEndian.Uint64(buf[32:40]))
+
+	return s, nil
+}
+
+// MetricsHistory returns the metrics snapshots persisted by the ledger, ordered
+// from oldest to newest. At most sys.MetricsHistorySize snapshots are kept.
+func (l *Ledger) MetricsHistory() ([]MetricsSnapshot, error) {
+	return LoadMetricsHistory(l.accounts.kv)
+}
+
+// RecordMetricsHistory persists a snapshot of the key metrics of the ledger
+// every sys.MetricsHistoryInterval. Numbering of snapshots carries on from the
+// latest snapshot persisted, should the node have been restarted.
+func (l *Ledger) RecordMetricsHistory() {
+	var seq uint64
+
+	history, err := l.MetricsHistory()
+	if err == nil && len(history) > 0 {
+		seq = history[len(history)-1].Seq + 1
+	}
+
+	for {
+		select {
+		case <-l.ctx.Done():
+			return
+		case <-time.After(sys.MetricsHistoryInterval):
+		}
+
+		if err := StoreMetricsSnapshot(l.accounts.kv, l.snapshotMetrics(seq, time.Now()), sys.MetricsHistorySize); err != nil {
+			logger := log.Metrics()
+			logger.Warn().Err(err).Msg("Failed to persist a snapshot of our metrics.")
+			continue
+		}
+
+		seq++
+	}
+}
+
+func (l *Ledger) snapshotMetrics(seq uint64, now time.Time) MetricsSnapshot {
+	return MetricsSnapshot{
+		Seq:  seq,
+		Time: now,
+
+		RoundIndex:  l.rounds.Latest().Index,
+		NumPeers:    uint32(len(l.client.ClosestPeerIDs())),
+		MempoolSize: uint32(l.graph.PendingLen()),
+
+		FinalityLatency: time.Duration(l.metrics.finalizeLatency.Percentile(0.5)),
+	}
+}
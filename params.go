@@ -0,0 +1,165 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/pkg/errors"
+	"github.com/valyala/fastjson"
+	"sort"
+	"time"
+)
+
+// Bounds of the parameters which may be tuned while a node is running.
+const (
+	MaxTunableSnowballK = 64
+
+	MinTunableQueryTimeout = 100 * time.Millisecond
+	MaxTunableQueryTimeout = 1 * time.Minute
+
+	MinTunableSyncPeriod = 100 * time.Millisecond
+	MaxTunableSyncPeriod = 1 * time.Minute
+)
+
+// tunableParams maps the names of parameters which may be tuned while a node
+// is running to functions which validate a value for the parameter, and return
+// a function which applies it. Durations are given in milliseconds.
+var tunableParams = map[string]func(v *fastjson.Value) (func(p *sys.ConsensusParams), error){
+	"snowball.k": func(v *fastjson.Value) (func(p *sys.ConsensusParams), error) {
+		k, err := positiveInt(v)
+		if err != nil {
+			return nil, err
+		}
+
+		if k > MaxTunableSnowballK {
+			return nil, errors.Errorf("must be at most %d, but got %d", MaxTunableSnowballK, k)
+		}
+
+		return func(p *sys.ConsensusParams) { p.SnowballK = k }, nil
+	},
+	"snowball.alpha": func(v *fastjson.Value) (func(p *sys.ConsensusParams), error) {
+		alpha, err := snowballAlpha(v)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(p *sys.ConsensusParams) { p.SnowballAlpha = alpha }, nil
+	},
+	"query.timeout": func(v *fastjson.Value) (func(p *sys.ConsensusParams), error) {
+		timeout, err := durationInRange(v, MinTunableQueryTimeout, MaxTunableQueryTimeout)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(p *sys.ConsensusParams) { p.QueryTimeout = timeout }, nil
+	},
+	"sync.period": func(v *fastjson.Value) (func(p *sys.ConsensusParams), error) {
+		period, err := durationInRange(v, MinTunableSyncPeriod, MaxTunableSyncPeriod)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(p *sys.ConsensusParams) { p.SyncPeriod = period }, nil
+	},
+}
+
+// TuneParams sets the parameters named in the JSON object v to the values
+// they are mapped to while the node is running. Either all parameters are
+// applied, or none are should any of them be unknown or out of range.
+// Changes take effect from the next consensus or sync round onwards.
+func TuneParams(v *fastjson.Value) error {
+	obj, err := v.Object()
+	if err != nil {
+		return err
+	}
+
+	var (
+		names   []string
+		applies = make(map[string]func(p *sys.ConsensusParams))
+	)
+
+	obj.Visit(func(key []byte, v *fastjson.Value) {
+		if err != nil {
+			return
+		}
+
+		parse, exists := tunableParams[string(key)]
+		if !exists {
+			err = errors.Errorf("unknown parameter %q", key)
+			return
+		}
+
+		if _, exists := applies[string(key)]; exists {
+			err = errors.Errorf("found duplicate entries for parameter %q", key)
+			return
+		}
+
+		apply, perr := parse(v)
+		if perr != nil {
+			err = errors.Wrapf(perr, "invalid value for parameter %q", key)
+			return
+		}
+
+		names = append(names, string(key))
+		applies[string(key)] = apply
+	})
+
+	if err != nil {
+		return err
+	}
+
+	sort.Strings(names)
+
+	sys.UpdateParams(func(p *sys.ConsensusParams) {
+		for _, name := range names {
+			applies[name](p)
+		}
+	})
+
+	return nil
+}
+
+// TunableParams returns the current values of all parameters which may be
+// tuned while the node is running as a JSON object.
+func TunableParams(arena *fastjson.Arena) *fastjson.Value {
+	params := sys.Params()
+
+	o := arena.NewObject()
+
+	o.Set("snowball.k", arena.NewNumberInt(params.SnowballK))
+	o.Set("snowball.alpha", arena.NewNumberFloat64(params.SnowballAlpha))
+	o.Set("query.timeout", arena.NewNumberInt(int(params.QueryTimeout/time.Millisecond)))
+	o.Set("sync.period", arena.NewNumberInt(int(params.SyncPeriod/time.Millisecond)))
+
+	return o
+}
+
+func durationInRange(v *fastjson.Value, min, max time.Duration) (time.Duration, error) {
+	ms, err := v.Int64()
+	if err != nil {
+		return 0, err
+	}
+
+	if lo, hi := int64(min/time.Millisecond), int64(max/time.Millisecond); ms < lo || ms > hi {
+		return 0, errors.Errorf("must be in the range [%d, %d] milliseconds, but got %d", lo, hi, ms)
+	}
+
+	return time.Duration(ms) * time.Millisecond, nil
+}
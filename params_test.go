@@ -0,0 +1,65 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fastjson"
+	"testing"
+	"time"
+)
+
+func TestTuneParams(t *testing.T) {
+	defer func(params sys.ConsensusParams) {
+		sys.UpdateParams(func(p *sys.ConsensusParams) { *p = params })
+	}(sys.Params())
+
+	k := sys.Params().SnowballK
+
+	invalid := []string{
+		`[]`,
+		`{"snowball.beta": 10}`,
+		`{"snowball.k": 0}`,
+		`{"snowball.k": 65}`,
+		`{"snowball.alpha": 1.5}`,
+		`{"query.timeout": 50}`,
+		`{"sync.period": 3600000}`,
+		`{"snowball.k": 10, "snowball.k": 12}`,
+		`{"snowball.k": 10, "query.timeout": "1s"}`,
+	}
+
+	for _, params := range invalid {
+		assert.Error(t, TuneParams(fastjson.MustParse(params)), params)
+	}
+
+	// Params are not partially applied should any of them be invalid.
+	assert.Equal(t, k, sys.Params().SnowballK)
+
+	assert.NoError(t, TuneParams(fastjson.MustParse(`{"snowball.k": 10, "snowball.alpha": 0.5, "query.timeout": 250, "sync.period": 3000}`)))
+
+	assert.Equal(t, 10, sys.Params().SnowballK)
+	assert.Equal(t, 0.5, sys.Params().SnowballAlpha)
+	assert.Equal(t, 250*time.Millisecond, sys.Params().QueryTimeout)
+	assert.Equal(t, 3*time.Second, sys.Params().SyncPeriod)
+
+	var arena fastjson.Arena
+	assert.Equal(t, `{"snowball.k":10,"snowball.alpha":0.5,"query.timeout":250,"sync.period":3000}`, TunableParams(&arena).String())
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"bytes"
+	"github.com/perlin-network/noise"
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"net"
+	"sort"
+	"sync"
+)
+
+// PeerBans is the set of peers, identified by their public keys, which a node
+// refuses to connect to or accept connections from.
+//
+// PeerBans is a noise protocol, which is to be placed after the S/Kademlia
+// protocol in the transport credentials of the node, such that the IDs of
+// peers are known by the time their connections are checked against it.
+type PeerBans struct {
+	sync.RWMutex
+	banned map[AccountID]struct{}
+}
+
+func NewPeerBans() *PeerBans {
+	return &PeerBans{banned: make(map[AccountID]struct{})}
+}
+
+// Ban has connections to and from the peer with the public key id be refused.
+func (b *PeerBans) Ban(id AccountID) {
+	b.Lock()
+	b.banned[id] = struct{}{}
+	b.Unlock()
+}
+
+// Unban lifts the ban on the peer with the public key id. It returns false if
+// the peer was not banned.
+func (b *PeerBans) Unban(id AccountID) bool {
+	b.Lock()
+	defer b.Unlock()
+
+	if _, banned := b.banned[id]; !banned {
+		return false
+	}
+
+	delete(b.banned, id)
+
+	return true
+}
+
+// IsBanned returns whether or not the peer with the public key id is banned.
+func (b *PeerBans) IsBanned(id AccountID) bool {
+	b.RLock()
+	_, banned := b.banned[id]
+	b.RUnlock()
+
+	return banned
+}
+
+// List returns the public keys of all banned peers in ascending order.
+func (b *PeerBans) List() []AccountID {
+	b.RLock()
+
+	ids := make([]AccountID, 0, len(b.banned))
+	for id := range b.banned {
+		ids = append(ids, id)
+	}
+
+	b.RUnlock()
+
+	sort.Slice(ids, func(i, j int) bool {
+		return bytes.Compare(ids[i][:], ids[j][:]) < 0
+	})
+
+	return ids
+}
+
+func (b *PeerBans) Client(info noise.Info, ctx context.Context, authority string, conn net.Conn) (net.Conn, error) {
+	return b.check(info, conn)
+}
+
+func (b *PeerBans) Server(info noise.Info, conn net.Conn) (net.Conn, error) {
+	return b.check(info, conn)
+}
+
+func (b *PeerBans) check(info noise.Info, conn net.Conn) (net.Conn, error) {
+	id, ok := info.Get(skademlia.KeyID).(*skademlia.ID)
+	if !ok {
+		return conn, nil
+	}
+
+	if publicKey := id.PublicKey(); b.IsBanned(publicKey) {
+		err := errors.Errorf("peer %x is banned", publicKey)
+		if cerr := conn.Close(); cerr != nil {
+			err = errors.Wrap(cerr, err.Error())
+		}
+		return nil, err
+	}
+
+	return conn, nil
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"math"
+	"math/rand"
+	"net"
+	"sync"
+)
+
+// PeerDiversity selects peers to query for consensus and syncing such that an
+// attacker who controls many addresses can't monopolize this node's view of
+// the network.
+//
+// At most sys.MaxPeersPerSubnet peers are selected from any single /24 IPv4
+// or /48 IPv6 subnet, and at least a sys.MinOutboundPeerFraction fraction of
+// selected peers must be outbound peers, which are peers this node was
+// explicitly instructed to dial rather than peers it learned of through the
+// network. Loopback and private addresses are exempt from subnet limits.
+type PeerDiversity struct {
+	sync.RWMutex
+
+	outbound map[string]struct{}
+}
+
+func NewPeerDiversity() *PeerDiversity {
+	return &PeerDiversity{outbound: make(map[string]struct{})}
+}
+
+// MarkOutbound marks the peer located at address as an outbound peer.
+func (d *PeerDiversity) MarkOutbound(address string) {
+	d.Lock()
+	d.outbound[address] = struct{}{}
+	d.Unlock()
+}
+
+// IsOutbound returns true if the peer located at address is an outbound peer.
+func (d *PeerDiversity) IsOutbound(address string) bool {
+	d.RLock()
+	_, exists := d.outbound[address]
+	d.RUnlock()
+
+	return exists
+}
+
+// Select randomly selects amount peers out of peers which satisfy the
+// diversity requirements, or returns an error if not enough peers do.
+func (d *PeerDiversity) Select(peers []*grpc.ClientConn, amount int) ([]*grpc.ClientConn, error) {
+	var outbound, inbound []*grpc.ClientConn
+
+	for _, peer := range peers {
+		if d.IsOutbound(peer.Target()) {
+			outbound = append(outbound, peer)
+		} else {
+			inbound = append(inbound, peer)
+		}
+	}
+
+	rand.Shuffle(len(outbound), func(i, j int) {
+		outbound[i], outbound[j] = outbound[j], outbound[i]
+	})
+
+	rand.Shuffle(len(inbound), func(i, j int) {
+		inbound[i], inbound[j] = inbound[j], inbound[i]
+	})
+
+	required := int(math.Ceil(sys.MinOutboundPeerFraction * float64(amount)))
+
+	selected := make([]*grpc.ClientConn, 0, amount)
+	chosen := make(map[*grpc.ClientConn]struct{}, amount)
+	subnets := make(map[string]int)
+
+	pick := func(candidates []*grpc.ClientConn, limit int) {
+		for _, peer := range candidates {
+			if len(selected) >= limit {
+				return
+			}
+
+			if _, exists := chosen[peer]; exists {
+				continue
+			}
+
+			subnet, limited := peerSubnet(peer.Target())
+
+			if limited && sys.MaxPeersPerSubnet > 0 && subnets[subnet] >= sys.MaxPeersPerSubnet {
+				continue
+			}
+
+			subnets[subnet]++
+			chosen[peer] = struct{}{}
+			selected = append(selected, peer)
+		}
+	}
+
+	// Fill the required share of outbound peers first, and then the rest
+	// from all peers.
+
+	pick(outbound, required)
+
+	if len(selected) < required {
+		return selected, errors.Errorf("only %d diverse outbound peer(s) are available, but require a minimum of %d", len(selected), required)
+	}
+
+	pick(append(outbound, inbound...), amount)
+
+	if len(selected) < amount {
+		return selected, errors.Errorf("only %d diverse peer(s) are available, but require a minimum of %d peer(s)", len(selected), amount)
+	}
+
+	return selected, nil
+}
+
+// peerSubnet returns the subnet the peer located at address belongs to, and
+// whether or not the number of peers selected from it is to be limited.
+// Addresses which are not IP addresses are treated as their own subnet.
+func peerSubnet(address string) (string, bool) {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+
+	ip := net.ParseIP(host)
+
+	if ip == nil {
+		return host, true
+	}
+
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() {
+		return ip.String(), false
+	}
+
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.Mask(net.CIDRMask(24, 32)).String(), true
+	}
+
+	return ip.Mask(net.CIDRMask(48, 128)).String(), true
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"testing"
+)
+
+func TestPeerSubnet(t *testing.T) {
+	subnet, limited := peerSubnet("1.2.3.4:3000")
+	assert.True(t, limited)
+	assert.Equal(t, "1.2.3.0", subnet)
+
+	subnet, limited = peerSubnet("[2001:db8:1:2::1]:3000")
+	assert.True(t, limited)
+	assert.Equal(t, "2001:db8:1::", subnet)
+
+	_, limited = peerSubnet("127.0.0.1:3000")
+	assert.False(t, limited)
+
+	_, limited = peerSubnet("10.0.0.1:3000")
+	assert.False(t, limited)
+
+	subnet, limited = peerSubnet("example.com:3000")
+	assert.True(t, limited)
+	assert.Equal(t, "example.com", subnet)
+}
+
+func TestPeerDiversitySelect(t *testing.T) {
+	maxPerSubnet, minOutbound := sys.MaxPeersPerSubnet, sys.MinOutboundPeerFraction
+	defer func() { sys.MaxPeersPerSubnet, sys.MinOutboundPeerFraction = maxPerSubnet, minOutbound }()
+
+	var conns []*grpc.ClientConn
+
+	for _, addr := range []string{"1.2.3.4:3000", "1.2.3.5:3000", "1.2.3.6:3000", "5.6.7.8:3000"} {
+		conn, err := grpc.Dial(addr, grpc.WithInsecure())
+		assert.NoError(t, err)
+		defer conn.Close()
+
+		conns = append(conns, conn)
+	}
+
+	d := NewPeerDiversity()
+
+	sys.MaxPeersPerSubnet = 1
+	sys.MinOutboundPeerFraction = 0
+
+	// Only two subnets are available.
+
+	selected, err := d.Select(conns, 2)
+	assert.NoError(t, err)
+	assert.Len(t, selected, 2)
+	assert.NotEqual(t, selected[0].Target()[:6], selected[1].Target()[:6])
+
+	_, err = d.Select(conns, 3)
+	assert.Error(t, err)
+
+	sys.MaxPeersPerSubnet = 0
+
+	selected, err = d.Select(conns, 4)
+	assert.NoError(t, err)
+	assert.Len(t, selected, 4)
+
+	// Half of the selected peers must be outbound.
+
+	sys.MinOutboundPeerFraction = 0.5
+
+	_, err = d.Select(conns, 2)
+	assert.Error(t, err)
+
+	d.MarkOutbound("5.6.7.8:3000")
+
+	for i := 0; i < 10; i++ {
+		selected, err = d.Select(conns, 2)
+		assert.NoError(t, err)
+		assert.Contains(t, selected, conns[3])
+	}
+}
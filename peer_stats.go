@@ -0,0 +1,497 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"context"
+	"github.com/perlin-network/noise"
+	"github.com/perlin-network/noise/skademlia"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PeerOpcodeStats holds counters for all messages exchanged with a single peer
+// under a single RPC method, from the perspective of this node.
+type PeerOpcodeStats struct {
+	Calls  uint64
+	Errors uint64
+
+	// Calls which the peer failed to respond to before their deadline.
+	Timeouts uint64
+
+	MessagesSent     uint64
+	MessagesReceived uint64
+
+	BytesSent     uint64
+	BytesReceived uint64
+}
+
+// PeerRoot is the latest round a peer has reported to this node.
+type PeerRoot struct {
+	Index  uint64
+	ID     RoundID
+	Merkle MerkleNodeID
+	SeenAt time.Time
+}
+
+// PeerStatsSnapshot is a point-in-time copy of all statistics recorded for a
+// single peer.
+type PeerStatsSnapshot struct {
+	Address  string
+	LastSeen time.Time
+
+	// Smoothed round-trip time of calls this node has made to the peer.
+	Latency time.Duration
+
+	// Round-trip time of the latest ping the peer responded to, and when it
+	// responded. See KeepPeersAlive.
+	PingRTT  time.Duration
+	LastPong time.Time
+
+	// Estimate of how far the clock of the peer is ahead of ours, as of the
+	// latest ping it responded to. Nil should the peer not report its time.
+	ClockOffset *time.Duration
+
+	Root    *PeerRoot
+	Opcodes map[string]PeerOpcodeStats
+}
+
+type peerStats struct {
+	address  string
+	lastSeen time.Time
+	latency  time.Duration
+
+	pingRTT     time.Duration
+	lastPong    time.Time
+	clockOffset *time.Duration
+
+	root    *PeerRoot
+	opcodes map[string]*PeerOpcodeStats
+
+	// Time statistics were last recorded for the peer, after which it is
+	// considered to be idle. See Prune.
+	active time.Time
+}
+
+// PeerStats records per-peer, per-RPC method protocol statistics. Outgoing
+// calls are tracked through gRPC client interceptors, and incoming calls
+// through a gRPC stats handler. Statistics of peers are kept until they are
+// unregistered, or until they are pruned away for having gone idle.
+type PeerStats struct {
+	sync.RWMutex
+
+	peers     map[AccountID]*peerStats
+	addresses map[string]AccountID
+}
+
+func NewPeerStats() *PeerStats {
+	return &PeerStats{
+		peers:     make(map[AccountID]*peerStats),
+		addresses: make(map[string]AccountID),
+	}
+}
+
+// Register associates the address of a peer with its ID, such that calls made
+// over connections dialed to said address may be attributed to it.
+func (s *PeerStats) Register(id *skademlia.ID) {
+	s.Lock()
+	s.register(id)
+	s.Unlock()
+}
+
+// Unregister evicts all statistics recorded for a peer, such as once it has
+// disconnected from us.
+func (s *PeerStats) Unregister(id *skademlia.ID) {
+	s.Lock()
+	defer s.Unlock()
+
+	publicKey := id.PublicKey()
+
+	stats, exists := s.peers[publicKey]
+	if !exists {
+		return
+	}
+
+	delete(s.peers, publicKey)
+
+	if s.addresses[stats.address] == publicKey {
+		delete(s.addresses, stats.address)
+	}
+}
+
+// Prune evicts all statistics recorded for peers which no statistics have
+// been recorded for since idle ago, and returns the number of peers evicted.
+func (s *PeerStats) Prune(idle time.Duration) int {
+	s.Lock()
+	defer s.Unlock()
+
+	cutoff := time.Now().Add(-idle)
+	pruned := 0
+
+	for publicKey, stats := range s.peers {
+		if !stats.active.Before(cutoff) {
+			continue
+		}
+
+		delete(s.peers, publicKey)
+
+		if s.addresses[stats.address] == publicKey {
+			delete(s.addresses, stats.address)
+		}
+
+		pruned++
+	}
+
+	return pruned
+}
+
+// ObserveRound records the latest round reported to us by a peer.
+func (s *PeerStats) ObserveRound(id *skademlia.ID, round Round) {
+	s.Lock()
+	defer s.Unlock()
+
+	stats := s.register(id)
+	stats.root = &PeerRoot{Index: round.Index, ID: round.ID, Merkle: round.Merkle, SeenAt: time.Now()}
+}
+
+// Snapshot returns a copy of the statistics recorded for a peer, or false if
+// no statistics have yet been recorded for it.
+func (s *PeerStats) Snapshot(id AccountID) (PeerStatsSnapshot, bool) {
+	s.RLock()
+	defer s.RUnlock()
+
+	stats, exists := s.peers[id]
+	if !exists {
+		return PeerStatsSnapshot{}, false
+	}
+
+	snapshot := PeerStatsSnapshot{
+		Address:     stats.address,
+		LastSeen:    stats.lastSeen,
+		Latency:     stats.latency,
+		PingRTT:     stats.pingRTT,
+		LastPong:    stats.lastPong,
+		ClockOffset: stats.clockOffset,
+		Opcodes:     make(map[string]PeerOpcodeStats, len(stats.opcodes)),
+	}
+
+	if stats.root != nil {
+		root := *stats.root
+		snapshot.Root = &root
+	}
+
+	for opcode, counters := range stats.opcodes {
+		snapshot.Opcodes[opcode] = *counters
+	}
+
+	return snapshot, true
+}
+
+func (s *PeerStats) register(id *skademlia.ID) *peerStats {
+	publicKey := id.PublicKey()
+
+	stats, exists := s.peers[publicKey]
+	if !exists {
+		stats = &peerStats{opcodes: make(map[string]*PeerOpcodeStats)}
+		s.peers[publicKey] = stats
+	}
+
+	stats.address = id.Address()
+	stats.active = time.Now()
+	s.addresses[id.Address()] = publicKey
+
+	return stats
+}
+
+// update applies fn to the counters of a peer under a given RPC method. Calls
+// made to peers whose IDs are unknown are not recorded.
+func (s *PeerStats) update(id *skademlia.ID, address string, method string, fn func(*PeerOpcodeStats)) {
+	s.Lock()
+	defer s.Unlock()
+
+	var stats *peerStats
+
+	if id != nil {
+		stats = s.register(id)
+	} else if publicKey, exists := s.addresses[address]; exists {
+		stats = s.peers[publicKey]
+	}
+
+	if stats == nil {
+		return
+	}
+
+	opcode := method[strings.LastIndex(method, "/")+1:]
+
+	counters, exists := stats.opcodes[opcode]
+	if !exists {
+		counters = new(PeerOpcodeStats)
+		stats.opcodes[opcode] = counters
+	}
+
+	fn(counters)
+
+	stats.lastSeen = time.Now()
+	stats.active = stats.lastSeen
+}
+
+// observeLatency folds the round-trip time of a call made over a connection
+// dialed to address into the smoothed latency of the peer, the same way TCP
+// smooths its round-trip time estimates.
+func (s *PeerStats) observeLatency(address string, rtt time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+
+	publicKey, exists := s.addresses[address]
+	if !exists {
+		return
+	}
+
+	stats := s.peers[publicKey]
+
+	if stats.latency == 0 {
+		stats.latency = rtt
+	} else {
+		stats.latency += (rtt - stats.latency) / 8
+	}
+}
+
+// observePing records the round-trip time of a ping the peer connected to at
+// address has responded to, and how far its clock is estimated to be ahead of
+// ours should it have reported its time.
+func (s *PeerStats) observePing(address string, rtt time.Duration, offset *time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+
+	publicKey, exists := s.addresses[address]
+	if !exists {
+		return
+	}
+
+	stats := s.peers[publicKey]
+	stats.pingRTT = rtt
+	stats.lastPong = time.Now()
+	stats.clockOffset = offset
+	stats.active = stats.lastPong
+}
+
+// ClockOffset returns the median of how far the clocks of peers are estimated
+// to be ahead of ours, alongside the number of peers the estimate is based
+// on. Only peers which have reported their time in response to a ping are
+// counted.
+func (s *PeerStats) ClockOffset() (time.Duration, int) {
+	s.RLock()
+
+	var offsets []time.Duration
+
+	for _, stats := range s.peers {
+		if stats.clockOffset != nil {
+			offsets = append(offsets, *stats.clockOffset)
+		}
+	}
+
+	s.RUnlock()
+
+	if len(offsets) == 0 {
+		return 0, 0
+	}
+
+	sort.Slice(offsets, func(i, j int) bool {
+		return offsets[i] < offsets[j]
+	})
+
+	return offsets[len(offsets)/2], len(offsets)
+}
+
+func messageSize(m interface{}) uint64 {
+	if sized, ok := m.(interface{ Size() int }); ok {
+		return uint64(sized.Size())
+	}
+
+	return 0
+}
+
+func (s *PeerStats) UnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	rtt := time.Since(start)
+
+	s.update(nil, cc.Target(), method, func(counters *PeerOpcodeStats) {
+		counters.Calls++
+		counters.MessagesSent++
+		counters.BytesSent += messageSize(req)
+
+		if err != nil {
+			counters.Errors++
+
+			if status.Code(err) == codes.DeadlineExceeded {
+				counters.Timeouts++
+			}
+
+			return
+		}
+
+		counters.MessagesReceived++
+		counters.BytesReceived += messageSize(reply)
+	})
+
+	if err == nil {
+		s.observeLatency(cc.Target(), rtt)
+	}
+
+	return err
+}
+
+func (s *PeerStats) StreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+
+	s.update(nil, cc.Target(), method, func(counters *PeerOpcodeStats) {
+		counters.Calls++
+
+		if err != nil {
+			counters.Errors++
+		}
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return peerStatsClientStream{ClientStream: stream, stats: s, address: cc.Target(), method: method}, nil
+}
+
+type peerStatsClientStream struct {
+	grpc.ClientStream
+
+	stats   *PeerStats
+	address string
+	method  string
+}
+
+func (s peerStatsClientStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+
+	s.stats.update(nil, s.address, s.method, func(counters *PeerOpcodeStats) {
+		if err != nil {
+			if err != io.EOF {
+				counters.Errors++
+			}
+			return
+		}
+
+		counters.MessagesSent++
+		counters.BytesSent += messageSize(m)
+	})
+
+	return err
+}
+
+func (s peerStatsClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+
+	s.stats.update(nil, s.address, s.method, func(counters *PeerOpcodeStats) {
+		if err != nil {
+			if err != io.EOF {
+				counters.Errors++
+			}
+			return
+		}
+
+		counters.MessagesReceived++
+		counters.BytesReceived += messageSize(m)
+	})
+
+	return err
+}
+
+type peerStatsMethodKey struct{}
+
+// ServerStatsHandler returns a gRPC stats handler which records statistics
+// for all calls made to this node by its peers.
+func (s *PeerStats) ServerStatsHandler() stats.Handler {
+	return peerStatsServerHandler{stats: s}
+}
+
+type peerStatsServerHandler struct {
+	stats *PeerStats
+}
+
+func (h peerStatsServerHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	return context.WithValue(ctx, peerStatsMethodKey{}, info.FullMethodName)
+}
+
+func (h peerStatsServerHandler) HandleRPC(ctx context.Context, rs stats.RPCStats) {
+	method, ok := ctx.Value(peerStatsMethodKey{}).(string)
+	if !ok {
+		return
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return
+	}
+
+	info := noise.InfoFromPeer(p)
+	if info == nil {
+		return
+	}
+
+	id, ok := info.Get(skademlia.KeyID).(*skademlia.ID)
+	if !ok {
+		return
+	}
+
+	switch rs := rs.(type) {
+	case *stats.Begin:
+		h.stats.update(id, "", method, func(counters *PeerOpcodeStats) {
+			counters.Calls++
+		})
+	case *stats.InPayload:
+		h.stats.update(id, "", method, func(counters *PeerOpcodeStats) {
+			counters.MessagesReceived++
+			counters.BytesReceived += uint64(rs.Length)
+		})
+	case *stats.OutPayload:
+		h.stats.update(id, "", method, func(counters *PeerOpcodeStats) {
+			counters.MessagesSent++
+			counters.BytesSent += uint64(rs.Length)
+		})
+	case *stats.End:
+		if rs.Error != nil {
+			h.stats.update(id, "", method, func(counters *PeerOpcodeStats) {
+				counters.Errors++
+			})
+		}
+	}
+}
+
+func (h peerStatsServerHandler) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (h peerStatsServerHandler) HandleConn(ctx context.Context, cs stats.ConnStats) {}
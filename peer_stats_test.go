@@ -0,0 +1,162 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"context"
+	"fmt"
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/blake2b"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestPeerStats(t *testing.T) {
+	stats := NewPeerStats()
+
+	var publicKey AccountID
+	publicKey[0] = 1
+
+	id := skademlia.NewID("127.0.0.1:3000", publicKey, [blake2b.Size256]byte{})
+
+	conn, err := grpc.Dial(id.Address(), grpc.WithInsecure())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	invoke := func(err error) error {
+		return stats.UnaryClientInterceptor(context.Background(), "/wavelet.Wavelet/Query", &QueryRequest{RoundIndex: 1}, &QueryResponse{}, conn,
+			func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+				return err
+			},
+		)
+	}
+
+	// Calls to peers which have not been registered should not be recorded.
+	assert.NoError(t, invoke(nil))
+
+	_, exists := stats.Snapshot(publicKey)
+	assert.False(t, exists)
+
+	stats.Register(id)
+
+	assert.NoError(t, invoke(nil))
+	assert.Error(t, invoke(errors.New("failed")))
+	assert.Error(t, invoke(status.Error(codes.DeadlineExceeded, "timed out")))
+
+	snapshot, exists := stats.Snapshot(publicKey)
+	assert.True(t, exists)
+	assert.Equal(t, id.Address(), snapshot.Address)
+	assert.Nil(t, snapshot.Root)
+	assert.True(t, snapshot.Latency > 0)
+
+	counters := snapshot.Opcodes["Query"]
+	assert.EqualValues(t, 3, counters.Calls)
+	assert.EqualValues(t, 2, counters.Errors)
+	assert.EqualValues(t, 1, counters.Timeouts)
+	assert.EqualValues(t, 3, counters.MessagesSent)
+	assert.EqualValues(t, 1, counters.MessagesReceived)
+	assert.EqualValues(t, 3*(&QueryRequest{RoundIndex: 1}).Size(), counters.BytesSent)
+
+	round := NewRound(5, MerkleNodeID{1}, 0, Transaction{}, Transaction{})
+	stats.ObserveRound(id, round)
+
+	snapshot, _ = stats.Snapshot(publicKey)
+	if assert.NotNil(t, snapshot.Root) {
+		assert.Equal(t, round.Index, snapshot.Root.Index)
+		assert.Equal(t, round.ID, snapshot.Root.ID)
+		assert.Equal(t, round.Merkle, snapshot.Root.Merkle)
+	}
+}
+
+func TestPeerClockOffset(t *testing.T) {
+	stats := NewPeerStats()
+
+	_, n := stats.ClockOffset()
+	assert.Equal(t, 0, n)
+
+	offsets := []time.Duration{3 * time.Second, -time.Second, time.Second}
+
+	for i, offset := range offsets {
+		var publicKey AccountID
+		publicKey[0] = byte(i + 1)
+
+		id := skademlia.NewID(fmt.Sprintf("127.0.0.1:%d", 3000+i), publicKey, [blake2b.Size256]byte{})
+		stats.Register(id)
+
+		offset := offset
+		stats.observePing(id.Address(), time.Millisecond, &offset)
+	}
+
+	// Peers which do not report their time are not counted.
+	var publicKey AccountID
+	publicKey[0] = 4
+
+	id := skademlia.NewID("127.0.0.1:3003", publicKey, [blake2b.Size256]byte{})
+	stats.Register(id)
+	stats.observePing(id.Address(), time.Millisecond, nil)
+
+	median, n := stats.ClockOffset()
+	assert.Equal(t, 3, n)
+	assert.Equal(t, time.Second, median)
+}
+
+func TestPeerStatsEviction(t *testing.T) {
+	stats := NewPeerStats()
+
+	var a, b AccountID
+	a[0], b[0] = 1, 2
+
+	idA := skademlia.NewID("127.0.0.1:3000", a, [blake2b.Size256]byte{})
+	idB := skademlia.NewID("127.0.0.1:3001", b, [blake2b.Size256]byte{})
+
+	stats.Register(idA)
+	stats.Register(idB)
+
+	// Peers are evicted once they disconnect.
+	stats.Unregister(idA)
+
+	_, exists := stats.Snapshot(a)
+	assert.False(t, exists)
+	assert.NotContains(t, stats.addresses, idA.Address())
+
+	// Peers are only pruned away once they have gone idle.
+	assert.Equal(t, 0, stats.Prune(time.Minute))
+
+	_, exists = stats.Snapshot(b)
+	assert.True(t, exists)
+
+	time.Sleep(10 * time.Millisecond)
+
+	stats.observePing(idB.Address(), time.Millisecond, nil)
+	assert.Equal(t, 0, stats.Prune(5*time.Millisecond))
+
+	time.Sleep(10 * time.Millisecond)
+
+	assert.Equal(t, 1, stats.Prune(5*time.Millisecond))
+
+	_, exists = stats.Snapshot(b)
+	assert.False(t, exists)
+	assert.Empty(t, stats.addresses)
+}
@@ -23,10 +23,12 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"github.com/perlin-network/wavelet/avl"
 	"github.com/perlin-network/wavelet/log"
 	"github.com/perlin-network/wavelet/sys"
 	"github.com/pkg/errors"
 	"golang.org/x/crypto/blake2b"
+	"time"
 )
 
 type Protocol struct {
@@ -41,6 +43,19 @@ func (p *Protocol) Gossip(stream Wavelet_GossipServer) error {
 			return err
 		}
 
+		report := batch.ReportRejections && sys.GossipRejections
+
+		var (
+			snapshot   *avl.Tree
+			round      uint64
+			rejections []*Rejection
+		)
+
+		if report {
+			snapshot = p.ledger.accounts.Snapshot()
+			round = p.ledger.rounds.Latest().Index + 1
+		}
+
 		for _, buf := range batch.Transactions {
 			tx, err := UnmarshalTransaction(bytes.NewReader(buf))
 
@@ -50,9 +65,23 @@ func (p *Protocol) Gossip(stream Wavelet_GossipServer) error {
 				continue
 			}
 
-			if err := p.ledger.AddTransaction(tx); err != nil && errors.Cause(err) != ErrMissingParents {
+			err = p.ledger.AddTransaction(tx)
+
+			if err != nil && errors.Cause(err) != ErrMissingParents {
 				fmt.Printf("error adding incoming tx to graph [%v]: %+v\n", err, tx)
 			}
+
+			if report {
+				if rejection := rejectTransaction(snapshot, round, tx, err); rejection != nil {
+					rejections = append(rejections, rejection)
+				}
+			}
+		}
+
+		if len(rejections) > 0 {
+			if err := stream.Send(&GossipResponse{Rejections: rejections}); err != nil {
+				return err
+			}
 		}
 	}
 }
@@ -145,14 +174,96 @@ func (p *Protocol) CheckOutOfSync(context.Context, *OutOfSyncRequest) (*OutOfSyn
 func (p *Protocol) DownloadTx(ctx context.Context, req *DownloadTxRequest) (*DownloadTxResponse, error) {
 	res := &DownloadTxResponse{Transactions: make([][]byte, 0, len(req.Ids))}
 
+	// Leave some room for the framing of the response.
+	limit := sys.MaxMessageSize - 4096
+	size := 0
+
 	for _, buf := range req.Ids {
 		var id TransactionID
 		copy(id[:], buf)
 
 		if tx := p.ledger.Graph().FindTransaction(id); tx != nil {
-			res.Transactions = append(res.Transactions, tx.Marshal())
+			buf := tx.Marshal()
+
+			// Transactions which do not fit in the response are to be requested for again later.
+			if size+len(buf) > limit {
+				break
+			}
+
+			res.Transactions = append(res.Transactions, buf)
+			size += len(buf)
 		}
 	}
 
 	return res, nil
 }
+
+func (p *Protocol) SyncState(ctx context.Context, req *SyncStateRequest) (*SyncStateResponse, error) {
+	round, snapshot, err := p.ledger.consistentSnapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	res := &SyncStateResponse{Round: round.Marshal(), Entries: make([]*StateEntry, 0, len(req.Keys))}
+
+	// Leave some room for the framing of the response.
+	limit := sys.MaxMessageSize - 4096
+	size := len(res.Round)
+
+	for _, key := range req.Keys {
+		proof, err := snapshot.Prove(key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to prove state under key %x", key)
+		}
+
+		entry := &StateEntry{Key: key, Proof: proof}
+
+		// Entries which do not fit in the response are to be requested for again later.
+		if size+entry.Size() > limit {
+			break
+		}
+
+		res.Entries = append(res.Entries, entry)
+		size += entry.Size()
+	}
+
+	return res, nil
+}
+
+// Replicate streams every round the ledger advances to, alongside the state
+// changes and transactions applied in it, to a read replica whose latest round
+// is at the index requested. The latest round of the ledger is streamed right
+// away, such that the replica may tell whether or not it is caught up.
+func (p *Protocol) Replicate(req *ReplicateRequest, stream Wavelet_ReplicateServer) error {
+	from := req.RoundIndex
+
+	for {
+		// Listen for the ledger advancing before taking a snapshot, such that
+		// rounds advanced to while streaming the snapshot are not missed.
+		advanced := p.ledger.roundAdvanced()
+
+		// The ledger may be in the midst of committing a round, which it
+		// will notify about once it is done.
+		if round, snapshot, err := p.ledger.consistentSnapshot(); err == nil {
+			if err := p.ledger.sendReplicatedRound(stream, from, round, snapshot); err != nil {
+				return err
+			}
+
+			if round.Index > from {
+				from = round.Index
+			}
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-advanced:
+		}
+	}
+}
+
+// Ping echoes the nonce of a ping from a peer checking that this node is still
+// alive. See KeepPeersAlive.
+func (p *Protocol) Ping(ctx context.Context, req *PingRequest) (*PingResponse, error) {
+	return &PingResponse{Nonce: req.Nonce, Time: time.Now().UnixNano()}, nil
+}
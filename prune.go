@@ -0,0 +1,108 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"github.com/perlin-network/wavelet/log"
+	"github.com/perlin-network/wavelet/sys"
+	"time"
+)
+
+// PruneHistory periodically prunes away the changes made to accounts and the
+// receipts of transactions in all but the latest sys.PruneHistoryAfter
+// finalized rounds, reclaiming the disk space they take up on long-running
+// nodes. Nothing is pruned should sys.PruneHistoryAfter be 0, or should the
+// ledger be running as an archive node.
+func (l *Ledger) PruneHistory() {
+	if l.archive {
+		return
+	}
+
+	for {
+		select {
+		case <-l.ctx.Done():
+			return
+		case <-time.After(sys.PruneHistoryInterval):
+		}
+
+		if sys.PruneHistoryAfter == 0 {
+			continue
+		}
+
+		latest := l.rounds.Latest().Index
+
+		deltas, receipts, err := l.pruneHistory(latest, sys.PruneHistoryAfter)
+		if err != nil {
+			logger := log.Node()
+			logger.Warn().Err(err).Uint64("round", latest).Msg("Failed to prune away account history and transaction receipts.")
+			continue
+		}
+
+		if deltas > 0 || receipts > 0 {
+			logger := log.Consensus("prune")
+			logger.Debug().
+				Int("num_account_deltas", deltas).
+				Int("num_receipts", receipts).
+				Uint64("current_round_id", latest).
+				Uint64("kept_rounds", sys.PruneHistoryAfter).
+				Msg("Pruned away account history and transaction receipts.")
+		}
+	}
+}
+
+// pruneHistory prunes away the account history and the receipts of rounds
+// finalized before the latest keep rounds as of the round with index latest.
+// It returns the number of account deltas and receipts pruned away.
+func (l *Ledger) pruneHistory(latest, keep uint64) (int, int, error) {
+	if latest <= keep {
+		return 0, 0, nil
+	}
+
+	round := latest - keep
+
+	l.historyLock.Lock()
+	defer l.historyLock.Unlock()
+
+	if round <= l.historyPruned {
+		return 0, 0, nil
+	}
+
+	// Account history is moved forward before it is pruned, such that the
+	// state of accounts is never resolved against partially pruned history.
+	if from, err := LoadAccountHistoryFrom(l.accounts.kv); err != nil || from < round {
+		if err := StoreAccountHistoryFrom(l.accounts.kv, round); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	deltas, err := PruneAccountHistory(l.accounts.kv, round)
+	if err != nil {
+		return deltas, 0, err
+	}
+
+	receipts, err := PruneReceipts(l.accounts.kv, round)
+	if err != nil {
+		return deltas, receipts, err
+	}
+
+	l.historyPruned = round
+
+	return deltas, receipts, nil
+}
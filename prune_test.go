@@ -0,0 +1,86 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPruneHistory(t *testing.T) {
+	ledger := newTestLedger(t)
+
+	var account AccountID
+	account[0] = 1
+
+	for index := uint64(1); index <= 5; index++ {
+		latest := ledger.Rounds().Latest()
+
+		snapshot := ledger.Snapshot()
+		snapshot.SetViewID(index)
+		WriteAccountBalance(snapshot, account, index*10)
+
+		round := NewRound(index, snapshot.Checksum(), 0, latest.End, latest.End)
+		assert.NoError(t, ledger.applyReplicatedRound(round, snapshot.DumpDiff(latest.Index), nil))
+
+		var id TransactionID
+		id[0] = byte(index)
+
+		assert.NoError(t, StoreReceipts(ledger.accounts.kv, []*Receipt{{TxID: id, Round: index}}))
+	}
+
+	// Nothing is pruned until more rounds than are kept have been finalized.
+	deltas, receipts, err := ledger.pruneHistory(5, 5)
+	assert.NoError(t, err)
+	assert.Zero(t, deltas)
+	assert.Zero(t, receipts)
+
+	deltas, receipts, err = ledger.pruneHistory(5, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, deltas)
+	assert.Equal(t, 3, receipts)
+
+	assert.EqualValues(t, 3, ledger.AccountHistoryFrom())
+
+	_, err = ledger.AccountStateAt(account, 2)
+	assert.Equal(t, ErrAccountHistoryUnavailable, errors.Cause(err))
+
+	for index := uint64(3); index <= 5; index++ {
+		state, err := ledger.AccountStateAt(account, index)
+		assert.NoError(t, err)
+		assert.Equal(t, AccountState{Round: index, Balance: index * 10}, state)
+	}
+
+	history, err := ledger.AccountHistory(account, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+
+	for index := byte(1); index <= 5; index++ {
+		_, err := ledger.Receipt(TransactionID{index})
+		assert.Equal(t, index > 3, err == nil, "receipt of round %d", index)
+	}
+
+	// Rounds already pruned away are not pruned again.
+	deltas, receipts, err = ledger.pruneHistory(5, 2)
+	assert.NoError(t, err)
+	assert.Zero(t, deltas)
+	assert.Zero(t, receipts)
+}
@@ -0,0 +1,293 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/pkg/errors"
+	"io"
+)
+
+// Receipt records the outcome of a transaction finalized in a round. Should
+// the transaction have invoked or spawned a smart contract, whether directly
+// or through transactions queued up by smart contracts on its behalf, the
+// receipt also records what the smart contracts did while being executed.
+//
+// Receipts are not part of the ledger state. They are stored by each node as
+// it finalizes rounds, and are therefore unavailable for transactions which a
+// node adopted by syncing with its peers, or by replicating from upstream.
+type Receipt struct {
+	TxID  TransactionID
+	Round uint64
+
+	// Error is why the transaction failed to be applied to the ledger state.
+	// It is empty should the transaction have been applied.
+	Error string
+
+	// GasUsed is the gas spent across all smart contracts invoked on behalf of
+	// the transaction.
+	GasUsed uint64
+
+	// GasLimitExceeded, Reverted, and Result describe the invocation of the
+	// smart contract made by the transaction itself. Reverted is whether or
+	// not the changes made by the invocation were discarded, as it trapped,
+	// ran out of gas, or reported an error in Result.
+	GasLimitExceeded bool
+	Reverted         bool
+	Result           []byte
+
+	Events   []ContractEvent
+	Children []*Transaction // Transactions queued up by smart contracts, in the order they were applied.
+}
+
+const (
+	receiptGasLimitExceeded byte = 1 << iota
+	receiptReverted
+)
+
+// Applied returns whether or not the transaction was applied to the ledger
+// state.
+func (r *Receipt) Applied() bool {
+	return len(r.Error) == 0
+}
+
+// Receipt returns the receipt of a transaction finalized by this node.
+func (l *Ledger) Receipt(id TransactionID) (*Receipt, error) {
+	return LoadReceipt(l.accounts.kv, id)
+}
+
+// recordExecution records the outcome of a smart contract invocation made on
+// behalf of the transaction. queued is whether or not the invocation was made
+// by a transaction queued up by a smart contract, rather than by the
+// transaction itself. A nil receipt records nothing.
+func (r *Receipt) recordExecution(executor *ContractExecutor, queued bool) {
+	if r == nil {
+		return
+	}
+
+	r.GasUsed += executor.Gas
+
+	if !queued {
+		r.GasLimitExceeded = executor.GasLimitExceeded
+		r.Reverted = executor.Reverted
+		r.Result = executor.Error
+	}
+
+	if !executor.Reverted {
+		r.Events = append(r.Events, executor.Events...)
+	}
+}
+
+func (r *Receipt) recordChildren(children []*Transaction) {
+	if r == nil {
+		return
+	}
+
+	r.Children = append(r.Children, children...)
+}
+
+func (r *Receipt) Marshal() []byte {
+	var (
+		w   bytes.Buffer
+		buf [8]byte
+	)
+
+	writeBytes := func(b []byte) {
+		binary.BigEndian.PutUint32(buf[:4], uint32(len(b)))
+		w.Write(buf[:4])
+		w.Write(b)
+	}
+
+	w.Write(r.TxID[:])
+
+	binary.BigEndian.PutUint64(buf[:], r.Round)
+	w.Write(buf[:])
+
+	writeBytes([]byte(r.Error))
+
+	binary.BigEndian.PutUint64(buf[:], r.GasUsed)
+	w.Write(buf[:])
+
+	var flags byte
+
+	if r.GasLimitExceeded {
+		flags |= receiptGasLimitExceeded
+	}
+
+	if r.Reverted {
+		flags |= receiptReverted
+	}
+
+	w.WriteByte(flags)
+
+	writeBytes(r.Result)
+
+	binary.BigEndian.PutUint32(buf[:4], uint32(len(r.Events)))
+	w.Write(buf[:4])
+
+	for _, event := range r.Events {
+		w.Write(event.Contract[:])
+
+		binary.BigEndian.PutUint64(buf[:], event.Index)
+		w.Write(buf[:])
+
+		writeBytes(event.Marshal())
+	}
+
+	binary.BigEndian.PutUint32(buf[:4], uint32(len(r.Children)))
+	w.Write(buf[:4])
+
+	for _, child := range r.Children {
+		w.Write(child.Creator[:])
+		w.WriteByte(child.Tag)
+
+		writeBytes(child.Payload)
+	}
+
+	return w.Bytes()
+}
+
+func UnmarshalReceipt(buf []byte) (*Receipt, error) {
+	var (
+		r = new(Receipt)
+		b [8]byte
+	)
+
+	reader := bytes.NewReader(buf)
+
+	readBytes := func() ([]byte, error) {
+		if _, err := io.ReadFull(reader, b[:4]); err != nil {
+			return nil, err
+		}
+
+		size := binary.BigEndian.Uint32(b[:4])
+
+		if int64(size) > int64(reader.Len()) {
+			return nil, errors.Errorf("field is %d bytes, but only %d bytes are left", size, reader.Len())
+		}
+
+		field := make([]byte, size)
+		_, err := io.ReadFull(reader, field)
+
+		return field, err
+	}
+
+	if _, err := io.ReadFull(reader, r.TxID[:]); err != nil {
+		return nil, errors.Wrap(err, "failed to decode receipt transaction ID")
+	}
+
+	if _, err := io.ReadFull(reader, b[:]); err != nil {
+		return nil, errors.Wrap(err, "failed to decode receipt round")
+	}
+
+	r.Round = binary.BigEndian.Uint64(b[:])
+
+	msg, err := readBytes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode receipt error")
+	}
+
+	r.Error = string(msg)
+
+	if _, err := io.ReadFull(reader, b[:]); err != nil {
+		return nil, errors.Wrap(err, "failed to decode receipt gas used")
+	}
+
+	r.GasUsed = binary.BigEndian.Uint64(b[:])
+
+	flags, err := reader.ReadByte()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode receipt flags")
+	}
+
+	r.GasLimitExceeded = flags&receiptGasLimitExceeded != 0
+	r.Reverted = flags&receiptReverted != 0
+
+	if r.Result, err = readBytes(); err != nil {
+		return nil, errors.Wrap(err, "failed to decode receipt result")
+	}
+
+	if len(r.Result) == 0 {
+		r.Result = nil
+	}
+
+	if _, err := io.ReadFull(reader, b[:4]); err != nil {
+		return nil, errors.Wrap(err, "failed to decode number of receipt events")
+	}
+
+	for i := binary.BigEndian.Uint32(b[:4]); i > 0; i-- {
+		var contract TransactionID
+
+		if _, err := io.ReadFull(reader, contract[:]); err != nil {
+			return nil, errors.Wrap(err, "failed to decode receipt event contract ID")
+		}
+
+		if _, err := io.ReadFull(reader, b[:]); err != nil {
+			return nil, errors.Wrap(err, "failed to decode receipt event index")
+		}
+
+		index := binary.BigEndian.Uint64(b[:])
+
+		encoded, err := readBytes()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decode receipt event")
+		}
+
+		event, err := UnmarshalContractEvent(encoded)
+		if err != nil {
+			return nil, err
+		}
+
+		event.Contract, event.Index = contract, index
+
+		r.Events = append(r.Events, event)
+	}
+
+	if _, err := io.ReadFull(reader, b[:4]); err != nil {
+		return nil, errors.Wrap(err, "failed to decode number of receipt children")
+	}
+
+	for i := binary.BigEndian.Uint32(b[:4]); i > 0; i-- {
+		child := new(Transaction)
+
+		if _, err := io.ReadFull(reader, child.Creator[:]); err != nil {
+			return nil, errors.Wrap(err, "failed to decode receipt child creator")
+		}
+
+		child.Sender = child.Creator
+
+		if child.Tag, err = reader.ReadByte(); err != nil {
+			return nil, errors.Wrap(err, "failed to decode receipt child tag")
+		}
+
+		if child.Payload, err = readBytes(); err != nil {
+			return nil, errors.Wrap(err, "failed to decode receipt child payload")
+		}
+
+		r.Children = append(r.Children, child)
+	}
+
+	if reader.Len() > 0 {
+		return nil, errors.Errorf("receipt has %d unexpected trailing bytes", reader.Len())
+	}
+
+	return r, nil
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"encoding/hex"
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/pkg/errors"
+)
+
+// Machine-readable reasons a peer may report for rejecting a transaction
+// gossiped to it.
+const (
+	RejectionInvalid             = "invalid"
+	RejectionInvalidSignature    = "invalid_signature"
+	RejectionInsufficientBalance = "insufficient_balance"
+	RejectionExpired             = "expired"
+)
+
+// TransactionRejection is a reason reported by a peer for rejecting a
+// transaction that was gossiped to it.
+type TransactionRejection struct {
+	Peer    string
+	Reason  string
+	Message string
+}
+
+// rejectTransaction classifies the outcome of adding a gossiped transaction
+// to the ledger into a rejection to report back to its sender. It returns
+// nil if the transaction was not rejected.
+//
+// Transactions that were accepted into the graph are additionally checked
+// against the next round to be finalized and the balance of their creator,
+// such that a transaction which is bound to fail once finalized is reported
+// immediately.
+func rejectTransaction(snapshot *avl.Tree, round uint64, tx Transaction, err error) *Rejection {
+	if err == nil {
+		if tx.Expired(round) {
+			err = errors.Wrapf(ErrTransactionExpired, "tx is valid until round %d, but round %d is next to be finalized", tx.ValidUntilView, round)
+			return &Rejection{Id: tx.ID[:], Reason: RejectionExpired, Message: err.Error()}
+		}
+
+		err = checkTransactionBalance(snapshot, tx)
+
+		if err == nil {
+			return nil
+		}
+
+		return &Rejection{Id: tx.ID[:], Reason: RejectionInsufficientBalance, Message: err.Error()}
+	}
+
+	switch errors.Cause(err) {
+	case ErrMissingParents, ErrAlreadyExists:
+		return nil
+	case ErrInvalidCreatorSignature, ErrInvalidSenderSignature:
+		return &Rejection{Id: tx.ID[:], Reason: RejectionInvalidSignature, Message: err.Error()}
+	default:
+		return &Rejection{Id: tx.ID[:], Reason: RejectionInvalid, Message: err.Error()}
+	}
+}
+
+// checkTransactionBalance checks that the creator of a transaction is able
+// to afford its fee, and the amount of PERLs it transfers, should it be a
+// transfer transaction.
+func checkTransactionBalance(snapshot *avl.Tree, tx Transaction) error {
+	// FIXME(kenta): FOR TESTNET ONLY. FAUCET DOES NOT GET ANY PERLs DEDUCTED.
+	if hex.EncodeToString(tx.Creator[:]) == sys.FaucetAddress {
+		return nil
+	}
+
+	required := tx.Fee()
+
+	if tx.Tag == sys.TagTransfer {
+		params, err := ParseTransferTransaction(tx.Payload)
+		if err != nil {
+			return nil
+		}
+
+		if required+params.Amount < required {
+			return errors.Errorf("%x tried to send %d PERLs, which overflows once fees are included", tx.Creator, params.Amount)
+		}
+
+		required += params.Amount
+	}
+
+	balance, _ := ReadAccountBalance(snapshot, tx.Creator)
+
+	if balance < required {
+		return errors.Errorf("%x requires %d PERLs to cover the transaction and its fees, but only has %d PERLs", tx.Creator, required, balance)
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"encoding/binary"
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRejectTransaction(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	var payload [8 + 32]byte
+	binary.LittleEndian.PutUint64(payload[32:], 100)
+
+	tx := NewTransaction(keys, sys.TagTransfer, payload[:])
+	snapshot := avl.New(store.NewInmem())
+
+	// Errors which do not amount to the transaction being rejected.
+	assert.Nil(t, rejectTransaction(snapshot, 1, tx, errors.Wrap(ErrMissingParents, "failed to add transaction")))
+	assert.Nil(t, rejectTransaction(snapshot, 1, tx, ErrAlreadyExists))
+
+	rejection := rejectTransaction(snapshot, 1, tx, errors.Wrap(ErrInvalidSenderSignature, "failed to validate transaction"))
+	if assert.NotNil(t, rejection) {
+		assert.Equal(t, tx.ID[:], rejection.Id)
+		assert.Equal(t, RejectionInvalidSignature, rejection.Reason)
+	}
+
+	rejection = rejectTransaction(snapshot, 1, tx, errors.New("tx has an unknown tag"))
+	if assert.NotNil(t, rejection) {
+		assert.Equal(t, RejectionInvalid, rejection.Reason)
+		assert.Equal(t, "tx has an unknown tag", rejection.Message)
+	}
+
+	// Accepted transactions whose creator is unable to afford them are rejected.
+	WriteAccountBalance(snapshot, keys.PublicKey(), 100+sys.Params().TransactionFeeAmount-1)
+
+	rejection = rejectTransaction(snapshot, 1, tx, nil)
+	if assert.NotNil(t, rejection) {
+		assert.Equal(t, RejectionInsufficientBalance, rejection.Reason)
+	}
+
+	WriteAccountBalance(snapshot, keys.PublicKey(), 100+sys.Params().TransactionFeeAmount)
+	assert.Nil(t, rejectTransaction(snapshot, 1, tx, nil))
+
+	// Accepted transactions which have expired before the next round to be
+	// finalized are rejected.
+	expiring := NewExpiringTransaction(keys, sys.TagTransfer, payload[:], 1)
+	assert.Nil(t, rejectTransaction(snapshot, 1, expiring, nil))
+
+	rejection = rejectTransaction(snapshot, 2, expiring, nil)
+	if assert.NotNil(t, rejection) {
+		assert.Equal(t, RejectionExpired, rejection.Reason)
+	}
+}
+
+func TestRecordRejection(t *testing.T) {
+	ledger := newTestLedger(t)
+
+	var id TransactionID
+	id[0] = 1
+
+	ledger.recordRejection("a", &Rejection{Id: id[:], Reason: RejectionInsufficientBalance, Message: "first"})
+	ledger.recordRejection("a", &Rejection{Id: id[:], Reason: RejectionInsufficientBalance, Message: "duplicate"})
+	ledger.recordRejection("b", &Rejection{Id: id[:], Reason: RejectionInsufficientBalance, Message: "second"})
+	ledger.recordRejection("b", &Rejection{Id: id[:1], Reason: RejectionInvalid})
+
+	rejections := ledger.TransactionRejections(id)
+	if assert.Len(t, rejections, 2) {
+		assert.Equal(t, TransactionRejection{Peer: "a", Reason: RejectionInsufficientBalance, Message: "first"}, rejections[0])
+		assert.Equal(t, TransactionRejection{Peer: "b", Reason: RejectionInsufficientBalance, Message: "second"}, rejections[1])
+	}
+
+	assert.Empty(t, ledger.TransactionRejections(ZeroTransactionID))
+}
@@ -0,0 +1,293 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"bytes"
+	"context"
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/perlin-network/wavelet/log"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// Replicate has the ledger follow the full node located at address as a read
+// replica. The ledger subscribes to every round the full node advances to,
+// and adopts each round alongside the state changes and transactions applied
+// in it, without ever participating in consensus. Should the subscription be
+// lost, it is re-established. Replicate only returns once a standby ledger is
+// promoted, or once the ledger is stopped. See Promote and Stop.
+func (l *Ledger) Replicate(address string) {
+	var stop chan struct{}
+
+	if l.standby != nil {
+		stop = l.standby.stop
+	}
+
+	for {
+		err := l.replicateFrom(address, stop)
+
+		l.setSynced(false)
+
+		select {
+		case <-stop:
+			return
+		case <-l.ctx.Done():
+			return
+		default:
+		}
+
+		logger := log.Sync("replicate")
+		logger.Warn().
+			Err(err).
+			Str("upstream", address).
+			Msg("Lost our subscription to the rounds of our upstream node. Resubscribing...")
+
+		select {
+		case <-stop:
+			return
+		case <-l.ctx.Done():
+			return
+		case <-time.After(1 * time.Second):
+		}
+	}
+}
+
+func (l *Ledger) replicateFrom(address string, stop <-chan struct{}) error {
+	client := l.client
+	if l.standby != nil {
+		client = l.standby.replicator
+	}
+
+	conn, err := client.Dial(address)
+	if err != nil {
+		return errors.Wrap(err, "failed to dial upstream node")
+	}
+
+	ctx, cancel := context.WithCancel(l.ctx)
+	defer cancel()
+
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	stream, err := NewWaveletClient(conn).Replicate(ctx, &ReplicateRequest{RoundIndex: l.rounds.Latest().Index})
+	if err != nil {
+		return errors.Wrap(err, "failed to subscribe to upstream node")
+	}
+
+	var (
+		diff         []byte
+		transactions [][]byte
+	)
+
+	for {
+		res, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		diff = append(diff, res.Diff...)
+		transactions = append(transactions, res.Transactions...)
+
+		if !res.Done {
+			continue
+		}
+
+		round, err := UnmarshalRound(bytes.NewReader(res.Round))
+		if err != nil {
+			return errors.Wrap(err, "upstream node sent an invalid round")
+		}
+
+		if err := l.applyReplicatedRound(round, diff, transactions); err != nil {
+			return err
+		}
+
+		diff, transactions = nil, nil
+
+		l.setSynced(true)
+	}
+}
+
+// applyReplicatedRound has the ledger adopt a round replicated from its
+// upstream node, given the diff of the ledger state since the latest round of
+// the ledger, and the transactions applied in the round. Rounds which are not
+// newer than the latest round of the ledger are ignored.
+func (l *Ledger) applyReplicatedRound(round Round, diff []byte, transactions [][]byte) error {
+	current := l.rounds.Latest()
+
+	if round.Index <= current.Index {
+		return nil
+	}
+
+	snapshot := l.accounts.Snapshot()
+
+	if err := snapshot.ApplyDiff(diff); err != nil {
+		return errors.Wrapf(err, "failed to apply diff of round %d", round.Index)
+	}
+
+	if checksum := snapshot.Checksum(); checksum != round.Merkle {
+		return errors.Errorf("applying diff of round %d yielded merkle root %x, but expected %x", round.Index, checksum, round.Merkle)
+	}
+
+	for _, buf := range transactions {
+		tx, err := UnmarshalTransaction(bytes.NewReader(buf))
+		if err != nil {
+			return errors.Wrapf(err, "upstream node sent an invalid transaction applied in round %d", round.Index)
+		}
+
+		if err := l.graph.AddTransaction(tx); err != nil && errors.Cause(err) != ErrAlreadyExists && errors.Cause(err) != ErrMissingParents {
+			continue
+		}
+
+		// Transactions applied in rounds other than the one right after the
+		// latest round of the ledger may not be attributed to a round.
+
+		if round.Index == current.Index+1 {
+			l.statuses.put(tx.ID, transactionStatus{status: TransactionStatusApplied, round: round.Index})
+		}
+	}
+
+	prev := l.accounts.Snapshot()
+
+	pruned, err := l.commitRound(&round, snapshot)
+	if err != nil {
+		return errors.Wrapf(err, "failed to commit round %d", round.Index)
+	}
+
+	if pruned != nil {
+		l.graph.PruneBelowDepth(pruned.End.Depth)
+	}
+
+	l.graph.UpdateRoot(round.End)
+
+	if err := l.storeRoundHistory(prev, snapshot, current.Index, round.Index); err != nil {
+		logger := log.Sync("replicate")
+		logger.Warn().Err(err).Uint64("round", round.Index).Msg("Failed to store account history.")
+	}
+
+	// The changes made in the rounds skipped are folded into those of the
+	// round, such that the state of accounts in the rounds skipped is lost.
+	if round.Index > current.Index+1 {
+		l.restartAccountHistory(round.Index)
+	}
+
+	l.LogChanges(snapshot, current.Index)
+
+	l.roundFinalized(round)
+
+	logger := log.Sync("replicate")
+	logger.Info().
+		Int("num_tx", len(transactions)).
+		Uint64("old_round", current.Index).
+		Uint64("new_round", round.Index).
+		Hex("new_root", round.End.ID[:]).
+		Hex("new_merkle_root", round.Merkle[:]).
+		Msg("Adopted a round from our upstream node.")
+
+	return nil
+}
+
+// sendReplicatedRound sends round to a read replica whose latest round is at
+// index from, alongside the diff of snapshot since index from, and whatever
+// transactions applied in the rounds since index from are still cached by the
+// ledger. The diff and transactions are split across as many responses as is
+// necessary to fit within the max message size, the last of which is marked
+// as done.
+func (l *Ledger) sendReplicatedRound(stream Wavelet_ReplicateServer, from uint64, round Round, snapshot *avl.Tree) error {
+	res := &ReplicateResponse{Round: round.Marshal()}
+
+	if round.Index <= from {
+		res.Done = true
+		return stream.Send(res)
+	}
+
+	// Leave some room for the framing of the response.
+	limit := sys.MaxMessageSize - 4096 - len(res.Round)
+
+	diff := snapshot.DumpDiff(from)
+
+	for len(diff) > limit {
+		res.Diff, diff = diff[:limit], diff[limit:]
+
+		if err := stream.Send(res); err != nil {
+			return err
+		}
+	}
+
+	// The last chunk of the diff is sent alongside transactions.
+	res.Diff = diff
+
+	size := len(res.Diff)
+
+	for _, tx := range l.finalizedTransactions(from, round.Index) {
+		buf := tx.Marshal()
+
+		if size+len(buf) > limit {
+			if err := stream.Send(res); err != nil {
+				return err
+			}
+
+			res = &ReplicateResponse{Round: res.Round}
+			size = 0
+		}
+
+		res.Transactions = append(res.Transactions, buf)
+		size += len(buf)
+	}
+
+	res.Done = true
+
+	return stream.Send(res)
+}
+
+// finalizedTransactions returns the transactions applied in the rounds with
+// indices (from, to] which the ledger still has cached from having collapsed
+// them, ordered by the round they were applied in.
+func (l *Ledger) finalizedTransactions(from, to uint64) []*Transaction {
+	var transactions []*Transaction
+
+	start := from + 1
+
+	if oldest := l.rounds.Oldest().Index; start < oldest {
+		start = oldest
+	}
+
+	for index := start; index <= to; index++ {
+		round, err := l.rounds.GetByIndex(index)
+		if err != nil {
+			continue
+		}
+
+		results, exists := l.cacheCollapse.load(round.End.ID)
+		if !exists {
+			continue
+		}
+
+		transactions = append(transactions, results.(*CollapseResults).applied...)
+	}
+
+	return transactions
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"bytes"
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"testing"
+)
+
+type replicateStream struct {
+	grpc.ServerStream
+	responses []*ReplicateResponse
+}
+
+func (s *replicateStream) Send(res *ReplicateResponse) error {
+	s.responses = append(s.responses, res)
+	return nil
+}
+
+func TestReplicateRound(t *testing.T) {
+	upstream := newTestLedger(t)
+	replica := newTestLedger(t)
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	var account AccountID
+	account[0] = 1
+
+	// Advance the upstream ledger by a round which applied a transaction.
+	latest := upstream.Rounds().Latest()
+	tx := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagNop, nil), &latest.End)
+
+	snapshot := upstream.Snapshot()
+	snapshot.SetViewID(1)
+	WriteAccountBalance(snapshot, account, 1337)
+
+	round := NewRound(1, snapshot.Checksum(), 1, latest.End, tx)
+
+	_, err = upstream.rounds.Save(&round)
+	assert.NoError(t, err)
+	assert.NoError(t, upstream.accounts.Commit(snapshot))
+
+	upstream.cacheCollapse.put(round.End.ID, &CollapseResults{applied: []*Transaction{&tx}})
+
+	stream := new(replicateStream)
+	assert.NoError(t, upstream.sendReplicatedRound(stream, 0, round, upstream.Snapshot()))
+
+	var (
+		diff         []byte
+		transactions [][]byte
+	)
+
+	for _, res := range stream.responses {
+		assert.Equal(t, round.Marshal(), res.Round)
+
+		diff = append(diff, res.Diff...)
+		transactions = append(transactions, res.Transactions...)
+	}
+
+	if assert.NotEmpty(t, stream.responses) {
+		assert.True(t, stream.responses[len(stream.responses)-1].Done)
+	}
+
+	if assert.Len(t, transactions, 1) {
+		assert.True(t, bytes.Equal(tx.Marshal(), transactions[0]))
+	}
+
+	var finalized []Round
+	replica.OnRoundFinalized(func(round Round) {
+		finalized = append(finalized, round)
+	})
+
+	assert.NoError(t, replica.applyReplicatedRound(round, diff, transactions))
+
+	if assert.Len(t, finalized, 1) {
+		assert.Equal(t, round.ID, finalized[0].ID)
+	}
+
+	assert.Equal(t, round.ID, replica.Rounds().Latest().ID)
+	assert.Equal(t, round.Merkle, replica.Snapshot().Checksum())
+
+	balance, exists := ReadAccountBalance(replica.Snapshot(), account)
+	assert.True(t, exists)
+	assert.EqualValues(t, 1337, balance)
+
+	status, index := replica.TransactionStatus(tx.ID)
+	assert.Equal(t, TransactionStatusApplied, status)
+	assert.EqualValues(t, 1, index)
+
+	// Rounds which are not newer than the latest round of the replica are ignored.
+	assert.NoError(t, replica.applyReplicatedRound(round, nil, nil))
+	assert.Len(t, finalized, 1)
+
+	// Replicas which are caught up are only sent the latest round.
+	stream = new(replicateStream)
+	assert.NoError(t, upstream.sendReplicatedRound(stream, round.Index, round, upstream.Snapshot()))
+
+	if assert.Len(t, stream.responses, 1) {
+		assert.True(t, stream.responses[0].Done)
+		assert.Empty(t, stream.responses[0].Diff)
+		assert.Empty(t, stream.responses[0].Transactions)
+	}
+
+	// Diffs which do not yield the merkle root of the round are rejected.
+	next := NewRound(2, round.Merkle, 0, round.End, round.End)
+	next.Merkle[0] ^= 1
+
+	assert.Error(t, replica.applyReplicatedRound(next, nil, nil))
+	assert.Equal(t, round.ID, replica.Rounds().Latest().ID)
+}
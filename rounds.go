@@ -22,6 +22,7 @@ package wavelet
 import (
 	"fmt"
 	"github.com/perlin-network/wavelet/store"
+	"sort"
 	"sync"
 )
 
@@ -120,3 +121,24 @@ func (r *Rounds) GetByIndex(ix uint64) (*Round, error) {
 
 	return round, nil
 }
+
+// Since returns all rounds retained with an index greater than ix, ordered by
+// their index. Only the latest few rounds are retained, such that rounds which
+// have since been evicted are left out.
+func (r *Rounds) Since(ix uint64) []*Round {
+	var rounds []*Round
+
+	r.RLock()
+	for _, round := range r.buffer {
+		if round.Index > ix {
+			rounds = append(rounds, round)
+		}
+	}
+	r.RUnlock()
+
+	sort.Slice(rounds, func(i, j int) bool {
+		return rounds[i].Index < rounds[j].Index
+	})
+
+	return rounds
+}
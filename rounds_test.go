@@ -98,3 +98,26 @@ func TestRoundsCircular(t *testing.T) {
 	assert.Equal(t, uint32(4), newRM.latest)
 	assert.Equal(t, uint32(5), newRM.oldest)
 }
+
+func TestRoundsSince(t *testing.T) {
+	rm, err := NewRounds(store.NewInmem(), 10)
+	assert.EqualError(t, errors.Cause(err), "key not found")
+
+	for i := 0; i < 15; i++ {
+		_, err := rm.Save(&Round{Index: uint64(i + 1)})
+		assert.NoError(t, err)
+	}
+
+	indices := func(rounds []*Round) []uint64 {
+		ix := make([]uint64, 0, len(rounds))
+		for _, round := range rounds {
+			ix = append(ix, round.Index)
+		}
+		return ix
+	}
+
+	assert.Equal(t, []uint64{13, 14, 15}, indices(rm.Since(12)))
+	assert.Equal(t, []uint64{6, 7, 8, 9, 10, 11, 12, 13, 14, 15}, indices(rm.Since(0)))
+	assert.Empty(t, rm.Since(15))
+	assert.Empty(t, rm.Since(20))
+}
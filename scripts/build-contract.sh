@@ -0,0 +1,78 @@
+#!/bin/bash
+# Copyright (c) 2019 Perlin
+#
+# Permission is hereby granted, free of charge, to any person obtaining a copy of
+# this software and associated documentation files (the "Software"), to deal in
+# the Software without restriction, including without limitation the rights to
+# use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+# the Software, and to permit persons to whom the Software is furnished to do so,
+# subject to the following conditions:
+#
+# The above copyright notice and this permission notice shall be included in all
+# copies or substantial portions of the Software.
+#
+# THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+# IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+# FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+# COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+# IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+# CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+#
+# This script compiles a smart contract written in Go against the contract-sdk
+# package into a WebAssembly module using TinyGo, which may then be spawned
+# through the `spawn` command of wavelet.
+set -eu
+
+OUTPUT=""
+
+function show_help {
+    echo "Usage: build-contract.sh [-h] [-o output] <package directory>"
+    echo "    -h    Display this help message."
+    echo "    -o    Output file (default: <package directory>/<package name>.wasm)."
+}
+
+while getopts "h?o:" opt; do
+    case "$opt" in
+    h|\?)
+        show_help
+        exit 0
+        ;;
+    o)  OUTPUT="$OPTARG"
+        ;;
+    esac
+done
+
+shift $((OPTIND-1))
+
+if [[ $# -ne 1 ]]; then
+    show_help
+    exit 1
+fi
+
+PACKAGE_DIR="$1"
+
+if [[ -z "${OUTPUT}" ]]; then
+    OUTPUT="${PACKAGE_DIR}/$(basename "$(cd "${PACKAGE_DIR}" && pwd)").wasm"
+fi
+
+if ! command -v tinygo > /dev/null; then
+    echo "TinyGo is required to compile smart contracts. See https://tinygo.org/getting-started/install/."
+    exit 1
+fi
+
+# Smart contracts may only import host functions provided by wavelet, and have
+# their memory persisted in between invocations. Hence, the module is built
+# without a scheduler, a garbage collector, or any imports from WASI, and traps
+# on panics.
+tinygo build \
+    -target=wasm-unknown \
+    -scheduler=none \
+    -gc=leaking \
+    -panic=trap \
+    -no-debug \
+    -opt=z \
+    -o "${OUTPUT}" \
+    "${PACKAGE_DIR}"
+
+echo "Built smart contract ${OUTPUT}."
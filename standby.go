@@ -0,0 +1,123 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet/log"
+	"github.com/pkg/errors"
+	"sync"
+)
+
+var (
+	ErrNotStandby      = errors.New("node is not running as a standby")
+	ErrAlreadyPromoted = errors.New("standby has already been promoted")
+)
+
+// standby is the state of a ledger running as a cold standby of a validator.
+type standby struct {
+	sync.Mutex
+
+	// replicator is the client through which rounds are replicated from the
+	// validator. It must hold an identity other than that of the validator, as
+	// the validator refuses connections from peers holding its own identity.
+	replicator *skademlia.Client
+
+	// join connects the client of the ledger, which holds the identity of the
+	// validator, to the network once the standby is promoted.
+	join func()
+
+	promoted bool
+
+	stop    chan struct{} // Closed to stop replicating from the validator.
+	stopped chan struct{} // Closed once replicating from the validator has stopped.
+}
+
+// WithStandby has the ledger run as a cold standby of the validator located at
+// address. The client of the ledger is expected to hold the identity of the
+// validator, but to not yet be connected to the network. Until the standby is
+// promoted, the ledger replicates rounds from the validator through replicator
+// as a read replica would. See Promote.
+func WithStandby(address string, replicator *skademlia.Client, join func()) LedgerOption {
+	return func(ledger *Ledger) {
+		ledger.upstream = address
+		ledger.standby = &standby{
+			replicator: replicator,
+			join:       join,
+			stop:       make(chan struct{}),
+			stopped:    make(chan struct{}),
+		}
+	}
+}
+
+// IsStandby returns whether or not the ledger is running as a cold standby
+// which has yet to be promoted.
+func (l *Ledger) IsStandby() bool {
+	if l.standby == nil {
+		return false
+	}
+
+	l.standby.Lock()
+	defer l.standby.Unlock()
+
+	return !l.standby.promoted
+}
+
+// Promote has a cold standby take over from the validator it replicates from.
+// Replication is stopped before the client of the ledger, which holds the
+// identity of the validator, joins the network, such that the ledger never
+// adopts a round from the validator after it starts participating in consensus
+// itself. Promote returns ErrNotStandby should the ledger not be running as a
+// standby, and ErrAlreadyPromoted should it have already been promoted.
+//
+// The validator is expected to have been taken offline beforehand, as two
+// nodes holding the same identity may not participate in consensus at once.
+func (l *Ledger) Promote() error {
+	if l.standby == nil {
+		return ErrNotStandby
+	}
+
+	l.standby.Lock()
+	defer l.standby.Unlock()
+
+	if l.standby.promoted {
+		return ErrAlreadyPromoted
+	}
+
+	close(l.standby.stop)
+	<-l.standby.stopped
+
+	if l.standby.join != nil {
+		l.standby.join()
+	}
+
+	l.standby.promoted = true
+
+	l.PerformConsensus()
+	l.spawn(l.SyncToLatestRound)
+
+	logger := log.Node()
+	logger.Info().
+		Str("validator", l.upstream).
+		Uint64("round", l.rounds.Latest().Index).
+		Msg("Promoted from a standby. Now participating in consensus.")
+
+	return nil
+}
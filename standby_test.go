@@ -0,0 +1,61 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"github.com/perlin-network/noise"
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestPromoteStandby(t *testing.T) {
+	assert.Equal(t, ErrNotStandby, newTestLedger(t).Promote())
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	replicator := skademlia.NewClient(":0", keys)
+	replicator.SetCredentials(noise.NewCredentials(":0", replicator.Protocol()))
+
+	joined := 0
+
+	// Have the standby replicate from an address nobody listens on, such that
+	// it is kept retrying to subscribe to the validator when it is promoted.
+	ledger := newTestLedger(t, WithStandby("127.0.0.1:1", replicator, func() { joined++ }))
+	assert.True(t, ledger.IsStandby())
+
+	promoted := make(chan error, 1)
+	go func() { promoted <- ledger.Promote() }()
+
+	select {
+	case err := <-promoted:
+		assert.NoError(t, err)
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out waiting for the standby to stop replicating")
+	}
+
+	assert.False(t, ledger.IsStandby())
+	assert.Equal(t, 1, joined)
+
+	assert.Equal(t, ErrAlreadyPromoted, ledger.Promote())
+	assert.Equal(t, 1, joined)
+}
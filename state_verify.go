@@ -0,0 +1,210 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/pkg/errors"
+)
+
+// StateReport is the result of checking the integrity of the ledger state.
+type StateReport struct {
+	Round      uint64
+	MerkleRoot MerkleNodeID
+
+	NumLeaves   uint64
+	NumAccounts uint64
+
+	// Inconsistencies found within the ledger state. The state is healthy
+	// should there be none.
+	Problems []string
+}
+
+func (r *StateReport) problem(format string, args ...interface{}) {
+	r.Problems = append(r.Problems, fmt.Sprintf(format, args...))
+}
+
+// Sizes of the values stored under keys of accounts, for keys whose values are
+// of a fixed size.
+var accountValueSizes = map[byte]int{
+	keyAccountNonce[0]:             8,
+	keyAccountBalance[0]:           8,
+	keyAccountStake[0]:             8,
+	keyAccountReward[0]:            8,
+	keyAccountContractNumPages[0]:  8,
+	keyAccountContractOwner[0]:     SizeAccountID,
+	keyAccountContractPaused[0]:    1,
+	keyAccountContractQuota[0]:     8,
+	keyAccountContractCalls[0]:     16,
+	keyAccountContractNumEvents[0]: 8,
+	keyAccountDelegation[0]:        SizeDelegation,
+	keyAccountDelegationSpent[0]:   16,
+}
+
+// VerifyState checks the integrity of the ledger state as of the latest round.
+// It walks every node of the state tree, recomputing its merkle root, and checks
+// that the root matches the merkle root of the latest round. It then checks that
+// every account is stored consistently, and that the number of accounts tracked
+// matches the number of accounts stored. progress is called with the number of
+// leaves of the state tree visited so far.
+//
+// An error is returned should the state tree be corrupt or incomplete, such that
+// it may not be walked. Inconsistencies otherwise are reported as problems.
+func (l *Ledger) VerifyState(progress func(leaves uint64)) (*StateReport, error) {
+	report := new(StateReport)
+
+	round, snapshot, err := l.consistentSnapshot()
+	if err != nil {
+		report.problem("%v", errors.Cause(err))
+
+		round, snapshot = *l.rounds.Latest(), l.accounts.Snapshot()
+	}
+
+	report.Round = round.Index
+
+	if err := snapshot.Verify(func(leaves uint64) {
+		report.NumLeaves = leaves
+
+		if progress != nil {
+			progress(leaves)
+		}
+	}); err != nil {
+		return report, errors.Wrap(err, "state tree is corrupt")
+	}
+
+	report.MerkleRoot = snapshot.Checksum()
+
+	if report.MerkleRoot != round.Merkle {
+		report.problem("recomputed state merkle root %x does not match round %d merkle root %x", report.MerkleRoot, round.Index, round.Merkle)
+	}
+
+	var (
+		code     = make(map[AccountID]struct{})
+		numPages = make(map[AccountID]uint64)
+		pages    = make(map[AccountID][]uint64)
+
+		numEvents = make(map[AccountID]uint64)
+		events    = make(map[AccountID][]uint64)
+	)
+
+	snapshot.IteratePrefix(keyAccounts[:], func(key, value []byte) {
+		key = key[len(keyAccounts):]
+
+		if len(key) < 1+SizeAccountID {
+			report.problem("account key %x is too short", key)
+			return
+		}
+
+		var id AccountID
+		copy(id[:], key[len(key)-SizeAccountID:])
+
+		kind, rest := key[0], key[1:len(key)-SizeAccountID]
+
+		// Only memory pages and events, and delegations which are keyed by
+		// the ID of their account followed by the session key, are keyed by
+		// more than the ID of their account.
+		switch kind {
+		case keyAccountContractPages[0], keyAccountContractEvents[0]:
+		case keyAccountDelegation[0], keyAccountDelegationSpent[0]:
+			if len(rest) != SizeAccountID {
+				report.problem("delegation to session key %x has a malformed key %x", id, key)
+				return
+			}
+		default:
+			if len(rest) > 0 {
+				report.problem("account %x has a malformed key %x", id, key)
+				return
+			}
+		}
+
+		if size, fixed := accountValueSizes[kind]; fixed && len(value) != size {
+			report.problem("account %x has a value of %d bytes under key %x, but it must be %d bytes", id, len(value), kind, size)
+			return
+		}
+
+		switch kind {
+		case keyAccountNonce[0]:
+			report.NumAccounts++
+		case keyAccountContractCode[0]:
+			code[id] = struct{}{}
+		case keyAccountContractNumPages[0]:
+			numPages[id] = binary.LittleEndian.Uint64(value)
+		case keyAccountContractPages[0]:
+			if len(rest) != 8 {
+				report.problem("contract %x has a memory page stored under a malformed index %x", id, rest)
+				return
+			}
+
+			pages[id] = append(pages[id], binary.LittleEndian.Uint64(rest))
+		case keyAccountContractNumEvents[0]:
+			numEvents[id] = binary.LittleEndian.Uint64(value)
+		case keyAccountContractEvents[0]:
+			if len(rest) != 8 {
+				report.problem("contract %x has an event stored under a malformed index %x", id, rest)
+				return
+			}
+
+			events[id] = append(events[id], binary.LittleEndian.Uint64(rest))
+		}
+	})
+
+	if accountsLen := ReadAccountsLen(snapshot); accountsLen != report.NumAccounts {
+		report.problem("%d accounts are tracked, but %d accounts are stored", accountsLen, report.NumAccounts)
+	}
+
+	for id := range numPages {
+		if _, exists := code[id]; !exists {
+			report.problem("contract %x has memory pages, but no code", id)
+		}
+	}
+
+	for id, indices := range pages {
+		n, exists := numPages[id]
+		if !exists {
+			report.problem("contract %x has %d memory pages stored, but no number of memory pages", id, len(indices))
+			continue
+		}
+
+		for _, idx := range indices {
+			if idx >= n {
+				report.problem("contract %x has memory page %d stored, but only %d memory pages", id, idx, n)
+			}
+		}
+	}
+
+	for id, indices := range events {
+		n := numEvents[id]
+
+		for _, idx := range indices {
+			if idx >= n {
+				report.problem("contract %x has event %d stored, but only %d events", id, idx, n)
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// Healthy returns whether or not no inconsistencies were found within the
+// ledger state.
+func (r *StateReport) Healthy() bool {
+	return len(r.Problems) == 0
+}
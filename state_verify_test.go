@@ -0,0 +1,81 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestVerifyState(t *testing.T) {
+	ledger := newTestLedger(t)
+
+	var leaves uint64
+
+	report, err := ledger.VerifyState(func(n uint64) {
+		leaves = n
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.True(t, report.Healthy(), report.Problems)
+	assert.Equal(t, ledger.Rounds().Latest().Merkle, report.MerkleRoot)
+	assert.Equal(t, ReadAccountsLen(ledger.Snapshot()), report.NumAccounts)
+	assert.Equal(t, report.NumLeaves, leaves)
+	assert.NotZero(t, leaves)
+
+	var contract TransactionID
+	contract[0] = 1
+
+	// Advance the ledger by a round whose state is inconsistent.
+	snapshot := ledger.Snapshot()
+	snapshot.SetViewID(1)
+
+	WriteAccountNonce(snapshot, contract, 1)
+	WriteAccountContractCode(snapshot, contract, []byte("code"))
+	WriteAccountContractNumPages(snapshot, contract, 1)
+	WriteAccountContractPage(snapshot, contract, 0, []byte("page"))
+	WriteAccountContractPage(snapshot, contract, 1, []byte("page"))
+
+	// Delegations are keyed by more than the ID of their account, but are not
+	// malformed.
+	var key AccountID
+	key[0] = 2
+
+	WriteAccountDelegation(snapshot, contract, key, Delegation{MaxSpend: 1, AllowedTags: 1 << sys.TagTransfer, Expiry: 2})
+	WriteAccountDelegationSpent(snapshot, contract, key, 1, 1)
+
+	latest := ledger.Rounds().Latest()
+	round := NewRound(1, snapshot.Checksum(), 0, latest.End, latest.End)
+
+	_, err = ledger.rounds.Save(&round)
+	assert.NoError(t, err)
+	assert.NoError(t, ledger.accounts.Commit(snapshot))
+
+	report, err = ledger.VerifyState(nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.EqualValues(t, 1, report.Round)
+	assert.Len(t, report.Problems, 2)
+}
@@ -0,0 +1,247 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package store
+
+import (
+	"bytes"
+	"github.com/pkg/errors"
+	"go.etcd.io/bbolt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var (
+	// BucketAccounts holds all nodes and roots of the accounts tree, whose keys
+	// are prefixed with either '@' or '.'.
+	BucketAccounts = []byte("accounts")
+
+	// BucketMeta holds all other ledger metadata, such as rounds and peers.
+	BucketMeta = []byte("meta")
+)
+
+// bucketFor returns the bucket a key, or all keys with a given prefix, is
+// stored under. It returns nil for an empty prefix, which spans all buckets.
+func bucketFor(key []byte) []byte {
+	if len(key) == 0 {
+		return nil
+	}
+
+	if key[0] == '@' || key[0] == '.' {
+		return BucketAccounts
+	}
+
+	return BucketMeta
+}
+
+var _ WriteBatch = (*boltWriteBatch)(nil)
+
+type boltWriteBatch struct {
+	pairs []kvPair
+}
+
+func (b *boltWriteBatch) Put(key, value []byte) {
+	b.pairs = append(b.pairs, kvPair{
+		key:   append([]byte{}, key...),
+		value: append([]byte{}, value...),
+	})
+}
+
+func (b *boltWriteBatch) Clear() {
+	b.pairs = b.pairs[:0]
+}
+
+func (b *boltWriteBatch) Count() int {
+	return len(b.pairs)
+}
+
+func (b *boltWriteBatch) Destroy() {
+	b.pairs = nil
+}
+
+var _ KV = (*boltKV)(nil)
+
+type boltKV struct {
+	path string
+	db   *bbolt.DB
+}
+
+func (b *boltKV) Close() error {
+	return b.db.Close()
+}
+
+func (b *boltKV) Get(key []byte) ([]byte, error) {
+	var value []byte
+
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		buf := tx.Bucket(bucketFor(key)).Get(key)
+		if buf == nil {
+			return errors.New("key not found")
+		}
+
+		value = append([]byte{}, buf...)
+
+		return nil
+	})
+
+	return value, err
+}
+
+func (b *boltKV) MultiGet(keys ...[]byte) ([][]byte, error) {
+	var bufs = make([][]byte, len(keys))
+
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		for i, key := range keys {
+			buf := tx.Bucket(bucketFor(key)).Get(key)
+			if buf == nil {
+				return errors.New("key not found")
+			}
+
+			bufs[i] = append([]byte{}, buf...)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return bufs, nil
+}
+
+func (b *boltKV) Put(key, value []byte) error {
+	if len(key) == 0 {
+		return errors.New("bolt: key must not be empty")
+	}
+
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(bucketFor(key)).Put(key, value)
+	})
+}
+
+// Scan iterates over keys under the bucket prefix maps to. An empty prefix
+// spans all buckets, whose keys are merged such that they are visited in
+// ascending order.
+func (b *boltKV) Scan(prefix []byte, fn func(key, value []byte) bool) error {
+	return b.db.View(func(tx *bbolt.Tx) error {
+		if bucket := bucketFor(prefix); bucket != nil {
+			c := tx.Bucket(bucket).Cursor()
+
+			for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+				if !fn(k, v) {
+					break
+				}
+			}
+
+			return nil
+		}
+
+		a, m := tx.Bucket(BucketAccounts).Cursor(), tx.Bucket(BucketMeta).Cursor()
+
+		ak, av := a.First()
+		mk, mv := m.First()
+
+		for ak != nil || mk != nil {
+			if mk == nil || (ak != nil && bytes.Compare(ak, mk) < 0) {
+				if !fn(ak, av) {
+					break
+				}
+
+				ak, av = a.Next()
+			} else {
+				if !fn(mk, mv) {
+					break
+				}
+
+				mk, mv = m.Next()
+			}
+		}
+
+		return nil
+	})
+}
+
+func (b *boltKV) NewWriteBatch() WriteBatch {
+	return &boltWriteBatch{}
+}
+
+func (b *boltKV) CommitWriteBatch(batch WriteBatch) error {
+	wb, ok := batch.(*boltWriteBatch)
+	if !ok {
+		return errors.New("bolt: not fed in a proper bolt write batch")
+	}
+
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		for _, pair := range wb.pairs {
+			if len(pair.key) == 0 {
+				return errors.New("bolt: key must not be empty")
+			}
+
+			if err := tx.Bucket(bucketFor(pair.key)).Put(pair.key, pair.value); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (b *boltKV) Delete(key []byte) error {
+	if len(key) == 0 {
+		return nil
+	}
+
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(bucketFor(key)).Delete(key)
+	})
+}
+
+// NewBolt opens, or creates, a single-file BoltDB database located at path.
+func NewBolt(path string) (*boltKV, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, errors.Wrap(err, "failed to create directory for Bolt DB")
+	}
+
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create Bolt DB")
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		for _, bucket := range [][]byte{BucketAccounts, BucketMeta} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		_ = db.Close()
+		return nil, errors.Wrap(err, "failed to create Bolt DB buckets")
+	}
+
+	return &boltKV{
+		path: path,
+		db:   db,
+	}, nil
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package store
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestBolt(t *testing.T) (*boltKV, func()) {
+	dir, err := ioutil.TempDir("", "bolt")
+	assert.NoError(t, err)
+
+	db, err := NewBolt(filepath.Join(dir, "wavelet.db"))
+	assert.NoError(t, err)
+
+	return db, func() {
+		_ = db.Close()
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func TestBoltExistence(t *testing.T) {
+	db, cleanup := newTestBolt(t)
+	defer cleanup()
+
+	_, err := db.Get([]byte("not_exist"))
+	assert.Error(t, err)
+
+	assert.NoError(t, db.Put([]byte("exist"), []byte{}))
+
+	val, err := db.Get([]byte("exist"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{}, val)
+
+	assert.NoError(t, db.Delete([]byte("exist")))
+
+	_, err = db.Get([]byte("exist"))
+	assert.Error(t, err)
+}
+
+func TestBoltBuckets(t *testing.T) {
+	db, cleanup := newTestBolt(t)
+	defer cleanup()
+
+	batch := db.NewWriteBatch()
+	batch.Put([]byte("@1:node"), []byte("node"))
+	batch.Put([]byte(".root"), []byte("root"))
+	batch.Put([]byte{0x10, '1'}, []byte("round"))
+	assert.Equal(t, 3, batch.Count())
+	assert.NoError(t, db.CommitWriteBatch(batch))
+
+	values, err := db.MultiGet([]byte("@1:node"), []byte(".root"), []byte{0x10, '1'})
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("node"), []byte("root"), []byte("round")}, values)
+
+	var keys []string
+
+	assert.NoError(t, db.Scan([]byte("@"), func(key, value []byte) bool {
+		keys = append(keys, string(key))
+		return true
+	}))
+
+	assert.Equal(t, []string{"@1:node"}, keys)
+
+	keys = keys[:0]
+
+	assert.NoError(t, db.Scan(nil, func(key, value []byte) bool {
+		keys = append(keys, string(key))
+		return true
+	}))
+
+	assert.Equal(t, []string{"\x101", ".root", "@1:node"}, keys)
+
+	assert.Error(t, db.CommitWriteBatch(NewInmem().NewWriteBatch()))
+}
+
+func TestBoltReopen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bolt")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "wavelet.db")
+
+	db, err := New(BackendBolt, path)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Put([]byte("a"), []byte("1")))
+	assert.NoError(t, db.Close())
+
+	db, err = New(BackendBolt, path)
+	assert.NoError(t, err)
+	defer db.Close()
+
+	val, err := db.Get([]byte("a"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("1"), val)
+
+	_, err = New("unknown", path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,340 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package store
+
+import (
+	"container/list"
+	"github.com/pkg/errors"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	DefaultCacheSize           = 4096
+	DefaultCacheFlushThreshold = 256
+)
+
+type CacheOption func(*Cache)
+
+// WithCacheSize sets the maximum number of entries held in the cache.
+func WithCacheSize(size int) CacheOption {
+	return func(c *Cache) {
+		c.size = size
+	}
+}
+
+// WithCacheFlushThreshold sets the number of pending writes after which they
+// are flushed to the backing store. A threshold of zero or less has all
+// writes go straight through to the backing store.
+func WithCacheFlushThreshold(threshold int) CacheOption {
+	return func(c *Cache) {
+		c.threshold = threshold
+	}
+}
+
+var _ KV = (*Cache)(nil)
+
+// Cache is a read-through, write-behind LRU cache layered over a KV store.
+//
+// Writes are buffered in memory until either the number of pending writes
+// exceeds the configured flush threshold, Flush is called, or the cache is
+// closed. Deletions, write batches and scans always flush pending writes
+// first, and hence observe a consistent view of the backing store.
+//
+// Keys and values are copied both as they are put into and as they are read
+// from the cache, such that callers may freely reuse their buffers.
+type Cache struct {
+	sync.Mutex
+
+	kv KV
+
+	size      int
+	threshold int
+
+	entries map[string]*list.Element
+	order   *list.List
+	dirty   map[string][]byte
+
+	hits   uint64
+	misses uint64
+}
+
+type cacheEntry struct {
+	key   string
+	value []byte
+}
+
+func NewCache(kv KV, opts ...CacheOption) *Cache {
+	c := &Cache{
+		kv: kv,
+
+		size:      DefaultCacheSize,
+		threshold: DefaultCacheFlushThreshold,
+
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		dirty:   make(map[string][]byte),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Hits returns the number of reads served from the cache.
+func (c *Cache) Hits() uint64 {
+	return atomic.LoadUint64(&c.hits)
+}
+
+// Misses returns the number of reads which had to be served by the backing
+// store.
+func (c *Cache) Misses() uint64 {
+	return atomic.LoadUint64(&c.misses)
+}
+
+func (c *Cache) Get(key []byte) ([]byte, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	buf, err := c.get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte{}, buf...), nil
+}
+
+func (c *Cache) MultiGet(keys ...[]byte) ([][]byte, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	bufs := make([][]byte, 0, len(keys))
+
+	for _, key := range keys {
+		buf, err := c.get(key)
+		if err != nil {
+			return nil, err
+		}
+
+		bufs = append(bufs, append([]byte{}, buf...))
+	}
+
+	return bufs, nil
+}
+
+func (c *Cache) Put(key, value []byte) error {
+	value = append([]byte{}, value...)
+
+	c.Lock()
+	defer c.Unlock()
+
+	if c.threshold <= 0 {
+		if err := c.kv.Put(key, value); err != nil {
+			return err
+		}
+
+		c.insert(string(key), value)
+		return nil
+	}
+
+	c.insert(string(key), value)
+	c.dirty[string(key)] = value
+
+	if len(c.dirty) >= c.threshold {
+		return c.flush()
+	}
+
+	return nil
+}
+
+func (c *Cache) Scan(prefix []byte, fn func(key, value []byte) bool) error {
+	c.Lock()
+	err := c.flush()
+	c.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	return c.kv.Scan(prefix, fn)
+}
+
+var _ WriteBatch = (*cacheWriteBatch)(nil)
+
+type cacheWriteBatch struct {
+	WriteBatch
+	pairs []kvPair
+}
+
+func (b *cacheWriteBatch) Put(key, value []byte) {
+	b.WriteBatch.Put(key, value)
+	b.pairs = append(b.pairs, kvPair{key: append([]byte{}, key...), value: append([]byte{}, value...)})
+}
+
+func (b *cacheWriteBatch) Clear() {
+	b.WriteBatch.Clear()
+	b.pairs = b.pairs[:0]
+}
+
+func (b *cacheWriteBatch) Destroy() {
+	b.WriteBatch.Destroy()
+	b.pairs = nil
+}
+
+func (c *Cache) NewWriteBatch() WriteBatch {
+	return &cacheWriteBatch{WriteBatch: c.kv.NewWriteBatch()}
+}
+
+// CommitWriteBatch flushes all pending writes, and then writes batch through
+// to both the backing store and the cache.
+func (c *Cache) CommitWriteBatch(batch WriteBatch) error {
+	wb, ok := batch.(*cacheWriteBatch)
+	if !ok {
+		return errors.New("cache: not fed in a proper cache write batch")
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	if err := c.flush(); err != nil {
+		return err
+	}
+
+	if err := c.kv.CommitWriteBatch(wb.WriteBatch); err != nil {
+		return err
+	}
+
+	for _, pair := range wb.pairs {
+		c.insert(string(pair.key), pair.value)
+	}
+
+	return nil
+}
+
+func (c *Cache) Delete(key []byte) error {
+	c.Lock()
+	defer c.Unlock()
+
+	delete(c.dirty, string(key))
+	c.evict(string(key))
+
+	return c.kv.Delete(key)
+}
+
+// Flush writes all pending writes to the backing store.
+func (c *Cache) Flush() error {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.flush()
+}
+
+// Close flushes all pending writes, and closes the backing store.
+func (c *Cache) Close() error {
+	c.Lock()
+	defer c.Unlock()
+
+	if err := c.flush(); err != nil {
+		return err
+	}
+
+	c.purge()
+
+	return c.kv.Close()
+}
+
+func (c *Cache) get(key []byte) ([]byte, error) {
+	if value, pending := c.dirty[string(key)]; pending {
+		atomic.AddUint64(&c.hits, 1)
+		return value, nil
+	}
+
+	if elem, exists := c.entries[string(key)]; exists {
+		atomic.AddUint64(&c.hits, 1)
+
+		c.order.MoveToFront(elem)
+		return elem.Value.(*cacheEntry).value, nil
+	}
+
+	atomic.AddUint64(&c.misses, 1)
+
+	buf, err := c.kv.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	c.insert(string(key), buf)
+
+	return buf, nil
+}
+
+func (c *Cache) insert(key string, value []byte) {
+	if c.size <= 0 {
+		return
+	}
+
+	if elem, exists := c.entries[key]; exists {
+		elem.Value.(*cacheEntry).value = value
+		c.order.MoveToFront(elem)
+
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, value: value})
+
+	for c.order.Len() > c.size {
+		c.evict(c.order.Back().Value.(*cacheEntry).key)
+	}
+}
+
+func (c *Cache) evict(key string) {
+	if elem, exists := c.entries[key]; exists {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+	}
+}
+
+func (c *Cache) purge() {
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+}
+
+func (c *Cache) flush() error {
+	if len(c.dirty) == 0 {
+		return nil
+	}
+
+	batch := c.kv.NewWriteBatch()
+	defer batch.Destroy()
+
+	for key, value := range c.dirty {
+		batch.Put([]byte(key), value)
+	}
+
+	if err := c.kv.CommitWriteBatch(batch); err != nil {
+		return errors.Wrap(err, "cache: failed to flush pending writes")
+	}
+
+	c.dirty = make(map[string][]byte)
+
+	return nil
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package store
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func TestCacheReadThrough(t *testing.T) {
+	backing := NewInmem()
+	assert.NoError(t, backing.Put([]byte("a"), []byte("1")))
+
+	cache := NewCache(backing, WithCacheSize(2))
+	defer cache.Close()
+
+	_, err := cache.Get([]byte("not_exist"))
+	assert.Error(t, err)
+
+	for i := 0; i < 3; i++ {
+		val, err := cache.Get([]byte("a"))
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("1"), val)
+	}
+
+	assert.EqualValues(t, 2, cache.Hits())
+	assert.EqualValues(t, 2, cache.Misses())
+
+	// Exceeding the size of the cache should evict the least recently used entry.
+	assert.NoError(t, backing.Put([]byte("b"), []byte("2")))
+	assert.NoError(t, backing.Put([]byte("c"), []byte("3")))
+
+	vals, err := cache.MultiGet([]byte("b"), []byte("c"))
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("2"), []byte("3")}, vals)
+
+	_, err = cache.Get([]byte("a"))
+	assert.NoError(t, err)
+
+	assert.EqualValues(t, 2, cache.Hits())
+	assert.EqualValues(t, 5, cache.Misses())
+}
+
+func TestCacheWriteBehind(t *testing.T) {
+	backing := NewInmem()
+
+	cache := NewCache(backing, WithCacheSize(1), WithCacheFlushThreshold(3))
+
+	assert.NoError(t, cache.Put([]byte("a"), []byte("1")))
+	assert.NoError(t, cache.Put([]byte("b"), []byte("2")))
+
+	_, err := backing.Get([]byte("a"))
+	assert.Error(t, err)
+
+	// Pending writes should be readable despite having been evicted.
+	val, err := cache.Get([]byte("a"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("1"), val)
+
+	assert.NoError(t, cache.Put([]byte("c"), []byte("3")))
+
+	vals, err := backing.MultiGet([]byte("a"), []byte("b"), []byte("c"))
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("1"), []byte("2"), []byte("3")}, vals)
+
+	assert.NoError(t, cache.Put([]byte("d"), []byte("4")))
+	assert.NoError(t, cache.Delete([]byte("d")))
+
+	assert.NoError(t, cache.Put([]byte("e"), []byte("5")))
+
+	var keys []string
+
+	assert.NoError(t, cache.Scan(nil, func(key, value []byte) bool {
+		keys = append(keys, string(key))
+		return true
+	}))
+
+	assert.Equal(t, []string{"a", "b", "c", "e"}, keys)
+}
+
+func TestCacheWriteBatch(t *testing.T) {
+	path := "level_cache"
+	_ = os.RemoveAll(path)
+
+	db, err := NewLevelDB(path)
+	assert.NoError(t, err)
+	defer os.RemoveAll(path)
+
+	cache := NewCache(db)
+
+	assert.NoError(t, cache.Put([]byte("exist"), []byte("value")))
+
+	wb := cache.NewWriteBatch()
+	wb.Put([]byte("key_batch1"), []byte("val_batch1"))
+	wb.Put([]byte("key_batch2"), []byte("val_batch2"))
+	assert.NoError(t, cache.CommitWriteBatch(wb))
+
+	assert.Error(t, cache.CommitWriteBatch(db.NewWriteBatch()))
+
+	vals, err := cache.MultiGet([]byte("exist"), []byte("key_batch1"), []byte("key_batch2"))
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("value"), []byte("val_batch1"), []byte("val_batch2")}, vals)
+	assert.EqualValues(t, 0, cache.Misses())
+
+	assert.NoError(t, cache.Close())
+
+	db, err = NewLevelDB(path)
+	assert.NoError(t, err)
+	defer db.Close()
+
+	val, err := db.Get([]byte("exist"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("value"), val)
+}
+
+func TestCacheCopiesValues(t *testing.T) {
+	cache := NewCache(NewInmem(), WithCacheFlushThreshold(8))
+	defer cache.Close()
+
+	value := []byte("1")
+	assert.NoError(t, cache.Put([]byte("a"), value))
+
+	// Reusing the buffer which was put should not modify the cached value.
+	value[0] = '2'
+
+	val, err := cache.Get([]byte("a"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("1"), val)
+
+	// Nor should modifying the value which was read.
+	val[0] = '3'
+
+	vals, err := cache.MultiGet([]byte("a"))
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("1")}, vals)
+
+	vals[0][0] = '4'
+
+	assert.NoError(t, cache.Flush())
+
+	val, err = cache.Get([]byte("a"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("1"), val)
+}
@@ -103,6 +103,29 @@ func (s *inmemKV) Put(key, value []byte) error {
 	return nil
 }
 
+func (s *inmemKV) Scan(prefix []byte, fn func(key, value []byte) bool) error {
+	s.RLock()
+	defer s.RUnlock()
+
+	for elem := s.db.Front(); elem != nil; elem = elem.Next() {
+		key := elem.Key().([]byte)
+
+		if !bytes.HasPrefix(key, prefix) {
+			if bytes.Compare(key, prefix) > 0 {
+				break
+			}
+
+			continue
+		}
+
+		if !fn(key, elem.Value.([]byte)) {
+			break
+		}
+	}
+
+	return nil
+}
+
 var (
 	writeBatchPool = sync.Pool{
 		New: func() interface{} {
@@ -124,7 +147,11 @@ func (s *inmemKV) CommitWriteBatch(batch WriteBatch) error {
 			_ = s.db.Set(pair.key, pair.value)
 		}
 
+		// Batches are shared across stores through the pool, and must
+		// therefore be emptied before being handed out again.
+		wb.Clear()
 		writeBatchPool.Put(wb)
+
 		return nil
 	}
 
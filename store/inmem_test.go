@@ -71,3 +71,35 @@ func TestExistence(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, []byte{}, val)
 }
+
+func TestScan(t *testing.T) {
+	db := NewInmem()
+	defer func() {
+		_ = db.Close()
+	}()
+
+	assert.NoError(t, db.Put([]byte("a"), []byte("0")))
+	assert.NoError(t, db.Put([]byte("prefix_2"), []byte("2")))
+	assert.NoError(t, db.Put([]byte("prefix_1"), []byte("1")))
+	assert.NoError(t, db.Put([]byte("z"), []byte("3")))
+
+	var keys, values []string
+
+	assert.NoError(t, db.Scan([]byte("prefix_"), func(key, value []byte) bool {
+		keys = append(keys, string(key))
+		values = append(values, string(value))
+		return true
+	}))
+
+	assert.Equal(t, []string{"prefix_1", "prefix_2"}, keys)
+	assert.Equal(t, []string{"1", "2"}, values)
+
+	count := 0
+
+	assert.NoError(t, db.Scan(nil, func(key, value []byte) bool {
+		count++
+		return count < 2
+	}))
+
+	assert.Equal(t, 2, count)
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/filter"
 	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 var _ WriteBatch = (*leveldbWriteBatch)(nil)
@@ -82,6 +83,19 @@ func (l *leveldbKV) Put(key, value []byte) error {
 	return l.db.Put(key, value, nil)
 }
 
+func (l *leveldbKV) Scan(prefix []byte, fn func(key, value []byte) bool) error {
+	iter := l.db.NewIterator(util.BytesPrefix(prefix), nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		if !fn(iter.Key(), iter.Value()) {
+			break
+		}
+	}
+
+	return iter.Error()
+}
+
 func (l *leveldbKV) NewWriteBatch() WriteBatch {
 	return &leveldbWriteBatch{
 		batch: &leveldb.Batch{},
@@ -116,3 +116,27 @@ func TestLevelDB(t *testing.T) {
 	_, err = db2.Get([]byte("exist"))
 	assert.Error(t, err)
 }
+
+func TestLevelDBScan(t *testing.T) {
+	path := "level"
+	_ = os.RemoveAll(path)
+
+	db, err := NewLevelDB(path)
+	assert.NoError(t, err)
+	defer os.RemoveAll(path)
+	defer db.Close()
+
+	assert.NoError(t, db.Put([]byte("a"), []byte("0")))
+	assert.NoError(t, db.Put([]byte("prefix_2"), []byte("2")))
+	assert.NoError(t, db.Put([]byte("prefix_1"), []byte("1")))
+	assert.NoError(t, db.Put([]byte("z"), []byte("3")))
+
+	var keys []string
+
+	assert.NoError(t, db.Scan([]byte("prefix_"), func(key, value []byte) bool {
+		keys = append(keys, string(key))
+		return true
+	}))
+
+	assert.Equal(t, []string{"prefix_1", "prefix_2"}, keys)
+}
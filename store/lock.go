@@ -0,0 +1,122 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package store
+
+import (
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrLocked is returned by Lock should the database be locked by another
+// process.
+var ErrLocked = errors.New("database is locked by another process")
+
+// FileLock is an exclusive lock held on a database for as long as a process
+// has it open.
+type FileLock struct {
+	file *os.File
+}
+
+// Lock acquires an exclusive lock on the database located at path through a
+// lock file placed alongside it, such that no two nodes may open the same
+// database at once. The process ID of the holder of the lock is written to
+// the lock file. The lock is released once the process exits, even should it
+// crash, or when Release is called.
+func Lock(path string) (*FileLock, error) {
+	name := filepath.Clean(path) + ".lock"
+
+	file, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open lock file %q", name)
+	}
+
+	if err := lockFile(file); err != nil {
+		_ = file.Close()
+
+		if err != ErrLocked {
+			return nil, errors.Wrapf(err, "failed to lock %q", name)
+		}
+
+		if pid := lockHolder(name); pid != 0 {
+			return nil, errors.Wrapf(ErrLocked, "%q is held by process %d", name, pid)
+		}
+
+		return nil, errors.Wrapf(ErrLocked, "%q is held", name)
+	}
+
+	if err := file.Truncate(0); err != nil {
+		_ = file.Close()
+		return nil, errors.Wrapf(err, "failed to truncate lock file %q", name)
+	}
+
+	if _, err := file.WriteString(strconv.Itoa(os.Getpid())); err != nil {
+		_ = file.Close()
+		return nil, errors.Wrapf(err, "failed to write to lock file %q", name)
+	}
+
+	return &FileLock{file: file}, nil
+}
+
+// Release releases the lock. The lock file itself is left in place, as
+// removing it would race against another process acquiring the lock.
+func (l *FileLock) Release() error {
+	return l.file.Close()
+}
+
+func lockHolder(name string) int {
+	buf, err := ioutil.ReadFile(name)
+	if err != nil {
+		return 0
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(buf)))
+	if err != nil {
+		return 0
+	}
+
+	return pid
+}
+
+// FreeSpace returns the number of bytes available to the process on the
+// filesystem the database located at path is, or is to be, placed on.
+func FreeSpace(path string) (uint64, error) {
+	dir := filepath.Clean(path)
+
+	// The database may not have been created yet, in which case the space
+	// available is that of the closest directory which exists.
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			break
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+
+		dir = parent
+	}
+
+	return freeSpace(dir)
+}
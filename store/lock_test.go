@@ -0,0 +1,69 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package store
+
+import (
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lock")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "db")
+
+	lock, err := Lock(path)
+	assert.NoError(t, err)
+
+	buf, err := ioutil.ReadFile(path + ".lock")
+	assert.NoError(t, err)
+	assert.Equal(t, strconv.Itoa(os.Getpid()), string(buf))
+
+	_, err = Lock(path)
+	assert.Equal(t, ErrLocked, errors.Cause(err))
+	assert.Contains(t, err.Error(), strconv.Itoa(os.Getpid()))
+
+	assert.NoError(t, lock.Release())
+
+	lock, err = Lock(path)
+	assert.NoError(t, err)
+	assert.NoError(t, lock.Release())
+}
+
+func TestFreeSpace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "space")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	available, err := FreeSpace(dir)
+	assert.NoError(t, err)
+	assert.NotZero(t, available)
+
+	nested, err := FreeSpace(filepath.Join(dir, "does", "not", "exist"))
+	assert.NoError(t, err)
+	assert.InDelta(t, available, nested, float64(available)/10)
+}
@@ -0,0 +1,211 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package store
+
+import (
+	"expvar"
+	"github.com/pkg/errors"
+	"github.com/rcrowley/go-metrics"
+	"time"
+)
+
+var _ KV = (*Instrumented)(nil)
+
+// Instrumented is a KV store which records the number of operations, the
+// number of bytes read and written, and the latency of all operations made to
+// an underlying KV store into a metrics registry.
+type Instrumented struct {
+	kv KV
+
+	registry metrics.Registry
+
+	gets      metrics.Timer
+	multiGets metrics.Timer
+	puts      metrics.Timer
+	deletes   metrics.Timer
+	scans     metrics.Timer
+	commits   metrics.Timer
+
+	bytesRead    metrics.Meter
+	bytesWritten metrics.Meter
+
+	// errors counts all failed operations, including lookups of keys which
+	// do not exist.
+	errors metrics.Counter
+}
+
+func NewInstrumented(kv KV, registry metrics.Registry) *Instrumented {
+	return &Instrumented{
+		kv: kv,
+
+		registry: registry,
+
+		gets:      metrics.NewRegisteredTimer("store.get", registry),
+		multiGets: metrics.NewRegisteredTimer("store.multiget", registry),
+		puts:      metrics.NewRegisteredTimer("store.put", registry),
+		deletes:   metrics.NewRegisteredTimer("store.delete", registry),
+		scans:     metrics.NewRegisteredTimer("store.scan", registry),
+		commits:   metrics.NewRegisteredTimer("store.batch.commit", registry),
+
+		bytesRead:    metrics.NewRegisteredMeter("store.bytes.read", registry),
+		bytesWritten: metrics.NewRegisteredMeter("store.bytes.written", registry),
+
+		errors: metrics.NewRegisteredCounter("store.errors", registry),
+	}
+}
+
+// Registry returns the registry all metrics of the store are recorded into.
+func (s *Instrumented) Registry() metrics.Registry {
+	return s.registry
+}
+
+// Publish exports a snapshot of all metrics of the store through expvar under
+// name. It panics if name is already in use.
+func (s *Instrumented) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return s.registry.GetAll()
+	}))
+}
+
+func (s *Instrumented) observe(err error) {
+	if err != nil {
+		s.errors.Inc(1)
+	}
+}
+
+func (s *Instrumented) Get(key []byte) ([]byte, error) {
+	start := time.Now()
+	value, err := s.kv.Get(key)
+	s.gets.UpdateSince(start)
+
+	s.bytesRead.Mark(int64(len(value)))
+	s.observe(err)
+
+	return value, err
+}
+
+func (s *Instrumented) MultiGet(keys ...[]byte) ([][]byte, error) {
+	start := time.Now()
+	values, err := s.kv.MultiGet(keys...)
+	s.multiGets.UpdateSince(start)
+
+	for _, value := range values {
+		s.bytesRead.Mark(int64(len(value)))
+	}
+
+	s.observe(err)
+
+	return values, err
+}
+
+func (s *Instrumented) Put(key, value []byte) error {
+	start := time.Now()
+	err := s.kv.Put(key, value)
+	s.puts.UpdateSince(start)
+
+	if err == nil {
+		s.bytesWritten.Mark(int64(len(key) + len(value)))
+	}
+
+	s.observe(err)
+
+	return err
+}
+
+func (s *Instrumented) Scan(prefix []byte, fn func(key, value []byte) bool) error {
+	start := time.Now()
+
+	var read int64
+
+	err := s.kv.Scan(prefix, func(key, value []byte) bool {
+		read += int64(len(value))
+		return fn(key, value)
+	})
+
+	s.scans.UpdateSince(start)
+	s.bytesRead.Mark(read)
+	s.observe(err)
+
+	return err
+}
+
+func (s *Instrumented) Delete(key []byte) error {
+	start := time.Now()
+	err := s.kv.Delete(key)
+	s.deletes.UpdateSince(start)
+
+	s.observe(err)
+
+	return err
+}
+
+var _ WriteBatch = (*instrumentedWriteBatch)(nil)
+
+type instrumentedWriteBatch struct {
+	WriteBatch
+	size int64
+}
+
+func (b *instrumentedWriteBatch) Put(key, value []byte) {
+	b.WriteBatch.Put(key, value)
+	b.size += int64(len(key) + len(value))
+}
+
+func (b *instrumentedWriteBatch) Clear() {
+	b.WriteBatch.Clear()
+	b.size = 0
+}
+
+func (s *Instrumented) NewWriteBatch() WriteBatch {
+	return &instrumentedWriteBatch{WriteBatch: s.kv.NewWriteBatch()}
+}
+
+func (s *Instrumented) CommitWriteBatch(batch WriteBatch) error {
+	wb, ok := batch.(*instrumentedWriteBatch)
+	if !ok {
+		return errors.New("instrumented: not fed in a proper instrumented write batch")
+	}
+
+	start := time.Now()
+	err := s.kv.CommitWriteBatch(wb.WriteBatch)
+	s.commits.UpdateSince(start)
+
+	if err == nil {
+		s.bytesWritten.Mark(wb.size)
+	}
+
+	s.observe(err)
+
+	return err
+}
+
+func (s *Instrumented) Close() error {
+	s.gets.Stop()
+	s.multiGets.Stop()
+	s.puts.Stop()
+	s.deletes.Stop()
+	s.scans.Stop()
+	s.commits.Stop()
+
+	s.bytesRead.Stop()
+	s.bytesWritten.Stop()
+
+	return s.kv.Close()
+}
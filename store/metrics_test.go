@@ -0,0 +1,59 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package store
+
+import (
+	"github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestInstrumented(t *testing.T) {
+	registry := metrics.NewRegistry()
+
+	kv := NewInstrumented(NewInmem(), registry)
+	defer kv.Close()
+
+	assert.NoError(t, kv.Put([]byte("a"), []byte("1234")))
+
+	batch := kv.NewWriteBatch()
+	batch.Put([]byte("b"), []byte("56"))
+	assert.NoError(t, kv.CommitWriteBatch(batch))
+
+	value, err := kv.Get([]byte("a"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("1234"), value)
+
+	_, err = kv.Get([]byte("missing"))
+	assert.Error(t, err)
+
+	assert.NoError(t, kv.Scan([]byte("b"), func(key, value []byte) bool { return true }))
+
+	assert.EqualValues(t, 1, registry.Get("store.put").(metrics.Timer).Count())
+	assert.EqualValues(t, 1, registry.Get("store.batch.commit").(metrics.Timer).Count())
+	assert.EqualValues(t, 2, registry.Get("store.get").(metrics.Timer).Count())
+	assert.EqualValues(t, 1, registry.Get("store.scan").(metrics.Timer).Count())
+
+	assert.EqualValues(t, 8, registry.Get("store.bytes.written").(metrics.Meter).Count())
+	assert.EqualValues(t, 6, registry.Get("store.bytes.read").(metrics.Meter).Count())
+	assert.EqualValues(t, 1, registry.Get("store.errors").(metrics.Counter).Count())
+
+	assert.Error(t, kv.CommitWriteBatch(NewInmem().NewWriteBatch()))
+}
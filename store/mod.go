@@ -20,9 +20,17 @@
 package store
 
 import (
+	"github.com/pkg/errors"
 	"io"
 )
 
+// Backends which KV stores may be opened with through New.
+const (
+	BackendInmem   = "inmem"
+	BackendLevelDB = "leveldb"
+	BackendBolt    = "bolt"
+)
+
 type KV interface {
 	io.Closer
 
@@ -31,6 +39,11 @@ type KV interface {
 
 	Put(key, value []byte) error
 
+	// Scan iterates in ascending key order over all key-value pairs whose key
+	// is prefixed with prefix, until fn returns false. The key and value passed
+	// to fn must not be modified, nor retained after fn returns.
+	Scan(prefix []byte, fn func(key, value []byte) bool) error
+
 	NewWriteBatch() WriteBatch
 	CommitWriteBatch(batch WriteBatch) error
 
@@ -44,3 +57,18 @@ type WriteBatch interface {
 	Count() int
 	Destroy()
 }
+
+// New opens, or creates, a KV store located at path using the named backend.
+// The path of an in-memory store is ignored.
+func New(backend, path string) (KV, error) {
+	switch backend {
+	case BackendInmem:
+		return NewInmem(), nil
+	case BackendLevelDB:
+		return NewLevelDB(path)
+	case BackendBolt:
+		return NewBolt(path)
+	default:
+		return nil, errors.Errorf("unknown database backend %q", backend)
+	}
+}
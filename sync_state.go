@@ -0,0 +1,79 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"bytes"
+	"context"
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/pkg/errors"
+)
+
+// StateValue is a value stored under a key in the state of the ledger, which
+// has been proven to be stored in, or absent from, the state of a round.
+type StateValue struct {
+	Key    []byte
+	Value  []byte
+	Exists bool
+}
+
+// QueryState fetches the values stored under keys in the latest state of a
+// peer, and verifies them against the Merkle root of the latest round of the
+// peer. It is up to the caller to decide whether or not the round returned is
+// to be trusted.
+//
+// Values which did not fit in the response of the peer are omitted from the
+// end of the values returned, and are to be queried for again.
+func QueryState(ctx context.Context, client WaveletClient, keys ...[]byte) (Round, []StateValue, error) {
+	res, err := client.SyncState(ctx, &SyncStateRequest{Keys: keys})
+	if err != nil {
+		return Round{}, nil, errors.Wrap(err, "failed to query peer for state")
+	}
+
+	return verifyStateResponse(res, keys)
+}
+
+func verifyStateResponse(res *SyncStateResponse, keys [][]byte) (Round, []StateValue, error) {
+	round, err := UnmarshalRound(bytes.NewReader(res.Round))
+	if err != nil {
+		return Round{}, nil, errors.Wrap(err, "peer responded with an invalid round")
+	}
+
+	if len(res.Entries) > len(keys) {
+		return Round{}, nil, errors.Errorf("queried for %d keys, but peer responded with %d entries", len(keys), len(res.Entries))
+	}
+
+	values := make([]StateValue, 0, len(res.Entries))
+
+	for i, entry := range res.Entries {
+		if !bytes.Equal(entry.Key, keys[i]) {
+			return Round{}, nil, errors.Errorf("expected entry %d to be for key %x, but it is for key %x", i, keys[i], entry.Key)
+		}
+
+		value, exists, err := avl.VerifyProof(round.Merkle, entry.Key, entry.Proof)
+		if err != nil {
+			return Round{}, nil, errors.Wrapf(err, "peer responded with an invalid proof for key %x", entry.Key)
+		}
+
+		values = append(values, StateValue{Key: entry.Key, Value: value, Exists: exists})
+	}
+
+	return round, values, nil
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"context"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSyncState(t *testing.T) {
+	ledger := newTestLedger(t)
+
+	var account, missing AccountID
+	account[0] = 1
+	missing[0] = 2
+
+	// Advance the ledger by a round.
+	snapshot := ledger.Snapshot()
+	snapshot.SetViewID(1)
+	WriteAccountBalance(snapshot, account, 1337)
+
+	latest := ledger.Rounds().Latest()
+	round := NewRound(1, snapshot.Checksum(), 0, latest.End, latest.End)
+
+	_, err := ledger.rounds.Save(&round)
+	assert.NoError(t, err)
+	assert.NoError(t, ledger.accounts.Commit(snapshot))
+
+	keys := [][]byte{AccountBalanceKey(account), AccountBalanceKey(missing)}
+
+	res, err := ledger.Protocol().SyncState(context.Background(), &SyncStateRequest{Keys: keys})
+	assert.NoError(t, err)
+
+	proven, values, err := verifyStateResponse(res, keys)
+	assert.NoError(t, err)
+	assert.Equal(t, round.ID, proven.ID)
+
+	if assert.Len(t, values, 2) {
+		assert.True(t, values[0].Exists)
+		assert.EqualValues(t, 1337, binary.LittleEndian.Uint64(values[0].Value))
+
+		assert.False(t, values[1].Exists)
+	}
+
+	// Responses for keys other than those queried for are rejected.
+	_, _, err = verifyStateResponse(res, [][]byte{AccountBalanceKey(missing), AccountBalanceKey(account)})
+	assert.Error(t, err)
+
+	_, _, err = verifyStateResponse(res, keys[:1])
+	assert.Error(t, err)
+
+	// Responses with proofs which do not match the round responded with are rejected.
+	res.Round = latest.Marshal()
+
+	_, _, err = verifyStateResponse(res, keys)
+	assert.Error(t, err)
+}
@@ -20,6 +20,7 @@
 package sys
 
 import (
+	"runtime"
 	"time"
 )
 
@@ -30,6 +31,12 @@ const (
 	TagContract
 	TagStake
 	TagBatch
+	TagContractAdmin
+	TagDelegate
+	TagDelegated
+	TagUpdateContract
+	TagCreateToken
+	TagTransferToken
 )
 
 const (
@@ -38,50 +45,133 @@ const (
 	WithdrawReward
 )
 
+// Contract administration opcodes.
+const (
+	PauseContract byte = iota
+	ResumeContract
+	TransferContractOwnership
+	SetContractCallQuota
+)
+
+// Delegation opcodes.
+const (
+	GrantDelegation byte = iota
+	RevokeDelegation
+)
+
 var (
 	// S/Kademlia overlay network parameters.
 	SKademliaC1 = 1
 	SKademliaC2 = 1
 
-	// Snowball consensus protocol parameters.
-	SnowballK     = 2
-	SnowballAlpha = 0.8
-	SnowballBeta  = 150
+	// Interval at which peers are pinged to measure their round-trip time and
+	// to check that they are still alive, the time they are given to respond,
+	// and the number of pings in a row they may fail to respond to before they
+	// are disconnected.
+	PingInterval   = 5 * time.Second
+	PingTimeout    = 3 * time.Second
+	MaxMissedPings = 3
 
-	// Timeout for querying a transaction to K peers.
-	QueryTimeout = 1 * time.Second
+	// Time after which the statistics recorded for a peer are evicted should
+	// no further statistics be recorded for it.
+	PeerStatsIdleTimeout = 5 * time.Minute
+
+	// Largest amount by which our clock may differ from the median of the
+	// clocks of our peers before the node warns that its clock is off.
+	MaxClockOffset = 10 * time.Second
+
+	// Disk space which must be available for the database for a node to start,
+	// and below which the node warns that it is running low on disk space.
+	MinFreeDiskSpace uint64 = 64 * 1024 * 1024
+	LowFreeDiskSpace uint64 = 1024 * 1024 * 1024
 
 	// Number of rounds we should be behind before we start syncing.
 	SyncIfRoundsDifferBy uint64 = 2
 
+	// Fraction of peers queried while syncing which must all report the same
+	// latest round for it to be adopted as the round to sync to. It must be
+	// greater than 1/2 for the round to be unique.
+	SyncQuorum = 2.0 / 3.0
+
+	// Max number of peers from a single /24 IPv4 or /48 IPv6 subnet which
+	// may be selected to be queried for consensus or syncing. Zero means
+	// no limit.
+	MaxPeersPerSubnet = 1
+
+	// Minimum fraction of peers selected to be queried for consensus or
+	// syncing which must be peers this node was explicitly instructed to dial.
+	MinOutboundPeerFraction = 0.0
+
+	// Whether or not to ask peers for, and answer peers with, machine-readable
+	// reasons for rejecting gossiped transactions.
+	GossipRejections = false
+
 	// Size of individual chunks sent for a syncing peer.
 	SyncChunkSize = 16384
 
-	// Max graph depth difference to search for eligible transaction
-	// parents from for our node.
-	MaxDepthDiff uint64 = 10
+	// Max number of recipients a smart contract may transfer PERLs to through
+	// a single call to send transfers to multiple recipients at once.
+	MaxContractTransferRecipients = 64
 
-	// Max number of parents referencable by a transaction.
-	MaxParentsPerTransaction = 32
+	// Limits of the events a smart contract may emit. Topics may be at most
+	// MaxContractEventTopicSize bytes, payloads at most
+	// MaxContractEventPayloadSize bytes, and a single invocation of a smart
+	// contract may emit at most MaxContractEventsPerCall events.
+	MaxContractEventTopicSize   = 64
+	MaxContractEventPayloadSize = 1024
+	MaxContractEventsPerCall    = 32
+
+	// Limits of fungible tokens issued on the ledger. Symbols may be at most
+	// MaxTokenSymbolLength bytes, and balances of tokens may be denominated in
+	// at most MaxTokenDecimals decimal places.
+	MaxTokenSymbolLength = 12
+	MaxTokenDecimals     = uint8(18)
+
+	// Name of the runtime smart contracts are executed with. The interpreter
+	// is the only runtime shipped. Runtimes registered by programs embedding
+	// the ledger are only used on platforms they support, and are otherwise
+	// fallen back from to the interpreter.
+	ContractRuntime = "interpreter"
 
-	// Minimum difficulty to define a critical transaction.
-	MinDifficulty byte = 8
+	// Number of workers transactions within a round are speculatively applied
+	// on in parallel. Transactions are applied one after another should it be
+	// at most 1.
+	ApplyWorkers = runtime.NumCPU()
 
-	// Factor to scale a transactions confidence down by to compute the difficulty needed to define a critical transaction.
-	DifficultyScaleFactor = 0.5
+	// Max number of parents referencable by a transaction.
+	MaxParentsPerTransaction = 32
 
-	// Fee amount paid by a node per transaction.
-	TransactionFeeAmount uint64 = 2
+	// Max size of a transactions payload in bytes. Transactions with larger
+	// payloads are rejected by all nodes.
+	MaxTransactionPayloadSize = 2 * 1024 * 1024
 
-	// Minimum amount of stake to start being able to reap validator rewards.
-	MinimumStake uint64 = 100
+	// Max size of a single message exchanged between peers in bytes. It must
+	// be large enough to fit a batch of gossiped transactions which includes a
+	// transaction with the largest permitted payload.
+	MaxMessageSize = 4 * 1024 * 1024
 
-	MinimumRewardWithdraw = MinimumStake
+	MinimumRewardWithdraw = params.MinimumStake
 
 	RewardWithdrawalsRoundLimit = 50
 
 	PruningLimit = uint8(30)
 
+	// Fraction of transactions whose lifecycle timings are sampled to measure
+	// end-to-end transaction latency.
+	TransactionLatencySampleRate = 0.1
+
+	// Interval at which a snapshot of the key metrics of the node is persisted,
+	// and the number of most recent snapshots kept.
+	MetricsHistoryInterval = 1 * time.Minute
+	MetricsHistorySize     = 1440
+
+	// Number of latest finalized rounds the changes made to accounts and the
+	// receipts of transactions are kept for by nodes other than archive nodes,
+	// and the interval at which those of older rounds are pruned away. Nothing
+	// is pruned should it be 0.
+	PruneHistoryAfter    = uint64(100000)
+	PruneHistoryInterval = 1 * time.Minute
+
 	FaucetAddress = "0f569c84d434fb0ca682c733176f7c0c2d853fce04d95ae131d2f9b4124d93d8"
 
 	GasTable = map[string]uint64{
@@ -264,5 +354,12 @@ var (
 		"wavelet.hash.sha256":         2500,  // TODO: Review
 		"wavelet.hash.sha512":         3000,  // TODO: Review
 		"wavelet.verify.ed25519":      50000, // TODO: Review
+		"wavelet.transfer.recipient":  1000,  // TODO: Review
+		"wavelet.random_beacon":       500,   // TODO: Review
+		"wavelet.random":              500,   // TODO: Review
+		"wavelet.event":               1000,  // TODO: Review
+		"wavelet.event.byte":          10,    // TODO: Review
+		"wavelet.balance":             500,   // TODO: Review
+		"wavelet.stake":               500,   // TODO: Review
 	}
 )
@@ -0,0 +1,139 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package sys
+
+import (
+	"sync"
+	"time"
+)
+
+// ConsensusParams are the parameters of consensus which may change while a
+// node is running, either by being tuned or by being overridden by a genesis
+// file. They must only be read through Params, and only be changed through
+// UpdateParams.
+type ConsensusParams struct {
+	// Snowball consensus protocol parameters. A preference must survive
+	// SnowballBeta rounds of Snowball for it to be finalized.
+	SnowballK     int
+	SnowballAlpha float64
+	SnowballBeta  int
+
+	// Timeout for querying a transaction to K peers.
+	QueryTimeout time.Duration
+
+	// Period between checks of whether we are behind the latest round of the
+	// network. The period shortens as peers agree on a round to sync to.
+	SyncPeriod time.Duration
+
+	// Max graph depth difference to search for eligible transaction
+	// parents from for our node.
+	MaxDepthDiff uint64
+
+	// Minimum difficulty to define a critical transaction.
+	MinDifficulty byte
+
+	// Factor to scale a transactions confidence down by to compute the difficulty needed to define a critical transaction.
+	DifficultyScaleFactor float64
+
+	// Fee amount paid by a node per transaction.
+	TransactionFeeAmount uint64
+
+	// Fee amount paid by a node per byte of a transaction, on top of TransactionFeeAmount.
+	TransactionFeePerByte uint64
+
+	// Whether or not transaction fees are burned, rather than rewarded to a validator.
+	BurnTransactionFees bool
+
+	// Minimum amount of stake to start being able to reap validator rewards.
+	MinimumStake uint64
+
+	// Max number of levels deep smart contracts may recursively queue up
+	// transactions on behalf of a single originating transaction.
+	MaxContractQueueDepth int
+
+	// Max number of transactions smart contracts may queue up in total on
+	// behalf of a single originating transaction.
+	MaxContractQueuedTransactions int
+
+	// Limits of the WebAssembly virtual machine smart contracts are executed
+	// in. Contracts are limited to a number of 64KiB memory pages, and to a
+	// number of entries in their table of indirectly callable functions. All
+	// nodes must agree on these limits, as a contract which exceeds them on
+	// one node but not on another leads to the ledger state diverging.
+	ContractDefaultMemoryPages int
+	ContractMaxMemoryPages     int
+	ContractDefaultTableSize   int
+	ContractMaxTableSize       int
+	ContractMaxValueSlots      int
+	ContractMaxCallStackDepth  int
+}
+
+var (
+	paramsLock sync.RWMutex
+	params     = ConsensusParams{
+		SnowballK:     2,
+		SnowballAlpha: 0.8,
+		SnowballBeta:  150,
+
+		QueryTimeout: 1 * time.Second,
+
+		SyncPeriod: 1500 * time.Millisecond,
+
+		MaxDepthDiff: 10,
+
+		MinDifficulty:         8,
+		DifficultyScaleFactor: 0.5,
+
+		TransactionFeeAmount:  2,
+		TransactionFeePerByte: 0,
+		BurnTransactionFees:   false,
+
+		MinimumStake: 100,
+
+		MaxContractQueueDepth:         8,
+		MaxContractQueuedTransactions: 256,
+
+		ContractDefaultMemoryPages: 4,
+		ContractMaxMemoryPages:     32,
+		ContractDefaultTableSize:   65536,
+		ContractMaxTableSize:       65536,
+		ContractMaxValueSlots:      4096,
+		ContractMaxCallStackDepth:  256,
+	}
+)
+
+// Params returns a copy of the current consensus parameters. Parameters which
+// must be consistent with one another, such as K and alpha, must be read from
+// the same copy.
+func Params() ConsensusParams {
+	paramsLock.RLock()
+	defer paramsLock.RUnlock()
+
+	return params
+}
+
+// UpdateParams atomically changes the current consensus parameters through
+// update, which must not block.
+func UpdateParams(update func(p *ConsensusParams)) {
+	paramsLock.Lock()
+	defer paramsLock.Unlock()
+
+	update(&params)
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"encoding/binary"
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/pkg/errors"
+)
+
+// Token is a fungible token issued on the ledger. Tokens are identified by the
+// ID of the transaction which created them, and their entire supply is
+// credited to their creator upon being created.
+type Token struct {
+	Creator AccountID
+
+	Supply   uint64
+	Decimals uint8
+	Symbol   string
+}
+
+func (t Token) Marshal() []byte {
+	buf := make([]byte, SizeAccountID+8+1+len(t.Symbol))
+
+	copy(buf[:SizeAccountID], t.Creator[:])
+	binary.LittleEndian.PutUint64(buf[SizeAccountID:SizeAccountID+8], t.Supply)
+	buf[SizeAccountID+8] = t.Decimals
+	copy(buf[SizeAccountID+9:], t.Symbol)
+
+	return buf
+}
+
+func UnmarshalToken(buf []byte) (Token, error) {
+	var t Token
+
+	if len(buf) < SizeAccountID+9 {
+		return t, errors.Errorf("token must be at least %d bytes, but got %d bytes", SizeAccountID+9, len(buf))
+	}
+
+	copy(t.Creator[:], buf[:SizeAccountID])
+	t.Supply = binary.LittleEndian.Uint64(buf[SizeAccountID : SizeAccountID+8])
+	t.Decimals = buf[SizeAccountID+8]
+	t.Symbol = string(buf[SizeAccountID+9:])
+
+	return t, nil
+}
+
+// TokenBalance is the balance an account holds of a single token.
+type TokenBalance struct {
+	TokenID TransactionID
+	Balance uint64
+}
+
+func ReadToken(tree *avl.Tree, id TransactionID) (Token, bool) {
+	buf, exists := readUnderAccounts(tree, id, keyTokens[:])
+	if !exists {
+		return Token{}, false
+	}
+
+	token, err := UnmarshalToken(buf)
+	if err != nil {
+		return Token{}, false
+	}
+
+	return token, true
+}
+
+func WriteToken(tree *avl.Tree, id TransactionID, token Token) {
+	writeUnderAccounts(tree, id, keyTokens[:], token.Marshal())
+}
+
+// ReadAccountTokenBalance reads the balance an account holds of a token.
+func ReadAccountTokenBalance(tree *avl.Tree, id AccountID, token TransactionID) (uint64, bool) {
+	buf, exists := tree.Lookup(tokenBalanceKey(id, token))
+	if !exists || len(buf) != 8 {
+		return 0, false
+	}
+
+	return binary.LittleEndian.Uint64(buf), true
+}
+
+func WriteAccountTokenBalance(tree *avl.Tree, id AccountID, token TransactionID, balance uint64) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], balance)
+
+	tree.Insert(tokenBalanceKey(id, token), buf[:])
+}
+
+// ReadAccountTokenBalances reads the balances an account holds of all tokens
+// it has ever held, ordered by the ID of the token.
+func ReadAccountTokenBalances(tree *avl.Tree, id AccountID) []TokenBalance {
+	var balances []TokenBalance
+
+	prefix := accountKey(id, keyAccountTokenBalance[:])
+
+	tree.IteratePrefix(prefix, func(key, value []byte) {
+		if len(key) != len(prefix)+SizeTransactionID || len(value) != 8 {
+			return
+		}
+
+		var balance TokenBalance
+
+		copy(balance.TokenID[:], key[len(prefix):])
+		balance.Balance = binary.LittleEndian.Uint64(value)
+
+		balances = append(balances, balance)
+	})
+
+	return balances
+}
+
+func tokenBalanceKey(id AccountID, token TransactionID) []byte {
+	return append(accountKey(id, keyAccountTokenBalance[:]), token[:]...)
+}
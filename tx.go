@@ -34,12 +34,24 @@ import (
 	"sort"
 )
 
+// Bits of the flags of a marshaled transaction, marking which of its optional
+// fields are recorded.
+const (
+	txFlagCreator byte = 1 << iota
+	txFlagValidUntilView
+)
+
 type Transaction struct {
 	Sender  AccountID // Transaction sender.
 	Creator AccountID // Transaction creator.
 
 	Nonce uint64
 
+	// Index of the last round the transaction may be applied in, such that
+	// it is rejected once finalized in any round after it. The transaction
+	// never expires should it be 0.
+	ValidUntilView uint64
+
 	ParentIDs []TransactionID // Transactions parents.
 
 	Depth uint64 // Graph depth.
@@ -57,16 +69,46 @@ type Transaction struct {
 }
 
 func NewTransaction(creator *skademlia.Keypair, tag byte, payload []byte) Transaction {
-	tx := Transaction{Tag: tag, Payload: payload}
+	return NewExpiringTransaction(creator, tag, payload, 0)
+}
 
-	var nonce [8]byte // TODO(kenta): nonce
+// NewExpiringTransaction creates a transaction which may only be applied in
+// rounds up to and including the round indexed validUntilView. Once expired, a
+// transaction may safely be re-signed and sent again, as it is rejected should
+// it ever be finalized. See Transaction.ValidUntilView.
+func NewExpiringTransaction(creator *skademlia.Keypair, tag byte, payload []byte, validUntilView uint64) Transaction {
+	tx := Transaction{Tag: tag, Payload: payload, ValidUntilView: validUntilView}
 
 	tx.Creator = creator.PublicKey()
-	tx.CreatorSignature = edwards25519.Sign(creator.PrivateKey(), append(nonce[:], append([]byte{tx.Tag}, tx.Payload...)...))
+	tx.CreatorSignature = edwards25519.Sign(creator.PrivateKey(), tx.creatorMessage())
 
 	return tx
 }
 
+// creatorMessage returns the message signed by the creator of the
+// transaction. The expiry of the transaction is only signed should it be set,
+// such that signatures of transactions which never expire are left unchanged.
+func (t Transaction) creatorMessage() []byte {
+	var nonce [8]byte // TODO(kenta): nonce
+
+	msg := append(nonce[:], append([]byte{t.Tag}, t.Payload...)...)
+
+	if t.ValidUntilView != 0 {
+		var buf [8]byte
+		binary.BigEndian.PutUint64(buf[:], t.ValidUntilView)
+
+		msg = append(msg, buf[:]...)
+	}
+
+	return msg
+}
+
+// Expired returns whether or not the transaction may no longer be applied in
+// the round indexed round.
+func (t Transaction) Expired(round uint64) bool {
+	return t.ValidUntilView != 0 && round > t.ValidUntilView
+}
+
 func NewBatchTransaction(creator *skademlia.Keypair, tags []byte, payloads [][]byte) Transaction {
 	if len(tags) != len(payloads) {
 		panic("UNEXPECTED: Number of tags must be equivalent to number of payloads.")
@@ -117,6 +159,26 @@ func AttachSenderToTransaction(sender *skademlia.Keypair, tx Transaction, parent
 	return tx
 }
 
+// Fee returns the amount of PERLs the creator of the transaction pays to have
+// it applied, being the TransactionFeeAmount plus TransactionFeePerByte for
+// every byte of the marshaled transaction. It saturates should it overflow.
+func (t Transaction) Fee() uint64 {
+	params := sys.Params()
+	fee := params.TransactionFeeAmount
+
+	if params.TransactionFeePerByte == 0 {
+		return fee
+	}
+
+	size := uint64(len(t.Marshal()))
+
+	if size > (math.MaxUint64-fee)/params.TransactionFeePerByte {
+		return math.MaxUint64
+	}
+
+	return fee + size*params.TransactionFeePerByte
+}
+
 func (t *Transaction) rehash() *Transaction {
 	t.ID = blake2b.Sum256(t.Marshal())
 
@@ -137,11 +199,20 @@ func (t Transaction) Marshal() []byte {
 
 	w.Write(t.Sender[:])
 
+	var flags byte
+
 	if t.Creator != t.Sender {
-		w.WriteByte(1)
+		flags |= txFlagCreator
+	}
+
+	if t.ValidUntilView != 0 {
+		flags |= txFlagValidUntilView
+	}
+
+	w.WriteByte(flags)
+
+	if flags&txFlagCreator != 0 {
 		w.Write(t.Creator[:])
-	} else {
-		w.WriteByte(0)
 	}
 
 	var buf [8]byte
@@ -149,6 +220,11 @@ func (t Transaction) Marshal() []byte {
 	binary.BigEndian.PutUint64(buf[:8], t.Nonce)
 	w.Write(buf[:8])
 
+	if flags&txFlagValidUntilView != 0 {
+		binary.BigEndian.PutUint64(buf[:8], t.ValidUntilView)
+		w.Write(buf[:8])
+	}
+
 	w.WriteByte(byte(len(t.ParentIDs)))
 	for _, parentID := range t.ParentIDs {
 		w.Write(parentID[:])
@@ -182,9 +258,16 @@ func UnmarshalTransaction(r io.Reader) (t Transaction, err error) {
 
 	if _, err = io.ReadFull(r, buf[:1]); err != nil {
 		err = errors.Wrap(err, "failed to decode check bit to see if transaction creator is recorded")
+		return
+	}
+
+	if buf[0]&^(txFlagCreator|txFlagValidUntilView) != 0 {
+		err = errors.Errorf("transaction has unknown flags %08b", buf[0])
+		return
 	}
 
-	creatorRecorded := buf[0] == 1
+	creatorRecorded := buf[0]&txFlagCreator != 0
+	validUntilViewRecorded := buf[0]&txFlagValidUntilView != 0
 
 	if !creatorRecorded {
 		t.Creator = t.Sender
@@ -202,6 +285,20 @@ func UnmarshalTransaction(r io.Reader) (t Transaction, err error) {
 
 	t.Nonce = binary.BigEndian.Uint64(buf[:8])
 
+	if validUntilViewRecorded {
+		if _, err = io.ReadFull(r, buf[:8]); err != nil {
+			err = errors.Wrap(err, "failed to read valid until view")
+			return
+		}
+
+		t.ValidUntilView = binary.BigEndian.Uint64(buf[:8])
+
+		if t.ValidUntilView == 0 {
+			err = errors.New("transaction records a valid until view of 0")
+			return
+		}
+	}
+
 	if _, err = io.ReadFull(r, buf[:1]); err != nil {
 		err = errors.Wrap(err, "failed to read num parents")
 		return
@@ -240,7 +337,14 @@ func UnmarshalTransaction(r io.Reader) (t Transaction, err error) {
 		return
 	}
 
-	t.Payload = make([]byte, binary.BigEndian.Uint32(buf[:4]))
+	payloadLen := binary.BigEndian.Uint32(buf[:4])
+
+	if payloadLen > uint32(sys.MaxTransactionPayloadSize) {
+		err = errors.Errorf("transaction payload is %d bytes, but may only be %d bytes at most", payloadLen, sys.MaxTransactionPayloadSize)
+		return
+	}
+
+	t.Payload = make([]byte, payloadLen)
 
 	if _, err = io.ReadFull(r, t.Payload[:]); err != nil {
 		err = errors.Wrap(err, "could not read transaction payload")
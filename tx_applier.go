@@ -21,24 +21,148 @@ package wavelet
 
 import (
 	"encoding/hex"
-	"fmt"
 	"github.com/perlin-network/wavelet/avl"
 	"github.com/perlin-network/wavelet/log"
 	"github.com/perlin-network/wavelet/sys"
 	"github.com/pkg/errors"
 )
 
+var (
+	// ErrTransferToSelf is returned when a transfer transaction has its creator
+	// sending PERLs to themselves.
+	ErrTransferToSelf = errors.New("sender and recipient of transfer are the same account")
+
+	ErrContractQueueDepthExceeded = errors.New("smart contracts queued up transactions too many levels deep")
+	ErrContractQueueLimitExceeded = errors.New("smart contracts queued up too many transactions")
+)
+
+// ContractExecutorState is carried across all transactions queued up by
+// smart contracts on behalf of a single originating transaction.
 type ContractExecutorState struct {
 	Sender   AccountID
 	GasLimit uint64
+
+	// TxID is the ID of the originating transaction.
+	TxID TransactionID
+
+	// Depth is the number of smart contract invocations the transactions
+	// currently being applied were recursively queued up through.
+	Depth int
+
+	// Queued is the number of transactions queued up by smart contracts so far.
+	Queued int
+
+	// receipt records what smart contracts did on behalf of the originating
+	// transaction, and is nil should no receipt be kept.
+	receipt *Receipt
+}
+
+// transactionProcessor applies transactions of the tag it is registered under
+// to a snapshot. state is nil unless the transaction was queued up by a smart
+// contract, or is made on behalf of an account by one. Smart contracts invoked
+// while applying the transaction are recorded into receipt, should it not be
+// nil.
+type transactionProcessor struct {
+	name  string
+	apply func(snapshot *avl.Tree, round *Round, tx *Transaction, state *ContractExecutorState, receipt *Receipt) (*avl.Tree, error)
+}
+
+// transactionProcessors maps tags to the processor which handles transactions
+// of that tag, such that each transaction is only dispatched to the single
+// processor responsible for it. Nops have no processor registered.
+var transactionProcessors map[byte]transactionProcessor
+
+func init() {
+	// Registered in init, as processors for batches and delegated transactions
+	// recursively dispatch transactions through the processors themselves.
+	transactionProcessors = map[byte]transactionProcessor{
+		sys.TagTransfer:       {name: "transfer", apply: applyTransferTransaction},
+		sys.TagStake:          {name: "stake", apply: stateless(ApplyStakeTransaction)},
+		sys.TagContract:       {name: "contract", apply: applyContractTransaction},
+		sys.TagBatch:          {name: "batch", apply: applyBatchTransaction},
+		sys.TagContractAdmin:  {name: "contract admin", apply: stateless(ApplyContractAdminTransaction)},
+		sys.TagDelegate:       {name: "delegate", apply: stateless(ApplyDelegateTransaction)},
+		sys.TagDelegated:      {name: "delegated", apply: applyDelegatedTransaction},
+		sys.TagUpdateContract: {name: "update contract", apply: stateless(ApplyUpdateContractTransaction)},
+		sys.TagCreateToken:    {name: "create token", apply: applyCreateTokenTransaction},
+		sys.TagTransferToken:  {name: "transfer token", apply: stateless(ApplyTransferTokenTransaction)},
+	}
+}
+
+func stateless(apply func(snapshot *avl.Tree, round *Round, tx *Transaction) (*avl.Tree, error)) func(*avl.Tree, *Round, *Transaction, *ContractExecutorState, *Receipt) (*avl.Tree, error) {
+	return func(snapshot *avl.Tree, round *Round, tx *Transaction, _ *ContractExecutorState, _ *Receipt) (*avl.Tree, error) {
+		return apply(snapshot, round, tx)
+	}
+}
+
+// applyTransaction dispatches tx to the processor registered under its tag.
+// Transactions with tags no processor is registered under are left unapplied.
+func applyTransaction(snapshot *avl.Tree, round *Round, tx *Transaction, state *ContractExecutorState, receipt *Receipt) (*avl.Tree, error) {
+	processor, exists := transactionProcessors[tx.Tag]
+	if !exists {
+		return snapshot, nil
+	}
+
+	return processor.apply(snapshot, round, tx, state, receipt)
+}
+
+// applyContractQueue applies all transactions a smart contract has queued up
+// while executing. It fails should the originating transaction have smart
+// contracts queue up transactions beyond MaxContractQueueDepth levels deep, or
+// beyond MaxContractQueuedTransactions transactions in total.
+func applyContractQueue(snapshot *avl.Tree, round *Round, queue []*Transaction, state *ContractExecutorState) error {
+	if len(queue) == 0 {
+		return nil
+	}
+
+	params := sys.Params()
+
+	if state.Depth >= params.MaxContractQueueDepth {
+		return errors.Wrapf(ErrContractQueueDepthExceeded, "contract: transactions may only be queued up %d levels deep", params.MaxContractQueueDepth)
+	}
+
+	if state.Queued += len(queue); state.Queued > params.MaxContractQueuedTransactions {
+		return errors.Wrapf(ErrContractQueueLimitExceeded, "contract: only %d transactions may be queued up in total", params.MaxContractQueuedTransactions)
+	}
+
+	state.Depth++
+	defer func() { state.Depth-- }()
+
+	state.receipt.recordChildren(queue)
+
+	for _, entry := range queue {
+		if _, err := applyTransaction(snapshot, round, entry, state, state.receipt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// originatingTxID returns the ID of the transaction which originated tx, which
+// is tx itself should it not have been queued up by a smart contract.
+func originatingTxID(tx *Transaction, state *ContractExecutorState) TransactionID {
+	if state != nil {
+		return state.TxID
+	}
+
+	return tx.ID
 }
 
 func ApplyTransferTransaction(snapshot *avl.Tree, round *Round, tx *Transaction, state *ContractExecutorState) (*avl.Tree, error) {
+	return applyTransferTransaction(snapshot, round, tx, state, nil)
+}
+
+func applyTransferTransaction(snapshot *avl.Tree, round *Round, tx *Transaction, state *ContractExecutorState, receipt *Receipt) (*avl.Tree, error) {
 	params, err := ParseTransferTransaction(tx.Payload)
 	if err != nil {
 		return nil, err
 	}
 
+	if params.Recipient == tx.Creator {
+		return nil, errors.Wrapf(ErrTransferToSelf, "transfer: %x tried to send %d PERLs", tx.Creator, params.Amount)
+	}
+
 	code, codeAvailable := ReadAccountContractCode(snapshot, params.Recipient)
 
 	if !codeAvailable && (params.GasLimit > 0 || len(params.FuncName) > 0 || len(params.FuncParams) > 0) {
@@ -46,29 +170,56 @@ func ApplyTransferTransaction(snapshot *avl.Tree, round *Round, tx *Transaction,
 	}
 
 	senderBalance, _ := ReadAccountBalance(snapshot, tx.Creator)
+	recipientBalance, _ := ReadAccountBalance(snapshot, params.Recipient)
+
+	newRecipientBalance, err := Amount(recipientBalance).Add(Amount(params.Amount))
+	if err != nil {
+		return nil, errors.Wrapf(err, "transfer: %x tried to send %d PERLs to %x, which already has %d PERLs",
+			tx.Creator, params.Amount, params.Recipient, recipientBalance)
+	}
 
 	// FIXME(kenta): FOR TESTNET ONLY. FAUCET DOES NOT GET ANY PERLs DEDUCTED.
 	if hex.EncodeToString(tx.Creator[:]) == sys.FaucetAddress {
-		recipientBalance, _ := ReadAccountBalance(snapshot, params.Recipient)
-		WriteAccountBalance(snapshot, params.Recipient, recipientBalance+params.Amount)
+		WriteAccountBalance(snapshot, params.Recipient, uint64(newRecipientBalance))
 
 		return snapshot, nil
 	}
 
-	if senderBalance < params.Amount {
-		return nil, errors.Errorf("transfer: %x tried send %d PERLs to %x, but only has %d PERLs",
+	newSenderBalance, err := Amount(senderBalance).Sub(Amount(params.Amount))
+	if err != nil {
+		return nil, errors.Wrapf(err, "transfer: %x tried send %d PERLs to %x, but only has %d PERLs",
 			tx.Creator, params.Amount, params.Recipient, senderBalance)
 	}
 
 	if !codeAvailable {
-		WriteAccountBalance(snapshot, tx.Creator, senderBalance-params.Amount)
-
-		recipientBalance, _ := ReadAccountBalance(snapshot, params.Recipient)
-		WriteAccountBalance(snapshot, params.Recipient, recipientBalance+params.Amount)
+		WriteAccountBalance(snapshot, tx.Creator, uint64(newSenderBalance))
+		WriteAccountBalance(snapshot, params.Recipient, uint64(newRecipientBalance))
 
 		return snapshot, nil
 	}
 
+	if IsContractConstructor(string(params.FuncName)) {
+		return nil, errors.Wrapf(ErrContractConstructor, "transfer: may not invoke %q on smart contract %x", params.FuncName, params.Recipient)
+	}
+
+	if ReadAccountContractPaused(snapshot, params.Recipient) {
+		return nil, errors.Errorf("transfer: smart contract %x is paused", params.Recipient)
+	}
+
+	if quota, exists := ReadAccountContractCallQuota(snapshot, params.Recipient); exists && quota > 0 {
+		lastRound, calls := ReadAccountContractCalls(snapshot, params.Recipient)
+
+		if lastRound != round.Index {
+			calls = 0
+		}
+
+		if calls >= quota {
+			return nil, errors.Errorf("transfer: smart contract %x may only be invoked %d times per round", params.Recipient, quota)
+		}
+
+		WriteAccountContractCalls(snapshot, params.Recipient, round.Index, calls+1)
+	}
+
 	sender := tx.Creator
 	if state != nil {
 		sender = state.Sender
@@ -79,28 +230,41 @@ func ApplyTransferTransaction(snapshot *avl.Tree, round *Round, tx *Transaction,
 		return nil, errors.New("transfer: gas limit for invoking smart contract must be greater than zero")
 	}
 
-	senderBalance, _ = ReadAccountBalance(snapshot, sender)
+	// The sender must be able to afford both the amount transferred and the gas
+	// limit claimed should they be one and the same account paying for both.
+
+	claimed := Amount(params.GasLimit)
 
-	if senderBalance < params.GasLimit {
-		return nil, errors.Errorf("transfer: %x attempted to claim a gas limit of %d PERLs, but only has %d PERLs",
-			sender, params.GasLimit, senderBalance)
+	if sender == tx.Creator {
+		if claimed, err = claimed.Add(Amount(params.Amount)); err != nil {
+			return nil, errors.Wrapf(err, "transfer: %x attempted to claim a gas limit of %d PERLs on top of sending %d PERLs",
+				sender, params.GasLimit, params.Amount)
+		}
 	}
 
-	WriteAccountBalance(snapshot, tx.Creator, senderBalance-params.Amount)
+	if gasBalance, _ := ReadAccountBalance(snapshot, sender); Amount(gasBalance) < claimed {
+		return nil, errors.Wrapf(ErrAmountUnderflow, "transfer: %x attempted to claim a gas limit of %d PERLs, but only has %d PERLs",
+			sender, params.GasLimit, gasBalance)
+	}
 
-	recipientBalance, _ := ReadAccountBalance(snapshot, params.Recipient)
-	WriteAccountBalance(snapshot, params.Recipient, recipientBalance+params.Amount)
+	WriteAccountBalance(snapshot, tx.Creator, uint64(newSenderBalance))
+	WriteAccountBalance(snapshot, params.Recipient, uint64(newRecipientBalance))
 
-	executor := &ContractExecutor{}
+	executor := &ContractExecutor{TxID: originatingTxID(tx, state)}
 
 	if err := executor.Execute(snapshot, params.Recipient, round, tx, params.Amount, params.GasLimit, string(params.FuncName), params.FuncParams, code); err != nil {
 		return nil, errors.Wrap(err, "transfer: failed to invoke smart contract")
 	}
 
+	receipt.recordExecution(executor, state != nil)
+
 	if executor.GasLimitExceeded { // Revert changes and have the sender pay gas fees.
-		WriteAccountBalance(snapshot, tx.Creator, senderBalance-executor.Gas)
+		newSenderBalance, err := Amount(senderBalance).Sub(Amount(executor.Gas))
+		if err != nil {
+			return nil, errors.Wrapf(err, "transfer: %x spent %d PERLs worth of gas, but only has %d PERLs", tx.Creator, executor.Gas, senderBalance)
+		}
 
-		recipientBalance, _ := ReadAccountBalance(snapshot, params.Recipient)
+		WriteAccountBalance(snapshot, tx.Creator, uint64(newSenderBalance))
 		WriteAccountBalance(snapshot, params.Recipient, recipientBalance)
 
 		logger := log.Contracts("gas")
@@ -111,7 +275,13 @@ func ApplyTransferTransaction(snapshot *avl.Tree, round *Round, tx *Transaction,
 			Uint64("gas_limit", params.GasLimit).
 			Msg("Exceeded gas limit while invoking smart contract function.")
 	} else {
-		WriteAccountBalance(snapshot, tx.Creator, senderBalance-params.Amount-executor.Gas)
+		newSenderBalance, err := newSenderBalance.Sub(Amount(executor.Gas))
+		if err != nil {
+			return nil, errors.Wrapf(err, "transfer: %x spent %d PERLs worth of gas on top of sending %d PERLs, but only has %d PERLs",
+				tx.Creator, executor.Gas, params.Amount, senderBalance)
+		}
+
+		WriteAccountBalance(snapshot, tx.Creator, uint64(newSenderBalance))
 
 		logger := log.Contracts("gas")
 		logger.Info().
@@ -122,33 +292,15 @@ func ApplyTransferTransaction(snapshot *avl.Tree, round *Round, tx *Transaction,
 			Msg("Deducted PERLs for invoking smart contract function.")
 
 		if state == nil {
-			state = &ContractExecutorState{Sender: tx.Sender}
+			state = &ContractExecutorState{Sender: tx.Sender, TxID: tx.ID, receipt: receipt}
 		}
 
 		if params.GasLimit > executor.Gas {
 			state.GasLimit = params.GasLimit - executor.Gas
 		}
 
-		for _, entry := range executor.Queue {
-			switch entry.Tag {
-			case sys.TagNop:
-			case sys.TagTransfer:
-				if _, err := ApplyTransferTransaction(snapshot, round, entry, state); err != nil {
-					return nil, err
-				}
-			case sys.TagStake:
-				if _, err := ApplyStakeTransaction(snapshot, round, entry); err != nil {
-					return nil, err
-				}
-			case sys.TagContract:
-				if _, err := ApplyContractTransaction(snapshot, round, entry, state); err != nil {
-					return nil, err
-				}
-			case sys.TagBatch:
-				if _, err := ApplyBatchTransaction(snapshot, round, entry); err != nil {
-					return nil, err
-				}
-			}
+		if err := applyContractQueue(snapshot, round, executor.Queue, state); err != nil {
+			return nil, err
 		}
 	}
 
@@ -167,29 +319,42 @@ func ApplyStakeTransaction(snapshot *avl.Tree, round *Round, tx *Transaction) (*
 
 	switch params.Opcode {
 	case sys.PlaceStake:
-		if balance < params.Amount {
-			return nil, errors.Errorf("stake: %x attempt to place a stake of %d PERLs, but only has %d PERLs", tx.Creator, params.Amount, balance)
+		newBalance, err := Amount(balance).Sub(Amount(params.Amount))
+		if err != nil {
+			return nil, errors.Wrapf(err, "stake: %x attempt to place a stake of %d PERLs, but only has %d PERLs", tx.Creator, params.Amount, balance)
 		}
 
-		WriteAccountBalance(snapshot, tx.Creator, balance-params.Amount)
-		WriteAccountStake(snapshot, tx.Creator, stake+params.Amount)
+		newStake, err := Amount(stake).Add(Amount(params.Amount))
+		if err != nil {
+			return nil, errors.Wrapf(err, "stake: %x attempt to place a stake of %d PERLs on top of %d PERLs staked", tx.Creator, params.Amount, stake)
+		}
+
+		WriteAccountBalance(snapshot, tx.Creator, uint64(newBalance))
+		WriteAccountStake(snapshot, tx.Creator, uint64(newStake))
 	case sys.WithdrawStake:
-		if stake < params.Amount {
-			return nil, errors.Errorf("stake: %x attempt to withdraw a stake of %d PERLs, but only has staked %d PERLs", tx.Creator, params.Amount, stake)
+		newStake, err := Amount(stake).Sub(Amount(params.Amount))
+		if err != nil {
+			return nil, errors.Wrapf(err, "stake: %x attempt to withdraw a stake of %d PERLs, but only has staked %d PERLs", tx.Creator, params.Amount, stake)
+		}
+
+		newBalance, err := Amount(balance).Add(Amount(params.Amount))
+		if err != nil {
+			return nil, errors.Wrapf(err, "stake: %x attempt to withdraw a stake of %d PERLs into a balance of %d PERLs", tx.Creator, params.Amount, balance)
 		}
 
-		WriteAccountBalance(snapshot, tx.Creator, balance+params.Amount)
-		WriteAccountStake(snapshot, tx.Creator, stake-params.Amount)
+		WriteAccountBalance(snapshot, tx.Creator, uint64(newBalance))
+		WriteAccountStake(snapshot, tx.Creator, uint64(newStake))
 	case sys.WithdrawReward:
 		if params.Amount < sys.MinimumRewardWithdraw {
 			return nil, errors.Errorf("stake: %x attempt to withdraw rewards amounting to %d PERLs, but system requires the minimum amount to withdraw to be %d PERLs", tx.Creator, params.Amount, sys.MinimumRewardWithdraw)
 		}
 
-		if reward < params.Amount {
-			return nil, errors.Errorf("stake: %x attempt to withdraw rewards amounting to %d PERLs, but only has rewards amounting to %d PERLs", tx.Creator, params.Amount, reward)
+		newReward, err := Amount(reward).Sub(Amount(params.Amount))
+		if err != nil {
+			return nil, errors.Wrapf(err, "stake: %x attempt to withdraw rewards amounting to %d PERLs, but only has rewards amounting to %d PERLs", tx.Creator, params.Amount, reward)
 		}
 
-		WriteAccountReward(snapshot, tx.Creator, reward-params.Amount)
+		WriteAccountReward(snapshot, tx.Creator, uint64(newReward))
 		StoreRewardWithdrawalRequest(snapshot, RewardWithdrawalRequest{
 			account: tx.Creator,
 			amount:  params.Amount,
@@ -201,6 +366,10 @@ func ApplyStakeTransaction(snapshot *avl.Tree, round *Round, tx *Transaction) (*
 }
 
 func ApplyContractTransaction(snapshot *avl.Tree, round *Round, tx *Transaction, state *ContractExecutorState) (*avl.Tree, error) {
+	return applyContractTransaction(snapshot, round, tx, state, nil)
+}
+
+func applyContractTransaction(snapshot *avl.Tree, round *Round, tx *Transaction, state *ContractExecutorState, receipt *Receipt) (*avl.Tree, error) {
 	params, err := ParseContractTransaction(tx.Payload)
 	if err != nil {
 		return nil, err
@@ -210,6 +379,15 @@ func ApplyContractTransaction(snapshot *avl.Tree, round *Round, tx *Transaction,
 		return nil, errors.New("contract: already exists")
 	}
 
+	if err := ValidateContractCode(params.Code); err != nil {
+		return nil, err
+	}
+
+	constructor, err := contractConstructor(params.Code)
+	if err != nil {
+		return nil, err
+	}
+
 	sender := tx.Creator
 	if state != nil {
 		sender = state.Sender
@@ -226,46 +404,39 @@ func ApplyContractTransaction(snapshot *avl.Tree, round *Round, tx *Transaction,
 		return nil, errors.Errorf("contract: %x tried to spawn a contract using a gas limit of %d PERLs but only has %d PERLs", sender, params.GasLimit, balance)
 	}
 
-	executor := &ContractExecutor{}
+	executor := &ContractExecutor{TxID: originatingTxID(tx, state)}
 
-	if err := executor.Execute(snapshot, tx.ID, round, tx, 0, params.GasLimit, `init`, params.Params, params.Code); err != nil {
+	if err := executor.Execute(snapshot, tx.ID, round, tx, 0, params.GasLimit, constructor, params.Params, params.Code); err != nil {
 		return nil, errors.Wrap(err, "contract: failed to init smart contract")
 	}
 
-	WriteAccountBalance(snapshot, tx.Creator, balance-executor.Gas)
+	receipt.recordExecution(executor, state != nil)
 
-	if !executor.GasLimitExceeded {
+	newBalance, err := Amount(balance).Sub(Amount(executor.Gas))
+	if err != nil {
+		return nil, errors.Wrapf(err, "contract: %x spent %d PERLs worth of gas spawning a contract but only has %d PERLs", sender, executor.Gas, balance)
+	}
+
+	WriteAccountBalance(snapshot, tx.Creator, uint64(newBalance))
+
+	// The contract is only spawned should its constructor succeed, such that
+	// it is never left partially initialized.
+
+	if !executor.Reverted {
 		if state == nil {
-			state = &ContractExecutorState{Sender: tx.Sender}
+			state = &ContractExecutorState{Sender: tx.Sender, TxID: tx.ID, receipt: receipt}
 		}
 
 		if params.GasLimit > executor.Gas {
 			state.GasLimit = params.GasLimit - executor.Gas
 		}
 
-		for _, entry := range executor.Queue {
-			switch entry.Tag {
-			case sys.TagNop:
-			case sys.TagTransfer:
-				if _, err := ApplyTransferTransaction(snapshot, round, entry, state); err != nil {
-					return nil, err
-				}
-			case sys.TagStake:
-				if _, err := ApplyStakeTransaction(snapshot, round, entry); err != nil {
-					return nil, err
-				}
-			case sys.TagContract:
-				if _, err := ApplyContractTransaction(snapshot, round, entry, state); err != nil {
-					return nil, err
-				}
-			case sys.TagBatch:
-				if _, err := ApplyBatchTransaction(snapshot, round, entry); err != nil {
-					return nil, err
-				}
-			}
+		if err := applyContractQueue(snapshot, round, executor.Queue, state); err != nil {
+			return nil, err
 		}
 
 		WriteAccountContractCode(snapshot, tx.ID, params.Code)
+		WriteAccountContractOwner(snapshot, tx.ID, tx.Creator)
 	}
 
 	logger := log.Contracts("gas")
@@ -274,17 +445,39 @@ func ApplyContractTransaction(snapshot *avl.Tree, round *Round, tx *Transaction,
 		Hex("contract_id", tx.ID[:]).
 		Uint64("gas", executor.Gas).
 		Uint64("gas_limit", params.GasLimit).
+		Bool("spawned", !executor.Reverted).
 		Msg("Deducted PERLs for spawning a smart contract.")
 
 	return snapshot, nil
 }
 
+// ApplyBatchTransaction applies all entries of a batch transaction in order.
+// Should any entry fail, changes made by all entries before it are reverted,
+// such that the batch is applied all-or-nothing.
 func ApplyBatchTransaction(snapshot *avl.Tree, round *Round, tx *Transaction) (*avl.Tree, error) {
+	return applyBatchTransaction(snapshot, round, tx, nil, nil)
+}
+
+// applyBatchTransaction applies entries of batches queued up by smart contracts
+// under the same state as the batch, such that the entries count towards
+// MaxContractQueuedTransactions alongside the batch itself, and smart contracts
+// they invoke queue up transactions towards the same MaxContractQueueDepth.
+func applyBatchTransaction(snapshot *avl.Tree, round *Round, tx *Transaction, state *ContractExecutorState, receipt *Receipt) (*avl.Tree, error) {
 	params, err := ParseBatchTransaction(tx.Payload)
 	if err != nil {
 		return nil, err
 	}
 
+	if state != nil {
+		limit := sys.Params().MaxContractQueuedTransactions
+
+		if state.Queued += int(params.Size); state.Queued > limit {
+			return nil, errors.Wrapf(ErrContractQueueLimitExceeded, "batch: only %d transactions may be queued up in total", limit)
+		}
+	}
+
+	original := snapshot.Snapshot()
+
 	for i := uint8(0); i < params.Size; i++ {
 		entry := &Transaction{
 			ID:      tx.ID,
@@ -295,23 +488,265 @@ func ApplyBatchTransaction(snapshot *avl.Tree, round *Round, tx *Transaction) (*
 			Payload: params.Payloads[i],
 		}
 
-		switch entry.Tag {
-		case sys.TagNop:
-		case sys.TagTransfer:
-			if _, err := ApplyTransferTransaction(snapshot, round, entry, nil); err != nil {
-				return nil, err
-			}
-		case sys.TagStake:
-			if _, err := ApplyStakeTransaction(snapshot, round, entry); err != nil {
-				fmt.Println(err)
-				return nil, err
-			}
-		case sys.TagContract:
-			if _, err := ApplyContractTransaction(snapshot, round, entry, nil); err != nil {
-				return nil, err
-			}
+		if _, err := applyTransaction(snapshot, round, entry, state, receipt); err != nil {
+			snapshot.Revert(original)
+			return nil, errors.Wrapf(err, "batch: entry %d failed to apply", i)
+		}
+	}
+
+	return snapshot, nil
+}
+
+func ApplyContractAdminTransaction(snapshot *avl.Tree, round *Round, tx *Transaction) (*avl.Tree, error) {
+	params, err := ParseContractAdminTransaction(tx.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, exists := ReadAccountContractCode(snapshot, params.ContractID); !exists {
+		return nil, errors.Errorf("contract admin: smart contract %x does not exist", params.ContractID)
+	}
+
+	owner, exists := ReadAccountContractOwner(snapshot, params.ContractID)
+	if !exists {
+		return nil, errors.Errorf("contract admin: smart contract %x has no owner", params.ContractID)
+	}
+
+	if owner != tx.Creator {
+		return nil, errors.Errorf("contract admin: %x attempted to administer smart contract %x, which is owned by %x", tx.Creator, params.ContractID, owner)
+	}
+
+	switch params.Opcode {
+	case sys.PauseContract:
+		WriteAccountContractPaused(snapshot, params.ContractID, true)
+	case sys.ResumeContract:
+		WriteAccountContractPaused(snapshot, params.ContractID, false)
+	case sys.TransferContractOwnership:
+		WriteAccountContractOwner(snapshot, params.ContractID, params.Owner)
+	case sys.SetContractCallQuota:
+		WriteAccountContractCallQuota(snapshot, params.ContractID, params.Quota)
+	}
+
+	logger := log.Contracts("admin")
+	logger.Info().
+		Hex("owner_id", tx.Creator[:]).
+		Hex("contract_id", params.ContractID[:]).
+		Uint8("opcode", params.Opcode).
+		Msg("Administered smart contract.")
+
+	return snapshot, nil
+}
+
+// ApplyUpdateContractTransaction replaces the code of a smart contract, should the transaction be made by the owner of
+// the contract. The memory of the contract is carried over, and its constructor is not invoked again, such that the
+// new code is expected to be compatible with the memory left behind by the code it replaces.
+func ApplyUpdateContractTransaction(snapshot *avl.Tree, round *Round, tx *Transaction) (*avl.Tree, error) {
+	params, err := ParseUpdateContractTransaction(tx.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, exists := ReadAccountContractCode(snapshot, params.ContractID); !exists {
+		return nil, errors.Errorf("update contract: smart contract %x does not exist", params.ContractID)
+	}
+
+	owner, exists := ReadAccountContractOwner(snapshot, params.ContractID)
+	if !exists {
+		return nil, errors.Errorf("update contract: smart contract %x has no owner", params.ContractID)
+	}
+
+	if owner != tx.Creator {
+		return nil, errors.Errorf("update contract: %x attempted to update smart contract %x, which is owned by %x", tx.Creator, params.ContractID, owner)
+	}
+
+	if err := ValidateContractCode(params.Code); err != nil {
+		return nil, err
+	}
+
+	WriteAccountContractCode(snapshot, params.ContractID, params.Code)
+
+	logger := log.Contracts("update")
+	logger.Info().
+		Hex("owner_id", tx.Creator[:]).
+		Hex("contract_id", params.ContractID[:]).
+		Int("code_size", len(params.Code)).
+		Msg("Updated code of smart contract.")
+
+	return snapshot, nil
+}
+
+// ApplyCreateTokenTransaction issues a fungible token identified by the ID of the transaction, and credits its entire
+// supply to the creator of the transaction.
+func ApplyCreateTokenTransaction(snapshot *avl.Tree, round *Round, tx *Transaction) (*avl.Tree, error) {
+	return applyCreateTokenTransaction(snapshot, round, tx, nil, nil)
+}
+
+func applyCreateTokenTransaction(snapshot *avl.Tree, round *Round, tx *Transaction, state *ContractExecutorState, _ *Receipt) (*avl.Tree, error) {
+	// Transactions queued up by smart contracts, including entries of batches
+	// queued up by them, have no ID of their own for the token to be
+	// identified by.
+	if state != nil {
+		return nil, errors.New("create token: tokens may not be created by smart contracts")
+	}
+
+	params, err := ParseCreateTokenTransaction(tx.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, exists := ReadToken(snapshot, tx.ID); exists {
+		return nil, errors.Errorf("create token: token %x already exists", tx.ID)
+	}
+
+	WriteToken(snapshot, tx.ID, Token{Creator: tx.Creator, Supply: params.Supply, Decimals: params.Decimals, Symbol: params.Symbol})
+	WriteAccountTokenBalance(snapshot, tx.Creator, tx.ID, params.Supply)
+
+	logger := log.Accounts("token")
+	logger.Info().
+		Hex("creator_id", tx.Creator[:]).
+		Hex("token_id", tx.ID[:]).
+		Str("symbol", params.Symbol).
+		Uint64("supply", params.Supply).
+		Uint8("decimals", params.Decimals).
+		Msg("Created token.")
+
+	return snapshot, nil
+}
+
+// ApplyTransferTokenTransaction moves an amount of a fungible token from the balance the creator of the transaction
+// holds of it to that of the recipient.
+func ApplyTransferTokenTransaction(snapshot *avl.Tree, round *Round, tx *Transaction) (*avl.Tree, error) {
+	params, err := ParseTransferTokenTransaction(tx.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	token, exists := ReadToken(snapshot, params.TokenID)
+	if !exists {
+		return nil, errors.Errorf("transfer token: token %x does not exist", params.TokenID)
+	}
+
+	if params.Recipient == tx.Creator {
+		return nil, errors.Wrapf(ErrTransferToSelf, "transfer token: %x tried to send %d %s", tx.Creator, params.Amount, token.Symbol)
+	}
+
+	senderBalance, _ := ReadAccountTokenBalance(snapshot, tx.Creator, params.TokenID)
+	recipientBalance, _ := ReadAccountTokenBalance(snapshot, params.Recipient, params.TokenID)
+
+	newSenderBalance, err := Amount(senderBalance).Sub(Amount(params.Amount))
+	if err != nil {
+		return nil, errors.Wrapf(err, "transfer token: %x tried to send %d %s to %x, but only has %d %s",
+			tx.Creator, params.Amount, token.Symbol, params.Recipient, senderBalance, token.Symbol)
+	}
+
+	newRecipientBalance, err := Amount(recipientBalance).Add(Amount(params.Amount))
+	if err != nil {
+		return nil, errors.Wrapf(err, "transfer token: %x tried to send %d %s to %x, which already has %d %s",
+			tx.Creator, params.Amount, token.Symbol, params.Recipient, recipientBalance, token.Symbol)
+	}
+
+	WriteAccountTokenBalance(snapshot, tx.Creator, params.TokenID, uint64(newSenderBalance))
+	WriteAccountTokenBalance(snapshot, params.Recipient, params.TokenID, uint64(newRecipientBalance))
+
+	return snapshot, nil
+}
+
+func ApplyDelegateTransaction(snapshot *avl.Tree, round *Round, tx *Transaction) (*avl.Tree, error) {
+	params, err := ParseDelegateTransaction(tx.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	switch params.Opcode {
+	case sys.GrantDelegation:
+		if params.Key == tx.Creator {
+			return nil, errors.Errorf("delegate: %x may not grant a delegation to itself", tx.Creator)
+		}
+
+		if params.Delegation.Expiry <= round.Index {
+			return nil, errors.Errorf("delegate: delegation expires at round %d, which has already passed", params.Delegation.Expiry)
+		}
+
+		DeleteAccountDelegation(snapshot, tx.Creator, params.Key)
+		WriteAccountDelegation(snapshot, tx.Creator, params.Key, params.Delegation)
+	case sys.RevokeDelegation:
+		if _, exists := ReadAccountDelegation(snapshot, tx.Creator, params.Key); !exists {
+			return nil, errors.Errorf("delegate: %x has not granted a delegation to session key %x", tx.Creator, params.Key)
 		}
+
+		DeleteAccountDelegation(snapshot, tx.Creator, params.Key)
+	}
+
+	logger := log.Accounts("delegate")
+	logger.Info().
+		Hex("account_id", tx.Creator[:]).
+		Hex("session_key", params.Key[:]).
+		Uint8("opcode", params.Opcode).
+		Msg("Updated delegation of account.")
+
+	return snapshot, nil
+}
+
+// ApplyDelegatedTransaction applies a transaction made by a session key on behalf of an account. The transaction is
+// rejected should the account not have granted the session key a delegation which allows for its tag, should the
+// delegation have expired, or should the PERLs spent from the balance of the account in the current round exceed
+// what the delegation allows for. The caller is expected to revert the snapshot should an error be returned.
+func ApplyDelegatedTransaction(snapshot *avl.Tree, round *Round, tx *Transaction, state *ContractExecutorState) (*avl.Tree, error) {
+	return applyDelegatedTransaction(snapshot, round, tx, state, nil)
+}
+
+func applyDelegatedTransaction(snapshot *avl.Tree, round *Round, tx *Transaction, state *ContractExecutorState, receipt *Receipt) (*avl.Tree, error) {
+	params, err := ParseDelegatedTransaction(tx.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	delegation, exists := ReadAccountDelegation(snapshot, params.Principal, tx.Creator)
+	if !exists {
+		return nil, errors.Errorf("delegated: session key %x may not act on behalf of %x", tx.Creator, params.Principal)
+	}
+
+	if round.Index >= delegation.Expiry {
+		return nil, errors.Errorf("delegated: delegation of session key %x by %x expired at round %d", tx.Creator, params.Principal, delegation.Expiry)
+	}
+
+	if !delegation.Allows(params.Tag) {
+		return nil, errors.Errorf("delegated: session key %x may not make transactions with tag %d on behalf of %x", tx.Creator, params.Tag, params.Principal)
+	}
+
+	entry := &Transaction{
+		ID:      tx.ID,
+		Sender:  tx.Sender,
+		Creator: params.Principal,
+		Nonce:   tx.Nonce,
+		Tag:     params.Tag,
+		Payload: params.Payload,
 	}
 
+	before, _ := ReadAccountBalance(snapshot, params.Principal)
+
+	if _, err := applyTransaction(snapshot, round, entry, state, receipt); err != nil {
+		return nil, err
+	}
+
+	after, _ := ReadAccountBalance(snapshot, params.Principal)
+
+	if after >= before {
+		return snapshot, nil
+	}
+
+	spentRound, spent := ReadAccountDelegationSpent(snapshot, params.Principal, tx.Creator)
+	if spentRound != round.Index {
+		spent = 0
+	}
+
+	total, err := Amount(spent).Add(Amount(before - after))
+	if err != nil || uint64(total) > delegation.MaxSpend {
+		return nil, errors.Errorf("delegated: session key %x may only spend %d PERLs per round on behalf of %x, but has spent %d PERLs and attempted to spend %d PERLs",
+			tx.Creator, delegation.MaxSpend, params.Principal, spent, before-after)
+	}
+
+	WriteAccountDelegationSpent(snapshot, params.Principal, tx.Creator, round.Index, uint64(total))
+
 	return snapshot, nil
 }
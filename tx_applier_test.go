@@ -0,0 +1,729 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"encoding/binary"
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+	"testing/quick"
+)
+
+func TestTransactionProcessors(t *testing.T) {
+	// Every tag but that of nops must be handled by a processor.
+	for tag := sys.TagTransfer; tag <= sys.TagTransferToken; tag++ {
+		processor, exists := transactionProcessors[tag]
+		assert.True(t, exists, "tag %d", tag)
+		assert.NotEmpty(t, processor.name, "tag %d", tag)
+	}
+
+	_, exists := transactionProcessors[sys.TagNop]
+	assert.False(t, exists)
+
+	snapshot := avl.New(store.NewInmem())
+
+	// Transactions with tags no processor is registered under are left unapplied.
+	for _, tag := range []byte{sys.TagNop, sys.TagTransferToken + 1} {
+		_, err := applyTransaction(snapshot, &Round{}, &Transaction{Tag: tag}, nil, nil)
+		assert.NoError(t, err)
+	}
+}
+
+func TestParseContractAdminTransaction(t *testing.T) {
+	var contract TransactionID
+	contract[0] = 1
+
+	var owner AccountID
+	owner[0] = 2
+
+	var quota [8]byte
+	binary.LittleEndian.PutUint64(quota[:], 10)
+
+	params, err := ParseContractAdminTransaction(append(contract[:], sys.PauseContract))
+	assert.NoError(t, err)
+	assert.Equal(t, contract, params.ContractID)
+	assert.Equal(t, sys.PauseContract, params.Opcode)
+
+	params, err = ParseContractAdminTransaction(append(append(contract[:], sys.TransferContractOwnership), owner[:]...))
+	assert.NoError(t, err)
+	assert.Equal(t, owner, params.Owner)
+
+	params, err = ParseContractAdminTransaction(append(append(contract[:], sys.SetContractCallQuota), quota[:]...))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 10, params.Quota)
+
+	invalid := [][]byte{
+		contract[:16],
+		contract[:],
+		append(contract[:], 0xFF),
+		append(contract[:], sys.ResumeContract, 0),
+		append(contract[:], sys.TransferContractOwnership),
+		append(append(contract[:], sys.TransferContractOwnership), ZeroAccountID[:]...),
+		append(append(contract[:], sys.SetContractCallQuota), quota[:4]...),
+	}
+
+	for _, payload := range invalid {
+		_, err := ParseContractAdminTransaction(payload)
+		assert.Error(t, err, "%x", payload)
+	}
+}
+
+func TestApplyContractAdminTransaction(t *testing.T) {
+	owner, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	other, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	var contract TransactionID
+	contract[0] = 1
+
+	snapshot := avl.New(store.NewInmem())
+	round := &Round{Index: 1}
+
+	admin := func(keys *skademlia.Keypair, payload ...byte) error {
+		tx := NewTransaction(keys, sys.TagContractAdmin, append(contract[:], payload...))
+		_, err := ApplyContractAdminTransaction(snapshot, round, &tx)
+		return err
+	}
+
+	invoke := func() error {
+		tx := NewTransaction(other, sys.TagTransfer, append(contract[:], make([]byte, 8)...))
+		_, err := ApplyTransferTransaction(snapshot, round, &tx, nil)
+		return err
+	}
+
+	// Contracts which do not exist may not be administered.
+	assert.Error(t, admin(owner, sys.PauseContract))
+
+	WriteAccountContractCode(snapshot, contract, wasmMagic)
+	WriteAccountContractOwner(snapshot, contract, owner.PublicKey())
+
+	// Contracts may only be administered by their owner.
+	assert.Error(t, admin(other, sys.PauseContract))
+	assert.False(t, ReadAccountContractPaused(snapshot, contract))
+
+	assert.NoError(t, admin(owner, sys.PauseContract))
+	assert.True(t, ReadAccountContractPaused(snapshot, contract))
+	assert.Contains(t, invoke().Error(), "is paused")
+
+	assert.NoError(t, admin(owner, sys.ResumeContract))
+	assert.False(t, ReadAccountContractPaused(snapshot, contract))
+	assert.NotContains(t, invoke().Error(), "is paused")
+
+	// Invocations beyond the call quota of a contract within a round are rejected.
+	var quota [8]byte
+	binary.LittleEndian.PutUint64(quota[:], 1)
+
+	assert.NoError(t, admin(owner, append([]byte{sys.SetContractCallQuota}, quota[:]...)...))
+
+	WriteAccountContractCalls(snapshot, contract, round.Index, 1)
+	assert.Contains(t, invoke().Error(), "may only be invoked 1 times per round")
+
+	WriteAccountContractCalls(snapshot, contract, round.Index-1, 1)
+	assert.NotContains(t, invoke().Error(), "may only be invoked")
+
+	lastRound, calls := ReadAccountContractCalls(snapshot, contract)
+	assert.Equal(t, round.Index, lastRound)
+	assert.EqualValues(t, 1, calls)
+
+	// Ownership may be transferred, after which the previous owner may no longer administer the contract.
+	newOwner := other.PublicKey()
+
+	assert.NoError(t, admin(owner, append([]byte{sys.TransferContractOwnership}, newOwner[:]...)...))
+	assert.Error(t, admin(owner, sys.PauseContract))
+	assert.NoError(t, admin(other, sys.PauseContract))
+}
+
+func TestParseUpdateContractTransaction(t *testing.T) {
+	var contract TransactionID
+	contract[0] = 1
+
+	params, err := ParseUpdateContractTransaction(append(contract[:], wasmMagic...))
+	assert.NoError(t, err)
+	assert.Equal(t, contract, params.ContractID)
+	assert.Equal(t, wasmMagic, params.Code)
+
+	_, err = ParseUpdateContractTransaction(contract[:16])
+	assert.Error(t, err)
+
+	_, err = ParseUpdateContractTransaction(contract[:])
+	assert.Error(t, err)
+}
+
+func TestApplyUpdateContractTransaction(t *testing.T) {
+	owner, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	other, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	var contract TransactionID
+	contract[0] = 1
+
+	snapshot := avl.New(store.NewInmem())
+	round := &Round{Index: 1}
+
+	oldCode := []byte("\x00asm\x01\x00\x00\x00")
+	newCode := append([]byte("\x00asm\x01\x00\x00\x00"), 0x01, 0x04, 0x01, 0x60, 0x00, 0x00)
+
+	update := func(keys *skademlia.Keypair, code []byte) error {
+		tx := NewTransaction(keys, sys.TagUpdateContract, append(contract[:], code...))
+		_, err := ApplyUpdateContractTransaction(snapshot, round, &tx)
+		return err
+	}
+
+	// Contracts which do not exist may not be updated.
+	assert.Error(t, update(owner, newCode))
+
+	memory := make([]byte, PageSize)
+	memory[0] = 0xFF
+
+	WriteAccountContractCode(snapshot, contract, oldCode)
+	WriteAccountContractOwner(snapshot, contract, owner.PublicKey())
+	SaveContractMemorySnapshot(snapshot, contract, memory)
+
+	// Contracts may only be updated by their owner, and only with valid code.
+	assert.Error(t, update(other, newCode))
+	assert.Error(t, update(owner, []byte("not wasm")))
+
+	code, _ := ReadAccountContractCode(snapshot, contract)
+	assert.Equal(t, oldCode, code)
+
+	// The memory of the contract is carried over to its new code.
+	assert.NoError(t, update(owner, newCode))
+
+	code, _ = ReadAccountContractCode(snapshot, contract)
+	assert.Equal(t, newCode, code)
+	assert.Equal(t, memory, LoadContractMemorySnapshot(snapshot, contract))
+}
+
+func TestParseDelegateTransaction(t *testing.T) {
+	var key AccountID
+	key[0] = 1
+
+	delegation := Delegation{MaxSpend: 100, AllowedTags: 1 << sys.TagTransfer, Expiry: 10}
+
+	params, err := ParseDelegateTransaction(append(append([]byte{sys.GrantDelegation}, key[:]...), delegation.Marshal()...))
+	assert.NoError(t, err)
+	assert.Equal(t, key, params.Key)
+	assert.Equal(t, delegation, params.Delegation)
+
+	params, err = ParseDelegateTransaction(append([]byte{sys.RevokeDelegation}, key[:]...))
+	assert.NoError(t, err)
+	assert.Equal(t, sys.RevokeDelegation, params.Opcode)
+
+	invalid := [][]byte{
+		{sys.GrantDelegation},
+		append([]byte{0xFF}, key[:]...),
+		append([]byte{sys.RevokeDelegation}, ZeroAccountID[:]...),
+		append([]byte{sys.RevokeDelegation}, append(key[:], 0)...),
+		append(append([]byte{sys.GrantDelegation}, key[:]...), delegation.Marshal()[:12]...),
+		append(append([]byte{sys.GrantDelegation}, key[:]...), Delegation{AllowedTags: 1 << sys.TagBatch, Expiry: 10}.Marshal()...),
+		append(append([]byte{sys.GrantDelegation}, key[:]...), Delegation{AllowedTags: 1 << sys.TagDelegate, Expiry: 10}.Marshal()...),
+		append(append([]byte{sys.GrantDelegation}, key[:]...), Delegation{AllowedTags: 1 << sys.TagTransfer}.Marshal()...),
+	}
+
+	for _, payload := range invalid {
+		_, err := ParseDelegateTransaction(payload)
+		assert.Error(t, err, "%x", payload)
+	}
+
+	_, err = ParseDelegatedTransaction(append(key[:], sys.TagDelegated))
+	assert.Error(t, err)
+
+	_, err = ParseDelegatedTransaction(key[:])
+	assert.Error(t, err)
+}
+
+func TestApplyDelegatedTransaction(t *testing.T) {
+	principal, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	session, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	var recipient AccountID
+	recipient[0] = 1
+
+	snapshot := avl.New(store.NewInmem())
+	round := &Round{Index: 1}
+
+	WriteAccountBalance(snapshot, principal.PublicKey(), 1000)
+
+	delegate := func(opcode byte, delegation Delegation) error {
+		key := session.PublicKey()
+
+		payload := append([]byte{opcode}, key[:]...)
+		if opcode == sys.GrantDelegation {
+			payload = append(payload, delegation.Marshal()...)
+		}
+
+		tx := NewTransaction(principal, sys.TagDelegate, payload)
+		_, err := ApplyDelegateTransaction(snapshot, round, &tx)
+		return err
+	}
+
+	act := func(tag byte, payload []byte) error {
+		id := principal.PublicKey()
+
+		tx := NewTransaction(session, sys.TagDelegated, append(append(id[:], tag), payload...))
+
+		original := snapshot.Snapshot()
+
+		_, err := ApplyDelegatedTransaction(snapshot, round, &tx, nil)
+		if err != nil {
+			snapshot.Revert(original)
+		}
+
+		return err
+	}
+
+	balance := func(id AccountID) uint64 {
+		balance, _ := ReadAccountBalance(snapshot, id)
+		return balance
+	}
+
+	// Session keys may not act on behalf of accounts which have not granted them a delegation.
+	assert.Error(t, act(sys.TagTransfer, transferPayload(recipient, 10)))
+	assert.Error(t, delegate(sys.RevokeDelegation, Delegation{}))
+
+	// Delegations which have already expired may not be granted.
+	assert.Error(t, delegate(sys.GrantDelegation, Delegation{MaxSpend: 100, AllowedTags: 1 << sys.TagTransfer, Expiry: round.Index}))
+
+	assert.NoError(t, delegate(sys.GrantDelegation, Delegation{MaxSpend: 100, AllowedTags: 1 << sys.TagTransfer, Expiry: 3}))
+
+	// Spends are made from the balance of the account, up to the max spend per round.
+	assert.NoError(t, act(sys.TagTransfer, transferPayload(recipient, 60)))
+	assert.EqualValues(t, 940, balance(principal.PublicKey()))
+	assert.EqualValues(t, 60, balance(recipient))
+
+	assert.Contains(t, act(sys.TagTransfer, transferPayload(recipient, 50)).Error(), "may only spend 100 PERLs per round")
+	assert.EqualValues(t, 940, balance(principal.PublicKey()))
+
+	assert.NoError(t, act(sys.TagTransfer, transferPayload(recipient, 40)))
+	assert.EqualValues(t, 900, balance(principal.PublicKey()))
+
+	// Tags not allowed by the delegation are rejected.
+	var stake [9]byte
+	stake[0] = sys.PlaceStake
+	binary.LittleEndian.PutUint64(stake[1:], 10)
+
+	assert.Contains(t, act(sys.TagStake, stake[:]).Error(), "may not make transactions with tag")
+
+	// Spends are tracked per round.
+	round.Index++
+
+	assert.NoError(t, act(sys.TagTransfer, transferPayload(recipient, 100)))
+	assert.EqualValues(t, 800, balance(principal.PublicKey()))
+
+	// Delegations expire, and may be revoked.
+	round.Index++
+
+	assert.Contains(t, act(sys.TagTransfer, transferPayload(recipient, 1)).Error(), "expired")
+
+	assert.NoError(t, delegate(sys.RevokeDelegation, Delegation{}))
+
+	_, exists := ReadAccountDelegation(snapshot, principal.PublicKey(), session.PublicKey())
+	assert.False(t, exists)
+
+	spentRound, spent := ReadAccountDelegationSpent(snapshot, principal.PublicKey(), session.PublicKey())
+	assert.Zero(t, spentRound)
+	assert.Zero(t, spent)
+}
+
+func transferPayload(recipient AccountID, amount uint64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], amount)
+
+	return append(recipient[:], buf[:]...)
+}
+
+func TestApplyTransferTransactionBalances(t *testing.T) {
+	sender, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	var recipient AccountID
+	recipient[0] = 1
+
+	// Balances must be conserved by every transfer which is applied, and be left
+	// untouched by every transfer which underflows or overflows a balance.
+	property := func(senderBalance, recipientBalance, amount uint64) bool {
+		snapshot := avl.New(store.NewInmem())
+
+		WriteAccountBalance(snapshot, sender.PublicKey(), senderBalance)
+		WriteAccountBalance(snapshot, recipient, recipientBalance)
+
+		tx := NewTransaction(sender, sys.TagTransfer, transferPayload(recipient, amount))
+		_, err := ApplyTransferTransaction(snapshot, &Round{}, &tx, nil)
+
+		newSenderBalance, _ := ReadAccountBalance(snapshot, sender.PublicKey())
+		newRecipientBalance, _ := ReadAccountBalance(snapshot, recipient)
+
+		switch {
+		case recipientBalance > math.MaxUint64-amount:
+			return errors.Cause(err) == ErrAmountOverflow && newSenderBalance == senderBalance && newRecipientBalance == recipientBalance
+		case amount > senderBalance:
+			return errors.Cause(err) == ErrAmountUnderflow && newSenderBalance == senderBalance && newRecipientBalance == recipientBalance
+		default:
+			return err == nil && newSenderBalance == senderBalance-amount && newRecipientBalance == recipientBalance+amount
+		}
+	}
+
+	assert.NoError(t, quick.Check(property, nil))
+
+	// Check edge cases which are unlikely to be randomly generated.
+	edges := [][3]uint64{
+		{0, 0, 0},
+		{1, 0, 1},
+		{0, 0, 1},
+		{math.MaxUint64, 0, math.MaxUint64},
+		{math.MaxUint64, 1, math.MaxUint64},
+		{math.MaxUint64, math.MaxUint64, 1},
+		{math.MaxUint64, math.MaxUint64, 0},
+	}
+
+	for _, edge := range edges {
+		assert.True(t, property(edge[0], edge[1], edge[2]), "%v", edge)
+	}
+}
+
+func TestApplyTransferTransactionToSelf(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	snapshot := avl.New(store.NewInmem())
+	WriteAccountBalance(snapshot, keys.PublicKey(), 100)
+
+	tx := NewTransaction(keys, sys.TagTransfer, transferPayload(keys.PublicKey(), 10))
+
+	_, err = ApplyTransferTransaction(snapshot, &Round{}, &tx, nil)
+	assert.Equal(t, ErrTransferToSelf, errors.Cause(err))
+
+	balance, _ := ReadAccountBalance(snapshot, keys.PublicKey())
+	assert.EqualValues(t, 100, balance)
+}
+
+func TestApplyTransferTransactionMemo(t *testing.T) {
+	sender, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	var recipient AccountID
+	recipient[0] = 1
+
+	transfers := []Transfer{
+		{Recipient: recipient, Amount: 10},
+		{Recipient: recipient, Amount: 10, GasLimit: 5},
+		{Recipient: recipient, Amount: 10, Memo: []byte("memo")},
+		{Recipient: recipient, Amount: 10, FuncName: []byte("on_money_received"), Memo: []byte("memo")},
+	}
+
+	for _, transfer := range transfers {
+		params, err := ParseTransferTransaction(transfer.Marshal())
+		assert.NoError(t, err)
+		assert.Equal(t, transfer.Recipient, params.Recipient)
+		assert.Equal(t, transfer.Amount, params.Amount)
+		assert.Equal(t, transfer.GasLimit, params.GasLimit)
+		assert.Equal(t, string(transfer.FuncName), string(params.FuncName))
+		assert.Equal(t, string(transfer.Memo), string(params.Memo))
+	}
+
+	assert.Equal(t, transferPayload(recipient, 10), transfers[0].Marshal())
+
+	_, err = ParseTransferTransaction(transfers[2].Marshal()[:len(transfers[2].Marshal())-1])
+	assert.Error(t, err)
+
+	// Memos are opaque to the ledger, and may be attached to transfers to
+	// accounts which are not smart contracts.
+	snapshot := avl.New(store.NewInmem())
+	WriteAccountBalance(snapshot, sender.PublicKey(), 100)
+
+	tx := NewTransaction(sender, sys.TagTransfer, transfers[2].Marshal())
+
+	_, err = ApplyTransferTransaction(snapshot, &Round{}, &tx, nil)
+	assert.NoError(t, err)
+
+	balance, _ := ReadAccountBalance(snapshot, recipient)
+	assert.EqualValues(t, 10, balance)
+}
+
+func TestApplyTransferTransactionGasLimit(t *testing.T) {
+	sender, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	var contract AccountID
+	contract[0] = 1
+
+	snapshot := avl.New(store.NewInmem())
+	WriteAccountContractCode(snapshot, contract, wasmMagic)
+
+	var gasLimit [8]byte
+	binary.LittleEndian.PutUint64(gasLimit[:], math.MaxUint64)
+
+	// The gas limit claimed on top of the amount sent must not be able to
+	// overflow past the balance check of the sender.
+	WriteAccountBalance(snapshot, sender.PublicKey(), math.MaxUint64)
+
+	tx := NewTransaction(sender, sys.TagTransfer, append(transferPayload(contract, 1), gasLimit[:]...))
+
+	_, err = ApplyTransferTransaction(snapshot, &Round{}, &tx, nil)
+	assert.Equal(t, ErrAmountOverflow, errors.Cause(err))
+
+	// The sender must be able to afford both the amount sent and the gas limit claimed.
+	binary.LittleEndian.PutUint64(gasLimit[:], 10)
+	WriteAccountBalance(snapshot, sender.PublicKey(), 15)
+
+	tx = NewTransaction(sender, sys.TagTransfer, append(transferPayload(contract, 10), gasLimit[:]...))
+
+	_, err = ApplyTransferTransaction(snapshot, &Round{}, &tx, nil)
+	assert.Equal(t, ErrAmountUnderflow, errors.Cause(err))
+}
+
+func TestApplyContractQueueLimits(t *testing.T) {
+	snapshot := avl.New(store.NewInmem())
+	round := &Round{}
+
+	nops := func(n int) []*Transaction {
+		queue := make([]*Transaction, n)
+		for i := range queue {
+			queue[i] = &Transaction{Tag: sys.TagNop}
+		}
+
+		return queue
+	}
+
+	state := &ContractExecutorState{}
+	assert.NoError(t, applyContractQueue(snapshot, round, nops(sys.Params().MaxContractQueuedTransactions), state))
+	assert.Equal(t, 0, state.Depth)
+	assert.Equal(t, sys.Params().MaxContractQueuedTransactions, state.Queued)
+
+	// Transactions queued up count towards the same limit across the entire originating transaction.
+	err := applyContractQueue(snapshot, round, nops(1), state)
+	assert.Equal(t, ErrContractQueueLimitExceeded, errors.Cause(err))
+
+	state = &ContractExecutorState{Depth: sys.Params().MaxContractQueueDepth - 1}
+	assert.NoError(t, applyContractQueue(snapshot, round, nops(1), state))
+
+	state = &ContractExecutorState{Depth: sys.Params().MaxContractQueueDepth}
+	err = applyContractQueue(snapshot, round, nops(1), state)
+	assert.Equal(t, ErrContractQueueDepthExceeded, errors.Cause(err))
+
+	// Empty queues are always fine.
+	assert.NoError(t, applyContractQueue(snapshot, round, nil, state))
+}
+
+func TestApplyContractQueueBatchLimits(t *testing.T) {
+	defer func(params sys.ConsensusParams) {
+		sys.UpdateParams(func(p *sys.ConsensusParams) { *p = params })
+	}(sys.Params())
+
+	sys.UpdateParams(func(p *sys.ConsensusParams) { p.MaxContractQueuedTransactions = 4 })
+
+	snapshot := avl.New(store.NewInmem())
+	round := &Round{}
+
+	batchOf := func(n int) []*Transaction {
+		var batch Batch
+
+		for i := 0; i < n; i++ {
+			assert.NoError(t, batch.Add(sys.TagNop, nil))
+		}
+
+		return []*Transaction{{Tag: sys.TagBatch, Payload: batch.Marshal()}}
+	}
+
+	// Entries of batches queued up by smart contracts count towards the same
+	// limit as the batch itself.
+	state := &ContractExecutorState{}
+	assert.NoError(t, applyContractQueue(snapshot, round, batchOf(3), state))
+	assert.Equal(t, 4, state.Queued)
+	assert.Equal(t, 0, state.Depth)
+
+	state = &ContractExecutorState{}
+	err := applyContractQueue(snapshot, round, batchOf(4), state)
+	assert.Equal(t, ErrContractQueueLimitExceeded, errors.Cause(err))
+
+	// Batches not queued up by smart contracts are not limited.
+	tx := &Transaction{Tag: sys.TagBatch, Payload: batchOf(8)[0].Payload}
+
+	_, err = applyTransaction(snapshot, round, tx, nil, nil)
+	assert.NoError(t, err)
+}
+
+func TestParseTokenTransactions(t *testing.T) {
+	create := CreateToken{Supply: 1000, Decimals: 2, Symbol: "GOLD"}
+
+	params, err := ParseCreateTokenTransaction(create.Marshal())
+	assert.NoError(t, err)
+	assert.Equal(t, create, params)
+
+	for _, invalid := range []CreateToken{
+		{Supply: 0, Decimals: 2, Symbol: "GOLD"},
+		{Supply: 1000, Decimals: sys.MaxTokenDecimals + 1, Symbol: "GOLD"},
+		{Supply: 1000, Decimals: 2},
+		{Supply: 1000, Decimals: 2, Symbol: "gold"},
+		{Supply: 1000, Decimals: 2, Symbol: "GOLDGOLDGOLDGOLD"},
+	} {
+		_, err := ParseCreateTokenTransaction(invalid.Marshal())
+		assert.Error(t, err, "%+v", invalid)
+	}
+
+	_, err = ParseCreateTokenTransaction([]byte{1})
+	assert.Error(t, err)
+
+	transfer := TransferToken{TokenID: TransactionID{1}, Recipient: AccountID{2}, Amount: 10}
+
+	parsed, err := ParseTransferTokenTransaction(transfer.Marshal())
+	assert.NoError(t, err)
+	assert.Equal(t, transfer, parsed)
+
+	_, err = ParseTransferTokenTransaction(TransferToken{TokenID: TransactionID{1}, Recipient: AccountID{2}}.Marshal())
+	assert.Error(t, err)
+
+	_, err = ParseTransferTokenTransaction(transfer.Marshal()[1:])
+	assert.Error(t, err)
+}
+
+func TestApplyTokenTransactions(t *testing.T) {
+	alice, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	bob, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	snapshot := avl.New(store.NewInmem())
+	round := &Round{Index: 1}
+
+	create := NewTransaction(alice, sys.TagCreateToken, CreateToken{Supply: 1000, Decimals: 2, Symbol: "GOLD"}.Marshal())
+
+	_, err = applyTransaction(snapshot, round, &create, nil, nil)
+	assert.NoError(t, err)
+
+	token, exists := ReadToken(snapshot, create.ID)
+	assert.True(t, exists)
+	assert.Equal(t, Token{Creator: alice.PublicKey(), Supply: 1000, Decimals: 2, Symbol: "GOLD"}, token)
+
+	balance, _ := ReadAccountTokenBalance(snapshot, alice.PublicKey(), create.ID)
+	assert.EqualValues(t, 1000, balance)
+
+	// Tokens may only be created once, and not by smart contracts.
+	_, err = applyTransaction(snapshot, round, &create, nil, nil)
+	assert.Error(t, err)
+
+	queued := NewTransaction(bob, sys.TagCreateToken, CreateToken{Supply: 1, Symbol: "SILVER"}.Marshal())
+	_, err = applyTransaction(snapshot, round, &queued, &ContractExecutorState{}, nil)
+	assert.Error(t, err)
+
+	// Nor may they be created by batches queued up by smart contracts, which
+	// would otherwise have the token be identified by the empty ID of the
+	// queued up batch.
+	var batch Batch
+	assert.NoError(t, batch.Add(sys.TagCreateToken, CreateToken{Supply: 1, Symbol: "SILVER"}.Marshal()))
+
+	fresh := avl.New(store.NewInmem())
+
+	err = applyContractQueue(fresh, round, []*Transaction{{Creator: bob.PublicKey(), Tag: sys.TagBatch, Payload: batch.Marshal()}}, &ContractExecutorState{})
+	assert.Error(t, err)
+
+	_, exists = ReadToken(fresh, TransactionID{})
+	assert.False(t, exists)
+
+	transfer := func(keys *skademlia.Keypair, token TransactionID, recipient AccountID, amount uint64) error {
+		tx := NewTransaction(keys, sys.TagTransferToken, TransferToken{TokenID: token, Recipient: recipient, Amount: amount}.Marshal())
+		_, err := applyTransaction(snapshot, round, &tx, nil, nil)
+		return err
+	}
+
+	assert.NoError(t, transfer(alice, create.ID, bob.PublicKey(), 300))
+
+	// Tokens which do not exist may not be transferred, nor may more tokens
+	// be transferred than are held, nor may tokens be transferred to oneself.
+	assert.Error(t, transfer(alice, TransactionID{1}, bob.PublicKey(), 1))
+	assert.Error(t, transfer(bob, create.ID, alice.PublicKey(), 301))
+	assert.Equal(t, ErrTransferToSelf, errors.Cause(transfer(alice, create.ID, alice.PublicKey(), 1)))
+
+	assert.Equal(t, []TokenBalance{{TokenID: create.ID, Balance: 700}}, ReadAccountTokenBalances(snapshot, alice.PublicKey()))
+	assert.Equal(t, []TokenBalance{{TokenID: create.ID, Balance: 300}}, ReadAccountTokenBalances(snapshot, bob.PublicKey()))
+
+	// The native balances of accounts are left untouched.
+	native, _ := ReadAccountBalance(snapshot, alice.PublicKey())
+	assert.Zero(t, native)
+}
+
+func TestApplyBatchTransaction(t *testing.T) {
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	snapshot := avl.New(store.NewInmem())
+	round := &Round{Index: 1}
+
+	WriteAccountBalance(snapshot, keys.PublicKey(), 100)
+
+	var batch Batch
+
+	for i := byte(1); i <= 3; i++ {
+		assert.NoError(t, batch.Add(sys.TagTransfer, Transfer{Recipient: AccountID{i}, Amount: 30}.Marshal()))
+	}
+
+	assert.Error(t, batch.Add(sys.TagBatch, batch.Marshal()))
+
+	parsed, err := ParseBatchTransaction(batch.Marshal())
+	assert.NoError(t, err)
+	assert.Equal(t, batch, parsed)
+
+	_, err = ParseBatchTransaction([]byte{0})
+	assert.Error(t, err)
+
+	tx := NewTransaction(keys, sys.TagBatch, batch.Marshal())
+
+	_, err = applyTransaction(snapshot, round, &tx, nil, nil)
+	assert.NoError(t, err)
+
+	balance, _ := ReadAccountBalance(snapshot, keys.PublicKey())
+	assert.EqualValues(t, 10, balance)
+
+	for i := byte(1); i <= 3; i++ {
+		balance, _ := ReadAccountBalance(snapshot, AccountID{i})
+		assert.EqualValues(t, 30, balance)
+	}
+
+	// Should any entry fail, none of the entries before it are applied.
+	var failing Batch
+
+	assert.NoError(t, failing.Add(sys.TagTransfer, Transfer{Recipient: AccountID{1}, Amount: 5}.Marshal()))
+	assert.NoError(t, failing.Add(sys.TagTransfer, Transfer{Recipient: AccountID{2}, Amount: 10}.Marshal()))
+
+	tx = NewTransaction(keys, sys.TagBatch, failing.Marshal())
+
+	_, err = applyTransaction(snapshot, round, &tx, nil, nil)
+	assert.Error(t, err)
+
+	balance, _ = ReadAccountBalance(snapshot, keys.PublicKey())
+	assert.EqualValues(t, 10, balance)
+
+	balance, _ = ReadAccountBalance(snapshot, AccountID{1})
+	assert.EqualValues(t, 30, balance)
+}
@@ -0,0 +1,199 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"bytes"
+	"github.com/perlin-network/noise/edwards25519"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/pkg/errors"
+	"github.com/rcrowley/go-metrics"
+	"time"
+)
+
+// TransactionCheck is a check a transaction must pass before it is added to
+// the graph, and thereafter gossiped to peers. Checks are run while the graph
+// is locked, and must therefore not call into the graph.
+type TransactionCheck func(tx *Transaction) error
+
+// transactionStage is a single named stage of the pipeline of checks run
+// against transactions being added to the graph, alongside the time spent
+// running it and the number of transactions it has rejected.
+type transactionStage struct {
+	name  string
+	check TransactionCheck
+
+	latency  metrics.Timer
+	rejected metrics.Counter
+}
+
+type namedTransactionCheck struct {
+	name  string
+	check TransactionCheck
+}
+
+// CheckTransactions appends check under name to the end of the pipeline of
+// checks run against transactions being added to the graph, after all of the
+// checks built into the graph have passed.
+func CheckTransactions(name string, check TransactionCheck) GraphOption {
+	return func(graph *Graph) {
+		graph.customChecks = append(graph.customChecks, namedTransactionCheck{name: name, check: check})
+	}
+}
+
+// buildTransactionPipeline assembles the checks built into the graph followed
+// by any custom checks, in the order they are to be run. Cheaper checks are
+// run first, such that a malformed transaction is rejected before its
+// signatures are verified.
+func (g *Graph) buildTransactionPipeline() {
+	checks := []namedTransactionCheck{
+		{name: "format", check: g.checkTransactionFormat},
+		{name: "depth", check: g.checkTransactionDepth},
+		{name: "parents", check: g.checkTransactionParents},
+		{name: "payload", check: g.checkTransactionPayload},
+		{name: "signature", check: g.checkTransactionSignatures},
+	}
+
+	checks = append(checks, g.customChecks...)
+
+	g.pipeline = make([]transactionStage, 0, len(checks))
+
+	for _, c := range checks {
+		stage := transactionStage{name: c.name, check: c.check, latency: metrics.NilTimer{}, rejected: metrics.NilCounter{}}
+
+		if g.metrics != nil {
+			stage.latency = metrics.GetOrRegisterTimer("tx.check."+c.name+".latency", g.metrics.registry)
+			stage.rejected = metrics.GetOrRegisterCounter("tx.check."+c.name+".rejected", g.metrics.registry)
+		}
+
+		g.pipeline = append(g.pipeline, stage)
+	}
+}
+
+func (g *Graph) validateTransaction(tx Transaction) error {
+	for _, stage := range g.pipeline {
+		start := time.Now()
+		err := stage.check(&tx)
+		stage.latency.UpdateSince(start)
+
+		if err != nil {
+			stage.rejected.Inc(1)
+			return errors.Wrapf(err, "tx failed %s check", stage.name)
+		}
+	}
+
+	return nil
+}
+
+func (g *Graph) checkTransactionFormat(tx *Transaction) error {
+	if tx.ID == ZeroTransactionID {
+		return errors.New("tx must have an ID")
+	}
+
+	if tx.Sender == ZeroAccountID {
+		return errors.New("tx must have sender associated to it")
+	}
+
+	if tx.Creator == ZeroAccountID {
+		return errors.New("tx must have a creator associated to it")
+	}
+
+	if tx.Tag > sys.TagTransferToken {
+		return errors.New("tx has an unknown tag")
+	}
+
+	return nil
+}
+
+func (g *Graph) checkTransactionDepth(tx *Transaction) error {
+	if g.rootDepth > sys.Params().MaxDepthDiff+tx.Depth {
+		return errors.Errorf("transactions depth is too low compared to root: root depth is %d, but tx depth is %d", g.rootDepth, tx.Depth)
+	}
+
+	return nil
+}
+
+func (g *Graph) checkTransactionParents(tx *Transaction) error {
+	if len(tx.ParentIDs) == 0 {
+		return errors.New("transaction has no parents")
+	}
+
+	if len(tx.ParentIDs) > sys.MaxParentsPerTransaction {
+		return errors.Errorf("tx has %d parents, but tx may only have %d parents at most", len(tx.ParentIDs), sys.MaxParentsPerTransaction)
+	}
+
+	// Check that parents are lexicographically sorted, are not itself, and are unique.
+	set := make(map[TransactionID]struct{}, len(tx.ParentIDs))
+
+	for i := len(tx.ParentIDs) - 1; i > 0; i-- {
+		if tx.ID == tx.ParentIDs[i] {
+			return errors.New("tx must not include itself in its parents")
+		}
+
+		if bytes.Compare(tx.ParentIDs[i-1][:], tx.ParentIDs[i][:]) > 0 {
+			return errors.New("tx must have lexicographically sorted parent ids")
+		}
+
+		if _, duplicate := set[tx.ParentIDs[i]]; duplicate {
+			return errors.New("tx must not have duplicate parent ids")
+		}
+
+		set[tx.ParentIDs[i]] = struct{}{}
+	}
+
+	return nil
+}
+
+func (g *Graph) checkTransactionPayload(tx *Transaction) error {
+	if tx.Tag != sys.TagNop && len(tx.Payload) == 0 {
+		return errors.New("tx must have payload if not a nop transaction")
+	}
+
+	if tx.Tag == sys.TagNop && len(tx.Payload) != 0 {
+		return errors.New("tx must have no payload if is a nop transaction")
+	}
+
+	if len(tx.Payload) > sys.MaxTransactionPayloadSize {
+		return errors.Errorf("tx has a payload of %d bytes, but tx payloads may only be %d bytes at most", len(tx.Payload), sys.MaxTransactionPayloadSize)
+	}
+
+	return nil
+}
+
+func (g *Graph) checkTransactionSignatures(tx *Transaction) error {
+	if !g.verifySignatures {
+		return nil
+	}
+
+	if tx.Sender != tx.Creator {
+		if !edwards25519.Verify(tx.Creator, tx.creatorMessage(), tx.CreatorSignature) {
+			return ErrInvalidCreatorSignature
+		}
+	}
+
+	cpy := *tx
+	cpy.SenderSignature = ZeroSignature
+
+	if !edwards25519.Verify(tx.Sender, cpy.Marshal(), tx.SenderSignature) {
+		return ErrInvalidSenderSignature
+	}
+
+	return nil
+}
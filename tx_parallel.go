@@ -0,0 +1,111 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"github.com/perlin-network/wavelet/avl"
+	"sync"
+)
+
+// speculation is a transaction applied ahead of the transactions before it
+// within a round to a fork of the state the round started from.
+type speculation struct {
+	tx *Transaction
+
+	changes *avl.Changes
+	receipt *Receipt
+	err     error
+
+	done chan struct{}
+}
+
+// collapseTransactionsInParallel applies txs to res.snapshot in order across
+// a pool of workers, yielding the exact same state, receipts, and rejections
+// applying them one after another would.
+//
+// Each transaction is speculatively applied on a worker to a fork of the state
+// the round started from, recording the keys it read and the changes it made.
+// Speculations are then committed in order by replaying their changes. Should
+// a transaction have read any key written by a transaction committed before
+// it, such as when both are sent from the same account, it is applied again on
+// top of the state all transactions before it were committed to.
+//
+// Validator rewards are credited without being read (see RewardValidators),
+// such that transactions rewarding the same validator do not conflict.
+func (l *Ledger) collapseTransactionsInParallel(res *CollapseResults, round uint64, root Transaction, txs []*Transaction, workers int) {
+	if workers > len(txs) {
+		workers = len(txs)
+	}
+
+	specs := make([]*speculation, len(txs))
+	pending := make(chan *speculation, len(txs))
+
+	for i, tx := range txs {
+		specs[i] = &speculation{tx: tx, done: make(chan struct{})}
+		pending <- specs[i]
+	}
+
+	close(pending)
+
+	base := res.snapshot.Snapshot()
+	base.SetViewID(round)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+
+			for spec := range pending {
+				fork, changes := base.Track()
+
+				spec.changes = changes
+				spec.receipt, spec.err = l.collapseTransaction(fork, round, root, spec.tx, false)
+
+				close(spec.done)
+			}
+		}()
+	}
+
+	// Keys written to since the round started.
+	written := make(map[string]struct{})
+
+	for _, spec := range specs {
+		<-spec.done
+
+		if spec.changes.ReadsAny(written) || !spec.changes.Replay(res.snapshot) {
+			fork, changes := res.snapshot.Track()
+
+			spec.changes = changes
+			spec.receipt, spec.err = l.collapseTransaction(fork, round, root, spec.tx, false)
+
+			// The changes may not fail to be replayed, as nothing has
+			// been written since the fork was made.
+			changes.Replay(res.snapshot)
+		}
+
+		spec.changes.AddWritten(written)
+
+		res.record(spec.tx, spec.receipt, spec.err)
+	}
+
+	wg.Wait()
+}
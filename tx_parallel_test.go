@@ -0,0 +1,102 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCollapseTransactionsInParallel(t *testing.T) {
+	defer func(workers int) { sys.ApplyWorkers = workers }(sys.ApplyWorkers)
+
+	l := newTestLedger(t)
+
+	keys := make([]*skademlia.Keypair, 6)
+
+	snapshot := l.Snapshot()
+
+	for i := range keys {
+		k, err := skademlia.NewKeys(1, 1)
+		assert.NoError(t, err)
+
+		keys[i] = k
+
+		// Have every account be eligible to be rewarded transaction fees.
+		WriteAccountBalance(snapshot, k.PublicKey(), 1000000)
+		WriteAccountStake(snapshot, k.PublicKey(), sys.Params().MinimumStake+1)
+	}
+
+	assert.NoError(t, l.accounts.Commit(snapshot))
+
+	var end *Transaction
+
+	for i := 0; i < 48; i++ {
+		sender, recipient := keys[i%len(keys)], keys[(i*5+1)%len(keys)]
+		if sender == recipient {
+			recipient = keys[(i+1)%len(keys)]
+		}
+
+		amount := uint64(1 + i)
+
+		// Have some transfers be rejected for exceeding the balance of
+		// their sender.
+		if i%11 == 10 {
+			amount = 10000000
+		}
+
+		tx := AttachSenderToTransaction(sender, NewTransaction(sender, sys.TagTransfer, transferPayload(recipient.PublicKey(), amount)), l.Graph().FindEligibleParents()...)
+		assert.NoError(t, l.Graph().AddTransaction(tx))
+
+		end = l.Graph().FindTransaction(tx.ID)
+	}
+
+	root := l.Rounds().Latest().End
+
+	collapse := func(workers int) *CollapseResults {
+		sys.ApplyWorkers = workers
+		l.cacheCollapse = NewLRU(16)
+
+		results, err := l.CollapseTransactions(1, root, *end, false)
+		assert.NoError(t, err)
+
+		return results
+	}
+
+	sequential, parallel := collapse(1), collapse(4)
+
+	assert.NotZero(t, sequential.appliedCount)
+	assert.NotZero(t, sequential.rejectedCount)
+
+	// Applying transactions in parallel must yield the exact same state as
+	// applying them one after another does.
+	assert.Equal(t, sequential.snapshot.Checksum(), parallel.snapshot.Checksum())
+	assert.Equal(t, sequential.applied, parallel.applied)
+	assert.Equal(t, sequential.rejected, parallel.rejected)
+	assert.Equal(t, sequential.receipts, parallel.receipts)
+
+	if assert.Len(t, parallel.rejectedErrors, len(sequential.rejectedErrors)) {
+		for i, err := range sequential.rejectedErrors {
+			assert.EqualError(t, parallel.rejectedErrors[i], err.Error())
+		}
+	}
+}
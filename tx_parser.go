@@ -26,6 +26,7 @@ import (
 	"github.com/pkg/errors"
 	"io"
 	"io/ioutil"
+	"math"
 )
 
 type Transfer struct {
@@ -35,6 +36,50 @@ type Transfer struct {
 
 	FuncName   []byte
 	FuncParams []byte
+
+	// Memo is a private note attached to the transfer, which is encrypted to
+	// the recipient client-side. It is opaque to consensus. See package memo.
+	Memo []byte
+}
+
+// Marshal encodes the transfer into the payload of a transfer transaction.
+// Optional fields are only written if they, or any field after them, are set.
+func (t Transfer) Marshal() []byte {
+	w := bytes.NewBuffer(nil)
+	b := make([]byte, 8)
+
+	w.Write(t.Recipient[:])
+
+	binary.LittleEndian.PutUint64(b, t.Amount)
+	w.Write(b)
+
+	hasFuncName := len(t.FuncName) > 0 || len(t.FuncParams) > 0 || len(t.Memo) > 0
+	hasFuncParams := len(t.FuncParams) > 0 || len(t.Memo) > 0
+
+	if t.GasLimit > 0 || hasFuncName {
+		binary.LittleEndian.PutUint64(b, t.GasLimit)
+		w.Write(b)
+	}
+
+	if hasFuncName {
+		binary.LittleEndian.PutUint32(b[:4], uint32(len(t.FuncName)))
+		w.Write(b[:4])
+		w.Write(t.FuncName)
+	}
+
+	if hasFuncParams {
+		binary.LittleEndian.PutUint32(b[:4], uint32(len(t.FuncParams)))
+		w.Write(b[:4])
+		w.Write(t.FuncParams)
+	}
+
+	if len(t.Memo) > 0 {
+		binary.LittleEndian.PutUint32(b[:4], uint32(len(t.Memo)))
+		w.Write(b[:4])
+		w.Write(t.Memo)
+	}
+
+	return w.Bytes()
 }
 
 // ParseTransferTransaction parses and performs sanity checks on the payload of a transfer transaction.
@@ -86,6 +131,18 @@ func ParseTransferTransaction(payload []byte) (Transfer, error) {
 		}
 	}
 
+	if r.Len() > 0 {
+		if _, err := io.ReadFull(r, b[:4]); err != nil {
+			return tx, errors.Wrap(err, "transfer: failed to decode size of memo")
+		}
+
+		tx.Memo = make([]byte, binary.LittleEndian.Uint32(b[:4]))
+
+		if _, err := io.ReadFull(r, tx.Memo); err != nil {
+			return tx, errors.Wrap(err, "transfer: failed to decode memo")
+		}
+	}
+
 	return tx, nil
 }
 
@@ -117,6 +174,172 @@ func ParseStakeTransaction(payload []byte) (Stake, error) {
 	return tx, nil
 }
 
+type ContractAdmin struct {
+	ContractID TransactionID
+	Opcode     byte
+
+	Owner AccountID
+	Quota uint64
+}
+
+// ParseContractAdminTransaction parses and performs sanity checks on the payload of a contract administration
+// transaction.
+func ParseContractAdminTransaction(payload []byte) (ContractAdmin, error) {
+	r := bytes.NewReader(payload)
+
+	tx := ContractAdmin{}
+
+	if _, err := io.ReadFull(r, tx.ContractID[:]); err != nil {
+		return tx, errors.Wrap(err, "contract admin: failed to decode contract ID")
+	}
+
+	opcode, err := r.ReadByte()
+	if err != nil {
+		return tx, errors.Wrap(err, "contract admin: failed to decode opcode")
+	}
+
+	tx.Opcode = opcode
+
+	switch tx.Opcode {
+	case sys.PauseContract, sys.ResumeContract:
+	case sys.TransferContractOwnership:
+		if _, err := io.ReadFull(r, tx.Owner[:]); err != nil {
+			return tx, errors.Wrap(err, "contract admin: failed to decode new owner")
+		}
+
+		if tx.Owner == ZeroAccountID {
+			return tx, errors.New("contract admin: new owner must not be empty")
+		}
+	case sys.SetContractCallQuota:
+		var buf [8]byte
+
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return tx, errors.Wrap(err, "contract admin: failed to decode call quota")
+		}
+
+		tx.Quota = binary.LittleEndian.Uint64(buf[:])
+	default:
+		return tx, errors.New("contract admin: opcode must be 0, 1, 2, or 3")
+	}
+
+	if r.Len() > 0 {
+		return tx, errors.Errorf("contract admin: payload has %d unexpected trailing bytes", r.Len())
+	}
+
+	return tx, nil
+}
+
+type UpdateContract struct {
+	ContractID TransactionID
+	Code       []byte
+}
+
+// ParseUpdateContractTransaction parses and performs sanity checks on the payload of a transaction replacing the code
+// of a smart contract.
+func ParseUpdateContractTransaction(payload []byte) (UpdateContract, error) {
+	tx := UpdateContract{}
+
+	if len(payload) < SizeTransactionID {
+		return tx, errors.New("update contract: failed to decode contract ID")
+	}
+
+	copy(tx.ContractID[:], payload[:SizeTransactionID])
+
+	tx.Code = payload[SizeTransactionID:]
+
+	if len(tx.Code) == 0 {
+		return tx, errors.New("update contract: new code must not be empty")
+	}
+
+	return tx, nil
+}
+
+type Delegate struct {
+	Opcode byte
+	Key    AccountID // Session key being granted or revoked a delegation.
+
+	Delegation Delegation
+}
+
+// ParseDelegateTransaction parses and performs sanity checks on the payload of a transaction granting a session key
+// a delegation to act on behalf of an account, or revoking it.
+func ParseDelegateTransaction(payload []byte) (Delegate, error) {
+	r := bytes.NewReader(payload)
+
+	tx := Delegate{}
+
+	opcode, err := r.ReadByte()
+	if err != nil {
+		return tx, errors.Wrap(err, "delegate: failed to decode opcode")
+	}
+
+	tx.Opcode = opcode
+
+	if _, err := io.ReadFull(r, tx.Key[:]); err != nil {
+		return tx, errors.Wrap(err, "delegate: failed to decode session key")
+	}
+
+	if tx.Key == ZeroAccountID {
+		return tx, errors.New("delegate: session key must not be empty")
+	}
+
+	switch tx.Opcode {
+	case sys.GrantDelegation:
+		var buf [SizeDelegation]byte
+
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return tx, errors.Wrap(err, "delegate: failed to decode delegation")
+		}
+
+		tx.Delegation = UnmarshalDelegation(buf[:])
+
+		if tx.Delegation.AllowedTags&^delegableTags != 0 {
+			return tx, errors.Errorf("delegate: only nop, transfer, contract, stake, contract admin, and update contract transactions may be delegated, but got tags %b", tx.Delegation.AllowedTags)
+		}
+
+		if tx.Delegation.Expiry == 0 {
+			return tx, errors.New("delegate: delegation must expire after round 0")
+		}
+	case sys.RevokeDelegation:
+	default:
+		return tx, errors.New("delegate: opcode must be 0 or 1")
+	}
+
+	if r.Len() > 0 {
+		return tx, errors.Errorf("delegate: payload has %d unexpected trailing bytes", r.Len())
+	}
+
+	return tx, nil
+}
+
+type Delegated struct {
+	Principal AccountID // Account the transaction is made on behalf of.
+
+	Tag     byte
+	Payload []byte
+}
+
+// ParseDelegatedTransaction parses and performs sanity checks on the payload of a transaction made by a session key
+// on behalf of an account.
+func ParseDelegatedTransaction(payload []byte) (Delegated, error) {
+	tx := Delegated{}
+
+	if len(payload) < SizeAccountID+1 {
+		return tx, errors.Errorf("delegated: payload must be at least %d bytes", SizeAccountID+1)
+	}
+
+	copy(tx.Principal[:], payload[:SizeAccountID])
+
+	tx.Tag = payload[SizeAccountID]
+	tx.Payload = payload[SizeAccountID+1:]
+
+	if tx.Tag > sys.TagTransferToken || delegableTags&(1<<tx.Tag) == 0 {
+		return tx, errors.Errorf("delegated: transactions with tag %d may not be delegated", tx.Tag)
+	}
+
+	return tx, nil
+}
+
 type Contract struct {
 	GasLimit uint64
 
@@ -160,12 +383,50 @@ func ParseContractTransaction(payload []byte) (Contract, error) {
 	return tx, nil
 }
 
+// Batch is a list of operations applied all-or-nothing under a single
+// signature, such that should any one of them fail, none of them are applied.
 type Batch struct {
 	Size     uint8
 	Tags     []uint8
 	Payloads [][]byte
 }
 
+// Add appends an operation tagged tag with payload to the batch. A batch may
+// hold at most math.MaxUint8 operations, none of which may be batches.
+func (b *Batch) Add(tag uint8, payload []byte) error {
+	if tag == sys.TagBatch {
+		return errors.New("batch: entries inside batch cannot be batch transactions themselves")
+	}
+
+	if b.Size == math.MaxUint8 {
+		return errors.Errorf("batch: batches may only hold %d entries at most", math.MaxUint8)
+	}
+
+	b.Size++
+	b.Tags = append(b.Tags, tag)
+	b.Payloads = append(b.Payloads, payload)
+
+	return nil
+}
+
+// Marshal encodes the batch into the payload of a batch transaction.
+func (b Batch) Marshal() []byte {
+	buf := bytes.NewBuffer(nil)
+	buf.WriteByte(b.Size)
+
+	var size [4]byte
+
+	for i := uint8(0); i < b.Size; i++ {
+		buf.WriteByte(b.Tags[i])
+
+		binary.BigEndian.PutUint32(size[:], uint32(len(b.Payloads[i])))
+		buf.Write(size[:])
+		buf.Write(b.Payloads[i])
+	}
+
+	return buf.Bytes()
+}
+
 // ParseBatchTransaction parses and performs sanity checks on the payload of a batch transaction.
 func ParseBatchTransaction(payload []byte) (Batch, error) {
 	r := bytes.NewReader(payload)
@@ -177,6 +438,10 @@ func ParseBatchTransaction(payload []byte) (Batch, error) {
 		return tx, errors.Wrap(err, "batch: failed to decode number of transactions in batch")
 	}
 
+	if b[0] == 0 {
+		return tx, errors.New("batch: batch must hold at least one entry")
+	}
+
 	tx.Size = b[0]
 	tx.Tags = make([]uint8, tx.Size)
 	tx.Payloads = make([][]byte, tx.Size)
@@ -205,3 +470,92 @@ func ParseBatchTransaction(payload []byte) (Batch, error) {
 
 	return tx, nil
 }
+
+type CreateToken struct {
+	Supply   uint64
+	Decimals uint8
+	Symbol   string
+}
+
+// Marshal encodes the token into the payload of a transaction creating it.
+func (t CreateToken) Marshal() []byte {
+	buf := make([]byte, 8+1+len(t.Symbol))
+
+	binary.LittleEndian.PutUint64(buf[0:8], t.Supply)
+	buf[8] = t.Decimals
+	copy(buf[9:], t.Symbol)
+
+	return buf
+}
+
+// ParseCreateTokenTransaction parses and performs sanity checks on the payload of a transaction issuing a fungible
+// token.
+func ParseCreateTokenTransaction(payload []byte) (CreateToken, error) {
+	tx := CreateToken{}
+
+	if len(payload) < 8+1 {
+		return tx, errors.Errorf("create token: payload must be at least %d bytes", 8+1)
+	}
+
+	tx.Supply = binary.LittleEndian.Uint64(payload[0:8])
+	tx.Decimals = payload[8]
+	tx.Symbol = string(payload[9:])
+
+	if tx.Supply == 0 {
+		return tx, errors.New("create token: supply must be greater than zero")
+	}
+
+	if tx.Decimals > sys.MaxTokenDecimals {
+		return tx, errors.Errorf("create token: tokens may have at most %d decimals, but got %d decimals", sys.MaxTokenDecimals, tx.Decimals)
+	}
+
+	if len(tx.Symbol) == 0 || len(tx.Symbol) > sys.MaxTokenSymbolLength {
+		return tx, errors.Errorf("create token: symbol must be between 1 and %d bytes, but got %d bytes", sys.MaxTokenSymbolLength, len(tx.Symbol))
+	}
+
+	for _, c := range tx.Symbol {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return tx, errors.Errorf("create token: symbol %q may only consist of uppercase letters and digits", tx.Symbol)
+		}
+	}
+
+	return tx, nil
+}
+
+type TransferToken struct {
+	TokenID   TransactionID
+	Recipient AccountID
+	Amount    uint64
+}
+
+// Marshal encodes the transfer into the payload of a token transfer transaction.
+func (t TransferToken) Marshal() []byte {
+	buf := make([]byte, SizeTransactionID+SizeAccountID+8)
+
+	copy(buf[:SizeTransactionID], t.TokenID[:])
+	copy(buf[SizeTransactionID:SizeTransactionID+SizeAccountID], t.Recipient[:])
+	binary.LittleEndian.PutUint64(buf[SizeTransactionID+SizeAccountID:], t.Amount)
+
+	return buf
+}
+
+// ParseTransferTokenTransaction parses and performs sanity checks on the payload of a transaction transferring a
+// fungible token.
+func ParseTransferTokenTransaction(payload []byte) (TransferToken, error) {
+	tx := TransferToken{}
+
+	if len(payload) != SizeTransactionID+SizeAccountID+8 {
+		return tx, errors.Errorf("transfer token: payload must be exactly %d bytes", SizeTransactionID+SizeAccountID+8)
+	}
+
+	copy(tx.TokenID[:], payload[:SizeTransactionID])
+	copy(tx.Recipient[:], payload[SizeTransactionID:SizeTransactionID+SizeAccountID])
+
+	tx.Amount = binary.LittleEndian.Uint64(payload[SizeTransactionID+SizeAccountID:])
+
+	if tx.Amount == 0 {
+		return tx, errors.New("transfer token: amount must be greater than zero")
+	}
+
+	return tx, nil
+}
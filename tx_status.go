@@ -0,0 +1,98 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+// Statuses a transaction goes through over its lifecycle, from being received
+// by this node up until it is finalized in a round.
+const (
+	// Never seen by this node, or pruned away since.
+	TransactionStatusUnknown = "unknown"
+
+	// Stored in the graph, though some of its parents are yet to be received.
+	TransactionStatusReceived = "received"
+
+	// Stored in the graph alongside all of its parents.
+	TransactionStatusAccepted = "accepted"
+
+	// Part of a round proposed for consensus which is yet to be finalized.
+	TransactionStatusQueried = "queried"
+
+	// Applied to the ledger state in a finalized round.
+	TransactionStatusApplied = "applied"
+
+	// Part of a finalized round, though failed to be applied to the ledger state.
+	TransactionStatusRejected = "rejected"
+)
+
+type transactionStatus struct {
+	status string
+	round  uint64
+}
+
+// TransactionStatus returns the lifecycle status of a transaction, alongside
+// the index of the round it was queried or finalized in. The round index is
+// only meaningful should the transaction have been queried, applied or
+// rejected, and is otherwise zero.
+//
+// Transactions which were finalized in a round that has since been evicted
+// from the ledgers records, or which were adopted by syncing with peers, are
+// reported as applied with a round index of zero.
+func (l *Ledger) TransactionStatus(id TransactionID) (string, uint64) {
+	if recorded, exists := l.statuses.load(id); exists {
+		recorded := recorded.(transactionStatus)
+		return recorded.status, recorded.round
+	}
+
+	tx := l.graph.FindTransaction(id)
+
+	if tx == nil {
+		return TransactionStatusUnknown, 0
+	}
+
+	if tx.Depth <= l.graph.RootDepth() {
+		return TransactionStatusApplied, 0
+	}
+
+	if !l.graph.IsTransactionComplete(id) {
+		return TransactionStatusReceived, 0
+	}
+
+	return TransactionStatusAccepted, 0
+}
+
+// markTransactionsQueried records that the given transactions are part of a
+// round proposed for consensus under the index round.
+func (l *Ledger) markTransactionsQueried(round uint64, transactions ...*Transaction) {
+	for _, tx := range transactions {
+		l.statuses.put(tx.ID, transactionStatus{status: TransactionStatusQueried, round: round})
+	}
+}
+
+// markTransactionsFinalized records the outcome of transactions that were
+// part of a round finalized under the index round.
+func (l *Ledger) markTransactionsFinalized(round uint64, results *CollapseResults) {
+	for _, tx := range results.applied {
+		l.statuses.put(tx.ID, transactionStatus{status: TransactionStatusApplied, round: round})
+	}
+
+	for _, tx := range results.rejected {
+		l.statuses.put(tx.ID, transactionStatus{status: TransactionStatusRejected, round: round})
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/perlin-network/noise/skademlia"
 	"github.com/perlin-network/wavelet/sys"
 	"github.com/stretchr/testify/assert"
+	"math"
 	"testing"
 )
 
@@ -53,3 +54,67 @@ func BenchmarkMarshalUnmarshalTX(b *testing.B) {
 		assert.NoError(b, err)
 	}
 }
+
+func TestTransactionFee(t *testing.T) {
+	defer func(params sys.ConsensusParams) {
+		sys.UpdateParams(func(p *sys.ConsensusParams) { *p = params })
+	}(sys.Params())
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	tx := NewTransaction(keys, sys.TagTransfer, make([]byte, 40))
+
+	sys.UpdateParams(func(p *sys.ConsensusParams) { p.TransactionFeeAmount, p.TransactionFeePerByte = 2, 0 })
+	assert.EqualValues(t, 2, tx.Fee())
+
+	sys.UpdateParams(func(p *sys.ConsensusParams) { p.TransactionFeePerByte = 3 })
+	assert.EqualValues(t, 2+3*len(tx.Marshal()), tx.Fee())
+
+	// Larger transactions pay larger fees.
+	larger := NewTransaction(keys, sys.TagTransfer, make([]byte, 80))
+	assert.EqualValues(t, tx.Fee()+3*40, larger.Fee())
+
+	sys.UpdateParams(func(p *sys.ConsensusParams) { p.TransactionFeePerByte = math.MaxUint64 })
+	assert.EqualValues(t, uint64(math.MaxUint64), tx.Fee())
+}
+
+func TestTransactionValidUntilView(t *testing.T) {
+	creator, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	sender, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	tx := AttachSenderToTransaction(sender, NewExpiringTransaction(creator, sys.TagTransfer, make([]byte, 40), 10))
+
+	decoded, err := UnmarshalTransaction(bytes.NewReader(tx.Marshal()))
+	assert.NoError(t, err)
+	assert.Equal(t, tx.ID, decoded.ID)
+	assert.EqualValues(t, 10, decoded.ValidUntilView)
+
+	assert.False(t, tx.Expired(9))
+	assert.False(t, tx.Expired(10))
+	assert.True(t, tx.Expired(11))
+
+	// Transactions which never expire are marshaled as they were before
+	// expiries were introduced.
+	forever := AttachSenderToTransaction(sender, NewTransaction(creator, sys.TagTransfer, make([]byte, 40)))
+	assert.False(t, forever.Expired(math.MaxUint64))
+	assert.Len(t, forever.Marshal(), len(tx.Marshal())-8)
+
+	// The expiry is signed by the creator, such that it may not be altered.
+	graph := NewGraph(VerifySignatures())
+	assert.NoError(t, graph.checkTransactionSignatures(&tx))
+
+	altered := tx
+	altered.ValidUntilView = 20
+	altered = AttachSenderToTransaction(sender, altered)
+	assert.Equal(t, ErrInvalidCreatorSignature, graph.checkTransactionSignatures(&altered))
+
+	buf := tx.Marshal()
+	buf[SizeAccountID] |= 1 << 7
+
+	_, err = UnmarshalTransaction(bytes.NewReader(buf))
+	assert.Error(t, err)
+}
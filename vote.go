@@ -21,18 +21,46 @@ package wavelet
 
 import (
 	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet/log"
 	"github.com/perlin-network/wavelet/sys"
+	"math"
 	"sync"
 )
 
+// voteQuorum returns the number of votes from distinct peers a sample is
+// tallied at. Rather than wait on all K peers to respond, and thus on the
+// slowest of them, a sample is tallied as soon as enough votes are in for a
+// round to have the support of alpha of K peers. Peers that respond late
+// have their votes count towards the next sample instead.
+func voteQuorum(params sys.ConsensusParams) int {
+	quorum := int(math.Ceil(params.SnowballAlpha * float64(params.SnowballK)))
+
+	if quorum < 1 {
+		quorum = 1
+	}
+
+	if quorum > params.SnowballK {
+		quorum = params.SnowballK
+	}
+
+	return quorum
+}
+
 type vote struct {
 	voter     *skademlia.ID
 	preferred *Round
 }
 
+// CollectVotes tallies votes received over voteChan in samples, ticking
+// snowball once per sample. The parameters a sample is tallied with are read
+// once when the sample starts, such that parameters tuned while a sample is
+// being collected only take effect from the next sample onwards.
 func CollectVotes(accounts *Accounts, snowball *Snowball, voteChan <-chan vote, wg *sync.WaitGroup) {
-	votes := make([]vote, 0, sys.SnowballK)
-	voters := make(map[AccountID]struct{}, sys.SnowballK)
+	params := sys.Params()
+	quorum := voteQuorum(params)
+
+	votes := make([]vote, 0, params.SnowballK)
+	voters := make(map[AccountID]struct{}, params.SnowballK)
 
 	for vote := range voteChan {
 		if _, recorded := voters[vote.voter.PublicKey()]; recorded {
@@ -42,7 +70,7 @@ func CollectVotes(accounts *Accounts, snowball *Snowball, voteChan <-chan vote,
 		voters[vote.voter.PublicKey()] = struct{}{}
 		votes = append(votes, vote)
 
-		if len(votes) == cap(votes) {
+		if len(votes) >= quorum {
 			snapshot := accounts.Snapshot()
 
 			counts := make(map[RoundID]float64, len(votes))
@@ -57,8 +85,8 @@ func CollectVotes(accounts *Accounts, snowball *Snowball, voteChan <-chan vote,
 
 				stake, _ := ReadAccountStake(snapshot, vote.voter.PublicKey())
 
-				if stake < sys.MinimumStake {
-					stake = sys.MinimumStake
+				if stake < params.MinimumStake {
+					stake = params.MinimumStake
 				}
 
 				stakes[vote.preferred.ID] += float64(stake)
@@ -96,15 +124,32 @@ func CollectVotes(accounts *Accounts, snowball *Snowball, voteChan <-chan vote,
 					vote.preferred = ZeroRoundPtr
 				}
 
-				if counts[vote.preferred.ID] >= sys.SnowballAlpha {
+				if counts[vote.preferred.ID] >= params.SnowballAlpha {
 					majority = vote.preferred
 					break
 				}
 			}
 
+			previous := snowball.Preferred()
+
 			snowball.Tick(majority)
 
-			voters = make(map[AccountID]struct{}, sys.SnowballK)
+			if preferred := snowball.Preferred(); previous != nil && preferred != nil && previous.ID != preferred.ID {
+				logger := log.Consensus("prefer")
+				logger.Info().
+					Hex("old_round_id", previous.ID[:]).
+					Hex("new_round_id", preferred.ID[:]).
+					Uint64("old_round", previous.Index).
+					Uint64("new_round", preferred.Index).
+					Hex("old_root", previous.End.ID[:]).
+					Hex("new_root", preferred.End.ID[:]).
+					Msg("Switched preferred round to a conflicting round.")
+			}
+
+			params = sys.Params()
+			quorum = voteQuorum(params)
+
+			voters = make(map[AccountID]struct{}, params.SnowballK)
 			votes = votes[:0]
 		}
 	}
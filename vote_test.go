@@ -0,0 +1,193 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"github.com/perlin-network/noise/skademlia"
+	"github.com/perlin-network/wavelet/log"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fastjson"
+	"golang.org/x/crypto/blake2b"
+	"io/ioutil"
+	"sync"
+	"testing"
+)
+
+func TestCollectVotesLogsPreferenceSwitch(t *testing.T) {
+	var buf lockedBuffer
+
+	log.SetWriter("test_prefer_events", &buf)
+	defer log.SetWriter("test_prefer_events", ioutil.Discard)
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	start := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagTransfer, nil))
+
+	a := NewRound(1, ZeroMerkleNodeID, 0, start, AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagStake, nil)))
+	b := NewRound(1, ZeroMerkleNodeID, 0, start, AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagContract, nil)))
+
+	voters := make([]*skademlia.ID, sys.Params().SnowballK)
+
+	for i := range voters {
+		voter, err := skademlia.NewKeys(1, 1)
+		assert.NoError(t, err)
+
+		voters[i] = skademlia.NewID("127.0.0.1:3000", voter.PublicKey(), [blake2b.Size256]byte{})
+	}
+
+	snowball := NewSnowball()
+	voteChan := make(chan vote, sys.Params().SnowballK)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go CollectVotes(NewAccounts(store.NewInmem()), snowball, voteChan, &wg)
+
+	// Have all voters unanimously prefer round A once, and then round B twice.
+
+	for _, round := range []*Round{&a, &b, &b} {
+		for _, voter := range voters {
+			voteChan <- vote{voter: voter, preferred: round}
+		}
+	}
+
+	close(voteChan)
+	wg.Wait()
+
+	assert.Equal(t, b, *snowball.Preferred())
+
+	buf.Lock()
+	defer buf.Unlock()
+
+	var switches []string
+
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		v, err := fastjson.ParseBytes(line)
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		if string(v.GetStringBytes(log.KeyModule)) == log.ModuleConsensus && string(v.GetStringBytes(log.KeyEvent)) == "prefer" {
+			switches = append(switches, string(v.GetStringBytes("old_round_id"))+">"+string(v.GetStringBytes("new_round_id")))
+		}
+	}
+
+	assert.Equal(t, []string{hex.EncodeToString(a.ID[:]) + ">" + hex.EncodeToString(b.ID[:])}, switches)
+}
+
+func TestCollectVotesTalliesAtQuorum(t *testing.T) {
+	defer func(params sys.ConsensusParams) {
+		sys.UpdateParams(func(p *sys.ConsensusParams) { *p = params })
+	}(sys.Params())
+
+	sys.UpdateParams(func(p *sys.ConsensusParams) { p.SnowballK, p.SnowballAlpha = 5, 0.6 })
+	assert.Equal(t, 3, voteQuorum(sys.Params()))
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	start := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagTransfer, nil))
+	round := NewRound(1, ZeroMerkleNodeID, 0, start, AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagStake, nil)))
+
+	voters := make([]*skademlia.ID, sys.Params().SnowballK)
+
+	for i := range voters {
+		voter, err := skademlia.NewKeys(1, 1)
+		assert.NoError(t, err)
+
+		voters[i] = skademlia.NewID("127.0.0.1:3000", voter.PublicKey(), [blake2b.Size256]byte{})
+	}
+
+	snowball := NewSnowball()
+	voteChan := make(chan vote, 2*sys.Params().SnowballK)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go CollectVotes(NewAccounts(store.NewInmem()), snowball, voteChan, &wg)
+
+	// Six votes make for two samples of three votes each, rather than one sample
+	// of five votes, with the first voter voting again in the second sample.
+
+	for _, voter := range append(voters, voters[0]) {
+		voteChan <- vote{voter: voter, preferred: &round}
+	}
+
+	close(voteChan)
+	wg.Wait()
+
+	assert.Equal(t, round, *snowball.Preferred())
+	assert.Equal(t, 1, snowball.Progress())
+
+	// The quorum is never more than K, nor less than one.
+
+	sys.UpdateParams(func(p *sys.ConsensusParams) { p.SnowballAlpha = 1 })
+	assert.Equal(t, 5, voteQuorum(sys.Params()))
+
+	sys.UpdateParams(func(p *sys.ConsensusParams) { p.SnowballAlpha = 0.01 })
+	assert.Equal(t, 1, voteQuorum(sys.Params()))
+}
+
+func TestCollectVotesKeepsParamsForSample(t *testing.T) {
+	defer func(params sys.ConsensusParams) {
+		sys.UpdateParams(func(p *sys.ConsensusParams) { *p = params })
+	}(sys.Params())
+
+	sys.UpdateParams(func(p *sys.ConsensusParams) { p.SnowballK, p.SnowballAlpha = 5, 0.6 })
+
+	keys, err := skademlia.NewKeys(1, 1)
+	assert.NoError(t, err)
+
+	start := AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagTransfer, nil))
+	round := NewRound(1, ZeroMerkleNodeID, 0, start, AttachSenderToTransaction(keys, NewTransaction(keys, sys.TagStake, nil)))
+
+	snowball := NewSnowball()
+	voteChan := make(chan vote)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go CollectVotes(NewAccounts(store.NewInmem()), snowball, voteChan, &wg)
+
+	for i := 0; i < 3; i++ {
+		voter, err := skademlia.NewKeys(1, 1)
+		assert.NoError(t, err)
+
+		voteChan <- vote{voter: skademlia.NewID("127.0.0.1:3000", voter.PublicKey(), [blake2b.Size256]byte{}), preferred: &round}
+
+		// Raising alpha mid-sample, and thus the quorum to all five peers,
+		// does not hold back the sample from being tallied at three votes.
+		if i == 0 {
+			sys.UpdateParams(func(p *sys.ConsensusParams) { p.SnowballAlpha = 1 })
+		}
+	}
+
+	close(voteChan)
+	wg.Wait()
+
+	if assert.NotNil(t, snowball.Preferred()) {
+		assert.Equal(t, round, *snowball.Preferred())
+	}
+}
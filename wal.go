@@ -0,0 +1,92 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/perlin-network/wavelet/log"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/pkg/errors"
+)
+
+// commitRound advances the ledger to round by committing snapshot, the state
+// of accounts as of round, and then saving round. It returns the round pruned
+// to make room for round, if any.
+//
+// Advancing the ledger is recorded to a write-ahead log beforehand, such that
+// should the ledger stop partway, the state of accounts is never left
+// inconsistent with the latest round saved once it is restarted. The state is
+// committed before round is saved, as the root of the state only moves once
+// all of its nodes are written, and so round may always be saved again on
+// restart should the state have been committed.
+func (l *Ledger) commitRound(round *Round, snapshot *avl.Tree) (*Round, error) {
+	if err := StoreWriteAheadLog(l.accounts.kv, *round); err != nil {
+		return nil, err
+	}
+
+	if err := l.accounts.Commit(snapshot); err != nil {
+		return nil, err
+	}
+
+	pruned, err := l.rounds.Save(round)
+	if err != nil {
+		return pruned, errors.Wrapf(err, "failed to save round %d", round.Index)
+	}
+
+	return pruned, ClearWriteAheadLog(l.accounts.kv)
+}
+
+// recoverWriteAheadLog finishes advancing, or discards advancing the ledger
+// to the round recorded in the write-ahead log of kv. Should the state of
+// accounts have been committed, the round is saved should it not have been.
+// Otherwise, the round is discarded, as it is only saved after the state is
+// committed.
+func recoverWriteAheadLog(kv store.KV, accounts *Accounts, rounds *Rounds) error {
+	round, exists, err := LoadWriteAheadLog(kv)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return nil
+	}
+
+	logger := log.Node()
+
+	if accounts.tree.Checksum() == round.Merkle {
+		if rounds.Latest().ID != round.ID {
+			if _, err := rounds.Save(&round); err != nil {
+				return errors.Wrapf(err, "failed to save round %d", round.Index)
+			}
+		}
+
+		logger.Info().
+			Uint64("round", round.Index).
+			Hex("merkle_root", round.Merkle[:]).
+			Msg("Rolled forward to the round the ledger was advancing to before it was stopped.")
+	} else {
+		logger.Info().
+			Uint64("round", round.Index).
+			Hex("merkle_root", round.Merkle[:]).
+			Msg("Discarded the round the ledger was advancing to before it was stopped.")
+	}
+
+	return ClearWriteAheadLog(kv)
+}
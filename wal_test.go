@@ -0,0 +1,96 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wavelet
+
+import (
+	"github.com/perlin-network/wavelet/avl"
+	"github.com/perlin-network/wavelet/store"
+	"github.com/perlin-network/wavelet/sys"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRecoverWriteAheadLog(t *testing.T) {
+	kv := store.NewInmem()
+
+	var account AccountID
+	account[0] = 1
+
+	accounts := NewAccounts(kv)
+	rounds, _ := NewRounds(kv, sys.PruningLimit)
+
+	advance := func(index, balance uint64) (Round, *avl.Tree) {
+		snapshot := accounts.Snapshot()
+		snapshot.SetViewID(index)
+		WriteAccountBalance(snapshot, account, balance)
+
+		return NewRound(index, snapshot.Checksum(), 0, Transaction{}, Transaction{}), snapshot
+	}
+
+	restart := func() {
+		var err error
+
+		accounts = NewAccounts(kv)
+		rounds, err = NewRounds(kv, sys.PruningLimit)
+		assert.NoError(t, err)
+
+		assert.NoError(t, recoverWriteAheadLog(kv, accounts, rounds))
+
+		_, exists, err := LoadWriteAheadLog(kv)
+		assert.NoError(t, err)
+		assert.False(t, exists)
+	}
+
+	genesis, snapshot := advance(0, 0)
+	assert.NoError(t, accounts.Commit(snapshot))
+
+	_, err := rounds.Save(&genesis)
+	assert.NoError(t, err)
+
+	// Stopping after the state of a round is committed, but before the round
+	// is saved, rolls the ledger forward to the round.
+	first, snapshot := advance(1, 1)
+
+	assert.NoError(t, StoreWriteAheadLog(kv, first))
+	assert.NoError(t, accounts.Commit(snapshot))
+
+	restart()
+
+	assert.Equal(t, first.ID, rounds.Latest().ID)
+	assert.Equal(t, first.Merkle, accounts.Snapshot().Checksum())
+
+	// Stopping before the state of a round is committed discards the round.
+	second, _ := advance(2, 2)
+
+	assert.NoError(t, StoreWriteAheadLog(kv, second))
+
+	restart()
+
+	assert.Equal(t, first.ID, rounds.Latest().ID)
+	assert.Equal(t, first.Merkle, accounts.Snapshot().Checksum())
+
+	// Rounds already saved are not saved again.
+	assert.NoError(t, StoreWriteAheadLog(kv, first))
+
+	restart()
+
+	assert.Equal(t, first.ID, rounds.Latest().ID)
+	assert.Equal(t, genesis.ID, rounds.Oldest().ID)
+}
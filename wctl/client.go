@@ -20,22 +20,116 @@
 package wctl
 
 import (
+	"bytes"
 	"encoding/base64"
+	"encoding/binary"
 	"encoding/hex"
 	"fmt"
 	"github.com/fasthttp/websocket"
 	"github.com/perlin-network/noise/edwards25519"
+	"github.com/perlin-network/wavelet/memo"
+	"github.com/perlin-network/wavelet/sys"
 	"github.com/valyala/fasthttp"
+	"github.com/valyala/fastjson"
+	"math"
+	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 )
 
+// Defaults of the timeout of requests, and of the retrying of requests, used
+// should they be left unspecified in the config of a client.
+const (
+	DefaultTimeout      = 5 * time.Second
+	DefaultMaxRetries   = 3
+	DefaultRetryBackoff = 250 * time.Millisecond
+)
+
 type Config struct {
 	APIHost    string
 	APIPort    uint16
 	PrivateKey edwards25519.PrivateKey
 	UseHTTPS   bool
+
+	// APIKey is presented to nodes which require API keys be presented to
+	// access their API. It may be left empty otherwise.
+	APIKey string
+
+	// Timeout is how long the node is given to respond to a request.
+	Timeout time.Duration
+
+	// MaxRetries is the number of times a request is retried should the node
+	// be unreachable, or respond that it is unavailable or that it is being
+	// sent too many requests. Requests which may have been processed by the
+	// node are only retried if they are reads. It may be negative for
+	// requests to never be retried.
+	MaxRetries int
+
+	// RetryBackoff is how long to wait before retrying a request for the
+	// first time. It doubles for every retry after, unless the node asks for
+	// the client to wait longer.
+	RetryBackoff time.Duration
+}
+
+// APIError is returned should a node respond to a request with an error.
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+
+	Status string // User-level status message.
+	Err    string // Application-level error message.
+
+	// RetryAfter is how long the node asked for the client to wait before
+	// retrying the request, if at all.
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s %s: unexpected status code %d", e.Method, e.Path, e.StatusCode)
+
+	if len(e.Status) > 0 {
+		msg += ": " + e.Status
+	}
+
+	if len(e.Err) > 0 {
+		msg += ": " + e.Err
+	}
+
+	return msg
+}
+
+// retryable returns whether or not the request which failed with e may be
+// retried. The node does not process requests it rejects for being sent too
+// many of them, or for being unavailable.
+func (e *APIError) retryable() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return e.Method == ReqGet
+	default:
+		return false
+	}
+}
+
+func parseAPIError(method, path string, res *fasthttp.Response) *APIError {
+	err := &APIError{Method: method, Path: path, StatusCode: res.StatusCode()}
+
+	if v, perr := fastjson.ParseBytes(res.Body()); perr == nil {
+		err.Status = string(v.GetStringBytes("status"))
+		err.Err = string(v.GetStringBytes("error"))
+	} else {
+		err.Err = string(res.Body())
+	}
+
+	if seconds, perr := strconv.Atoi(string(res.Header.Peek("Retry-After"))); perr == nil && seconds > 0 {
+		err.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	return err
 }
 
 type Client struct {
@@ -48,8 +142,20 @@ type Client struct {
 }
 
 func NewClient(config Config) (*Client, error) {
+	if config.Timeout == 0 {
+		config.Timeout = DefaultTimeout
+	}
+
+	if config.MaxRetries == 0 {
+		config.MaxRetries = DefaultMaxRetries
+	}
+
+	if config.RetryBackoff == 0 {
+		config.RetryBackoff = DefaultRetryBackoff
+	}
+
 	stdClient := &http.Client{
-		Timeout: 5 * time.Second,
+		Timeout: config.Timeout,
 	}
 
 	return &Client{Config: config, PrivateKey: config.PrivateKey, PublicKey: config.PrivateKey.Public(), stdClient: stdClient}, nil
@@ -84,6 +190,10 @@ func (c *Client) Request(path string, method string, body MarshalableJSON) ([]by
 	req.Header.SetMethod(method)
 	req.Header.SetContentType("application/json")
 
+	if len(c.Config.APIKey) > 0 {
+		req.Header.Set(headerAPIKey, c.Config.APIKey)
+	}
+
 	if body != nil {
 		raw, err := body.MarshalJSON()
 		if err != nil {
@@ -96,15 +206,46 @@ func (c *Client) Request(path string, method string, body MarshalableJSON) ([]by
 	res := fasthttp.AcquireResponse()
 	defer fasthttp.ReleaseResponse(res)
 
-	if err := fasthttp.DoTimeout(req, res, 5*time.Second); err != nil {
-		return nil, err
+	for attempt := 0; ; attempt++ {
+		var wait time.Duration
+
+		err := fasthttp.DoTimeout(req, res, c.Config.Timeout)
+
+		switch {
+		case err == nil && res.StatusCode() == http.StatusOK:
+			return append([]byte(nil), res.Body()...), nil
+		case err == nil:
+			apiErr := parseAPIError(method, path, res)
+			if !apiErr.retryable() || attempt >= c.Config.MaxRetries {
+				return nil, apiErr
+			}
+
+			wait = apiErr.RetryAfter
+		default:
+			// Requests which could not be sent at all were not processed by
+			// the node, and may thus always be retried.
+			if !(method == ReqGet || isDialError(err)) || attempt >= c.Config.MaxRetries {
+				return nil, err
+			}
+		}
+
+		if backoff := c.Config.RetryBackoff << uint(attempt); backoff > wait {
+			wait = backoff
+		}
+
+		time.Sleep(wait)
+
+		res.Reset()
 	}
+}
 
-	if res.StatusCode() != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code for query sent to %q: %d. request body: %q, response body: %q", addr, res.StatusCode(), req.Body(), res.Body())
+func isDialError(err error) bool {
+	if err == fasthttp.ErrNoFreeConns {
+		return true
 	}
 
-	return res.Body(), nil
+	opErr, ok := err.(*net.OpError)
+	return ok && opErr.Op == "dial"
 }
 
 // EstablishWS will create a websocket connection.
@@ -120,7 +261,13 @@ func (c *Client) EstablishWS(path string, query url.Values) (*websocket.Conn, er
 		HandshakeTimeout: 3 * time.Second,
 	}
 
-	conn, _, err := dialer.Dial(uri.String(), nil)
+	var header http.Header
+
+	if len(c.Config.APIKey) > 0 {
+		header = http.Header{headerAPIKey: []string{c.Config.APIKey}}
+	}
+
+	conn, _, err := dialer.Dial(uri.String(), header)
 	return conn, err
 }
 
@@ -296,6 +443,42 @@ func (c *Client) GetLedgerStatus(senderID *string, creatorID *string, offset *ui
 	return res, err
 }
 
+// GetLedgerState returns only what changed in the ledger of the node since
+// the round with index since.
+func (c *Client) GetLedgerState(since uint64) (LedgerStateResponse, error) {
+	path := fmt.Sprintf("%s?since=%d", RouteLedgerState, since)
+
+	var res LedgerStateResponse
+	err := c.RequestJSON(path, ReqGet, nil, &res)
+	return res, err
+}
+
+// GetRoundDiff returns the diff of the ledger state made by the round with
+// index round. Only archive nodes serve the diffs of past rounds.
+func (c *Client) GetRoundDiff(round uint64) ([]byte, error) {
+	path := fmt.Sprintf("%s/%d/diff", RouteRounds, round)
+
+	return c.Request(path, ReqGet, nil)
+}
+
+// GetRoundChanges returns the changes made to the balances and stakes of
+// accounts by the round with index round, ordered by account. Only archive
+// nodes serve the changes made by past rounds.
+func (c *Client) GetRoundChanges(round uint64) (RoundChanges, error) {
+	path := fmt.Sprintf("%s/%d/changes", RouteRounds, round)
+
+	var res RoundChanges
+	err := c.RequestJSON(path, ReqGet, nil, &res)
+	return res, err
+}
+
+// GetRandomBeacon returns the random beacon of the latest round of the ledger.
+func (c *Client) GetRandomBeacon() (RandomBeacon, error) {
+	var res RandomBeacon
+	err := c.RequestJSON(RouteBeacon, ReqGet, nil, &res)
+	return res, err
+}
+
 func (c *Client) GetAccount(accountID string) (Account, error) {
 	path := fmt.Sprintf("%s/%s", RouteAccount, accountID)
 
@@ -304,6 +487,46 @@ func (c *Client) GetAccount(accountID string) (Account, error) {
 	return res, err
 }
 
+// GetAccountAt returns the balance and stake of an account as of when the
+// round with index round was finalized.
+func (c *Client) GetAccountAt(accountID string, round uint64) (AccountState, error) {
+	path := fmt.Sprintf("%s/%s?round=%d", RouteAccount, accountID, round)
+
+	var res AccountState
+	err := c.RequestJSON(path, ReqGet, nil, &res)
+	return res, err
+}
+
+// GetAccounts returns the accounts with the given public keys in a single
+// request, in the order they were given.
+func (c *Client) GetAccounts(accountIDs []string) (Accounts, error) {
+	path := fmt.Sprintf("%s/batch", RouteAccount)
+
+	var res Accounts
+	err := c.RequestJSON(path, ReqPost, &AccountBatchRequest{PublicKeys: accountIDs}, &res)
+	return res, err
+}
+
+// GetAccountHistory returns the changes made to the balance and stake of an
+// account in the rounds after the round with index since.
+func (c *Client) GetAccountHistory(accountID string, since uint64) (AccountHistory, error) {
+	path := fmt.Sprintf("%s/%s/history?since=%d", RouteAccount, accountID, since)
+
+	var res AccountHistory
+	err := c.RequestJSON(path, ReqGet, nil, &res)
+	return res, err
+}
+
+// GetAccountProof returns a proof of the nonce, balance, stake and reward of an
+// account against the Merkle root of the latest round of the node.
+func (c *Client) GetAccountProof(accountID string) (AccountProof, error) {
+	path := fmt.Sprintf("%s/%s/proof", RouteAccount, accountID)
+
+	var res AccountProof
+	err := c.RequestJSON(path, ReqGet, nil, &res)
+	return res, err
+}
+
 func (c *Client) GetContractCode(contractID string) (string, error) {
 	path := fmt.Sprintf("%s/%s", RouteContract, contractID)
 
@@ -321,7 +544,30 @@ func (c *Client) GetContractPages(contractID string, index *uint64) (string, err
 	return base64.StdEncoding.EncodeToString(res), err
 }
 
-func (c *Client) ListTransactions(senderID *string, creatorID *string, offset *uint64, limit *uint64) ([]Transaction, error) {
+// GetContractEvents returns up to limit events emitted by a contract, starting
+// from the event indexed by offset. Events may be filtered by the ID of the
+// transaction which originated them, and by their topic.
+func (c *Client) GetContractEvents(contractID string, offset uint64, limit uint64, txID *string, topic *string) (ContractEvents, error) {
+	v := url.Values{}
+	v.Set("offset", strconv.FormatUint(offset, 10))
+	v.Set("limit", strconv.FormatUint(limit, 10))
+
+	if txID != nil {
+		v.Set("tx", *txID)
+	}
+
+	if topic != nil {
+		v.Set("topic", *topic)
+	}
+
+	path := fmt.Sprintf("%s/%s/events?%s", RouteContract, contractID, v.Encode())
+
+	var res ContractEvents
+	err := c.RequestJSON(path, ReqGet, nil, &res)
+	return res, err
+}
+
+func (c *Client) ListTransactions(senderID *string, creatorID *string, tag *byte, offset *uint64, limit *uint64) ([]Transaction, error) {
 	path := fmt.Sprintf("%s?", RouteTxList)
 	if senderID != nil {
 		path = fmt.Sprintf("%ssender=%s&", path, *senderID)
@@ -329,6 +575,9 @@ func (c *Client) ListTransactions(senderID *string, creatorID *string, offset *u
 	if creatorID != nil {
 		path = fmt.Sprintf("%screator=%s&", path, *creatorID)
 	}
+	if tag != nil {
+		path = fmt.Sprintf("%stag=%d&", path, *tag)
+	}
 	if offset != nil {
 		path = fmt.Sprintf("%soffset=%d&", path, *offset)
 	}
@@ -351,21 +600,227 @@ func (c *Client) GetTransaction(txID string) (Transaction, error) {
 	return res, err
 }
 
+// GetTransactionStatus returns the lifecycle status of a transaction, and
+// the index of the round it was queried or finalized in if any.
+func (c *Client) GetTransactionStatus(txID string) (TransactionStatus, error) {
+	path := fmt.Sprintf("%s/%s/status", RouteTxList, txID)
+
+	var res TransactionStatus
+	err := c.RequestJSON(path, ReqGet, nil, &res)
+	return res, err
+}
+
+// GetTransactionReceipt returns the receipt of a transaction finalized by the
+// node, describing what smart contracts did on its behalf.
+func (c *Client) GetTransactionReceipt(txID string) (TransactionReceipt, error) {
+	path := fmt.Sprintf("%s/%s/receipt", RouteTxList, txID)
+
+	var res TransactionReceipt
+	err := c.RequestJSON(path, ReqGet, nil, &res)
+	return res, err
+}
+
 func (c *Client) SendTransaction(tag byte, payload []byte) (SendTransactionResponse, error) {
+	return c.SendExpiringTransaction(tag, payload, 0)
+}
+
+// SendExpiringTransaction sends a transaction which may only be applied in
+// rounds up to and including the round indexed validUntilView. A transaction
+// which has expired without being applied may safely be sent again, as it is
+// rejected should it ever be finalized. It never expires should
+// validUntilView be 0.
+func (c *Client) SendExpiringTransaction(tag byte, payload []byte, validUntilView uint64) (SendTransactionResponse, error) {
 	var res SendTransactionResponse
 
 	var nonce [8]byte // TODO(kenta): nonce
 
-	signature := edwards25519.Sign(c.PrivateKey, append(nonce[:], append([]byte{tag}, payload...)...))
+	msg := append(nonce[:], append([]byte{tag}, payload...)...)
+
+	if validUntilView != 0 {
+		var buf [8]byte
+		binary.BigEndian.PutUint64(buf[:], validUntilView)
+
+		msg = append(msg, buf[:]...)
+	}
+
+	signature := edwards25519.Sign(c.PrivateKey, msg)
 
 	req := SendTransactionRequest{
-		Sender:    hex.EncodeToString(c.PublicKey[:]),
-		Tag:       tag,
-		Payload:   hex.EncodeToString(payload),
-		Signature: hex.EncodeToString(signature[:]),
+		Sender:         hex.EncodeToString(c.PublicKey[:]),
+		Tag:            tag,
+		Payload:        hex.EncodeToString(payload),
+		Signature:      hex.EncodeToString(signature[:]),
+		ValidUntilView: validUntilView,
 	}
 
 	err := c.RequestJSON(RouteTxSend, ReqPost, &req, &res)
 
 	return res, err
 }
+
+// SendTransfer sends amount PERLs to recipient. Should note not be empty, it
+// is encrypted to recipient and attached to the transfer, such that only the
+// recipient may read it.
+func (c *Client) SendTransfer(recipient edwards25519.PublicKey, amount uint64, note []byte) (SendTransactionResponse, error) {
+	payload := bytes.NewBuffer(nil)
+	payload.Write(recipient[:])
+
+	var intBuf [8]byte
+	binary.LittleEndian.PutUint64(intBuf[:], amount)
+	payload.Write(intBuf[:])
+
+	if len(note) > 0 {
+		encrypted, err := memo.Encrypt(recipient, note)
+		if err != nil {
+			return SendTransactionResponse{}, err
+		}
+
+		// Write an empty gas limit, function name and function parameters
+		// before the memo.
+		payload.Write(make([]byte, 8+4+4))
+
+		binary.LittleEndian.PutUint32(intBuf[:4], uint32(len(encrypted)))
+		payload.Write(intBuf[:4])
+		payload.Write(encrypted)
+	}
+
+	return c.SendTransaction(sys.TagTransfer, payload.Bytes())
+}
+
+// BatchEntry is a single operation within a batch transaction.
+type BatchEntry struct {
+	Tag     byte
+	Payload []byte
+}
+
+// TransferEntry returns a batch entry sending amount PERLs to recipient.
+func TransferEntry(recipient edwards25519.PublicKey, amount uint64) BatchEntry {
+	payload := make([]byte, len(recipient)+8)
+
+	copy(payload, recipient[:])
+	binary.LittleEndian.PutUint64(payload[len(recipient):], amount)
+
+	return BatchEntry{Tag: sys.TagTransfer, Payload: payload}
+}
+
+// SendBatch sends entries as a single batch transaction, which is applied
+// all-or-nothing. At most 255 entries may be sent at once, and entries may not
+// be batches themselves.
+func (c *Client) SendBatch(entries []BatchEntry) (SendTransactionResponse, error) {
+	if len(entries) == 0 || len(entries) > math.MaxUint8 {
+		return SendTransactionResponse{}, fmt.Errorf("a batch must hold between 1 and %d entries, but got %d entries", math.MaxUint8, len(entries))
+	}
+
+	payload := bytes.NewBuffer(nil)
+	payload.WriteByte(byte(len(entries)))
+
+	var intBuf [4]byte
+
+	for _, entry := range entries {
+		if entry.Tag == sys.TagBatch {
+			return SendTransactionResponse{}, fmt.Errorf("entries inside a batch cannot be batches themselves")
+		}
+
+		payload.WriteByte(entry.Tag)
+
+		binary.BigEndian.PutUint32(intBuf[:], uint32(len(entry.Payload)))
+		payload.Write(intBuf[:])
+		payload.Write(entry.Payload)
+	}
+
+	return c.SendTransaction(sys.TagBatch, payload.Bytes())
+}
+
+// CreateToken issues a fungible token, crediting its entire supply to the
+// client. The token is identified by the ID of the transaction creating it.
+func (c *Client) CreateToken(symbol string, supply uint64, decimals uint8) (SendTransactionResponse, error) {
+	payload := make([]byte, 8+1+len(symbol))
+
+	binary.LittleEndian.PutUint64(payload[0:8], supply)
+	payload[8] = decimals
+	copy(payload[9:], symbol)
+
+	return c.SendTransaction(sys.TagCreateToken, payload)
+}
+
+// SendToken sends amount of the token with ID tokenID to recipient.
+func (c *Client) SendToken(tokenID string, recipient edwards25519.PublicKey, amount uint64) (SendTransactionResponse, error) {
+	id, err := hex.DecodeString(tokenID)
+	if err != nil {
+		return SendTransactionResponse{}, err
+	}
+
+	if len(id) != 32 {
+		return SendTransactionResponse{}, fmt.Errorf("token ID must be 32 bytes, but got %d bytes", len(id))
+	}
+
+	payload := bytes.NewBuffer(nil)
+	payload.Write(id)
+	payload.Write(recipient[:])
+
+	var intBuf [8]byte
+	binary.LittleEndian.PutUint64(intBuf[:], amount)
+	payload.Write(intBuf[:])
+
+	return c.SendTransaction(sys.TagTransferToken, payload.Bytes())
+}
+
+// GetToken returns the symbol, supply, and decimals of a token.
+func (c *Client) GetToken(tokenID string) (Token, error) {
+	path := fmt.Sprintf("%s/%s", RouteTokens, tokenID)
+
+	var res Token
+	err := c.RequestJSON(path, ReqGet, nil, &res)
+	return res, err
+}
+
+// GetAccountTokens returns the balances an account holds of all tokens it has
+// ever held.
+func (c *Client) GetAccountTokens(accountID string) (TokenBalances, error) {
+	path := fmt.Sprintf("%s/%s/tokens", RouteAccount, accountID)
+
+	var res TokenBalances
+	err := c.RequestJSON(path, ReqGet, nil, &res)
+	return res, err
+}
+
+// DecryptMemo decrypts the memo attached to a transfer sent to the client.
+func (c *Client) DecryptMemo(tx Transaction) ([]byte, error) {
+	if tx.Memo == "" {
+		return nil, fmt.Errorf("transaction %s has no memo attached", tx.ID)
+	}
+
+	encrypted, err := hex.DecodeString(tx.Memo)
+	if err != nil {
+		return nil, err
+	}
+
+	return memo.Decrypt(c.PrivateKey, encrypted)
+}
+
+// UploadContract has the node spawn a smart contract out of the given
+// WebAssembly code, signed and paid for by the node itself.
+func (c *Client) UploadContract(code []byte, gasLimit uint64, params []byte) (UploadContractResponse, error) {
+	var res UploadContractResponse
+
+	req := UploadContractRequest{
+		Contract: base64.StdEncoding.EncodeToString(code),
+		GasLimit: gasLimit,
+		Params:   hex.EncodeToString(params),
+	}
+
+	err := c.RequestJSON(RouteContract, ReqPost, &req, &res)
+
+	return res, err
+}
+
+// CallContract simulates calling a smart contract function against the
+// latest ledger state of the node, without sending out any transaction.
+func (c *Client) CallContract(contractID string, req CallContractRequest) (CallContractResponse, error) {
+	var res CallContractResponse
+
+	path := fmt.Sprintf("%s/%s/call", RouteContract, contractID)
+	err := c.RequestJSON(path, ReqPost, &req, &res)
+
+	return res, err
+}
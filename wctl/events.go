@@ -0,0 +1,160 @@
+// Copyright (c) 2019 Perlin
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package wctl
+
+import (
+	"github.com/fasthttp/websocket"
+	"github.com/perlin-network/wavelet/log"
+	"github.com/valyala/fastjson"
+	"net/url"
+	"time"
+)
+
+// Event is a single event emitted by a node over one of its websocket routes.
+type Event struct {
+	Module  string
+	Event   string
+	Level   string
+	Message string
+
+	// Raw is the event encoded as a JSON object, from which fields specific
+	// to the event may be read.
+	Raw []byte
+}
+
+func (e *Event) ParseJSON(v *fastjson.Value) {
+	*e = Event{
+		Module:  string(v.GetStringBytes(log.KeyModule)),
+		Event:   string(v.GetStringBytes(log.KeyEvent)),
+		Level:   string(v.GetStringBytes("level")),
+		Message: string(v.GetStringBytes("message")),
+		Raw:     v.MarshalTo(nil),
+	}
+}
+
+// Subscribe subscribes to the events emitted by the node over the websocket
+// route, filtered by query. Batches of events sent by the node are split up,
+// such that the channel returned yields events one by one.
+//
+// Should the connection to the node drop, Subscribe reconnects to it with the
+// backoff of the client doubling after every failed attempt, up until the
+// retries of the client are exhausted, after which the channel is closed.
+// Events emitted while disconnected are missed. The channel is also closed
+// once stop is closed.
+func (c *Client) Subscribe(stop <-chan struct{}, route string, query url.Values) (<-chan Event, error) {
+	if stop == nil {
+		stop = make(chan struct{})
+	}
+
+	ws, err := c.EstablishWS(route, query)
+	if err != nil {
+		return nil, err
+	}
+
+	evChan := make(chan Event)
+
+	go func() {
+		defer close(evChan)
+
+		for {
+			if !c.pumpEvents(stop, ws, evChan) {
+				return
+			}
+
+			if ws = c.reconnectWS(stop, route, query); ws == nil {
+				return
+			}
+		}
+	}()
+
+	return evChan, nil
+}
+
+// pumpEvents forwards the events read from ws to evChan until either stop is
+// closed, or ws drops. It returns true should ws have dropped.
+func (c *Client) pumpEvents(stop <-chan struct{}, ws *websocket.Conn, evChan chan<- Event) bool {
+	done := make(chan struct{})
+	defer close(done)
+
+	// Closing the connection unblocks any pending read once stop is closed.
+	go func() {
+		select {
+		case <-stop:
+		case <-done:
+		}
+
+		_ = ws.Close()
+	}()
+
+	var parser fastjson.Parser
+
+	for {
+		_, message, err := ws.ReadMessage()
+		if err != nil {
+			select {
+			case <-stop:
+				return false
+			default:
+				return true
+			}
+		}
+
+		v, err := parser.ParseBytes(message)
+		if err != nil {
+			continue
+		}
+
+		values := []*fastjson.Value{v}
+
+		if v.Type() == fastjson.TypeArray {
+			values = v.GetArray()
+		}
+
+		for _, v := range values {
+			var ev Event
+			ev.ParseJSON(v)
+
+			select {
+			case <-stop:
+				return false
+			case evChan <- ev:
+			}
+		}
+	}
+}
+
+// reconnectWS attempts to re-establish a websocket connection to route. It
+// returns nil should stop be closed, or the retries of the client be
+// exhausted before a connection is established.
+func (c *Client) reconnectWS(stop <-chan struct{}, route string, query url.Values) *websocket.Conn {
+	for attempt := 0; attempt < c.Config.MaxRetries; attempt++ {
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(c.Config.RetryBackoff << uint(attempt)):
+		}
+
+		if ws, err := c.EstablishWS(route, query); err == nil {
+			return ws
+		}
+	}
+
+	return nil
+}
@@ -21,35 +21,59 @@ package wctl
 
 import (
 	"github.com/valyala/fastjson"
+	"strconv"
 )
 
 const (
-	RouteLedger   = "/ledger"
-	RouteAccount  = "/accounts"
-	RouteContract = "/contract"
-	RouteTxList   = "/tx"
-	RouteTxSend   = "/tx/send"
-
-	RouteWSBroadcaster  = "/poll/broadcaster"
-	RouteWSConsensus    = "/poll/consensus"
-	RouteWSStake        = "/poll/stake"
-	RouteWSAccounts     = "/poll/accounts"
-	RouteWSContracts    = "/poll/contract"
-	RouteWSTransactions = "/poll/tx"
-	RouteWSMetrics      = "/poll/metrics"
+	RouteLedger      = "/v1/ledger"
+	RouteLedgerState = "/v1/ledger/state"
+	RouteRounds      = "/v1/ledger/rounds"
+	RouteTokens      = "/v1/tokens"
+	RouteBeacon      = "/v1/ledger/beacon"
+	RouteAccount     = "/v1/accounts"
+	RouteContract    = "/v1/contract"
+	RouteTxList      = "/v1/tx"
+	RouteTxSend      = "/v1/tx/send"
+
+	RouteWSBroadcaster    = "/v1/poll/broadcaster"
+	RouteWSConsensus      = "/v1/poll/consensus"
+	RouteWSStake          = "/v1/poll/stake"
+	RouteWSAccounts       = "/v1/poll/accounts"
+	RouteWSContracts      = "/v1/poll/contract"
+	RouteWSContractEvents = "/v1/poll/contract_events"
+	RouteWSTransactions   = "/v1/poll/tx"
+	RouteWSMetrics        = "/v1/poll/metrics"
 
 	ReqPost = "POST"
 	ReqGet  = "GET"
+
+	headerAPIKey = "X-API-Key"
 )
 
 var (
 	_ UnmarshalableJSON = (*SendTransactionResponse)(nil)
 	_ UnmarshalableJSON = (*LedgerStatusResponse)(nil)
+	_ UnmarshalableJSON = (*LedgerStateResponse)(nil)
+	_ UnmarshalableJSON = (*RandomBeacon)(nil)
 	_ UnmarshalableJSON = (*Transaction)(nil)
 	_ UnmarshalableJSON = (*TransactionList)(nil)
 	_ UnmarshalableJSON = (*Account)(nil)
+	_ UnmarshalableJSON = (*AccountState)(nil)
+	_ UnmarshalableJSON = (*Accounts)(nil)
+	_ UnmarshalableJSON = (*AccountHistory)(nil)
+	_ UnmarshalableJSON = (*RoundChanges)(nil)
+	_ UnmarshalableJSON = (*Token)(nil)
+	_ UnmarshalableJSON = (*TokenBalances)(nil)
+
+	_ UnmarshalableJSON = (*UploadContractResponse)(nil)
+	_ UnmarshalableJSON = (*CallContractResponse)(nil)
+	_ UnmarshalableJSON = (*ContractEvents)(nil)
+	_ UnmarshalableJSON = (*TransactionReceipt)(nil)
 
 	_ MarshalableJSON = (*SendTransactionRequest)(nil)
+	_ MarshalableJSON = (*UploadContractRequest)(nil)
+	_ MarshalableJSON = (*CallContractRequest)(nil)
+	_ MarshalableJSON = (*AccountBatchRequest)(nil)
 )
 
 type UnmarshalableJSON interface {
@@ -65,6 +89,10 @@ type SendTransactionRequest struct {
 	Tag       byte   `json:"tag"`
 	Payload   string `json:"payload"`
 	Signature string `json:"signature"`
+
+	// ValidUntilView is the index of the last round the transaction may be
+	// applied in. The transaction never expires should it be 0.
+	ValidUntilView uint64 `json:"valid_until_view"`
 }
 
 func (s *SendTransactionRequest) MarshalJSON() ([]byte, error) {
@@ -76,6 +104,10 @@ func (s *SendTransactionRequest) MarshalJSON() ([]byte, error) {
 	o.Set("payload", arena.NewString(s.Payload))
 	o.Set("signature", arena.NewString(s.Signature))
 
+	if s.ValidUntilView != 0 {
+		o.Set("valid_until_view", arena.NewNumberString(strconv.FormatUint(s.ValidUntilView, 10)))
+	}
+
 	return o.MarshalTo(nil), nil
 }
 
@@ -105,6 +137,157 @@ func (s *SendTransactionResponse) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+type UploadContractRequest struct {
+	Contract string `json:"contract"`
+	GasLimit uint64 `json:"gas_limit"`
+	Params   string `json:"params"`
+}
+
+func (s *UploadContractRequest) MarshalJSON() ([]byte, error) {
+	var arena fastjson.Arena
+	o := arena.NewObject()
+
+	o.Set("contract", arena.NewString(s.Contract))
+	o.Set("gas_limit", arena.NewNumberString(strconv.FormatUint(s.GasLimit, 10)))
+	o.Set("params", arena.NewString(s.Params))
+
+	return o.MarshalTo(nil), nil
+}
+
+type UploadContractResponse struct {
+	SendTransactionResponse
+
+	ContractID string `json:"contract_id"`
+}
+
+func (s *UploadContractResponse) UnmarshalJSON(b []byte) error {
+	if err := s.SendTransactionResponse.UnmarshalJSON(b); err != nil {
+		return err
+	}
+
+	var parser fastjson.Parser
+
+	v, err := parser.ParseBytes(b)
+	if err != nil {
+		return err
+	}
+
+	s.ContractID = string(v.GetStringBytes("contract_id"))
+
+	return nil
+}
+
+type CallContractRequest struct {
+	Func     string `json:"func"`
+	Params   string `json:"params"`
+	Sender   string `json:"sender"`
+	Amount   uint64 `json:"amount"`
+	GasLimit uint64 `json:"gas_limit"`
+}
+
+func (s *CallContractRequest) MarshalJSON() ([]byte, error) {
+	var arena fastjson.Arena
+	o := arena.NewObject()
+
+	o.Set("func", arena.NewString(s.Func))
+	o.Set("params", arena.NewString(s.Params))
+	if s.Sender != "" {
+		o.Set("sender", arena.NewString(s.Sender))
+	}
+	o.Set("amount", arena.NewNumberString(strconv.FormatUint(s.Amount, 10)))
+	o.Set("gas_limit", arena.NewNumberString(strconv.FormatUint(s.GasLimit, 10)))
+
+	return o.MarshalTo(nil), nil
+}
+
+type CallContractTransaction struct {
+	Tag     byte   `json:"tag"`
+	Payload string `json:"payload"`
+}
+
+type CallContractResponse struct {
+	Result           string                    `json:"result"`
+	Gas              uint64                    `json:"gas"`
+	GasLimitExceeded bool                      `json:"gas_limit_exceeded"`
+	Logs             []string                  `json:"logs"`
+	Transactions     []CallContractTransaction `json:"transactions"`
+	Events           []ContractEvent           `json:"events"`
+}
+
+func (s *CallContractResponse) UnmarshalJSON(b []byte) error {
+	var parser fastjson.Parser
+
+	v, err := parser.ParseBytes(b)
+	if err != nil {
+		return err
+	}
+
+	s.Result = string(v.GetStringBytes("result"))
+	s.Gas = v.GetUint64("gas")
+	s.GasLimitExceeded = v.GetBool("gas_limit_exceeded")
+
+	for _, msg := range v.GetArray("logs") {
+		s.Logs = append(s.Logs, string(msg.GetStringBytes()))
+	}
+
+	for _, tx := range v.GetArray("transactions") {
+		s.Transactions = append(s.Transactions, CallContractTransaction{
+			Tag:     byte(tx.GetUint("tag")),
+			Payload: string(tx.GetStringBytes("payload")),
+		})
+	}
+
+	for _, event := range v.GetArray("events") {
+		var e ContractEvent
+		e.ParseJSON(event)
+
+		s.Events = append(s.Events, e)
+	}
+
+	return nil
+}
+
+type ContractEvent struct {
+	ContractID string `json:"contract_id"`
+	Index      uint64 `json:"index"`
+	TxID       string `json:"tx_id"`
+	Topic      string `json:"topic"`
+	Payload    string `json:"payload"`
+}
+
+func (e *ContractEvent) ParseJSON(v *fastjson.Value) {
+	e.ContractID = string(v.GetStringBytes("contract_id"))
+	e.Index = v.GetUint64("index")
+	e.TxID = string(v.GetStringBytes("tx_id"))
+	e.Topic = string(v.GetStringBytes("topic"))
+	e.Payload = string(v.GetStringBytes("payload"))
+}
+
+type ContractEvents []ContractEvent
+
+func (l *ContractEvents) UnmarshalJSON(b []byte) error {
+	var parser fastjson.Parser
+
+	v, err := parser.ParseBytes(b)
+	if err != nil {
+		return err
+	}
+
+	a, err := v.Array()
+	if err != nil {
+		return err
+	}
+
+	for _, event := range a {
+		var e ContractEvent
+		e.ParseJSON(event)
+
+		*l = append(*l, e)
+	}
+
+	return nil
+}
+
 type LedgerStatusResponse struct {
 	PublicKey     string   `json:"public_key"`
 	HostAddress   string   `json:"address"`
@@ -138,6 +321,165 @@ func (l *LedgerStatusResponse) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// LedgerStateResponse describes what changed in the ledger since the round
+// Since. Should nothing have changed, only Round, Since and Changed are set.
+type LedgerStateResponse struct {
+	Round   uint64 `json:"round"`
+	Since   uint64 `json:"since"`
+	Changed bool   `json:"changed"`
+
+	RootID     string `json:"root_id"`
+	MerkleRoot string `json:"merkle_root"`
+
+	// Rounds are the rounds finalized since the round Since. Truncated is
+	// set should the node no longer retain some of them.
+	Rounds    []LedgerStateRound `json:"rounds"`
+	Truncated bool               `json:"truncated"`
+
+	NumAccounts  uint64 `json:"num_accounts"`
+	NumTx        uint64 `json:"num_tx"`
+	NumMissingTx uint64 `json:"num_missing_tx"`
+}
+
+type LedgerStateRound struct {
+	Index   uint64 `json:"index"`
+	ID      string `json:"id"`
+	RootID  string `json:"root_id"`
+	Applied uint64 `json:"applied"`
+}
+
+func (l *LedgerStateResponse) UnmarshalJSON(b []byte) error {
+	var parser fastjson.Parser
+
+	v, err := parser.ParseBytes(b)
+	if err != nil {
+		return err
+	}
+
+	l.Round = v.GetUint64("round")
+	l.Since = v.GetUint64("since")
+	l.Changed = v.GetBool("changed")
+
+	l.RootID = string(v.GetStringBytes("root_id"))
+	l.MerkleRoot = string(v.GetStringBytes("merkle_root"))
+
+	for _, round := range v.GetArray("rounds") {
+		l.Rounds = append(l.Rounds, LedgerStateRound{
+			Index:   round.GetUint64("index"),
+			ID:      string(round.GetStringBytes("id")),
+			RootID:  string(round.GetStringBytes("root_id")),
+			Applied: round.GetUint64("applied"),
+		})
+	}
+
+	l.Truncated = v.GetBool("truncated")
+
+	l.NumAccounts = v.GetUint64("num_accounts")
+	l.NumTx = v.GetUint64("num_tx")
+	l.NumMissingTx = v.GetUint64("num_missing_tx")
+
+	return nil
+}
+
+// RandomBeacon is the random beacon of the latest round of the ledger, which
+// smart contracts executed in the round after it see.
+type RandomBeacon struct {
+	Round  uint64 `json:"round"`
+	Beacon string `json:"beacon"`
+}
+
+func (r *RandomBeacon) UnmarshalJSON(b []byte) error {
+	var parser fastjson.Parser
+
+	v, err := parser.ParseBytes(b)
+	if err != nil {
+		return err
+	}
+
+	r.Round = v.GetUint64("round")
+	r.Beacon = string(v.GetStringBytes("beacon"))
+
+	return nil
+}
+
+type TransactionStatus struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	Round  uint64 `json:"round"`
+}
+
+func (s *TransactionStatus) UnmarshalJSON(b []byte) error {
+	var parser fastjson.Parser
+
+	v, err := parser.ParseBytes(b)
+	if err != nil {
+		return err
+	}
+
+	s.ID = string(v.GetStringBytes("id"))
+	s.Status = string(v.GetStringBytes("status"))
+	s.Round = v.GetUint64("round")
+
+	return nil
+}
+
+type TransactionReceipt struct {
+	ID    string `json:"id"`
+	Round uint64 `json:"round"`
+
+	Applied bool   `json:"applied"`
+	Error   string `json:"error"`
+
+	GasUsed          uint64 `json:"gas_used"`
+	GasLimitExceeded bool   `json:"gas_limit_exceeded"`
+	Reverted         bool   `json:"reverted"`
+	Result           string `json:"result"`
+
+	Events       []ContractEvent      `json:"events"`
+	Transactions []ReceiptTransaction `json:"transactions"`
+}
+
+type ReceiptTransaction struct {
+	Creator string `json:"creator"`
+	Tag     byte   `json:"tag"`
+	Payload string `json:"payload"`
+}
+
+func (r *TransactionReceipt) UnmarshalJSON(b []byte) error {
+	var parser fastjson.Parser
+
+	v, err := parser.ParseBytes(b)
+	if err != nil {
+		return err
+	}
+
+	r.ID = string(v.GetStringBytes("id"))
+	r.Round = v.GetUint64("round")
+	r.Applied = v.GetBool("applied")
+	r.Error = string(v.GetStringBytes("error"))
+	r.GasUsed = v.GetUint64("gas_used")
+	r.GasLimitExceeded = v.GetBool("gas_limit_exceeded")
+	r.Reverted = v.GetBool("reverted")
+	r.Result = string(v.GetStringBytes("result"))
+
+	for _, event := range v.GetArray("events") {
+		var e ContractEvent
+		e.ParseJSON(event)
+
+		r.Events = append(r.Events, e)
+	}
+
+	for _, tx := range v.GetArray("transactions") {
+		r.Transactions = append(r.Transactions, ReceiptTransaction{
+			Creator: string(tx.GetStringBytes("creator")),
+			Tag:     byte(tx.GetUint("tag")),
+			Payload: string(tx.GetStringBytes("payload")),
+		})
+	}
+
+	return nil
+}
+
 type Transaction struct {
 	ID string `json:"id"`
 
@@ -157,6 +499,12 @@ type Transaction struct {
 	CreatorSignature string `json:"creator_signature"`
 
 	Depth uint64 `json:"depth"`
+
+	ValidUntilView uint64 `json:"valid_until_view"`
+
+	// Memo is the hex-encoded memo attached to a transfer, which is encrypted
+	// to its recipient. See Client.DecryptMemo.
+	Memo string `json:"memo"`
 }
 
 func (t *Transaction) UnmarshalJSON(b []byte) error {
@@ -189,6 +537,8 @@ func (t *Transaction) ParseJSON(v *fastjson.Value) {
 	t.SenderSignature = string(v.GetStringBytes("sender_signature"))
 	t.CreatorSignature = string(v.GetStringBytes("creator_signature"))
 	t.Depth = v.GetUint64("depth")
+	t.ValidUntilView = v.GetUint64("valid_until_view")
+	t.Memo = string(v.GetStringBytes("memo"))
 }
 
 type TransactionList []Transaction
@@ -246,3 +596,257 @@ func (a *Account) UnmarshalJSON(b []byte) error {
 
 	return nil
 }
+
+// AccountState is the balance and stake of an account as of a past round.
+type AccountState struct {
+	PublicKey string `json:"public_key"`
+	Round     uint64 `json:"round"`
+	Balance   uint64 `json:"balance"`
+	Stake     uint64 `json:"stake"`
+}
+
+func (a *AccountState) UnmarshalJSON(b []byte) error {
+	var parser fastjson.Parser
+
+	v, err := parser.ParseBytes(b)
+	if err != nil {
+		return err
+	}
+
+	a.PublicKey = string(v.GetStringBytes("public_key"))
+	a.Round = v.GetUint64("round")
+	a.Balance = v.GetUint64("balance")
+	a.Stake = v.GetUint64("stake")
+
+	return nil
+}
+
+// AccountProof proves the state of an account against the Merkle root of a
+// round. Each proof lists the hex-encoded nodes of the accounts tree on the
+// path to the value it proves.
+type AccountProof struct {
+	PublicKey  string `json:"id"`
+	Round      uint64 `json:"round"`
+	RoundID    string `json:"round_id"`
+	MerkleRoot string `json:"merkle_root"`
+
+	Proofs AccountProofs `json:"proofs"`
+}
+
+type AccountProofs struct {
+	Nonce   []string `json:"nonce"`
+	Balance []string `json:"balance"`
+	Stake   []string `json:"stake"`
+	Reward  []string `json:"reward"`
+}
+
+func (a *AccountProof) UnmarshalJSON(b []byte) error {
+	var parser fastjson.Parser
+
+	v, err := parser.ParseBytes(b)
+	if err != nil {
+		return err
+	}
+
+	a.PublicKey = string(v.GetStringBytes("id"))
+	a.Round = v.GetUint64("round")
+	a.RoundID = string(v.GetStringBytes("round_id"))
+	a.MerkleRoot = string(v.GetStringBytes("merkle_root"))
+
+	nodes := func(field string) []string {
+		var list []string
+
+		for _, node := range v.GetArray("proofs", field) {
+			list = append(list, string(node.GetStringBytes()))
+		}
+
+		return list
+	}
+
+	a.Proofs.Nonce = nodes("nonce")
+	a.Proofs.Balance = nodes("balance")
+	a.Proofs.Stake = nodes("stake")
+	a.Proofs.Reward = nodes("reward")
+
+	return nil
+}
+
+type Accounts []Account
+
+func (a *Accounts) UnmarshalJSON(b []byte) error {
+	var parser fastjson.Parser
+
+	v, err := parser.ParseBytes(b)
+	if err != nil {
+		return err
+	}
+
+	list, err := v.Array()
+	if err != nil {
+		return err
+	}
+
+	for _, account := range list {
+		*a = append(*a, Account{
+			PublicKey:  string(account.GetStringBytes("public_key")),
+			Balance:    account.GetUint64("balance"),
+			Stake:      account.GetUint64("stake"),
+			IsContract: account.GetBool("is_contract"),
+			NumPages:   account.GetUint64("num_mem_pages"),
+		})
+	}
+
+	return nil
+}
+
+type AccountBatchRequest struct {
+	PublicKeys []string `json:"public_keys"`
+}
+
+func (s *AccountBatchRequest) MarshalJSON() ([]byte, error) {
+	var arena fastjson.Arena
+	o := arena.NewObject()
+
+	keys := arena.NewArray()
+	for i, key := range s.PublicKeys {
+		keys.SetArrayItem(i, arena.NewString(key))
+	}
+	o.Set("public_keys", keys)
+
+	return o.MarshalTo(nil), nil
+}
+
+// AccountDelta is the change made to the balance and stake of an account in
+// a single round.
+type AccountDelta struct {
+	Round uint64 `json:"round"`
+
+	Balance     uint64 `json:"balance"`
+	PrevBalance uint64 `json:"prev_balance"`
+
+	Stake     uint64 `json:"stake"`
+	PrevStake uint64 `json:"prev_stake"`
+}
+
+type AccountHistory []AccountDelta
+
+// Token is a fungible token issued on the ledger.
+type Token struct {
+	ID       string `json:"id"`
+	Creator  string `json:"creator"`
+	Supply   uint64 `json:"supply"`
+	Decimals uint8  `json:"decimals"`
+	Symbol   string `json:"symbol"`
+}
+
+func (t *Token) UnmarshalJSON(b []byte) error {
+	var parser fastjson.Parser
+
+	v, err := parser.ParseBytes(b)
+	if err != nil {
+		return err
+	}
+
+	t.ID = string(v.GetStringBytes("id"))
+	t.Creator = string(v.GetStringBytes("creator"))
+	t.Supply = v.GetUint64("supply")
+	t.Decimals = uint8(v.GetUint("decimals"))
+	t.Symbol = string(v.GetStringBytes("symbol"))
+
+	return nil
+}
+
+// TokenBalance is the balance an account holds of a single token.
+type TokenBalance struct {
+	TokenID string `json:"token_id"`
+	Balance uint64 `json:"balance"`
+}
+
+type TokenBalances []TokenBalance
+
+func (t *TokenBalances) UnmarshalJSON(b []byte) error {
+	var parser fastjson.Parser
+
+	v, err := parser.ParseBytes(b)
+	if err != nil {
+		return err
+	}
+
+	a, err := v.Array()
+	if err != nil {
+		return err
+	}
+
+	for _, balance := range a {
+		*t = append(*t, TokenBalance{
+			TokenID: string(balance.GetStringBytes("token_id")),
+			Balance: balance.GetUint64("balance"),
+		})
+	}
+
+	return nil
+}
+
+// AccountChange is the change made to the balance and stake of an account in
+// a single round, as indexed by round by archive nodes.
+type AccountChange struct {
+	PublicKey string `json:"public_key"`
+	AccountDelta
+}
+
+type RoundChanges []AccountChange
+
+func (r *RoundChanges) UnmarshalJSON(b []byte) error {
+	var parser fastjson.Parser
+
+	v, err := parser.ParseBytes(b)
+	if err != nil {
+		return err
+	}
+
+	a, err := v.Array()
+	if err != nil {
+		return err
+	}
+
+	for _, change := range a {
+		*r = append(*r, AccountChange{
+			PublicKey: string(change.GetStringBytes("public_key")),
+			AccountDelta: AccountDelta{
+				Round:       change.GetUint64("round"),
+				Balance:     change.GetUint64("balance"),
+				PrevBalance: change.GetUint64("prev_balance"),
+				Stake:       change.GetUint64("stake"),
+				PrevStake:   change.GetUint64("prev_stake"),
+			},
+		})
+	}
+
+	return nil
+}
+
+func (h *AccountHistory) UnmarshalJSON(b []byte) error {
+	var parser fastjson.Parser
+
+	v, err := parser.ParseBytes(b)
+	if err != nil {
+		return err
+	}
+
+	a, err := v.Array()
+	if err != nil {
+		return err
+	}
+
+	for _, delta := range a {
+		*h = append(*h, AccountDelta{
+			Round:       delta.GetUint64("round"),
+			Balance:     delta.GetUint64("balance"),
+			PrevBalance: delta.GetUint64("prev_balance"),
+			Stake:       delta.GetUint64("stake"),
+			PrevStake:   delta.GetUint64("prev_stake"),
+		})
+	}
+
+	return nil
+}